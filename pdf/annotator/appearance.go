@@ -0,0 +1,120 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// Makes the appearance dictionary with a normal appearance (N) form drawn with `content` in the global page
+// coordinate system.  The form bounding box is the annotation rectangle `bbox`, so no matrix is needed.
+func makeAppearanceDict(content []byte, bbox *pdf.PdfRectangle, resources *pdf.PdfPageResources) (*pdfcore.PdfObjectDictionary, error) {
+	form := pdf.NewXObjectForm()
+	form.Resources = resources
+	if form.Resources == nil {
+		form.Resources = pdf.NewPdfPageResources()
+	}
+
+	err := form.SetContentStream(content, nil)
+	if err != nil {
+		return nil, err
+	}
+	form.BBox = bbox.ToPdfObject()
+
+	apDict := pdfcore.MakeDict()
+	apDict.Set("N", form.ToPdfObject())
+	return apDict, nil
+}
+
+// Adds a graphics state named gs1 with stroke and fill opacity and an optional blend mode to the resources.
+// Returns the name of the graphics state or an empty string if none was needed.
+func addAppearanceExtGState(resources *pdf.PdfPageResources, opacity float64, blendMode string) (string, error) {
+	if opacity >= 1.0 && blendMode == "" {
+		return "", nil
+	}
+
+	gsState := pdf.NewPdfExtGState()
+	if opacity < 1.0 {
+		gsState.SetOpacity(opacity)
+	}
+	gsState.BM = blendMode
+	err := resources.SetExtGStateByName("gs1", gsState)
+	if err != nil {
+		common.Log.Debug("Unable to add extgstate gs1")
+		return "", err
+	}
+	return "gs1", nil
+}
+
+// Gets the bounding rectangle of a set of points expanded by `margin` on each side.
+func getPointsBbox(points []draw.Point, margin float64) *pdf.PdfRectangle {
+	bbox := &pdf.PdfRectangle{}
+	for idx, p := range points {
+		if idx == 0 {
+			bbox.Llx, bbox.Urx = p.X, p.X
+			bbox.Lly, bbox.Ury = p.Y, p.Y
+			continue
+		}
+		bbox.Llx = math.Min(bbox.Llx, p.X)
+		bbox.Lly = math.Min(bbox.Lly, p.Y)
+		bbox.Urx = math.Max(bbox.Urx, p.X)
+		bbox.Ury = math.Max(bbox.Ury, p.Y)
+	}
+
+	bbox.Llx -= margin
+	bbox.Lly -= margin
+	bbox.Urx += margin
+	bbox.Ury += margin
+	return bbox
+}
+
+// Gets the PDF name of a line ending style for the LE entry of line, polyline and free text annotations.
+func getLineEndingName(style draw.LineEndingStyle) pdfcore.PdfObjectName {
+	switch style {
+	case draw.LineEndingStyleArrow:
+		return "ClosedArrow"
+	case draw.LineEndingStyleButt:
+		return "Butt"
+	}
+	return "None"
+}
+
+// Draws the line ending `style` at point `tip` of a line segment coming from point `from` with the creator.  The
+// arrow is filled and the butt stroked, so the fill and stroke colors should both be set to the line color.
+// Returns the point where the line segment should end so that it does not poke out of the arrow tip.
+func drawLineEnding(creator *pdfcontent.ContentCreator, tip, from draw.Point, style draw.LineEndingStyle, lineWidth float64) draw.Point {
+	theta := draw.NewVectorBetween(from, tip).GetPolarAngle()
+	size := 3 * lineWidth
+
+	switch style {
+	case draw.LineEndingStyleArrow:
+		base := tip.AddVector(draw.NewVectorPolar(size, theta+math.Pi))
+		side := draw.NewVectorPolar(size/2, theta+math.Pi/2)
+
+		path := draw.NewPath()
+		path = path.AppendPoint(tip)
+		path = path.AppendPoint(base.AddVector(side))
+		path = path.AppendPoint(base.AddVector(side.Flip()))
+		draw.DrawPathWithCreator(path, creator)
+		creator.Add_h().Add_f()
+		return base
+	case draw.LineEndingStyleButt:
+		side := draw.NewVectorPolar(size/2, theta+math.Pi/2)
+
+		path := draw.NewPath()
+		path = path.AppendPoint(tip.AddVector(side))
+		path = path.AppendPoint(tip.AddVector(side.Flip()))
+		draw.DrawPathWithCreator(path, creator)
+		creator.Add_S()
+	}
+	return tip
+}
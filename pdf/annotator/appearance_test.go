@@ -0,0 +1,308 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+	"github.com/unidoc/unidoc/pdf/model/textencoding"
+)
+
+// getAppearanceOps returns the operations of the normal appearance of `annotation`.
+func getAppearanceOps(t *testing.T, annotation *pdf.PdfAnnotation) pdfcontent.ContentStreamOperations {
+	apDict, ok := pdfcore.TraceToDirectObject(annotation.AP).(*pdfcore.PdfObjectDictionary)
+	if !ok {
+		t.Fatalf("Missing appearance dictionary")
+	}
+	stream, ok := pdfcore.TraceToDirectObject(apDict.Get("N")).(*pdfcore.PdfObjectStream)
+	if !ok {
+		t.Fatalf("Missing normal appearance")
+	}
+	data, err := pdfcore.DecodeStream(stream)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	ops, err := pdfcontent.NewContentStreamParser(string(data)).Parse()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return *ops
+}
+
+// getFloats returns the numeric values of `objs`.
+func getFloats(t *testing.T, objs ...pdfcore.PdfObject) []float64 {
+	vals, err := pdfcore.MakeArray(objs...).ToFloat64Array()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return vals
+}
+
+// checkFloats checks that `vals` are `expected`, within rounding errors.
+func checkFloats(t *testing.T, what string, vals, expected []float64) {
+	if len(vals) != len(expected) {
+		t.Errorf("%s: %v, expected %v", what, vals, expected)
+		return
+	}
+	for i := range vals {
+		if math.Abs(vals[i]-expected[i]) > 1e-6 {
+			t.Errorf("%s: %v, expected %v", what, vals, expected)
+			return
+		}
+	}
+}
+
+// getPathPoints returns the points of the m and l operations of `ops`, and the number of operations with
+// each operand.
+func getPathPoints(t *testing.T, ops pdfcontent.ContentStreamOperations) ([]float64, map[string]int) {
+	points := []float64{}
+	counts := map[string]int{}
+	for _, op := range ops {
+		counts[op.Operand]++
+		if op.Operand == "m" || op.Operand == "l" {
+			points = append(points, getFloats(t, op.Params...)...)
+		}
+	}
+	return points, counts
+}
+
+func TestTextMarkupQuadPoints(t *testing.T) {
+	black := pdf.NewPdfColorDeviceRGB(0, 0, 0)
+	for _, quadPoints := range [][]float64{nil, {1, 2, 3, 4, 5, 6, 7}, {1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}} {
+		_, err := CreateTextMarkupAnnotation(TextMarkupAnnotationDef{QuadPoints: quadPoints, Color: black})
+		if err == nil {
+			t.Errorf("%d QuadPoints should fail", len(quadPoints))
+		}
+	}
+
+	// Two lines of text.
+	annotation, err := CreateTextMarkupAnnotation(TextMarkupAnnotationDef{
+		MarkupType: TextMarkupHighlight,
+		QuadPoints: []float64{10, 30, 110, 30, 10, 16, 110, 16, 10, 14, 60, 14, 10, 0, 60, 0},
+		Color:      pdf.NewPdfColorDeviceRGB(1, 1, 0),
+		Opacity:    1,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, ok := annotation.GetContext().(*pdf.PdfAnnotationHighlight); !ok {
+		t.Errorf("Invalid annotation type %T", annotation.GetContext())
+	}
+	checkFloats(t, "Rect", getFloats(t, *annotation.Rect.(*pdfcore.PdfObjectArray)...), []float64{9, -1, 111, 31})
+	_, counts := getPathPoints(t, getAppearanceOps(t, annotation))
+	if counts["f"] != 2 {
+		t.Errorf("Invalid number of filled quadrilaterals: %d", counts["f"])
+	}
+}
+
+func TestTextMarkupGeometry(t *testing.T) {
+	// Text of height 14: the lines are 1 point wide.
+	quadPoints := []float64{10, 30, 110, 30, 10, 16, 110, 16}
+	red := pdf.NewPdfColorDeviceRGB(1, 0, 0)
+
+	annotation, err := CreateTextMarkupAnnotation(TextMarkupAnnotationDef{
+		MarkupType: TextMarkupUnderline,
+		QuadPoints: quadPoints,
+		Color:      red,
+		Opacity:    1,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	ops := getAppearanceOps(t, annotation)
+	points, counts := getPathPoints(t, ops)
+	checkFloats(t, "Underline", points, []float64{10, 17, 110, 17})
+	if counts["S"] != 1 || counts["gs"] != 0 {
+		t.Errorf("Invalid underline operations: %v", counts)
+	}
+	for _, op := range ops {
+		if op.Operand == "w" {
+			checkFloats(t, "Line width", getFloats(t, op.Params...), []float64{1})
+		}
+	}
+
+	// The zigzag has a period of half the text height and an amplitude of an eighth of it, and ends at the
+	// end of the text.
+	annotation, err = CreateTextMarkupAnnotation(TextMarkupAnnotationDef{
+		MarkupType: TextMarkupSquiggly,
+		QuadPoints: quadPoints,
+		Color:      red,
+		Opacity:    0.5,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	points, counts = getPathPoints(t, getAppearanceOps(t, annotation))
+	if counts["m"] != 1 || counts["l"] != 29 || counts["gs"] != 1 {
+		t.Fatalf("Invalid squiggly operations: %v", counts)
+	}
+	checkFloats(t, "Squiggly start", points[:4], []float64{10, 17, 13.5, 18.75})
+	checkFloats(t, "Squiggly end", points[len(points)-4:], []float64{108, 17, 110, 18.75})
+	for i := 0; i < len(points); i += 2 {
+		if points[i+1] != 17 && points[i+1] != 18.75 {
+			t.Fatalf("Squiggly point (%v, %v) out of line", points[i], points[i+1])
+		}
+	}
+}
+
+func TestFreeTextWrap(t *testing.T) {
+	font := fonts.NewFontHelvetica()
+	encoder := textencoding.NewWinAnsiTextEncoder()
+	width := getFreeTextWidth("Hello world", font, encoder, 10) + 1
+
+	checkLines := func(lines, expected []string) {
+		if len(lines) != len(expected) {
+			t.Errorf("Invalid lines: %q, expected %q", lines, expected)
+			return
+		}
+		for i := range lines {
+			if lines[i] != expected[i] {
+				t.Errorf("Invalid lines: %q, expected %q", lines, expected)
+				return
+			}
+		}
+	}
+	checkLines(wrapFreeText("Hello world foo\nbar", font, encoder, 10, width), []string{"Hello world", "foo", "bar"})
+	checkLines(wrapFreeText("a\n\nb", font, encoder, 10, width), []string{"a", "", "b"})
+	// Words wider than the box are placed on lines of their own.
+	checkLines(wrapFreeText("a verylongword b", font, encoder, 10, 5), []string{"a", "verylongword", "b"})
+}
+
+func TestFreeTextAlignment(t *testing.T) {
+	font := fonts.NewFontHelvetica()
+	textWidth := getFreeTextWidth("Hi", font, textencoding.NewWinAnsiTextEncoder(), 12)
+
+	// Box with a padding of 2 and an inner width of 96.
+	expected := map[FreeTextAlignment]float64{
+		FreeTextAlignmentLeft:   2,
+		FreeTextAlignmentCenter: 2 + (96-textWidth)/2,
+		FreeTextAlignmentRight:  2 + 96 - textWidth,
+	}
+	for alignment, x := range expected {
+		annotation, err := CreateFreeTextAnnotation(FreeTextAnnotationDef{
+			Width:     100,
+			Height:    50,
+			Text:      "Hi",
+			Alignment: alignment,
+			Opacity:   1,
+		})
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		freeText := annotation.GetContext().(*pdf.PdfAnnotationFreeText)
+		if q, ok := freeText.Q.(*pdfcore.PdfObjectInteger); !ok || int(*q) != int(alignment) {
+			t.Errorf("Invalid quadding: %v", freeText.Q)
+		}
+		checkFloats(t, "Rect", getFloats(t, *annotation.Rect.(*pdfcore.PdfObjectArray)...), []float64{0, 0, 100, 50})
+
+		found := false
+		for _, op := range getAppearanceOps(t, annotation) {
+			if op.Operand == "Td" {
+				checkFloats(t, "Text position", getFloats(t, op.Params...), []float64{x, 50 - 2 - 12})
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Text not positioned")
+		}
+	}
+}
+
+func TestFreeTextFont(t *testing.T) {
+	font, err := pdf.NewPdfFontFromTTFFile("../../testfiles/roboto/Roboto-Regular.ttf")
+	if err != nil {
+		t.Skipf("Font not available: %v", err)
+	}
+	fontObj := font.ToPdfObject()
+	fontDict := pdfcore.TraceToDirectObject(fontObj).(*pdfcore.PdfObjectDictionary)
+	encoding := fontDict.Get("Encoding")
+
+	acroForm := pdf.NewPdfAcroForm()
+	acroForm.DR = pdf.NewPdfPageResources()
+	acroForm.DR.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+
+	annotation, err := CreateFreeTextAnnotation(FreeTextAnnotationDef{
+		Width:    100,
+		Height:   50,
+		Text:     "Café",
+		Font:     *font,
+		AcroForm: acroForm,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	freeText := annotation.GetContext().(*pdf.PdfAnnotationFreeText)
+	if contents := pdf.DecodeTextString(freeText.Contents.String()); contents != "Café" {
+		t.Errorf("Invalid contents: %q", contents)
+	}
+
+	// The font is added under an unused name of the default resources, which the default appearance refers to.
+	if da := freeText.DA.String(); !strings.HasPrefix(da, "/F2 12") {
+		t.Errorf("Invalid default appearance: %q", da)
+	}
+	drFont, found := acroForm.DR.GetFontByName("F2")
+	if !found {
+		t.Fatalf("Font missing from the default resources")
+	}
+
+	// The font dictionary of the font is not changed or shared.
+	if drFont == fontObj || fontDict.Get("Encoding") != encoding {
+		t.Errorf("Font dictionary changed")
+	}
+}
+
+func TestPolyLineLineEndings(t *testing.T) {
+	vertices := draw.NewPath()
+	vertices = vertices.AppendPoint(draw.NewPoint(0, 0))
+	_, err := CreatePolyLineAnnotation(PolyLineAnnotationDef{Vertices: vertices})
+	if err == nil {
+		t.Errorf("Polyline with a single vertex should fail")
+	}
+
+	vertices = vertices.AppendPoint(draw.NewPoint(50, 0))
+	vertices = vertices.AppendPoint(draw.NewPoint(50, 50))
+	annotation, err := CreatePolyLineAnnotation(PolyLineAnnotationDef{
+		Vertices:         vertices,
+		LineColor:        pdf.NewPdfColorDeviceRGB(0, 0, 1),
+		LineWidth:        2,
+		Opacity:          1,
+		LineEndingStyle1: draw.LineEndingStyleArrow,
+		LineEndingStyle2: draw.LineEndingStyleButt,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	polyline := annotation.GetContext().(*pdf.PdfAnnotationPolyLine)
+	le, ok := polyline.LE.(*pdfcore.PdfObjectArray)
+	if !ok || len(*le) != 2 || (*le)[0].String() != "ClosedArrow" || (*le)[1].String() != "Butt" {
+		t.Errorf("Invalid line endings: %v", polyline.LE)
+	}
+	checkFloats(t, "Vertices", getFloats(t, *polyline.Vertices.(*pdfcore.PdfObjectArray)...),
+		[]float64{0, 0, 50, 0, 50, 50})
+	// The endings extend twice the line width beyond the vertices.
+	checkFloats(t, "Rect", getFloats(t, *annotation.Rect.(*pdfcore.PdfObjectArray)...), []float64{-4, -4, 54, 54})
+
+	// The filled arrow of size 3 times the line width at the first vertex, the butt across the last vertex,
+	// and the line starting at the base of the arrow.
+	points, counts := getPathPoints(t, getAppearanceOps(t, annotation))
+	checkFloats(t, "Polyline", points, []float64{
+		0, 0, 6, -3, 6, 3,
+		47, 50, 53, 50,
+		6, 0, 50, 0, 50, 50,
+	})
+	if counts["f"] != 1 || counts["S"] != 2 {
+		t.Errorf("Invalid painting operations: %v", counts)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// Defines a caret marking an insertion point, drawn within a Width x Height box with a lower left corner at
+// (X,Y).  The tip of the caret is at the top center of the box.
+type CaretAnnotationDef struct {
+	X       float64
+	Y       float64
+	Width   float64
+	Height  float64
+	Color   *pdf.PdfColorDeviceRGB
+	Opacity float64 // Alpha value (0-1).
+}
+
+// Creates a caret annotation object that can be added to page PDF annotations.
+func CreateCaretAnnotation(caretDef CaretAnnotationDef) (*pdf.PdfAnnotation, error) {
+	caretAnnotation := pdf.NewPdfAnnotationCaret()
+
+	r, g, b := caretDef.Color.R(), caretDef.Color.G(), caretDef.Color.B()
+	caretAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	caretAnnotation.Sy = pdfcore.MakeName("None")
+
+	// Opacity.
+	if caretDef.Opacity < 1.0 {
+		caretAnnotation.CA = pdfcore.MakeFloat(caretDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addAppearanceExtGState(resources, caretDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	x, y, w, h := caretDef.X, caretDef.Y, caretDef.Width, caretDef.Height
+	creator := pdfcontent.NewContentCreator()
+	creator.Add_q().Add_rg(r, g, b)
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+	// Two curved strokes meeting at the tip.
+	creator.
+		Add_m(x, y).
+		Add_c(x+w*0.35, y+h*0.15, x+w*0.5, y+h*0.6, x+w*0.5, y+h).
+		Add_c(x+w*0.5, y+h*0.6, x+w*0.65, y+h*0.15, x+w, y).
+		Add_h().
+		Add_f().
+		Add_Q()
+
+	bbox := &pdf.PdfRectangle{Llx: x, Lly: y, Urx: x + w, Ury: y + h}
+	apDict, err := makeAppearanceDict(creator.Bytes(), bbox, resources)
+	if err != nil {
+		return nil, err
+	}
+	caretAnnotation.AP = apDict
+	caretAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return caretAnnotation.PdfAnnotation, nil
+}
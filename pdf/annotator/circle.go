@@ -69,10 +69,9 @@ func makeCircleAnnotationAppearanceStream(circDef CircleAnnotationDef) (*pdfcore
 	gsName := ""
 	if circDef.Opacity < 1.0 {
 		// Create graphics state with right opacity.
-		gsState := pdfcore.MakeDict()
-		gsState.Set("ca", pdfcore.MakeFloat(circDef.Opacity))
-		gsState.Set("CA", pdfcore.MakeFloat(circDef.Opacity))
-		err := form.Resources.AddExtGState("gs1", gsState)
+		gsState := pdf.NewPdfExtGState()
+		gsState.SetOpacity(circDef.Opacity)
+		err := form.Resources.SetExtGStateByName("gs1", gsState)
 		if err != nil {
 			common.Log.Debug("Unable to add extgstate gs1")
 			return nil, nil, err
@@ -103,8 +102,8 @@ func makeCircleAnnotationAppearanceStream(circDef CircleAnnotationDef) (*pdfcore
 func drawPdfCircle(circDef CircleAnnotationDef, gsName string) ([]byte, *pdf.PdfRectangle, *pdf.PdfRectangle, error) {
 	// The annotation is drawn locally in a relative coordinate system with 0,0 as the origin rather than an offset.
 	circle := draw.Circle{
-		X:             circDef.X,
-		Y:             circDef.Y,
+		X:             0,
+		Y:             0,
 		Width:         circDef.Width,
 		Height:        circDef.Height,
 		FillEnabled:   circDef.FillEnabled,
@@ -0,0 +1,271 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+	"github.com/unidoc/unidoc/pdf/model/textencoding"
+)
+
+// Horizontal alignment of free text, the values match the quadding (Q) entry.
+type FreeTextAlignment int
+
+const (
+	FreeTextAlignmentLeft   FreeTextAlignment = 0
+	FreeTextAlignmentCenter FreeTextAlignment = 1
+	FreeTextAlignmentRight  FreeTextAlignment = 2
+)
+
+// Defines a free text annotation showing Text in a Width x Height box with a lower left corner at (X,Y).  The text
+// is wrapped to the width of the box, explicit line breaks are kept.  The box can optionally have a border and
+// a filling color.
+// The Font defaults to Helvetica, the FontSize to 12 and the TextColor to black.  The text is encoded with
+// WinAnsiEncoding, the font itself is not changed.
+// If AcroForm is set, the font is added to its default resources (DR) under the name used by the default
+// appearance (DA), so that viewers regenerating the appearance can resolve it.
+type FreeTextAnnotationDef struct {
+	X             float64
+	Y             float64
+	Width         float64
+	Height        float64
+	Text          string
+	Font          fonts.Font
+	FontSize      float64
+	TextColor     *pdf.PdfColorDeviceRGB
+	Alignment     FreeTextAlignment
+	FillEnabled   bool // Show fill?
+	FillColor     *pdf.PdfColorDeviceRGB
+	BorderEnabled bool // Show border?
+	BorderWidth   float64
+	BorderColor   *pdf.PdfColorDeviceRGB
+	Opacity       float64 // Alpha value (0-1).
+	AcroForm      *pdf.PdfAcroForm
+}
+
+// Creates a free text annotation object that can be added to page PDF annotations.
+func CreateFreeTextAnnotation(textDef FreeTextAnnotationDef) (*pdf.PdfAnnotation, error) {
+	// Defaults.
+	fontName := pdfcore.PdfObjectName("Helv")
+	if textDef.Font == nil {
+		textDef.Font = fonts.NewFontHelvetica()
+	} else {
+		fontName = "F1"
+	}
+	if textDef.FontSize <= 0 {
+		textDef.FontSize = 12
+	}
+	if textDef.TextColor == nil {
+		textDef.TextColor = pdf.NewPdfColorDeviceRGB(0, 0, 0)
+	}
+
+	encoder := textencoding.NewWinAnsiTextEncoder()
+	fontObj := makeFreeTextFont(textDef.Font, encoder)
+	if textDef.AcroForm != nil {
+		var err error
+		fontName, err = addFreeTextFontToAcroForm(textDef.AcroForm, fontName, fontObj)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	textAnnotation := pdf.NewPdfAnnotationFreeText()
+	textAnnotation.Contents = pdfcore.MakeString(pdf.EncodeTextString(textDef.Text))
+
+	// The default appearance is used by viewers that regenerate the appearance when the text is edited.
+	da := pdfcontent.NewContentCreator()
+	da.Add_Tf(fontName, textDef.FontSize).
+		Add_rg(textDef.TextColor.R(), textDef.TextColor.G(), textDef.TextColor.B())
+	textAnnotation.DA = pdfcore.MakeString(strings.TrimSpace(strings.Replace(da.String(), "\n", " ", -1)))
+	textAnnotation.Q = pdfcore.MakeInteger(int64(textDef.Alignment))
+
+	if textDef.BorderEnabled {
+		r, g, b := textDef.BorderColor.R(), textDef.BorderColor.G(), textDef.BorderColor.B()
+		textAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	}
+	bs := pdf.NewBorderStyle()
+	if textDef.BorderEnabled {
+		bs.SetBorderWidth(textDef.BorderWidth)
+	} else {
+		bs.SetBorderWidth(0)
+	}
+	textAnnotation.BS = bs.ToPdfObject()
+
+	// Opacity.
+	if textDef.Opacity < 1.0 {
+		textAnnotation.CA = pdfcore.MakeFloat(textDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	apDict, err := makeFreeTextAnnotationAppearanceStream(textDef, fontName, fontObj, encoder)
+	if err != nil {
+		return nil, err
+	}
+	textAnnotation.AP = apDict
+	textAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{textDef.X, textDef.Y, textDef.X + textDef.Width,
+		textDef.Y + textDef.Height})
+
+	return textAnnotation.PdfAnnotation, nil
+}
+
+// Makes the font dictionary of the free text: a copy of the dictionary of the font with the encoding of
+// `encoder`, so that the font and its dictionary, which may be shared, are not changed.
+func makeFreeTextFont(font fonts.Font, encoder textencoding.TextEncoder) pdfcore.PdfObject {
+	dict, isDict := pdfcore.TraceToDirectObject(font.ToPdfObject()).(*pdfcore.PdfObjectDictionary)
+	if !isDict {
+		common.Log.Debug("Invalid font dictionary, using Helvetica")
+		dict = pdfcore.TraceToDirectObject(fonts.NewFontHelvetica().ToPdfObject()).(*pdfcore.PdfObjectDictionary)
+	}
+	fontDict := pdfcore.MakeDict()
+	for _, key := range dict.Keys() {
+		fontDict.Set(key, dict.Get(key))
+	}
+	fontDict.Set("Encoding", encoder.ToPdfObject())
+	return pdfcore.MakeIndirectObject(fontDict)
+}
+
+// Adds the font of a free text to the default resources of the AcroForm and returns its name.  The standard
+// name Helv is kept if already in use, other fonts get the first unused name F1, F2, ...
+func addFreeTextFontToAcroForm(acroForm *pdf.PdfAcroForm, fontName pdfcore.PdfObjectName,
+	fontObj pdfcore.PdfObject) (pdfcore.PdfObjectName, error) {
+	if acroForm.DR == nil {
+		acroForm.DR = pdf.NewPdfPageResources()
+	}
+	if fontName == "Helv" {
+		if acroForm.DR.HasFontByName(fontName) {
+			return fontName, nil
+		}
+	} else {
+		for i := 2; acroForm.DR.HasFontByName(fontName); i++ {
+			fontName = pdfcore.PdfObjectName(fmt.Sprintf("F%d", i))
+		}
+	}
+	if err := acroForm.DR.SetFontByName(fontName, fontObj); err != nil {
+		return "", err
+	}
+	return fontName, nil
+}
+
+func makeFreeTextAnnotationAppearanceStream(textDef FreeTextAnnotationDef, fontName pdfcore.PdfObjectName,
+	fontObj pdfcore.PdfObject, encoder textencoding.TextEncoder) (*pdfcore.PdfObjectDictionary, error) {
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addAppearanceExtGState(resources, textDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = resources.SetFontByName(fontName, fontObj)
+	if err != nil {
+		return nil, err
+	}
+
+	x, y, w, h := textDef.X, textDef.Y, textDef.Width, textDef.Height
+	creator := pdfcontent.NewContentCreator()
+	creator.Add_q()
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+	if textDef.FillEnabled {
+		creator.
+			Add_rg(textDef.FillColor.R(), textDef.FillColor.G(), textDef.FillColor.B()).
+			Add_re(x, y, w, h).
+			Add_f()
+	}
+	padding := 2.0
+	if textDef.BorderEnabled && textDef.BorderWidth > 0 {
+		bw := textDef.BorderWidth
+		creator.
+			Add_RG(textDef.BorderColor.R(), textDef.BorderColor.G(), textDef.BorderColor.B()).
+			Add_w(bw).
+			Add_re(x+bw/2, y+bw/2, w-bw, h-bw).
+			Add_S()
+		padding += bw
+	}
+
+	// Clip the text to the inside of the box.
+	innerWidth := w - 2*padding
+	creator.
+		Add_re(x+padding, y+padding, innerWidth, h-2*padding).
+		Add_W().
+		Add_n()
+
+	fontSize := textDef.FontSize
+	leading := 1.2 * fontSize
+	lines := wrapFreeText(textDef.Text, textDef.Font, encoder, fontSize, innerWidth)
+
+	creator.
+		Add_BT().
+		Add_Tf(fontName, fontSize).
+		Add_rg(textDef.TextColor.R(), textDef.TextColor.G(), textDef.TextColor.B())
+	prevX, prevY := 0.0, 0.0
+	for idx, line := range lines {
+		lineX := x + padding
+		switch textDef.Alignment {
+		case FreeTextAlignmentCenter:
+			lineX += (innerWidth - getFreeTextWidth(line, textDef.Font, encoder, fontSize)) / 2
+		case FreeTextAlignmentRight:
+			lineX += innerWidth - getFreeTextWidth(line, textDef.Font, encoder, fontSize)
+		}
+		lineY := y + h - padding - fontSize - float64(idx)*leading
+
+		creator.Add_Td(lineX-prevX, lineY-prevY)
+		creator.Add_Tj(pdfcore.PdfObjectString(encoder.Encode(line)))
+		prevX, prevY = lineX, lineY
+	}
+	creator.Add_ET().Add_Q()
+
+	bbox := &pdf.PdfRectangle{Llx: x, Lly: y, Urx: x + w, Ury: y + h}
+	return makeAppearanceDict(creator.Bytes(), bbox, resources)
+}
+
+// Wraps the text into lines that fit within `width` when shown with the font.  Explicit line breaks are kept
+// and a word that is wider than `width` is placed on a line of its own.
+func wrapFreeText(text string, font fonts.Font, encoder textencoding.TextEncoder, fontSize, width float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line == "" {
+				line = word
+				continue
+			}
+			candidate := line + " " + word
+			if getFreeTextWidth(candidate, font, encoder, fontSize) > width {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line = candidate
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Gets the width of a line of text shown with the font in the given size.
+func getFreeTextWidth(text string, font fonts.Font, encoder textencoding.TextEncoder, fontSize float64) float64 {
+	width := 0.0
+	for _, r := range text {
+		glyph, found := encoder.RuneToGlyph(r)
+		if !found {
+			common.Log.Debug("Rune 0x%x not supported by text encoder", r)
+			continue
+		}
+		metrics, found := font.GetGlyphCharMetrics(glyph)
+		if !found {
+			common.Log.Debug("Glyph %q not found in font", glyph)
+			continue
+		}
+		width += metrics.Wx
+	}
+	return width * fontSize / 1000.0
+}
@@ -0,0 +1,305 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// Generates the appearance streams of markup annotations from their entries, such as annotations imported from
+// XFDF.  Implements pdf.AnnotationAppearanceGenerator.  The appearance and rectangle are those of the annotation
+// created by the corresponding annotator function, annotations of other types are left unchanged.
+type AppearanceGenerator struct{}
+
+// Generates the normal appearance (AP) of the annotation and updates its rectangle to the appearance bounds.
+func (gen AppearanceGenerator) GenerateAppearance(annotation *pdf.PdfAnnotation) error {
+	rect, err := getAnnotationRect(annotation.Rect)
+	if err != nil {
+		return err
+	}
+	color := getAnnotationColor(annotation.C)
+	if color == nil {
+		color = pdf.NewPdfColorDeviceRGB(0, 0, 0)
+	}
+
+	var created *pdf.PdfAnnotation
+	switch t := annotation.GetContext().(type) {
+	case *pdf.PdfAnnotationHighlight:
+		created, err = generateTextMarkupAppearance(TextMarkupHighlight, t.QuadPoints, color, t.PdfAnnotationMarkup)
+	case *pdf.PdfAnnotationUnderline:
+		created, err = generateTextMarkupAppearance(TextMarkupUnderline, t.QuadPoints, color, t.PdfAnnotationMarkup)
+	case *pdf.PdfAnnotationSquiggly:
+		created, err = generateTextMarkupAppearance(TextMarkupSquiggly, t.QuadPoints, color, t.PdfAnnotationMarkup)
+	case *pdf.PdfAnnotationStrikeOut:
+		created, err = generateTextMarkupAppearance(TextMarkupStrikeOut, t.QuadPoints, color, t.PdfAnnotationMarkup)
+	case *pdf.PdfAnnotationInk:
+		inkDef := InkAnnotationDef{
+			LineColor: color,
+			LineWidth: getAnnotationBorderWidth(t.BS),
+			Opacity:   getAnnotationNumber(t.CA, 1),
+		}
+		if inkList, ok := pdfcore.TraceToDirectObject(t.InkList).(*pdfcore.PdfObjectArray); ok {
+			for _, obj := range *inkList {
+				inkDef.Strokes = append(inkDef.Strokes, getAnnotationPath(obj))
+			}
+		}
+		created, err = CreateInkAnnotation(inkDef)
+	case *pdf.PdfAnnotationPolygon:
+		fillColor := getAnnotationColor(t.IC)
+		created, err = CreatePolygonAnnotation(PolygonAnnotationDef{
+			Vertices:    getAnnotationPath(t.Vertices),
+			LineColor:   color,
+			LineWidth:   getAnnotationBorderWidth(t.BS),
+			FillEnabled: fillColor != nil,
+			FillColor:   fillColor,
+			Opacity:     getAnnotationNumber(t.CA, 1),
+		})
+	case *pdf.PdfAnnotationPolyLine:
+		le1, le2 := getAnnotationLineEndings(t.LE)
+		created, err = CreatePolyLineAnnotation(PolyLineAnnotationDef{
+			Vertices:         getAnnotationPath(t.Vertices),
+			LineColor:        color,
+			LineWidth:        getAnnotationBorderWidth(t.BS),
+			Opacity:          getAnnotationNumber(t.CA, 1),
+			LineEndingStyle1: le1,
+			LineEndingStyle2: le2,
+		})
+	case *pdf.PdfAnnotationLine:
+		l := getAnnotationFloats(t.L)
+		if len(l) != 4 {
+			return errors.New("Invalid line annotation L")
+		}
+		le1, le2 := getAnnotationLineEndings(t.LE)
+		created, err = CreateLineAnnotation(LineAnnotationDef{
+			X1:               l[0],
+			Y1:               l[1],
+			X2:               l[2],
+			Y2:               l[3],
+			LineColor:        color,
+			Opacity:          getAnnotationNumber(t.CA, 1),
+			LineWidth:        getAnnotationBorderWidth(t.BS),
+			LineEndingStyle1: le1,
+			LineEndingStyle2: le2,
+		})
+	case *pdf.PdfAnnotationSquare:
+		fillColor := getAnnotationColor(t.IC)
+		created, err = CreateRectangleAnnotation(RectangleAnnotationDef{
+			X:             rect.Llx,
+			Y:             rect.Lly,
+			Width:         rect.Urx - rect.Llx,
+			Height:        rect.Ury - rect.Lly,
+			FillEnabled:   fillColor != nil,
+			FillColor:     fillColor,
+			BorderEnabled: annotation.C != nil,
+			BorderWidth:   getAnnotationBorderWidth(t.BS),
+			BorderColor:   color,
+			Opacity:       getAnnotationNumber(t.CA, 1),
+		})
+	case *pdf.PdfAnnotationCircle:
+		fillColor := getAnnotationColor(t.IC)
+		created, err = CreateCircleAnnotation(CircleAnnotationDef{
+			X:             rect.Llx,
+			Y:             rect.Lly,
+			Width:         rect.Urx - rect.Llx,
+			Height:        rect.Ury - rect.Lly,
+			FillEnabled:   fillColor != nil,
+			FillColor:     fillColor,
+			BorderEnabled: annotation.C != nil,
+			BorderWidth:   getAnnotationBorderWidth(t.BS),
+			BorderColor:   color,
+			Opacity:       getAnnotationNumber(t.CA, 1),
+		})
+	case *pdf.PdfAnnotationCaret:
+		created, err = CreateCaretAnnotation(CaretAnnotationDef{
+			X:       rect.Llx,
+			Y:       rect.Lly,
+			Width:   rect.Urx - rect.Llx,
+			Height:  rect.Ury - rect.Lly,
+			Color:   color,
+			Opacity: getAnnotationNumber(t.CA, 1),
+		})
+	case *pdf.PdfAnnotationFreeText:
+		textDef := FreeTextAnnotationDef{
+			X:         rect.Llx,
+			Y:         rect.Lly,
+			Width:     rect.Urx - rect.Llx,
+			Height:    rect.Ury - rect.Lly,
+			Text:      getTextString(annotation.Contents),
+			Alignment: FreeTextAlignment(getAnnotationNumber(t.Q, 0)),
+			Opacity:   getAnnotationNumber(t.CA, 1),
+		}
+		textDef.FontSize, textDef.TextColor = parseDefaultAppearance(getTextString(t.DA))
+		if width := getAnnotationBorderWidth(t.BS); annotation.C != nil && width > 0 {
+			textDef.BorderEnabled = true
+			textDef.BorderWidth = width
+			textDef.BorderColor = color
+		}
+		created, err = CreateFreeTextAnnotation(textDef)
+	case *pdf.PdfAnnotationText:
+		noteDef := NoteAnnotationDef{
+			X:       rect.Llx,
+			Y:       rect.Lly,
+			Color:   getAnnotationColor(annotation.C),
+			Opacity: getAnnotationNumber(t.CA, 1),
+		}
+		if name, ok := pdfcore.TraceToDirectObject(t.Name).(*pdfcore.PdfObjectName); ok {
+			noteDef.Icon = NoteIcon(*name)
+		}
+		created, err = CreateNoteAnnotation(noteDef)
+	case *pdf.PdfAnnotationStamp:
+		stampDef := StampAnnotationDef{
+			X:       rect.Llx,
+			Y:       rect.Lly,
+			Width:   rect.Urx - rect.Llx,
+			Height:  rect.Ury - rect.Lly,
+			Color:   getAnnotationColor(annotation.C),
+			Opacity: getAnnotationNumber(t.CA, 1),
+		}
+		if name, ok := pdfcore.TraceToDirectObject(t.Name).(*pdfcore.PdfObjectName); ok {
+			stampDef.Name = StampName(*name)
+		}
+		created, err = CreateStampAnnotation(stampDef)
+	default:
+		common.Log.Debug("No appearance generation for %T", t)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	annotation.AP = created.AP
+	annotation.Rect = created.Rect
+	return nil
+}
+
+func generateTextMarkupAppearance(markupType TextMarkupType, quadPoints pdfcore.PdfObject, color *pdf.PdfColorDeviceRGB, markup *pdf.PdfAnnotationMarkup) (*pdf.PdfAnnotation, error) {
+	return CreateTextMarkupAnnotation(TextMarkupAnnotationDef{
+		MarkupType: markupType,
+		QuadPoints: getAnnotationFloats(quadPoints),
+		Color:      color,
+		Opacity:    getAnnotationNumber(markup.CA, 1),
+	})
+}
+
+// Parses the font size and fill color of a default appearance string such as "/Helv 12 Tf 0 0 1 rg".
+// Returns a zero size and nil color for missing operators.
+func parseDefaultAppearance(da string) (float64, *pdf.PdfColorDeviceRGB) {
+	fontSize := 0.0
+	var color *pdf.PdfColorDeviceRGB
+
+	operands := []float64{}
+	for _, token := range strings.Fields(da) {
+		if val, err := strconv.ParseFloat(token, 64); err == nil {
+			operands = append(operands, val)
+			continue
+		}
+		n := len(operands)
+		switch token {
+		case "Tf":
+			if n >= 1 {
+				fontSize = operands[n-1]
+			}
+		case "rg":
+			if n >= 3 {
+				color = pdf.NewPdfColorDeviceRGB(operands[n-3], operands[n-2], operands[n-1])
+			}
+		case "g":
+			if n >= 1 {
+				color = pdf.NewPdfColorDeviceRGB(operands[n-1], operands[n-1], operands[n-1])
+			}
+		}
+		if !strings.HasPrefix(token, "/") {
+			operands = operands[:0]
+		}
+	}
+	return fontSize, color
+}
+
+func getAnnotationRect(obj pdfcore.PdfObject) (*pdf.PdfRectangle, error) {
+	arr, ok := pdfcore.TraceToDirectObject(obj).(*pdfcore.PdfObjectArray)
+	if !ok {
+		return nil, errors.New("Annotation Rect missing")
+	}
+	return pdf.NewPdfRectangle(*arr)
+}
+
+// Gets the values of an array of numbers or nil if not an array of numbers.
+func getAnnotationFloats(obj pdfcore.PdfObject) []float64 {
+	arr, ok := pdfcore.TraceToDirectObject(obj).(*pdfcore.PdfObjectArray)
+	if !ok {
+		return nil
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return nil
+	}
+	return vals
+}
+
+func getAnnotationNumber(obj pdfcore.PdfObject, defaultValue float64) float64 {
+	switch t := pdfcore.TraceToDirectObject(obj).(type) {
+	case *pdfcore.PdfObjectFloat:
+		return float64(*t)
+	case *pdfcore.PdfObjectInteger:
+		return float64(*t)
+	}
+	return defaultValue
+}
+
+// Gets an RGB color from a color array or nil if not an RGB color.
+func getAnnotationColor(obj pdfcore.PdfObject) *pdf.PdfColorDeviceRGB {
+	vals := getAnnotationFloats(obj)
+	if len(vals) != 3 {
+		return nil
+	}
+	return pdf.NewPdfColorDeviceRGB(vals[0], vals[1], vals[2])
+}
+
+// Gets the width of a border style dictionary, defaults to 1.
+func getAnnotationBorderWidth(obj pdfcore.PdfObject) float64 {
+	if bs, ok := pdfcore.TraceToDirectObject(obj).(*pdfcore.PdfObjectDictionary); ok {
+		return getAnnotationNumber(bs.Get("W"), 1)
+	}
+	return 1
+}
+
+// Gets a path from an array of alternating x and y coordinates.
+func getAnnotationPath(obj pdfcore.PdfObject) draw.Path {
+	path := draw.NewPath()
+	vals := getAnnotationFloats(obj)
+	for i := 0; i+1 < len(vals); i += 2 {
+		path = path.AppendPoint(draw.NewPoint(vals[i], vals[i+1]))
+	}
+	return path
+}
+
+// Gets the line ending styles of an LE array.  Arrows are drawn closed, other styles are drawn as butts.
+func getAnnotationLineEndings(obj pdfcore.PdfObject) (draw.LineEndingStyle, draw.LineEndingStyle) {
+	styles := []draw.LineEndingStyle{draw.LineEndingStyleNone, draw.LineEndingStyleNone}
+	if arr, ok := pdfcore.TraceToDirectObject(obj).(*pdfcore.PdfObjectArray); ok && len(*arr) == 2 {
+		for i, obj := range *arr {
+			name, ok := pdfcore.TraceToDirectObject(obj).(*pdfcore.PdfObjectName)
+			if !ok {
+				continue
+			}
+			switch *name {
+			case "None":
+			case "ClosedArrow", "OpenArrow", "RClosedArrow", "ROpenArrow":
+				styles[i] = draw.LineEndingStyleArrow
+			default:
+				styles[i] = draw.LineEndingStyleButt
+			}
+		}
+	}
+	return styles[0], styles[1]
+}
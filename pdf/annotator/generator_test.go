@@ -0,0 +1,146 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+func TestGeneratorXFDFRect(t *testing.T) {
+	xfdf := `<xfdf><annots>
+<text page="0" rect="10,10,30,30" color="#FFFF00" icon="Comment"/>
+<freetext page="0" rect="10,10,110,40" justification="centered">Text</freetext>
+<square page="0" rect="10,10,50,20" color="#FF0000" width="2"/>
+<circle page="0" rect="10,10,50,20" color="#FF0000" interior-color="#00FF00" width="2"/>
+<caret page="0" rect="10,10,20,25" color="#0000FF"/>
+<stamp page="0" rect="10,10,110,40" color="#FF0000" icon="Approved"/>
+</annots></xfdf>`
+	annots, err := pdf.ReadXFDF(strings.NewReader(xfdf), AppearanceGenerator{})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := [][]float64{{10, 10, 30, 30}, {10, 10, 110, 40}, {10, 10, 50, 20}, {10, 10, 50, 20},
+		{10, 10, 20, 25}, {10, 10, 110, 40}}
+	if len(annots) != len(expected) {
+		t.Fatalf("Invalid number of annotations: %d", len(annots))
+	}
+	for i, annot := range annots {
+		what := annot.Annotation.GetContext()
+		if annot.Annotation.AP == nil {
+			t.Errorf("%T: appearance missing", what)
+		}
+		checkFloats(t, "Rect", getFloats(t, *annot.Annotation.Rect.(*pdfcore.PdfObjectArray)...), expected[i])
+	}
+}
+
+func TestGeneratorRoundTrip(t *testing.T) {
+	red := pdf.NewPdfColorDeviceRGB(1, 0, 0)
+	path := draw.NewPath()
+	path = path.AppendPoint(draw.NewPoint(10, 10))
+	path = path.AppendPoint(draw.NewPoint(50, 20))
+	path = path.AppendPoint(draw.NewPoint(30, 60))
+	quadPoints := []float64{10, 30, 110, 30, 10, 16, 110, 16}
+
+	type creator func() (*pdf.PdfAnnotation, error)
+	creators := []creator{
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateTextMarkupAnnotation(TextMarkupAnnotationDef{MarkupType: TextMarkupHighlight,
+				QuadPoints: quadPoints, Color: red, Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateTextMarkupAnnotation(TextMarkupAnnotationDef{MarkupType: TextMarkupUnderline,
+				QuadPoints: quadPoints, Color: red, Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateTextMarkupAnnotation(TextMarkupAnnotationDef{MarkupType: TextMarkupSquiggly,
+				QuadPoints: quadPoints, Color: red, Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateTextMarkupAnnotation(TextMarkupAnnotationDef{MarkupType: TextMarkupStrikeOut,
+				QuadPoints: quadPoints, Color: red, Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateInkAnnotation(InkAnnotationDef{Strokes: []draw.Path{path}, LineColor: red, LineWidth: 2,
+				Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreatePolygonAnnotation(PolygonAnnotationDef{Vertices: path, LineColor: red, LineWidth: 2,
+				Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreatePolyLineAnnotation(PolyLineAnnotationDef{Vertices: path, LineColor: red, LineWidth: 2,
+				Opacity: 1, LineEndingStyle1: draw.LineEndingStyleArrow})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateLineAnnotation(LineAnnotationDef{X1: 10, Y1: 10, X2: 50, Y2: 60, LineColor: red,
+				LineWidth: 2, Opacity: 1, LineEndingStyle2: draw.LineEndingStyleButt})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateRectangleAnnotation(RectangleAnnotationDef{X: 10, Y: 10, Width: 40, Height: 10,
+				BorderEnabled: true, BorderWidth: 2, BorderColor: red, Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateCircleAnnotation(CircleAnnotationDef{X: 10, Y: 10, Width: 40, Height: 10,
+				FillEnabled: true, FillColor: red, BorderEnabled: true, BorderWidth: 2, BorderColor: red, Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateCaretAnnotation(CaretAnnotationDef{X: 10, Y: 10, Width: 10, Height: 15, Color: red,
+				Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateFreeTextAnnotation(FreeTextAnnotationDef{X: 10, Y: 10, Width: 100, Height: 30,
+				Text: "Text", Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateNoteAnnotation(NoteAnnotationDef{X: 10, Y: 10, Contents: "Note", Opacity: 1})
+		},
+		func() (*pdf.PdfAnnotation, error) {
+			return CreateStampAnnotation(StampAnnotationDef{X: 10, Y: 10, Width: 100, Height: 30,
+				Name: StampApproved, Opacity: 1})
+		},
+	}
+
+	// Regenerating the appearance of a created annotation keeps its rectangle.
+	for _, create := range creators {
+		annotation, err := create()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		what := annotation.GetContext()
+		rect := getFloats(t, *annotation.Rect.(*pdfcore.PdfObjectArray)...)
+		annotation.AP = nil
+		if err := (AppearanceGenerator{}).GenerateAppearance(annotation); err != nil {
+			t.Fatalf("%T: %v", what, err)
+		}
+		if annotation.AP == nil {
+			t.Errorf("%T: appearance missing", what)
+		}
+		checkFloats(t, "Rect", getFloats(t, *annotation.Rect.(*pdfcore.PdfObjectArray)...), rect)
+	}
+}
+
+func TestGeneratorXFDFErrors(t *testing.T) {
+	// The polygon without vertices is imported without appearance.
+	xfdf := `<xfdf><annots>
+<polygon page="0" rect="10,10,50,20" color="#FF0000"/>
+<square page="0" rect="10,10,50,20" color="#FF0000"/>
+</annots></xfdf>`
+	annots, err := pdf.ReadXFDF(strings.NewReader(xfdf), AppearanceGenerator{})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(annots) != 2 {
+		t.Fatalf("Invalid number of annotations: %d", len(annots))
+	}
+	if annots[0].Annotation.AP != nil || annots[1].Annotation.AP == nil {
+		t.Errorf("Invalid appearances: %v %v", annots[0].Annotation.AP, annots[1].Annotation.AP)
+	}
+}
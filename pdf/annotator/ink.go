@@ -0,0 +1,107 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// Defines a freehand ink annotation consisting of one or more strokes, each a path of points in page
+// coordinates.  The strokes are drawn with round caps and joins in the specified width, color and opacity.
+type InkAnnotationDef struct {
+	Strokes   []draw.Path
+	LineColor *pdf.PdfColorDeviceRGB
+	LineWidth float64
+	Opacity   float64 // Alpha value (0-1).
+}
+
+// Creates an ink annotation object that can be added to page PDF annotations.
+func CreateInkAnnotation(inkDef InkAnnotationDef) (*pdf.PdfAnnotation, error) {
+	inkAnnotation := pdf.NewPdfAnnotationInk()
+
+	inkList := pdfcore.PdfObjectArray{}
+	for _, stroke := range inkDef.Strokes {
+		coords := []float64{}
+		for _, p := range stroke.Points {
+			coords = append(coords, p.X, p.Y)
+		}
+		if len(coords) > 0 {
+			inkList = append(inkList, pdfcore.MakeArrayFromFloats(coords))
+		}
+	}
+	if len(inkList) == 0 {
+		return nil, errors.New("Ink annotation requires at least one point")
+	}
+	inkAnnotation.InkList = &inkList
+
+	r, g, b := inkDef.LineColor.R(), inkDef.LineColor.G(), inkDef.LineColor.B()
+	inkAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	bs := pdf.NewBorderStyle()
+	bs.SetBorderWidth(inkDef.LineWidth)
+	inkAnnotation.BS = bs.ToPdfObject()
+
+	// Opacity.
+	if inkDef.Opacity < 1.0 {
+		inkAnnotation.CA = pdfcore.MakeFloat(inkDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	apDict, bbox, err := makeInkAnnotationAppearanceStream(inkDef)
+	if err != nil {
+		return nil, err
+	}
+	inkAnnotation.AP = apDict
+	inkAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return inkAnnotation.PdfAnnotation, nil
+}
+
+func makeInkAnnotationAppearanceStream(inkDef InkAnnotationDef) (*pdfcore.PdfObjectDictionary, *pdf.PdfRectangle, error) {
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addAppearanceExtGState(resources, inkDef.Opacity, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	creator := pdfcontent.NewContentCreator()
+	creator.
+		Add_q().
+		Add_RG(inkDef.LineColor.R(), inkDef.LineColor.G(), inkDef.LineColor.B()).
+		Add_w(inkDef.LineWidth).
+		Add_J("1").
+		Add_j("1")
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+
+	points := []draw.Point{}
+	for _, stroke := range inkDef.Strokes {
+		if len(stroke.Points) == 0 {
+			continue
+		}
+		path := stroke.Copy()
+		if len(path.Points) == 1 {
+			// A single point is drawn as a dot by the round line cap.
+			path = path.AppendPoint(path.Points[0])
+		}
+		draw.DrawPathWithCreator(path, creator)
+		creator.Add_S()
+		points = append(points, stroke.Points...)
+	}
+	creator.Add_Q()
+
+	bbox := getPointsBbox(points, inkDef.LineWidth/2)
+	apDict, err := makeAppearanceDict(creator.Bytes(), bbox, resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return apDict, bbox, nil
+}
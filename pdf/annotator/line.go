@@ -78,9 +78,10 @@ func makeLineAnnotationAppearanceStream(lineDef LineAnnotationDef) (*pdfcore.Pdf
 	gsName := ""
 	if lineDef.Opacity < 1.0 {
 		// Create graphics state with right opacity.
-		gsState := pdfcore.MakeDict()
-		gsState.Set("ca", pdfcore.MakeFloat(lineDef.Opacity))
-		err := form.Resources.AddExtGState("gs1", gsState)
+		gsState := pdf.NewPdfExtGState()
+		opacity := lineDef.Opacity
+		gsState.Ca = &opacity
+		err := form.Resources.SetExtGStateByName("gs1", gsState)
 		if err != nil {
 			common.Log.Debug("Unable to add extgstate gs1")
 			return nil, nil, err
@@ -0,0 +1,320 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+	"time"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+	"github.com/unidoc/unidoc/pdf/model/textencoding"
+)
+
+// Annotation flags (F) used by the annotator.
+const (
+	annotationFlagHidden   = 2
+	annotationFlagPrint    = 4
+	annotationFlagNoZoom   = 8
+	annotationFlagNoRotate = 16
+)
+
+// The icon of a text (sticky note) annotation.
+type NoteIcon string
+
+const (
+	NoteIconComment      NoteIcon = "Comment"
+	NoteIconKey          NoteIcon = "Key"
+	NoteIconNote         NoteIcon = "Note"
+	NoteIconHelp         NoteIcon = "Help"
+	NoteIconNewParagraph NoteIcon = "NewParagraph"
+	NoteIconParagraph    NoteIcon = "Paragraph"
+	NoteIconInsert       NoteIcon = "Insert"
+)
+
+// The relationship (RT) of an annotation to the annotation it is in reply to.
+type ReplyType string
+
+const (
+	ReplyTypeReply ReplyType = "R"     // A reply to the annotation.
+	ReplyTypeGroup ReplyType = "Group" // Grouped with the annotation, shown as a single unit.
+)
+
+// The state model of a state annotation.
+type NoteStateModel string
+
+const (
+	NoteStateModelMarked NoteStateModel = "Marked"
+	NoteStateModelReview NoteStateModel = "Review"
+)
+
+// The state of an annotation within a state model.  Marked and Unmarked belong to the Marked model, the others
+// to the Review model.
+type NoteState string
+
+const (
+	NoteStateMarked    NoteState = "Marked"
+	NoteStateUnmarked  NoteState = "Unmarked"
+	NoteStateAccepted  NoteState = "Accepted"
+	NoteStateRejected  NoteState = "Rejected"
+	NoteStateCancelled NoteState = "Cancelled"
+	NoteStateCompleted NoteState = "Completed"
+	NoteStateNone      NoteState = "None"
+)
+
+// The size of the note icons in points.
+const noteIconSize = 20.0
+
+// Defines a text (sticky note) annotation shown as an icon with a lower left corner at (X,Y).  The note can
+// optionally have a popup window in PopupRect showing the Contents and be a reply to another annotation.
+// The Icon defaults to Note, the Color to yellow and the CreationDate to the current time.
+type NoteAnnotationDef struct {
+	X            float64
+	Y            float64
+	Contents     string
+	Author       string
+	Subject      string
+	Icon         NoteIcon
+	Color        *pdf.PdfColorDeviceRGB
+	Opacity      float64 // Alpha value (0-1).
+	Open         bool    // Initially open the popup?
+	PopupRect    *pdf.PdfRectangle
+	CreationDate time.Time
+	InReplyTo    *pdf.PdfAnnotation
+	ReplyType    ReplyType // Relationship to InReplyTo, defaults to a reply.
+}
+
+// Defines a state annotation which records the state of another annotation set by Author, such as a review
+// accepting a comment.  The Date defaults to the current time.
+type StateAnnotationDef struct {
+	Author     string
+	StateModel NoteStateModel
+	State      NoteState
+	Date       time.Time
+}
+
+// Creates a text (sticky note) annotation object that can be added to page PDF annotations.  If a popup is
+// created, it is referenced by the Popup entry of the note and needs to be added to the page annotations as
+// well, see AddAnnotationToPage.
+func CreateNoteAnnotation(noteDef NoteAnnotationDef) (*pdf.PdfAnnotation, error) {
+	// Defaults.
+	if noteDef.Icon == "" {
+		noteDef.Icon = NoteIconNote
+	}
+	if noteDef.Color == nil {
+		noteDef.Color = pdf.NewPdfColorDeviceRGB(1, 1, 0)
+	}
+	if noteDef.CreationDate.IsZero() {
+		noteDef.CreationDate = time.Now()
+	}
+
+	noteAnnotation := pdf.NewPdfAnnotationText()
+	noteAnnotation.Contents = pdfcore.MakeString(pdf.EncodeTextString(noteDef.Contents))
+	noteAnnotation.Name = pdfcore.MakeName(string(noteDef.Icon))
+	noteAnnotation.Open = pdfcore.MakeBool(noteDef.Open)
+	noteAnnotation.F = pdfcore.MakeInteger(annotationFlagPrint | annotationFlagNoZoom | annotationFlagNoRotate)
+	setMarkupInfo(noteAnnotation.PdfAnnotation, noteAnnotation.PdfAnnotationMarkup, noteDef.Author,
+		noteDef.Subject, noteDef.CreationDate)
+
+	r, g, b := noteDef.Color.R(), noteDef.Color.G(), noteDef.Color.B()
+	noteAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+
+	// Opacity.
+	if noteDef.Opacity < 1.0 {
+		noteAnnotation.CA = pdfcore.MakeFloat(noteDef.Opacity)
+	}
+
+	if noteDef.InReplyTo != nil {
+		noteAnnotation.IRT = noteDef.InReplyTo.GetContainingPdfObject()
+		if noteDef.ReplyType != "" && noteDef.ReplyType != ReplyTypeReply {
+			noteAnnotation.RT = pdfcore.MakeName(string(noteDef.ReplyType))
+		}
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	apDict, err := makeNoteAnnotationAppearanceStream(noteDef)
+	if err != nil {
+		return nil, err
+	}
+	noteAnnotation.AP = apDict
+	noteAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{noteDef.X, noteDef.Y, noteDef.X + noteIconSize,
+		noteDef.Y + noteIconSize})
+
+	if noteDef.PopupRect != nil {
+		popup := pdf.NewPdfAnnotationPopup()
+		popup.Rect = noteDef.PopupRect.ToPdfObject()
+		popup.Parent = noteAnnotation.GetContainingPdfObject()
+		popup.Open = pdfcore.MakeBool(noteDef.Open)
+		noteAnnotation.Popup = popup
+	}
+
+	return noteAnnotation.PdfAnnotation, nil
+}
+
+// Creates a state annotation for `annotation` that can be added to page PDF annotations.  The state annotation
+// is a hidden text annotation in reply to the annotation.
+func CreateStateAnnotation(annotation *pdf.PdfAnnotation, stateDef StateAnnotationDef) (*pdf.PdfAnnotation, error) {
+	if annotation == nil {
+		return nil, errors.New("State annotation requires an annotation")
+	}
+	if stateDef.StateModel == "" || stateDef.State == "" {
+		return nil, errors.New("State annotation requires a state model and state")
+	}
+	if stateDef.Date.IsZero() {
+		stateDef.Date = time.Now()
+	}
+
+	stateAnnotation := pdf.NewPdfAnnotationText()
+	contents := stateDef.Author + " set state to " + string(stateDef.State)
+	stateAnnotation.Contents = pdfcore.MakeString(pdf.EncodeTextString(contents))
+	stateAnnotation.F = pdfcore.MakeInteger(annotationFlagHidden | annotationFlagPrint | annotationFlagNoZoom |
+		annotationFlagNoRotate)
+	stateAnnotation.IRT = annotation.GetContainingPdfObject()
+	stateAnnotation.StateModel = pdfcore.MakeString(string(stateDef.StateModel))
+	stateAnnotation.State = pdfcore.MakeString(string(stateDef.State))
+	setMarkupInfo(stateAnnotation.PdfAnnotation, stateAnnotation.PdfAnnotationMarkup, stateDef.Author, "",
+		stateDef.Date)
+
+	// Use the location of the annotation, the state annotation itself is not shown.
+	stateAnnotation.Rect = annotation.Rect
+	if stateAnnotation.Rect == nil {
+		stateAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{0, 0, 0, 0})
+	}
+
+	return stateAnnotation.PdfAnnotation, nil
+}
+
+// Adds an annotation to the annotations of the page along with its popup, if any, and sets the page reference
+// (P) of both.
+func AddAnnotationToPage(page *pdf.PdfPage, annotation *pdf.PdfAnnotation) {
+	annotation.P = page.GetContainingPdfObject()
+	page.Annotations = append(page.Annotations, annotation)
+
+	markup := getMarkup(annotation)
+	if markup == nil || markup.Popup == nil {
+		return
+	}
+	for _, annot := range page.Annotations {
+		if annot.GetContainingPdfObject() == markup.Popup.GetContainingPdfObject() {
+			return
+		}
+	}
+	markup.Popup.P = page.GetContainingPdfObject()
+	page.Annotations = append(page.Annotations, markup.Popup.PdfAnnotation)
+}
+
+// Sets the author (T), subject, creation and modification dates of a markup annotation.
+func setMarkupInfo(annotation *pdf.PdfAnnotation, markup *pdf.PdfAnnotationMarkup, author, subject string, date time.Time) {
+	if author != "" {
+		markup.T = pdfcore.MakeString(pdf.EncodeTextString(author))
+	}
+	if subject != "" {
+		markup.Subj = pdfcore.MakeString(pdf.EncodeTextString(subject))
+	}
+	pdfDate := pdf.NewPdfDateFromTime(date)
+	markup.CreationDate = pdfDate.ToPdfObject()
+	annotation.M = pdfDate.ToPdfObject()
+}
+
+func makeNoteAnnotationAppearanceStream(noteDef NoteAnnotationDef) (*pdfcore.PdfObjectDictionary, error) {
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addAppearanceExtGState(resources, noteDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	creator := pdfcontent.NewContentCreator()
+	creator.
+		Add_q().
+		Add_rg(noteDef.Color.R(), noteDef.Color.G(), noteDef.Color.B()).
+		Add_RG(0, 0, 0).
+		Add_w(0.6).
+		Add_j("1")
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+
+	// The icons are drawn in a local noteIconSize x noteIconSize box.
+	iconText := ""
+	iconFontSize := 0.0
+	switch noteDef.Icon {
+	case NoteIconComment:
+		creator.
+			Add_m(2, 18).Add_l(18, 18).Add_l(18, 7).Add_l(9, 7).Add_l(5, 2).Add_l(6, 7).Add_l(2, 7).
+			Add_h().Add_B().
+			Add_m(5, 14.5).Add_l(15, 14.5).
+			Add_m(5, 10.5).Add_l(15, 10.5).
+			Add_S()
+	case NoteIconKey:
+		addCirclePath(creator, 6, 13, 4)
+		creator.
+			Add_B().
+			Add_m(10, 14).Add_l(18, 14).Add_l(18, 12).Add_l(17, 12).Add_l(17, 9).Add_l(15, 9).Add_l(15, 12).
+			Add_l(10, 12).Add_h().Add_B()
+	case NoteIconHelp:
+		addCirclePath(creator, 10, 10, 8.5)
+		creator.Add_B()
+		iconText, iconFontSize = "?", 13
+	case NoteIconInsert:
+		creator.
+			Add_m(2, 2).Add_l(10, 18).Add_l(18, 2).Add_l(14, 2).Add_l(10, 11).Add_l(6, 2).
+			Add_h().Add_B()
+	case NoteIconParagraph:
+		creator.Add_re(2, 2, 16, 16).Add_B()
+		iconText, iconFontSize = "¶", 14
+	case NoteIconNewParagraph:
+		creator.Add_m(4, 10).Add_l(10, 18).Add_l(16, 10).Add_h().Add_B()
+		iconText, iconFontSize = "NP", 8
+	default:
+		// Note: a page with lines of text.
+		creator.
+			Add_re(3, 1, 14, 18).Add_B().
+			Add_m(6, 15).Add_l(14, 15).
+			Add_m(6, 12).Add_l(14, 12).
+			Add_m(6, 9).Add_l(14, 9).
+			Add_m(6, 6).Add_l(11, 6).
+			Add_S()
+	}
+
+	if iconText != "" {
+		font := fonts.NewFontHelveticaBold()
+		encoder := textencoding.NewWinAnsiTextEncoder()
+		err := resources.SetFontByName("HeBo", font.ToPdfObject())
+		if err != nil {
+			return nil, err
+		}
+		baseline := 10 - iconFontSize*0.36
+		if noteDef.Icon == NoteIconNewParagraph {
+			baseline = 2.5
+		}
+		width := getFreeTextWidth(iconText, font, encoder, iconFontSize)
+		creator.
+			Add_BT().
+			Add_Tf("HeBo", iconFontSize).
+			Add_rg(0, 0, 0).
+			Add_Td(10-width/2, baseline).
+			Add_Tj(pdfcore.PdfObjectString(encoder.Encode(iconText))).
+			Add_ET()
+	}
+	creator.Add_Q()
+
+	bbox := &pdf.PdfRectangle{Llx: 0, Lly: 0, Urx: noteIconSize, Ury: noteIconSize}
+	return makeAppearanceDict(creator.Bytes(), bbox, resources)
+}
+
+// Adds a closed circle path with center (cx,cy) and radius r approximated by four Bezier curves.
+func addCirclePath(creator *pdfcontent.ContentCreator, cx, cy, r float64) {
+	k := 0.5523 * r
+	creator.
+		Add_m(cx+r, cy).
+		Add_c(cx+r, cy+k, cx+k, cy+r, cx, cy+r).
+		Add_c(cx-k, cy+r, cx-r, cy+k, cx-r, cy).
+		Add_c(cx-r, cy-k, cx-k, cy-r, cx, cy-r).
+		Add_c(cx+k, cy-r, cx+r, cy-k, cx+r, cy).
+		Add_h()
+}
@@ -0,0 +1,176 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// Defines a closed polygon through the Vertices (in page coordinates).  The polygon has a border of the specified
+// width and color and can optionally be filled.
+type PolygonAnnotationDef struct {
+	Vertices    draw.Path
+	LineColor   *pdf.PdfColorDeviceRGB
+	LineWidth   float64
+	FillEnabled bool // Show fill?
+	FillColor   *pdf.PdfColorDeviceRGB
+	Opacity     float64 // Alpha value (0-1).
+}
+
+// Defines an open polyline through the Vertices (in page coordinates).  The line ending styles can be none
+// (regular line), arrows or butts at either end.  The line also has a specified width, color and opacity.
+type PolyLineAnnotationDef struct {
+	Vertices         draw.Path
+	LineColor        *pdf.PdfColorDeviceRGB
+	LineWidth        float64
+	Opacity          float64              // Alpha value (0-1).
+	LineEndingStyle1 draw.LineEndingStyle // Line ending style of the first vertex.
+	LineEndingStyle2 draw.LineEndingStyle // Line ending style of the last vertex.
+}
+
+// Creates a polygon annotation object that can be added to page PDF annotations.
+func CreatePolygonAnnotation(polygonDef PolygonAnnotationDef) (*pdf.PdfAnnotation, error) {
+	if len(polygonDef.Vertices.Points) < 2 {
+		return nil, errors.New("Polygon requires at least two vertices")
+	}
+	polygonAnnotation := pdf.NewPdfAnnotationPolygon()
+	polygonAnnotation.Vertices = makeVerticesArray(polygonDef.Vertices)
+
+	r, g, b := polygonDef.LineColor.R(), polygonDef.LineColor.G(), polygonDef.LineColor.B()
+	polygonAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	if polygonDef.FillEnabled {
+		r, g, b := polygonDef.FillColor.R(), polygonDef.FillColor.G(), polygonDef.FillColor.B()
+		polygonAnnotation.IC = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	}
+	bs := pdf.NewBorderStyle()
+	bs.SetBorderWidth(polygonDef.LineWidth)
+	polygonAnnotation.BS = bs.ToPdfObject()
+
+	// Opacity.
+	if polygonDef.Opacity < 1.0 {
+		polygonAnnotation.CA = pdfcore.MakeFloat(polygonDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addAppearanceExtGState(resources, polygonDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	creator := pdfcontent.NewContentCreator()
+	creator.
+		Add_q().
+		Add_RG(r, g, b).
+		Add_w(polygonDef.LineWidth).
+		Add_j("1")
+	if polygonDef.FillEnabled {
+		creator.Add_rg(polygonDef.FillColor.R(), polygonDef.FillColor.G(), polygonDef.FillColor.B())
+	}
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+	draw.DrawPathWithCreator(polygonDef.Vertices, creator)
+	if polygonDef.FillEnabled {
+		creator.Add_b()
+	} else {
+		creator.Add_s()
+	}
+	creator.Add_Q()
+
+	bbox := getPointsBbox(polygonDef.Vertices.Points, polygonDef.LineWidth/2)
+	apDict, err := makeAppearanceDict(creator.Bytes(), bbox, resources)
+	if err != nil {
+		return nil, err
+	}
+	polygonAnnotation.AP = apDict
+	polygonAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return polygonAnnotation.PdfAnnotation, nil
+}
+
+// Creates a polyline annotation object that can be added to page PDF annotations.
+func CreatePolyLineAnnotation(polylineDef PolyLineAnnotationDef) (*pdf.PdfAnnotation, error) {
+	points := polylineDef.Vertices.Points
+	if len(points) < 2 {
+		return nil, errors.New("Polyline requires at least two vertices")
+	}
+	polylineAnnotation := pdf.NewPdfAnnotationPolyLine()
+	polylineAnnotation.Vertices = makeVerticesArray(polylineDef.Vertices)
+
+	// Line endings.
+	polylineAnnotation.LE = pdfcore.MakeArray(
+		pdfcore.MakeName(string(getLineEndingName(polylineDef.LineEndingStyle1))),
+		pdfcore.MakeName(string(getLineEndingName(polylineDef.LineEndingStyle2))))
+
+	r, g, b := polylineDef.LineColor.R(), polylineDef.LineColor.G(), polylineDef.LineColor.B()
+	polylineAnnotation.IC = pdfcore.MakeArrayFromFloats([]float64{r, g, b}) // fill color of line endings, rgb 0-1.
+	polylineAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})  // line color, rgb 0-1.
+	bs := pdf.NewBorderStyle()
+	bs.SetBorderWidth(polylineDef.LineWidth)
+	polylineAnnotation.BS = bs.ToPdfObject()
+
+	// Opacity.
+	if polylineDef.Opacity < 1.0 {
+		polylineAnnotation.CA = pdfcore.MakeFloat(polylineDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addAppearanceExtGState(resources, polylineDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	creator := pdfcontent.NewContentCreator()
+	creator.
+		Add_q().
+		Add_RG(r, g, b).
+		Add_rg(r, g, b).
+		Add_w(polylineDef.LineWidth).
+		Add_j("1")
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+
+	// Draw the endings first, the line stops at the base of arrows.
+	n := len(points)
+	path := polylineDef.Vertices.Copy()
+	path.Points[0] = drawLineEnding(creator, points[0], points[1], polylineDef.LineEndingStyle1, polylineDef.LineWidth)
+	path.Points[n-1] = drawLineEnding(creator, points[n-1], points[n-2], polylineDef.LineEndingStyle2, polylineDef.LineWidth)
+	draw.DrawPathWithCreator(path, creator)
+	creator.Add_S()
+	creator.Add_Q()
+
+	// The line endings extend up to 1.5 times the line width on the sides, plus the butt line caps.
+	margin := polylineDef.LineWidth / 2
+	if polylineDef.LineEndingStyle1 != draw.LineEndingStyleNone || polylineDef.LineEndingStyle2 != draw.LineEndingStyleNone {
+		margin = 2 * polylineDef.LineWidth
+	}
+	bbox := getPointsBbox(points, margin)
+	apDict, err := makeAppearanceDict(creator.Bytes(), bbox, resources)
+	if err != nil {
+		return nil, err
+	}
+	polylineAnnotation.AP = apDict
+	polylineAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return polylineAnnotation.PdfAnnotation, nil
+}
+
+// Makes the Vertices array of alternating x and y coordinates.
+func makeVerticesArray(path draw.Path) *pdfcore.PdfObjectArray {
+	coords := []float64{}
+	for _, p := range path.Points {
+		coords = append(coords, p.X, p.Y)
+	}
+	return pdfcore.MakeArrayFromFloats(coords)
+}
@@ -72,10 +72,9 @@ func makeRectangleAnnotationAppearanceStream(rectDef RectangleAnnotationDef) (*p
 	gsName := ""
 	if rectDef.Opacity < 1.0 {
 		// Create graphics state with right opacity.
-		gsState := pdfcore.MakeDict()
-		gsState.Set("ca", pdfcore.MakeFloat(rectDef.Opacity))
-		gsState.Set("CA", pdfcore.MakeFloat(rectDef.Opacity))
-		err := form.Resources.AddExtGState("gs1", gsState)
+		gsState := pdf.NewPdfExtGState()
+		gsState.SetOpacity(rectDef.Opacity)
+		err := form.Resources.SetExtGStateByName("gs1", gsState)
 		if err != nil {
 			common.Log.Debug("Unable to add extgstate gs1")
 			return nil, nil, err
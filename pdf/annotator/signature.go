@@ -0,0 +1,155 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+// SignatureLine is a line of text shown in the appearance of a visible signature field, displayed as
+// "Desc: Text".
+type SignatureLine struct {
+	Desc string
+	Text string
+}
+
+// A visible signature field with a lower left corner at (X,Y) and a specified Width and Height.  The appearance
+// shows the lines of text on a (optionally) filled and bordered background.
+type SignatureFieldDef struct {
+	X             float64
+	Y             float64
+	Width         float64
+	Height        float64
+	Lines         []SignatureLine
+	FontSize      float64 // Defaults to 10.
+	TextColor     *pdf.PdfColorDeviceRGB
+	FillEnabled   bool // Show fill?
+	FillColor     *pdf.PdfColorDeviceRGB
+	BorderEnabled bool // Show border?
+	BorderWidth   float64
+	BorderColor   *pdf.PdfColorDeviceRGB
+}
+
+// Creates a signature field for `signature` with a visible appearance that can be added to a page with
+// PdfAppender.Sign.
+func CreateSignatureField(signature *pdf.PdfSignature, sigDef SignatureFieldDef) (*pdf.PdfFieldSignature, error) {
+	if signature == nil {
+		return nil, errors.New("Signature missing")
+	}
+	if sigDef.Width <= 0 || sigDef.Height <= 0 {
+		return nil, errors.New("Invalid signature field dimensions")
+	}
+
+	field := pdf.NewPdfFieldSignature(signature)
+
+	apDict, err := makeSignatureAppearanceStream(sigDef)
+	if err != nil {
+		return nil, err
+	}
+	field.Widget.AP = apDict
+	field.Widget.Rect = pdfcore.MakeArrayFromFloats([]float64{sigDef.X, sigDef.Y, sigDef.X + sigDef.Width, sigDef.Y + sigDef.Height})
+
+	return field, nil
+}
+
+func makeSignatureAppearanceStream(sigDef SignatureFieldDef) (*pdfcore.PdfObjectDictionary, error) {
+	form := pdf.NewXObjectForm()
+	form.Resources = pdf.NewPdfPageResources()
+
+	font := fonts.NewFontHelvetica()
+	err := form.Resources.SetFontByName("Helv", font.ToPdfObject())
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := drawSignatureAppearance(sigDef, "Helv")
+	if err != nil {
+		return nil, err
+	}
+
+	err = form.SetContentStream(content, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Local bounding box for the XObject Form.
+	bbox := &pdf.PdfRectangle{Llx: 0, Lly: 0, Urx: sigDef.Width, Ury: sigDef.Height}
+	form.BBox = bbox.ToPdfObject()
+
+	apDict := pdfcore.MakeDict()
+	apDict.Set("N", form.ToPdfObject())
+
+	return apDict, nil
+}
+
+func drawSignatureAppearance(sigDef SignatureFieldDef, fontName pdfcore.PdfObjectName) ([]byte, error) {
+	var content []byte
+
+	if sigDef.FillEnabled || sigDef.BorderEnabled {
+		// Drawn locally with 0,0 as the origin.
+		rect := draw.Rectangle{
+			X:             0,
+			Y:             0,
+			Width:         sigDef.Width,
+			Height:        sigDef.Height,
+			FillEnabled:   sigDef.FillEnabled,
+			FillColor:     sigDef.FillColor,
+			BorderEnabled: sigDef.BorderEnabled,
+			BorderWidth:   sigDef.BorderWidth,
+			BorderColor:   sigDef.BorderColor,
+			Opacity:       1.0,
+		}
+		rectContent, _, err := rect.Draw("")
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, "q\n"...)
+		content = append(content, rectContent...)
+		content = append(content, "\nQ\n"...)
+	}
+
+	cc := contentstream.NewContentCreator()
+	fontSize := sigDef.FontSize
+	if fontSize <= 0 {
+		fontSize = 10
+	}
+	leading := 1.2 * fontSize
+	margin := 2 + sigDef.BorderWidth
+
+	cc.Add_q()
+	// Clip the text to the field area.
+	cc.Add_re(0, 0, sigDef.Width, sigDef.Height).Add_W().Add_n()
+	cc.Add_BT()
+	cc.Add_Tf(fontName, fontSize)
+	cc.Add_TL(leading)
+	if sigDef.TextColor != nil {
+		cc.Add_rg(sigDef.TextColor.R(), sigDef.TextColor.G(), sigDef.TextColor.B())
+	} else {
+		cc.Add_g(0)
+	}
+	cc.Add_Td(margin, sigDef.Height-margin-fontSize)
+	for i, line := range sigDef.Lines {
+		text := line.Text
+		if line.Desc != "" {
+			text = line.Desc + ": " + text
+		}
+		if i > 0 {
+			cc.Add_Tstar()
+		}
+		cc.Add_Tj(pdfcore.PdfObjectString(text))
+	}
+	cc.Add_ET()
+	cc.Add_Q()
+	content = append(content, cc.Bytes()...)
+
+	return content, nil
+}
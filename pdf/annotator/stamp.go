@@ -0,0 +1,202 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+	"github.com/unidoc/unidoc/pdf/model/textencoding"
+)
+
+// The name of a rubber stamp annotation.  Custom names can be used for image stamps.
+type StampName string
+
+// Standard stamp names.
+const (
+	StampApproved            StampName = "Approved"
+	StampExperimental        StampName = "Experimental"
+	StampNotApproved         StampName = "NotApproved"
+	StampAsIs                StampName = "AsIs"
+	StampExpired             StampName = "Expired"
+	StampNotForPublicRelease StampName = "NotForPublicRelease"
+	StampConfidential        StampName = "Confidential"
+	StampFinal               StampName = "Final"
+	StampSold                StampName = "Sold"
+	StampDepartmental        StampName = "Departmental"
+	StampForComment          StampName = "ForComment"
+	StampTopSecret           StampName = "TopSecret"
+	StampDraft               StampName = "Draft"
+	StampForPublicRelease    StampName = "ForPublicRelease"
+)
+
+// Defines a rubber stamp annotation in a Width x Height box with a lower left corner at (X,Y).  Standard stamps
+// show the Name as a label in a rounded frame of the specified Color (defaults to green for approving stamps and
+// red for the others).  If an Image is specified, the stamp shows the image scaled to the box instead, and the
+// Name defaults to "Image".
+// The CreationDate defaults to the current time.
+type StampAnnotationDef struct {
+	X            float64
+	Y            float64
+	Width        float64
+	Height       float64
+	Name         StampName
+	Image        *pdf.Image
+	Color        *pdf.PdfColorDeviceRGB
+	Opacity      float64 // Alpha value (0-1).
+	Contents     string
+	Author       string
+	Subject      string
+	CreationDate time.Time
+}
+
+// Creates a rubber stamp annotation object that can be added to page PDF annotations.
+func CreateStampAnnotation(stampDef StampAnnotationDef) (*pdf.PdfAnnotation, error) {
+	// Defaults.
+	if stampDef.Name == "" {
+		if stampDef.Image != nil {
+			stampDef.Name = "Image"
+		} else {
+			stampDef.Name = StampDraft
+		}
+	}
+	if stampDef.Color == nil {
+		switch stampDef.Name {
+		case StampApproved, StampFinal, StampForPublicRelease:
+			stampDef.Color = pdf.NewPdfColorDeviceRGB(0.1, 0.5, 0.1)
+		default:
+			stampDef.Color = pdf.NewPdfColorDeviceRGB(0.8, 0.1, 0.1)
+		}
+	}
+	if stampDef.CreationDate.IsZero() {
+		stampDef.CreationDate = time.Now()
+	}
+
+	stampAnnotation := pdf.NewPdfAnnotationStamp()
+	stampAnnotation.Name = pdfcore.MakeName(string(stampDef.Name))
+	if stampDef.Contents != "" {
+		stampAnnotation.Contents = pdfcore.MakeString(pdf.EncodeTextString(stampDef.Contents))
+	}
+	stampAnnotation.F = pdfcore.MakeInteger(annotationFlagPrint)
+	setMarkupInfo(stampAnnotation.PdfAnnotation, stampAnnotation.PdfAnnotationMarkup, stampDef.Author,
+		stampDef.Subject, stampDef.CreationDate)
+
+	if stampDef.Image == nil {
+		r, g, b := stampDef.Color.R(), stampDef.Color.G(), stampDef.Color.B()
+		stampAnnotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+	}
+
+	// Opacity.
+	if stampDef.Opacity < 1.0 {
+		stampAnnotation.CA = pdfcore.MakeFloat(stampDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	apDict, err := makeStampAnnotationAppearanceStream(stampDef)
+	if err != nil {
+		return nil, err
+	}
+	stampAnnotation.AP = apDict
+	stampAnnotation.Rect = pdfcore.MakeArrayFromFloats([]float64{stampDef.X, stampDef.Y, stampDef.X + stampDef.Width,
+		stampDef.Y + stampDef.Height})
+
+	return stampAnnotation.PdfAnnotation, nil
+}
+
+func makeStampAnnotationAppearanceStream(stampDef StampAnnotationDef) (*pdfcore.PdfObjectDictionary, error) {
+	resources := pdf.NewPdfPageResources()
+	gsName, err := addAppearanceExtGState(resources, stampDef.Opacity, "")
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := stampDef.Width, stampDef.Height
+	creator := pdfcontent.NewContentCreator()
+	creator.Add_q()
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+
+	if stampDef.Image != nil {
+		ximg, err := pdf.NewXObjectImageFromImage(stampDef.Image, nil, pdfcore.NewFlateEncoder())
+		if err != nil {
+			return nil, err
+		}
+		err = resources.SetXObjectImageByName("Im1", ximg)
+		if err != nil {
+			return nil, err
+		}
+		creator.Add_cm(w, 0, 0, h, 0, 0).Add_Do("Im1")
+	} else {
+		r, g, b := stampDef.Color.R(), stampDef.Color.G(), stampDef.Color.B()
+
+		// Rounded frame.
+		lineWidth := math.Min(h*0.08, 3)
+		radius := math.Min(h, w) * 0.15
+		x0, y0 := lineWidth/2, lineWidth/2
+		x1, y1 := w-lineWidth/2, h-lineWidth/2
+		k := 0.5523 * radius
+		creator.
+			Add_RG(r, g, b).
+			Add_rg(r, g, b).
+			Add_w(lineWidth).
+			Add_m(x0+radius, y0).
+			Add_l(x1-radius, y0).
+			Add_c(x1-radius+k, y0, x1, y0+radius-k, x1, y0+radius).
+			Add_l(x1, y1-radius).
+			Add_c(x1, y1-radius+k, x1-radius+k, y1, x1-radius, y1).
+			Add_l(x0+radius, y1).
+			Add_c(x0+radius-k, y1, x0, y1-radius+k, x0, y1-radius).
+			Add_l(x0, y0+radius).
+			Add_c(x0, y0+radius-k, x0+radius-k, y0, x0+radius, y0).
+			Add_h().
+			Add_S()
+
+		// Label scaled to fit within the frame.
+		label := getStampLabel(stampDef.Name)
+		font := fonts.NewFontHelveticaBold()
+		encoder := textencoding.NewWinAnsiTextEncoder()
+		err := resources.SetFontByName("HeBo", font.ToPdfObject())
+		if err != nil {
+			return nil, err
+		}
+		padding := 2*lineWidth + radius/2
+		fontSize := h * 0.6
+		if width := getFreeTextWidth(label, font, encoder, fontSize); width > w-2*padding && width > 0 {
+			fontSize *= (w - 2*padding) / width
+		}
+		width := getFreeTextWidth(label, font, encoder, fontSize)
+		// Center vertically on the cap height of Helvetica-Bold (0.718 em).
+		creator.
+			Add_BT().
+			Add_Tf("HeBo", fontSize).
+			Add_Td((w-width)/2, (h-fontSize*0.718)/2).
+			Add_Tj(pdfcore.PdfObjectString(encoder.Encode(label))).
+			Add_ET()
+	}
+	creator.Add_Q()
+
+	bbox := &pdf.PdfRectangle{Llx: 0, Lly: 0, Urx: w, Ury: h}
+	return makeAppearanceDict(creator.Bytes(), bbox, resources)
+}
+
+// Gets the label of a stamp shown in the appearance, e.g. NOT APPROVED for NotApproved.
+func getStampLabel(name StampName) string {
+	label := []rune{}
+	for idx, r := range string(name) {
+		if idx > 0 && unicode.IsUpper(r) {
+			label = append(label, ' ')
+		}
+		label = append(label, r)
+	}
+	return strings.ToUpper(string(label))
+}
@@ -0,0 +1,186 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"errors"
+	"math"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/contentstream/draw"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// The type of a text markup annotation.
+type TextMarkupType int
+
+const (
+	TextMarkupHighlight TextMarkupType = iota
+	TextMarkupUnderline
+	TextMarkupSquiggly
+	TextMarkupStrikeOut
+)
+
+// Defines a text markup annotation (highlight, underline, squiggly or strike-out) over one or more
+// quadrilaterals of text.  Each quadrilateral is specified by 8 numbers in QuadPoints: the upper left, upper
+// right, lower left and lower right corners (x1,y1 ... x4,y4) of the marked text, as written by most viewers.
+// The quadrilaterals can be rotated for text that is not horizontal.
+type TextMarkupAnnotationDef struct {
+	MarkupType TextMarkupType
+	QuadPoints []float64
+	Color      *pdf.PdfColorDeviceRGB
+	Opacity    float64 // Alpha value (0-1).
+}
+
+// Creates a text markup annotation object that can be added to page PDF annotations.
+func CreateTextMarkupAnnotation(markupDef TextMarkupAnnotationDef) (*pdf.PdfAnnotation, error) {
+	if len(markupDef.QuadPoints) == 0 || len(markupDef.QuadPoints)%8 != 0 {
+		return nil, errors.New("QuadPoints should contain 8 numbers per quadrilateral")
+	}
+	quadPoints := pdfcore.MakeArrayFromFloats(markupDef.QuadPoints)
+
+	var annotation *pdf.PdfAnnotation
+	var markup *pdf.PdfAnnotationMarkup
+	switch markupDef.MarkupType {
+	case TextMarkupHighlight:
+		highlight := pdf.NewPdfAnnotationHighlight()
+		highlight.QuadPoints = quadPoints
+		annotation, markup = highlight.PdfAnnotation, highlight.PdfAnnotationMarkup
+	case TextMarkupUnderline:
+		underline := pdf.NewPdfAnnotationUnderline()
+		underline.QuadPoints = quadPoints
+		annotation, markup = underline.PdfAnnotation, underline.PdfAnnotationMarkup
+	case TextMarkupSquiggly:
+		squiggly := pdf.NewPdfAnnotationSquiggly()
+		squiggly.QuadPoints = quadPoints
+		annotation, markup = squiggly.PdfAnnotation, squiggly.PdfAnnotationMarkup
+	case TextMarkupStrikeOut:
+		strikeOut := pdf.NewPdfAnnotationStrikeOut()
+		strikeOut.QuadPoints = quadPoints
+		annotation, markup = strikeOut.PdfAnnotation, strikeOut.PdfAnnotationMarkup
+	default:
+		return nil, errors.New("Unsupported text markup type")
+	}
+
+	r, g, b := markupDef.Color.R(), markupDef.Color.G(), markupDef.Color.B()
+	annotation.C = pdfcore.MakeArrayFromFloats([]float64{r, g, b})
+
+	// Opacity.
+	if markupDef.Opacity < 1.0 {
+		markup.CA = pdfcore.MakeFloat(markupDef.Opacity)
+	}
+
+	// Make the appearance stream (for uniform appearance).
+	apDict, bbox, err := makeTextMarkupAnnotationAppearanceStream(markupDef)
+	if err != nil {
+		return nil, err
+	}
+	annotation.AP = apDict
+	annotation.Rect = pdfcore.MakeArrayFromFloats([]float64{bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury})
+
+	return annotation, nil
+}
+
+func makeTextMarkupAnnotationAppearanceStream(markupDef TextMarkupAnnotationDef) (*pdfcore.PdfObjectDictionary, *pdf.PdfRectangle, error) {
+	resources := pdf.NewPdfPageResources()
+
+	// Highlights are multiplied with the underlying text so that it stays legible.
+	blendMode := ""
+	if markupDef.MarkupType == TextMarkupHighlight {
+		blendMode = "Multiply"
+	}
+	gsName, err := addAppearanceExtGState(resources, markupDef.Opacity, blendMode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, g, b := markupDef.Color.R(), markupDef.Color.G(), markupDef.Color.B()
+	creator := pdfcontent.NewContentCreator()
+	creator.Add_q().Add_rg(r, g, b).Add_RG(r, g, b)
+	if len(gsName) > 0 {
+		creator.Add_gs(pdfcore.PdfObjectName(gsName))
+	}
+
+	points := []draw.Point{}
+	for i := 0; i < len(markupDef.QuadPoints); i += 8 {
+		q := markupDef.QuadPoints[i : i+8]
+		ul, ur := draw.NewPoint(q[0], q[1]), draw.NewPoint(q[2], q[3])
+		ll, lr := draw.NewPoint(q[4], q[5]), draw.NewPoint(q[6], q[7])
+		points = append(points, ul, ur, ll, lr)
+		drawTextMarkupQuad(creator, markupDef.MarkupType, ul, ur, ll, lr)
+	}
+	creator.Add_Q()
+
+	// Keep the strokes along the edges within the bounding box.
+	bbox := getPointsBbox(points, 1)
+	apDict, err := makeAppearanceDict(creator.Bytes(), bbox, resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return apDict, bbox, nil
+}
+
+// Draws the markup of a single quadrilateral with upper left, upper right, lower left and lower right corners.
+func drawTextMarkupQuad(creator *pdfcontent.ContentCreator, markupType TextMarkupType, ul, ur, ll, lr draw.Point) {
+	height := draw.NewVectorBetween(ll, ul).Magnitude()
+	if height == 0 {
+		return
+	}
+	up := draw.NewVectorBetween(ll, ul)
+	lineWidth := math.Max(height/14, 0.5)
+
+	switch markupType {
+	case TextMarkupHighlight:
+		path := draw.NewPath()
+		path = path.AppendPoint(ul)
+		path = path.AppendPoint(ur)
+		path = path.AppendPoint(lr)
+		path = path.AppendPoint(ll)
+		draw.DrawPathWithCreator(path, creator)
+		creator.Add_h().Add_f()
+	case TextMarkupUnderline:
+		offset := up.Scale(lineWidth / height)
+		creator.Add_w(lineWidth)
+		path := draw.NewPath()
+		path = path.AppendPoint(ll.AddVector(offset))
+		path = path.AppendPoint(lr.AddVector(offset))
+		draw.DrawPathWithCreator(path, creator)
+		creator.Add_S()
+	case TextMarkupStrikeOut:
+		offset := up.Scale(0.5)
+		creator.Add_w(lineWidth)
+		path := draw.NewPath()
+		path = path.AppendPoint(ll.AddVector(offset))
+		path = path.AppendPoint(lr.AddVector(offset))
+		draw.DrawPathWithCreator(path, creator)
+		creator.Add_S()
+	case TextMarkupSquiggly:
+		// Zigzag along the bottom of the quadrilateral with a period of half the text height.
+		base := draw.NewVectorBetween(ll, lr)
+		length := base.Magnitude()
+		step := height / 4
+		amplitude := up.Scale(height / 8 / height)
+		start := ll.AddVector(up.Scale(lineWidth / height))
+
+		creator.Add_w(lineWidth).Add_j("1")
+		path := draw.NewPath()
+		n := int(math.Ceil(length / step))
+		for i := 0; i <= n; i++ {
+			along := math.Min(float64(i)*step, length)
+			p := start
+			if length > 0 {
+				p = start.AddVector(base.Scale(along / length))
+			}
+			if i%2 == 1 {
+				p = p.AddVector(amplitude)
+			}
+			path = path.AppendPoint(p)
+		}
+		draw.DrawPathWithCreator(path, creator)
+		creator.Add_S()
+	}
+}
@@ -0,0 +1,150 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// A comment thread: a markup annotation with the replies to it (IRT), the annotations grouped with it
+// (RT Group) and the state annotations recording its review or marked state.
+type CommentThread struct {
+	Annotation *pdf.PdfAnnotation
+	Author     string
+	Contents   string
+	Replies    []*CommentThread
+	Group      []*pdf.PdfAnnotation
+	States     []*pdf.PdfAnnotation
+}
+
+// Gets the comment threads of the markup annotations on a page in the order of the page annotations.  Replies
+// are nested under the annotation they reply to, popups and non-markup annotations are not included.
+func GetCommentThreads(page *pdf.PdfPage) []*CommentThread {
+	threads := map[pdfcore.PdfObject]*CommentThread{}
+	parents := map[*CommentThread]*CommentThread{}
+	order := []*CommentThread{}
+
+	for _, annot := range page.Annotations {
+		markup := getMarkup(annot)
+		if markup == nil {
+			continue
+		}
+		thread := &CommentThread{
+			Annotation: annot,
+			Author:     getTextString(markup.T),
+			Contents:   getTextString(annot.Contents),
+		}
+		threads[annot.GetContainingPdfObject()] = thread
+		order = append(order, thread)
+	}
+
+	roots := []*CommentThread{}
+	for _, thread := range order {
+		markup := getMarkup(thread.Annotation)
+		parent, has := threads[markup.IRT]
+		if markup.IRT == nil || !has || isThreadAncestor(parents, thread, parent) {
+			roots = append(roots, thread)
+			continue
+		}
+
+		if text, isText := thread.Annotation.GetContext().(*pdf.PdfAnnotationText); isText && text.State != nil {
+			parent.States = append(parent.States, thread.Annotation)
+			continue
+		}
+		if rt, isName := pdfcore.TraceToDirectObject(markup.RT).(*pdfcore.PdfObjectName); isName && *rt == "Group" {
+			parent.Group = append(parent.Group, thread.Annotation)
+			continue
+		}
+		parent.Replies = append(parent.Replies, thread)
+		parents[thread] = parent
+	}
+
+	return roots
+}
+
+// Gets the current state of the comment in the state model, i.e. the state of its last state annotation in
+// the model.  Returns an empty state if none has been set.
+func (thread *CommentThread) GetState(stateModel NoteStateModel) NoteState {
+	state := NoteState("")
+	for _, annot := range thread.States {
+		text, isText := annot.GetContext().(*pdf.PdfAnnotationText)
+		if !isText {
+			continue
+		}
+		// The state model defaults to Marked if only the state is specified.
+		model := NoteStateModelMarked
+		if text.StateModel != nil {
+			model = NoteStateModel(getTextString(text.StateModel))
+		}
+		if model == stateModel {
+			state = NoteState(getTextString(text.State))
+		}
+	}
+	return state
+}
+
+// Checks whether `ancestor` is `thread` or one of the threads it is a reply to.
+func isThreadAncestor(parents map[*CommentThread]*CommentThread, ancestor, thread *CommentThread) bool {
+	for thread != nil {
+		if thread == ancestor {
+			return true
+		}
+		thread = parents[thread]
+	}
+	return false
+}
+
+// Gets the markup annotation fields of an annotation or nil if it is not a markup annotation.
+func getMarkup(annotation *pdf.PdfAnnotation) *pdf.PdfAnnotationMarkup {
+	switch t := annotation.GetContext().(type) {
+	case *pdf.PdfAnnotationText:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationFreeText:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationLine:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationSquare:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationCircle:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationPolygon:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationPolyLine:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationHighlight:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationUnderline:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationSquiggly:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationStrikeOut:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationCaret:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationStamp:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationInk:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationFileAttachment:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationSound:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationProjection:
+		return t.PdfAnnotationMarkup
+	case *pdf.PdfAnnotationRedact:
+		return t.PdfAnnotationMarkup
+	}
+	return nil
+}
+
+// Gets the value of a text string object as UTF-8 or an empty string if not a string.
+func getTextString(obj pdfcore.PdfObject) string {
+	if str, isString := pdfcore.TraceToDirectObject(obj).(*pdfcore.PdfObjectString); isString {
+		return pdf.DecodeTextString(string(*str))
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package annotator
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+func TestCommentThreads(t *testing.T) {
+	page := pdf.NewPdfPage()
+	page.MediaBox = &pdf.PdfRectangle{Llx: 0, Lly: 0, Urx: 200, Ury: 200}
+	page.Resources = pdf.NewPdfPageResources()
+
+	createNote := func(noteDef NoteAnnotationDef) *pdf.PdfAnnotation {
+		note, err := CreateNoteAnnotation(noteDef)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		AddAnnotationToPage(page, note)
+		return note
+	}
+	addState := func(annotation *pdf.PdfAnnotation, stateModel NoteStateModel, state NoteState, date time.Time) {
+		stateAnnotation, err := CreateStateAnnotation(annotation, StateAnnotationDef{
+			Author:     "Reviewer",
+			StateModel: stateModel,
+			State:      state,
+			Date:       date,
+		})
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		AddAnnotationToPage(page, stateAnnotation)
+	}
+
+	date := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	comment := createNote(NoteAnnotationDef{X: 10, Y: 10, Contents: "Comment", Author: "Alice",
+		PopupRect: &pdf.PdfRectangle{Llx: 40, Lly: 10, Urx: 140, Ury: 60}})
+	reply := createNote(NoteAnnotationDef{X: 10, Y: 10, Contents: "Réponse ✓", Author: "Zoë", Subject: "Überprüfung",
+		InReplyTo: comment})
+	createNote(NoteAnnotationDef{X: 10, Y: 10, Contents: "Reply to reply", Author: "Alice", InReplyTo: reply})
+	createNote(NoteAnnotationDef{X: 10, Y: 10, Contents: "Grouped", InReplyTo: comment, ReplyType: ReplyTypeGroup})
+	addState(comment, NoteStateModelReview, NoteStateAccepted, date)
+	addState(comment, NoteStateModelReview, NoteStateRejected, date.Add(time.Hour))
+	addState(reply, NoteStateModelMarked, NoteStateMarked, date)
+
+	// Notes in reply to each other.
+	first := createNote(NoteAnnotationDef{X: 100, Y: 100, Contents: "First"})
+	second := createNote(NoteAnnotationDef{X: 100, Y: 100, Contents: "Second", InReplyTo: first})
+	first.GetContext().(*pdf.PdfAnnotationText).IRT = second.GetContainingPdfObject()
+
+	writer := pdf.NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err := os.Create("/tmp/annotator_threads.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	f, err = os.Open("/tmp/annotator_threads.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	reader, err := pdf.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	outPage, err := reader.GetPage(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	threads := GetCommentThreads(outPage)
+	if len(threads) != 2 {
+		t.Fatalf("Invalid number of threads: %d", len(threads))
+	}
+
+	thread := threads[0]
+	if thread.Author != "Alice" || thread.Contents != "Comment" {
+		t.Errorf("Invalid comment: %s %s", thread.Author, thread.Contents)
+	}
+	if len(thread.Replies) != 1 || thread.Replies[0].Contents != "Réponse ✓" || thread.Replies[0].Author != "Zoë" {
+		t.Fatalf("Invalid replies: %+v", thread.Replies)
+	}
+	if subject := getTextString(getMarkup(thread.Replies[0].Annotation).Subj); subject != "Überprüfung" {
+		t.Errorf("Invalid subject of reply: %q", subject)
+	}
+	if replies := thread.Replies[0].Replies; len(replies) != 1 || replies[0].Contents != "Reply to reply" {
+		t.Errorf("Invalid nested replies: %+v", replies)
+	}
+	if len(thread.Group) != 1 || len(thread.States) != 2 {
+		t.Errorf("Invalid group %d or states %d", len(thread.Group), len(thread.States))
+	}
+	if state := thread.GetState(NoteStateModelReview); state != NoteStateRejected {
+		t.Errorf("Invalid review state: %q", state)
+	}
+	if state := thread.GetState(NoteStateModelMarked); state != "" {
+		t.Errorf("Invalid marked state: %q", state)
+	}
+	if state := thread.Replies[0].GetState(NoteStateModelMarked); state != NoteStateMarked {
+		t.Errorf("Invalid marked state of reply: %q", state)
+	}
+
+	// The cycle is broken at the note replying to a note in reply to it.
+	thread = threads[1]
+	if thread.Contents != "Second" || len(thread.Replies) != 1 || thread.Replies[0].Contents != "First" ||
+		len(thread.Replies[0].Replies) != 0 {
+		t.Errorf("Invalid thread of notes in reply to each other: %+v", thread)
+	}
+}
@@ -616,3 +616,32 @@ func (this *ContentCreator) Add_TJ(vals ...PdfObject) *ContentCreator {
 	this.operands = append(this.operands, &op)
 	return this
 }
+
+/* Marked content operators. */
+
+// BMC: Begin a marked-content sequence with tag `tag`, terminated by EMC.
+func (this *ContentCreator) Add_BMC(tag PdfObjectName) *ContentCreator {
+	op := ContentStreamOperation{}
+	op.Operand = "BMC"
+	op.Params = makeParamsFromNames([]PdfObjectName{tag})
+	this.operands = append(this.operands, &op)
+	return this
+}
+
+// BDC: Begin a marked-content sequence with tag `tag` and property list `properties`, either a name of a
+// property list in the Properties resources or an inline dictionary.  Terminated by EMC.
+func (this *ContentCreator) Add_BDC(tag PdfObjectName, properties PdfObject) *ContentCreator {
+	op := ContentStreamOperation{}
+	op.Operand = "BDC"
+	op.Params = append(makeParamsFromNames([]PdfObjectName{tag}), properties)
+	this.operands = append(this.operands, &op)
+	return this
+}
+
+// EMC: End a marked-content sequence.
+func (this *ContentCreator) Add_EMC() *ContentCreator {
+	op := ContentStreamOperation{}
+	op.Operand = "EMC"
+	this.operands = append(this.operands, &op)
+	return this
+}
@@ -0,0 +1,106 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/core"
+)
+
+// Matrix is a transformation matrix [a b c d e f] as used by the cm and Tm operators.  It maps a point (x, y) to
+// (a*x + c*y + e, b*x + d*y + f).
+type Matrix [6]float64
+
+// IdentityMatrix returns the matrix which leaves points unchanged.
+func IdentityMatrix() Matrix {
+	return Matrix{1, 0, 0, 1, 0, 0}
+}
+
+// NewMatrix returns the matrix [a b c d e f].
+func NewMatrix(a, b, c, d, e, f float64) Matrix {
+	return Matrix{a, b, c, d, e, f}
+}
+
+// TranslationMatrix returns the matrix translating points by (tx, ty).
+func TranslationMatrix(tx, ty float64) Matrix {
+	return Matrix{1, 0, 0, 1, tx, ty}
+}
+
+// NewMatrixFromPdfObjects returns the matrix given by six numbers, such as the operands of cm or the Matrix entry
+// of a form XObject.
+func NewMatrixFromPdfObjects(objs []core.PdfObject) (Matrix, error) {
+	if len(objs) != 6 {
+		return IdentityMatrix(), errors.New("Invalid number of matrix elements")
+	}
+	arr := core.PdfObjectArray(objs)
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return IdentityMatrix(), err
+	}
+	return Matrix{vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]}, nil
+}
+
+// Mult returns the matrix which applies `m` first and `other` next, i.e. the product m x other.  The CTM after
+// "a b c d e f cm" is NewMatrix(a, b, c, d, e, f).Mult(ctm).
+func (m Matrix) Mult(other Matrix) Matrix {
+	return Matrix{
+		m[0]*other[0] + m[1]*other[2],
+		m[0]*other[1] + m[1]*other[3],
+		m[2]*other[0] + m[3]*other[2],
+		m[2]*other[1] + m[3]*other[3],
+		m[4]*other[0] + m[5]*other[2] + other[4],
+		m[4]*other[1] + m[5]*other[3] + other[5],
+	}
+}
+
+// Transform returns the point (x, y) transformed by the matrix.
+func (m Matrix) Transform(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// Inverse returns the inverse of the matrix.  The bool flag is false if the matrix is not invertible.
+func (m Matrix) Inverse() (Matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if math.Abs(det) < 1e-12 {
+		return IdentityMatrix(), false
+	}
+	return Matrix{
+		m[3] / det,
+		-m[1] / det,
+		-m[2] / det,
+		m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// ScalingFactor returns the factor by which the matrix scales lengths on average, i.e. the square root of the
+// absolute value of its determinant.
+func (m Matrix) ScalingFactor() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// TransformRectangle returns the bounding box (llx, lly, urx, ury) of the rectangle with corners (llx, lly) and
+// (urx, ury) transformed by the matrix.
+func (m Matrix) TransformRectangle(llx, lly, urx, ury float64) (float64, float64, float64, float64) {
+	xs := [4]float64{}
+	ys := [4]float64{}
+	xs[0], ys[0] = m.Transform(llx, lly)
+	xs[1], ys[1] = m.Transform(urx, lly)
+	xs[2], ys[2] = m.Transform(urx, ury)
+	xs[3], ys[3] = m.Transform(llx, ury)
+
+	minX, minY, maxX, maxY := xs[0], ys[0], xs[0], ys[0]
+	for i := 1; i < 4; i++ {
+		minX = math.Min(minX, xs[i])
+		minY = math.Min(minY, ys[i])
+		maxX = math.Max(maxX, xs[i])
+		maxY = math.Max(maxY, ys[i])
+	}
+	return minX, minY, maxX, maxY
+}
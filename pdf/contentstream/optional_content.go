@@ -0,0 +1,147 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// OptionalContentSection is a marked-content section of a content stream that belongs to optional content,
+// i.e. the operations between "/OC /Name BDC" and the matching EMC.
+type OptionalContentSection struct {
+	// Name is the name of the property list in the Properties resources, empty for inline dictionaries.
+	Name core.PdfObjectName
+	// Group is the optional content group of the section, nil if the section belongs to a membership
+	// dictionary.
+	Group *model.PdfOptionalContentGroup
+	// Membership is the optional content membership dictionary of the section, nil if the section belongs
+	// to a group.
+	Membership *model.PdfOCMembership
+	// Start and End are the indices of the BDC and EMC operations of the section.
+	Start, End int
+	// Parent is the enclosing optional content section, nil for sections at top level.
+	Parent *OptionalContentSection
+}
+
+// Operations returns the operations of the section in `ops`, excluding the BDC and EMC operations.
+func (section *OptionalContentSection) Operations(ops ContentStreamOperations) ContentStreamOperations {
+	return ops[section.Start+1 : section.End]
+}
+
+// IsVisible returns true if the section is visible with the default configuration of optional content
+// properties `props`.  Sections inside hidden sections are hidden.
+func (section *OptionalContentSection) IsVisible(props *model.PdfOCProperties) bool {
+	for s := section; s != nil; s = s.Parent {
+		visible := true
+		if s.Group != nil {
+			visible = props.IsVisible(s.Group)
+		} else if s.Membership != nil {
+			visible = s.Membership.IsVisible(props.IsVisible)
+		}
+		if !visible {
+			return false
+		}
+	}
+	return true
+}
+
+// GetOptionalContentSections returns the optional content sections of content stream operations `ops`, in
+// order of their BDC operations, with the property lists of the sections looked up in `resources`.  If
+// `props` is not nil, the groups of the sections are those of the document's optional content properties.
+// Unterminated sections end at the end of `ops`.
+func GetOptionalContentSections(ops ContentStreamOperations, resources *model.PdfPageResources,
+	props *model.PdfOCProperties) ([]*OptionalContentSection, error) {
+	if props == nil {
+		props = model.NewPdfOCProperties()
+	}
+
+	var sections []*OptionalContentSection
+	// Stack of the open marked-content sections, nil for those that are not optional content.
+	var stack []*OptionalContentSection
+	for idx, op := range ops {
+		switch op.Operand {
+		case "BMC":
+			stack = append(stack, nil)
+		case "BDC":
+			section, err := newOptionalContentSection(op, resources, props)
+			if err != nil {
+				return nil, err
+			}
+			if section != nil {
+				section.Start = idx
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] != nil {
+						section.Parent = stack[i]
+						break
+					}
+				}
+				sections = append(sections, section)
+			}
+			stack = append(stack, section)
+		case "EMC":
+			if len(stack) == 0 {
+				common.Log.Debug("ERROR: EMC without matching BMC/BDC")
+				continue
+			}
+			if section := stack[len(stack)-1]; section != nil {
+				section.End = idx
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	for _, section := range stack {
+		if section != nil {
+			common.Log.Debug("ERROR: Unterminated optional content section")
+			section.End = len(ops)
+		}
+	}
+	return sections, nil
+}
+
+// newOptionalContentSection returns the optional content section started by BDC operation `op`, or nil if
+// the section is not optional content.
+func newOptionalContentSection(op *ContentStreamOperation, resources *model.PdfPageResources,
+	props *model.PdfOCProperties) (*OptionalContentSection, error) {
+	if len(op.Params) != 2 {
+		return nil, nil
+	}
+	if tag, ok := op.Params[0].(*core.PdfObjectName); !ok || *tag != "OC" {
+		return nil, nil
+	}
+
+	section := &OptionalContentSection{}
+	obj := op.Params[1]
+	if name, ok := obj.(*core.PdfObjectName); ok {
+		section.Name = *name
+		var found bool
+		if resources != nil {
+			obj, found = resources.GetPropertiesByName(*name)
+		}
+		if !found {
+			common.Log.Debug("ERROR: Optional content properties %s not found", *name)
+			return section, nil
+		}
+	}
+
+	dict, ok := core.TraceToDirectObject(obj).(*core.PdfObjectDictionary)
+	if !ok {
+		common.Log.Debug("ERROR: Invalid optional content properties (%T)", obj)
+		return section, nil
+	}
+	var err error
+	if t, ok := dict.Get("Type").(*core.PdfObjectName); ok && *t == "OCMD" {
+		section.Membership, err = props.GetMembership(obj)
+	} else {
+		section.Group, err = props.GetGroup(obj)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return section, nil
+}
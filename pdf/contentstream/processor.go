@@ -20,6 +20,9 @@ type GraphicsState struct {
 	ColorspaceNonStroking PdfColorspace
 	ColorStroking         PdfColor
 	ColorNonStroking      PdfColor
+	// CTM is the current transformation matrix relative to the coordinate system at the start of the content
+	// stream.
+	CTM Matrix
 }
 
 type GraphicStateStack []GraphicsState
@@ -44,6 +47,9 @@ type ContentStreamProcessor struct {
 
 	handlers     []HandlerEntry
 	currentIndex int
+
+	// initialState is the graphics state at the start of processing, nil for the default state.
+	initialState *GraphicsState
 }
 
 type HandlerFunc func(op *ContentStreamOperation, gs GraphicsState, resources *PdfPageResources) error
@@ -193,13 +199,25 @@ func (csp *ContentStreamProcessor) getInitialColor(cs PdfColorspace) (PdfColor,
 	return nil, errors.New("Unsupported colorspace")
 }
 
+// SetInitialGraphicsState sets the graphics state at the start of processing to `gs`, instead of the default
+// DeviceGray black colors and identity CTM.  Used for content streams that inherit the graphics state where they
+// are drawn, such as form XObjects, patterns and Type 3 glyphs.
+func (csp *ContentStreamProcessor) SetInitialGraphicsState(gs GraphicsState) {
+	csp.initialState = &gs
+}
+
 // Process the entire operations.
 func (this *ContentStreamProcessor) Process(resources *PdfPageResources) error {
 	// Initialize graphics state
-	this.graphicsState.ColorspaceStroking = NewPdfColorspaceDeviceGray()
-	this.graphicsState.ColorspaceNonStroking = NewPdfColorspaceDeviceGray()
-	this.graphicsState.ColorStroking = NewPdfColorDeviceGray(0)
-	this.graphicsState.ColorNonStroking = NewPdfColorDeviceGray(0)
+	if this.initialState != nil {
+		this.graphicsState = *this.initialState
+	} else {
+		this.graphicsState.ColorspaceStroking = NewPdfColorspaceDeviceGray()
+		this.graphicsState.ColorspaceNonStroking = NewPdfColorspaceDeviceGray()
+		this.graphicsState.ColorStroking = NewPdfColorDeviceGray(0)
+		this.graphicsState.ColorNonStroking = NewPdfColorDeviceGray(0)
+		this.graphicsState.CTM = IdentityMatrix()
+	}
 
 	for _, op := range this.operations {
 		var err error
@@ -209,7 +227,13 @@ func (this *ContentStreamProcessor) Process(resources *PdfPageResources) error {
 		case "q":
 			this.graphicsStack.Push(this.graphicsState)
 		case "Q":
+			if len(this.graphicsStack) == 0 {
+				common.Log.Debug("Q operand without matching q, skipping over")
+				break
+			}
 			this.graphicsState = this.graphicsStack.Pop()
+		case "cm":
+			this.handleCommand_cm(op)
 
 		// Color operations (Table 74 p. 179)
 		case "CS":
@@ -261,6 +285,17 @@ func (this *ContentStreamProcessor) Process(resources *PdfPageResources) error {
 	return nil
 }
 
+// cm: Concatenate the matrix to the current transformation matrix.  Invalid matrices are skipped.
+// a b c d e f cm
+func (csp *ContentStreamProcessor) handleCommand_cm(op *ContentStreamOperation) {
+	m, err := NewMatrixFromPdfObjects(op.Params)
+	if err != nil {
+		common.Log.Debug("Invalid cm command, skipping over: %v", err)
+		return
+	}
+	csp.graphicsState.CTM = m.Mult(csp.graphicsState.CTM)
+}
+
 // CS: Set the current color space for stroking operations.
 func (csp *ContentStreamProcessor) handleCommand_CS(op *ContentStreamOperation, resources *PdfPageResources) error {
 	if len(op.Params) < 1 {
@@ -41,6 +41,7 @@ type PdfParser struct {
 	reader           *bufio.Reader
 	fileSize         int64
 	xrefs            XrefTable
+	xrefOffset       int64 // Offset of the most recent xref section (startxref).
 	objstms          ObjectStreams
 	trailer          *PdfObjectDictionary
 	ObjCache         ObjectCache // TODO: Unexport (v3).
@@ -70,6 +71,17 @@ func (parser *PdfParser) GetTrailer() *PdfObjectDictionary {
 	return parser.trailer
 }
 
+// GetXrefOffset returns the offset of the most recent cross reference section, i.e. the value following the
+// last startxref keyword in the file.  Needed when appending incremental updates (Prev entry of the trailer).
+func (parser *PdfParser) GetXrefOffset() int64 {
+	return parser.xrefOffset
+}
+
+// GetFileSize returns the size of the underlying PDF file in bytes.
+func (parser *PdfParser) GetFileSize() int64 {
+	return parser.fileSize
+}
+
 // Skip over any spaces.
 func (parser *PdfParser) skipSpaces() (int, error) {
 	cnt := 0
@@ -1199,6 +1211,8 @@ func (parser *PdfParser) loadXrefs() (*PdfObjectDictionary, error) {
 			return nil, err
 		}
 	}
+	parser.xrefOffset = offsetXref
+
 	// Read the xref.
 	parser.rs.Seek(int64(offsetXref), io.SeekStart)
 	parser.reader = bufio.NewReader(parser.rs)
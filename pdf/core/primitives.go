@@ -528,7 +528,7 @@ func (ind *PdfIndirectObject) String() string {
 
 // DefaultWriteString outputs the object as it is to be written to file.
 func (ind *PdfIndirectObject) DefaultWriteString() string {
-	outStr := fmt.Sprintf("%d 0 R", (*ind).ObjectNumber)
+	outStr := fmt.Sprintf("%d %d R", (*ind).ObjectNumber, (*ind).GenerationNumber)
 	return outStr
 }
 
@@ -538,7 +538,7 @@ func (stream *PdfObjectStream) String() string {
 
 // DefaultWriteString outputs the object as it is to be written to file.
 func (stream *PdfObjectStream) DefaultWriteString() string {
-	outStr := fmt.Sprintf("%d 0 R", (*stream).ObjectNumber)
+	outStr := fmt.Sprintf("%d %d R", (*stream).ObjectNumber, (*stream).GenerationNumber)
 	return outStr
 }
 
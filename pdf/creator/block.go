@@ -7,7 +7,6 @@ package creator
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/unidoc/unidoc/common"
 	"github.com/unidoc/unidoc/pdf/contentstream"
@@ -39,6 +38,11 @@ type Block struct {
 
 	// Margins to be applied around the block when drawing on Page.
 	margins margins
+
+	// Logical structure of the contents for tagged output: the marked contents and the top-level structure
+	// elements.
+	marked   []*markedContent
+	elements []*model.PdfStructElement
 }
 
 // NewBlock creates a new Block with specified width and height.
@@ -108,6 +112,8 @@ func (blk *Block) duplicate() *Block {
 		dupContents = append(dupContents, op)
 	}
 	dup.contents = &dupContents
+	dup.marked = append([]*markedContent{}, blk.marked...)
+	dup.elements = append([]*model.PdfStructElement{}, blk.elements...)
 
 	return dup
 }
@@ -246,6 +252,22 @@ func (blk *Block) translate(tx, ty float64) {
 	blk.contents.WrapIfNeeded()
 }
 
+// setLayer marks the contents of the block as belonging to optional content group `layer`, with property
+// list name `name`.
+func (blk *Block) setLayer(name core.PdfObjectName, layer *model.PdfOptionalContentGroup) error {
+	err := blk.resources.SetPropertiesByName(name, layer.ToPdfObject())
+	if err != nil {
+		return err
+	}
+
+	ops := contentstream.NewContentCreator().
+		Add_BDC("OC", &name).
+		Operations()
+	*ops = append(*ops, *blk.contents...)
+	*blk.contents = append(*ops, *contentstream.NewContentCreator().Add_EMC().Operations()...)
+	return nil
+}
+
 // drawToPage draws the block on a PdfPage. Generates the content streams and appends to the PdfPage's content
 // stream and links needed resources.
 func (blk *Block) drawToPage(page *model.PdfPage) error {
@@ -306,6 +328,7 @@ func (blk *Block) Draw(d Drawable) error {
 		if err != nil {
 			return err
 		}
+		blk.mergeStructure(newBlock)
 	}
 
 	return nil
@@ -327,6 +350,7 @@ func (blk *Block) DrawWithContext(d Drawable, ctx DrawContext) error {
 		if err != nil {
 			return err
 		}
+		blk.mergeStructure(newBlock)
 	}
 
 	return nil
@@ -335,7 +359,11 @@ func (blk *Block) DrawWithContext(d Drawable, ctx DrawContext) error {
 // mergeBlocks appends another block onto the block.
 func (blk *Block) mergeBlocks(toAdd *Block) error {
 	err := mergeContents(blk.contents, blk.resources, toAdd.contents, toAdd.resources)
-	return err
+	if err != nil {
+		return err
+	}
+	blk.mergeStructure(toAdd)
+	return nil
 }
 
 // mergeContents merges contents and content streams.
@@ -346,7 +374,7 @@ func mergeContents(contents *contentstream.ContentStreamOperations, resources *m
 	// To properly add contents from a block, we need to handle the resources that the block is
 	// using and make sure it is accessible in the modified Page.
 	//
-	// Currently supporting: Font, XObject, Colormap, Pattern, Shading, GState resources
+	// Currently supporting: Font, XObject, Colormap, Pattern, Shading, GState, Properties resources
 	// from the block.
 	//
 
@@ -356,6 +384,7 @@ func mergeContents(contents *contentstream.ContentStreamOperations, resources *m
 	patternMap := map[core.PdfObjectName]core.PdfObjectName{}
 	shadingMap := map[core.PdfObjectName]core.PdfObjectName{}
 	gstateMap := map[core.PdfObjectName]core.PdfObjectName{}
+	propertiesMap := map[core.PdfObjectName]core.PdfObjectName{}
 
 	for _, op := range *contentsToAdd {
 		switch op.Operand {
@@ -522,18 +551,21 @@ func mergeContents(contents *contentstream.ContentStreamOperations, resources *m
 						gs, found := resourcesToAdd.GetExtGState(*name)
 						if found {
 							useName = *name
-							i := 1
-							for {
-								gs2, found := resources.GetExtGState(useName)
-								if !found || gs == gs2 {
-									break
+							if gs2, found := resources.GetExtGState(useName); found && gs != gs2 {
+								// Name in use by another graphics state.
+								extGState, err := model.NewPdfExtGStateFromObject(gs)
+								if err != nil {
+									return err
 								}
-								useName = core.PdfObjectName(fmt.Sprintf("GS%d", i))
-								i++
+								useName, err = resources.AddNewExtGState("GS", extGState)
+								if err != nil {
+									return err
+								}
+							} else {
+								resources.AddExtGState(useName, gs)
 							}
 						}
 
-						resources.AddExtGState(useName, gs)
 						gstateMap[*name] = useName
 					}
 
@@ -541,6 +573,37 @@ func mergeContents(contents *contentstream.ContentStreamOperations, resources *m
 					op.Params[0] = &useName
 				}
 			}
+		case "BDC":
+			// Marked-content property list, e.g. optional content.
+			if len(op.Params) == 2 {
+				if name, ok := op.Params[1].(*core.PdfObjectName); ok {
+					if _, processed := propertiesMap[*name]; !processed {
+						useName := *name
+						// Process if not already processed.
+						obj, found := resourcesToAdd.GetPropertiesByName(*name)
+						if found {
+							for {
+								obj2, found := resources.GetPropertiesByName(useName)
+								if !found || obj2 == obj {
+									break
+								}
+								useName = useName + "0"
+							}
+
+							err := resources.SetPropertiesByName(useName, obj)
+							if err != nil {
+								return err
+							}
+						} else {
+							common.Log.Debug("Properties %s not found", *name)
+						}
+						propertiesMap[*name] = useName
+					}
+
+					useName := propertiesMap[*name]
+					op.Params[1] = &useName
+				}
+			}
 		}
 
 		*contents = append(*contents, op)
@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/model"
 	"github.com/unidoc/unidoc/pdf/model/fonts"
 )
 
@@ -58,6 +59,7 @@ func (c *Creator) NewChapter(title string) *Chapter {
 	p := NewParagraph(heading)
 	p.SetFontSize(16)
 	p.SetFont(fonts.NewFontHelvetica()) // bold?
+	p.structType = model.StructTypeH1
 
 	chap.heading = p
 	chap.contents = []Drawable{}
@@ -115,7 +117,7 @@ func (chap *Chapter) Add(d Drawable) error {
 	case *Chapter:
 		common.Log.Debug("Error: Cannot add chapter to a chapter")
 		return errors.New("Type check error")
-	case *Paragraph, *Image, *Block, *Subchapter, *Table, *PageBreak:
+	case *Paragraph, *Image, *Block, *Subchapter, *Table, *List, *PageBreak:
 		chap.contents = append(chap.contents, d)
 	default:
 		common.Log.Debug("Unsupported: %T", d)
@@ -167,6 +169,8 @@ func (chap *Chapter) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 		ctx = c
 	}
 
+	groupStructure(blocks, model.NewPdfStructElement(model.StructTypeSect))
+
 	if chap.positioning.isRelative() {
 		// Move back X to same start of line.
 		ctx.X = origCtx.X
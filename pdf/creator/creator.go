@@ -7,10 +7,12 @@ package creator
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
 	"github.com/unidoc/unidoc/pdf/model"
 )
 
@@ -44,6 +46,25 @@ type Creator struct {
 
 	// Forms.
 	acroForm *model.PdfAcroForm
+
+	// Page labels.
+	pageLabels            []*model.PdfPageLabelRange
+	frontMatterLabelStyle *model.PageLabelStyle
+	frontMatterPages      int
+
+	// Optional content (layers).
+	ocProperties *model.PdfOCProperties
+
+	// Logical structure for tagged output.
+	tagged         bool
+	structTreeRoot *model.PdfStructTreeRoot
+	document       *model.PdfStructElement
+	mcids          map[*model.PdfPage]int64
+	structPages    map[*model.PdfStructElement]*model.PdfPage
+
+	// PDF/A conformance level of the output, and the embedded substitutes of non-embedded fonts by name.
+	pdfaConformance model.PdfAConformance
+	pdfaFonts       map[string]*model.PdfFont
 }
 
 // SetForms Add Acroforms to a PDF file.  Sets the specified form for writing.
@@ -52,6 +73,63 @@ func (c *Creator) SetForms(form *model.PdfAcroForm) error {
 	return nil
 }
 
+// SetPageLabels sets the page labels of the output to page labelling ranges `ranges`, with the page indices
+// of the output (including the front page and table of contents).
+// Example: label the first two pages with roman numerals, then number from 1 with a "p. " prefix:
+//
+// c.SetPageLabels([]*model.PdfPageLabelRange{
+//	model.NewPdfPageLabelRange(0, model.PageLabelStyleRomanLower),
+//	&model.PdfPageLabelRange{StartPage: 2, Style: model.PageLabelStyleDecimal, Prefix: "p. ", Start: 1},
+// })
+//
+func (c *Creator) SetPageLabels(ranges []*model.PdfPageLabelRange) {
+	c.pageLabels = ranges
+}
+
+// SetFrontMatterPageLabels labels the generated front page and table of contents pages with numbering
+// style `style` (e.g. roman numerals), and the following pages with decimal numbers starting from 1.
+// Ignored when page labels are set with SetPageLabels.
+func (c *Creator) SetFrontMatterPageLabels(style model.PageLabelStyle) {
+	c.frontMatterLabelStyle = &style
+}
+
+// getPageLabels returns the page labelling ranges of the output, or nil if not labelled.
+func (c *Creator) getPageLabels() []*model.PdfPageLabelRange {
+	if c.pageLabels != nil {
+		return c.pageLabels
+	}
+	if c.frontMatterLabelStyle == nil || c.frontMatterPages == 0 {
+		return nil
+	}
+	ranges := []*model.PdfPageLabelRange{model.NewPdfPageLabelRange(0, *c.frontMatterLabelStyle)}
+	if c.frontMatterPages < len(c.pages) {
+		ranges = append(ranges, model.NewPdfPageLabelRange(c.frontMatterPages, model.PageLabelStyleDecimal))
+	}
+	return ranges
+}
+
+// AddLayer adds an optional content group (layer) named `name` to the document, visible by default.
+// Drawables are placed into the layer with DrawInLayer.
+func (c *Creator) AddLayer(name string) *model.PdfOptionalContentGroup {
+	return c.GetOptionalContentProperties().AddGroup(name)
+}
+
+// GetOptionalContentProperties returns the optional content properties of the document: its layers and
+// their default visibility.
+// Example: add a layer that is only shown when printing:
+//
+// layer := c.AddLayer("Print only")
+// layer.ViewState = model.OCStateOff
+// layer.PrintState = model.OCStateOn
+// c.GetOptionalContentProperties().SetVisible(layer, false)
+//
+func (c *Creator) GetOptionalContentProperties() *model.PdfOCProperties {
+	if c.ocProperties == nil {
+		c.ocProperties = model.NewPdfOCProperties()
+	}
+	return c.ocProperties
+}
+
 // FrontpageFunctionArgs holds the input arguments to a front page drawing function.
 // It is designed as a struct, so additional parameters can be added in the future with backwards compatibility.
 type FrontpageFunctionArgs struct {
@@ -271,6 +349,7 @@ func (c *Creator) Context() DrawContext {
 // table of contents.
 func (c *Creator) finalize() error {
 	totPages := len(c.pages)
+	contentPages := len(c.pages)
 
 	// Estimate number of additional generated pages and update TOC.
 	genpages := 0
@@ -352,6 +431,8 @@ func (c *Creator) finalize() error {
 
 	}
 
+	c.frontMatterPages = len(c.pages) - contentPages
+
 	for idx, page := range c.pages {
 		c.setActivePage(page)
 		if c.drawHeaderFunc != nil {
@@ -364,6 +445,7 @@ func (c *Creator) finalize() error {
 				TotalPages: totPages,
 			}
 			c.drawHeaderFunc(headerBlock, args)
+			headerBlock.setArtifact("Header")
 			headerBlock.SetPos(0, 0)
 			err := c.Draw(headerBlock)
 			if err != nil {
@@ -382,6 +464,7 @@ func (c *Creator) finalize() error {
 				TotalPages: totPages,
 			}
 			c.drawFooterFunc(footerBlock, args)
+			footerBlock.setArtifact("Footer")
 			footerBlock.SetPos(0, c.pageHeight-footerBlock.height)
 			err := c.Draw(footerBlock)
 			if err != nil {
@@ -425,6 +508,17 @@ func (c *Creator) MoveDown(dy float64) {
 // Draw draws the Drawable widget to the document.  This can span over 1 or more pages. Additional pages are added if
 // the contents go over the current Page.
 func (c *Creator) Draw(d Drawable) error {
+	return c.draw(d, nil)
+}
+
+// DrawInLayer draws the Drawable widget to the document like Draw, with the contents belonging to
+// optional content group `layer`, which is shown or hidden with the layer.
+func (c *Creator) DrawInLayer(d Drawable, layer *model.PdfOptionalContentGroup) error {
+	return c.draw(d, layer)
+}
+
+// draw draws the Drawable widget to the document, into optional content group `layer` if not nil.
+func (c *Creator) draw(d Drawable, layer *model.PdfOptionalContentGroup) error {
 	if c.getActivePage() == nil {
 		// Add a new Page if none added already.
 		c.NewPage()
@@ -440,7 +534,17 @@ func (c *Creator) Draw(d Drawable) error {
 			c.NewPage()
 		}
 
+		if layer != nil {
+			err := blk.setLayer(c.getLayerName(layer), layer)
+			if err != nil {
+				return err
+			}
+		}
+
 		p := c.getActivePage()
+		if c.tagged {
+			c.tagBlock(blk, p)
+		}
 		err := blk.drawToPage(p)
 		if err != nil {
 			return err
@@ -455,6 +559,20 @@ func (c *Creator) Draw(d Drawable) error {
 	return nil
 }
 
+// getLayerName returns the name of the property list of optional content group `layer` in the page
+// resources, and adds the group to the document if needed.
+func (c *Creator) getLayerName(layer *model.PdfOptionalContentGroup) core.PdfObjectName {
+	props := c.GetOptionalContentProperties()
+	for i, ocg := range props.OCGs {
+		if ocg == layer {
+			return core.PdfObjectName(fmt.Sprintf("OC%d", i+1))
+		}
+	}
+	props.OCGs = append(props.OCGs, layer)
+	props.D.Order = append(props.D.Order, &model.PdfOCOrderItem{Group: layer})
+	return core.PdfObjectName(fmt.Sprintf("OC%d", len(props.OCGs)))
+}
+
 // Write output of creator to io.WriteSeeker interface.
 func (c *Creator) Write(ws io.WriteSeeker) error {
 	if !c.finalized {
@@ -488,6 +606,35 @@ func (c *Creator) Write(ws io.WriteSeeker) error {
 		}
 	}
 
+	// Page labels.
+	if labels := c.getPageLabels(); labels != nil {
+		err := pdfWriter.SetPageLabelRanges(labels)
+		if err != nil {
+			common.Log.Debug("Failure: %v", err)
+			return err
+		}
+	}
+
+	// Logical structure.
+	if c.tagged {
+		c.sortStructure()
+		pdfWriter.SetStructTreeRoot(c.structTreeRoot)
+	}
+
+	// Optional content.
+	if c.ocProperties != nil {
+		err := pdfWriter.SetOptionalContentProperties(c.ocProperties)
+		if err != nil {
+			common.Log.Debug("Failure: %v", err)
+			return err
+		}
+	}
+
+	pdfWriter.SetPdfAConformance(c.pdfaConformance)
+	for name, font := range c.pdfaFonts {
+		pdfWriter.SetPdfAFontSubstitute(name, font)
+	}
+
 	err := pdfWriter.Write(ws)
 	if err != nil {
 		return err
@@ -496,6 +643,25 @@ func (c *Creator) Write(ws io.WriteSeeker) error {
 	return nil
 }
 
+// SetPdfAConformance sets the PDF/A conformance level of the output for archiving, e.g.
+// model.PdfAConformanceA2B.  All the fonts used must be embedded (e.g. loaded with
+// model.NewPdfFontFromTTFFile) or replaced by an embedded font set with SetPdfAFontSubstitute.  No font files
+// are bundled for the standard fonts and no substitute is set by default, so unless all text has embedded
+// fonts, a substitute for "Helvetica", the default font of paragraphs and headings, is required.  Otherwise Write fails with a *model.PdfAError listing the
+// violations of the conformance level, such as standard fonts that are not embedded or encryption.
+func (c *Creator) SetPdfAConformance(conformance model.PdfAConformance) {
+	c.pdfaConformance = conformance
+}
+
+// SetPdfAFontSubstitute sets embedded font `font` to replace the standard font named `name`, e.g.
+// "Helvetica", in PDF/A output.  See model.PdfWriter.SetPdfAFontSubstitute.
+func (c *Creator) SetPdfAFontSubstitute(name string, font *model.PdfFont) {
+	if c.pdfaFonts == nil {
+		c.pdfaFonts = map[string]*model.PdfFont{}
+	}
+	c.pdfaFonts[name] = font
+}
+
 // SetPdfWriterAccessFunc sets a PdfWriter access function/hook.
 // Exposes the PdfWriter just prior to writing the PDF.  Can be used to encrypt the output PDF, etc.
 //
@@ -14,16 +14,20 @@ import (
 	goimage "image"
 	"io/ioutil"
 	"math"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/boombuler/barcode"
 	"github.com/boombuler/barcode/qr"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
 	"github.com/unidoc/unidoc/pdf/core"
 	"github.com/unidoc/unidoc/pdf/model"
 	"github.com/unidoc/unidoc/pdf/model/fonts"
 	"github.com/unidoc/unidoc/pdf/model/textencoding"
+	"github.com/unidoc/unidoc/pdf/validator"
 )
 
 func init() {
@@ -1429,3 +1433,447 @@ func TestEncrypting1(t *testing.T) {
 		return
 	}
 }
+
+// Test page labels of the front matter (front page and table of contents) and content pages.
+func TestFrontMatterPageLabels(t *testing.T) {
+	c := New()
+	ch := c.NewChapter("Introduction")
+	ch.Add(NewParagraph("Contents"))
+	c.Draw(ch)
+	c.NewPage()
+	c.Draw(NewParagraph("Second page"))
+
+	c.CreateFrontPage(func(args FrontpageFunctionArgs) {
+		c.Draw(NewParagraph("Front page"))
+	})
+	c.CreateTableOfContents(func(toc *TableOfContents) (*Chapter, error) {
+		ch := c.NewChapter("Table of contents")
+		for _, entry := range toc.entries {
+			ch.Add(NewParagraph(fmt.Sprintf("%s %d", entry.Title, entry.PageNumber)))
+		}
+		return ch, nil
+	})
+	c.SetFrontMatterPageLabels(model.PageLabelStyleRomanLower)
+
+	err := c.WriteToFile("/tmp/creator_page_labels.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	f, err := os.Open("/tmp/creator_page_labels.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	labels, err := reader.GetPageLabels()
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	expected := []string{"i", "ii", "1", "2"}
+	if len(labels) != len(expected) {
+		t.Fatalf("Invalid page labels: %v", labels)
+	}
+	for i := range labels {
+		if labels[i] != expected[i] {
+			t.Fatalf("Invalid page labels: %v", labels)
+		}
+	}
+}
+
+// Graphics states of merged blocks are renamed when their names are taken by other graphics states.
+func TestBlockExtGStateNames(t *testing.T) {
+	blk := NewBlock(100, 100)
+	for _, opacity := range []float64{0.5, 0.25} {
+		toAdd := NewBlock(100, 100)
+		gs := model.NewPdfExtGState()
+		gs.SetOpacity(opacity)
+		if err := toAdd.resources.SetExtGStateByName("GS1", gs); err != nil {
+			t.Fatalf("Fail: %v\n", err)
+		}
+		if err := toAdd.addContentsByString("q /GS1 gs 0 0 10 10 re f Q"); err != nil {
+			t.Fatalf("Fail: %v\n", err)
+		}
+		if err := blk.Draw(toAdd); err != nil {
+			t.Fatalf("Fail: %v\n", err)
+		}
+	}
+
+	for name, opacity := range map[core.PdfObjectName]float64{"GS1": 0.5, "GS0": 0.25} {
+		gs, found := blk.resources.GetExtGStateByName(name)
+		if !found || gs.Ca == nil || *gs.Ca != opacity {
+			t.Errorf("Invalid graphics state %s: %+v", name, gs)
+		}
+	}
+	if ops := string(blk.contents.Bytes()); !strings.Contains(ops, "/GS1 gs") || !strings.Contains(ops, "/GS0 gs") {
+		t.Errorf("Invalid contents: %s", ops)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	c := New()
+	watermark := c.AddLayer("Watermark")
+	printOnly := c.AddLayer("Print only")
+	printOnly.ViewState = model.OCStateOff
+	printOnly.PrintState = model.OCStateOn
+	c.GetOptionalContentProperties().SetVisible(printOnly, false)
+
+	if err := c.Draw(NewParagraph("Always visible")); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if err := c.DrawInLayer(NewParagraph("DRAFT"), watermark); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if err := c.DrawInLayer(NewParagraph("Printed copy"), printOnly); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	err := c.WriteToFile("/tmp/creator_layers.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	f, err := os.Open("/tmp/creator_layers.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	props, err := reader.GetOptionalContentProperties()
+	if err != nil || props == nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if len(props.OCGs) != 2 || props.OCGs[0].Name != "Watermark" || props.OCGs[1].Name != "Print only" {
+		t.Fatalf("Invalid layers: %v", props.OCGs)
+	}
+
+	page, err := reader.GetPage(1)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	ops, err := contentstream.NewContentStreamParser(content).Parse()
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	sections, err := contentstream.GetOptionalContentSections(*ops, page.Resources, props)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if len(sections) != 2 || sections[0].Group != props.OCGs[0] || sections[1].Group != props.OCGs[1] {
+		t.Fatalf("Invalid sections: %v", sections)
+	}
+	if !sections[0].IsVisible(props) || sections[1].IsVisible(props) {
+		t.Errorf("Invalid visibility")
+	}
+	hasText := false
+	for _, op := range sections[0].Operations(*ops) {
+		if op.Operand == "Tj" || op.Operand == "TJ" {
+			hasText = true
+		}
+	}
+	if !hasText {
+		t.Errorf("Missing text in watermark layer")
+	}
+}
+
+func TestTaggedOutput(t *testing.T) {
+	c := New()
+	c.SetTagged(true)
+
+	ch := c.NewChapter("Introduction")
+	ch.Add(NewParagraph("First paragraph"))
+	sc := c.NewSubchapter(ch, "Background")
+	sc.Add(NewParagraph("Second paragraph"))
+
+	table := NewTable(2)
+	table.SetHeaderRows(1)
+	for _, text := range []string{"Name", "Value", "a", "1"} {
+		cell := table.NewCell()
+		cell.SetBorder(CellBorderStyleBox, 1)
+		cell.SetContent(NewParagraph(text))
+	}
+	ch.Add(table)
+
+	imgData, err := ioutil.ReadFile(testImageFile1)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	img, err := NewImageFromData(imgData)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	img.SetAltText("Company logo")
+	img.ScaleToWidth(100)
+	ch.Add(img)
+
+	if err := c.Draw(ch); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	c.DrawFooter(func(footer *Block, args FooterFunctionArgs) {
+		footer.Draw(NewParagraph(fmt.Sprintf("Page %d", args.PageNum)))
+	})
+
+	err = c.WriteToFile("/tmp/creator_tagged.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	f, err := os.Open("/tmp/creator_tagged.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if tagged, err := reader.IsTagged(); err != nil || !tagged {
+		t.Fatalf("Output not tagged (%v)", err)
+	}
+	root, err := reader.GetStructTreeRoot()
+	if err != nil || root == nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	var types []string
+	root.Walk(func(elem *model.PdfStructElement, depth int) bool {
+		types = append(types, fmt.Sprintf("%d:%s", depth, elem.S))
+		return true
+	})
+	expected := []string{"0:Document", "1:Sect", "2:H1", "2:P", "2:Sect", "3:H2", "3:P", "2:Table",
+		"3:TR", "4:TH", "5:P", "4:TH", "5:P", "3:TR", "4:TD", "5:P", "4:TD", "5:P", "2:Figure"}
+	if len(types) != len(expected) {
+		t.Fatalf("Invalid structure: %v", types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Fatalf("Invalid structure: %v", types)
+		}
+	}
+
+	// The marked content of the page belongs to the structure elements, the footer is an artifact.
+	page := reader.PageList[0]
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	ops, err := contentstream.NewContentStreamParser(content).Parse()
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	numMarked, numArtifacts := 0, 0
+	for _, op := range *ops {
+		if op.Operand != "BDC" && op.Operand != "BMC" {
+			continue
+		}
+		tag := op.Params[0].(*core.PdfObjectName)
+		if *tag == "Artifact" {
+			numArtifacts++
+			continue
+		}
+		props, ok := op.Params[1].(*core.PdfObjectDictionary)
+		if !ok {
+			t.Fatalf("Invalid marked content: %v", op.Params)
+		}
+		mcid := props.Get("MCID").(*core.PdfObjectInteger)
+		elem, err := root.GetElementByMCID(page, int64(*mcid))
+		if err != nil || elem == nil || elem.S != string(*tag) {
+			t.Fatalf("Invalid element for MCID %d: %v (%v)", *mcid, elem, err)
+		}
+		numMarked++
+	}
+	if numMarked != 9 {
+		t.Errorf("Invalid number of marked contents: %d", numMarked)
+	}
+	// Footer and table borders.
+	if numArtifacts < 5 {
+		t.Errorf("Invalid number of artifacts: %d", numArtifacts)
+	}
+}
+
+func TestTaggedList(t *testing.T) {
+	c := New()
+	c.SetTagged(true)
+
+	list := NewList()
+	list.SetNumbered(true)
+	list.Add(NewParagraph("First item"))
+	list.Add(NewParagraph("Second item"))
+	for i := 0; i < 80; i++ {
+		list.Add(NewParagraph(fmt.Sprintf("Item %d", i+3)))
+	}
+	if err := c.Draw(list); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if len(c.pages) != 2 {
+		t.Errorf("Invalid number of pages: %d", len(c.pages))
+	}
+
+	err := c.WriteToFile("/tmp/creator_tagged_list.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	f, err := os.Open("/tmp/creator_tagged_list.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	root, err := reader.GetStructTreeRoot()
+	if err != nil || root == nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	var types []string
+	root.Walk(func(elem *model.PdfStructElement, depth int) bool {
+		types = append(types, fmt.Sprintf("%d:%s", depth, elem.S))
+		return true
+	})
+	// The list continued on the second page is a single L element.
+	expected := []string{"0:Document", "1:L", "2:LI", "3:Lbl", "3:LBody", "4:P", "2:LI", "3:Lbl", "3:LBody",
+		"4:P"}
+	if len(types) != len(expected)+80*4 {
+		t.Fatalf("Invalid structure: %v", types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Fatalf("Invalid structure: %v", types)
+		}
+	}
+}
+
+func TestPdfAConformance(t *testing.T) {
+	// Standard fonts are not embedded.
+	c := New()
+	c.SetPdfAConformance(model.PdfAConformanceA2B)
+	if err := c.Draw(NewParagraph("Archived")); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	err := c.WriteToFile("/tmp/creator_pdfa_invalid.pdf")
+	pdfaErr, ok := err.(*model.PdfAError)
+	if !ok {
+		t.Fatalf("Expected PDF/A error, got %v", err)
+	}
+	if len(pdfaErr.Violations) != 1 || pdfaErr.Violations[0] != "Font Helvetica is not embedded" {
+		t.Errorf("Invalid violations: %v", pdfaErr.Violations)
+	}
+
+	// The Helvetica default font of paragraphs, headings and the watermark of unlicensed copies is replaced
+	// by an embedded font.
+	roboto, err := model.NewPdfFontFromTTFFile(testRobotoRegularTTFFile)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	c = New()
+	c.SetPdfAConformance(model.PdfAConformanceA2B)
+	c.SetPdfAFontSubstitute("Helvetica", roboto)
+	ch := c.NewChapter("Archive")
+	ch.Add(NewParagraph("Archived"))
+	if err := c.Draw(ch); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	c.NewPage()
+	if err := c.Draw(NewParagraph("Second page")); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if err := c.WriteToFile("/tmp/creator_pdfa.pdf"); err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+
+	f, err := os.Open("/tmp/creator_pdfa.pdf")
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if numPages, err := reader.GetNumPages(); err != nil || numPages != 2 {
+		t.Errorf("Invalid number of pages: %d (%v)", numPages, err)
+	}
+	for i, page := range reader.PageList {
+		fonts, ok := core.TraceToDirectObject(page.Resources.Font).(*core.PdfObjectDictionary)
+		if !ok {
+			t.Fatalf("Missing fonts on page %d", i+1)
+		}
+		for _, name := range fonts.Keys() {
+			font, ok := core.TraceToDirectObject(fonts.Get(name)).(*core.PdfObjectDictionary)
+			if !ok {
+				t.Fatalf("Invalid font %s", name)
+			}
+			descriptor, ok := core.TraceToDirectObject(font.Get("FontDescriptor")).(*core.PdfObjectDictionary)
+			if !ok || descriptor.Get("FontFile2") == nil {
+				t.Errorf("Font %s not embedded on page %d", name, i+1)
+			}
+		}
+	}
+	xmp, err := reader.GetXmpMetadata()
+	if err != nil || xmp == nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if xmp.PdfAPart != 2 || xmp.PdfAConformance != "B" {
+		t.Errorf("Invalid PDF/A identification: %d %s", xmp.PdfAPart, xmp.PdfAConformance)
+	}
+	trailer, err := reader.GetTrailer()
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if ids, ok := core.TraceToDirectObject(trailer.Get("ID")).(*core.PdfObjectArray); !ok || len(*ids) != 2 {
+		t.Errorf("Missing document ID: %v", trailer.Get("ID"))
+	}
+	// resolve returns the object `obj` refers to.
+	resolve := func(obj core.PdfObject) core.PdfObject {
+		if ref, isRef := obj.(*core.PdfObjectReference); isRef {
+			obj, err = reader.GetIndirectObjectByNumber(int(ref.ObjectNumber))
+			if err != nil {
+				t.Fatalf("Fail: %v\n", err)
+			}
+		}
+		return core.TraceToDirectObject(obj)
+	}
+	catalog, ok := resolve(trailer.Get("Root")).(*core.PdfObjectDictionary)
+	if !ok {
+		t.Fatalf("Missing catalog")
+	}
+	outputIntents, ok := resolve(catalog.Get("OutputIntents")).(*core.PdfObjectArray)
+	if !ok || len(*outputIntents) != 1 {
+		t.Fatalf("Missing output intent: %v", catalog.Get("OutputIntents"))
+	}
+	outputIntent, ok := resolve((*outputIntents)[0]).(*core.PdfObjectDictionary)
+	if !ok {
+		t.Fatalf("Invalid output intent")
+	}
+	if s, ok := outputIntent.Get("S").(*core.PdfObjectName); !ok || *s != "GTS_PDFA1" {
+		t.Errorf("Invalid output intent subtype: %v", outputIntent.Get("S"))
+	}
+	if _, ok := resolve(outputIntent.Get("DestOutputProfile")).(*core.PdfObjectStream); !ok {
+		t.Errorf("Missing output profile")
+	}
+
+	v, err := validator.New(reader)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	violations, err := v.Validate(validator.ProfilePdfA2B)
+	if err != nil {
+		t.Fatalf("Fail: %v\n", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("PDF/A-2b violations: %v", violations)
+	}
+}
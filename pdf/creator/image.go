@@ -50,6 +50,9 @@ type Image struct {
 
 	// Encoder
 	encoder core.StreamEncoder
+
+	// Alternate description of the image in tagged output.
+	altText string
 }
 
 // NewImage create a new image from a unidoc image (model.Image).
@@ -129,6 +132,12 @@ func (img *Image) SetOpacity(opacity float64) {
 	img.opacity = opacity
 }
 
+// SetAltText sets the alternate description of the image, i.e. a textual description for accessibility in
+// tagged output (see Creator.SetTagged).
+func (img *Image) SetAltText(text string) {
+	img.altText = text
+}
+
 // SetMargins sets the margins for the Image (in relative mode): left, right, top, bottom.
 func (img *Image) SetMargins(left, right, top, bottom float64) {
 	img.margins.left = left
@@ -281,23 +290,14 @@ func drawImageOnBlock(blk *Block, img *Image, ctx DrawContext) (DrawContext, err
 		return ctx, err
 	}
 
-	// Find an available GS name.
-	i := 0
-	gsName := core.PdfObjectName(fmt.Sprintf("GS%d", i))
-	for blk.resources.HasExtGState(gsName) {
-		i++
-		gsName = core.PdfObjectName(fmt.Sprintf("GS%d", i))
-	}
-
 	// Graphics state with normal blend mode.
-	gs0 := core.MakeDict()
-	gs0.Set("BM", core.MakeName("Normal"))
+	gs0 := model.NewPdfExtGState()
+	gs0.BM = "Normal"
 	if img.opacity < 1.0 {
-		gs0.Set("CA", core.MakeFloat(img.opacity))
-		gs0.Set("ca", core.MakeFloat(img.opacity))
+		gs0.SetOpacity(img.opacity)
 	}
 
-	err = blk.resources.AddExtGState(gsName, core.MakeIndirectObject(gs0))
+	gsName, err := blk.resources.AddNewExtGState("GS", gs0)
 	if err != nil {
 		return ctx, err
 	}
@@ -327,6 +327,9 @@ func drawImageOnBlock(blk *Block, img *Image, ctx DrawContext) (DrawContext, err
 	ops.WrapIfNeeded()
 
 	blk.addContents(ops)
+	figure := model.NewPdfStructElement(model.StructTypeFigure)
+	figure.Alt = img.altText
+	blk.markContents(ops, figure)
 
 	if img.positioning.isRelative() {
 		ctx.Y += img.Height()
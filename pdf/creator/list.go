@@ -0,0 +1,165 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"fmt"
+
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// List is a component of list items, each a paragraph drawn after a bullet or number marker.  The list can
+// wrap across multiple pages, an item is not split up.  In tagged output the list is tagged as L, with an LI
+// element per item containing the marker (Lbl) and the item body (LBody).
+type List struct {
+	items []*Paragraph
+
+	// Numbered lists are marked "1.", "2.", ... instead of bullets.
+	numbered bool
+
+	// Width of the marker column, the item bodies are indented by it.
+	indent float64
+
+	// Margins to be applied around the list when drawing on Page.
+	margins margins
+}
+
+// NewList returns a new bulleted list with an indent of 15 points.
+func NewList() *List {
+	return &List{indent: 15}
+}
+
+// SetNumbered sets whether the items are numbered instead of bulleted.
+func (l *List) SetNumbered(numbered bool) {
+	l.numbered = numbered
+}
+
+// SetIndent sets the width of the marker column, by which the items are indented.
+func (l *List) SetIndent(indent float64) {
+	l.indent = indent
+}
+
+// SetMargins sets the margins of the list.
+func (l *List) SetMargins(left, right, top, bottom float64) {
+	l.margins.left = left
+	l.margins.right = right
+	l.margins.top = top
+	l.margins.bottom = bottom
+}
+
+// GetMargins returns the margins of the list: left, right, top, bottom.
+func (l *List) GetMargins() (float64, float64, float64, float64) {
+	return l.margins.left, l.margins.right, l.margins.top, l.margins.bottom
+}
+
+// Add adds an item with body `p` to the list.  The marker is drawn in the font, size and color of `p`.
+func (l *List) Add(p *Paragraph) {
+	l.items = append(l.items, p)
+}
+
+// marker returns the marker of item `i` with body `p`.
+func (l *List) marker(i int, p *Paragraph) *Paragraph {
+	text := "•"
+	if l.numbered {
+		text = fmt.Sprintf("%d.", i+1)
+	}
+	marker := NewParagraph(text)
+	marker.textFont = p.textFont
+	marker.encoder = p.encoder
+	marker.fontSize = p.fontSize
+	marker.lineHeight = p.lineHeight
+	marker.color = p.color
+	marker.SetEnableWrap(false)
+	marker.SetMargins(0, 0, p.margins.top, 0)
+	marker.structType = model.StructTypeLbl
+	return marker
+}
+
+// GeneratePageBlocks generates the page blocks.  Multiple blocks are generated if the items wrap over
+// multiple pages.  Implements the Drawable interface.
+func (l *List) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext, error) {
+	origCtx := ctx
+
+	ctx.X += l.margins.left
+	ctx.Y += l.margins.top
+	ctx.Width -= l.margins.left + l.margins.right
+	ctx.Height -= l.margins.top
+
+	listElem := model.NewPdfStructElement(model.StructTypeL)
+	blk := NewBlock(ctx.PageWidth, ctx.PageHeight)
+	blocks := []*Block{blk}
+
+	for i, body := range l.items {
+		bodyWidth := ctx.Width - l.indent - body.margins.left - body.margins.right
+		body.SetWidth(bodyWidth)
+		if body.Height()+body.margins.top+body.margins.bottom > ctx.Height {
+			// Keep the marker and the body together on a new page.
+			blk = NewBlock(ctx.PageWidth, ctx.PageHeight)
+			blocks = append(blocks, blk)
+			ctx.Page++
+			ctx.Y = ctx.Margins.top
+			ctx.Height = ctx.PageHeight - ctx.Margins.top - ctx.Margins.bottom
+		}
+
+		// The marker and the body are drawn on a block of their own, to group their structure elements into
+		// LI > Lbl, LBody.
+		itemBlk := NewBlock(ctx.PageWidth, ctx.PageHeight)
+		markerCtx := ctx
+		markerCtx.Width = l.indent
+		if err := itemBlk.DrawWithContext(l.marker(i, body), markerCtx); err != nil {
+			return nil, ctx, err
+		}
+		bodyCtx := ctx
+		bodyCtx.X += l.indent
+		bodyCtx.Width -= l.indent
+		bodyBlocks, newCtx, err := body.GeneratePageBlocks(bodyCtx)
+		if err != nil {
+			return nil, ctx, err
+		}
+		if err := itemBlk.mergeBlocks(bodyBlocks[0]); err != nil {
+			return nil, ctx, err
+		}
+		itemBlocks := append([]*Block{itemBlk}, bodyBlocks[1:]...)
+
+		itemElem := model.NewPdfStructElement(model.StructTypeLI)
+		bodyElem := model.NewPdfStructElement(model.StructTypeLBody)
+		for _, b := range itemBlocks {
+			for _, elem := range b.elements {
+				if elem.S == model.StructTypeLbl {
+					itemElem.AddKid(elem)
+				} else {
+					bodyElem.AddKid(elem)
+				}
+			}
+			b.elements = nil
+		}
+		itemElem.AddKid(bodyElem)
+		listElem.AddKid(itemElem)
+
+		if err := blk.mergeBlocks(itemBlk); err != nil {
+			return nil, ctx, err
+		}
+		for _, b := range itemBlocks[1:] {
+			// The body does not fit on a page.
+			blk = b
+			blocks = append(blocks, blk)
+		}
+
+		ctx.Page = newCtx.Page
+		ctx.Y = newCtx.Y
+		ctx.Height = newCtx.Height
+	}
+
+	for _, b := range blocks {
+		b.elements = []*model.PdfStructElement{listElem}
+	}
+
+	ctx.X = origCtx.X
+	ctx.Width = origCtx.Width
+	ctx.Y += l.margins.bottom
+	ctx.Height -= l.margins.bottom
+	return blocks, ctx, nil
+}
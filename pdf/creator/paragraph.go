@@ -68,6 +68,9 @@ type Paragraph struct {
 
 	// Text lines after wrapping to available width.
 	textLines []string
+
+	// Structure type in tagged output, e.g. H1 for headings.
+	structType string
 }
 
 // NewParagraph create a new text paragraph. Uses default parameters: Helvetica, WinAnsiEncoding and wrap enabled
@@ -91,6 +94,7 @@ func NewParagraph(text string) *Paragraph {
 	p.scaleY = 1
 
 	p.positioning = positionRelative
+	p.structType = model.StructTypeP
 
 	return p
 }
@@ -513,6 +517,7 @@ func drawParagraphOnBlock(blk *Block, p *Paragraph, ctx DrawContext) (DrawContex
 	ops.WrapIfNeeded()
 
 	blk.addContents(ops)
+	blk.markContents(ops, model.NewPdfStructElement(p.structType))
 
 	if p.positioning.isRelative() {
 		pHeight := p.Height() + p.margins.bottom
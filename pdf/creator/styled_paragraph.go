@@ -14,6 +14,7 @@ import (
 	"github.com/unidoc/unidoc/common"
 	"github.com/unidoc/unidoc/pdf/contentstream"
 	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
 	"github.com/unidoc/unidoc/pdf/model/textencoding"
 )
 
@@ -626,6 +627,7 @@ func drawStyledParagraphOnBlock(blk *Block, p *StyledParagraph, ctx DrawContext)
 	ops.WrapIfNeeded()
 
 	blk.addContents(ops)
+	blk.markContents(ops, model.NewPdfStructElement(model.StructTypeP))
 
 	if p.positioning.isRelative() {
 		pHeight := p.Height() + p.margins.bottom
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/model"
 	"github.com/unidoc/unidoc/pdf/model/fonts"
 )
 
@@ -57,6 +58,7 @@ func (c *Creator) NewSubchapter(ch *Chapter, title string) *Subchapter {
 
 	p.SetFontSize(14)
 	p.SetFont(fonts.NewFontHelvetica()) // bold?
+	p.structType = model.StructTypeH2
 
 	subchap.showNumbering = true
 	subchap.includeInTOC = true
@@ -119,12 +121,12 @@ func (subchap *Subchapter) GetMargins() (float64, float64, float64, float64) {
 }
 
 // Add adds a new Drawable to the chapter.
-// The currently supported Drawables are: *Paragraph, *Image, *Block, *Table.
+// The currently supported Drawables are: *Paragraph, *Image, *Block, *Table, *List.
 func (subchap *Subchapter) Add(d Drawable) {
 	switch d.(type) {
 	case *Chapter, *Subchapter:
 		common.Log.Debug("Error: Cannot add chapter or subchapter to a subchapter")
-	case *Paragraph, *Image, *Block, *Table, *PageBreak:
+	case *Paragraph, *Image, *Block, *Table, *List, *PageBreak:
 		subchap.contents = append(subchap.contents, d)
 	default:
 		common.Log.Debug("Unsupported: %T", d)
@@ -172,6 +174,8 @@ func (subchap *Subchapter) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawCo
 		ctx = c
 	}
 
+	groupStructure(blocks, model.NewPdfStructElement(model.StructTypeSect))
+
 	if subchap.positioning.isRelative() {
 		// Move back X to same start of line.
 		ctx.X = origCtx.X
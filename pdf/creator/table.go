@@ -9,6 +9,7 @@ import (
 	"errors"
 
 	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
 	"github.com/unidoc/unidoc/pdf/model"
 )
 
@@ -43,6 +44,9 @@ type Table struct {
 
 	// Margins to be applied around the block when drawing on Page.
 	margins margins
+
+	// Number of header rows, whose cells are header cells (TH) in tagged output.
+	headerRows int
 }
 
 // NewTable create a new Table with a specified number of columns.
@@ -85,6 +89,16 @@ func (table *Table) SetColumnWidths(widths ...float64) error {
 	return nil
 }
 
+// SetHeaderRows sets the number of header rows at the top of the table.  The cells of the header rows are
+// header cells (TH) in tagged output.
+func (table *Table) SetHeaderRows(rows int) error {
+	if rows < 0 {
+		return errors.New("Range check error")
+	}
+	table.headerRows = rows
+	return nil
+}
+
 // Height returns the total height of all rows.
 func (table *Table) Height() float64 {
 	sum := float64(0.0)
@@ -269,6 +283,10 @@ func (table *Table) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 
 	}
 
+	// Structure elements of the table, rows and cells for tagged output.
+	tableElem := model.NewPdfStructElement(model.StructTypeTable)
+	rowElems := map[int]*model.PdfStructElement{}
+
 	// Draw cells.
 	// row height, cell height
 	for _, cell := range table.cells {
@@ -316,6 +334,30 @@ func (table *Table) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 		ctx.X = ulX + xrel
 		ctx.Y = ulY + yrel
 
+		rowElem, has := rowElems[cell.row]
+		if !has {
+			rowElem = model.NewPdfStructElement(model.StructTypeTR)
+			tableElem.AddKid(rowElem)
+			rowElems[cell.row] = rowElem
+		}
+		cellElem := model.NewPdfStructElement(model.StructTypeTD)
+		attrs := core.MakeDict()
+		if cell.row <= table.headerRows {
+			cellElem.S = model.StructTypeTH
+			attrs.Set("Scope", core.MakeName("Column"))
+		}
+		if cell.rowspan > 1 {
+			attrs.Set("RowSpan", core.MakeInteger(int64(cell.rowspan)))
+		}
+		if cell.colspan > 1 {
+			attrs.Set("ColSpan", core.MakeInteger(int64(cell.colspan)))
+		}
+		if len(attrs.Keys()) > 0 {
+			attrs.Set("O", core.MakeName("Table"))
+			cellElem.Attributes = append(cellElem.Attributes, attrs)
+		}
+		rowElem.AddKid(cellElem)
+
 		if cell.backgroundColor != nil {
 			// Draw background (fill)
 			rect := NewRectangle(ctx.X, ctx.Y, w, h)
@@ -391,16 +433,26 @@ func (table *Table) GeneratePageBlocks(ctx DrawContext) ([]*Block, DrawContext,
 				}
 			}
 
+			numElems := len(block.elements)
 			err := block.DrawWithContext(cell.content, ctx)
 			if err != nil {
 				common.Log.Debug("Error: %v\n", err)
 			}
+			// The structure elements of the content are kids of the cell.
+			for _, elem := range block.elements[numElems:] {
+				cellElem.AddKid(elem)
+			}
+			block.elements = block.elements[:numElems]
 		}
 
 		ctx.Y += h
 	}
 	blocks = append(blocks, block)
 
+	for _, block := range blocks {
+		block.elements = []*model.PdfStructElement{tableElem}
+	}
+
 	if table.positioning.isAbsolute() {
 		return blocks, origCtx, nil
 	}
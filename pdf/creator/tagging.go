@@ -0,0 +1,238 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package creator
+
+import (
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// markedContent is a section of the contents of a block, from operation first to operation last, which is
+// the content of structure element elem in tagged output, or an artifact with properties artifact if elem
+// is nil.
+type markedContent struct {
+	elem        *model.PdfStructElement
+	artifact    *core.PdfObjectDictionary
+	first, last *contentstream.ContentStreamOperation
+}
+
+// paintingOperators are the operators that paint content.  Content not belonging to a structure element
+// is marked as an artifact in tagged output.
+var paintingOperators = map[string]bool{
+	"S": true, "s": true, "f": true, "F": true, "f*": true, "B": true, "B*": true, "b": true, "b*": true,
+	"sh": true, "Do": true, "BI": true, "Tj": true, "TJ": true, "'": true, "\"": true,
+}
+
+// markContents marks operations `ops` of the block as the content of structure element `elem`, which becomes
+// a top-level structure element of the block.
+func (blk *Block) markContents(ops *contentstream.ContentStreamOperations, elem *model.PdfStructElement) {
+	if len(*ops) == 0 {
+		return
+	}
+	blk.marked = append(blk.marked, &markedContent{elem: elem, first: (*ops)[0], last: (*ops)[len(*ops)-1]})
+	blk.addStructElement(elem)
+}
+
+// addStructElement adds `elem` to the top-level structure elements of the block if not already there.
+func (blk *Block) addStructElement(elem *model.PdfStructElement) {
+	for _, e := range blk.elements {
+		if e == elem {
+			return
+		}
+	}
+	blk.elements = append(blk.elements, elem)
+}
+
+// setArtifact marks all the contents of the block as a pagination artifact of subtype `subtype`, e.g.
+// "Header" or "Footer", replacing any structure of the contents.
+func (blk *Block) setArtifact(subtype string) {
+	blk.marked = nil
+	blk.elements = nil
+	if len(*blk.contents) == 0 {
+		return
+	}
+	props := core.MakeDict()
+	props.Set("Type", core.MakeName("Pagination"))
+	props.Set("Subtype", core.MakeName(subtype))
+	blk.marked = append(blk.marked, &markedContent{
+		artifact: props,
+		first:    (*blk.contents)[0],
+		last:     (*blk.contents)[len(*blk.contents)-1],
+	})
+}
+
+// mergeStructure adds the marked contents and top-level structure elements of `toAdd`, whose contents were
+// merged into the block.
+func (blk *Block) mergeStructure(toAdd *Block) {
+	blk.marked = append(blk.marked, toAdd.marked...)
+	for _, elem := range toAdd.elements {
+		blk.addStructElement(elem)
+	}
+}
+
+// groupStructure makes the top-level structure elements of `blocks` kids of `elem`, which becomes the
+// top-level structure element of the blocks.
+func groupStructure(blocks []*Block, elem *model.PdfStructElement) {
+	added := map[*model.PdfStructElement]bool{}
+	for _, blk := range blocks {
+		for _, kid := range blk.elements {
+			if !added[kid] {
+				elem.AddKid(kid)
+				added[kid] = true
+			}
+		}
+		blk.elements = []*model.PdfStructElement{elem}
+	}
+}
+
+// SetTagged enables tagged output (14.8): the logical structure of the contents drawn afterwards is written
+// for accessibility, with paragraphs (P), chapter and subchapter headings (H1, H2), tables (Table, TR, TH,
+// TD), lists (L, LI, Lbl, LBody) and images (Figure) in reading order.  Headers, footers and other content
+// such as table borders are marked as artifacts.
+func (c *Creator) SetTagged(tagged bool) {
+	c.tagged = tagged
+	if tagged && c.structTreeRoot == nil {
+		c.structTreeRoot = model.NewPdfStructTreeRoot()
+		c.document = model.NewPdfStructElement(model.StructTypeDocument)
+		c.structTreeRoot.K = append(c.structTreeRoot.K, c.document)
+		c.mcids = map[*model.PdfPage]int64{}
+		c.structPages = map[*model.PdfStructElement]*model.PdfPage{}
+	}
+}
+
+// GetStructTreeRoot returns the logical structure of the document, nil if not tagged.  The drawn contents
+// are added to the top-level Document element.
+func (c *Creator) GetStructTreeRoot() *model.PdfStructTreeRoot {
+	return c.structTreeRoot
+}
+
+// tagBlock marks the contents of block `blk` drawn on `page` with marked-content operators: the contents of
+// structure elements with their MCIDs, and the content that is not part of the structure as artifacts.
+// The top-level structure elements of the block are added to the document element.
+func (c *Creator) tagBlock(blk *Block, page *model.PdfPage) {
+	starts := map[*contentstream.ContentStreamOperation]*markedContent{}
+	for _, mc := range blk.marked {
+		if _, has := starts[mc.first]; !has {
+			starts[mc.first] = mc
+		}
+	}
+
+	ops := contentstream.ContentStreamOperations{}
+	var unmarked contentstream.ContentStreamOperations
+	addUnmarked := func() {
+		painted := false
+		for _, op := range unmarked {
+			if paintingOperators[op.Operand] {
+				painted = true
+				break
+			}
+		}
+		if painted {
+			ops = append(ops, *contentstream.NewContentCreator().Add_BMC("Artifact").Operations()...)
+			ops = append(ops, unmarked...)
+			ops = append(ops, *contentstream.NewContentCreator().Add_EMC().Operations()...)
+		} else {
+			ops = append(ops, unmarked...)
+		}
+		unmarked = nil
+	}
+
+	var current *markedContent
+	for _, op := range *blk.contents {
+		if mc, has := starts[op]; has && current == nil {
+			addUnmarked()
+			cc := contentstream.NewContentCreator()
+			if mc.elem != nil {
+				mcid := c.nextMCID(page)
+				props := core.MakeDict()
+				props.Set("MCID", core.MakeInteger(mcid))
+				cc.Add_BDC(core.PdfObjectName(mc.elem.S), props)
+				mc.elem.AddMarkedContent(page, mcid)
+			} else {
+				cc.Add_BDC("Artifact", mc.artifact)
+			}
+			ops = append(ops, *cc.Operations()...)
+			current = mc
+		}
+
+		if current == nil {
+			unmarked = append(unmarked, op)
+			continue
+		}
+		ops = append(ops, op)
+		if op == current.last {
+			ops = append(ops, *contentstream.NewContentCreator().Add_EMC().Operations()...)
+			current = nil
+		}
+	}
+	if current != nil {
+		common.Log.Debug("ERROR: Marked content not terminated")
+		ops = append(ops, *contentstream.NewContentCreator().Add_EMC().Operations()...)
+	}
+	addUnmarked()
+	*blk.contents = ops
+
+	for _, elem := range blk.elements {
+		if elem.Parent == nil {
+			c.document.AddKid(elem)
+			c.structPages[elem] = page
+		}
+	}
+}
+
+// nextMCID returns the next marked-content identifier of `page`, following those of the existing contents.
+func (c *Creator) nextMCID(page *model.PdfPage) int64 {
+	mcid, has := c.mcids[page]
+	if !has {
+		mcid = getNextMCID(page)
+	}
+	c.mcids[page] = mcid + 1
+	return mcid
+}
+
+// getNextMCID returns the marked-content identifier following the largest one in the contents of `page`.
+func getNextMCID(page *model.PdfPage) int64 {
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		return 0
+	}
+	ops, err := contentstream.NewContentStreamParser(content).Parse()
+	if err != nil {
+		return 0
+	}
+
+	next := int64(0)
+	for _, op := range *ops {
+		if op.Operand != "BDC" || len(op.Params) != 2 {
+			continue
+		}
+		props, ok := op.Params[1].(*core.PdfObjectDictionary)
+		if !ok {
+			continue
+		}
+		if mcid, ok := props.Get("MCID").(*core.PdfObjectInteger); ok && int64(*mcid) >= next {
+			next = int64(*mcid) + 1
+		}
+	}
+	return next
+}
+
+// sortStructure sorts the kids of the document element in page order, as the front page and table of
+// contents are drawn last.
+func (c *Creator) sortStructure() {
+	pageIndex := map[*model.PdfPage]int{}
+	for i, page := range c.pages {
+		pageIndex[page] = i
+	}
+	kids := c.document.Kids
+	sort.SliceStable(kids, func(i, j int) bool {
+		return pageIndex[c.structPages[kids[i].Element]] < pageIndex[c.structPages[kids[j].Element]]
+	})
+}
@@ -0,0 +1,849 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fontfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/unidoc/unidoc/common"
+)
+
+// cffFont is a parsed CFF font program with Type 2 charstrings, either name-keyed or CID-keyed.
+type cffFont struct {
+	charStrings [][]byte
+	globalSubrs [][]byte
+
+	// privates holds the private data of each font dict, a single one for name-keyed fonts.  fdSelect selects
+	// the font dict of each glyph of CID-keyed fonts.
+	privates []cffPrivate
+	fdSelect []byte
+
+	isCID bool
+	// names holds the glyph IDs by glyph name for name-keyed fonts, cids the glyph IDs by CID for CID-keyed
+	// fonts.
+	names map[string]int
+	cids  map[int]int
+	// encoding holds the glyph IDs of the character codes of the built-in encoding of name-keyed fonts.
+	encoding map[int]int
+}
+
+// cffPrivate holds the private data of a CFF font dict: the local subroutines and the font matrix.
+type cffPrivate struct {
+	subrs  [][]byte
+	matrix [6]float64
+}
+
+// cffDict is a parsed CFF DICT, with the operands by operator.  Two byte operators 12 x are stored as 1200 + x.
+type cffDict map[int][]float64
+
+// parseCFF parses CFF font program `data`.
+func parseCFF(data []byte) (*cffFont, error) {
+	if len(data) < 4 {
+		return nil, errors.New("CFF font too short")
+	}
+	pos := int(data[2])
+
+	// Name, Top DICT, String and Global Subr INDEXes.
+	_, pos, err := readCFFIndex(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	topDicts, pos, err := readCFFIndex(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	if len(topDicts) == 0 {
+		return nil, errors.New("CFF font without Top DICT")
+	}
+	stringIndex, pos, err := readCFFIndex(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	font := &cffFont{}
+	font.globalSubrs, _, err = readCFFIndex(data, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	top := parseCFFDict(topDicts[0])
+	if ops := top[17]; len(ops) == 1 {
+		font.charStrings, _, err = readCFFIndex(data, int(ops[0]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(font.charStrings) == 0 {
+		return nil, errors.New("CFF font without CharStrings")
+	}
+
+	topMatrix, hasTopMatrix := cffMatrix(top[1207])
+	_, font.isCID = top[1230]
+	if font.isCID {
+		// Each font dict has its private data and may have its own font matrix.
+		fdArray := [][]byte{}
+		if ops := top[1236]; len(ops) == 1 {
+			fdArray, _, err = readCFFIndex(data, int(ops[0]))
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, fd := range fdArray {
+			fontDict := parseCFFDict(fd)
+			private := parseCFFPrivate(data, fontDict[18])
+			if matrix, has := cffMatrix(fontDict[1207]); has {
+				if hasTopMatrix {
+					matrix = multiplyMatrix(matrix, topMatrix)
+				}
+				private.matrix = matrix
+			} else if hasTopMatrix {
+				private.matrix = topMatrix
+			}
+			font.privates = append(font.privates, private)
+		}
+		if ops := top[1237]; len(ops) == 1 {
+			font.fdSelect = parseFDSelect(data, int(ops[0]), len(font.charStrings))
+		}
+	} else {
+		private := parseCFFPrivate(data, top[18])
+		if hasTopMatrix {
+			private.matrix = topMatrix
+		}
+		font.privates = []cffPrivate{private}
+	}
+	if len(font.privates) == 0 {
+		font.privates = []cffPrivate{{matrix: defaultCFFMatrix}}
+	}
+
+	// The charset gives the SID, or CID of CID-keyed fonts, of each glyph.
+	charset := []int{0}
+	if ops := top[15]; len(ops) == 1 && int(ops[0]) > 2 {
+		charset = parseCFFCharset(data, int(ops[0]), len(font.charStrings))
+	} else {
+		// The predefined ISOAdobe charset, used for the Expert charsets as well.
+		for gid := 1; gid < len(font.charStrings); gid++ {
+			charset = append(charset, gid)
+		}
+	}
+
+	if font.isCID {
+		font.cids = map[int]int{}
+		for gid, cid := range charset {
+			font.cids[cid] = gid
+		}
+		return font, nil
+	}
+
+	font.names = map[string]int{}
+	for gid, sid := range charset {
+		if name := cffString(sid, stringIndex); name != "" {
+			font.names[name] = gid
+		}
+	}
+
+	font.encoding = map[int]int{}
+	encodingOffset := 0
+	if ops := top[16]; len(ops) == 1 {
+		encodingOffset = int(ops[0])
+	}
+	if encodingOffset > 1 {
+		font.parseEncoding(data, encodingOffset, charset, stringIndex)
+	} else {
+		// Standard encoding, used for the Expert encoding as well.
+		for code, name := range standardEncoding {
+			if gid, has := font.names[name]; has && name != "" {
+				font.encoding[code] = gid
+			}
+		}
+	}
+	return font, nil
+}
+
+// defaultCFFMatrix is the default font matrix of CFF fonts.
+var defaultCFFMatrix = [6]float64{0.001, 0, 0, 0.001, 0, 0}
+
+// cffMatrix returns the font matrix of FontMatrix operands `ops`.
+func cffMatrix(ops []float64) ([6]float64, bool) {
+	var m [6]float64
+	if len(ops) != 6 {
+		return defaultCFFMatrix, false
+	}
+	copy(m[:], ops)
+	return m, true
+}
+
+// multiplyMatrix returns the matrix product a × b of matrices [a b c d e f].
+func multiplyMatrix(a, b [6]float64) [6]float64 {
+	return [6]float64{
+		a[0]*b[0] + a[1]*b[2], a[0]*b[1] + a[1]*b[3],
+		a[2]*b[0] + a[3]*b[2], a[2]*b[1] + a[3]*b[3],
+		a[4]*b[0] + a[5]*b[2] + b[4], a[4]*b[1] + a[5]*b[3] + b[5],
+	}
+}
+
+// parseCFFPrivate parses the Private DICT at (size, offset) operands `ops` and its local subroutines.
+func parseCFFPrivate(data []byte, ops []float64) cffPrivate {
+	private := cffPrivate{matrix: defaultCFFMatrix}
+	if len(ops) != 2 {
+		return private
+	}
+	size, offset := int(ops[0]), int(ops[1])
+	if offset < 0 || size < 0 || offset+size > len(data) {
+		common.Log.Debug("CFF Private DICT out of bounds")
+		return private
+	}
+	dict := parseCFFDict(data[offset : offset+size])
+	if subrs := dict[19]; len(subrs) == 1 {
+		var err error
+		private.subrs, _, err = readCFFIndex(data, offset+int(subrs[0]))
+		if err != nil {
+			common.Log.Debug("Invalid CFF Subrs: %v", err)
+		}
+	}
+	return private
+}
+
+// parseCFFCharset parses the charset at `offset` for `numGlyphs` glyphs.
+func parseCFFCharset(data []byte, offset, numGlyphs int) []int {
+	charset := []int{0}
+	if offset >= len(data) {
+		return charset
+	}
+	format := data[offset]
+	pos := offset + 1
+	for len(charset) < numGlyphs {
+		switch format {
+		case 0:
+			if pos+2 > len(data) {
+				return charset
+			}
+			charset = append(charset, int(binary.BigEndian.Uint16(data[pos:])))
+			pos += 2
+		case 1, 2:
+			size := 3
+			if format == 2 {
+				size = 4
+			}
+			if pos+size > len(data) {
+				return charset
+			}
+			first := int(binary.BigEndian.Uint16(data[pos:]))
+			nLeft := int(data[pos+2])
+			if format == 2 {
+				nLeft = int(binary.BigEndian.Uint16(data[pos+2:]))
+			}
+			pos += size
+			for i := 0; i <= nLeft && len(charset) < numGlyphs; i++ {
+				charset = append(charset, first+i)
+			}
+		default:
+			common.Log.Debug("Unsupported CFF charset format %d", format)
+			return charset
+		}
+	}
+	return charset
+}
+
+// parseFDSelect parses the FDSelect at `offset`, returning the font dict index of each of `numGlyphs` glyphs.
+func parseFDSelect(data []byte, offset, numGlyphs int) []byte {
+	fds := make([]byte, numGlyphs)
+	if offset >= len(data) {
+		return fds
+	}
+	switch data[offset] {
+	case 0:
+		if offset+1+numGlyphs <= len(data) {
+			copy(fds, data[offset+1:])
+		}
+	case 3:
+		if offset+3 > len(data) {
+			return fds
+		}
+		nRanges := int(binary.BigEndian.Uint16(data[offset+1:]))
+		pos := offset + 3
+		for i := 0; i < nRanges && pos+5 <= len(data); i++ {
+			first := int(binary.BigEndian.Uint16(data[pos:]))
+			fd := data[pos+2]
+			next := int(binary.BigEndian.Uint16(data[pos+3:]))
+			for gid := first; gid < next && gid < numGlyphs; gid++ {
+				fds[gid] = fd
+			}
+			pos += 3
+		}
+	default:
+		common.Log.Debug("Unsupported FDSelect format %d", data[offset])
+	}
+	return fds
+}
+
+// parseEncoding parses the custom encoding at `offset` of a name-keyed font with charset `charset`.
+func (font *cffFont) parseEncoding(data []byte, offset int, charset []int, stringIndex [][]byte) {
+	if offset >= len(data) {
+		return
+	}
+	format := data[offset]
+	pos := offset + 1
+	switch format & 0x7f {
+	case 0:
+		if pos >= len(data) {
+			return
+		}
+		nCodes := int(data[pos])
+		pos++
+		for gid := 1; gid <= nCodes && pos < len(data); gid++ {
+			font.encoding[int(data[pos])] = gid
+			pos++
+		}
+	case 1:
+		if pos >= len(data) {
+			return
+		}
+		nRanges := int(data[pos])
+		pos++
+		gid := 1
+		for i := 0; i < nRanges && pos+2 <= len(data); i++ {
+			first := int(data[pos])
+			nLeft := int(data[pos+1])
+			pos += 2
+			for code := first; code <= first+nLeft; code++ {
+				font.encoding[code] = gid
+				gid++
+			}
+		}
+	default:
+		common.Log.Debug("Unsupported CFF encoding format %d", format)
+		return
+	}
+
+	// Supplements map additional codes to glyphs by SID.
+	if format&0x80 == 0 || pos >= len(data) {
+		return
+	}
+	nSups := int(data[pos])
+	pos++
+	for i := 0; i < nSups && pos+3 <= len(data); i++ {
+		code := int(data[pos])
+		sid := int(binary.BigEndian.Uint16(data[pos+1:]))
+		pos += 3
+		if gid, has := font.names[cffString(sid, stringIndex)]; has {
+			font.encoding[code] = gid
+		}
+	}
+}
+
+// cffString returns the string of string ID `sid`.
+func cffString(sid int, stringIndex [][]byte) string {
+	if sid < len(cffStandardStrings) {
+		return cffStandardStrings[sid]
+	}
+	if sid-len(cffStandardStrings) < len(stringIndex) {
+		return string(stringIndex[sid-len(cffStandardStrings)])
+	}
+	return ""
+}
+
+// readCFFIndex reads the INDEX at `pos`, returning its objects and the position after it.
+func readCFFIndex(data []byte, pos int) ([][]byte, int, error) {
+	if pos < 0 || pos+2 > len(data) {
+		return nil, pos, errors.New("CFF INDEX out of bounds")
+	}
+	count := int(binary.BigEndian.Uint16(data[pos:]))
+	if count == 0 {
+		return nil, pos + 2, nil
+	}
+	if pos+3 > len(data) {
+		return nil, pos, errors.New("CFF INDEX out of bounds")
+	}
+	offSize := int(data[pos+2])
+	if offSize < 1 || offSize > 4 || pos+3+(count+1)*offSize > len(data) {
+		return nil, pos, errors.New("Invalid CFF INDEX")
+	}
+	offset := func(i int) int {
+		v := 0
+		for _, b := range data[pos+3+i*offSize : pos+3+(i+1)*offSize] {
+			v = v<<8 | int(b)
+		}
+		return v
+	}
+	// Offsets are relative to the byte preceding the object data.
+	base := pos + 3 + (count+1)*offSize - 1
+	objects := make([][]byte, count)
+	for i := range objects {
+		start, end := base+offset(i), base+offset(i+1)
+		if start < base || end < start || end > len(data) {
+			return nil, pos, errors.New("Invalid CFF INDEX offsets")
+		}
+		objects[i] = data[start:end]
+	}
+	return objects, base + offset(count), nil
+}
+
+// parseCFFDict parses DICT data `data`.
+func parseCFFDict(data []byte) cffDict {
+	dict := cffDict{}
+	operands := []float64{}
+	for pos := 0; pos < len(data); {
+		b0 := data[pos]
+		switch {
+		case b0 <= 21:
+			op := int(b0)
+			pos++
+			if b0 == 12 {
+				if pos >= len(data) {
+					return dict
+				}
+				op = 1200 + int(data[pos])
+				pos++
+			}
+			dict[op] = operands
+			operands = []float64{}
+		case b0 == 30:
+			// Real number in packed BCD nibbles.
+			s := []byte{}
+			pos++
+		realLoop:
+			for ; pos < len(data); pos++ {
+				for _, nibble := range []byte{data[pos] >> 4, data[pos] & 0x0f} {
+					switch {
+					case nibble <= 9:
+						s = append(s, '0'+nibble)
+					case nibble == 0xa:
+						s = append(s, '.')
+					case nibble == 0xb:
+						s = append(s, 'E')
+					case nibble == 0xc:
+						s = append(s, 'E', '-')
+					case nibble == 0xe:
+						s = append(s, '-')
+					case nibble == 0xf:
+						pos++
+						break realLoop
+					}
+				}
+			}
+			v, _ := strconv.ParseFloat(string(s), 64)
+			operands = append(operands, v)
+		default:
+			v, n := readCFFNumber(data[pos:])
+			if n == 0 {
+				return dict
+			}
+			operands = append(operands, v)
+			pos += n
+		}
+	}
+	return dict
+}
+
+// readCFFNumber reads an integer operand of a DICT or a charstring at the start of `data`, returning its value
+// and length, or a length of 0 if invalid.
+func readCFFNumber(data []byte) (float64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	b0 := int(data[0])
+	switch {
+	case b0 == 28:
+		if len(data) < 3 {
+			return 0, 0
+		}
+		return float64(int16(binary.BigEndian.Uint16(data[1:]))), 3
+	case b0 == 29:
+		if len(data) < 5 {
+			return 0, 0
+		}
+		return float64(int32(binary.BigEndian.Uint32(data[1:]))), 5
+	case b0 >= 32 && b0 <= 246:
+		return float64(b0 - 139), 1
+	case b0 >= 247 && b0 <= 250:
+		if len(data) < 2 {
+			return 0, 0
+		}
+		return float64((b0-247)*256 + int(data[1]) + 108), 2
+	case b0 >= 251 && b0 <= 254:
+		if len(data) < 2 {
+			return 0, 0
+		}
+		return float64(-(b0-251)*256 - int(data[1]) - 108), 2
+	}
+	return 0, 0
+}
+
+// glyphByName returns the glyph ID of glyph `name` of a name-keyed font.
+func (font *cffFont) glyphByName(name string) (int, bool) {
+	gid, has := font.names[name]
+	return gid, has
+}
+
+// glyph returns the outline of glyph `gid` in text space units for a font size of 1.
+func (font *cffFont) glyph(gid int) Outline {
+	if gid < 0 || gid >= len(font.charStrings) {
+		return nil
+	}
+	fd := 0
+	if gid < len(font.fdSelect) && int(font.fdSelect[gid]) < len(font.privates) {
+		fd = int(font.fdSelect[gid])
+	}
+	private := font.privates[fd]
+
+	b := &outlineBuilder{}
+	interp := &type2Interpreter{font: font, subrs: private.subrs, b: b}
+	if err := interp.run(font.charStrings[gid], 0); err != nil {
+		common.Log.Debug("Invalid charstring of glyph %d: %v", gid, err)
+	}
+	b.closePath()
+	m := private.matrix
+	return b.outline.Transform(m[0], m[1], m[2], m[3], m[4], m[5])
+}
+
+// type2Interpreter interprets Type 2 charstrings, building the glyph outline.
+type type2Interpreter struct {
+	font  *cffFont
+	subrs [][]byte
+	b     *outlineBuilder
+
+	stack     []float64
+	nStems    int
+	haveWidth bool
+	ended     bool
+	// transient is the storage of the put and get operators.
+	transient [32]float64
+}
+
+// errCharstring is returned for invalid charstrings.
+var errCharstring = errors.New("Invalid charstring")
+
+// subrBias returns the bias of subroutine numbers for `count` subroutines.
+func subrBias(count int) int {
+	switch {
+	case count < 1240:
+		return 107
+	case count < 33900:
+		return 1131
+	}
+	return 32768
+}
+
+// clearWidth removes the optional width argument, present if the number of arguments exceeds `n` (or is odd for
+// `n` of -1), before the first stack clearing operator.
+func (t *type2Interpreter) clearWidth(odd bool, n int) {
+	if t.haveWidth {
+		return
+	}
+	t.haveWidth = true
+	if (odd && len(t.stack)%2 == 1) || (!odd && len(t.stack) > n) {
+		t.stack = t.stack[1:]
+	}
+}
+
+// run interprets charstring `code` at subroutine nesting `depth`.
+func (t *type2Interpreter) run(code []byte, depth int) error {
+	if depth > 10 {
+		return errCharstring
+	}
+	b := t.b
+	for pos := 0; pos < len(code) && !t.ended; {
+		op := int(code[pos])
+		if op == 28 || op >= 32 {
+			if op == 255 {
+				if pos+5 > len(code) {
+					return errCharstring
+				}
+				t.stack = append(t.stack, float64(int32(binary.BigEndian.Uint32(code[pos+1:])))/65536)
+				pos += 5
+				continue
+			}
+			v, n := readCFFNumber(code[pos:])
+			if n == 0 {
+				return errCharstring
+			}
+			t.stack = append(t.stack, v)
+			pos += n
+			continue
+		}
+		pos++
+
+		s := t.stack
+		switch op {
+		case 1, 3, 18, 23: // hstem, vstem, hstemhm, vstemhm
+			t.clearWidth(true, 0)
+			t.nStems += len(t.stack) / 2
+		case 19, 20: // hintmask, cntrmask
+			t.clearWidth(true, 0)
+			t.nStems += len(t.stack) / 2
+			pos += (t.nStems + 7) / 8
+		case 21: // rmoveto
+			t.clearWidth(false, 2)
+			s = t.stack
+			if len(s) >= 2 {
+				b.moveTo(b.x+s[0], b.y+s[1])
+			}
+		case 22: // hmoveto
+			t.clearWidth(false, 1)
+			s = t.stack
+			if len(s) >= 1 {
+				b.moveTo(b.x+s[0], b.y)
+			}
+		case 4: // vmoveto
+			t.clearWidth(false, 1)
+			s = t.stack
+			if len(s) >= 1 {
+				b.moveTo(b.x, b.y+s[0])
+			}
+		case 5: // rlineto
+			for i := 0; i+1 < len(s); i += 2 {
+				b.lineTo(b.x+s[i], b.y+s[i+1])
+			}
+		case 6, 7: // hlineto, vlineto
+			horizontal := op == 6
+			for i := 0; i < len(s); i++ {
+				if horizontal {
+					b.lineTo(b.x+s[i], b.y)
+				} else {
+					b.lineTo(b.x, b.y+s[i])
+				}
+				horizontal = !horizontal
+			}
+		case 8: // rrcurveto
+			for i := 0; i+5 < len(s); i += 6 {
+				t.curve(s[i], s[i+1], s[i+2], s[i+3], s[i+4], s[i+5])
+			}
+		case 24: // rcurveline
+			i := 0
+			for ; i+5 < len(s)-2; i += 6 {
+				t.curve(s[i], s[i+1], s[i+2], s[i+3], s[i+4], s[i+5])
+			}
+			if i+1 < len(s) {
+				b.lineTo(b.x+s[i], b.y+s[i+1])
+			}
+		case 25: // rlinecurve
+			i := 0
+			for ; i+1 < len(s)-6; i += 2 {
+				b.lineTo(b.x+s[i], b.y+s[i+1])
+			}
+			if i+5 < len(s) {
+				t.curve(s[i], s[i+1], s[i+2], s[i+3], s[i+4], s[i+5])
+			}
+		case 26: // vvcurveto
+			i := 0
+			dx1 := 0.0
+			if len(s)%2 == 1 {
+				dx1 = s[0]
+				i = 1
+			}
+			for ; i+3 < len(s); i += 4 {
+				t.curve(dx1, s[i], s[i+1], s[i+2], 0, s[i+3])
+				dx1 = 0
+			}
+		case 27: // hhcurveto
+			i := 0
+			dy1 := 0.0
+			if len(s)%2 == 1 {
+				dy1 = s[0]
+				i = 1
+			}
+			for ; i+3 < len(s); i += 4 {
+				t.curve(s[i], dy1, s[i+1], s[i+2], s[i+3], 0)
+				dy1 = 0
+			}
+		case 30, 31: // vhcurveto, hvcurveto
+			horizontal := op == 31
+			for i := 0; i+3 < len(s); i += 4 {
+				last := 0.0
+				if len(s)-i == 5 {
+					last = s[i+4]
+				}
+				if horizontal {
+					t.curve(s[i], 0, s[i+1], s[i+2], last, s[i+3])
+				} else {
+					t.curve(0, s[i], s[i+1], s[i+2], s[i+3], last)
+				}
+				horizontal = !horizontal
+			}
+		case 10, 29: // callsubr, callgsubr
+			if len(s) == 0 {
+				return errCharstring
+			}
+			subrs := t.subrs
+			if op == 29 {
+				subrs = t.font.globalSubrs
+			}
+			index := int(s[len(s)-1]) + subrBias(len(subrs))
+			t.stack = s[:len(s)-1]
+			if index < 0 || index >= len(subrs) {
+				return errCharstring
+			}
+			if err := t.run(subrs[index], depth+1); err != nil {
+				return err
+			}
+			continue
+		case 11: // return
+			return nil
+		case 14: // endchar
+			t.clearWidth(true, 0)
+			if len(t.stack) == 4 {
+				t.seac(t.stack[0], t.stack[1], int(t.stack[2]), int(t.stack[3]), depth)
+			}
+			b.closePath()
+			t.ended = true
+			return nil
+		case 12:
+			if pos >= len(code) {
+				return errCharstring
+			}
+			op2 := int(code[pos])
+			pos++
+			if !t.escape(op2) {
+				continue
+			}
+		default:
+			common.Log.Debug("Unsupported Type 2 charstring operator %d", op)
+		}
+		t.stack = t.stack[:0]
+	}
+	return nil
+}
+
+// curve adds a curve with control and end points relative to the previous point.
+func (t *type2Interpreter) curve(dx1, dy1, dx2, dy2, dx3, dy3 float64) {
+	b := t.b
+	x1, y1 := b.x+dx1, b.y+dy1
+	x2, y2 := x1+dx2, y1+dy2
+	b.cubeTo(x1, y1, x2, y2, x2+dx3, y2+dy3)
+}
+
+// escape runs two byte operator 12 `op`.  Returns true if the operator clears the stack.
+func (t *type2Interpreter) escape(op int) bool {
+	s := t.stack
+	pop := func(n int) []float64 {
+		if len(t.stack) < n {
+			return nil
+		}
+		args := append([]float64{}, t.stack[len(t.stack)-n:]...)
+		t.stack = t.stack[:len(t.stack)-n]
+		return args
+	}
+
+	switch op {
+	case 35: // flex
+		if len(s) >= 12 {
+			t.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+			t.curve(s[6], s[7], s[8], s[9], s[10], s[11])
+		}
+	case 34: // hflex
+		if len(s) >= 7 {
+			t.curve(s[0], 0, s[1], s[2], s[3], 0)
+			t.curve(s[4], 0, s[5], -s[2], s[6], 0)
+		}
+	case 36: // hflex1
+		if len(s) >= 9 {
+			t.curve(s[0], s[1], s[2], s[3], s[4], 0)
+			t.curve(s[5], 0, s[6], s[7], s[8], -(s[1] + s[3] + s[7]))
+		}
+	case 37: // flex1
+		if len(s) >= 11 {
+			dx := s[0] + s[2] + s[4] + s[6] + s[8]
+			dy := s[1] + s[3] + s[5] + s[7] + s[9]
+			t.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+			if math.Abs(dx) > math.Abs(dy) {
+				t.curve(s[6], s[7], s[8], s[9], s[10], -dy)
+			} else {
+				t.curve(s[6], s[7], s[8], s[9], -dx, s[10])
+			}
+		}
+	case 0: // dotsection (deprecated)
+	case 9: // abs
+		if args := pop(1); args != nil {
+			t.stack = append(t.stack, math.Abs(args[0]))
+		}
+		return false
+	case 10: // add
+		if args := pop(2); args != nil {
+			t.stack = append(t.stack, args[0]+args[1])
+		}
+		return false
+	case 11: // sub
+		if args := pop(2); args != nil {
+			t.stack = append(t.stack, args[0]-args[1])
+		}
+		return false
+	case 12: // div
+		if args := pop(2); args != nil && args[1] != 0 {
+			t.stack = append(t.stack, args[0]/args[1])
+		}
+		return false
+	case 14: // neg
+		if args := pop(1); args != nil {
+			t.stack = append(t.stack, -args[0])
+		}
+		return false
+	case 18: // drop
+		pop(1)
+		return false
+	case 20: // put
+		if args := pop(2); args != nil && args[1] >= 0 && int(args[1]) < len(t.transient) {
+			t.transient[int(args[1])] = args[0]
+		}
+		return false
+	case 21: // get
+		if args := pop(1); args != nil && args[0] >= 0 && int(args[0]) < len(t.transient) {
+			t.stack = append(t.stack, t.transient[int(args[0])])
+		}
+		return false
+	case 24: // mul
+		if args := pop(2); args != nil {
+			t.stack = append(t.stack, args[0]*args[1])
+		}
+		return false
+	case 26: // sqrt
+		if args := pop(1); args != nil {
+			t.stack = append(t.stack, math.Sqrt(math.Abs(args[0])))
+		}
+		return false
+	case 27: // dup
+		if len(s) > 0 {
+			t.stack = append(t.stack, s[len(s)-1])
+		}
+		return false
+	case 28: // exch
+		if len(s) >= 2 {
+			s[len(s)-1], s[len(s)-2] = s[len(s)-2], s[len(s)-1]
+		}
+		return false
+	default:
+		common.Log.Debug("Unsupported Type 2 charstring operator 12 %d", op)
+	}
+	return true
+}
+
+// seac composes an accented character of the glyphs with standard encoding codes `bchar` and `achar`, the
+// accent offset by (adx, ady).
+func (t *type2Interpreter) seac(adx, ady float64, bchar, achar int, depth int) {
+	if bchar < 0 || bchar > 255 || achar < 0 || achar > 255 {
+		return
+	}
+	base, okBase := t.font.names[standardEncoding[bchar]]
+	accent, okAccent := t.font.names[standardEncoding[achar]]
+	if !okBase || !okAccent {
+		return
+	}
+
+	for _, part := range []struct {
+		gid    int
+		dx, dy float64
+	}{{base, 0, 0}, {accent, adx, ady}} {
+		b := &outlineBuilder{}
+		interp := &type2Interpreter{font: t.font, subrs: t.subrs, b: b}
+		if err := interp.run(t.font.charStrings[part.gid], depth+1); err != nil {
+			common.Log.Debug("Invalid seac component: %v", err)
+		}
+		b.closePath()
+		t.b.closePath()
+		t.b.outline = append(t.b.outline, b.outline.Transform(1, 0, 0, 1, part.dx, part.dy)...)
+	}
+}
@@ -0,0 +1,806 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fontfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/cmap"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+	"github.com/unidoc/unidoc/pdf/model/textencoding"
+)
+
+// Font is a PDF font loaded from a font dictionary: the widths, glyph outlines and Unicode values of its
+// character codes.  All metrics are in text space units for a font size of 1.
+type Font struct {
+	// BaseFont is the PostScript name of the font, without the subset tag.
+	BaseFont string
+	// Subtype is the font type: Type0, Type1, MMType1, TrueType or Type3.
+	Subtype string
+	// Vertical is set for composite fonts with vertical writing mode.
+	Vertical bool
+	// Ascent and Descent are the maximum height above and depth below the baseline of the glyphs.
+	Ascent  float64
+	Descent float64
+	// Type3 holds the glyph descriptions of Type 3 fonts, it is nil for other fonts.
+	Type3 *Type3
+
+	codeBytes    int
+	widths       map[int]float64
+	defaultWidth float64
+	// Vertical metrics of composite fonts: the vertical displacements and position vector y components by CID
+	// and their defaults.
+	verticalWidths  map[int][2]float64
+	defaultVertical [2]float64
+
+	// names holds the glyph names of the character codes of simple fonts.
+	names     map[int]string
+	toUnicode *cmap.CMap
+	symbolic  bool
+	// flags are the font descriptor flags.
+	flags int
+	// hasEncoding is set if the font dictionary specifies an encoding, replacing the built-in encoding of the
+	// font program.
+	hasEncoding bool
+	// cidToGID maps the CIDs of CIDFontType2 fonts to glyph IDs, nil for the identity mapping.
+	cidToGID map[int]int
+
+	program *program
+	// substituted is set if the program is a substitute of a non-embedded font.
+	substituted bool
+	outlines    map[int]Outline
+}
+
+// Type3 holds the glyph descriptions of a Type 3 font.
+type Type3 struct {
+	// Matrix maps glyph space to text space.
+	Matrix [6]float64
+	// CharProcs holds the content stream describing the glyph of each character code.
+	CharProcs map[int]*core.PdfObjectStream
+	// Resources are the resources used by the glyph descriptions, nil if they use the resources of the page.
+	Resources *model.PdfPageResources
+}
+
+// program is a parsed font program of one of the supported formats.
+type program struct {
+	data     []byte
+	trueType *trueTypeFont
+	cff      *cffFont
+	type1    *type1Font
+}
+
+// parseProgram parses font program `data`, detecting its format.
+func parseProgram(data []byte) (*program, error) {
+	switch {
+	case len(data) >= 4 && (bytes.HasPrefix(data, []byte{0, 1, 0, 0}) || bytes.HasPrefix(data, []byte("true")) ||
+		bytes.HasPrefix(data, []byte("OTTO"))):
+		font, err := parseTrueType(data)
+		if err != nil {
+			return nil, err
+		}
+		return &program{data: data, trueType: font}, nil
+	case len(data) >= 4 && data[0] == 1 && data[1] == 0:
+		font, err := parseCFF(data)
+		if err != nil {
+			return nil, err
+		}
+		return &program{data: data, cff: font}, nil
+	}
+	font, err := parseType1(data)
+	if err != nil {
+		return nil, err
+	}
+	return &program{data: data, type1: font}, nil
+}
+
+// Load loads the font of font dictionary `obj`.  Invalid entries are skipped over with default metrics, so that
+// a font is always returned.
+func Load(obj core.PdfObject) *Font {
+	font := &Font{
+		codeBytes:       1,
+		widths:          map[int]float64{},
+		defaultWidth:    0.5,
+		Ascent:          0.8,
+		Descent:         -0.2,
+		names:           map[int]string{},
+		outlines:        map[int]Outline{},
+		defaultVertical: [2]float64{0.88, -1},
+	}
+
+	d, ok := core.TraceToDirectObject(obj).(*core.PdfObjectDictionary)
+	if !ok {
+		common.Log.Debug("Font not a dictionary (%T), using default metrics", obj)
+		return font
+	}
+	if subtype, ok := core.TraceToDirectObject(d.Get("Subtype")).(*core.PdfObjectName); ok {
+		font.Subtype = string(*subtype)
+	}
+	if baseFont, ok := core.TraceToDirectObject(d.Get("BaseFont")).(*core.PdfObjectName); ok {
+		font.BaseFont = stripSubsetTag(string(*baseFont))
+	}
+	if stream, ok := core.TraceToDirectObject(d.Get("ToUnicode")).(*core.PdfObjectStream); ok {
+		if data, err := core.DecodeStream(stream); err == nil {
+			font.toUnicode, err = cmap.LoadCmapFromData(data)
+			if err != nil {
+				common.Log.Debug("Invalid ToUnicode CMap: %v", err)
+			}
+		}
+	}
+
+	if font.Subtype == "Type0" {
+		font.loadComposite(d)
+		return font
+	}
+
+	scale := 0.001
+	if font.Subtype == "Type3" {
+		font.loadType3(d)
+		scale = font.Type3.Matrix[0]
+	}
+	descriptor, _ := core.TraceToDirectObject(d.Get("FontDescriptor")).(*core.PdfObjectDictionary)
+	font.loadDescriptor(descriptor, scale)
+	if font.Subtype != "Type3" {
+		font.loadProgram(descriptor)
+	}
+	font.loadEncoding(d.Get("Encoding"))
+	if !font.loadSimpleWidths(d, descriptor, scale) {
+		// Standard 14 fonts are not required to specify widths, use the built-in metrics.
+		font.loadStandardWidths()
+	}
+	return font
+}
+
+// stripSubsetTag returns font name `name` without the six letter tag of font subsets, such as "ABCDEF+".
+func stripSubsetTag(name string) string {
+	if len(name) > 7 && name[6] == '+' && strings.ToUpper(name[:6]) == name[:6] {
+		return name[7:]
+	}
+	return name
+}
+
+// loadType3 loads the glyph descriptions of Type 3 font dictionary `d`.
+func (font *Font) loadType3(d *core.PdfObjectDictionary) {
+	font.Type3 = &Type3{Matrix: [6]float64{0.001, 0, 0, 0.001, 0, 0}, CharProcs: map[int]*core.PdfObjectStream{}}
+	if m := getNumbers(d.Get("FontMatrix")); len(m) == 6 {
+		copy(font.Type3.Matrix[:], m)
+	}
+	if bbox := getNumbers(d.Get("FontBBox")); len(bbox) == 4 && bbox[1] < bbox[3] {
+		font.Ascent = bbox[3] * font.Type3.Matrix[3]
+		font.Descent = bbox[1] * font.Type3.Matrix[3]
+	}
+	if res, ok := core.TraceToDirectObject(d.Get("Resources")).(*core.PdfObjectDictionary); ok {
+		resources, err := model.NewPdfPageResourcesFromDict(res)
+		if err != nil {
+			common.Log.Debug("Invalid Type 3 font resources: %v", err)
+		} else {
+			font.Type3.Resources = resources
+		}
+	}
+
+	// The procedures are named by glyph name, the encoding Differences map the codes to the names.
+	procs, _ := core.TraceToDirectObject(d.Get("CharProcs")).(*core.PdfObjectDictionary)
+	font.loadEncoding(d.Get("Encoding"))
+	if procs == nil {
+		return
+	}
+	for code, name := range font.names {
+		if stream, ok := core.TraceToDirectObject(procs.Get(core.PdfObjectName(name))).(*core.PdfObjectStream); ok {
+			font.Type3.CharProcs[code] = stream
+		}
+	}
+}
+
+// loadDescriptor loads the flags, ascent and descent of font descriptor `descriptor`.
+func (font *Font) loadDescriptor(descriptor *core.PdfObjectDictionary, scale float64) {
+	if descriptor == nil {
+		font.symbolic = font.BaseFont == "Symbol" || font.BaseFont == "ZapfDingbats"
+		return
+	}
+	if flags, err := getNumber(descriptor.Get("Flags")); err == nil {
+		font.flags = int(flags)
+		font.symbolic = font.flags&flagSymbolic != 0
+	}
+	ascent, err1 := getNumber(descriptor.Get("Ascent"))
+	descent, err2 := getNumber(descriptor.Get("Descent"))
+	if err1 == nil && err2 == nil && ascent > descent {
+		font.Ascent = ascent * scale
+		font.Descent = descent * scale
+	}
+}
+
+// loadProgram loads the font program embedded in font descriptor `descriptor`, or a substitute if not embedded.
+func (font *Font) loadProgram(descriptor *core.PdfObjectDictionary) {
+	if descriptor != nil {
+		for _, key := range []core.PdfObjectName{"FontFile", "FontFile2", "FontFile3"} {
+			stream, ok := core.TraceToDirectObject(descriptor.Get(key)).(*core.PdfObjectStream)
+			if !ok {
+				continue
+			}
+			data, err := core.DecodeStream(stream)
+			if err != nil {
+				common.Log.Debug("Failed decoding %s of font %s: %v", key, font.BaseFont, err)
+				break
+			}
+			font.program, err = parseProgram(data)
+			if err != nil {
+				common.Log.Debug("Invalid %s of font %s: %v", key, font.BaseFont, err)
+			}
+			return
+		}
+	}
+	font.program = lookupSubstitute(font.BaseFont, font.flags)
+	font.substituted = font.program != nil
+}
+
+// loadEncoding loads the glyph names of the character codes of a simple font with Encoding entry `obj`.
+func (font *Font) loadEncoding(obj core.PdfObject) {
+	base := obj
+	var differences *core.PdfObjectArray
+	if d, ok := core.TraceToDirectObject(obj).(*core.PdfObjectDictionary); ok {
+		base = d.Get("BaseEncoding")
+		differences, _ = core.TraceToDirectObject(d.Get("Differences")).(*core.PdfObjectArray)
+	}
+	font.hasEncoding = obj != nil
+
+	names := font.builtinEncoding()
+	if name, ok := core.TraceToDirectObject(base).(*core.PdfObjectName); ok {
+		switch *name {
+		case "WinAnsiEncoding":
+			names = encoderNames(textencoding.NewWinAnsiTextEncoder())
+		case "MacRomanEncoding":
+			names = arrayNames(macRomanEncoding)
+		case "StandardEncoding":
+			names = arrayNames(standardEncoding)
+		default:
+			common.Log.Debug("Unsupported base encoding %s, using the built-in encoding", *name)
+		}
+	} else if font.Subtype == "TrueType" && !font.symbolic {
+		names = arrayNames(standardEncoding)
+	}
+	for code, name := range names {
+		font.names[code] = name
+	}
+
+	if differences == nil {
+		return
+	}
+	code := 0
+	for _, obj := range *differences {
+		switch t := core.TraceToDirectObject(obj).(type) {
+		case *core.PdfObjectInteger:
+			code = int(*t)
+		case *core.PdfObjectName:
+			font.names[code] = string(*t)
+			code++
+		}
+	}
+}
+
+// builtinEncoding returns the glyph names of the built-in encoding of the font program, the standard encoding
+// if unknown.
+func (font *Font) builtinEncoding() map[int]string {
+	switch font.BaseFont {
+	case "Symbol":
+		return encoderNames(textencoding.NewSymbolEncoder())
+	case "ZapfDingbats":
+		return encoderNames(textencoding.NewZapfDingbatsEncoder())
+	}
+	if font.program != nil && font.program.type1 != nil && len(font.program.type1.encoding) > 0 {
+		return font.program.type1.encoding
+	}
+	return arrayNames(standardEncoding)
+}
+
+// encoderNames returns the glyph names of the character codes of `encoder`.
+func encoderNames(encoder textencoding.TextEncoder) map[int]string {
+	names := map[int]string{}
+	// Codes below 32 are not mapped by the encodings.
+	for code := 32; code < 256; code++ {
+		if name, found := encoder.CharcodeToGlyph(byte(code)); found {
+			names[code] = name
+		}
+	}
+	return names
+}
+
+// arrayNames returns the glyph names of the character codes of encoding array `encoding`.
+func arrayNames(encoding [256]string) map[int]string {
+	names := map[int]string{}
+	for code, name := range encoding {
+		if name != "" {
+			names[code] = name
+		}
+	}
+	return names
+}
+
+// loadSimpleWidths loads the FirstChar and Widths entries of simple font dictionary `d`.  Returns false if the
+// widths are missing.
+func (font *Font) loadSimpleWidths(d, descriptor *core.PdfObjectDictionary, scale float64) bool {
+	firstChar, err := getNumber(d.Get("FirstChar"))
+	widths := getNumbers(d.Get("Widths"))
+	if err != nil || widths == nil {
+		return false
+	}
+	for i, w := range widths {
+		font.widths[int(firstChar)+i] = w * scale
+	}
+	if descriptor != nil {
+		if missingWidth, err := getNumber(descriptor.Get("MissingWidth")); err == nil {
+			font.defaultWidth = missingWidth * scale
+		} else {
+			font.defaultWidth = 0
+		}
+	}
+	return true
+}
+
+// standardFonts holds the metrics of the standard 14 fonts, by their names and common aliases.
+var standardFonts = map[string]func() fonts.Font{
+	"Courier":               func() fonts.Font { return fonts.NewFontCourier() },
+	"Courier-Bold":          func() fonts.Font { return fonts.NewFontCourierBold() },
+	"Courier-Oblique":       func() fonts.Font { return fonts.NewFontCourierOblique() },
+	"Courier-BoldOblique":   func() fonts.Font { return fonts.NewFontCourierBoldOblique() },
+	"Helvetica":             func() fonts.Font { return fonts.NewFontHelvetica() },
+	"Helvetica-Bold":        func() fonts.Font { return fonts.NewFontHelveticaBold() },
+	"Helvetica-Oblique":     func() fonts.Font { return fonts.NewFontHelveticaOblique() },
+	"Helvetica-BoldOblique": func() fonts.Font { return fonts.NewFontHelveticaBoldOblique() },
+	"Times-Roman":           func() fonts.Font { return fonts.NewFontTimesRoman() },
+	"Times-Bold":            func() fonts.Font { return fonts.NewFontTimesBold() },
+	"Times-Italic":          func() fonts.Font { return fonts.NewFontTimesItalic() },
+	"Times-BoldItalic":      func() fonts.Font { return fonts.NewFontTimesBoldItalic() },
+	"Symbol":                func() fonts.Font { return fonts.NewFontSymbol() },
+	"ZapfDingbats":          func() fonts.Font { return fonts.NewFontZapfDingbats() },
+}
+
+// standardAliases maps common names of the standard 14 fonts to their names.
+var standardAliases = map[string]string{
+	"Arial":                    "Helvetica",
+	"Arial,Bold":               "Helvetica-Bold",
+	"Arial,Italic":             "Helvetica-Oblique",
+	"Arial,BoldItalic":         "Helvetica-BoldOblique",
+	"ArialMT":                  "Helvetica",
+	"Arial-BoldMT":             "Helvetica-Bold",
+	"Arial-ItalicMT":           "Helvetica-Oblique",
+	"Arial-BoldItalicMT":       "Helvetica-BoldOblique",
+	"CourierNew":               "Courier",
+	"CourierNew,Bold":          "Courier-Bold",
+	"CourierNew,Italic":        "Courier-Oblique",
+	"CourierNew,BoldItalic":    "Courier-BoldOblique",
+	"CourierNewPSMT":           "Courier",
+	"TimesNewRoman":            "Times-Roman",
+	"TimesNewRoman,Bold":       "Times-Bold",
+	"TimesNewRoman,Italic":     "Times-Italic",
+	"TimesNewRoman,BoldItalic": "Times-BoldItalic",
+	"TimesNewRomanPSMT":        "Times-Roman",
+}
+
+// StandardName returns the name of the standard 14 font that `name` is, or is an alias of, or "" if none.
+func StandardName(name string) string {
+	if alias, has := standardAliases[name]; has {
+		return alias
+	}
+	if _, has := standardFonts[name]; has {
+		return name
+	}
+	return ""
+}
+
+// loadStandardWidths loads the widths of the character codes of a standard 14 font from the built-in metrics.
+func (font *Font) loadStandardWidths() {
+	newFont, has := standardFonts[StandardName(font.BaseFont)]
+	if !has {
+		common.Log.Debug("Font %s without widths, using default metrics", font.BaseFont)
+		return
+	}
+	stdFont := newFont()
+	for code, name := range font.names {
+		if metrics, found := stdFont.GetGlyphCharMetrics(name); found {
+			font.widths[code] = metrics.Wx * 0.001
+		}
+	}
+}
+
+// loadComposite loads Type 0 font dictionary `d` and its descendant CIDFont.  Codes are two bytes long and used
+// as CIDs, as for the Identity-H and Identity-V encodings.
+func (font *Font) loadComposite(d *core.PdfObjectDictionary) {
+	font.codeBytes = 2
+	font.defaultWidth = 1
+	if encoding, ok := core.TraceToDirectObject(d.Get("Encoding")).(*core.PdfObjectName); ok {
+		font.Vertical = strings.HasSuffix(string(*encoding), "-V")
+		if *encoding != "Identity-H" && *encoding != "Identity-V" {
+			common.Log.Debug("Unsupported CMap %s, using identity mapping", *encoding)
+		}
+	}
+
+	descendants, ok := core.TraceToDirectObject(d.Get("DescendantFonts")).(*core.PdfObjectArray)
+	if !ok || len(*descendants) == 0 {
+		common.Log.Debug("Type0 font without descendant font, using default metrics")
+		return
+	}
+	cidFont, ok := core.TraceToDirectObject((*descendants)[0]).(*core.PdfObjectDictionary)
+	if !ok {
+		common.Log.Debug("Invalid descendant font, using default metrics")
+		return
+	}
+	descriptor, _ := core.TraceToDirectObject(cidFont.Get("FontDescriptor")).(*core.PdfObjectDictionary)
+	font.loadDescriptor(descriptor, 0.001)
+	font.loadProgram(descriptor)
+
+	if stream, ok := core.TraceToDirectObject(cidFont.Get("CIDToGIDMap")).(*core.PdfObjectStream); ok {
+		if data, err := core.DecodeStream(stream); err == nil {
+			font.cidToGID = map[int]int{}
+			for i := 0; i+1 < len(data); i += 2 {
+				font.cidToGID[i/2] = int(binary.BigEndian.Uint16(data[i:]))
+			}
+		}
+	}
+
+	if dw, err := getNumber(cidFont.Get("DW")); err == nil {
+		font.defaultWidth = dw * 0.001
+	}
+	parseCIDWidths(cidFont.Get("W"), 1, func(cid int, w []float64) {
+		font.widths[cid] = w[0] * 0.001
+	})
+
+	if dw2 := getNumbers(cidFont.Get("DW2")); len(dw2) == 2 {
+		font.defaultVertical = [2]float64{dw2[0] * 0.001, dw2[1] * 0.001}
+	}
+	font.verticalWidths = map[int][2]float64{}
+	parseCIDWidths(cidFont.Get("W2"), 3, func(cid int, w []float64) {
+		// The entries are w1y vx vy, with vx half the horizontal width.
+		font.verticalWidths[cid] = [2]float64{w[2] * 0.001, w[0] * 0.001}
+	})
+}
+
+// parseCIDWidths calls `set` for each CID with `n` metrics of W or W2 array `obj`, which has the forms
+// "c [m1 m2 ...]" and "cfirst clast m...".
+func parseCIDWidths(obj core.PdfObject, n int, set func(cid int, metrics []float64)) {
+	arr, ok := core.TraceToDirectObject(obj).(*core.PdfObjectArray)
+	if !ok {
+		return
+	}
+	for i := 0; i+1 < len(*arr); {
+		first, err := getNumber((*arr)[i])
+		if err != nil {
+			common.Log.Debug("Invalid CID widths entry: %v", err)
+			return
+		}
+		if _, isArr := core.TraceToDirectObject((*arr)[i+1]).(*core.PdfObjectArray); isArr {
+			metrics := getNumbers((*arr)[i+1])
+			for j := 0; j+n <= len(metrics); j += n {
+				set(int(first)+j/n, metrics[j:j+n])
+			}
+			i += 2
+			continue
+		}
+		if i+1+n >= len(*arr) {
+			return
+		}
+		last, err := getNumber((*arr)[i+1])
+		metrics := getOperandNumbers((*arr)[i+2 : i+2+n])
+		if err != nil || metrics == nil || last-first > 65535 {
+			common.Log.Debug("Invalid CID widths range")
+			return
+		}
+		for cid := int(first); cid <= int(last); cid++ {
+			set(cid, metrics)
+		}
+		i += 2 + n
+	}
+}
+
+// Codes splits the string `data` into character codes.
+func (font *Font) Codes(data []byte) []int {
+	codes := make([]int, 0, len(data)/font.codeBytes)
+	for i := 0; i < len(data); i += font.codeBytes {
+		code := 0
+		for j := i; j < i+font.codeBytes && j < len(data); j++ {
+			code = code<<8 | int(data[j])
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// SplitCodes splits the string `data` into the byte sequences of its character codes, the codes of Codes.
+func (font *Font) SplitCodes(data []byte) [][]byte {
+	split := make([][]byte, 0, len(data)/font.codeBytes)
+	for i := 0; i < len(data); i += font.codeBytes {
+		end := i + font.codeBytes
+		if end > len(data) {
+			end = len(data)
+		}
+		split = append(split, data[i:end])
+	}
+	return split
+}
+
+// Width returns the horizontal displacement of the glyph of character code `code`.
+func (font *Font) Width(code int) float64 {
+	if w, has := font.widths[code]; has {
+		return w
+	}
+	return font.defaultWidth
+}
+
+// VerticalMetrics returns the vertical displacement `w1` of the glyph of character code `code` of a vertical
+// font and its position vector (vx, vy), the offset of the origin for vertical writing from the origin for
+// horizontal writing.
+func (font *Font) VerticalMetrics(code int) (w1, vx, vy float64) {
+	vx = font.Width(code) / 2
+	w1, vy = font.defaultVertical[1], font.defaultVertical[0]
+	if m, has := font.verticalWidths[code]; has {
+		w1, vy = m[0], m[1]
+	}
+	return w1, vx, vy
+}
+
+// IsSpace checks whether character code `code` is a single byte code 32, to which word spacing applies.
+func (font *Font) IsSpace(code int) bool {
+	return font.codeBytes == 1 && code == 32
+}
+
+// Unicode returns the text of character code `code`, or "" if unknown.
+func (font *Font) Unicode(code int) string {
+	if font.toUnicode != nil {
+		if s := font.toUnicode.CharcodeToUnicode(uint64(code)); s != "?" {
+			return s
+		}
+	}
+	if name, has := font.names[code]; has {
+		if r, ok := glyphNameToRune(name); ok {
+			return string(r)
+		}
+	}
+	return ""
+}
+
+// glyphNameToRune returns the Unicode value of glyph `name`, by the Adobe glyph list or uniXXXX and uXXXX[XX]
+// names.
+func glyphNameToRune(name string) (rune, bool) {
+	if r, found := textencoding.NewWinAnsiTextEncoder().GlyphToRune(name); found {
+		return r, true
+	}
+	hex := ""
+	switch {
+	case strings.HasPrefix(name, "uni") && len(name) >= 7:
+		hex = name[3:7]
+	case strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7:
+		hex = name[1:]
+	}
+	if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+		return rune(v), true
+	}
+	return 0, false
+}
+
+// Glyph returns the outline of the glyph of character code `code`.  Returns false if the font has no outlines,
+// such as Type 3 fonts and non-embedded fonts without a registered substitute.
+func (font *Font) Glyph(code int) (Outline, bool) {
+	if font.program == nil {
+		return nil, false
+	}
+	if outline, has := font.outlines[code]; has {
+		return outline, true
+	}
+	outline := font.lookupGlyph(code)
+	font.outlines[code] = outline
+	return outline, true
+}
+
+// WebFont returns the embedded TrueType or OpenType program of the font and its media type, if it has a Unicode
+// cmap, so that text can be drawn with it from the Unicode values of the character codes.  Returns nil for other
+// fonts.
+func (font *Font) WebFont() ([]byte, string) {
+	p := font.program
+	if p == nil || font.substituted || p.trueType == nil || !p.trueType.hasCmap(3, 1) {
+		return nil, ""
+	}
+	if bytes.HasPrefix(p.data, []byte("OTTO")) {
+		return p.data, "font/otf"
+	}
+	return p.data, "font/ttf"
+}
+
+// HasUnicodeGlyph checks whether the glyph of character code `code` is the glyph of its Unicode value in the
+// web font of the font, so that the code is drawn correctly as text.
+func (font *Font) HasUnicodeGlyph(code int) bool {
+	if data, _ := font.WebFont(); data == nil {
+		return false
+	}
+	runes := []rune(font.Unicode(code))
+	if len(runes) != 1 {
+		return false
+	}
+	tt := font.program.trueType
+	want, found := tt.lookup(3, 1, int(runes[0]))
+	if !found {
+		return false
+	}
+
+	gid := code
+	if font.Subtype == "Type0" {
+		if font.cidToGID != nil {
+			gid = font.cidToGID[code]
+		}
+		if tt.cff != nil && tt.cff.isCID {
+			gid = tt.cff.cids[code]
+		}
+	} else if gid, found = font.lookupTrueTypeGlyph(tt, code, font.names[code]); !found {
+		return false
+	}
+	return gid == want
+}
+
+// SubstituteName returns the name of the standard 14 font resembling the font, by its name and font descriptor
+// flags.
+func (font *Font) SubstituteName() string {
+	return substituteName(font.BaseFont, font.flags)
+}
+
+// lookupGlyph returns the outline of the glyph of character code `code` in the font program.
+func (font *Font) lookupGlyph(code int) Outline {
+	p := font.program
+	if font.Subtype == "Type0" && font.substituted {
+		// The CIDs of non-embedded fonts are mapped to the glyphs of their Unicode values.
+		runes := []rune(font.Unicode(code))
+		if len(runes) == 0 || p.trueType == nil {
+			return nil
+		}
+		if gid, found := p.trueType.lookup(3, 1, int(runes[0])); found {
+			return p.trueType.glyph(gid)
+		}
+		return nil
+	}
+	if font.Subtype == "Type0" {
+		// CIDs are mapped to glyph IDs by the CIDToGIDMap for TrueType and by the charset for CFF programs.
+		cid := code
+		gid := cid
+		if font.cidToGID != nil {
+			gid = font.cidToGID[cid]
+		}
+		cff := p.cff
+		if p.trueType != nil {
+			cff = p.trueType.cff
+			if cff == nil {
+				return p.trueType.glyph(gid)
+			}
+		}
+		if cff != nil {
+			if cff.isCID {
+				gid = cff.cids[cid]
+			}
+			return cff.glyph(gid)
+		}
+		return nil
+	}
+
+	name := font.names[code]
+	switch {
+	case p.type1 != nil:
+		outline, found := p.type1.glyph(name)
+		if !found {
+			if builtin, has := p.type1.encoding[code]; has {
+				outline, _ = p.type1.glyph(builtin)
+			}
+		}
+		return outline
+	case p.cff != nil:
+		return font.lookupCFFGlyph(p.cff, code, name)
+	case p.trueType != nil:
+		if gid, found := font.lookupTrueTypeGlyph(p.trueType, code, name); found {
+			return p.trueType.glyph(gid)
+		}
+		if p.trueType.cff != nil {
+			return font.lookupCFFGlyph(p.trueType.cff, code, name)
+		}
+	}
+	return nil
+}
+
+// lookupCFFGlyph returns the outline of the glyph of `code` with glyph name `name` of CFF program `cff`: by name
+// if the font specifies an encoding, otherwise by the built-in encoding of the program.
+func (font *Font) lookupCFFGlyph(cff *cffFont, code int, name string) Outline {
+	if font.hasEncoding || cff.isCID {
+		if gid, found := cff.glyphByName(name); found {
+			return cff.glyph(gid)
+		}
+	}
+	if gid, found := cff.encoding[code]; found {
+		return cff.glyph(gid)
+	}
+	if gid, found := cff.glyphByName(name); found {
+		return cff.glyph(gid)
+	}
+	return nil
+}
+
+// lookupTrueTypeGlyph returns the glyph ID of the glyph of `code` with glyph name `name` of TrueType program `tt`,
+// using the cmap subtables as specified for TrueType fonts (9.6.6.4 in the PDF reference).
+func (font *Font) lookupTrueTypeGlyph(tt *trueTypeFont, code int, name string) (int, bool) {
+	if !font.symbolic && tt.hasCmap(3, 1) {
+		if r, ok := glyphNameToRune(name); ok {
+			if gid, found := tt.lookup(3, 1, int(r)); found {
+				return gid, true
+			}
+		}
+	}
+	if tt.hasCmap(3, 0) {
+		for _, offset := range []int{0, 0xF000, 0xF100, 0xF200} {
+			if gid, found := tt.lookup(3, 0, offset+code); found {
+				return gid, true
+			}
+		}
+	}
+	if tt.hasCmap(1, 0) {
+		macCode := code
+		if !font.symbolic {
+			// The code of the glyph name in the Mac OS Roman encoding.
+			for c, n := range macRomanEncoding {
+				if n == name && n != "" {
+					macCode = c
+					break
+				}
+			}
+		}
+		if gid, found := tt.lookup(1, 0, macCode); found {
+			return gid, true
+		}
+	}
+	if gid, found := tt.names[name]; found && name != "" {
+		return gid, true
+	}
+	if tt.hasCmap(3, 1) {
+		// Symbolic fonts with only a Unicode cmap, such as non-embedded substitutes.
+		if r, ok := glyphNameToRune(name); ok {
+			return tt.lookup(3, 1, int(r))
+		}
+		return 0, false
+	}
+	// Without a usable cmap the codes are used as glyph IDs.
+	return code, code > 0 && code < tt.numGlyphs
+}
+
+// getNumber returns the value of a number object, integer or float.
+func getNumber(obj core.PdfObject) (float64, error) {
+	switch t := core.TraceToDirectObject(obj).(type) {
+	case *core.PdfObjectFloat:
+		return float64(*t), nil
+	case *core.PdfObjectInteger:
+		return float64(*t), nil
+	}
+	return 0, errors.New("Not a number")
+}
+
+// getNumbers returns the values of an array of numbers or nil if `obj` is not an array of numbers.
+func getNumbers(obj core.PdfObject) []float64 {
+	arr, ok := core.TraceToDirectObject(obj).(*core.PdfObjectArray)
+	if !ok {
+		return nil
+	}
+	return getOperandNumbers(*arr)
+}
+
+// getOperandNumbers returns the values of objects `objs` or nil if they are not all numbers.
+func getOperandNumbers(objs []core.PdfObject) []float64 {
+	vals := make([]float64, len(objs))
+	for i, obj := range objs {
+		v, err := getNumber(obj)
+		if err != nil {
+			return nil
+		}
+		vals[i] = v
+	}
+	return vals
+}
@@ -0,0 +1,54 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fontfile
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseTrueType(t *testing.T) {
+	data, err := ioutil.ReadFile("../../../testfiles/roboto/Roboto-Regular.ttf")
+	if err != nil {
+		t.Skipf("Font not available: %v", err)
+	}
+	font, err := parseTrueType(data)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	gid, ok := font.lookup(3, 1, 'H')
+	if !ok || gid == 0 {
+		t.Fatalf("Glyph of H not found in (3,1) cmap")
+	}
+	outline := font.glyph(gid)
+	if len(outline) == 0 {
+		t.Fatalf("Empty outline for H")
+	}
+	if outline[0].Op != MoveTo {
+		t.Errorf("Outline starts with %v, expected MoveTo", outline[0].Op)
+	}
+}
+
+func TestSubstituteName(t *testing.T) {
+	testcases := []struct {
+		name     string
+		flags    int
+		expected string
+	}{
+		{"Helvetica", 0, "Helvetica"},
+		{"Arial,Bold", 0, "Helvetica-Bold"},
+		{"TimesNewRomanPS-ItalicMT", flagSerif | flagItalic, "Times-Italic"},
+		{"CourierNewPSMT", flagFixedPitch, "Courier"},
+		{"Garamond", flagSerif, "Times-Roman"},
+		{"ZapfDingbats", flagSymbolic, "ZapfDingbats"},
+		{"Verdana-BoldItalic", 0, "Helvetica-BoldOblique"},
+	}
+	for _, tc := range testcases {
+		if got := substituteName(tc.name, tc.flags); got != tc.expected {
+			t.Errorf("%s (flags %d): got %s, expected %s", tc.name, tc.flags, got, tc.expected)
+		}
+	}
+}
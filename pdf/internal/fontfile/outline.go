@@ -0,0 +1,92 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package fontfile loads the glyph outlines, metrics and character mappings of PDF fonts, parsing the TrueType,
+// OpenType, CFF and Type 1 font programs embedded in them.  It is used for drawing text when rendering pages.
+package fontfile
+
+// Op is the operation of an outline segment.
+type Op int
+
+// Outline segment operations.  The number of points of each operation is 1 for MoveTo and LineTo, 2 for QuadTo
+// (control point and end point), 3 for CubeTo (two control points and end point) and 0 for Close.
+const (
+	MoveTo Op = iota
+	LineTo
+	QuadTo
+	CubeTo
+	Close
+)
+
+// Segment is a segment of a glyph outline.  Points holds the x, y coordinates of the points of the operation.
+type Segment struct {
+	Op     Op
+	Points []float64
+}
+
+// Outline is the outline of a glyph as a sequence of closed contours, each starting with a MoveTo segment.
+// Coordinates are in text space units for a font size of 1.
+type Outline []Segment
+
+// Transform returns the outline transformed by the matrix [a b c d e f].
+func (o Outline) Transform(a, b, c, d, e, f float64) Outline {
+	transformed := make(Outline, len(o))
+	for i, seg := range o {
+		points := make([]float64, len(seg.Points))
+		for j := 0; j+1 < len(seg.Points); j += 2 {
+			x, y := seg.Points[j], seg.Points[j+1]
+			points[j] = a*x + c*y + e
+			points[j+1] = b*x + d*y + f
+		}
+		transformed[i] = Segment{Op: seg.Op, Points: points}
+	}
+	return transformed
+}
+
+// outlineBuilder builds outlines from the relative and absolute moves of charstring and glyf programs, closing
+// open contours when a new one is started.
+type outlineBuilder struct {
+	outline Outline
+	x, y    float64
+	open    bool
+}
+
+func (b *outlineBuilder) moveTo(x, y float64) {
+	b.closePath()
+	b.x, b.y = x, y
+	b.outline = append(b.outline, Segment{Op: MoveTo, Points: []float64{x, y}})
+	b.open = true
+}
+
+func (b *outlineBuilder) ensureOpen() {
+	if !b.open {
+		b.moveTo(b.x, b.y)
+	}
+}
+
+func (b *outlineBuilder) lineTo(x, y float64) {
+	b.ensureOpen()
+	b.x, b.y = x, y
+	b.outline = append(b.outline, Segment{Op: LineTo, Points: []float64{x, y}})
+}
+
+func (b *outlineBuilder) quadTo(x1, y1, x, y float64) {
+	b.ensureOpen()
+	b.x, b.y = x, y
+	b.outline = append(b.outline, Segment{Op: QuadTo, Points: []float64{x1, y1, x, y}})
+}
+
+func (b *outlineBuilder) cubeTo(x1, y1, x2, y2, x, y float64) {
+	b.ensureOpen()
+	b.x, b.y = x, y
+	b.outline = append(b.outline, Segment{Op: CubeTo, Points: []float64{x1, y1, x2, y2, x, y}})
+}
+
+func (b *outlineBuilder) closePath() {
+	if b.open {
+		b.outline = append(b.outline, Segment{Op: Close})
+		b.open = false
+	}
+}
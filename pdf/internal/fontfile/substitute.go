@@ -0,0 +1,63 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fontfile
+
+import (
+	"strings"
+	"sync"
+)
+
+// substitutes holds the font programs registered for drawing non-embedded fonts, by font name.
+var substitutes = struct {
+	sync.RWMutex
+	programs map[string]*program
+}{programs: map[string]*program{}}
+
+// RegisterSubstitute registers font program `data` (TrueType, OpenType, CFF or Type 1) for drawing non-embedded
+// fonts named `name`.  The program registered with an empty name is used for fonts without a substitute of
+// their own.
+func RegisterSubstitute(name string, data []byte) error {
+	p, err := parseProgram(data)
+	if err != nil {
+		return err
+	}
+	substitutes.Lock()
+	substitutes.programs[name] = p
+	substitutes.Unlock()
+	return nil
+}
+
+// lookupSubstitute returns the substitute program of non-embedded font `name` with font descriptor flags
+// `flags`: the program registered for the font, otherwise the system font substituting the standard 14 font
+// resembling it.  Returns nil if none is found.
+func lookupSubstitute(name string, flags int) *program {
+	if p := lookupRegistered(name); p != nil {
+		return p
+	}
+	return lookupSystemFont(substituteName(name, flags))
+}
+
+// lookupRegistered returns the program registered for font `name`: the program of the name itself, of the
+// standard 14 font it is an alias of, of the regular style of its family or the default program.  Returns nil
+// if none is registered.
+func lookupRegistered(name string) *program {
+	substitutes.RLock()
+	defer substitutes.RUnlock()
+
+	candidates := []string{name, StandardName(name)}
+	if i := strings.IndexAny(name, ",-"); i > 0 {
+		candidates = append(candidates, name[:i], StandardName(name[:i]))
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if p, has := substitutes.programs[candidate]; has {
+			return p
+		}
+	}
+	return substitutes.programs[""]
+}
@@ -0,0 +1,176 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fontfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/unidoc/unidoc/common"
+)
+
+// Font descriptor flags (9.8.2 in the PDF reference).
+const (
+	flagFixedPitch = 1 << 0
+	flagSerif      = 1 << 1
+	flagSymbolic   = 1 << 2
+	flagItalic     = 1 << 6
+	flagForceBold  = 1 << 18
+)
+
+// systemFontDirs are the directories searched for the fonts substituting the standard 14 fonts.
+var systemFontDirs = []string{
+	"/usr/share/fonts",
+	"/usr/local/share/fonts",
+	"~/.fonts",
+	"~/.local/share/fonts",
+	"/Library/Fonts",
+	"/System/Library/Fonts",
+	"~/Library/Fonts",
+	`C:\Windows\Fonts`,
+}
+
+// systemFontFiles holds the file names of the fonts substituting each standard 14 font, by order of preference:
+// the metric compatible Liberation and URW fonts, the fonts of Windows and macOS and the DejaVu fonts.
+var systemFontFiles = map[string][]string{
+	"Helvetica": {"LiberationSans-Regular.ttf", "NimbusSans-Regular.otf", "n019003l.pfb", "arial.ttf",
+		"Arial.ttf", "DejaVuSans.ttf"},
+	"Helvetica-Bold": {"LiberationSans-Bold.ttf", "NimbusSans-Bold.otf", "n019004l.pfb", "arialbd.ttf",
+		"Arial Bold.ttf", "DejaVuSans-Bold.ttf"},
+	"Helvetica-Oblique": {"LiberationSans-Italic.ttf", "NimbusSans-Italic.otf", "n019023l.pfb", "ariali.ttf",
+		"Arial Italic.ttf", "DejaVuSans-Oblique.ttf"},
+	"Helvetica-BoldOblique": {"LiberationSans-BoldItalic.ttf", "NimbusSans-BoldItalic.otf", "n019024l.pfb",
+		"arialbi.ttf", "Arial Bold Italic.ttf", "DejaVuSans-BoldOblique.ttf"},
+	"Times-Roman": {"LiberationSerif-Regular.ttf", "NimbusRoman-Regular.otf", "n021003l.pfb", "times.ttf",
+		"Times New Roman.ttf", "DejaVuSerif.ttf"},
+	"Times-Bold": {"LiberationSerif-Bold.ttf", "NimbusRoman-Bold.otf", "n021004l.pfb", "timesbd.ttf",
+		"Times New Roman Bold.ttf", "DejaVuSerif-Bold.ttf"},
+	"Times-Italic": {"LiberationSerif-Italic.ttf", "NimbusRoman-Italic.otf", "n021023l.pfb", "timesi.ttf",
+		"Times New Roman Italic.ttf", "DejaVuSerif-Italic.ttf"},
+	"Times-BoldItalic": {"LiberationSerif-BoldItalic.ttf", "NimbusRoman-BoldItalic.otf", "n021024l.pfb",
+		"timesbi.ttf", "Times New Roman Bold Italic.ttf", "DejaVuSerif-BoldItalic.ttf"},
+	"Courier": {"LiberationMono-Regular.ttf", "NimbusMonoPS-Regular.otf", "n022003l.pfb", "cour.ttf",
+		"Courier New.ttf", "DejaVuSansMono.ttf"},
+	"Courier-Bold": {"LiberationMono-Bold.ttf", "NimbusMonoPS-Bold.otf", "n022004l.pfb", "courbd.ttf",
+		"Courier New Bold.ttf", "DejaVuSansMono-Bold.ttf"},
+	"Courier-Oblique": {"LiberationMono-Italic.ttf", "NimbusMonoPS-Italic.otf", "n022023l.pfb", "couri.ttf",
+		"Courier New Italic.ttf", "DejaVuSansMono-Oblique.ttf"},
+	"Courier-BoldOblique": {"LiberationMono-BoldItalic.ttf", "NimbusMonoPS-BoldItalic.otf", "n022024l.pfb",
+		"courbi.ttf", "Courier New Bold Italic.ttf", "DejaVuSansMono-BoldOblique.ttf"},
+	"Symbol":       {"StandardSymbolsPS.otf", "s050000l.pfb"},
+	"ZapfDingbats": {"D050000L.otf", "d050000l.pfb"},
+}
+
+// systemFonts holds the paths of the font files found in the system font directories by lower case file name,
+// and the programs loaded from them by standard 14 font name.
+var systemFonts = struct {
+	sync.Mutex
+	once     sync.Once
+	paths    map[string]string
+	programs map[string]*program
+}{programs: map[string]*program{}}
+
+// findSystemFonts lists the font files of the system font directories.
+func findSystemFonts() {
+	systemFonts.paths = map[string]string{}
+	home, _ := os.UserHomeDir()
+	for _, dir := range systemFontDirs {
+		if strings.HasPrefix(dir, "~") {
+			if home == "" {
+				continue
+			}
+			dir = filepath.Join(home, dir[1:])
+		}
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := strings.ToLower(info.Name())
+			if _, has := systemFonts.paths[name]; !has && !info.IsDir() {
+				systemFonts.paths[name] = path
+			}
+			return nil
+		})
+	}
+}
+
+// lookupSystemFont returns the program of the system font substituting standard 14 font `name`, nil if none is
+// found.
+func lookupSystemFont(name string) *program {
+	systemFonts.Lock()
+	defer systemFonts.Unlock()
+	if p, has := systemFonts.programs[name]; has {
+		return p
+	}
+	systemFonts.once.Do(findSystemFonts)
+
+	var p *program
+	for _, file := range systemFontFiles[name] {
+		path, has := systemFonts.paths[strings.ToLower(file)]
+		if !has {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			p, err = parseProgram(data)
+		}
+		if err != nil {
+			common.Log.Debug("Invalid system font %s: %v", path, err)
+			continue
+		}
+		break
+	}
+	systemFonts.programs[name] = p
+	return p
+}
+
+// substituteName returns the name of the standard 14 font resembling font `name` with font descriptor flags
+// `flags`: the standard font it is an alias of, otherwise the font with the style and family suggested by the
+// name and flags.
+func substituteName(name string, flags int) string {
+	if std := StandardName(name); std != "" {
+		return std
+	}
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "dingbat"):
+		return "ZapfDingbats"
+	case strings.Contains(lower, "symbol"):
+		return "Symbol"
+	}
+
+	bold := flags&flagForceBold != 0 || strings.Contains(lower, "bold") || strings.Contains(lower, "black") ||
+		strings.Contains(lower, "heavy")
+	italic := flags&flagItalic != 0 || strings.Contains(lower, "italic") || strings.Contains(lower, "oblique")
+	style := ""
+	switch {
+	case bold && italic:
+		style = "-BoldOblique"
+	case bold:
+		style = "-Bold"
+	case italic:
+		style = "-Oblique"
+	}
+
+	switch {
+	case flags&flagFixedPitch != 0 || strings.Contains(lower, "courier") || strings.Contains(lower, "mono"):
+		return "Courier" + style
+	case flags&flagSerif != 0 && !strings.Contains(lower, "sans") || strings.Contains(lower, "times"):
+		switch style {
+		case "":
+			return "Times-Roman"
+		case "-Oblique":
+			return "Times-Italic"
+		case "-BoldOblique":
+			return "Times-BoldItalic"
+		}
+		return "Times" + style
+	}
+	return "Helvetica" + style
+}
@@ -0,0 +1,508 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fontfile
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/unidoc/unidoc/common"
+)
+
+// trueTypeFont is a parsed TrueType or OpenType font program.  OpenType fonts with CFF outlines hold the parsed
+// CFF table, the glyph outlines of other fonts are given by the glyf table.
+type trueTypeFont struct {
+	tables     map[string][]byte
+	unitsPerEm float64
+	numGlyphs  int
+	loca       []int
+	glyf       []byte
+	cff        *cffFont
+
+	// cmaps holds the character mappings by platform and encoding ID, parsed when first used.
+	cmaps map[[2]int]map[int]int
+	// names holds the glyph IDs by glyph name of the post table.
+	names map[string]int
+}
+
+// parseTrueType parses TrueType or OpenType font program `data`.
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errors.New("TrueType font too short")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errors.New("TrueType table directory too short")
+	}
+
+	font := &trueTypeFont{tables: map[string][]byte{}, unitsPerEm: 1000}
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		tag := string(record[:4])
+		offset := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) || offset+length < offset {
+			common.Log.Debug("TrueType table %q out of bounds, skipping over", tag)
+			continue
+		}
+		font.tables[tag] = data[offset : offset+length]
+	}
+
+	head := font.tables["head"]
+	if len(head) < 54 {
+		return nil, errors.New("TrueType head table missing")
+	}
+	if unitsPerEm := binary.BigEndian.Uint16(head[18:]); unitsPerEm > 0 {
+		font.unitsPerEm = float64(unitsPerEm)
+	}
+	if maxp := font.tables["maxp"]; len(maxp) >= 6 {
+		font.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+	}
+
+	if table, has := font.tables["CFF "]; has {
+		cff, err := parseCFF(table)
+		if err != nil {
+			return nil, err
+		}
+		font.cff = cff
+		if font.numGlyphs == 0 {
+			font.numGlyphs = len(cff.charStrings)
+		}
+	} else {
+		font.glyf = font.tables["glyf"]
+		font.loca = parseLoca(font.tables["loca"], int16(binary.BigEndian.Uint16(head[50:])) != 0, font.numGlyphs)
+	}
+
+	font.cmaps = map[[2]int]map[int]int{}
+	font.names = parsePost(font.tables["post"])
+	return font, nil
+}
+
+// parseLoca returns the glyph offsets of the loca table `loca` with short or `long` offsets.
+func parseLoca(loca []byte, long bool, numGlyphs int) []int {
+	offsets := []int{}
+	if long {
+		for i := 0; i+4 <= len(loca) && i/4 <= numGlyphs; i += 4 {
+			offsets = append(offsets, int(binary.BigEndian.Uint32(loca[i:])))
+		}
+	} else {
+		for i := 0; i+2 <= len(loca) && i/2 <= numGlyphs; i += 2 {
+			offsets = append(offsets, 2*int(binary.BigEndian.Uint16(loca[i:])))
+		}
+	}
+	return offsets
+}
+
+// parsePost returns the glyph IDs by glyph name of post table `post`.  Only format 2 tables name the glyphs.
+func parsePost(post []byte) map[string]int {
+	names := map[string]int{}
+	if len(post) < 34 || binary.BigEndian.Uint32(post) != 0x00020000 {
+		return names
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(post[32:]))
+	if len(post) < 34+2*numGlyphs {
+		return names
+	}
+
+	// Names with indexes from 258 are stored as Pascal strings after the indexes.
+	custom := []string{}
+	for pos := 34 + 2*numGlyphs; pos < len(post); {
+		n := int(post[pos])
+		if pos+1+n > len(post) {
+			break
+		}
+		custom = append(custom, string(post[pos+1:pos+1+n]))
+		pos += 1 + n
+	}
+
+	for gid := 0; gid < numGlyphs; gid++ {
+		index := int(binary.BigEndian.Uint16(post[34+2*gid:]))
+		name := ""
+		if index < len(macGlyphNames) {
+			name = macGlyphNames[index]
+		} else if index-len(macGlyphNames) < len(custom) {
+			name = custom[index-len(macGlyphNames)]
+		}
+		if _, has := names[name]; name != "" && !has {
+			names[name] = gid
+		}
+	}
+	return names
+}
+
+// hasCmap checks whether the font has a cmap subtable for platform ID `platform` and encoding ID `encoding`.
+func (font *trueTypeFont) hasCmap(platform, encoding int) bool {
+	return font.cmap(platform, encoding) != nil
+}
+
+// lookup returns the glyph ID of character code `code` in the cmap subtable for `platform` and `encoding`.
+func (font *trueTypeFont) lookup(platform, encoding, code int) (int, bool) {
+	gid, found := font.cmap(platform, encoding)[code]
+	return gid, found && gid != 0
+}
+
+// cmap returns the character mapping of the cmap subtable for `platform` and `encoding`, or nil if the font
+// has no such subtable.
+func (font *trueTypeFont) cmap(platform, encoding int) map[int]int {
+	key := [2]int{platform, encoding}
+	if m, has := font.cmaps[key]; has {
+		return m
+	}
+	var m map[int]int
+
+	table := font.tables["cmap"]
+	if len(table) >= 4 {
+		numTables := int(binary.BigEndian.Uint16(table[2:]))
+		for i := 0; i < numTables && 4+8*i+8 <= len(table); i++ {
+			record := table[4+8*i:]
+			if int(binary.BigEndian.Uint16(record)) != platform || int(binary.BigEndian.Uint16(record[2:])) != encoding {
+				continue
+			}
+			offset := int(binary.BigEndian.Uint32(record[4:]))
+			if offset >= len(table) {
+				break
+			}
+			var err error
+			m, err = parseCmapSubtable(table[offset:])
+			if err != nil {
+				common.Log.Debug("Invalid cmap subtable (%d, %d): %v", platform, encoding, err)
+			}
+			break
+		}
+	}
+
+	font.cmaps[key] = m
+	return m
+}
+
+// parseCmapSubtable parses a cmap subtable of format 0, 4, 6 or 12.
+func parseCmapSubtable(data []byte) (map[int]int, error) {
+	if len(data) < 2 {
+		return nil, errors.New("Subtable too short")
+	}
+	m := map[int]int{}
+	u16 := func(pos int) int {
+		if pos+2 > len(data) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(data[pos:]))
+	}
+	u32 := func(pos int) int {
+		if pos+4 > len(data) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint32(data[pos:]))
+	}
+
+	switch format := u16(0); format {
+	case 0:
+		if len(data) < 6+256 {
+			return nil, errors.New("Format 0 subtable too short")
+		}
+		for code := 0; code < 256; code++ {
+			m[code] = int(data[6+code])
+		}
+	case 4:
+		segCount := u16(6) / 2
+		endCodes := 14
+		startCodes := endCodes + 2*segCount + 2
+		idDeltas := startCodes + 2*segCount
+		idRangeOffsets := idDeltas + 2*segCount
+		if idRangeOffsets+2*segCount > len(data) {
+			return nil, errors.New("Format 4 subtable too short")
+		}
+		for seg := 0; seg < segCount; seg++ {
+			end := u16(endCodes + 2*seg)
+			start := u16(startCodes + 2*seg)
+			delta := u16(idDeltas + 2*seg)
+			rangeOffset := u16(idRangeOffsets + 2*seg)
+			for code := start; code <= end && code != 0xFFFF; code++ {
+				gid := 0
+				if rangeOffset == 0 {
+					gid = (code + delta) & 0xFFFF
+				} else {
+					pos := idRangeOffsets + 2*seg + rangeOffset + 2*(code-start)
+					if gid = u16(pos); gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				m[code] = gid
+			}
+		}
+	case 6:
+		first := u16(6)
+		count := u16(8)
+		for i := 0; i < count; i++ {
+			m[first+i] = u16(10 + 2*i)
+		}
+	case 12:
+		numGroups := u32(12)
+		if 16+12*numGroups > len(data) || numGroups < 0 {
+			return nil, errors.New("Format 12 subtable too short")
+		}
+		for i := 0; i < numGroups; i++ {
+			start := u32(16 + 12*i)
+			end := u32(20 + 12*i)
+			gid := u32(24 + 12*i)
+			if end-start > 0xFFFF {
+				// Limit the size of the map for corrupt groups.
+				end = start + 0xFFFF
+			}
+			for code := start; code <= end; code++ {
+				m[code] = gid + code - start
+			}
+		}
+	default:
+		return nil, errors.New("Unsupported cmap subtable format")
+	}
+	return m, nil
+}
+
+// glyph returns the outline of glyph `gid` in text space units for a font size of 1.
+func (font *trueTypeFont) glyph(gid int) Outline {
+	if font.cff != nil {
+		return font.cff.glyph(gid)
+	}
+	b := &outlineBuilder{}
+	font.appendGlyph(b, gid, [6]float64{1 / font.unitsPerEm, 0, 0, 1 / font.unitsPerEm, 0, 0}, 0)
+	b.closePath()
+	return b.outline
+}
+
+// appendGlyph adds the contours of glyph `gid` transformed by matrix `m` to `b`.  The depth limits the nesting of
+// composite glyphs.
+func (font *trueTypeFont) appendGlyph(b *outlineBuilder, gid int, m [6]float64, depth int) {
+	if gid < 0 || gid+1 >= len(font.loca) || depth > 8 {
+		return
+	}
+	start, end := font.loca[gid], font.loca[gid+1]
+	if start >= end || end > len(font.glyf) {
+		// Empty glyph, such as space.
+		return
+	}
+	data := font.glyf[start:end]
+	if len(data) < 10 {
+		return
+	}
+
+	numContours := int(int16(binary.BigEndian.Uint16(data)))
+	if numContours < 0 {
+		font.appendComposite(b, data[10:], m, depth)
+		return
+	}
+	appendSimpleGlyph(b, data[10:], numContours, m)
+}
+
+// appendSimpleGlyph adds the `numContours` quadratic contours of simple glyph description `data` transformed by
+// `m` to `b`.
+func appendSimpleGlyph(b *outlineBuilder, data []byte, numContours int, m [6]float64) {
+	if len(data) < 2*numContours+2 {
+		return
+	}
+	endPts := make([]int, numContours)
+	numPoints := 0
+	for i := range endPts {
+		endPts[i] = int(binary.BigEndian.Uint16(data[2*i:]))
+		numPoints = endPts[i] + 1
+	}
+	pos := 2 * numContours
+	pos += 2 + int(binary.BigEndian.Uint16(data[pos:]))
+
+	// Flags, with repeat counts.
+	flags := make([]byte, 0, numPoints)
+	for len(flags) < numPoints && pos < len(data) {
+		flag := data[pos]
+		pos++
+		flags = append(flags, flag)
+		if flag&0x08 != 0 && pos < len(data) {
+			repeat := int(data[pos])
+			pos++
+			for i := 0; i < repeat && len(flags) < numPoints; i++ {
+				flags = append(flags, flag)
+			}
+		}
+	}
+	if len(flags) < numPoints {
+		return
+	}
+
+	// readCoords reads the deltas of the x or y coordinates, given by the short and same flag bits.
+	readCoords := func(shortBit, sameBit byte) []float64 {
+		coords := make([]float64, numPoints)
+		v := 0
+		for i, flag := range flags {
+			if flag&shortBit != 0 {
+				if pos >= len(data) {
+					return nil
+				}
+				d := int(data[pos])
+				pos++
+				if flag&sameBit == 0 {
+					d = -d
+				}
+				v += d
+			} else if flag&sameBit == 0 {
+				if pos+2 > len(data) {
+					return nil
+				}
+				v += int(int16(binary.BigEndian.Uint16(data[pos:])))
+				pos += 2
+			}
+			coords[i] = float64(v)
+		}
+		return coords
+	}
+	xs := readCoords(0x02, 0x10)
+	ys := readCoords(0x04, 0x20)
+	if xs == nil || ys == nil {
+		return
+	}
+
+	transform := func(i int) (float64, float64) {
+		return m[0]*xs[i] + m[2]*ys[i] + m[4], m[1]*xs[i] + m[3]*ys[i] + m[5]
+	}
+
+	first := 0
+	for _, last := range endPts {
+		if last < first || last >= numPoints {
+			return
+		}
+		appendContour(b, flags[first:last+1], func(i int) (float64, float64) { return transform(first + i) })
+		first = last + 1
+	}
+}
+
+// appendContour adds a quadratic contour with the on-curve flags `flags` and coordinates `point` of its points
+// to `b`.  Consecutive off-curve points have an implied on-curve point in the middle.
+func appendContour(b *outlineBuilder, flags []byte, point func(i int) (float64, float64)) {
+	n := len(flags)
+	if n == 0 {
+		return
+	}
+	onCurve := func(i int) bool { return flags[i%n]&0x01 != 0 }
+
+	// Start at an on-curve point, or the middle of the first two off-curve points.
+	startIdx := -1
+	for i := 0; i < n; i++ {
+		if onCurve(i) {
+			startIdx = i
+			break
+		}
+	}
+	var sx, sy float64
+	if startIdx >= 0 {
+		sx, sy = point(startIdx)
+	} else {
+		x0, y0 := point(0)
+		x1, y1 := point(1 % n)
+		sx, sy = (x0+x1)/2, (y0+y1)/2
+		startIdx = 0
+	}
+	b.moveTo(sx, sy)
+
+	var cx, cy float64
+	hasControl := false
+	for k := 1; k <= n; k++ {
+		i := (startIdx + k) % n
+		x, y := point(i)
+		if onCurve(i) {
+			if hasControl {
+				b.quadTo(cx, cy, x, y)
+				hasControl = false
+			} else {
+				b.lineTo(x, y)
+			}
+			continue
+		}
+		if hasControl {
+			mx, my := (cx+x)/2, (cy+y)/2
+			b.quadTo(cx, cy, mx, my)
+		}
+		cx, cy = x, y
+		hasControl = true
+	}
+	if hasControl {
+		b.quadTo(cx, cy, sx, sy)
+	}
+	b.closePath()
+}
+
+// appendComposite adds the components of composite glyph description `data` transformed by `m` to `b`.
+func (font *trueTypeFont) appendComposite(b *outlineBuilder, data []byte, m [6]float64, depth int) {
+	const (
+		argsAreWords  = 0x0001
+		argsAreXY     = 0x0002
+		haveScale     = 0x0008
+		moreComps     = 0x0020
+		haveXYScale   = 0x0040
+		haveTwoByTwo  = 0x0080
+		f2dot14Divide = 16384.0
+	)
+
+	pos := 0
+	for {
+		if pos+4 > len(data) {
+			return
+		}
+		flags := binary.BigEndian.Uint16(data[pos:])
+		gid := int(binary.BigEndian.Uint16(data[pos+2:]))
+		pos += 4
+
+		var dx, dy float64
+		if flags&argsAreWords != 0 {
+			if pos+4 > len(data) {
+				return
+			}
+			dx = float64(int16(binary.BigEndian.Uint16(data[pos:])))
+			dy = float64(int16(binary.BigEndian.Uint16(data[pos+2:])))
+			pos += 4
+		} else {
+			if pos+2 > len(data) {
+				return
+			}
+			dx = float64(int8(data[pos]))
+			dy = float64(int8(data[pos+1]))
+			pos += 2
+		}
+		if flags&argsAreXY == 0 {
+			// Components positioned by matching points are not supported.
+			common.Log.Debug("Composite glyph positioned by points, ignoring offset")
+			dx, dy = 0, 0
+		}
+
+		f2dot14 := func(i int) float64 {
+			if pos+2*i+2 > len(data) {
+				return 0
+			}
+			return float64(int16(binary.BigEndian.Uint16(data[pos+2*i:]))) / f2dot14Divide
+		}
+		a, bb, c, d := 1.0, 0.0, 0.0, 1.0
+		switch {
+		case flags&haveScale != 0:
+			a = f2dot14(0)
+			d = a
+			pos += 2
+		case flags&haveXYScale != 0:
+			a, d = f2dot14(0), f2dot14(1)
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			a, bb, c, d = f2dot14(0), f2dot14(1), f2dot14(2), f2dot14(3)
+			pos += 8
+		}
+
+		// The component matrix is applied before the glyph matrix `m`.
+		cm := [6]float64{
+			a*m[0] + bb*m[2], a*m[1] + bb*m[3],
+			c*m[0] + d*m[2], c*m[1] + d*m[3],
+			dx*m[0] + dy*m[2] + m[4], dx*m[1] + dy*m[3] + m[5],
+		}
+		font.appendGlyph(b, gid, cm, depth+1)
+
+		if flags&moreComps == 0 {
+			return
+		}
+	}
+}
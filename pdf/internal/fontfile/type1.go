@@ -0,0 +1,546 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package fontfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+
+	"github.com/unidoc/unidoc/common"
+)
+
+// type1Font is a parsed Type 1 font program.
+type type1Font struct {
+	matrix      [6]float64
+	encoding    map[int]string
+	subrs       [][]byte
+	charStrings map[string][]byte
+}
+
+// parseType1 parses Type 1 font program `data`, in PFA or PFB format.
+func parseType1(data []byte) (*type1Font, error) {
+	if len(data) > 0 && data[0] == 0x80 {
+		data = unwrapPFB(data)
+	}
+
+	eexec := bytes.Index(data, []byte("eexec"))
+	if eexec < 0 {
+		return nil, errors.New("Type 1 font without eexec section")
+	}
+	clear := data[:eexec]
+	encrypted := data[eexec+len("eexec"):]
+	for len(encrypted) > 0 && isPSWhitespace(encrypted[0]) {
+		encrypted = encrypted[1:]
+	}
+	if isHexData(encrypted) {
+		encrypted = decodeHexData(encrypted)
+	}
+	private := decryptType1(encrypted, 55665, 4)
+
+	font := &type1Font{
+		matrix:      defaultCFFMatrix,
+		encoding:    parseType1Encoding(clear),
+		charStrings: map[string][]byte{},
+	}
+	if m := parseType1Array(clear, "/FontMatrix"); len(m) == 6 {
+		copy(font.matrix[:], m)
+	}
+
+	lenIV := 4
+	if pos := bytes.Index(private, []byte("/lenIV")); pos >= 0 {
+		if tok, _ := nextPSToken(private, pos+len("/lenIV")); tok != "" {
+			if v, err := strconv.Atoi(tok); err == nil {
+				lenIV = v
+			}
+		}
+	}
+	decrypt := func(cs []byte) []byte {
+		if lenIV < 0 {
+			return cs
+		}
+		return decryptType1(cs, 4330, lenIV)
+	}
+
+	// Subrs: dup index length RD <binary> NP
+	if pos := bytes.Index(private, []byte("/Subrs")); pos >= 0 {
+		tok, pos := nextPSToken(private, pos+len("/Subrs"))
+		count, _ := strconv.Atoi(tok)
+		if count > 0 && count < 65536 {
+			font.subrs = make([][]byte, count)
+		}
+		if tok, next := nextPSToken(private, pos); tok == "array" {
+			pos = next
+		}
+		for {
+			tok, next := nextPSToken(private, pos)
+			if tok != "dup" {
+				break
+			}
+			tok, next = nextPSToken(private, next)
+			index, err1 := strconv.Atoi(tok)
+			tok, next = nextPSToken(private, next)
+			length, err2 := strconv.Atoi(tok)
+			_, next = nextPSToken(private, next)
+			next++
+			if err1 != nil || err2 != nil || length < 0 || next+length > len(private) {
+				break
+			}
+			if index >= 0 && index < len(font.subrs) {
+				font.subrs[index] = decrypt(private[next : next+length])
+			}
+			pos = next + length
+			// Skip NP, or "noaccess put".
+			for {
+				tok, next = nextPSToken(private, pos)
+				if tok == "dup" || tok == "" || tok == "ND" || tok == "|-" || tok == "readonly" || tok == "def" {
+					break
+				}
+				pos = next
+			}
+		}
+	}
+
+	// CharStrings: /name length RD <binary> ND
+	if pos := bytes.Index(private, []byte("/CharStrings")); pos >= 0 {
+		pos += len("/CharStrings")
+		for {
+			tok, next := nextPSToken(private, pos)
+			if tok == "" || tok == "end" {
+				break
+			}
+			if tok[0] != '/' {
+				pos = next
+				continue
+			}
+			name := tok[1:]
+			tok, next = nextPSToken(private, next)
+			length, err := strconv.Atoi(tok)
+			if err != nil {
+				// Not a charstring entry, such as the dict size.
+				pos = next
+				continue
+			}
+			_, next = nextPSToken(private, next)
+			next++
+			if length < 0 || next+length > len(private) {
+				break
+			}
+			font.charStrings[name] = decrypt(private[next : next+length])
+			pos = next + length
+		}
+	}
+
+	if len(font.charStrings) == 0 {
+		return nil, errors.New("Type 1 font without CharStrings")
+	}
+	return font, nil
+}
+
+// unwrapPFB returns the data of the segments of a PFB font file.
+func unwrapPFB(data []byte) []byte {
+	out := []byte{}
+	for len(data) >= 6 && data[0] == 0x80 && data[1] != 3 {
+		length := int(binary.LittleEndian.Uint32(data[2:]))
+		if length < 0 || 6+length > len(data) {
+			length = len(data) - 6
+		}
+		out = append(out, data[6:6+length]...)
+		data = data[6+length:]
+	}
+	return out
+}
+
+// isHexData checks whether the eexec section `data` is hex encoded.
+func isHexData(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	for _, c := range data[:4] {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeHexData decodes hex data `data`, skipping whitespace.
+func decodeHexData(data []byte) []byte {
+	digits := make([]byte, 0, len(data))
+	for _, c := range data {
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = digits[:len(digits)-1]
+	}
+	decoded := make([]byte, len(digits)/2)
+	hex.Decode(decoded, digits)
+	return decoded
+}
+
+// decryptType1 decrypts eexec or charstring encrypted `data` with key `r`, discarding the first `skip` bytes.
+func decryptType1(data []byte, r uint16, skip int) []byte {
+	const c1, c2 = 52845, 22719
+	out := make([]byte, len(data))
+	for i, c := range data {
+		out[i] = c ^ byte(r>>8)
+		r = (uint16(c)+r)*c1 + c2
+	}
+	if skip > len(out) {
+		return nil
+	}
+	return out[skip:]
+}
+
+// isPSWhitespace checks whether `c` is PostScript whitespace.
+func isPSWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+// nextPSToken returns the next whitespace or delimiter separated token of `data` from `pos` and the position
+// after it.  Arrays and procedure delimiters are single character tokens.
+func nextPSToken(data []byte, pos int) (string, int) {
+	for pos < len(data) && isPSWhitespace(data[pos]) {
+		pos++
+	}
+	if pos >= len(data) {
+		return "", pos
+	}
+	switch data[pos] {
+	case '[', ']', '{', '}':
+		return string(data[pos]), pos + 1
+	}
+	start := pos
+	pos++
+	for pos < len(data) && !isPSWhitespace(data[pos]) {
+		switch data[pos] {
+		case '[', ']', '{', '}', '/':
+			return string(data[start:pos]), pos
+		}
+		pos++
+	}
+	return string(data[start:pos]), pos
+}
+
+// parseType1Array returns the numbers of the array following `key` in `data`.
+func parseType1Array(data []byte, key string) []float64 {
+	pos := bytes.Index(data, []byte(key))
+	if pos < 0 {
+		return nil
+	}
+	tok, pos := nextPSToken(data, pos+len(key))
+	if tok != "[" && tok != "{" {
+		return nil
+	}
+	vals := []float64{}
+	for {
+		tok, pos = nextPSToken(data, pos)
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return vals
+		}
+		vals = append(vals, v)
+	}
+}
+
+// parseType1Encoding returns the built-in encoding of the cleartext part `data` of a Type 1 font: the standard
+// encoding or the glyph names set with "dup code /name put".
+func parseType1Encoding(data []byte) map[int]string {
+	encoding := map[int]string{}
+	pos := bytes.Index(data, []byte("/Encoding"))
+	if pos < 0 {
+		return encoding
+	}
+	tok, pos := nextPSToken(data, pos+len("/Encoding"))
+	if tok == "StandardEncoding" {
+		for code, name := range standardEncoding {
+			if name != "" {
+				encoding[code] = name
+			}
+		}
+		return encoding
+	}
+
+	for {
+		tok, next := nextPSToken(data, pos)
+		if tok == "" || tok == "readonly" || tok == "def" {
+			return encoding
+		}
+		pos = next
+		if tok != "dup" {
+			continue
+		}
+		codeTok, next := nextPSToken(data, pos)
+		nameTok, next := nextPSToken(data, next)
+		code, err := strconv.Atoi(codeTok)
+		if err != nil || len(nameTok) < 2 || nameTok[0] != '/' {
+			continue
+		}
+		encoding[code] = nameTok[1:]
+		pos = next
+	}
+}
+
+// glyph returns the outline of glyph `name` in text space units for a font size of 1.
+func (font *type1Font) glyph(name string) (Outline, bool) {
+	cs, has := font.charStrings[name]
+	if !has {
+		return nil, false
+	}
+	b := &outlineBuilder{}
+	interp := &type1Interpreter{font: font, b: b}
+	if err := interp.run(cs, 0); err != nil {
+		common.Log.Debug("Invalid charstring of glyph %s: %v", name, err)
+	}
+	b.closePath()
+	m := font.matrix
+	return b.outline.Transform(m[0], m[1], m[2], m[3], m[4], m[5]), true
+}
+
+// type1Interpreter interprets Type 1 charstrings, building the glyph outline.
+type type1Interpreter struct {
+	font *type1Font
+	b    *outlineBuilder
+
+	stack   []float64
+	psStack []float64
+	sbx     float64
+	ended   bool
+
+	// flexing is set between the start and end of a flex, flexPoints holds the points of the flex.
+	flexing    bool
+	flexPoints []float64
+}
+
+// run interprets charstring `code` at subroutine nesting `depth`.
+func (t *type1Interpreter) run(code []byte, depth int) error {
+	if depth > 10 {
+		return errCharstring
+	}
+	b := t.b
+	for pos := 0; pos < len(code) && !t.ended; {
+		op := int(code[pos])
+		if op >= 32 {
+			if op == 255 {
+				if pos+5 > len(code) {
+					return errCharstring
+				}
+				t.stack = append(t.stack, float64(int32(binary.BigEndian.Uint32(code[pos+1:]))))
+				pos += 5
+				continue
+			}
+			v, n := readCFFNumber(code[pos:])
+			if n == 0 {
+				return errCharstring
+			}
+			t.stack = append(t.stack, v)
+			pos += n
+			continue
+		}
+		pos++
+
+		s := t.stack
+		switch op {
+		case 1, 3: // hstem, vstem
+		case 13: // hsbw
+			if len(s) >= 2 {
+				t.sbx = s[0]
+				b.x, b.y = s[0], 0
+			}
+		case 21: // rmoveto
+			if len(s) >= 2 {
+				t.moveTo(b.x+s[0], b.y+s[1])
+			}
+		case 22: // hmoveto
+			if len(s) >= 1 {
+				t.moveTo(b.x+s[0], b.y)
+			}
+		case 4: // vmoveto
+			if len(s) >= 1 {
+				t.moveTo(b.x, b.y+s[0])
+			}
+		case 5: // rlineto
+			if len(s) >= 2 {
+				b.lineTo(b.x+s[0], b.y+s[1])
+			}
+		case 6: // hlineto
+			if len(s) >= 1 {
+				b.lineTo(b.x+s[0], b.y)
+			}
+		case 7: // vlineto
+			if len(s) >= 1 {
+				b.lineTo(b.x, b.y+s[0])
+			}
+		case 8: // rrcurveto
+			if len(s) >= 6 {
+				t.curve(s[0], s[1], s[2], s[3], s[4], s[5])
+			}
+		case 30: // vhcurveto
+			if len(s) >= 4 {
+				t.curve(0, s[0], s[1], s[2], s[3], 0)
+			}
+		case 31: // hvcurveto
+			if len(s) >= 4 {
+				t.curve(s[0], 0, s[1], s[2], 0, s[3])
+			}
+		case 9: // closepath
+			b.closePath()
+		case 10: // callsubr
+			if len(s) == 0 {
+				return errCharstring
+			}
+			index := int(s[len(s)-1])
+			t.stack = s[:len(s)-1]
+			if index < 0 || index >= len(t.font.subrs) {
+				return errCharstring
+			}
+			if err := t.run(t.font.subrs[index], depth+1); err != nil {
+				return err
+			}
+			continue
+		case 11: // return
+			return nil
+		case 14: // endchar
+			b.closePath()
+			t.ended = true
+			return nil
+		case 12:
+			if pos >= len(code) {
+				return errCharstring
+			}
+			op2 := int(code[pos])
+			pos++
+			if !t.escape(op2, depth) {
+				continue
+			}
+		default:
+			common.Log.Debug("Unsupported Type 1 charstring operator %d", op)
+		}
+		t.stack = t.stack[:0]
+	}
+	return nil
+}
+
+// moveTo starts a new contour at (x, y), or adds a point to the current flex.
+func (t *type1Interpreter) moveTo(x, y float64) {
+	if t.flexing {
+		t.b.x, t.b.y = x, y
+		t.flexPoints = append(t.flexPoints, x, y)
+		return
+	}
+	t.b.moveTo(x, y)
+}
+
+// curve adds a curve with control and end points relative to the previous point.
+func (t *type1Interpreter) curve(dx1, dy1, dx2, dy2, dx3, dy3 float64) {
+	b := t.b
+	x1, y1 := b.x+dx1, b.y+dy1
+	x2, y2 := x1+dx2, y1+dy2
+	b.cubeTo(x1, y1, x2, y2, x2+dx3, y2+dy3)
+}
+
+// escape runs two byte operator 12 `op`.  Returns true if the operator clears the stack.
+func (t *type1Interpreter) escape(op int, depth int) bool {
+	s := t.stack
+	b := t.b
+	switch op {
+	case 0, 1, 2: // dotsection, vstem3, hstem3
+	case 6: // seac
+		if len(s) >= 5 {
+			t.seac(s[0], s[1], s[2], int(s[3]), int(s[4]), depth)
+			t.ended = true
+		}
+	case 7: // sbw
+		if len(s) >= 4 {
+			t.sbx = s[0]
+			b.x, b.y = s[0], s[1]
+		}
+	case 12: // div
+		if len(s) >= 2 && s[len(s)-1] != 0 {
+			t.stack = append(s[:len(s)-2], s[len(s)-2]/s[len(s)-1])
+		}
+		return false
+	case 16: // callothersubr
+		if len(s) < 2 {
+			return true
+		}
+		othersubr := int(s[len(s)-1])
+		n := int(s[len(s)-2])
+		if n < 0 || n > len(s)-2 {
+			return true
+		}
+		args := s[len(s)-2-n : len(s)-2]
+		t.stack = s[:len(s)-2-n]
+		t.psStack = t.psStack[:0]
+		switch othersubr {
+		case 0:
+			// End of flex: the reference point and 7 points of the two curves.
+			t.flexing = false
+			p := t.flexPoints
+			if len(p) >= 14 {
+				b.cubeTo(p[2], p[3], p[4], p[5], p[6], p[7])
+				b.cubeTo(p[8], p[9], p[10], p[11], p[12], p[13])
+			}
+			t.psStack = append(t.psStack, b.y, b.x)
+		case 1:
+			t.flexing = true
+			t.flexPoints = t.flexPoints[:0]
+			t.b.ensureOpen()
+		case 2:
+		default:
+			// Hint replacement and other subroutines return their arguments.
+			for i := len(args) - 1; i >= 0; i-- {
+				t.psStack = append(t.psStack, args[i])
+			}
+		}
+		return false
+	case 17: // pop
+		if len(t.psStack) > 0 {
+			t.stack = append(t.stack, t.psStack[len(t.psStack)-1])
+			t.psStack = t.psStack[:len(t.psStack)-1]
+		}
+		return false
+	case 33: // setcurrentpoint
+		if len(s) >= 2 {
+			b.x, b.y = s[0], s[1]
+		}
+	default:
+		common.Log.Debug("Unsupported Type 1 charstring operator 12 %d", op)
+	}
+	return true
+}
+
+// seac composes an accented character of the glyphs with standard encoding codes `bchar` and `achar`.  The
+// accent with left side bearing `asb` is offset by (adx, ady) from the left side bearing of the base.
+func (t *type1Interpreter) seac(asb, adx, ady float64, bchar, achar int, depth int) {
+	if bchar < 0 || bchar > 255 || achar < 0 || achar > 255 {
+		return
+	}
+	base, okBase := t.font.charStrings[standardEncoding[bchar]]
+	accent, okAccent := t.font.charStrings[standardEncoding[achar]]
+	if !okBase || !okAccent {
+		return
+	}
+
+	dx := adx + t.sbx - asb
+	for _, part := range []struct {
+		cs     []byte
+		dx, dy float64
+	}{{base, 0, 0}, {accent, dx, ady}} {
+		b := &outlineBuilder{}
+		interp := &type1Interpreter{font: t.font, b: b}
+		if err := interp.run(part.cs, depth+1); err != nil {
+			common.Log.Debug("Invalid seac component: %v", err)
+		}
+		b.closePath()
+		t.b.closePath()
+		t.b.outline = append(t.b.outline, b.outline.Transform(1, 0, 0, 1, part.dx, part.dy)...)
+	}
+}
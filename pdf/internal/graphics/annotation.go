@@ -0,0 +1,69 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package graphics
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Annotation flags preventing annotations from being displayed.
+const (
+	annotationFlagHidden = 1 << 1
+	annotationFlagNoView = 1 << 5
+)
+
+// GetAppearance returns the normal appearance stream of annotation `annot` and the matrix mapping its bounding
+// box, transformed by its matrix, to the annotation rectangle.  Returns false if the annotation is not
+// displayed: it is hidden, or has no appearance or an empty bounding box.
+func GetAppearance(annot *model.PdfAnnotation) (*core.PdfObjectStream, contentstream.Matrix, bool) {
+	if flags, err := GetNumber(annot.F); err == nil && int(flags)&(annotationFlagHidden|annotationFlagNoView) != 0 {
+		return nil, contentstream.Matrix{}, false
+	}
+	stream := getNormalAppearance(annot)
+	rect := GetNumbers(annot.Rect)
+	if stream == nil || len(rect) != 4 {
+		return nil, contentstream.Matrix{}, false
+	}
+	bbox := GetNumbers(stream.PdfObjectDictionary.Get("BBox"))
+	if len(bbox) != 4 {
+		return nil, contentstream.Matrix{}, false
+	}
+
+	matrix := GetMatrix(stream.PdfObjectDictionary.Get("Matrix"))
+	llx, lly, urx, ury := matrix.TransformRectangle(bbox[0], bbox[1], bbox[2], bbox[3])
+	if urx-llx == 0 || ury-lly == 0 {
+		return nil, contentstream.Matrix{}, false
+	}
+	rllx, rlly := math.Min(rect[0], rect[2]), math.Min(rect[1], rect[3])
+	rurx, rury := math.Max(rect[0], rect[2]), math.Max(rect[1], rect[3])
+	sx, sy := (rurx-rllx)/(urx-llx), (rury-rlly)/(ury-lly)
+	return stream, contentstream.NewMatrix(sx, 0, 0, sy, rllx-llx*sx, rlly-lly*sy), true
+}
+
+// getNormalAppearance returns the normal appearance stream of annotation `annot`, nil if none.
+func getNormalAppearance(annot *model.PdfAnnotation) *core.PdfObjectStream {
+	ap, ok := core.TraceToDirectObject(annot.AP).(*core.PdfObjectDictionary)
+	if !ok {
+		return nil
+	}
+	switch n := core.TraceToDirectObject(ap.Get("N")).(type) {
+	case *core.PdfObjectStream:
+		return n
+	case *core.PdfObjectDictionary:
+		// Appearance states are selected by AS.
+		state, ok := core.TraceToDirectObject(annot.AS).(*core.PdfObjectName)
+		if !ok {
+			return nil
+		}
+		stream, _ := core.TraceToDirectObject(n.Get(*state)).(*core.PdfObjectStream)
+		return stream
+	}
+	return nil
+}
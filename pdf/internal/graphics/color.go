@@ -0,0 +1,81 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package graphics
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// ColorToRGB returns the RGB components of color `color` of colorspace `cs`.  Returns black and false if it
+// cannot be converted.
+func ColorToRGB(cs model.PdfColorspace, color model.PdfColor) (float64, float64, float64, bool) {
+	switch c := color.(type) {
+	case *model.PdfColorDeviceGray:
+		return c.Val(), c.Val(), c.Val(), true
+	case *model.PdfColorDeviceRGB:
+		return c.R(), c.G(), c.B(), true
+	case *model.PdfColorDeviceCMYK:
+		r, g, b := cmykToRGB(c.C(), c.M(), c.Y(), c.K())
+		return r, g, b, true
+	}
+	if cs == nil || color == nil {
+		return 0, 0, 0, false
+	}
+	rgb, err := cs.ColorToRGB(color)
+	if err != nil {
+		common.Log.Debug("Failed converting %s color to RGB: %v", cs, err)
+		return 0, 0, 0, false
+	}
+	if c, ok := rgb.(*model.PdfColorDeviceRGB); ok {
+		return c.R(), c.G(), c.B(), true
+	}
+	return 0, 0, 0, false
+}
+
+// FloatsToRGB returns the RGB components of the color with components `vals` of colorspace `cs`.  Returns
+// black and false if it cannot be converted.
+func FloatsToRGB(cs model.PdfColorspace, vals []float64) (float64, float64, float64, bool) {
+	switch cs.(type) {
+	case *model.PdfColorspaceDeviceGray, *model.PdfColorspaceCalGray:
+		if len(vals) == 1 {
+			return Clamp01(vals[0]), Clamp01(vals[0]), Clamp01(vals[0]), true
+		}
+	case *model.PdfColorspaceDeviceRGB, *model.PdfColorspaceCalRGB:
+		if len(vals) == 3 {
+			return Clamp01(vals[0]), Clamp01(vals[1]), Clamp01(vals[2]), true
+		}
+	case *model.PdfColorspaceDeviceCMYK:
+		if len(vals) == 4 {
+			r, g, b := cmykToRGB(vals[0], vals[1], vals[2], vals[3])
+			return r, g, b, true
+		}
+	case *model.PdfColorspaceICCBased:
+		switch len(vals) {
+		case 1:
+			return Clamp01(vals[0]), Clamp01(vals[0]), Clamp01(vals[0]), true
+		case 3:
+			return Clamp01(vals[0]), Clamp01(vals[1]), Clamp01(vals[2]), true
+		case 4:
+			r, g, b := cmykToRGB(vals[0], vals[1], vals[2], vals[3])
+			return r, g, b, true
+		}
+	}
+	if cs == nil {
+		return 0, 0, 0, false
+	}
+	color, err := cs.ColorFromFloats(vals)
+	if err != nil {
+		common.Log.Debug("Invalid %s color %v: %v", cs, vals, err)
+		return 0, 0, 0, false
+	}
+	return ColorToRGB(cs, color)
+}
+
+// cmykToRGB returns the RGB components of the CMYK color (c, m, y, k).
+func cmykToRGB(c, m, y, k float64) (float64, float64, float64) {
+	return Clamp01((1 - c) * (1 - k)), Clamp01((1 - m) * (1 - k)), Clamp01((1 - y) * (1 - k))
+}
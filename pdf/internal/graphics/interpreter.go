@@ -0,0 +1,363 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package graphics interprets PDF content streams for the renderer, svg and redactor packages: it tracks the
+// graphics and text state, builds paths, lays out shown glyphs and skips hidden optional content, and passes
+// what is painted to a Device.
+package graphics
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Device paints the content interpreted by an Interpreter.
+type Device interface {
+	// State returns the parameters of the graphics state which are set by the operators.
+	State() *State
+	// Save and Restore push and pop the graphics state of the device, including State, for the q and Q
+	// operators.
+	Save()
+	Restore()
+	// LoadFont returns the font of font dictionary `obj`, with default metrics if nil or invalid.
+	LoadFont(obj core.PdfObject) *fontfile.Font
+	// SetExtGStateEntry sets the parameter of the entry `key` with value `val` of a graphics state parameter
+	// dictionary which is not held by State, such as the blend mode BM and the soft mask SMask.
+	SetExtGStateEntry(key core.PdfObjectName, val core.PdfObject, ctm contentstream.Matrix)
+
+	// PaintPath paints path `p` in user space, transformed by `ctm` to device space: filled with the
+	// nonstroking color by `rule` if `fill` is set, then stroked with the stroking color if `stroke` is set.
+	PaintPath(p *Path, ctm contentstream.Matrix, fill bool, rule FillRule, stroke bool)
+	// ClipPath intersects the clipping path with path `p` in user space, transformed by `ctm`.
+	ClipPath(p *Path, ctm contentstream.Matrix, rule FillRule)
+	// DrawShading paints shading `name` of the resources over the clipping region, as by the sh operator.
+	DrawShading(name core.PdfObjectName, ctm contentstream.Matrix)
+	// DrawXObject paints XObject `name` of the resources, an image or a form.
+	DrawXObject(name core.PdfObjectName, ctm contentstream.Matrix)
+	// DrawInlineImage paints inline image `inline`.
+	DrawInlineImage(inline *contentstream.ContentStreamInlineImage, ctm contentstream.Matrix)
+
+	// BeginText and EndText start and end a text object.  The glyphs shown in the text object with a clipping
+	// text rendering mode are added to the clipping path at its end.
+	BeginText()
+	EndText()
+	// ShowText paints the glyphs `glyphs` of font `font` shown by a text showing operation, with text matrix
+	// `tm` at the start of the operation.
+	ShowText(font *fontfile.Font, tm contentstream.Matrix, glyphs []Glyph, ctm contentstream.Matrix)
+}
+
+// OperationHandler is implemented by devices which handle the content stream operations themselves, such as
+// to copy them with changes.
+type OperationHandler interface {
+	// HandleOperation is called with each operation `op` after it is interpreted, with the matrix `ctm`
+	// mapping user space to device space.
+	HandleOperation(op *contentstream.ContentStreamOperation, ctm contentstream.Matrix)
+}
+
+// Glyph is a glyph shown by a text showing operation.
+type Glyph struct {
+	Code int
+	// Trm maps text space for a font size of 1 to user space.
+	Trm contentstream.Matrix
+	// X, Y is the position of the glyph relative to the start of the operation, in text space without
+	// horizontal scaling, and Advance its horizontal advance, 0 for vertical writing.
+	X, Y    float64
+	Advance float64
+}
+
+// Interpreter interprets content streams, painting them with a Device.
+type Interpreter struct {
+	Device    Device
+	Resources *model.PdfPageResources
+	// OCProperties are the optional content properties of the document, nil for all content visible.
+	OCProperties *model.PdfOCProperties
+	// Base maps the coordinate system at the start of the content stream to device space.
+	Base contentstream.Matrix
+	// GS is the graphics state tracked by the processor at the current operation.
+	GS contentstream.GraphicsState
+
+	// hidden is the stack of open marked-content sequences, with the flag set for hidden optional content.
+	hidden []bool
+	// tm and tlm are the text matrix and the text line matrix.
+	tm, tlm contentstream.Matrix
+}
+
+// NewInterpreter returns an Interpreter of content streams with resources `resources`, drawn with matrix `base`
+// by `device`.
+func NewInterpreter(device Device, resources *model.PdfPageResources, ocProperties *model.PdfOCProperties,
+	base contentstream.Matrix) *Interpreter {
+	if resources == nil {
+		resources = model.NewPdfPageResources()
+	}
+	return &Interpreter{Device: device, Resources: resources, OCProperties: ocProperties, Base: base}
+}
+
+// IsHidden checks whether the current operation is in hidden optional content.
+func (i *Interpreter) IsHidden() bool {
+	return len(i.hidden) > 0 && i.hidden[len(i.hidden)-1]
+}
+
+// IsVisible checks whether the XObject or annotation with optional content entry `oc` is visible.
+func (i *Interpreter) IsVisible(oc core.PdfObject) bool {
+	if i.OCProperties == nil || core.TraceToDirectObject(oc) == nil {
+		return true
+	}
+	if _, isNull := core.TraceToDirectObject(oc).(*core.PdfObjectNull); isNull {
+		return true
+	}
+	visible, err := i.OCProperties.IsContentVisible(oc)
+	if err != nil {
+		common.Log.Debug("Invalid optional content: %v", err)
+		return true
+	}
+	return visible
+}
+
+// ClipRectangle intersects the clipping path with the rectangle (llx, lly, urx, ury) transformed by `ctm`.
+func (i *Interpreter) ClipRectangle(llx, lly, urx, ury float64, ctm contentstream.Matrix) {
+	i.Device.ClipPath(NewRectangle(llx, lly, urx, ury), ctm, NonZeroWinding)
+}
+
+// Process paints the operations of `content` with the initial graphics state `gs` of the processor.
+func (i *Interpreter) Process(content string, gs contentstream.GraphicsState) error {
+	parser := contentstream.NewContentStreamParser(content)
+	operations, err := parser.Parse()
+	if err != nil {
+		return err
+	}
+	return i.processOperations(*operations, gs)
+}
+
+// processOperations paints the content stream operations `ops`.
+func (i *Interpreter) processOperations(ops contentstream.ContentStreamOperations,
+	gs contentstream.GraphicsState) error {
+	p := &Path{}
+	var clipRule *FillRule
+
+	processor := contentstream.NewContentStreamProcessor(ops)
+	processor.SetInitialGraphicsState(gs)
+	processor.AddHandler(contentstream.HandlerConditionEnumAllOperands, "",
+		func(op *contentstream.ContentStreamOperation, gs contentstream.GraphicsState, resources *model.PdfPageResources) error {
+			i.GS = gs
+			ctm := gs.CTM.Mult(i.Base)
+			nums := GetOperandNumbers(op.Params)
+			state := i.Device.State()
+
+			switch op.Operand {
+			case "q":
+				i.Device.Save()
+			case "Q":
+				i.Device.Restore()
+			case "w":
+				if len(nums) == 1 {
+					state.LineWidth = nums[0]
+				}
+			case "J":
+				if len(nums) == 1 {
+					state.LineCap = int(nums[0])
+				}
+			case "j":
+				if len(nums) == 1 {
+					state.LineJoin = int(nums[0])
+				}
+			case "M":
+				if len(nums) == 1 {
+					state.MiterLimit = nums[0]
+				}
+			case "d":
+				if len(op.Params) == 2 {
+					phase, _ := GetNumber(op.Params[1])
+					state.SetDash(GetNumbers(op.Params[0]), phase)
+				}
+			case "gs":
+				if len(op.Params) == 1 {
+					if name, ok := op.Params[0].(*core.PdfObjectName); ok {
+						i.applyExtGState(*name, ctm)
+					}
+				}
+
+			case "m":
+				if len(nums) == 2 {
+					p.MoveTo(nums[0], nums[1])
+				}
+			case "l":
+				if len(nums) == 2 {
+					p.LineTo(nums[0], nums[1])
+				}
+			case "c":
+				if len(nums) == 6 {
+					p.CurveTo(nums[0], nums[1], nums[2], nums[3], nums[4], nums[5])
+				}
+			case "v":
+				if len(nums) == 4 {
+					p.CurveTo(p.current.X, p.current.Y, nums[0], nums[1], nums[2], nums[3])
+				}
+			case "y":
+				if len(nums) == 4 {
+					p.CurveTo(nums[0], nums[1], nums[2], nums[3], nums[2], nums[3])
+				}
+			case "h":
+				p.ClosePath()
+			case "re":
+				if len(nums) == 4 {
+					p.Rect(nums[0], nums[1], nums[2], nums[3])
+				}
+			case "W", "W*":
+				rule := NonZeroWinding
+				if op.Operand == "W*" {
+					rule = EvenOdd
+				}
+				clipRule = &rule
+			case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+				if op.Operand == "s" || op.Operand == "b" || op.Operand == "b*" {
+					p.ClosePath()
+				}
+				fill, rule, stroke := false, NonZeroWinding, false
+				switch op.Operand {
+				case "f", "F", "B", "b":
+					fill = true
+				case "f*", "B*", "b*":
+					fill, rule = true, EvenOdd
+				}
+				switch op.Operand {
+				case "S", "s", "B", "B*", "b", "b*":
+					stroke = true
+				}
+				if fill || stroke {
+					i.Device.PaintPath(p, ctm, fill, rule, stroke)
+				}
+				if clipRule != nil {
+					i.Device.ClipPath(p, ctm, *clipRule)
+				}
+				p = &Path{}
+				clipRule = nil
+
+			case "sh":
+				if len(op.Params) == 1 {
+					if name, ok := op.Params[0].(*core.PdfObjectName); ok {
+						i.Device.DrawShading(*name, ctm)
+					}
+				}
+			case "Do":
+				if len(op.Params) == 1 {
+					if name, ok := op.Params[0].(*core.PdfObjectName); ok {
+						i.Device.DrawXObject(*name, ctm)
+					}
+				}
+			case "BI":
+				if len(op.Params) == 1 {
+					if inline, ok := op.Params[0].(*contentstream.ContentStreamInlineImage); ok {
+						i.Device.DrawInlineImage(inline, ctm)
+					}
+				}
+
+			case "BMC":
+				i.hidden = append(i.hidden, i.IsHidden())
+			case "BDC":
+				i.hidden = append(i.hidden, i.IsHidden() || !i.isMarkedContentVisible(op))
+			case "EMC":
+				if len(i.hidden) > 0 {
+					i.hidden = i.hidden[:len(i.hidden)-1]
+				}
+
+			default:
+				i.handleTextOperation(op, nums, ctm)
+			}
+
+			if handler, ok := i.Device.(OperationHandler); ok {
+				handler.HandleOperation(op, ctm)
+			}
+			return nil
+		})
+
+	return processor.Process(i.Resources)
+}
+
+// isMarkedContentVisible checks whether the marked-content sequence started by BDC operation `op` is visible:
+// it is not optional content or its optional content is visible.
+func (i *Interpreter) isMarkedContentVisible(op *contentstream.ContentStreamOperation) bool {
+	if i.OCProperties == nil || len(op.Params) != 2 {
+		return true
+	}
+	if tag, ok := op.Params[0].(*core.PdfObjectName); !ok || *tag != "OC" {
+		return true
+	}
+	obj := op.Params[1]
+	if name, ok := obj.(*core.PdfObjectName); ok {
+		var found bool
+		obj, found = i.Resources.GetPropertiesByName(*name)
+		if !found {
+			common.Log.Debug("Optional content properties %s not found", *name)
+			return true
+		}
+	}
+	visible, err := i.OCProperties.IsContentVisible(obj)
+	if err != nil {
+		common.Log.Debug("Invalid optional content: %v", err)
+		return true
+	}
+	return visible
+}
+
+// applyExtGState sets the parameters of graphics state parameter dictionary `name` in the resources.
+func (i *Interpreter) applyExtGState(name core.PdfObjectName, ctm contentstream.Matrix) {
+	obj, found := i.Resources.GetExtGState(name)
+	if !found {
+		common.Log.Debug("ExtGState %s not found", name)
+		return
+	}
+	dict, ok := core.TraceToDirectObject(obj).(*core.PdfObjectDictionary)
+	if !ok {
+		common.Log.Debug("Invalid ExtGState %s (%T)", name, obj)
+		return
+	}
+
+	state := i.Device.State()
+	for _, key := range dict.Keys() {
+		val := dict.Get(key)
+		num, numErr := GetNumber(val)
+		switch key {
+		case "LW":
+			if numErr == nil {
+				state.LineWidth = num
+			}
+		case "LC":
+			if numErr == nil {
+				state.LineCap = int(num)
+			}
+		case "LJ":
+			if numErr == nil {
+				state.LineJoin = int(num)
+			}
+		case "ML":
+			if numErr == nil {
+				state.MiterLimit = num
+			}
+		case "D":
+			if arr, ok := core.TraceToDirectObject(val).(*core.PdfObjectArray); ok && len(*arr) == 2 {
+				phase, _ := GetNumber((*arr)[1])
+				state.SetDash(GetNumbers((*arr)[0]), phase)
+			}
+		case "CA":
+			if numErr == nil {
+				state.StrokeAlpha = Clamp01(num)
+			}
+		case "ca":
+			if numErr == nil {
+				state.FillAlpha = Clamp01(num)
+			}
+		case "Font":
+			if arr, ok := core.TraceToDirectObject(val).(*core.PdfObjectArray); ok && len(*arr) == 2 {
+				state.Font = i.Device.LoadFont((*arr)[0])
+				state.FontSize, _ = GetNumber((*arr)[1])
+			}
+		default:
+			i.Device.SetExtGStateEntry(key, val, ctm)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package graphics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+)
+
+// testDevice records what is painted by an Interpreter.
+type testDevice struct {
+	state  State
+	stack  []State
+	paths  []*Path
+	ctms   []contentstream.Matrix
+	clips  []*Path
+	glyphs []Glyph
+	text   int
+	ops    []string
+}
+
+func (d *testDevice) State() *State {
+	return &d.state
+}
+
+func (d *testDevice) Save() {
+	d.stack = append(d.stack, d.state)
+}
+
+func (d *testDevice) Restore() {
+	if len(d.stack) > 0 {
+		d.state = d.stack[len(d.stack)-1]
+		d.stack = d.stack[:len(d.stack)-1]
+	}
+}
+
+func (d *testDevice) LoadFont(obj core.PdfObject) *fontfile.Font {
+	return fontfile.Load(obj)
+}
+
+func (d *testDevice) SetExtGStateEntry(key core.PdfObjectName, val core.PdfObject, ctm contentstream.Matrix) {
+}
+
+func (d *testDevice) PaintPath(p *Path, ctm contentstream.Matrix, fill bool, rule FillRule, stroke bool) {
+	d.paths = append(d.paths, p)
+	d.ctms = append(d.ctms, ctm)
+}
+
+func (d *testDevice) ClipPath(p *Path, ctm contentstream.Matrix, rule FillRule) {
+	d.clips = append(d.clips, p)
+}
+
+func (d *testDevice) DrawShading(name core.PdfObjectName, ctm contentstream.Matrix) {}
+
+func (d *testDevice) DrawXObject(name core.PdfObjectName, ctm contentstream.Matrix) {}
+
+func (d *testDevice) DrawInlineImage(inline *contentstream.ContentStreamInlineImage, ctm contentstream.Matrix) {
+}
+
+func (d *testDevice) BeginText() {
+	d.text++
+}
+
+func (d *testDevice) EndText() {}
+
+func (d *testDevice) ShowText(font *fontfile.Font, tm contentstream.Matrix, glyphs []Glyph,
+	ctm contentstream.Matrix) {
+	d.glyphs = append(d.glyphs, glyphs...)
+}
+
+func (d *testDevice) HandleOperation(op *contentstream.ContentStreamOperation, ctm contentstream.Matrix) {
+	d.ops = append(d.ops, op.Operand)
+}
+
+func TestInterpreterPaths(t *testing.T) {
+	d := &testDevice{state: NewState()}
+	i := NewInterpreter(d, nil, nil, contentstream.IdentityMatrix())
+	content := "q 2 w 1 0 0 1 10 20 cm 0 0 5 5 re f Q 0 0 1 1 re W n"
+	if err := i.Process(content, DefaultProcessorState()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if len(d.paths) != 1 || len(d.clips) != 1 {
+		t.Fatalf("Painted %d paths and %d clipping paths, expected 1 and 1", len(d.paths), len(d.clips))
+	}
+	if sp := d.paths[0].Subpaths; len(sp) != 1 || len(sp[0].Segments) != 3 || !sp[0].Closed {
+		t.Errorf("Invalid rectangle path %+v", sp)
+	}
+	if d.ctms[0] != contentstream.TranslationMatrix(10, 20) {
+		t.Errorf("Path painted with matrix %v, expected a translation by (10, 20)", d.ctms[0])
+	}
+	if d.state.LineWidth != 1 {
+		t.Errorf("Line width %g after Q, expected 1", d.state.LineWidth)
+	}
+}
+
+func TestInterpreterText(t *testing.T) {
+	d := &testDevice{state: NewState()}
+	i := NewInterpreter(d, nil, nil, contentstream.IdentityMatrix())
+	content := "BT 2 0 0 2 100 200 Tm /F1 10 Tf 1 Tc (ab) Tj ET"
+	if err := i.Process(content, DefaultProcessorState()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if d.text != 1 || len(d.glyphs) != 2 {
+		t.Fatalf("Shown %d glyphs in %d text objects, expected 2 in 1", len(d.glyphs), d.text)
+	}
+	// Fonts without metrics have glyphs half an em wide.
+	if g := d.glyphs[1]; g.X != 6 || g.Y != 0 || g.Advance != 5 {
+		t.Errorf("Second glyph at (%g, %g) with advance %g, expected (6, 0) and 5", g.X, g.Y, g.Advance)
+	}
+	if trm, expected := d.glyphs[1].Trm, contentstream.NewMatrix(20, 0, 0, 20, 112, 200); trm != expected {
+		t.Errorf("Second glyph drawn with %v, expected %v", trm, expected)
+	}
+}
+
+func TestInterpreterOperations(t *testing.T) {
+	d := &testDevice{state: NewState()}
+	i := NewInterpreter(d, nil, nil, contentstream.IdentityMatrix())
+	content := "q 0 0 1 1 re f Q BT (a) Tj ET"
+	if err := i.Process(content, DefaultProcessorState()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// The operations are handled after they are interpreted.
+	if ops := strings.Join(d.ops, " "); ops != "q re f Q BT Tj ET" {
+		t.Errorf("Handled operations %q, expected all operations", ops)
+	}
+	if len(d.paths) != 1 || len(d.glyphs) != 1 {
+		t.Errorf("Painted %d paths and %d glyphs, expected 1 and 1", len(d.paths), len(d.glyphs))
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package graphics
+
+import (
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+)
+
+// FillRule is the rule determining the inside of a path for filling and clipping.
+type FillRule int
+
+const (
+	NonZeroWinding FillRule = iota
+	EvenOdd
+)
+
+// Point is a point in user or device space.
+type Point struct {
+	X, Y float64
+}
+
+// Transform returns the point transformed by `m`.
+func (p Point) Transform(m contentstream.Matrix) Point {
+	x, y := m.Transform(p.X, p.Y)
+	return Point{x, y}
+}
+
+// Segment is a straight line or cubic Bézier curve segment ending at `End`.  The control points `C1` and `C2`
+// are only used for curves.
+type Segment struct {
+	Curve  bool
+	C1, C2 Point
+	End    Point
+}
+
+// Subpath is a sequence of connected segments starting at `Start`.
+type Subpath struct {
+	Start    Point
+	Segments []Segment
+	Closed   bool
+}
+
+// Path is a PDF path, a sequence of subpaths, in the coordinates of the operations constructing it.
+type Path struct {
+	Subpaths []*Subpath
+	current  Point
+}
+
+// NewRectangle returns the path of the rectangle with corners (llx, lly) and (urx, ury).
+func NewRectangle(llx, lly, urx, ury float64) *Path {
+	p := &Path{}
+	p.Rect(llx, lly, urx-llx, ury-lly)
+	return p
+}
+
+// IsEmpty checks whether the path has no subpaths.
+func (p *Path) IsEmpty() bool {
+	return len(p.Subpaths) == 0
+}
+
+// last returns the subpath under construction, starting a new one at the current point if none is open.
+func (p *Path) last() *Subpath {
+	if len(p.Subpaths) == 0 || p.Subpaths[len(p.Subpaths)-1].Closed {
+		p.MoveTo(p.current.X, p.current.Y)
+	}
+	return p.Subpaths[len(p.Subpaths)-1]
+}
+
+// MoveTo starts a new subpath at (x, y).
+func (p *Path) MoveTo(x, y float64) {
+	// A moveto following a moveto replaces it.
+	if n := len(p.Subpaths); n > 0 && len(p.Subpaths[n-1].Segments) == 0 && !p.Subpaths[n-1].Closed {
+		p.Subpaths = p.Subpaths[:n-1]
+	}
+	p.current = Point{x, y}
+	p.Subpaths = append(p.Subpaths, &Subpath{Start: p.current})
+}
+
+// LineTo appends a straight line to (x, y).
+func (p *Path) LineTo(x, y float64) {
+	sp := p.last()
+	p.current = Point{x, y}
+	sp.Segments = append(sp.Segments, Segment{End: p.current})
+}
+
+// CurveTo appends a cubic Bézier curve with control points (x1, y1) and (x2, y2) to (x3, y3).
+func (p *Path) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	sp := p.last()
+	p.current = Point{x3, y3}
+	sp.Segments = append(sp.Segments, Segment{Curve: true, C1: Point{x1, y1}, C2: Point{x2, y2}, End: p.current})
+}
+
+// ClosePath closes the current subpath, the current point becomes its start.
+func (p *Path) ClosePath() {
+	if len(p.Subpaths) == 0 {
+		return
+	}
+	sp := p.Subpaths[len(p.Subpaths)-1]
+	sp.Closed = true
+	p.current = sp.Start
+}
+
+// Rect adds a closed rectangle subpath with corner (x, y), width `w` and height `h`.
+func (p *Path) Rect(x, y, w, h float64) {
+	p.MoveTo(x, y)
+	p.LineTo(x+w, y)
+	p.LineTo(x+w, y+h)
+	p.LineTo(x, y+h)
+	p.ClosePath()
+}
+
+// Transform returns the path transformed by `m`.
+func (p *Path) Transform(m contentstream.Matrix) *Path {
+	out := &Path{current: p.current.Transform(m)}
+	for _, sp := range p.Subpaths {
+		tsp := &Subpath{Start: sp.Start.Transform(m), Closed: sp.Closed, Segments: make([]Segment, len(sp.Segments))}
+		for i, seg := range sp.Segments {
+			tsp.Segments[i] = Segment{
+				Curve: seg.Curve,
+				C1:    seg.C1.Transform(m),
+				C2:    seg.C2.Transform(m),
+				End:   seg.End.Transform(m),
+			}
+		}
+		out.Subpaths = append(out.Subpaths, tsp)
+	}
+	return out
+}
+
+// AppendOutline adds the contours of glyph outline `outline` to the path, transformed by `m`.  Quadratic
+// curves are converted to cubic curves.
+func (p *Path) AppendOutline(outline fontfile.Outline, m contentstream.Matrix) {
+	for _, seg := range outline {
+		pts := make([]Point, len(seg.Points)/2)
+		for i := range pts {
+			pts[i] = Point{seg.Points[2*i], seg.Points[2*i+1]}.Transform(m)
+		}
+		switch {
+		case seg.Op == fontfile.MoveTo && len(pts) == 1:
+			p.MoveTo(pts[0].X, pts[0].Y)
+		case seg.Op == fontfile.LineTo && len(pts) == 1:
+			p.LineTo(pts[0].X, pts[0].Y)
+		case seg.Op == fontfile.QuadTo && len(pts) == 2:
+			p0 := p.current
+			c1 := Point{p0.X + 2.0/3*(pts[0].X-p0.X), p0.Y + 2.0/3*(pts[0].Y-p0.Y)}
+			c2 := Point{pts[1].X + 2.0/3*(pts[0].X-pts[1].X), pts[1].Y + 2.0/3*(pts[0].Y-pts[1].Y)}
+			p.CurveTo(c1.X, c1.Y, c2.X, c2.Y, pts[1].X, pts[1].Y)
+		case seg.Op == fontfile.CubeTo && len(pts) == 3:
+			p.CurveTo(pts[0].X, pts[0].Y, pts[1].X, pts[1].Y, pts[2].X, pts[2].Y)
+		case seg.Op == fontfile.Close:
+			p.ClosePath()
+		}
+	}
+}
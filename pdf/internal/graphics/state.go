@@ -0,0 +1,90 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package graphics
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Text rendering modes (9.3.6 in the PDF reference).
+const (
+	TextFill = iota
+	TextStroke
+	TextFillStroke
+	TextInvisible
+	TextFillClip
+	TextStrokeClip
+	TextFillStrokeClip
+	TextClip
+)
+
+// State holds the parameters of the graphics state which are not tracked by the content stream processor and
+// do not depend on the device: the line style, the constant opacities and the text state.
+type State struct {
+	LineWidth  float64
+	LineCap    int
+	LineJoin   int
+	MiterLimit float64
+	// Dash is the dash array, nil for solid lines.
+	Dash        []float64
+	DashPhase   float64
+	StrokeAlpha float64
+	FillAlpha   float64
+
+	Font        *fontfile.Font
+	FontSize    float64
+	CharSpacing float64
+	WordSpacing float64
+	Scaling     float64
+	Leading     float64
+	Rise        float64
+	RenderMode  int
+}
+
+// NewState returns the graphics state at the start of a page.
+func NewState() State {
+	return State{
+		LineWidth:   1,
+		MiterLimit:  10,
+		StrokeAlpha: 1,
+		FillAlpha:   1,
+		Scaling:     100,
+	}
+}
+
+// SetDash sets the dash pattern to `dash` with phase `phase`.  Invalid patterns, with negative lengths or all
+// zero lengths, are replaced by solid lines.
+func (s *State) SetDash(dash []float64, phase float64) {
+	total := 0.0
+	for _, v := range dash {
+		if v < 0 {
+			common.Log.Debug("Invalid dash array %v, using solid line", dash)
+			dash = nil
+			break
+		}
+		total += v
+	}
+	if total == 0 {
+		dash = nil
+	}
+	s.Dash = dash
+	s.DashPhase = phase
+}
+
+// DefaultProcessorState returns the graphics state of the processor at the start of a page: black colors in
+// DeviceGray and the identity matrix.
+func DefaultProcessorState() contentstream.GraphicsState {
+	return contentstream.GraphicsState{
+		ColorspaceStroking:    model.NewPdfColorspaceDeviceGray(),
+		ColorspaceNonStroking: model.NewPdfColorspaceDeviceGray(),
+		ColorStroking:         model.NewPdfColorDeviceGray(0),
+		ColorNonStroking:      model.NewPdfColorDeviceGray(0),
+		CTM:                   contentstream.IdentityMatrix(),
+	}
+}
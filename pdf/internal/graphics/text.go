@@ -0,0 +1,162 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package graphics
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+)
+
+// handleTextOperation handles the text object, state, positioning and showing operation `op` with numeric
+// operands `nums`.  Other operations are ignored.
+func (i *Interpreter) handleTextOperation(op *contentstream.ContentStreamOperation, nums []float64,
+	ctm contentstream.Matrix) {
+	state := i.Device.State()
+	switch op.Operand {
+	case "BT":
+		i.tm = contentstream.IdentityMatrix()
+		i.tlm = contentstream.IdentityMatrix()
+		i.Device.BeginText()
+	case "ET":
+		i.Device.EndText()
+	case "Tc":
+		if len(nums) == 1 {
+			state.CharSpacing = nums[0]
+		}
+	case "Tw":
+		if len(nums) == 1 {
+			state.WordSpacing = nums[0]
+		}
+	case "Tz":
+		if len(nums) == 1 {
+			state.Scaling = nums[0]
+		}
+	case "TL":
+		if len(nums) == 1 {
+			state.Leading = nums[0]
+		}
+	case "Ts":
+		if len(nums) == 1 {
+			state.Rise = nums[0]
+		}
+	case "Tr":
+		if len(nums) == 1 {
+			state.RenderMode = int(nums[0])
+		}
+	case "Tf":
+		if len(op.Params) == 2 {
+			if name, ok := op.Params[0].(*core.PdfObjectName); ok {
+				obj, found := i.Resources.GetFontByName(*name)
+				if !found {
+					common.Log.Debug("Font %s not found", *name)
+				}
+				state.Font = i.Device.LoadFont(obj)
+			}
+			if size, err := GetNumber(op.Params[1]); err == nil {
+				state.FontSize = size
+			}
+		}
+	case "Td", "TD":
+		if len(nums) == 2 {
+			if op.Operand == "TD" {
+				state.Leading = -nums[1]
+			}
+			i.tlm = contentstream.TranslationMatrix(nums[0], nums[1]).Mult(i.tlm)
+			i.tm = i.tlm
+		}
+	case "Tm":
+		if len(nums) == 6 {
+			i.tlm = contentstream.NewMatrix(nums[0], nums[1], nums[2], nums[3], nums[4], nums[5])
+			i.tm = i.tlm
+		}
+	case "T*":
+		i.tlm = contentstream.TranslationMatrix(0, -state.Leading).Mult(i.tlm)
+		i.tm = i.tlm
+	case "Tj", "TJ", "'", "\"":
+		if op.Operand == "'" || op.Operand == "\"" {
+			if op.Operand == "\"" && len(op.Params) == 3 {
+				state.WordSpacing, _ = GetNumber(op.Params[0])
+				state.CharSpacing, _ = GetNumber(op.Params[1])
+			}
+			i.tlm = contentstream.TranslationMatrix(0, -state.Leading).Mult(i.tlm)
+			i.tm = i.tlm
+		}
+		i.showText(op, ctm)
+	}
+}
+
+// showText paints the glyphs of text showing operation `op` and advances the text matrix past them.
+func (i *Interpreter) showText(op *contentstream.ContentStreamOperation, ctm contentstream.Matrix) {
+	var items []core.PdfObject
+	switch op.Operand {
+	case "TJ":
+		if len(op.Params) == 1 {
+			if arr, ok := op.Params[0].(*core.PdfObjectArray); ok {
+				items = *arr
+			}
+		}
+	default:
+		if len(op.Params) > 0 {
+			items = op.Params[len(op.Params)-1:]
+		}
+	}
+
+	state := i.Device.State()
+	font := state.Font
+	if font == nil {
+		common.Log.Debug("Text shown without font, using default metrics")
+		font = i.Device.LoadFont(nil)
+		state.Font = font
+	}
+	th := state.Scaling / 100
+	fs := state.FontSize
+
+	start := i.tm
+	var glyphs []Glyph
+	// ux, uy is the position relative to the start in text space, without horizontal scaling.
+	ux, uy := 0.0, 0.0
+
+	for _, item := range items {
+		switch t := item.(type) {
+		case *core.PdfObjectString:
+			for _, code := range font.Codes([]byte(*t)) {
+				trm := contentstream.NewMatrix(fs*th, 0, 0, fs, 0, state.Rise).Mult(i.tm)
+				spacing := state.CharSpacing
+				if font.IsSpace(code) {
+					spacing += state.WordSpacing
+				}
+				if font.Vertical {
+					w1, vx, vy := font.VerticalMetrics(code)
+					glyphs = append(glyphs, Glyph{
+						Code: code,
+						Trm:  contentstream.TranslationMatrix(-vx, -vy).Mult(trm),
+						X:    ux - vx*fs,
+						Y:    uy - vy*fs,
+					})
+					i.tm = contentstream.TranslationMatrix(0, w1*fs+spacing).Mult(i.tm)
+					uy += w1*fs + spacing
+				} else {
+					w := font.Width(code)
+					glyphs = append(glyphs, Glyph{Code: code, Trm: trm, X: ux, Y: uy, Advance: w * fs})
+					i.tm = contentstream.TranslationMatrix((w*fs+spacing)*th, 0).Mult(i.tm)
+					ux += w*fs + spacing
+				}
+			}
+		case *core.PdfObjectFloat, *core.PdfObjectInteger:
+			n, _ := GetNumber(t)
+			if font.Vertical {
+				i.tm = contentstream.TranslationMatrix(0, -n/1000*fs).Mult(i.tm)
+				uy -= n / 1000 * fs
+			} else {
+				i.tm = contentstream.TranslationMatrix(-n/1000*fs*th, 0).Mult(i.tm)
+				ux -= n / 1000 * fs
+			}
+		}
+	}
+
+	i.Device.ShowText(font, start, glyphs, ctm)
+}
@@ -0,0 +1,75 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package graphics
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+)
+
+// GetNumber returns the value of a number object, integer or float.
+func GetNumber(obj core.PdfObject) (float64, error) {
+	switch t := core.TraceToDirectObject(obj).(type) {
+	case *core.PdfObjectFloat:
+		return float64(*t), nil
+	case *core.PdfObjectInteger:
+		return float64(*t), nil
+	}
+	return 0, errors.New("Not a number")
+}
+
+// GetNumbers returns the values of an array of numbers or nil if `obj` is not an array of numbers.
+func GetNumbers(obj core.PdfObject) []float64 {
+	arr, ok := core.TraceToDirectObject(obj).(*core.PdfObjectArray)
+	if !ok || arr == nil {
+		return nil
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return nil
+	}
+	return vals
+}
+
+// GetOperandNumbers returns the values of operands `params` or nil if they are not all numbers.
+func GetOperandNumbers(params []core.PdfObject) []float64 {
+	arr := core.PdfObjectArray(params)
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return nil
+	}
+	return vals
+}
+
+// GetMatrix returns the matrix of array `obj` of six numbers, or the identity matrix if `obj` is not such an array.
+func GetMatrix(obj core.PdfObject) contentstream.Matrix {
+	if m := GetNumbers(obj); len(m) == 6 {
+		return contentstream.NewMatrix(m[0], m[1], m[2], m[3], m[4], m[5])
+	}
+	return contentstream.IdentityMatrix()
+}
+
+// GetExtend returns the values of the Extend entry of an axial or radial shading.
+func GetExtend(obj *core.PdfObjectArray) [2]bool {
+	extend := [2]bool{}
+	if obj == nil || len(*obj) != 2 {
+		return extend
+	}
+	for i, o := range *obj {
+		if b, ok := core.TraceToDirectObject(o).(*core.PdfObjectBool); ok {
+			extend[i] = bool(*b)
+		}
+	}
+	return extend
+}
+
+// Clamp01 returns `v` clamped to the range [0, 1].
+func Clamp01(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
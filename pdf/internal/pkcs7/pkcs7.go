@@ -0,0 +1,143 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package pkcs7 implements the subset of the Cryptographic Message Syntax (RFC 5652, PKCS#7) needed for
+// PDF digital signatures, i.e. creating and parsing SignedData structures with detached content, and the
+// time-stamp protocol (RFC 3161) structures used for timestamp tokens.
+package pkcs7
+
+import (
+	"crypto"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+)
+
+// Object identifiers.
+var (
+	OIDData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	OIDSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	OIDContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	OIDMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	OIDSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+
+	OIDDigestSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	OIDDigestSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	OIDDigestSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	OIDDigestSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	OIDEncryptionRSA      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	OIDSignatureSHA1RSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	OIDSignatureSHA256RSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	OIDSignatureSHA384RSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	OIDSignatureSHA512RSA = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	OIDEncryptionECDSA    = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	OIDSignatureECDSA256  = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	OIDSignatureECDSA384  = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	OIDSignatureECDSA512  = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	OIDSignatureECDSASHA1 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}
+)
+
+// ErrUnsupportedAlgorithm is returned when a digest or signature algorithm is not supported.
+var ErrUnsupportedAlgorithm = errors.New("Unsupported algorithm")
+
+// contentInfo is the outer structure of a CMS message.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// encapsulatedContentInfo holds the (optionally detached) signed content.
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerial struct {
+	IssuerName   asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// Attribute is a CMS attribute (signed or unsigned) with a single value.
+type Attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// attribute is the ASN.1 representation of an Attribute, the values being a SET OF ANY.
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// getDigestOID returns the object identifier of the digest algorithm `hash`.
+func getDigestOID(hash crypto.Hash) (asn1.ObjectIdentifier, error) {
+	switch hash {
+	case crypto.SHA1:
+		return OIDDigestSHA1, nil
+	case crypto.SHA256:
+		return OIDDigestSHA256, nil
+	case crypto.SHA384:
+		return OIDDigestSHA384, nil
+	case crypto.SHA512:
+		return OIDDigestSHA512, nil
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// GetHashForOID returns the hash function identified by the digest algorithm `oid`.
+func GetHashForOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(OIDDigestSHA1), oid.Equal(OIDSignatureECDSASHA1), oid.Equal(OIDSignatureSHA1RSA):
+		return crypto.SHA1, nil
+	case oid.Equal(OIDDigestSHA256), oid.Equal(OIDSignatureECDSA256), oid.Equal(OIDSignatureSHA256RSA):
+		return crypto.SHA256, nil
+	case oid.Equal(OIDDigestSHA384), oid.Equal(OIDSignatureECDSA384), oid.Equal(OIDSignatureSHA384RSA):
+		return crypto.SHA384, nil
+	case oid.Equal(OIDDigestSHA512), oid.Equal(OIDSignatureECDSA512), oid.Equal(OIDSignatureSHA512RSA):
+		return crypto.SHA512, nil
+	}
+	return 0, ErrUnsupportedAlgorithm
+}
+
+// marshalAttributes encodes `attrs` as a DER SET OF Attribute.
+func marshalAttributes(attrs []Attribute) ([]byte, error) {
+	list := make([]attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		value := attr.Value.FullBytes
+		if len(value) == 0 {
+			b, err := asn1.Marshal(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			value = b
+		}
+		list = append(list, attribute{
+			Type:   attr.Type,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+	}
+	return asn1.MarshalWithParams(list, "set")
+}
@@ -0,0 +1,251 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pkcs7
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"time"
+)
+
+// SignedData represents a CMS SignedData structure with a single signer.
+type SignedData struct {
+	// Certificates included in the structure. The signing certificate is the first one when created by Sign.
+	Certificates []*x509.Certificate
+
+	// ContentType and Content of the encapsulated content. Content is nil for detached signatures.
+	ContentType asn1.ObjectIdentifier
+	Content     []byte
+
+	// Hash is the digest algorithm of the signer.
+	Hash crypto.Hash
+
+	// SignatureAlgorithm identifies the algorithm used for generating Signature.
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+
+	// Signed (authenticated) and unsigned attributes of the signer.
+	SignedAttributes   []Attribute
+	UnsignedAttributes []Attribute
+
+	// Signature is the signature value computed over the DER encoded signed attributes.
+	Signature []byte
+
+	issuerAndSerial issuerAndSerial
+
+	// DER encoding of the signed attributes (SET OF Attribute) as covered by the signature.
+	rawSignedAttributes []byte
+}
+
+// SignOptions contains optional parameters for Sign.
+type SignOptions struct {
+	// SigningTime is added as signed attribute when not zero.
+	SigningTime time.Time
+
+	// ExtraSignedAttributes are appended to the content type, message digest and signing time attributes.
+	ExtraSignedAttributes []Attribute
+
+	// ContentType of the signed content, defaults to OIDData.
+	ContentType asn1.ObjectIdentifier
+
+	// Content is encapsulated in the structure when set (e.g. the TSTInfo of a timestamp token), otherwise
+	// the content is detached.
+	Content []byte
+
+	// NoSignedAttributes omits the signed attributes: the signature is computed over the message digest
+	// directly and SigningTime and ExtraSignedAttributes are ignored.
+	NoSignedAttributes bool
+}
+
+// Sign creates a SignedData structure for content whose message digest `digest` was computed with `hash`.
+// The content is detached unless set in `opts`.  The signature is generated with `signer`, which must correspond to the public key of the
+// first certificate in `certs`.  The remaining certificates (chain) are included as is.
+func Sign(digest []byte, hash crypto.Hash, signer crypto.Signer, certs []*x509.Certificate, opts *SignOptions) (*SignedData, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("Signing certificate missing")
+	}
+	if opts == nil {
+		opts = &SignOptions{}
+	}
+
+	if _, err := getDigestOID(hash); err != nil {
+		return nil, err
+	}
+
+	sigAlg, err := getSignatureAlgorithm(signer.Public(), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	contentTypeOID := OIDData
+	if opts.ContentType != nil {
+		contentTypeOID = opts.ContentType
+	}
+
+	sd := &SignedData{
+		Certificates:       certs,
+		ContentType:        contentTypeOID,
+		Content:            opts.Content,
+		Hash:               hash,
+		SignatureAlgorithm: sigAlg,
+		issuerAndSerial: issuerAndSerial{
+			IssuerName:   asn1.RawValue{FullBytes: certs[0].RawIssuer},
+			SerialNumber: certs[0].SerialNumber,
+		},
+	}
+
+	if opts.NoSignedAttributes {
+		sd.Signature, err = signer.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			return nil, err
+		}
+		return sd, nil
+	}
+
+	contentType, err := asn1.Marshal(contentTypeOID)
+	if err != nil {
+		return nil, err
+	}
+	messageDigest, err := asn1.Marshal(digest)
+	if err != nil {
+		return nil, err
+	}
+	sd.SignedAttributes = []Attribute{
+		{Type: OIDContentType, Value: asn1.RawValue{FullBytes: contentType}},
+		{Type: OIDMessageDigest, Value: asn1.RawValue{FullBytes: messageDigest}},
+	}
+	if !opts.SigningTime.IsZero() {
+		signingTime, err := asn1.Marshal(opts.SigningTime.UTC())
+		if err != nil {
+			return nil, err
+		}
+		sd.SignedAttributes = append(sd.SignedAttributes,
+			Attribute{Type: OIDSigningTime, Value: asn1.RawValue{FullBytes: signingTime}})
+	}
+	sd.SignedAttributes = append(sd.SignedAttributes, opts.ExtraSignedAttributes...)
+
+	sd.rawSignedAttributes, err = marshalAttributes(sd.SignedAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature is computed over the DER encoding of the signed attributes.
+	h := hash.New()
+	h.Write(sd.rawSignedAttributes)
+	sd.Signature, err = signer.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return sd, nil
+}
+
+// AddUnsignedAttribute adds an unsigned attribute to the signer, e.g. a signature timestamp token.
+func (sd *SignedData) AddUnsignedAttribute(attr Attribute) {
+	sd.UnsignedAttributes = append(sd.UnsignedAttributes, attr)
+}
+
+// Marshal returns the DER encoding of the SignedData wrapped in a ContentInfo structure.
+func (sd *SignedData) Marshal() ([]byte, error) {
+	digestOID, err := getDigestOID(sd.Hash)
+	if err != nil {
+		return nil, err
+	}
+	digestAlg := pkix.AlgorithmIdentifier{Algorithm: digestOID, Parameters: asn1.NullRawValue}
+
+	si := signerInfo{
+		Version:                   1,
+		IssuerAndSerialNumber:     sd.issuerAndSerial,
+		DigestAlgorithm:           digestAlg,
+		DigestEncryptionAlgorithm: sd.SignatureAlgorithm,
+		EncryptedDigest:           sd.Signature,
+	}
+	if len(sd.rawSignedAttributes) > 0 {
+		si.AuthenticatedAttributes, err = setToTagged(sd.rawSignedAttributes, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(sd.UnsignedAttributes) > 0 {
+		raw, err := marshalAttributes(sd.UnsignedAttributes)
+		if err != nil {
+			return nil, err
+		}
+		si.UnauthenticatedAttributes, err = setToTagged(raw, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var certs []byte
+	for _, cert := range sd.Certificates {
+		certs = append(certs, cert.Raw...)
+	}
+
+	version := 1
+	if !sd.ContentType.Equal(OIDData) {
+		version = 3
+	}
+	data := signedData{
+		Version:          version,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{digestAlg},
+		ContentInfo:      encapsulatedContentInfo{EContentType: sd.ContentType},
+		SignerInfos:      []signerInfo{si},
+	}
+	if sd.Content != nil {
+		octets, err := asn1.Marshal(sd.Content)
+		if err != nil {
+			return nil, err
+		}
+		data.ContentInfo.EContent = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets}
+	}
+	if len(certs) > 0 {
+		data.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs}
+	}
+
+	inner, err := asn1.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: OIDSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
+
+// setToTagged re-tags a DER encoded SET OF as an implicitly tagged [tag] value.
+func setToTagged(der []byte, tag int) (asn1.RawValue, error) {
+	var set asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &set); err != nil {
+		return set, err
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: set.Bytes}, nil
+}
+
+// getSignatureAlgorithm returns the signature algorithm identifier for public key `pub` and digest `hash`.
+func getSignatureAlgorithm(pub crypto.PublicKey, hash crypto.Hash) (pkix.AlgorithmIdentifier, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: OIDEncryptionRSA, Parameters: asn1.NullRawValue}, nil
+	case *ecdsa.PublicKey:
+		switch hash {
+		case crypto.SHA1:
+			return pkix.AlgorithmIdentifier{Algorithm: OIDSignatureECDSASHA1}, nil
+		case crypto.SHA256:
+			return pkix.AlgorithmIdentifier{Algorithm: OIDSignatureECDSA256}, nil
+		case crypto.SHA384:
+			return pkix.AlgorithmIdentifier{Algorithm: OIDSignatureECDSA384}, nil
+		case crypto.SHA512:
+			return pkix.AlgorithmIdentifier{Algorithm: OIDSignatureECDSA512}, nil
+		}
+	}
+	return pkix.AlgorithmIdentifier{}, ErrUnsupportedAlgorithm
+}
@@ -0,0 +1,153 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pkcs7
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// Time-Stamp Protocol (RFC 3161) structures.
+
+// OIDTSTInfo is the content type of the encapsulated content of a timestamp token.
+var OIDTSTInfo = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+
+// PKIStatus values of a TimeStampResp.
+const (
+	StatusGranted         = 0
+	StatusGrantedWithMods = 1
+	StatusRejection       = 2
+	StatusWaiting         = 3
+)
+
+// MessageImprint is the digest of the timestamped data along with the digest algorithm.
+type MessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// Hash returns the hash function of the message imprint.
+func (mi MessageImprint) Hash() (crypto.Hash, error) {
+	return GetHashForOID(mi.HashAlgorithm.Algorithm)
+}
+
+// TimeStampReq is a request sent to a time stamping authority.
+type TimeStampReq struct {
+	Version        int
+	MessageImprint MessageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+// NewTimeStampReq returns a request for timestamping the data with digest `digest` computed with `hash`.
+// A random nonce is included and the certificate of the authority is requested.
+func NewTimeStampReq(digest []byte, hash crypto.Hash) (*TimeStampReq, error) {
+	oid, err := getDigestOID(hash)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TimeStampReq{
+		Version: 1,
+		MessageImprint: MessageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	}, nil
+}
+
+// ParseTimeStampReq parses a DER encoded TimeStampReq.
+func ParseTimeStampReq(data []byte) (*TimeStampReq, error) {
+	req := &TimeStampReq{}
+	if _, err := asn1.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Marshal returns the DER encoding of the request.
+func (req *TimeStampReq) Marshal() ([]byte, error) {
+	return asn1.Marshal(*req)
+}
+
+// PKIStatusInfo is the status of a TimeStampResp.
+type PKIStatusInfo struct {
+	Status       int
+	StatusString asn1.RawValue  `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// TimeStampResp is the response of a time stamping authority.  TimeStampToken is a DER encoded
+// ContentInfo containing a SignedData structure, present if the request was granted.
+type TimeStampResp struct {
+	Status         PKIStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// ParseTimeStampResp parses a DER encoded TimeStampResp.
+func ParseTimeStampResp(data []byte) (*TimeStampResp, error) {
+	resp := &TimeStampResp{}
+	if _, err := asn1.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Marshal returns the DER encoding of the response.
+func (resp *TimeStampResp) Marshal() ([]byte, error) {
+	return asn1.Marshal(*resp)
+}
+
+// Accuracy of the time in a TSTInfo.
+type Accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// TSTInfo is the encapsulated content of a timestamp token.
+type TSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint MessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time        `asn1:"generalized"`
+	Accuracy       Accuracy         `asn1:"optional"`
+	Ordering       bool             `asn1:"optional"`
+	Nonce          *big.Int         `asn1:"optional"`
+	TSA            asn1.RawValue    `asn1:"optional,tag:0"`
+	Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+// Marshal returns the DER encoding of the TSTInfo.
+func (info *TSTInfo) Marshal() ([]byte, error) {
+	return asn1.Marshal(*info)
+}
+
+// TSTInfo returns the TSTInfo encapsulated in the SignedData of a timestamp token.
+func (sd *SignedData) TSTInfo() (*TSTInfo, error) {
+	if !sd.ContentType.Equal(OIDTSTInfo) {
+		return nil, errors.New("Not a timestamp token")
+	}
+	info := &TSTInfo{}
+	if _, err := asn1.Unmarshal(sd.Content, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
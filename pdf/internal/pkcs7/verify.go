@@ -0,0 +1,221 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrDigestMismatch is returned when the message digest attribute does not match the content digest.
+	ErrDigestMismatch = errors.New("Message digest mismatch")
+
+	// ErrInvalidSignature is returned when the signature value can not be verified with the signer's public key.
+	ErrInvalidSignature = errors.New("Invalid signature")
+
+	// ErrSignerNotFound is returned when the signing certificate is not included in the structure.
+	ErrSignerNotFound = errors.New("Signer certificate not found")
+)
+
+// Parse parses a DER encoded ContentInfo containing a SignedData structure.  Trailing data (e.g. the zero
+// padding of PDF signature contents) is ignored.  Only structures with a single signer are supported.
+func Parse(data []byte) (*SignedData, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(data, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(OIDSignedData) {
+		return nil, errors.New("Not a SignedData structure")
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, errors.New("Only a single signer is supported")
+	}
+	si := sd.SignerInfos[0]
+
+	p := &SignedData{
+		ContentType:        sd.ContentInfo.EContentType,
+		SignatureAlgorithm: si.DigestEncryptionAlgorithm,
+		Signature:          si.EncryptedDigest,
+		issuerAndSerial:    si.IssuerAndSerialNumber,
+	}
+
+	var err error
+	if len(sd.Certificates.Bytes) > 0 {
+		p.Certificates, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.Hash, err = GetHashForOID(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(si.AuthenticatedAttributes.Bytes) > 0 {
+		// The signature covers the attributes with the SET OF tag rather than the implicit [0] tag.
+		p.rawSignedAttributes, err = asn1.Marshal(asn1.RawValue{
+			Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: si.AuthenticatedAttributes.Bytes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.SignedAttributes, err = unmarshalAttributes(si.AuthenticatedAttributes.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(si.UnauthenticatedAttributes.Bytes) > 0 {
+		p.UnsignedAttributes, err = unmarshalAttributes(si.UnauthenticatedAttributes.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sd.ContentInfo.EContent.Bytes) > 0 {
+		var content asn1.RawValue
+		if _, err := asn1.Unmarshal(sd.ContentInfo.EContent.Bytes, &content); err != nil {
+			return nil, err
+		}
+		if content.IsCompound {
+			// Constructed OCTET STRING: concatenate the segments.
+			rest := content.Bytes
+			for len(rest) > 0 {
+				var segment []byte
+				rest, err = asn1.Unmarshal(rest, &segment)
+				if err != nil {
+					return nil, err
+				}
+				p.Content = append(p.Content, segment...)
+			}
+		} else {
+			p.Content = content.Bytes
+		}
+	}
+
+	return p, nil
+}
+
+// SignerCertificate returns the certificate of the signer or nil if not included.
+func (sd *SignedData) SignerCertificate() *x509.Certificate {
+	for _, cert := range sd.Certificates {
+		if bytes.Equal(cert.RawIssuer, sd.issuerAndSerial.IssuerName.FullBytes) &&
+			sd.issuerAndSerial.SerialNumber != nil && cert.SerialNumber.Cmp(sd.issuerAndSerial.SerialNumber) == 0 {
+			return cert
+		}
+	}
+	return nil
+}
+
+// SigningTime returns the value of the signing time signed attribute.  Returns false if not present.
+func (sd *SignedData) SigningTime() (time.Time, bool) {
+	val := findAttribute(sd.SignedAttributes, OIDSigningTime)
+	if val == nil {
+		return time.Time{}, false
+	}
+	var t time.Time
+	if _, err := asn1.Unmarshal(val.FullBytes, &t); err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// HasSignedAttributes checks whether the signer has signed attributes.  Only then Verify compares the message
+// digest attribute to the digest of the content, otherwise the signature value is verified against the digest
+// directly.
+func (sd *SignedData) HasSignedAttributes() bool {
+	return len(sd.rawSignedAttributes) > 0
+}
+
+// Verify verifies the signature of the signer, where `digest` is the message digest of the content (detached
+// or encapsulated).  Returns ErrDigestMismatch if the message digest attribute does not match `digest` and
+// ErrInvalidSignature if the signature value is not valid for the signer certificate.
+func (sd *SignedData) Verify(digest []byte) error {
+	cert := sd.SignerCertificate()
+	if cert == nil {
+		return ErrSignerNotFound
+	}
+
+	signed := digest
+	if len(sd.rawSignedAttributes) > 0 {
+		val := findAttribute(sd.SignedAttributes, OIDMessageDigest)
+		if val == nil {
+			return errors.New("Message digest attribute missing")
+		}
+		var md []byte
+		if _, err := asn1.Unmarshal(val.FullBytes, &md); err != nil {
+			return err
+		}
+		if !bytes.Equal(md, digest) {
+			return ErrDigestMismatch
+		}
+
+		h := sd.Hash.New()
+		h.Write(sd.rawSignedAttributes)
+		signed = h.Sum(nil)
+	}
+
+	return verifySignature(cert.PublicKey, sd.Hash, signed, sd.Signature)
+}
+
+// verifySignature checks signature `sig` of the digest `hashed` with public key `pub`.
+func verifySignature(pub crypto.PublicKey, hash crypto.Hash, hashed, sig []byte) error {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, hash, hashed, sig); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, hashed, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	return ErrUnsupportedAlgorithm
+}
+
+// unmarshalAttributes decodes the contents of a SET OF Attribute (without the outer tag and length).
+func unmarshalAttributes(data []byte) ([]Attribute, error) {
+	var attrs []Attribute
+	for len(data) > 0 {
+		var attr attribute
+		rest, err := asn1.Unmarshal(data, &attr)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+
+		var value asn1.RawValue
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &value); err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, Attribute{Type: attr.Type, Value: value})
+	}
+	return attrs, nil
+}
+
+// findAttribute returns the value of the attribute of type `oid` or nil if not present.
+func findAttribute(attrs []Attribute, oid asn1.ObjectIdentifier) *asn1.RawValue {
+	for i := range attrs {
+		if attrs[i].Type.Equal(oid) {
+			return &attrs[i].Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,655 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfActionType is the type of an action (S entry, Table 198 in 12.6.4).
+type PdfActionType string
+
+const (
+	ActionTypeGoTo        PdfActionType = "GoTo"
+	ActionTypeGoToR       PdfActionType = "GoToR"
+	ActionTypeGoToE       PdfActionType = "GoToE"
+	ActionTypeLaunch      PdfActionType = "Launch"
+	ActionTypeURI         PdfActionType = "URI"
+	ActionTypeNamed       PdfActionType = "Named"
+	ActionTypeJavaScript  PdfActionType = "JavaScript"
+	ActionTypeSubmitForm  PdfActionType = "SubmitForm"
+	ActionTypeResetForm   PdfActionType = "ResetForm"
+	ActionTypeImportData  PdfActionType = "ImportData"
+	ActionTypeHide        PdfActionType = "Hide"
+	ActionTypeSetOCGState PdfActionType = "SetOCGState"
+)
+
+// Standard named actions (12.6.4.11).
+const (
+	NamedActionNextPage  = "NextPage"
+	NamedActionPrevPage  = "PrevPage"
+	NamedActionFirstPage = "FirstPage"
+	NamedActionLastPage  = "LastPage"
+)
+
+// PdfAction represents an action dictionary (12.6).  The context contains the action of the specific type,
+// e.g. *PdfActionURI; actions of other types are kept as they are, with a nil context.
+type PdfAction struct {
+	context    PdfModel
+	actionType PdfActionType
+
+	// Next is the sequence of actions performed after this action (Next).
+	Next []*PdfAction
+
+	dict      *PdfObjectDictionary
+	container PdfObject
+}
+
+// PdfActionGoTo changes the view to a destination in the document.
+type PdfActionGoTo struct {
+	*PdfAction
+	Dest *PdfDestination
+}
+
+// PdfActionGoToR changes the view to a destination in another PDF file.  The page of explicit destinations
+// is a page number (starting from 0).
+type PdfActionGoToR struct {
+	*PdfAction
+	// File is the file specification of the other file.
+	File string
+	Dest *PdfDestination
+	// NewWindow specifies whether the file is opened in a new window, nil for the viewer preference.
+	NewWindow *bool
+}
+
+// PdfActionGoToE changes the view to a destination in an embedded file, or in the file containing the
+// document if it is itself embedded.
+type PdfActionGoToE struct {
+	*PdfAction
+	// File is the file specification of the root document, empty if the target is in the document.
+	File string
+	Dest *PdfDestination
+	// NewWindow specifies whether the file is opened in a new window, nil for the viewer preference.
+	NewWindow *bool
+	// Target is the target dictionary locating the embedded file (T).
+	Target PdfObject
+}
+
+// PdfActionLaunch launches an application or opens a document.
+type PdfActionLaunch struct {
+	*PdfAction
+	File string
+	// NewWindow specifies whether the document is opened in a new window, nil for the viewer preference.
+	NewWindow *bool
+}
+
+// PdfActionURI resolves a uniform resource identifier, e.g. opens a web page.
+type PdfActionURI struct {
+	*PdfAction
+	URI string
+	// IsMap specifies whether the mouse position is added to the URI.
+	IsMap bool
+}
+
+// PdfActionNamed executes a named action, e.g. NamedActionNextPage.
+type PdfActionNamed struct {
+	*PdfAction
+	Name string
+}
+
+// PdfActionJavaScript executes a JavaScript script.
+type PdfActionJavaScript struct {
+	*PdfAction
+	JS string
+}
+
+// PdfActionSubmitForm sends the values of form fields to a URL.
+type PdfActionSubmitForm struct {
+	*PdfAction
+	URL string
+	// Fields are the fields (field dictionaries or fully qualified names) included or excluded, all if empty.
+	Fields []PdfObject
+	Flags  int64
+}
+
+// PdfActionResetForm resets form fields to their default values.
+type PdfActionResetForm struct {
+	*PdfAction
+	// Fields are the fields (field dictionaries or fully qualified names) reset or excluded, all if empty.
+	Fields []PdfObject
+	Flags  int64
+}
+
+// PdfActionImportData imports field values from a file.
+type PdfActionImportData struct {
+	*PdfAction
+	File string
+}
+
+// PdfActionHide hides or shows annotations.
+type PdfActionHide struct {
+	*PdfAction
+	// Targets are the annotation dictionaries or fully qualified field names of the annotations.
+	Targets []PdfObject
+	// Hide specifies whether the annotations are hidden (true) or shown.
+	Hide bool
+}
+
+// PdfOCGStateChange is a change of the state of optional content groups by a SetOCGState action.
+type PdfOCGStateChange struct {
+	// State is OCStateOn, OCStateOff or OCStateToggle.
+	State OCState
+	// Groups are the optional content group dictionaries.
+	Groups []PdfObject
+}
+
+// OCStateToggle toggles the state of optional content groups in SetOCGState actions.
+const OCStateToggle OCState = "Toggle"
+
+// PdfActionSetOCGState sets the states of optional content groups.
+type PdfActionSetOCGState struct {
+	*PdfAction
+	State []*PdfOCGStateChange
+	// PreserveRB specifies whether the radio-button relationships of the groups are preserved.
+	PreserveRB bool
+}
+
+// newPdfAction returns a new action of type `actionType`.
+func newPdfAction(actionType PdfActionType) *PdfAction {
+	dict := MakeDict()
+	return &PdfAction{actionType: actionType, dict: dict, container: dict}
+}
+
+// NewPdfActionGoTo returns an action changing the view to destination `dest`.
+func NewPdfActionGoTo(dest *PdfDestination) *PdfActionGoTo {
+	action := &PdfActionGoTo{PdfAction: newPdfAction(ActionTypeGoTo), Dest: dest}
+	action.context = action
+	return action
+}
+
+// NewPdfActionGoToR returns an action changing the view to destination `dest` in PDF file `file`.
+func NewPdfActionGoToR(file string, dest *PdfDestination) *PdfActionGoToR {
+	action := &PdfActionGoToR{PdfAction: newPdfAction(ActionTypeGoToR), File: file, Dest: dest}
+	action.context = action
+	return action
+}
+
+// NewPdfActionGoToE returns an action changing the view to destination `dest` in the embedded file located
+// by target dictionary `target`.
+func NewPdfActionGoToE(target PdfObject, dest *PdfDestination) *PdfActionGoToE {
+	action := &PdfActionGoToE{PdfAction: newPdfAction(ActionTypeGoToE), Target: target, Dest: dest}
+	action.context = action
+	return action
+}
+
+// NewPdfActionLaunch returns an action opening file `file`.
+func NewPdfActionLaunch(file string) *PdfActionLaunch {
+	action := &PdfActionLaunch{PdfAction: newPdfAction(ActionTypeLaunch), File: file}
+	action.context = action
+	return action
+}
+
+// NewPdfActionURI returns an action resolving `uri`.
+func NewPdfActionURI(uri string) *PdfActionURI {
+	action := &PdfActionURI{PdfAction: newPdfAction(ActionTypeURI), URI: uri}
+	action.context = action
+	return action
+}
+
+// NewPdfActionNamed returns the named action `name`, e.g. NamedActionNextPage.
+func NewPdfActionNamed(name string) *PdfActionNamed {
+	action := &PdfActionNamed{PdfAction: newPdfAction(ActionTypeNamed), Name: name}
+	action.context = action
+	return action
+}
+
+// NewPdfActionJavaScript returns an action executing script `js`.
+func NewPdfActionJavaScript(js string) *PdfActionJavaScript {
+	action := &PdfActionJavaScript{PdfAction: newPdfAction(ActionTypeJavaScript), JS: js}
+	action.context = action
+	return action
+}
+
+// NewPdfActionSubmitForm returns an action sending the values of all the fields to `url`.
+func NewPdfActionSubmitForm(url string) *PdfActionSubmitForm {
+	action := &PdfActionSubmitForm{PdfAction: newPdfAction(ActionTypeSubmitForm), URL: url}
+	action.context = action
+	return action
+}
+
+// NewPdfActionResetForm returns an action resetting all the fields.
+func NewPdfActionResetForm() *PdfActionResetForm {
+	action := &PdfActionResetForm{PdfAction: newPdfAction(ActionTypeResetForm)}
+	action.context = action
+	return action
+}
+
+// NewPdfActionImportData returns an action importing field values from FDF file `file`.
+func NewPdfActionImportData(file string) *PdfActionImportData {
+	action := &PdfActionImportData{PdfAction: newPdfAction(ActionTypeImportData), File: file}
+	action.context = action
+	return action
+}
+
+// NewPdfActionHide returns an action hiding (`hide` true) or showing annotations `targets`.
+func NewPdfActionHide(hide bool, targets ...PdfObject) *PdfActionHide {
+	action := &PdfActionHide{PdfAction: newPdfAction(ActionTypeHide), Targets: targets, Hide: hide}
+	action.context = action
+	return action
+}
+
+// NewPdfActionSetOCGState returns an action changing the states of optional content groups.
+func NewPdfActionSetOCGState(state ...*PdfOCGStateChange) *PdfActionSetOCGState {
+	action := &PdfActionSetOCGState{PdfAction: newPdfAction(ActionTypeSetOCGState), State: state, PreserveRB: true}
+	action.context = action
+	return action
+}
+
+// GetContext returns the action of the specific type, e.g. *PdfActionURI, or nil for unsupported types.
+func (action *PdfAction) GetContext() PdfModel {
+	return action.context
+}
+
+// GetType returns the type of the action.
+func (action *PdfAction) GetType() PdfActionType {
+	return action.actionType
+}
+
+// GetContainingPdfObject returns the action dictionary, or the indirect object containing it.
+func (action *PdfAction) GetContainingPdfObject() PdfObject {
+	return action.container
+}
+
+// ToPdfObject returns the action dictionary updated from the fields of the action of the specific type and
+// the sequence of next actions.
+func (action *PdfAction) ToPdfObject() PdfObject {
+	if action.context != nil {
+		return action.context.ToPdfObject()
+	}
+	return action.toPdfObject()
+}
+
+// toPdfObject updates the entries common to all actions.
+func (action *PdfAction) toPdfObject() PdfObject {
+	d := action.dict
+	d.Set("Type", MakeName("Action"))
+	d.Set("S", MakeName(string(action.actionType)))
+	switch len(action.Next) {
+	case 0:
+		d.Remove("Next")
+	case 1:
+		d.Set("Next", action.Next[0].ToPdfObject())
+	default:
+		next := PdfObjectArray{}
+		for _, a := range action.Next {
+			next = append(next, a.ToPdfObject())
+		}
+		d.Set("Next", &next)
+	}
+	return action.container
+}
+
+// NewPdfActionFromObject loads an action from action dictionary `obj` and the actions following it.
+func NewPdfActionFromObject(obj PdfObject) (*PdfAction, error) {
+	return newPdfActionFromObject(obj, map[PdfObject]bool{})
+}
+
+// newPdfActionFromObject loads an action from `obj`, with `visited` the action dictionaries loaded to detect
+// loops in the sequences of next actions.
+func newPdfActionFromObject(obj PdfObject, visited map[PdfObject]bool) (*PdfAction, error) {
+	dict, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !ok {
+		return nil, fmt.Errorf("Action not a dictionary (%T)", obj)
+	}
+	if visited[dict] {
+		return nil, errors.New("Loop in next actions")
+	}
+	visited[dict] = true
+
+	s, ok := TraceToDirectObject(dict.Get("S")).(*PdfObjectName)
+	if !ok {
+		return nil, errors.New("Missing action type")
+	}
+	action := &PdfAction{actionType: PdfActionType(*s), dict: dict, container: obj}
+	if _, isIndirect := obj.(*PdfIndirectObject); !isIndirect {
+		action.container = dict
+	}
+
+	switch next := TraceToDirectObject(dict.Get("Next")).(type) {
+	case *PdfObjectDictionary:
+		a, err := newPdfActionFromObject(dict.Get("Next"), visited)
+		if err != nil {
+			return nil, err
+		}
+		action.Next = append(action.Next, a)
+	case *PdfObjectArray:
+		for _, o := range *next {
+			a, err := newPdfActionFromObject(o, visited)
+			if err != nil {
+				return nil, err
+			}
+			action.Next = append(action.Next, a)
+		}
+	}
+
+	var err error
+	switch action.actionType {
+	case ActionTypeGoTo:
+		ctx := &PdfActionGoTo{PdfAction: action}
+		ctx.Dest, err = NewPdfDestinationFromObject(dict.Get("D"))
+		action.context = ctx
+	case ActionTypeGoToR:
+		ctx := &PdfActionGoToR{PdfAction: action, File: getFileSpecName(dict.Get("F"))}
+		ctx.Dest, err = NewPdfDestinationFromObject(dict.Get("D"))
+		ctx.NewWindow = getOptionalBool(dict.Get("NewWindow"))
+		action.context = ctx
+	case ActionTypeGoToE:
+		ctx := &PdfActionGoToE{PdfAction: action, File: getFileSpecName(dict.Get("F")), Target: dict.Get("T")}
+		ctx.Dest, err = NewPdfDestinationFromObject(dict.Get("D"))
+		ctx.NewWindow = getOptionalBool(dict.Get("NewWindow"))
+		action.context = ctx
+	case ActionTypeLaunch:
+		ctx := &PdfActionLaunch{PdfAction: action, File: getFileSpecName(dict.Get("F"))}
+		ctx.NewWindow = getOptionalBool(dict.Get("NewWindow"))
+		action.context = ctx
+	case ActionTypeURI:
+		ctx := &PdfActionURI{PdfAction: action}
+		if uri, ok := TraceToDirectObject(dict.Get("URI")).(*PdfObjectString); ok {
+			ctx.URI = string(*uri)
+		}
+		if isMap := getOptionalBool(dict.Get("IsMap")); isMap != nil {
+			ctx.IsMap = *isMap
+		}
+		action.context = ctx
+	case ActionTypeNamed:
+		ctx := &PdfActionNamed{PdfAction: action}
+		if name, ok := TraceToDirectObject(dict.Get("N")).(*PdfObjectName); ok {
+			ctx.Name = string(*name)
+		}
+		action.context = ctx
+	case ActionTypeJavaScript:
+		ctx := &PdfActionJavaScript{PdfAction: action}
+		switch js := TraceToDirectObject(dict.Get("JS")).(type) {
+		case *PdfObjectString:
+			ctx.JS = DecodeTextString(string(*js))
+		case *PdfObjectStream:
+			data, err := DecodeStream(js)
+			if err != nil {
+				return nil, err
+			}
+			ctx.JS = DecodeTextString(string(data))
+		}
+		action.context = ctx
+	case ActionTypeSubmitForm:
+		ctx := &PdfActionSubmitForm{PdfAction: action, URL: getFileSpecName(dict.Get("F"))}
+		ctx.Fields = getObjectArray(dict.Get("Fields"))
+		ctx.Flags = getOptionalInteger(dict.Get("Flags"))
+		action.context = ctx
+	case ActionTypeResetForm:
+		ctx := &PdfActionResetForm{PdfAction: action}
+		ctx.Fields = getObjectArray(dict.Get("Fields"))
+		ctx.Flags = getOptionalInteger(dict.Get("Flags"))
+		action.context = ctx
+	case ActionTypeImportData:
+		action.context = &PdfActionImportData{PdfAction: action, File: getFileSpecName(dict.Get("F"))}
+	case ActionTypeHide:
+		ctx := &PdfActionHide{PdfAction: action, Hide: true}
+		if _, isArray := TraceToDirectObject(dict.Get("T")).(*PdfObjectArray); isArray {
+			ctx.Targets = getObjectArray(dict.Get("T"))
+		} else if t := dict.Get("T"); t != nil {
+			ctx.Targets = []PdfObject{t}
+		}
+		if hide := getOptionalBool(dict.Get("H")); hide != nil {
+			ctx.Hide = *hide
+		}
+		action.context = ctx
+	case ActionTypeSetOCGState:
+		ctx := &PdfActionSetOCGState{PdfAction: action, PreserveRB: true}
+		var change *PdfOCGStateChange
+		for _, o := range getObjectArray(dict.Get("State")) {
+			if name, ok := TraceToDirectObject(o).(*PdfObjectName); ok {
+				change = &PdfOCGStateChange{State: OCState(*name)}
+				ctx.State = append(ctx.State, change)
+			} else if change != nil {
+				change.Groups = append(change.Groups, o)
+			}
+		}
+		if preserve := getOptionalBool(dict.Get("PreserveRB")); preserve != nil {
+			ctx.PreserveRB = *preserve
+		}
+		action.context = ctx
+	default:
+		common.Log.Debug("Unsupported action type %s", action.actionType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// getFileSpecName returns the file name of file specification `obj`, empty if none.
+func getFileSpecName(obj PdfObject) string {
+	if obj == nil {
+		return ""
+	}
+	spec, err := newPdfFileSpecFromObject(obj)
+	if err != nil {
+		common.Log.Debug("ERROR: %v", err)
+		return ""
+	}
+	return spec.FileName
+}
+
+// getOptionalBool returns the value of boolean `obj`, nil if not a boolean.
+func getOptionalBool(obj PdfObject) *bool {
+	if b, ok := TraceToDirectObject(obj).(*PdfObjectBool); ok {
+		val := bool(*b)
+		return &val
+	}
+	return nil
+}
+
+// getOptionalInteger returns the value of integer `obj`, 0 if not an integer.
+func getOptionalInteger(obj PdfObject) int64 {
+	if i, ok := TraceToDirectObject(obj).(*PdfObjectInteger); ok {
+		return int64(*i)
+	}
+	return 0
+}
+
+// getObjectArray returns the elements of array `obj`, nil if not an array.
+func getObjectArray(obj PdfObject) []PdfObject {
+	if arr, ok := TraceToDirectObject(obj).(*PdfObjectArray); ok {
+		return *arr
+	}
+	return nil
+}
+
+// setDest sets destination `dest` as entry D of `d`.
+func setDest(d *PdfObjectDictionary, dest *PdfDestination) {
+	if dest == nil {
+		d.Remove("D")
+		return
+	}
+	d.Set("D", dest.ToPdfObject())
+}
+
+// setNewWindow sets `newWindow` as entry NewWindow of `d`.
+func setNewWindow(d *PdfObjectDictionary, newWindow *bool) {
+	if newWindow == nil {
+		d.Remove("NewWindow")
+		return
+	}
+	d.Set("NewWindow", MakeBool(*newWindow))
+}
+
+// setFileSpec sets file specification string `file` as entry F of `d`.
+func setFileSpec(d *PdfObjectDictionary, file string) {
+	if file == "" {
+		d.Remove("F")
+		return
+	}
+	d.Set("F", MakeString(file))
+}
+
+// setObjectArray sets `objs` as array entry `key` of `d`, removing it if empty.
+func setObjectArray(d *PdfObjectDictionary, key PdfObjectName, objs []PdfObject) {
+	if len(objs) == 0 {
+		d.Remove(key)
+		return
+	}
+	arr := PdfObjectArray(append([]PdfObject{}, objs...))
+	d.Set(key, &arr)
+}
+
+func (action *PdfActionGoTo) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	setDest(action.dict, action.Dest)
+	return container
+}
+
+func (action *PdfActionGoToR) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	setFileSpec(action.dict, action.File)
+	setDest(action.dict, action.Dest)
+	setNewWindow(action.dict, action.NewWindow)
+	return container
+}
+
+func (action *PdfActionGoToE) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	setFileSpec(action.dict, action.File)
+	setDest(action.dict, action.Dest)
+	setNewWindow(action.dict, action.NewWindow)
+	if action.Target != nil {
+		action.dict.Set("T", action.Target)
+	} else {
+		action.dict.Remove("T")
+	}
+	return container
+}
+
+func (action *PdfActionLaunch) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	setFileSpec(action.dict, action.File)
+	setNewWindow(action.dict, action.NewWindow)
+	return container
+}
+
+func (action *PdfActionURI) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	action.dict.Set("URI", MakeString(action.URI))
+	if action.IsMap {
+		action.dict.Set("IsMap", MakeBool(true))
+	} else {
+		action.dict.Remove("IsMap")
+	}
+	return container
+}
+
+func (action *PdfActionNamed) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	action.dict.Set("N", MakeName(action.Name))
+	return container
+}
+
+func (action *PdfActionJavaScript) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	action.dict.Set("JS", MakeString(EncodeTextString(action.JS)))
+	return container
+}
+
+func (action *PdfActionSubmitForm) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	url := MakeDict()
+	url.Set("FS", MakeName("URL"))
+	url.Set("F", MakeString(action.URL))
+	action.dict.Set("F", url)
+	setObjectArray(action.dict, "Fields", action.Fields)
+	action.dict.Set("Flags", MakeInteger(action.Flags))
+	return container
+}
+
+func (action *PdfActionResetForm) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	setObjectArray(action.dict, "Fields", action.Fields)
+	action.dict.Set("Flags", MakeInteger(action.Flags))
+	return container
+}
+
+func (action *PdfActionImportData) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	setFileSpec(action.dict, action.File)
+	return container
+}
+
+func (action *PdfActionHide) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	if len(action.Targets) == 1 {
+		action.dict.Set("T", action.Targets[0])
+	} else {
+		setObjectArray(action.dict, "T", action.Targets)
+	}
+	if action.Hide {
+		action.dict.Remove("H")
+	} else {
+		action.dict.Set("H", MakeBool(false))
+	}
+	return container
+}
+
+func (action *PdfActionSetOCGState) ToPdfObject() PdfObject {
+	container := action.PdfAction.toPdfObject()
+	state := PdfObjectArray{}
+	for _, change := range action.State {
+		state = append(state, MakeName(string(change.State)))
+		state = append(state, change.Groups...)
+	}
+	action.dict.Set("State", &state)
+	if action.PreserveRB {
+		action.dict.Remove("PreserveRB")
+	} else {
+		action.dict.Set("PreserveRB", MakeBool(false))
+	}
+	return container
+}
+
+// getAdditionalAction returns the action of additional-actions dictionary `aa` for trigger event `trigger`,
+// nil if none.
+func getAdditionalAction(aa PdfObject, trigger PdfObjectName) (*PdfAction, error) {
+	dict, ok := TraceToDirectObject(aa).(*PdfObjectDictionary)
+	if !ok {
+		return nil, nil
+	}
+	obj := dict.Get(trigger)
+	if obj == nil {
+		return nil, nil
+	}
+	return NewPdfActionFromObject(obj)
+}
+
+// setAdditionalAction sets `action` as the action of trigger event `trigger` in additional-actions dictionary
+// `aa`, removing it if nil, and returns the dictionary (nil if empty).
+func setAdditionalAction(aa PdfObject, trigger PdfObjectName, action *PdfAction) PdfObject {
+	dict, ok := TraceToDirectObject(aa).(*PdfObjectDictionary)
+	if !ok {
+		dict = MakeDict()
+		aa = dict
+	}
+	if action == nil {
+		dict.Remove(trigger)
+	} else {
+		dict.Set(trigger, action.ToPdfObject())
+	}
+	if len(dict.Keys()) == 0 {
+		return nil
+	}
+	return aa
+}
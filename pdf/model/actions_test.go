@@ -0,0 +1,178 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+func TestActionRoundTrip(t *testing.T) {
+	page := MakeIndirectObject(MakeDict())
+	newWindow := true
+	goToR := NewPdfActionGoToR("other.pdf", NewDestinationFit(MakeInteger(2)))
+	goToR.NewWindow = &newWindow
+	field := MakeIndirectObject(MakeDict())
+	submit := NewPdfActionSubmitForm("https://example.com/submit")
+	submit.Fields = []PdfObject{MakeString("name"), field}
+	submit.Flags = 4
+	ocg := NewPdfOptionalContentGroup("Layer").ToPdfObject()
+
+	testcases := []struct {
+		action *PdfAction
+		check  func(ctx PdfModel) bool
+	}{
+		{NewPdfActionGoTo(NewDestinationFit(page)).PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionGoTo)
+			return ok && a.Dest.Type == DestinationTypeFit && a.Dest.Page == page
+		}},
+		{goToR.PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionGoToR)
+			return ok && a.File == "other.pdf" && a.NewWindow != nil && *a.NewWindow
+		}},
+		{NewPdfActionGoToE(MakeDict(), NewNamedDestination("intro")).PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionGoToE)
+			return ok && a.Target != nil && a.Dest.Name == "intro" && a.NewWindow == nil
+		}},
+		{NewPdfActionURI("https://unidoc.io").PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionURI)
+			return ok && a.URI == "https://unidoc.io" && !a.IsMap
+		}},
+		{NewPdfActionLaunch("notes.txt").PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionLaunch)
+			return ok && a.File == "notes.txt"
+		}},
+		{NewPdfActionNamed(NamedActionLastPage).PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionNamed)
+			return ok && a.Name == NamedActionLastPage
+		}},
+		{NewPdfActionJavaScript("app.alert('Héllo');").PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionJavaScript)
+			return ok && a.JS == "app.alert('Héllo');"
+		}},
+		{submit.PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionSubmitForm)
+			return ok && a.URL == "https://example.com/submit" && len(a.Fields) == 2 && a.Fields[1] == field &&
+				a.Flags == 4
+		}},
+		{NewPdfActionResetForm().PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionResetForm)
+			return ok && len(a.Fields) == 0 && a.Flags == 0
+		}},
+		{NewPdfActionImportData("data.fdf").PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionImportData)
+			return ok && a.File == "data.fdf"
+		}},
+		{NewPdfActionHide(false, MakeString("field1")).PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionHide)
+			return ok && !a.Hide && len(a.Targets) == 1
+		}},
+		{NewPdfActionSetOCGState(&PdfOCGStateChange{State: OCStateToggle, Groups: []PdfObject{ocg}},
+			&PdfOCGStateChange{State: OCStateOff}).PdfAction, func(ctx PdfModel) bool {
+			a, ok := ctx.(*PdfActionSetOCGState)
+			return ok && a.PreserveRB && len(a.State) == 2 && a.State[0].State == OCStateToggle &&
+				len(a.State[0].Groups) == 1 && a.State[0].Groups[0] == ocg && a.State[1].State == OCStateOff
+		}},
+	}
+
+	for _, tcase := range testcases {
+		// Reparse the written dictionary.
+		str := tcase.action.ToPdfObject().DefaultWriteString()
+		loaded, err := NewPdfActionFromObject(tcase.action.ToPdfObject())
+		if err != nil {
+			t.Fatalf("%s: %v", tcase.action.GetType(), err)
+		}
+		if loaded.GetType() != tcase.action.GetType() || !tcase.check(loaded.GetContext()) {
+			t.Errorf("Invalid action %s: %+v", str, loaded.GetContext())
+		}
+	}
+}
+
+func TestActionNext(t *testing.T) {
+	action := NewPdfActionNamed(NamedActionNextPage)
+	action.Next = []*PdfAction{NewPdfActionURI("https://unidoc.io").PdfAction}
+	js := NewPdfActionJavaScript("1;")
+	js.Next = []*PdfAction{action.PdfAction, NewPdfActionResetForm().PdfAction}
+
+	loaded, err := NewPdfActionFromObject(js.ToPdfObject())
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(loaded.Next) != 2 || loaded.Next[0].GetType() != ActionTypeNamed ||
+		loaded.Next[1].GetType() != ActionTypeResetForm {
+		t.Fatalf("Invalid next actions: %v", loaded.Next)
+	}
+	if next := loaded.Next[0].Next; len(next) != 1 || next[0].GetContext().(*PdfActionURI).URI != "https://unidoc.io" {
+		t.Errorf("Invalid next action: %v", next)
+	}
+
+	// Loops are not allowed.
+	dict := action.ToPdfObject().(*PdfObjectDictionary)
+	dict.Set("Next", dict)
+	if _, err := NewPdfActionFromObject(dict); err == nil {
+		t.Errorf("Loop should fail")
+	}
+}
+
+func TestOpenAction(t *testing.T) {
+	writer := NewPdfWriter()
+	for i := 0; i < 2; i++ {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+		page.Resources = NewPdfPageResources()
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	pages := writer.pages.PdfObject.(*PdfObjectDictionary).Get("Kids").(*PdfObjectArray)
+	action := NewPdfActionGoTo(NewDestinationFit((*pages)[1]))
+	action.Next = []*PdfAction{NewPdfActionJavaScript("this.print();").PdfAction}
+	if err := writer.SetOpenAction(action.PdfAction); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	outline := NewPdfOutline()
+	item := NewOutlineBookmark("Website", (*pages)[0].(*PdfIndirectObject))
+	item.SetAction(NewPdfActionURI("https://unidoc.io").PdfAction)
+	outline.AddChild(item)
+	writer.AddOutlineTree(&outline.PdfOutlineTreeNode)
+
+	f, err := os.Create("/tmp/actions.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/actions.pdf")
+	openAction, err := reader.GetOpenAction()
+	if err != nil || openAction == nil {
+		t.Fatalf("Error: %v", err)
+	}
+	goTo, ok := openAction.GetContext().(*PdfActionGoTo)
+	if !ok || goTo.Dest.Page != reader.PageList[1].GetContainingPdfObject() {
+		t.Errorf("Invalid open action: %+v", openAction.GetContext())
+	}
+	if len(openAction.Next) != 1 || openAction.Next[0].GetContext().(*PdfActionJavaScript).JS != "this.print();" {
+		t.Errorf("Invalid next actions: %v", openAction.Next)
+	}
+
+	items := reader.GetOutlineTree().Children()
+	if len(items) != 1 {
+		t.Fatalf("Invalid outline")
+	}
+	itemAction, err := items[0].GetAction()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if uri, ok := itemAction.GetContext().(*PdfActionURI); !ok || uri.URI != "https://unidoc.io" || items[0].Dest != nil {
+		t.Errorf("Invalid outline action: %+v", itemAction.GetContext())
+	}
+}
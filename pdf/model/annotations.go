@@ -1466,6 +1466,26 @@ func (this *PdfAnnotationLink) ToPdfObject() PdfObject {
 	return container
 }
 
+// GetAction returns the action performed when the link is activated, or nil if it has none.
+func (this *PdfAnnotationLink) GetAction() (*PdfAction, error) {
+	if this.A == nil {
+		return nil, nil
+	}
+	return NewPdfActionFromObject(this.A)
+}
+
+// SetAction sets the action performed when the link is activated, removing its destination.
+func (this *PdfAnnotationLink) SetAction(action *PdfAction) {
+	d := this.primitive.PdfObject.(*PdfObjectDictionary)
+	d.Remove("A")
+	d.Remove("Dest")
+	this.A = nil
+	if action != nil {
+		this.A = action.ToPdfObject()
+	}
+	this.Dest = nil
+}
+
 func (this *PdfAnnotationFreeText) ToPdfObject() PdfObject {
 	this.PdfAnnotation.ToPdfObject()
 	container := this.primitive
@@ -1743,6 +1763,23 @@ func (this *PdfAnnotationWidget) ToPdfObject() PdfObject {
 	return container
 }
 
+// GetAction returns the action performed when the widget is activated, or nil if it has none.
+func (this *PdfAnnotationWidget) GetAction() (*PdfAction, error) {
+	if this.A == nil {
+		return nil, nil
+	}
+	return NewPdfActionFromObject(this.A)
+}
+
+// SetAction sets the action performed when the widget is activated.
+func (this *PdfAnnotationWidget) SetAction(action *PdfAction) {
+	this.primitive.PdfObject.(*PdfObjectDictionary).Remove("A")
+	this.A = nil
+	if action != nil {
+		this.A = action.ToPdfObject()
+	}
+}
+
 func (this *PdfAnnotationPrinterMark) ToPdfObject() PdfObject {
 	this.PdfAnnotation.ToPdfObject()
 	container := this.primitive
@@ -0,0 +1,496 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfAppender appends changes to an existing PDF file as an incremental update (section 7.5.6 of the
+// PDF32000_2008 spec).  The original file is kept byte for byte, new objects and new versions of modified
+// objects are written after it, followed by a cross reference section and trailer referring back to the
+// original.  This is required when signing documents, as existing signatures remain valid.
+type PdfAppender struct {
+	rs     io.ReadSeeker
+	parser *PdfParser
+	Reader *PdfReader
+
+	// Original objects that have been modified and are to be written out in the update.
+	updated    []PdfObject
+	updatedMap map[PdfObject]bool
+}
+
+// NewPdfAppender returns a new appender for the document loaded by `reader`.  Modifications of the
+// document structure are done through the appender.
+func NewPdfAppender(reader *PdfReader) (*PdfAppender, error) {
+	if reader.rs == nil {
+		return nil, errors.New("Reader input not available")
+	}
+	isEncrypted, err := reader.IsEncrypted()
+	if err != nil {
+		return nil, err
+	}
+	if isEncrypted {
+		// Objects in the update would need to be encrypted with the original key.
+		return nil, errors.New("Appending to encrypted documents is not supported")
+	}
+
+	a := &PdfAppender{
+		rs:         reader.rs,
+		parser:     reader.parser,
+		Reader:     reader,
+		updatedMap: map[PdfObject]bool{},
+	}
+	return a, nil
+}
+
+// isOriginal checks whether `obj` is an indirect or stream object loaded from the original document.
+func (a *PdfAppender) isOriginal(obj PdfObject) bool {
+	var num int64
+	switch t := obj.(type) {
+	case *PdfIndirectObject:
+		num = t.ObjectNumber
+	case *PdfObjectStream:
+		num = t.ObjectNumber
+	default:
+		return false
+	}
+
+	cached, has := a.parser.ObjCache[int(num)]
+	return has && cached == obj
+}
+
+// UpdateObject marks an indirect or stream object of the original document as modified, so that its new
+// version is written in the update.  New objects referenced from it are added automatically.
+func (a *PdfAppender) UpdateObject(obj PdfObject) error {
+	if !a.isOriginal(obj) {
+		return errors.New("Not an object of the original document")
+	}
+	if !a.updatedMap[obj] {
+		a.updatedMap[obj] = true
+		a.updated = append(a.updated, obj)
+	}
+	return nil
+}
+
+// markUpdated marks `obj` as updated if it is an object of the original document.  New objects do not need
+// to be marked, they are written out when referenced from updated objects.
+func (a *PdfAppender) markUpdated(obj PdfObject) {
+	if a.isOriginal(obj) {
+		a.UpdateObject(obj)
+	}
+}
+
+// resolve returns the object `obj` refers to if it is a reference, or `obj` itself otherwise.
+func (a *PdfAppender) resolve(obj PdfObject) (PdfObject, error) {
+	if ref, isRef := obj.(*PdfObjectReference); isRef {
+		return a.parser.LookupByReference(*ref)
+	}
+	return obj, nil
+}
+
+// getCatalog returns the indirect object containing the document catalog.
+func (a *PdfAppender) getCatalog() (*PdfIndirectObject, *PdfObjectDictionary, error) {
+	obj, err := a.resolve(a.parser.GetTrailer().Get("Root"))
+	if err != nil {
+		return nil, nil, err
+	}
+	container, ok := obj.(*PdfIndirectObject)
+	if !ok {
+		return nil, nil, errors.New("Invalid catalog")
+	}
+	dict, ok := container.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		return nil, nil, errors.New("Invalid catalog")
+	}
+	return container, dict, nil
+}
+
+// appendToArray appends `obj` to the array entry `key` of `dict`, which is contained in `container`.  The
+// array is created if missing.  Marks the object holding the array as updated.
+func (a *PdfAppender) appendToArray(container PdfObject, dict *PdfObjectDictionary, key PdfObjectName, obj PdfObject) error {
+	val, err := a.resolve(dict.Get(key))
+	if err != nil {
+		return err
+	}
+
+	switch t := val.(type) {
+	case nil:
+		dict.Set(key, MakeArray(obj))
+		a.markUpdated(container)
+		return nil
+	case *PdfObjectArray:
+		t.Append(obj)
+		a.markUpdated(container)
+		return nil
+	case *PdfIndirectObject:
+		arr, ok := t.PdfObject.(*PdfObjectArray)
+		if !ok {
+			return ErrTypeError
+		}
+		arr.Append(obj)
+		a.markUpdated(t)
+		return nil
+	}
+
+	common.Log.Debug("ERROR: %s not an array (%T)", key, val)
+	return ErrTypeError
+}
+
+// AddAnnotation adds annotation `annot` to page `pageNum` (starting from 1).
+func (a *PdfAppender) AddAnnotation(pageNum int, annot *PdfAnnotation) error {
+	page, err := a.Reader.GetPage(pageNum)
+	if err != nil {
+		return err
+	}
+	pageObj := page.GetPageAsIndirectObject()
+	pageDict, ok := pageObj.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		return errors.New("Invalid page object")
+	}
+
+	annot.P = pageObj
+	var annotObj PdfObject
+	if ctx := annot.GetContext(); ctx != nil {
+		annotObj = ctx.ToPdfObject()
+	} else {
+		annotObj = annot.ToPdfObject()
+	}
+
+	if err := a.appendToArray(pageObj, pageDict, "Annots", annotObj); err != nil {
+		return err
+	}
+	page.Annotations = append(page.Annotations, annot)
+	return nil
+}
+
+// AddField adds the form field `field` to the interactive form of the document, creating the form if the
+// document has none.
+func (a *PdfAppender) AddField(field *PdfField) error {
+	catalogObj, catalog, err := a.getCatalog()
+	if err != nil {
+		return err
+	}
+
+	formObj, err := a.resolve(catalog.Get("AcroForm"))
+	if err != nil {
+		return err
+	}
+	var formContainer PdfObject
+	var formDict *PdfObjectDictionary
+	switch t := formObj.(type) {
+	case nil, *PdfObjectNull:
+		formDict = MakeDict()
+		catalog.Set("AcroForm", MakeIndirectObject(formDict))
+		a.markUpdated(catalogObj)
+		formContainer = catalogObj
+	case *PdfIndirectObject:
+		d, ok := t.PdfObject.(*PdfObjectDictionary)
+		if !ok {
+			return ErrTypeError
+		}
+		formDict = d
+		formContainer = t
+	case *PdfObjectDictionary:
+		formDict = t
+		formContainer = catalogObj
+	default:
+		return ErrTypeError
+	}
+
+	if err := a.appendToArray(formContainer, formDict, "Fields", field.ToPdfObject()); err != nil {
+		return err
+	}
+	if a.Reader.AcroForm != nil && a.Reader.AcroForm.Fields != nil {
+		*a.Reader.AcroForm.Fields = append(*a.Reader.AcroForm.Fields, field)
+	}
+
+	return nil
+}
+
+// Sign adds the signature field `field` with its widget annotation on page `pageNum` (starting from 1).
+// The document is signed when written out.
+func (a *PdfAppender) Sign(pageNum int, field *PdfFieldSignature) error {
+	if field == nil || field.V == nil {
+		return errors.New("Signature field without signature")
+	}
+
+	if err := a.AddAnnotation(pageNum, field.Widget.PdfAnnotation); err != nil {
+		return err
+	}
+	if err := a.AddField(field.PdfField); err != nil {
+		return err
+	}
+	field.ToPdfObject()
+
+	// Signatures exist and the document should be changed by incremental updates only.
+	catalogObj, catalog, err := a.getCatalog()
+	if err != nil {
+		return err
+	}
+	formObj, err := a.resolve(catalog.Get("AcroForm"))
+	if err != nil {
+		return err
+	}
+	formDict, ok := TraceToDirectObject(formObj).(*PdfObjectDictionary)
+	if !ok {
+		return ErrTypeError
+	}
+	flags := int64(0)
+	if sigFlags, isInt := TraceToDirectObject(formDict.Get("SigFlags")).(*PdfObjectInteger); isInt {
+		flags = int64(*sigFlags)
+	}
+	formDict.Set("SigFlags", MakeInteger(flags|3))
+
+	if _, isIndirect := formObj.(*PdfIndirectObject); isIndirect {
+		a.markUpdated(formObj)
+	} else {
+		a.markUpdated(catalogObj)
+	}
+	return nil
+}
+
+// SetDSS sets the document security store of the document to `dss`, which can be a new store or the store
+// loaded with PdfReader.GetDSS.  The store is written out with the update, typically after the signatures it
+// holds validation related information for.
+func (a *PdfAppender) SetDSS(dss *PdfDSS) error {
+	catalogObj, catalog, err := a.getCatalog()
+	if err != nil {
+		return err
+	}
+
+	dss.ToPdfObject()
+	container := dss.GetContainingPdfObject()
+	if a.isOriginal(container) {
+		a.markUpdated(container)
+	} else {
+		catalog.Set("DSS", container)
+		a.markUpdated(catalogObj)
+	}
+
+	// Declare the ESIC (PAdES) extension of PDF 1.7 the DSS is defined in.
+	extObj, err := a.resolve(catalog.Get("Extensions"))
+	if err != nil {
+		return err
+	}
+	esic := MakeDict()
+	esic.Set("BaseVersion", MakeName("1.7"))
+	esic.Set("ExtensionLevel", MakeInteger(5))
+	switch t := extObj.(type) {
+	case nil, *PdfObjectNull:
+		extensions := MakeDict()
+		extensions.Set("ESIC", esic)
+		catalog.Set("Extensions", extensions)
+		a.markUpdated(catalogObj)
+	case *PdfObjectDictionary:
+		if t.Get("ESIC") == nil {
+			t.Set("ESIC", esic)
+			a.markUpdated(catalogObj)
+		}
+	case *PdfIndirectObject:
+		if d, isDict := t.PdfObject.(*PdfObjectDictionary); isDict && d.Get("ESIC") == nil {
+			d.Set("ESIC", esic)
+			a.markUpdated(t)
+		}
+	}
+
+	return nil
+}
+
+// collectObjects adds new (not original) indirect and stream objects referenced by `obj` to `objects`,
+// assigning them object numbers.
+func (a *PdfAppender) collectObjects(obj PdfObject, objects *[]PdfObject, added map[PdfObject]bool, nextNum *int64) {
+	switch t := obj.(type) {
+	case *PdfIndirectObject:
+		if added[t] || a.isOriginal(t) {
+			return
+		}
+		t.ObjectNumber = *nextNum
+		t.GenerationNumber = 0
+		*nextNum++
+		added[t] = true
+		*objects = append(*objects, t)
+		a.collectObjects(t.PdfObject, objects, added, nextNum)
+	case *PdfObjectStream:
+		if added[t] || a.isOriginal(t) {
+			return
+		}
+		t.ObjectNumber = *nextNum
+		t.GenerationNumber = 0
+		*nextNum++
+		added[t] = true
+		*objects = append(*objects, t)
+		a.collectObjects(t.PdfObjectDictionary, objects, added, nextNum)
+	case *pdfSignDictionary:
+		a.collectObjects(t.PdfObjectDictionary, objects, added, nextNum)
+	case *PdfObjectDictionary:
+		for _, key := range t.Keys() {
+			a.collectObjects(t.Get(key), objects, added, nextNum)
+		}
+	case *PdfObjectArray:
+		for _, o := range *t {
+			a.collectObjects(o, objects, added, nextNum)
+		}
+	}
+}
+
+// writeAppendedObject writes out an indirect or stream object with its object and generation number.
+func writeAppendedObject(w *bytes.Buffer, obj PdfObject) {
+	switch t := obj.(type) {
+	case *PdfIndirectObject:
+		w.WriteString(fmt.Sprintf("%d %d obj\n", t.ObjectNumber, t.GenerationNumber))
+		w.WriteString(t.PdfObject.DefaultWriteString())
+		w.WriteString("\nendobj\n")
+	case *PdfObjectStream:
+		w.WriteString(fmt.Sprintf("%d %d obj\n", t.ObjectNumber, t.GenerationNumber))
+		w.WriteString(t.PdfObjectDictionary.DefaultWriteString())
+		w.WriteString("\nstream\n")
+		w.Write(t.Stream)
+		w.WriteString("\nendstream\nendobj\n")
+	}
+}
+
+// Write writes the original document followed by the incremental update to `w`.  Signature fields added with
+// Sign are signed in the process.
+func (a *PdfAppender) Write(w io.Writer) error {
+	trailer := a.parser.GetTrailer()
+	if trailer == nil {
+		return errors.New("Missing trailer")
+	}
+	size, ok := TraceToDirectObject(trailer.Get("Size")).(*PdfObjectInteger)
+	if !ok {
+		return errors.New("Invalid trailer Size")
+	}
+
+	// Updated objects keep their numbers, new objects are numbered from the original trailer Size.
+	objects := append([]PdfObject{}, a.updated...)
+	added := map[PdfObject]bool{}
+	for _, obj := range a.updated {
+		added[obj] = true
+	}
+	nextNum := int64(*size)
+	for _, obj := range a.updated {
+		switch t := obj.(type) {
+		case *PdfIndirectObject:
+			a.collectObjects(t.PdfObject, &objects, added, &nextNum)
+		case *PdfObjectStream:
+			a.collectObjects(t.PdfObjectDictionary, &objects, added, &nextNum)
+		}
+	}
+	sigObjects := getSignatureObjects(objects)
+	if len(sigObjects) > 1 {
+		// Each signature covers the whole file including the other signatures.
+		return errors.New("Only one signature can be added per update")
+	}
+
+	// Original file.
+	var buf bytes.Buffer
+	if _, err := a.rs.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(&buf, a.rs); err != nil {
+		return err
+	}
+	if b := buf.Bytes(); len(b) > 0 && b[len(b)-1] != '\n' && b[len(b)-1] != '\r' {
+		buf.WriteString("\n")
+	}
+
+	// Updated and new objects.
+	offsets := map[int64]int64{}
+	gens := map[int64]int64{}
+	objOffsets := map[PdfObject]int64{}
+	for _, obj := range objects {
+		var num, gen int64
+		switch t := obj.(type) {
+		case *PdfIndirectObject:
+			num, gen = t.ObjectNumber, t.GenerationNumber
+		case *PdfObjectStream:
+			num, gen = t.ObjectNumber, t.GenerationNumber
+		}
+		offsets[num] = int64(buf.Len())
+		gens[num] = gen
+		objOffsets[obj] = int64(buf.Len())
+		writeAppendedObject(&buf, obj)
+	}
+
+	// Cross reference section, split into subsections of consecutive object numbers.
+	xrefOffset := buf.Len()
+	nums := make([]int64, 0, len(offsets))
+	for num := range offsets {
+		nums = append(nums, num)
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+
+	buf.WriteString("xref\r\n")
+	buf.WriteString(fmt.Sprintf("%d %d\r\n", 0, 1))
+	buf.WriteString(fmt.Sprintf("%.10d %.5d f\r\n", 0, 65535))
+	for i := 0; i < len(nums); {
+		j := i + 1
+		for j < len(nums) && nums[j] == nums[j-1]+1 {
+			j++
+		}
+		buf.WriteString(fmt.Sprintf("%d %d\r\n", nums[i], j-i))
+		for _, num := range nums[i:j] {
+			buf.WriteString(fmt.Sprintf("%.10d %.5d n\r\n", offsets[num], gens[num]))
+		}
+		i = j
+	}
+
+	// Trailer.
+	outTrailer := MakeDict()
+	if nextNum < int64(*size) {
+		nextNum = int64(*size)
+	}
+	outTrailer.Set("Size", MakeInteger(nextNum))
+	outTrailer.Set("Prev", MakeInteger(a.parser.GetXrefOffset()))
+	outTrailer.SetIfNotNil("Root", trailer.Get("Root"))
+	outTrailer.SetIfNotNil("Info", trailer.Get("Info"))
+	if ids, isArr := TraceToDirectObject(trailer.Get("ID")).(*PdfObjectArray); isArr && len(*ids) == 2 {
+		// The first identifier is permanent, the second one changes with each update.
+		b := make([]byte, 100)
+		rand.Read(b)
+		hashcode := md5.Sum(append([]byte(time.Now().Format(time.RFC850)), b...))
+		outTrailer.Set("ID", MakeArray((*ids)[0], MakeString(string(hashcode[:]))))
+	}
+	buf.WriteString("trailer\n")
+	buf.WriteString(outTrailer.DefaultWriteString())
+	buf.WriteString("\n")
+	buf.WriteString(fmt.Sprintf("startxref\n%d\n", xrefOffset))
+	buf.WriteString("%%EOF\n")
+
+	// Sign.
+	data := buf.Bytes()
+	for obj, sig := range sigObjects {
+		if err := signPdfBytes(data, objOffsets[obj], sig); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(data)
+	return err
+}
+
+// WriteToFile writes the document with the incremental update to file `outputPath`.
+func (a *PdfAppender) WriteToFile(outputPath string) error {
+	fWrite, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer fWrite.Close()
+
+	return a.Write(fWrite)
+}
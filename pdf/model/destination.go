@@ -0,0 +1,203 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// DestinationType is the type of a destination, specifying the view of the destination page (Table 151 in
+// 12.3.2.2).
+type DestinationType int
+
+const (
+	// DestinationTypeXYZ positions the point (Left, Top) at the upper left corner of the window, magnified
+	// by Zoom.
+	DestinationTypeXYZ DestinationType = iota
+	// DestinationTypeFit fits the page in the window.
+	DestinationTypeFit
+	// DestinationTypeFitH fits the width of the page in the window, with Top at the top of the window.
+	DestinationTypeFitH
+	// DestinationTypeFitV fits the height of the page in the window, with Left at the left of the window.
+	DestinationTypeFitV
+	// DestinationTypeFitR fits the rectangle (Left, Bottom, Right, Top) in the window.
+	DestinationTypeFitR
+	// DestinationTypeFitB fits the bounding box of the page contents in the window.
+	DestinationTypeFitB
+	// DestinationTypeFitBH fits the width of the bounding box in the window, with Top at the top.
+	DestinationTypeFitBH
+	// DestinationTypeFitBV fits the height of the bounding box in the window, with Left at the left.
+	DestinationTypeFitBV
+	// DestinationTypeNamed refers to a named destination by Name.
+	DestinationTypeNamed
+)
+
+var destinationTypeNames = map[DestinationType]PdfObjectName{
+	DestinationTypeXYZ:   "XYZ",
+	DestinationTypeFit:   "Fit",
+	DestinationTypeFitH:  "FitH",
+	DestinationTypeFitV:  "FitV",
+	DestinationTypeFitR:  "FitR",
+	DestinationTypeFitB:  "FitB",
+	DestinationTypeFitBH: "FitBH",
+	DestinationTypeFitBV: "FitBV",
+}
+
+// PdfDestination represents a destination (12.3.2): a view of a page, or a named destination.
+// The coordinates that do not apply to the destination type are ignored; nil coordinates (null) leave the
+// current value of the viewer unchanged.
+type PdfDestination struct {
+	Type DestinationType
+
+	// Page is the page object of the destination, or the page number (starting from 0) for destinations in
+	// other documents.
+	Page PdfObject
+
+	Left   *float64
+	Bottom *float64
+	Right  *float64
+	Top    *float64
+	Zoom   *float64
+
+	// Name is the name of a named destination.
+	Name string
+}
+
+// NewDestinationXYZ returns a destination positioning (`left`, `top`) of `page` at the upper left corner of
+// the window, magnified by `zoom` (0 keeps the current magnification).
+func NewDestinationXYZ(page PdfObject, left, top, zoom float64) *PdfDestination {
+	dest := &PdfDestination{Type: DestinationTypeXYZ, Page: page, Left: &left, Top: &top}
+	if zoom != 0 {
+		dest.Zoom = &zoom
+	}
+	return dest
+}
+
+// NewDestinationFit returns a destination fitting `page` in the window.
+func NewDestinationFit(page PdfObject) *PdfDestination {
+	return &PdfDestination{Type: DestinationTypeFit, Page: page}
+}
+
+// NewDestinationFitH returns a destination fitting the width of `page` in the window with `top` at the top.
+func NewDestinationFitH(page PdfObject, top float64) *PdfDestination {
+	return &PdfDestination{Type: DestinationTypeFitH, Page: page, Top: &top}
+}
+
+// NewDestinationFitV returns a destination fitting the height of `page` in the window with `left` at the
+// left.
+func NewDestinationFitV(page PdfObject, left float64) *PdfDestination {
+	return &PdfDestination{Type: DestinationTypeFitV, Page: page, Left: &left}
+}
+
+// NewDestinationFitR returns a destination fitting rectangle `rect` of `page` in the window.
+func NewDestinationFitR(page PdfObject, rect PdfRectangle) *PdfDestination {
+	return &PdfDestination{
+		Type:   DestinationTypeFitR,
+		Page:   page,
+		Left:   &rect.Llx,
+		Bottom: &rect.Lly,
+		Right:  &rect.Urx,
+		Top:    &rect.Ury,
+	}
+}
+
+// NewNamedDestination returns a reference to the named destination `name`.
+func NewNamedDestination(name string) *PdfDestination {
+	return &PdfDestination{Type: DestinationTypeNamed, Name: name}
+}
+
+// NewPdfDestinationFromObject loads a destination from `obj`: an explicit destination array, a name or
+// string of a named destination, or a dictionary with the destination in D.
+func NewPdfDestinationFromObject(obj PdfObject) (*PdfDestination, error) {
+	switch t := TraceToDirectObject(obj).(type) {
+	case *PdfObjectString:
+		return NewNamedDestination(string(*t)), nil
+	case *PdfObjectName:
+		return NewNamedDestination(string(*t)), nil
+	case *PdfObjectDictionary:
+		return NewPdfDestinationFromObject(t.Get("D"))
+	case *PdfObjectArray:
+		if len(*t) < 2 {
+			return nil, errors.New("Destination array too short")
+		}
+		name, ok := TraceToDirectObject((*t)[1]).(*PdfObjectName)
+		if !ok {
+			return nil, fmt.Errorf("Destination type not a name (%T)", (*t)[1])
+		}
+		dest := &PdfDestination{Page: (*t)[0]}
+		found := false
+		for typ, typName := range destinationTypeNames {
+			if typName == *name {
+				dest.Type = typ
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Invalid destination type %s", *name)
+		}
+
+		params := []**float64{}
+		switch dest.Type {
+		case DestinationTypeXYZ:
+			params = []**float64{&dest.Left, &dest.Top, &dest.Zoom}
+		case DestinationTypeFitH, DestinationTypeFitBH:
+			params = []**float64{&dest.Top}
+		case DestinationTypeFitV, DestinationTypeFitBV:
+			params = []**float64{&dest.Left}
+		case DestinationTypeFitR:
+			params = []**float64{&dest.Left, &dest.Bottom, &dest.Right, &dest.Top}
+		}
+		for i, param := range params {
+			if 2+i >= len(*t) {
+				break
+			}
+			val, err := getNumberAsFloat(TraceToDirectObject((*t)[2+i]))
+			if err != nil {
+				// Null: unchanged.
+				continue
+			}
+			*param = &val
+		}
+		return dest, nil
+	}
+	return nil, fmt.Errorf("Invalid destination (%T)", obj)
+}
+
+// ToPdfObject returns the destination as a PDF object: an array for explicit destinations, or a string for
+// named destinations.
+func (dest *PdfDestination) ToPdfObject() PdfObject {
+	if dest.Type == DestinationTypeNamed {
+		return MakeString(dest.Name)
+	}
+
+	param := func(val *float64) PdfObject {
+		if val == nil {
+			return MakeNull()
+		}
+		return MakeFloat(*val)
+	}
+
+	page := dest.Page
+	if page == nil {
+		page = MakeNull()
+	}
+	arr := PdfObjectArray{page, MakeName(string(destinationTypeNames[dest.Type]))}
+	switch dest.Type {
+	case DestinationTypeXYZ:
+		arr = append(arr, param(dest.Left), param(dest.Top), param(dest.Zoom))
+	case DestinationTypeFitH, DestinationTypeFitBH:
+		arr = append(arr, param(dest.Top))
+	case DestinationTypeFitV, DestinationTypeFitBV:
+		arr = append(arr, param(dest.Left))
+	case DestinationTypeFitR:
+		arr = append(arr, param(dest.Left), param(dest.Bottom), param(dest.Right), param(dest.Top))
+	}
+	return &arr
+}
@@ -0,0 +1,363 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfDSS represents the document security store (section 12.8.4.3 of ISO 32000-2), holding the validation
+// related information (certificates, OCSP responses and CRLs) needed for long-term validation of the
+// signatures of a document.  The entries are DER encoded data stored in streams.
+type PdfDSS struct {
+	Certs []*PdfObjectStream
+	OCSPs []*PdfObjectStream
+	CRLs  []*PdfObjectStream
+
+	// VRI holds the validation related information specific to a signature, keyed by the uppercase hex
+	// encoded SHA-1 digest of the signature contents (see GetVRIKey).
+	VRI map[string]*PdfVRI
+
+	// Streams by the SHA-1 digest of their decoded data, used to avoid storing the same data twice.
+	streams map[string]*PdfObjectStream
+
+	primitive *PdfIndirectObject
+}
+
+// PdfVRI represents a signature VRI dictionary, referring to the streams of the DSS used for validating the
+// signature.
+type PdfVRI struct {
+	Cert []*PdfObjectStream
+	OCSP []*PdfObjectStream
+	CRL  []*PdfObjectStream
+
+	// TU is the time the information was gathered (optional).
+	TU *PdfObjectString
+	// TS is a timestamp token of the VRI data (optional).
+	TS *PdfObjectStream
+}
+
+// NewPdfDSS returns a new empty document security store.
+func NewPdfDSS() *PdfDSS {
+	dss := &PdfDSS{
+		VRI:     map[string]*PdfVRI{},
+		streams: map[string]*PdfObjectStream{},
+	}
+	dss.primitive = MakeIndirectObject(MakeDict())
+	return dss
+}
+
+// GetVRIKey returns the key of the VRI dictionary for signature `sig`: the uppercase hex encoded SHA-1
+// digest of its Contents.
+func GetVRIKey(sig *PdfSignature) (string, error) {
+	if sig == nil || sig.Contents == nil {
+		return "", errors.New("Signature contents missing")
+	}
+	sum := sha1.Sum([]byte(*sig.Contents))
+	return strings.ToUpper(hex.EncodeToString(sum[:])), nil
+}
+
+// getStream returns the stream holding `data`, creating it if not in the store.
+func (dss *PdfDSS) getStream(data []byte) (*PdfObjectStream, error) {
+	sum := sha1.Sum(data)
+	key := string(sum[:])
+	if stream, has := dss.streams[key]; has {
+		return stream, nil
+	}
+
+	stream, err := MakeStream(data, NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	dss.streams[key] = stream
+	return stream, nil
+}
+
+// addStreams adds the DER encoded items `items` to `list`, skipping items already in the store.  Returns
+// the streams holding the items.
+func (dss *PdfDSS) addStreams(list *[]*PdfObjectStream, items [][]byte) ([]*PdfObjectStream, error) {
+	var streams []*PdfObjectStream
+	for _, item := range items {
+		stream, err := dss.getStream(item)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, s := range *list {
+			if s == stream {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*list = append(*list, stream)
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// AddCerts adds DER encoded certificates to the store.
+func (dss *PdfDSS) AddCerts(certs [][]byte) ([]*PdfObjectStream, error) {
+	return dss.addStreams(&dss.Certs, certs)
+}
+
+// AddOCSPs adds DER encoded OCSP responses to the store.
+func (dss *PdfDSS) AddOCSPs(ocsps [][]byte) ([]*PdfObjectStream, error) {
+	return dss.addStreams(&dss.OCSPs, ocsps)
+}
+
+// AddCRLs adds DER encoded certificate revocation lists to the store.
+func (dss *PdfDSS) AddCRLs(crls [][]byte) ([]*PdfObjectStream, error) {
+	return dss.addStreams(&dss.CRLs, crls)
+}
+
+// AddVRI adds the validation related information of signature `sig` to the store: the DER encoded
+// certificates `certs`, OCSP responses `ocsps` and CRLs `crls`.  The items are added to the store itself
+// and referred to by the VRI dictionary of the signature.
+func (dss *PdfDSS) AddVRI(sig *PdfSignature, certs, ocsps, crls [][]byte) (*PdfVRI, error) {
+	key, err := GetVRIKey(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	vri, has := dss.VRI[key]
+	if !has {
+		vri = &PdfVRI{}
+		dss.VRI[key] = vri
+	}
+
+	streams, err := dss.AddCerts(certs)
+	if err != nil {
+		return nil, err
+	}
+	vri.Cert = appendStreams(vri.Cert, streams)
+
+	streams, err = dss.AddOCSPs(ocsps)
+	if err != nil {
+		return nil, err
+	}
+	vri.OCSP = appendStreams(vri.OCSP, streams)
+
+	streams, err = dss.AddCRLs(crls)
+	if err != nil {
+		return nil, err
+	}
+	vri.CRL = appendStreams(vri.CRL, streams)
+
+	return vri, nil
+}
+
+// appendStreams appends the streams of `add` not yet in `list`.
+func appendStreams(list, add []*PdfObjectStream) []*PdfObjectStream {
+	for _, stream := range add {
+		found := false
+		for _, s := range list {
+			if s == stream {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, stream)
+		}
+	}
+	return list
+}
+
+// GetDSS returns the document security store of the document, or nil if there is none.
+func (this *PdfReader) GetDSS() (*PdfDSS, error) {
+	if this.catalog == nil {
+		return nil, errors.New("Catalog not loaded")
+	}
+	obj, err := this.traceToObject(this.catalog.Get("DSS"))
+	if err != nil {
+		return nil, err
+	}
+
+	var dict *PdfObjectDictionary
+	dss := NewPdfDSS()
+	switch t := obj.(type) {
+	case nil, *PdfObjectNull:
+		return nil, nil
+	case *PdfIndirectObject:
+		d, ok := t.PdfObject.(*PdfObjectDictionary)
+		if !ok {
+			return nil, ErrTypeError
+		}
+		dict = d
+		dss.primitive = t
+	case *PdfObjectDictionary:
+		dict = t
+		dss.primitive.PdfObject = t
+	default:
+		common.Log.Debug("ERROR: Invalid DSS (%T)", obj)
+		return nil, ErrTypeError
+	}
+
+	if dss.Certs, err = this.loadDSSStreams(dss, dict.Get("Certs")); err != nil {
+		return nil, err
+	}
+	if dss.OCSPs, err = this.loadDSSStreams(dss, dict.Get("OCSPs")); err != nil {
+		return nil, err
+	}
+	if dss.CRLs, err = this.loadDSSStreams(dss, dict.Get("CRLs")); err != nil {
+		return nil, err
+	}
+
+	vriObj, err := this.traceToObject(dict.Get("VRI"))
+	if err != nil {
+		return nil, err
+	}
+	if vriDict, ok := TraceToDirectObject(vriObj).(*PdfObjectDictionary); ok {
+		for _, key := range vriDict.Keys() {
+			obj, err := this.traceToObject(vriDict.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			d, ok := TraceToDirectObject(obj).(*PdfObjectDictionary)
+			if !ok {
+				common.Log.Debug("ERROR: Invalid VRI entry %s (%T)", key, obj)
+				continue
+			}
+
+			vri := &PdfVRI{}
+			if vri.Cert, err = this.loadDSSStreams(dss, d.Get("Cert")); err != nil {
+				return nil, err
+			}
+			if vri.OCSP, err = this.loadDSSStreams(dss, d.Get("OCSP")); err != nil {
+				return nil, err
+			}
+			if vri.CRL, err = this.loadDSSStreams(dss, d.Get("CRL")); err != nil {
+				return nil, err
+			}
+			tu, err := this.traceToObject(d.Get("TU"))
+			if err != nil {
+				return nil, err
+			}
+			vri.TU, _ = tu.(*PdfObjectString)
+			ts, err := this.traceToObject(d.Get("TS"))
+			if err != nil {
+				return nil, err
+			}
+			vri.TS, _ = ts.(*PdfObjectStream)
+
+			dss.VRI[strings.ToUpper(string(key))] = vri
+		}
+	}
+
+	return dss, nil
+}
+
+// loadDSSStreams loads the array of streams `obj` of a DSS or VRI dictionary, registering the streams with
+// `dss`.
+func (this *PdfReader) loadDSSStreams(dss *PdfDSS, obj PdfObject) ([]*PdfObjectStream, error) {
+	obj, err := this.traceToObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !ok {
+		return nil, nil
+	}
+
+	var streams []*PdfObjectStream
+	for _, o := range *arr {
+		o, err := this.traceToObject(o)
+		if err != nil {
+			return nil, err
+		}
+		stream, ok := o.(*PdfObjectStream)
+		if !ok {
+			common.Log.Debug("ERROR: DSS entry not a stream (%T)", o)
+			continue
+		}
+		data, err := DecodeStream(stream)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha1.Sum(data)
+		if existing, has := dss.streams[string(sum[:])]; has {
+			stream = existing
+		} else {
+			dss.streams[string(sum[:])] = stream
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// GetContainingPdfObject implements interface PdfModel.
+func (dss *PdfDSS) GetContainingPdfObject() PdfObject {
+	return dss.primitive
+}
+
+// ToPdfObject implements interface PdfModel.
+func (dss *PdfDSS) ToPdfObject() PdfObject {
+	container := dss.primitive
+	dict, ok := container.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		dict = MakeDict()
+		container.PdfObject = dict
+	}
+
+	dict.Set("Type", MakeName("DSS"))
+	setStreamArray(dict, "Certs", dss.Certs)
+	setStreamArray(dict, "OCSPs", dss.OCSPs)
+	setStreamArray(dict, "CRLs", dss.CRLs)
+
+	if len(dss.VRI) > 0 {
+		keys := make([]string, 0, len(dss.VRI))
+		for key := range dss.VRI {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		vriDict := MakeDict()
+		for _, key := range keys {
+			vriDict.Set(PdfObjectName(key), dss.VRI[key].ToPdfObject())
+		}
+		dict.Set("VRI", vriDict)
+	}
+
+	return container
+}
+
+// ToPdfObject returns the VRI dictionary.
+func (vri *PdfVRI) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("Type", MakeName("VRI"))
+	setStreamArray(dict, "Cert", vri.Cert)
+	setStreamArray(dict, "OCSP", vri.OCSP)
+	setStreamArray(dict, "CRL", vri.CRL)
+	if vri.TU != nil {
+		dict.Set("TU", vri.TU)
+	}
+	if vri.TS != nil {
+		dict.Set("TS", vri.TS)
+	}
+	return dict
+}
+
+// setStreamArray sets entry `key` of `dict` to an array of `streams`, or removes it if empty.
+func setStreamArray(dict *PdfObjectDictionary, key PdfObjectName, streams []*PdfObjectStream) {
+	if len(streams) == 0 {
+		dict.Remove(key)
+		return
+	}
+	arr := PdfObjectArray{}
+	for _, stream := range streams {
+		arr = append(arr, stream)
+	}
+	dict.Set(key, &arr)
+}
@@ -0,0 +1,600 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfEditor edits the page tree of a loaded document: pages can be inserted, removed, moved and rotated.
+// When written out, the inheritable page attributes (Resources, MediaBox, CropBox, Rotate) are resolved for
+// each page and the outlines, link annotations, form fields, named destinations and page labels of the
+// document are kept consistent with the edited page list: references to pages that are not in the output
+// are dropped.  The outlines, link annotations, form fields and content streams of the document are restored
+// after writing, so that parts of a document can be written out separately (see Split) and a document can be
+// written more than once.  Writing does update the page dictionaries though: their Parent is set to the
+// page tree of the output and the inherited page attributes are copied into them.
+type PdfEditor struct {
+	pages []*PdfPage
+	// pruneOutlines drops the outline items to pages not in the output instead of only their destinations,
+	// for the parts of a split document.
+	pruneOutlines bool
+
+	outlineTree  *PdfOutlineTreeNode
+	acroForm     *PdfAcroForm
+	ocProperties PdfObject
+
+	// Named destinations by name.
+	dests map[string]PdfObject
+	// Page labels by page.
+	labels map[*PdfPage]pageLabel
+
+	// Pages known to the editor, including pages removed from the document.
+	known map[*PdfIndirectObject]*PdfPage
+}
+
+// pageLabel is the label of a page: the page labelling range of the page and the number of the page within
+// the range.
+type pageLabel struct {
+	labelRange *PdfPageLabelRange
+	number     int64
+}
+
+// NewPdfEditor returns a new editor for the document loaded by `reader`.
+func NewPdfEditor(reader *PdfReader) (*PdfEditor, error) {
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		return nil, err
+	}
+
+	editor := &PdfEditor{
+		outlineTree: reader.GetOutlineTree(),
+		acroForm:    reader.AcroForm,
+		dests:       map[string]PdfObject{},
+		labels:      map[*PdfPage]pageLabel{},
+		known:       map[*PdfIndirectObject]*PdfPage{},
+	}
+	for i := 1; i <= numPages; i++ {
+		page, err := reader.GetPage(i)
+		if err != nil {
+			return nil, err
+		}
+		editor.pages = append(editor.pages, page)
+		editor.known[page.GetPageAsIndirectObject()] = page
+	}
+
+	editor.ocProperties, err = reader.GetOCProperties()
+	if err != nil {
+		return nil, err
+	}
+	if err := editor.loadDests(reader); err != nil {
+		return nil, err
+	}
+	if err := editor.loadPageLabels(reader); err != nil {
+		return nil, err
+	}
+
+	return editor, nil
+}
+
+// loadDests loads the named destinations of the document, from both the Dests dictionary of the catalog
+// (PDF 1.1) and the Dests name tree.
+func (e *PdfEditor) loadDests(reader *PdfReader) error {
+	obj, err := reader.traceToObject(reader.catalog.Get("Dests"))
+	if err != nil {
+		return err
+	}
+	if dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary); isDict {
+		for _, key := range dict.Keys() {
+			e.dests[string(key)] = dict.Get(key)
+		}
+	}
+
+	tree, err := reader.GetNameTree("Dests")
+	if err != nil {
+		return err
+	}
+	if tree != nil {
+		err := tree.Walk(func(name string, dest PdfObject) bool {
+			e.dests[name] = dest
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, dest := range e.dests {
+		dest, err := reader.traceToObject(dest)
+		if err != nil {
+			return err
+		}
+		if err := reader.traverseObjectData(dest); err != nil {
+			return err
+		}
+		e.dests[name] = TraceToDirectObject(dest)
+	}
+	return nil
+}
+
+// loadPageLabels determines the label of each page from the page labelling ranges of the document.
+func (e *PdfEditor) loadPageLabels(reader *PdfReader) error {
+	ranges, err := reader.GetPageLabelRanges()
+	if err != nil {
+		return err
+	}
+
+	for i, page := range e.pages {
+		idx := sort.Search(len(ranges), func(j int) bool { return ranges[j].StartPage > i }) - 1
+		if idx < 0 {
+			continue
+		}
+		labelRange := ranges[idx]
+		e.labels[page] = pageLabel{labelRange: labelRange, number: labelRange.Start + int64(i-labelRange.StartPage)}
+	}
+	return nil
+}
+
+// GetNumPages returns the number of pages of the edited document.
+func (e *PdfEditor) GetNumPages() int {
+	return len(e.pages)
+}
+
+// GetPage returns page `pageNum` (starting from 1) of the edited document.
+func (e *PdfEditor) GetPage(pageNum int) (*PdfPage, error) {
+	if pageNum < 1 || pageNum > len(e.pages) {
+		return nil, fmt.Errorf("Invalid page number %d (%d pages)", pageNum, len(e.pages))
+	}
+	return e.pages[pageNum-1], nil
+}
+
+// GetPages returns the pages of the edited document in order.
+func (e *PdfEditor) GetPages() []*PdfPage {
+	return append([]*PdfPage{}, e.pages...)
+}
+
+// hasPage checks whether `page` is in the edited document.
+func (e *PdfEditor) hasPage(page *PdfPage) bool {
+	for _, p := range e.pages {
+		if p == page || p.GetPageAsIndirectObject() == page.GetPageAsIndirectObject() {
+			return true
+		}
+	}
+	return false
+}
+
+// InsertPage inserts `page` before page `pageNum` (starting from 1).  With pageNum equal to the number of
+// pages plus one the page is appended.  The page can be a new page or a page of another document, but can
+// only be in the document once.
+func (e *PdfEditor) InsertPage(pageNum int, page *PdfPage) error {
+	if page == nil {
+		return errors.New("Page missing")
+	}
+	if pageNum < 1 || pageNum > len(e.pages)+1 {
+		return fmt.Errorf("Invalid page number %d (%d pages)", pageNum, len(e.pages))
+	}
+	if e.hasPage(page) {
+		return errors.New("Page already in the document")
+	}
+
+	idx := pageNum - 1
+	e.pages = append(e.pages, nil)
+	copy(e.pages[idx+1:], e.pages[idx:])
+	e.pages[idx] = page
+	e.known[page.GetPageAsIndirectObject()] = page
+	return nil
+}
+
+// AddPage appends `page` to the document.
+func (e *PdfEditor) AddPage(page *PdfPage) error {
+	return e.InsertPage(len(e.pages)+1, page)
+}
+
+// RemovePage removes page `pageNum` (starting from 1) from the document.
+func (e *PdfEditor) RemovePage(pageNum int) error {
+	if _, err := e.GetPage(pageNum); err != nil {
+		return err
+	}
+
+	e.pages = append(e.pages[:pageNum-1], e.pages[pageNum:]...)
+	return nil
+}
+
+// MovePage moves page `from` to position `to` (both starting from 1), i.e. the page becomes page `to` of
+// the document.
+func (e *PdfEditor) MovePage(from, to int) error {
+	page, err := e.GetPage(from)
+	if err != nil {
+		return err
+	}
+	if to < 1 || to > len(e.pages) {
+		return fmt.Errorf("Invalid page number %d (%d pages)", to, len(e.pages))
+	}
+
+	e.pages = append(e.pages[:from-1], e.pages[from:]...)
+	e.pages = append(e.pages, nil)
+	copy(e.pages[to:], e.pages[to-1:])
+	e.pages[to-1] = page
+	return nil
+}
+
+// RotatePage rotates page `pageNum` (starting from 1) clockwise by `angle` degrees, which must be a
+// multiple of 90, relative to its current (possibly inherited) rotation.
+func (e *PdfEditor) RotatePage(pageNum int, angle int64) error {
+	if angle%90 != 0 {
+		return errors.New("Rotation angle must be a multiple of 90")
+	}
+	page, err := e.GetPage(pageNum)
+	if err != nil {
+		return err
+	}
+
+	rotate, err := page.GetRotate()
+	if err != nil {
+		return err
+	}
+	rotate = (rotate + angle) % 360
+	if rotate < 0 {
+		rotate += 360
+	}
+	page.Rotate = &rotate
+	return nil
+}
+
+// isPageObject checks whether `obj` is an indirect object containing a page dictionary.
+func isPageObject(obj PdfObject) bool {
+	ind, isIndirect := obj.(*PdfIndirectObject)
+	if !isIndirect {
+		return false
+	}
+	dict, isDict := ind.PdfObject.(*PdfObjectDictionary)
+	if !isDict {
+		return false
+	}
+	name, isName := dict.Get("Type").(*PdfObjectName)
+	return isName && *name == "Page"
+}
+
+// isValidDest checks whether destination `dest` is valid in an output with pages `pageSet`: explicit
+// destinations ([page /Fit ...]) and named destinations must not refer to a page outside of the output.
+func (e *PdfEditor) isValidDest(dest PdfObject, pageSet map[PdfObject]bool) bool {
+	dest = TraceToDirectObject(dest)
+	switch t := dest.(type) {
+	case *PdfObjectString:
+		target, has := e.dests[string(*t)]
+		return !has || e.isValidDest(target, pageSet)
+	case *PdfObjectName:
+		target, has := e.dests[string(*t)]
+		return !has || e.isValidDest(target, pageSet)
+	case *PdfObjectDictionary:
+		// Destination dictionary of a named destination.
+		return e.isValidDest(t.Get("D"), pageSet)
+	case *PdfObjectArray:
+		if len(*t) == 0 {
+			return true
+		}
+		page := (*t)[0]
+		return !isPageObject(page) || pageSet[page]
+	}
+	return true
+}
+
+// isValidAction checks whether `action` is valid in an output with pages `pageSet`: GoTo actions must not
+// refer to a page outside of the output.
+func (e *PdfEditor) isValidAction(action PdfObject, pageSet map[PdfObject]bool) bool {
+	dict, isDict := TraceToDirectObject(action).(*PdfObjectDictionary)
+	if !isDict {
+		return true
+	}
+	if s, isName := TraceToDirectObject(dict.Get("S")).(*PdfObjectName); !isName || *s != "GoTo" {
+		return true
+	}
+	return e.isValidDest(dict.Get("D"), pageSet)
+}
+
+// copyOutlineItems copies the children of outline tree node `src` and their descendants to `dst` for an
+// output with pages `pageSet`.  The destinations and GoTo actions of items referring to pages not in the
+// output are dropped, the items are kept as their children can still be valid.  With pruneOutlines set, such
+// items are dropped unless they have descendants in the output.
+func (e *PdfEditor) copyOutlineItems(src, dst *PdfOutlineTreeNode, pageSet map[PdfObject]bool) {
+	for _, srcItem := range src.Children() {
+		item := NewPdfOutlineItem()
+		item.Title = srcItem.Title
+		item.C = srcItem.C
+		item.F = srcItem.F
+
+		validDest := srcItem.Dest == nil || e.isValidDest(srcItem.Dest, pageSet)
+		validAction := srcItem.A == nil || e.isValidAction(srcItem.A, pageSet)
+		if validDest {
+			item.Dest = srcItem.Dest
+		}
+		if validAction {
+			item.A = srcItem.A
+		}
+
+		e.copyOutlineItems(&srcItem.PdfOutlineTreeNode, &item.PdfOutlineTreeNode, pageSet)
+		if !validDest || !validAction {
+			if e.pruneOutlines && item.First == nil {
+				common.Log.Debug("Dropping outline item to page not in output (%s)", srcItem.Title)
+				continue
+			}
+			common.Log.Debug("Removing outline destination to page not in output (%s)", srcItem.Title)
+		}
+		item.SetOpen(srcItem.IsOpen())
+
+		if err := dst.AddChild(item); err != nil {
+			common.Log.Debug("ERROR: %v", err)
+		}
+	}
+}
+
+// makeOutlines returns the outline tree for an output with pages `pageSet`, or nil if empty.
+func (e *PdfEditor) makeOutlines(pageSet map[PdfObject]bool) *PdfOutline {
+	if e.outlineTree == nil {
+		return nil
+	}
+	outline := NewPdfOutlineTree()
+	e.copyOutlineItems(e.outlineTree, &outline.PdfOutlineTreeNode, pageSet)
+	if outline.First == nil {
+		return nil
+	}
+	return outline
+}
+
+// filterAnnotations returns the annotations of `page` without the links to pages outside of the output
+// with pages `pageSet`.
+func (e *PdfEditor) filterAnnotations(page *PdfPage, pageSet map[PdfObject]bool) []*PdfAnnotation {
+	if page.Annotations == nil {
+		return nil
+	}
+	annotations := []*PdfAnnotation{}
+	for _, annot := range page.Annotations {
+		if link, isLink := annot.GetContext().(*PdfAnnotationLink); isLink {
+			if (link.Dest != nil && !e.isValidDest(link.Dest, pageSet)) || (link.A != nil && !e.isValidAction(link.A, pageSet)) {
+				common.Log.Debug("Dropping link to page not in output")
+				continue
+			}
+		}
+		annotations = append(annotations, annot)
+	}
+	return annotations
+}
+
+// filterFields removes the widget annotations in `dropped` from the form fields in `fields`, dropping
+// fields whose widgets have all been removed.  Returns the remaining fields.  The previous kids of the
+// modified fields are restored by the functions added to `restore`.
+func (e *PdfEditor) filterFields(fields []*PdfField, dropped map[PdfObject]bool, restore *[]func()) []*PdfField {
+	remaining := []*PdfField{}
+	for _, field := range fields {
+		field := field
+		hadKids := len(field.KidsF) > 0 || len(field.KidsA) > 0
+		oldKidsA, oldKidsF := field.KidsA, field.KidsF
+		*restore = append(*restore, func() {
+			field.KidsA, field.KidsF = oldKidsA, oldKidsF
+		})
+
+		var kidsA []*PdfAnnotation
+		for _, annot := range field.KidsA {
+			if !dropped[annot.GetContainingPdfObject()] {
+				kidsA = append(kidsA, annot)
+			}
+		}
+
+		var kidFields []*PdfField
+		var kidsF []PdfModel
+		for _, kid := range field.KidsF {
+			if kidField, isField := kid.(*PdfField); isField {
+				kidFields = append(kidFields, kidField)
+			} else {
+				kidsF = append(kidsF, kid)
+			}
+		}
+		for _, kidField := range e.filterFields(kidFields, dropped, restore) {
+			kidsF = append(kidsF, kidField)
+		}
+
+		field.KidsA = kidsA
+		field.KidsF = kidsF
+		if hadKids && len(field.KidsF) == 0 && len(field.KidsA) == 0 {
+			common.Log.Debug("Dropping field with all widgets on pages not in output")
+			continue
+		}
+		remaining = append(remaining, field)
+	}
+	return remaining
+}
+
+// makeForm returns the interactive form for an output with pages `pageSet`, with the fields whose widgets
+// are on other pages of the document removed.
+func (e *PdfEditor) makeForm(pageSet map[PdfObject]bool, restore *[]func()) *PdfAcroForm {
+	if e.acroForm == nil {
+		return nil
+	}
+
+	dropped := map[PdfObject]bool{}
+	for obj, page := range e.known {
+		if pageSet[obj] {
+			continue
+		}
+		for _, annot := range page.Annotations {
+			dropped[annot.GetContainingPdfObject()] = true
+		}
+	}
+
+	form := *e.acroForm
+	if form.Fields != nil {
+		fields := e.filterFields(*form.Fields, dropped, restore)
+		form.Fields = &fields
+	}
+	if form.CO != nil {
+		kept := map[PdfObject]bool{}
+		var collect func(fields []*PdfField)
+		collect = func(fields []*PdfField) {
+			for _, field := range fields {
+				kept[field.GetContainingPdfObject()] = true
+				for _, kid := range field.KidsF {
+					if kidField, isField := kid.(*PdfField); isField {
+						collect([]*PdfField{kidField})
+					}
+				}
+			}
+		}
+		if form.Fields != nil {
+			collect(*form.Fields)
+		}
+		co := PdfObjectArray{}
+		for _, obj := range *form.CO {
+			if kept[obj] {
+				co = append(co, obj)
+			}
+		}
+		form.CO = &co
+	}
+	return &form
+}
+
+// makeDestTree returns the name tree of the named destinations valid in an output with pages `pageSet`, or
+// nil if there are none.
+func (e *PdfEditor) makeDestTree(pageSet map[PdfObject]bool) *PdfNameTree {
+	tree := NewPdfNameTree()
+	for name, dest := range e.dests {
+		if e.isValidDest(dest, pageSet) {
+			tree.entries[name] = dest
+		}
+	}
+	if len(tree.entries) == 0 {
+		return nil
+	}
+	return tree
+}
+
+// makePageLabels returns the page labelling ranges for the pages of the document, or nil if the pages have
+// no labels.  Pages without a label are labelled with their page number.
+func (e *PdfEditor) makePageLabels() []*PdfPageLabelRange {
+	hasLabels := false
+	for _, page := range e.pages {
+		if _, has := e.labels[page]; has {
+			hasLabels = true
+			break
+		}
+	}
+	if !hasLabels {
+		return nil
+	}
+
+	var ranges []*PdfPageLabelRange
+	var prev pageLabel
+	for i, page := range e.pages {
+		label, has := e.labels[page]
+		if !has {
+			label = pageLabel{number: int64(i + 1)}
+		}
+		if i > 0 && label.labelRange == prev.labelRange && label.number == prev.number+1 {
+			prev = label
+			continue
+		}
+
+		// Start a new labelling range.
+		labelRange := NewPdfPageLabelRange(i, PageLabelStyleDecimal)
+		if label.labelRange != nil {
+			labelRange.Style = label.labelRange.Style
+			labelRange.Prefix = label.labelRange.Prefix
+		}
+		labelRange.Start = label.number
+		ranges = append(ranges, labelRange)
+		prev = label
+	}
+	return ranges
+}
+
+// Write writes the edited document to `ws`.
+func (e *PdfEditor) Write(ws io.WriteSeeker) error {
+	pageSet := map[PdfObject]bool{}
+	for _, page := range e.pages {
+		pageSet[page.GetPageAsIndirectObject()] = true
+	}
+
+	// Models modified for the output are restored when done.
+	var restore []func()
+	defer func() {
+		for i := len(restore) - 1; i >= 0; i-- {
+			restore[i]()
+		}
+	}()
+
+	writer := NewPdfWriter()
+	for _, page := range e.pages {
+		page := page
+		annotations := page.Annotations
+		// The writer can add content streams to the page, e.g. the watermark of unlicensed builds.
+		contents := page.Contents
+		var contentArray PdfObjectArray
+		if arr, isArray := TraceToDirectObject(contents).(*PdfObjectArray); isArray {
+			contentArray = append(PdfObjectArray{}, *arr...)
+		}
+		restore = append(restore, func() {
+			page.Annotations = annotations
+			if arr, isArray := TraceToDirectObject(contents).(*PdfObjectArray); isArray {
+				*arr = contentArray
+			}
+			page.Contents = contents
+			page.ToPdfObject()
+		})
+		page.Annotations = e.filterAnnotations(page, pageSet)
+
+		if err := writer.AddPage(page); err != nil {
+			return err
+		}
+	}
+
+	if outline := e.makeOutlines(pageSet); outline != nil {
+		writer.AddOutlineTree(&outline.PdfOutlineTreeNode)
+	}
+
+	if form := e.makeForm(pageSet, &restore); form != nil {
+		if err := writer.SetForms(form); err != nil {
+			return err
+		}
+	}
+
+	if dests := e.makeDestTree(pageSet); dests != nil {
+		if err := writer.SetNameTree("Dests", dests); err != nil {
+			return err
+		}
+	}
+
+	if labels := e.makePageLabels(); labels != nil {
+		if err := writer.SetPageLabelRanges(labels); err != nil {
+			return err
+		}
+	}
+
+	if e.ocProperties != nil {
+		if err := writer.SetOCProperties(e.ocProperties); err != nil {
+			return err
+		}
+	}
+
+	return writer.Write(ws)
+}
+
+// WriteToFile writes the edited document to file `outputPath`.
+func (e *PdfEditor) WriteToFile(outputPath string) error {
+	fWrite, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer fWrite.Close()
+
+	return e.Write(fWrite)
+}
@@ -0,0 +1,287 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// makeEditorTestFile writes a 3 page document to `path`, with an outline item per page, a link on page 1
+// to page 3, a text field with its widget on page 3, a named destination "p3" to page 3 and lowercase roman
+// page labels.  The pages have a width of 100 times their number.
+func makeEditorTestFile(t *testing.T, path string) {
+	writer := NewPdfWriter()
+
+	var pages []*PdfPage
+	for i := 1; i <= 3; i++ {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: float64(100 * i), Ury: 200}
+		page.Resources = NewPdfPageResources()
+		pages = append(pages, page)
+	}
+
+	link := NewPdfAnnotationLink()
+	link.Rect = MakeArrayFromFloats([]float64{10, 10, 50, 50})
+	link.Dest = MakeArray(pages[2].GetPageAsIndirectObject(), MakeName("Fit"))
+	pages[0].Annotations = append(pages[0].Annotations, link.PdfAnnotation)
+
+	field := NewPdfField()
+	field.FT = MakeName("Tx")
+	field.T = MakeString("Text1")
+	widget := NewPdfAnnotationWidget()
+	widget.Rect = MakeArrayFromFloats([]float64{10, 60, 90, 80})
+	widget.P = pages[2].GetPageAsIndirectObject()
+	widget.Parent = field.GetContainingPdfObject()
+	field.KidsA = append(field.KidsA, widget.PdfAnnotation)
+	pages[2].Annotations = append(pages[2].Annotations, widget.PdfAnnotation)
+
+	form := NewPdfAcroForm()
+	form.Fields = &[]*PdfField{field}
+
+	outline := NewPdfOutlineTree()
+	var prev *PdfOutlineItem
+	for i, page := range pages {
+		item := NewOutlineBookmark(fmt.Sprintf("Page %d", i+1), page.GetPageAsIndirectObject())
+		item.Parent = &outline.PdfOutlineTreeNode
+		if prev == nil {
+			outline.First = &item.PdfOutlineTreeNode
+		} else {
+			prev.Next = &item.PdfOutlineTreeNode
+			item.Prev = &prev.PdfOutlineTreeNode
+		}
+		outline.Last = &item.PdfOutlineTreeNode
+		prev = item
+	}
+
+	for _, page := range pages {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	writer.AddOutlineTree(&outline.PdfOutlineTreeNode)
+	writer.SetForms(form)
+
+	dests := MakeDict()
+	dests.Set("Names", MakeArray(MakeString("p3"), MakeArray(pages[2].GetPageAsIndirectObject(), MakeName("Fit"))))
+	names := MakeDict()
+	names.Set("Dests", dests)
+	if err := writer.SetNameDictionary(names); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	label := MakeDict()
+	label.Set("S", MakeName("r"))
+	labels := MakeDict()
+	labels.Set("Nums", MakeArray(MakeInteger(0), label))
+	if err := writer.SetPageLabels(labels); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) *PdfReader {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return reader
+}
+
+// getPageWidths returns the media box widths of the pages of `reader`.
+func getPageWidths(t *testing.T, reader *PdfReader) []float64 {
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var widths []float64
+	for i := 1; i <= numPages; i++ {
+		page, err := reader.GetPage(i)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		mbox, err := page.GetMediaBox()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		widths = append(widths, mbox.Urx-mbox.Llx)
+	}
+	return widths
+}
+
+// getOutlineItems returns the top-level items of outline tree `tree`.
+func getOutlineItems(tree *PdfOutlineTreeNode) []*PdfOutlineItem {
+	var items []*PdfOutlineItem
+	if tree == nil {
+		return nil
+	}
+	for node := tree.First; node != nil; {
+		item := node.context.(*PdfOutlineItem)
+		items = append(items, item)
+		node = item.Next
+	}
+	return items
+}
+
+func TestEditorMoveRotate(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	reader := readTestFile(t, "/tmp/editor_src.pdf")
+
+	editor, err := NewPdfEditor(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// 1 2 3 -> 3 1 2
+	if err := editor.MovePage(3, 1); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.RotatePage(1, 90); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.RotatePage(1, -180); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.RotatePage(1, 45); err == nil {
+		t.Errorf("Rotation by 45 degrees should fail")
+	}
+	if err := editor.WriteToFile("/tmp/editor_moved.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	out := readTestFile(t, "/tmp/editor_moved.pdf")
+	widths := getPageWidths(t, out)
+	if len(widths) != 3 || widths[0] != 300 || widths[1] != 100 || widths[2] != 200 {
+		t.Fatalf("Invalid page order: %v", widths)
+	}
+	page, _ := out.GetPage(1)
+	if rotate, _ := page.GetRotate(); rotate != 270 {
+		t.Errorf("Invalid rotation: %d", rotate)
+	}
+
+	// Outline and link still refer to the moved page.
+	first, ok := out.GetOutlineTree().First.context.(*PdfOutlineItem)
+	if !ok {
+		t.Fatalf("Outline missing")
+	}
+	outlinePage := (*first.Dest.(*PdfObjectArray))[0]
+	if outlinePage != out.pageList[1] {
+		t.Errorf("Outline destination not page 2")
+	}
+	page2, _ := out.GetPage(2)
+	if len(page2.Annotations) != 1 {
+		t.Fatalf("Link missing")
+	}
+	link := page2.Annotations[0].GetContext().(*PdfAnnotationLink)
+	if (*TraceToDirectObject(link.Dest).(*PdfObjectArray))[0] != out.pageList[0] {
+		t.Errorf("Link destination not page 1")
+	}
+}
+
+func TestEditorRemoveInsert(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	reader := readTestFile(t, "/tmp/editor_src.pdf")
+
+	editor, err := NewPdfEditor(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.RemovePage(3); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.RemovePage(3); err == nil {
+		t.Errorf("Removing non-existing page should fail")
+	}
+
+	// Page from another document with inherited MediaBox.
+	other := readTestFile(t, "../../testfiles/minimal.pdf")
+	otherPage, err := other.GetPage(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.InsertPage(1, otherPage); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.AddPage(otherPage); err == nil {
+		t.Errorf("Adding the same page twice should fail")
+	}
+	if editor.GetNumPages() != 3 {
+		t.Fatalf("Invalid page count %d", editor.GetNumPages())
+	}
+	if err := editor.WriteToFile("/tmp/editor_removed.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	out := readTestFile(t, "/tmp/editor_removed.pdf")
+	widths := getPageWidths(t, out)
+	if len(widths) != 3 || widths[0] != 300 || widths[1] != 100 || widths[2] != 200 {
+		t.Fatalf("Invalid pages: %v", widths)
+	}
+
+	// Link to the removed page dropped.
+	page2, _ := out.GetPage(2)
+	for _, annot := range page2.Annotations {
+		if _, isLink := annot.GetContext().(*PdfAnnotationLink); isLink {
+			t.Errorf("Link to removed page not removed")
+		}
+	}
+
+	// Field with the widget on the removed page dropped.
+	if out.AcroForm != nil && out.AcroForm.Fields != nil && len(*out.AcroForm.Fields) != 0 {
+		t.Errorf("Field on removed page not removed")
+	}
+
+	// Outline item of the removed page kept, without destination.
+	items := getOutlineItems(out.GetOutlineTree())
+	if len(items) != 3 {
+		t.Fatalf("Invalid outline items: %d", len(items))
+	}
+	if items[0].Dest == nil || items[2].Dest != nil {
+		t.Errorf("Invalid outline destinations")
+	}
+}
+
+func TestEditorWriteTwice(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	reader := readTestFile(t, "/tmp/editor_src.pdf")
+
+	editor, err := NewPdfEditor(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	page, _ := editor.GetPage(1)
+	contents, err := page.GetContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// Content streams added by the writer are not kept in the document.
+	for i := 0; i < 2; i++ {
+		if err := editor.WriteToFile("/tmp/editor_twice.pdf"); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		after, err := page.GetContentStreams()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if len(after) != len(contents) {
+			t.Fatalf("Content streams changed by writing: %d, expected %d", len(after), len(contents))
+		}
+	}
+}
@@ -0,0 +1,535 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfExtGState represents a graphics state parameter dictionary (8.4.5), the parameters set by the gs operator.
+// Nil and empty fields are not set by the dictionary.
+type PdfExtGState struct {
+	// LW is the line width.
+	LW *float64
+	// LC is the line cap style and LJ the line join style.
+	LC *int64
+	LJ *int64
+	// ML is the miter limit.
+	ML *float64
+	// D is the line dash pattern.
+	D *PdfDashPattern
+	// RI is the rendering intent, e.g. "RelativeColorimetric".
+	RI string
+	// OP is the overprint flag for stroking and Op (op) for other painting operations.  Op defaults to OP.
+	OP *bool
+	Op *bool
+	// OPM is the overprint mode.
+	OPM *int64
+	// Font is the text font and size.
+	Font *PdfExtGStateFont
+	// BM is the blend mode, e.g. "Multiply".  The first blend mode of a list in a loaded dictionary is used, the
+	// list is kept unless BM is changed.
+	BM string
+	// SMask is the soft mask, SoftMaskNone for none.
+	SMask *PdfSoftMask
+	// CA is the constant alpha for stroking and Ca (ca) for other painting operations.
+	CA *float64
+	Ca *float64
+	// AIS is the alpha source flag: soft masks and alpha constants are shape instead of opacity values.
+	AIS *bool
+	// TK is the text knockout flag.
+	TK *bool
+	// SA is the automatic stroke adjustment flag.
+	SA *bool
+
+	dict      *PdfObjectDictionary
+	container *PdfIndirectObject
+	// saved holds the values of the fields in the dictionary, as loaded or last written, so that only the
+	// entries of changed fields are updated.  Entries which could not be loaded are left as they are.
+	saved *PdfExtGState
+}
+
+// PdfDashPattern is a line dash pattern (8.4.3.6): the lengths of alternating dashes and gaps, starting at
+// Phase in the pattern.  A solid line has no lengths.
+type PdfDashPattern struct {
+	Array []float64
+	Phase float64
+}
+
+// PdfExtGStateFont is the font entry of a graphics state parameter dictionary: a font dictionary and size.
+type PdfExtGStateFont struct {
+	Font PdfObject
+	Size float64
+}
+
+// NewPdfExtGState returns a new graphics state parameter dictionary with no parameters set.
+func NewPdfExtGState() *PdfExtGState {
+	return &PdfExtGState{dict: MakeDict()}
+}
+
+// NewPdfExtGStateFromObject loads a graphics state parameter dictionary from `obj`, a dictionary or an indirect
+// object containing one.  Invalid entries are skipped.
+func NewPdfExtGStateFromObject(obj PdfObject) (*PdfExtGState, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid graphics state parameter dictionary (%T)", obj)
+	}
+	gs := &PdfExtGState{dict: dict}
+	gs.container, _ = obj.(*PdfIndirectObject)
+
+	for _, entry := range []struct {
+		key PdfObjectName
+		val **float64
+	}{{"LW", &gs.LW}, {"ML", &gs.ML}, {"CA", &gs.CA}, {"ca", &gs.Ca}} {
+		if obj := dict.Get(entry.key); obj != nil {
+			val, err := getNumberAsFloat(TraceToDirectObject(obj))
+			if err != nil {
+				common.Log.Debug("Invalid graphics state %s (%T), skipping", entry.key, obj)
+				continue
+			}
+			*entry.val = &val
+		}
+	}
+	for _, entry := range []struct {
+		key PdfObjectName
+		val **int64
+	}{{"LC", &gs.LC}, {"LJ", &gs.LJ}, {"OPM", &gs.OPM}} {
+		if obj := dict.Get(entry.key); obj != nil {
+			val, err := getNumberAsInt64(TraceToDirectObject(obj))
+			if err != nil {
+				common.Log.Debug("Invalid graphics state %s (%T), skipping", entry.key, obj)
+				continue
+			}
+			*entry.val = &val
+		}
+	}
+	for _, entry := range []struct {
+		key PdfObjectName
+		val **bool
+	}{{"OP", &gs.OP}, {"op", &gs.Op}, {"AIS", &gs.AIS}, {"TK", &gs.TK}, {"SA", &gs.SA}} {
+		if obj := dict.Get(entry.key); obj != nil {
+			val, isBool := TraceToDirectObject(obj).(*PdfObjectBool)
+			if !isBool {
+				common.Log.Debug("Invalid graphics state %s (%T), skipping", entry.key, obj)
+				continue
+			}
+			b := bool(*val)
+			*entry.val = &b
+		}
+	}
+
+	if obj := dict.Get("D"); obj != nil {
+		dash, err := newPdfDashPatternFromObject(obj)
+		if err != nil {
+			common.Log.Debug("Invalid graphics state D, skipping: %v", err)
+		}
+		gs.D = dash
+	}
+	if ri, isName := TraceToDirectObject(dict.Get("RI")).(*PdfObjectName); isName {
+		gs.RI = string(*ri)
+	}
+	if obj := dict.Get("Font"); obj != nil {
+		arr, isArray := TraceToDirectObject(obj).(*PdfObjectArray)
+		if isArray && len(*arr) == 2 {
+			size, err := getNumberAsFloat(TraceToDirectObject((*arr)[1]))
+			if err == nil {
+				gs.Font = &PdfExtGStateFont{Font: (*arr)[0], Size: size}
+			}
+		}
+		if gs.Font == nil {
+			common.Log.Debug("Invalid graphics state Font (%s), skipping", obj)
+		}
+	}
+	switch t := TraceToDirectObject(dict.Get("BM")).(type) {
+	case *PdfObjectName:
+		gs.BM = string(*t)
+	case *PdfObjectArray:
+		// Deprecated list of blend modes, of which the first one supported is used.
+		if len(*t) > 0 {
+			if name, isName := TraceToDirectObject((*t)[0]).(*PdfObjectName); isName {
+				gs.BM = string(*name)
+			}
+		}
+	}
+	if obj := dict.Get("SMask"); obj != nil {
+		smask, err := NewPdfSoftMaskFromObject(obj)
+		if err != nil {
+			common.Log.Debug("Invalid graphics state SMask, skipping: %v", err)
+		}
+		gs.SMask = smask
+	}
+
+	gs.saved = gs.snapshot()
+	return gs, nil
+}
+
+// GetContainingPdfObject returns the object containing the graphics state parameter dictionary: the indirect
+// object it was loaded from, otherwise the dictionary.
+func (gs *PdfExtGState) GetContainingPdfObject() PdfObject {
+	if gs.container != nil {
+		return gs.container
+	}
+	return gs.dict
+}
+
+// SetOpacity sets the constant alpha for stroking and other painting operations to `opacity`.
+func (gs *PdfExtGState) SetOpacity(opacity float64) {
+	gs.CA = &opacity
+	gs.Ca = &opacity
+}
+
+// ToPdfObject returns the graphics state parameter dictionary updated from the fields, in the indirect object
+// it was loaded from if any.  Only the entries of the fields changed since loading are written or removed, so
+// that other entries, such as transfer functions and halftones, and entries which could not be loaded are kept.
+func (gs *PdfExtGState) ToPdfObject() PdfObject {
+	if gs.dict == nil {
+		gs.dict = MakeDict()
+	}
+	if gs.saved == nil {
+		gs.saved = &PdfExtGState{}
+	}
+	d := gs.dict
+	saved := gs.saved
+	d.Set("Type", MakeName("ExtGState"))
+
+	for _, entry := range []struct {
+		key        PdfObjectName
+		val, saved *float64
+	}{{"LW", gs.LW, saved.LW}, {"ML", gs.ML, saved.ML}, {"CA", gs.CA, saved.CA}, {"ca", gs.Ca, saved.Ca}} {
+		switch {
+		case entry.val != nil && (entry.saved == nil || *entry.val != *entry.saved):
+			d.Set(entry.key, MakeFloat(*entry.val))
+		case entry.val == nil && entry.saved != nil:
+			d.Remove(entry.key)
+		}
+	}
+	for _, entry := range []struct {
+		key        PdfObjectName
+		val, saved *int64
+	}{{"LC", gs.LC, saved.LC}, {"LJ", gs.LJ, saved.LJ}, {"OPM", gs.OPM, saved.OPM}} {
+		switch {
+		case entry.val != nil && (entry.saved == nil || *entry.val != *entry.saved):
+			d.Set(entry.key, MakeInteger(*entry.val))
+		case entry.val == nil && entry.saved != nil:
+			d.Remove(entry.key)
+		}
+	}
+	for _, entry := range []struct {
+		key        PdfObjectName
+		val, saved *bool
+	}{{"OP", gs.OP, saved.OP}, {"op", gs.Op, saved.Op}, {"AIS", gs.AIS, saved.AIS}, {"TK", gs.TK, saved.TK},
+		{"SA", gs.SA, saved.SA}} {
+		switch {
+		case entry.val != nil && (entry.saved == nil || *entry.val != *entry.saved):
+			d.Set(entry.key, MakeBool(*entry.val))
+		case entry.val == nil && entry.saved != nil:
+			d.Remove(entry.key)
+		}
+	}
+	for _, entry := range []struct {
+		key        PdfObjectName
+		val, saved string
+	}{{"RI", gs.RI, saved.RI}, {"BM", gs.BM, saved.BM}} {
+		switch {
+		case entry.val != "" && entry.val != entry.saved:
+			d.Set(entry.key, MakeName(entry.val))
+		case entry.val == "" && entry.saved != "":
+			d.Remove(entry.key)
+		}
+	}
+
+	switch {
+	case gs.D != nil && !gs.D.equals(saved.D):
+		d.Set("D", MakeArray(MakeArrayFromFloats(gs.D.Array), MakeFloat(gs.D.Phase)))
+	case gs.D == nil && saved.D != nil:
+		d.Remove("D")
+	}
+	switch {
+	case gs.Font != nil && (saved.Font == nil || *gs.Font != *saved.Font):
+		d.Set("Font", MakeArray(gs.Font.Font, MakeFloat(gs.Font.Size)))
+	case gs.Font == nil && saved.Font != nil:
+		d.Remove("Font")
+	}
+	switch {
+	case gs.SMask != nil:
+		// Soft mask dictionaries are updated in place, the entry is only replaced by another soft mask.
+		if smask := gs.SMask.ToPdfObject(); TraceToDirectObject(d.Get("SMask")) != smask {
+			d.Set("SMask", smask)
+		}
+	case saved.SMask != nil:
+		d.Remove("SMask")
+	}
+
+	gs.saved = gs.snapshot()
+	return gs.GetContainingPdfObject()
+}
+
+// snapshot returns a copy of the values of the fields.
+func (gs *PdfExtGState) snapshot() *PdfExtGState {
+	s := &PdfExtGState{RI: gs.RI, BM: gs.BM, SMask: gs.SMask}
+	for _, f := range []struct{ dst, src **float64 }{{&s.LW, &gs.LW}, {&s.ML, &gs.ML}, {&s.CA, &gs.CA},
+		{&s.Ca, &gs.Ca}} {
+		if *f.src != nil {
+			v := **f.src
+			*f.dst = &v
+		}
+	}
+	for _, f := range []struct{ dst, src **int64 }{{&s.LC, &gs.LC}, {&s.LJ, &gs.LJ}, {&s.OPM, &gs.OPM}} {
+		if *f.src != nil {
+			v := **f.src
+			*f.dst = &v
+		}
+	}
+	for _, f := range []struct{ dst, src **bool }{{&s.OP, &gs.OP}, {&s.Op, &gs.Op}, {&s.AIS, &gs.AIS},
+		{&s.TK, &gs.TK}, {&s.SA, &gs.SA}} {
+		if *f.src != nil {
+			v := **f.src
+			*f.dst = &v
+		}
+	}
+	if gs.D != nil {
+		s.D = &PdfDashPattern{Array: append([]float64(nil), gs.D.Array...), Phase: gs.D.Phase}
+	}
+	if gs.Font != nil {
+		font := *gs.Font
+		s.Font = &font
+	}
+	return s
+}
+
+// equals checks whether the dash pattern is the same as `other`, which may be nil.
+func (dash *PdfDashPattern) equals(other *PdfDashPattern) bool {
+	if other == nil || dash.Phase != other.Phase || len(dash.Array) != len(other.Array) {
+		return false
+	}
+	for i, v := range dash.Array {
+		if v != other.Array[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newPdfDashPatternFromObject loads a line dash pattern from an array [dashArray dashPhase] `obj`.
+func newPdfDashPatternFromObject(obj PdfObject) (*PdfDashPattern, error) {
+	arr, isArray := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !isArray || len(*arr) != 2 {
+		return nil, errors.New("Dash pattern not an array of two elements")
+	}
+	lengths, isArray := TraceToDirectObject((*arr)[0]).(*PdfObjectArray)
+	if !isArray {
+		return nil, errors.New("Dash array not an array")
+	}
+	dashArray, err := getNumbersAsFloat(*lengths)
+	if err != nil {
+		return nil, err
+	}
+	phase, err := getNumberAsFloat(TraceToDirectObject((*arr)[1]))
+	if err != nil {
+		return nil, err
+	}
+	return &PdfDashPattern{Array: dashArray, Phase: phase}, nil
+}
+
+// SoftMaskType is the subtype of a soft mask dictionary (Table 144): how the mask values are derived from the
+// transparency group.
+type SoftMaskType string
+
+const (
+	// SoftMaskNone is the soft mask of /SMask /None, which removes the current soft mask.
+	SoftMaskNone SoftMaskType = "None"
+	// SoftMaskAlpha derives the mask values from the group alpha.
+	SoftMaskAlpha SoftMaskType = "Alpha"
+	// SoftMaskLuminosity derives the mask values from the luminosity of the group composited with the backdrop
+	// color.
+	SoftMaskLuminosity SoftMaskType = "Luminosity"
+)
+
+// PdfSoftMask represents a soft mask dictionary (11.6.5.2), or the name None for no soft mask.
+type PdfSoftMask struct {
+	S SoftMaskType
+	// G is the transparency group XObject defining the mask values.
+	G *XObjectForm
+	// BC is the backdrop color of luminosity masks, in the color space of the group.  The default is black.
+	BC []float64
+	// TR is the transfer function mapping the mask values, nil for the identity.
+	TR PdfFunction
+
+	dict *PdfObjectDictionary
+	// saved holds the values of the fields in the dictionary, as loaded or last written, as for PdfExtGState.
+	saved *PdfSoftMask
+}
+
+// NewPdfSoftMask returns a soft mask of subtype `s` defined by transparency group XObject `group`.  The group
+// attributes of `group` are set to a transparency group if not already.
+func NewPdfSoftMask(s SoftMaskType, group *XObjectForm) *PdfSoftMask {
+	if _, err := NewPdfTransparencyGroupFromObject(group.Group); err != nil {
+		group.Group = NewPdfTransparencyGroup().ToPdfObject()
+	}
+	return &PdfSoftMask{S: s, G: group}
+}
+
+// NewPdfSoftMaskFromObject loads a soft mask from `obj`, a soft mask dictionary or the name None.
+func NewPdfSoftMaskFromObject(obj PdfObject) (*PdfSoftMask, error) {
+	switch t := TraceToDirectObject(obj).(type) {
+	case *PdfObjectName:
+		if *t != "None" {
+			return nil, fmt.Errorf("Invalid soft mask name (%s)", *t)
+		}
+		return &PdfSoftMask{S: SoftMaskNone}, nil
+	case *PdfObjectDictionary:
+		smask := &PdfSoftMask{dict: t}
+		s, isName := TraceToDirectObject(t.Get("S")).(*PdfObjectName)
+		if !isName || (*s != "Alpha" && *s != "Luminosity") {
+			return nil, fmt.Errorf("Invalid soft mask subtype (%s)", t.Get("S"))
+		}
+		smask.S = SoftMaskType(*s)
+
+		stream, isStream := TraceToDirectObject(t.Get("G")).(*PdfObjectStream)
+		if !isStream {
+			return nil, errors.New("Soft mask group not a stream")
+		}
+		group, err := NewXObjectFormFromStream(stream)
+		if err != nil {
+			return nil, err
+		}
+		smask.G = group
+
+		if bc, isArray := TraceToDirectObject(t.Get("BC")).(*PdfObjectArray); isArray {
+			smask.BC, err = getNumbersAsFloat(*bc)
+			if err != nil {
+				common.Log.Debug("Invalid soft mask backdrop color, skipping: %v", err)
+			}
+		}
+		if tr := t.Get("TR"); tr != nil {
+			if name, isName := TraceToDirectObject(tr).(*PdfObjectName); !isName || *name != "Identity" {
+				smask.TR, err = newPdfFunctionFromPdfObject(TraceToDirectObject(tr))
+				if err != nil {
+					common.Log.Debug("Invalid soft mask transfer function, skipping: %v", err)
+				}
+			}
+		}
+		smask.saved = smask.snapshot()
+		return smask, nil
+	}
+	return nil, fmt.Errorf("Invalid soft mask (%T)", obj)
+}
+
+// ToPdfObject returns the soft mask dictionary updated from the fields, or the name None.  Only the BC and TR
+// entries of changed fields are written or removed, so that entries which could not be loaded are kept.
+func (smask *PdfSoftMask) ToPdfObject() PdfObject {
+	if smask.S == SoftMaskNone {
+		return MakeName("None")
+	}
+	if smask.dict == nil {
+		smask.dict = MakeDict()
+	}
+	if smask.saved == nil {
+		smask.saved = &PdfSoftMask{}
+	}
+	d := smask.dict
+	saved := smask.saved
+	d.Set("Type", MakeName("Mask"))
+	d.Set("S", MakeName(string(smask.S)))
+	if smask.G != nil {
+		d.Set("G", smask.G.ToPdfObject())
+	}
+	switch {
+	case len(smask.BC) > 0 && !equalFloats(smask.BC, saved.BC):
+		d.Set("BC", MakeArrayFromFloats(smask.BC))
+	case len(smask.BC) == 0 && len(saved.BC) > 0:
+		d.Remove("BC")
+	}
+	switch {
+	case smask.TR != nil && smask.TR != saved.TR:
+		d.Set("TR", smask.TR.ToPdfObject())
+	case smask.TR == nil && saved.TR != nil:
+		d.Remove("TR")
+	}
+
+	smask.saved = smask.snapshot()
+	return d
+}
+
+// snapshot returns a copy of the values of the fields.
+func (smask *PdfSoftMask) snapshot() *PdfSoftMask {
+	return &PdfSoftMask{S: smask.S, G: smask.G, BC: append([]float64(nil), smask.BC...), TR: smask.TR}
+}
+
+// equalFloats checks whether `a` and `b` have the same values.
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if v != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// PdfTransparencyGroup represents the group attributes dictionary of a transparency group XObject (11.6.6).
+type PdfTransparencyGroup struct {
+	// CS is the group color space, nil if not set.
+	CS PdfColorspace
+	// I is the isolated flag and K the knockout flag.
+	I bool
+	K bool
+}
+
+// NewPdfTransparencyGroup returns new non-isolated, non-knockout transparency group attributes.
+func NewPdfTransparencyGroup() *PdfTransparencyGroup {
+	return &PdfTransparencyGroup{}
+}
+
+// NewPdfTransparencyGroupFromObject loads transparency group attributes from group attributes dictionary `obj`.
+func NewPdfTransparencyGroupFromObject(obj PdfObject) (*PdfTransparencyGroup, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid group attributes (%T)", obj)
+	}
+	if s, isName := TraceToDirectObject(dict.Get("S")).(*PdfObjectName); !isName || *s != "Transparency" {
+		return nil, errors.New("Not a transparency group")
+	}
+
+	group := &PdfTransparencyGroup{}
+	if obj := dict.Get("CS"); obj != nil {
+		cs, err := NewPdfColorspaceFromPdfObject(obj)
+		if err != nil {
+			common.Log.Debug("Invalid group color space, skipping: %v", err)
+		}
+		group.CS = cs
+	}
+	if i, isBool := TraceToDirectObject(dict.Get("I")).(*PdfObjectBool); isBool {
+		group.I = bool(*i)
+	}
+	if k, isBool := TraceToDirectObject(dict.Get("K")).(*PdfObjectBool); isBool {
+		group.K = bool(*k)
+	}
+	return group, nil
+}
+
+// ToPdfObject returns the group attributes dictionary.
+func (group *PdfTransparencyGroup) ToPdfObject() PdfObject {
+	d := MakeDict()
+	d.Set("Type", MakeName("Group"))
+	d.Set("S", MakeName("Transparency"))
+	if group.CS != nil {
+		d.Set("CS", group.CS.ToPdfObject())
+	}
+	if group.I {
+		d.Set("I", MakeBool(true))
+	}
+	if group.K {
+		d.Set("K", MakeBool(true))
+	}
+	return d
+}
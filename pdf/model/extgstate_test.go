@@ -0,0 +1,226 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+func TestExtGStateLoad(t *testing.T) {
+	rawText := `<< /Type /ExtGState /LW 2.5 /LC 1 /LJ 2 /ML 4 /D [[3 1] 2] /RI /Perceptual /OP true /op false
+		/OPM 1 /Font [5 0 R 12] /BM [/Multiply /Normal] /CA 0.5 /ca 0.25 /AIS false /TK true /SA true
+		/HT /Default >>`
+	parser := NewParserFromString(rawText)
+	dict, err := parser.ParseDict()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	gs, err := NewPdfExtGStateFromObject(dict)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if gs.LW == nil || *gs.LW != 2.5 || gs.LC == nil || *gs.LC != 1 || gs.LJ == nil || *gs.LJ != 2 ||
+		gs.ML == nil || *gs.ML != 4 {
+		t.Errorf("Invalid line parameters: %v %v %v %v", gs.LW, gs.LC, gs.LJ, gs.ML)
+	}
+	if gs.D == nil || len(gs.D.Array) != 2 || gs.D.Array[0] != 3 || gs.D.Phase != 2 {
+		t.Errorf("Invalid dash pattern: %+v", gs.D)
+	}
+	if gs.RI != "Perceptual" || gs.BM != "Multiply" {
+		t.Errorf("Invalid names: %q %q", gs.RI, gs.BM)
+	}
+	if gs.OP == nil || !*gs.OP || gs.Op == nil || *gs.Op || gs.OPM == nil || *gs.OPM != 1 {
+		t.Errorf("Invalid overprint parameters: %v %v %v", gs.OP, gs.Op, gs.OPM)
+	}
+	if gs.Font == nil || gs.Font.Size != 12 {
+		t.Errorf("Invalid font: %+v", gs.Font)
+	}
+	if gs.CA == nil || *gs.CA != 0.5 || gs.Ca == nil || *gs.Ca != 0.25 {
+		t.Errorf("Invalid alpha: %v %v", gs.CA, gs.Ca)
+	}
+	if gs.AIS == nil || *gs.AIS || gs.TK == nil || !*gs.TK || gs.SA == nil || !*gs.SA {
+		t.Errorf("Invalid flags: %v %v %v", gs.AIS, gs.TK, gs.SA)
+	}
+	if gs.SMask != nil {
+		t.Errorf("Unexpected soft mask: %+v", gs.SMask)
+	}
+
+	// Unchanged fields keep their entries as they are.
+	d := gs.ToPdfObject().(*PdfObjectDictionary)
+	if _, ok := d.Get("BM").(*PdfObjectArray); !ok {
+		t.Errorf("BM array not kept: %v", d.Get("BM"))
+	}
+	if _, ok := d.Get("LC").(*PdfObjectInteger); !ok {
+		t.Errorf("Invalid LC: %v", d.Get("LC"))
+	}
+
+	// Changed fields update the dictionary, other entries are kept.
+	gs.LW = nil
+	gs.BM = "Screen"
+	gs.SMask = &PdfSoftMask{S: SoftMaskNone}
+	d = gs.ToPdfObject().(*PdfObjectDictionary)
+	if d.Get("LW") != nil {
+		t.Errorf("LW not removed")
+	}
+	if bm, ok := d.Get("BM").(*PdfObjectName); !ok || *bm != "Screen" {
+		t.Errorf("Invalid BM: %v", d.Get("BM"))
+	}
+	if smask, ok := d.Get("SMask").(*PdfObjectName); !ok || *smask != "None" {
+		t.Errorf("Invalid SMask: %v", d.Get("SMask"))
+	}
+	if d.Get("HT") == nil {
+		t.Errorf("HT removed")
+	}
+}
+
+func TestExtGStateInvalidEntries(t *testing.T) {
+	rawText := `<< /Type /ExtGState /LW /Thick /D [3 1] /Font [12] /SMask /Unknown /CA 0.5 >>`
+	parser := NewParserFromString(rawText)
+	dict, err := parser.ParseDict()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	gs, err := NewPdfExtGStateFromObject(dict)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if gs.LW != nil || gs.D != nil || gs.Font != nil || gs.SMask != nil {
+		t.Errorf("Invalid entries loaded: %v %+v %+v %+v", gs.LW, gs.D, gs.Font, gs.SMask)
+	}
+
+	// Entries which could not be loaded are kept.
+	gs.SetOpacity(0.25)
+	d := gs.ToPdfObject().(*PdfObjectDictionary)
+	for _, key := range []PdfObjectName{"LW", "D", "Font", "SMask"} {
+		if d.Get(key) == nil {
+			t.Errorf("%s removed", key)
+		}
+	}
+	if ca, err := getNumberAsFloat(d.Get("ca")); err != nil || ca != 0.25 {
+		t.Errorf("Invalid ca: %v", d.Get("ca"))
+	}
+
+	// Entries written are removed when their fields are cleared.
+	gs.Ca = nil
+	gs.ToPdfObject()
+	if d.Get("ca") != nil {
+		t.Errorf("ca not removed")
+	}
+}
+
+func TestExtGStateSoftMask(t *testing.T) {
+	group := NewXObjectForm()
+	group.BBox = MakeArrayFromFloats([]float64{0, 0, 100, 100})
+	group.SetContentStream([]byte("0.5 g 0 0 100 100 re f"), nil)
+	transparency := NewPdfTransparencyGroup()
+	transparency.CS = NewPdfColorspaceDeviceGray()
+	transparency.I = true
+	group.Group = transparency.ToPdfObject()
+
+	smask := NewPdfSoftMask(SoftMaskLuminosity, group)
+	smask.BC = []float64{1}
+	smask.TR = &PdfFunctionType2{Domain: []float64{0, 1}, C0: []float64{1}, C1: []float64{0}, N: 1}
+
+	gs := NewPdfExtGState()
+	gs.SMask = smask
+	gs.SetOpacity(0.8)
+
+	res := NewPdfPageResources()
+	name, err := res.AddNewExtGState("GS", gs)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if name != "GS0" {
+		t.Errorf("Name %s, expected GS0", name)
+	}
+
+	loaded, found := res.GetExtGStateByName(name)
+	if !found {
+		t.Fatalf("Graphics state %s not found", name)
+	}
+	if loaded.CA == nil || *loaded.CA != 0.8 || loaded.Ca == nil || *loaded.Ca != 0.8 {
+		t.Errorf("Invalid alpha: %v %v", loaded.CA, loaded.Ca)
+	}
+	if loaded.SMask == nil || loaded.SMask.S != SoftMaskLuminosity || loaded.SMask.G == nil {
+		t.Fatalf("Invalid soft mask: %+v", loaded.SMask)
+	}
+	if len(loaded.SMask.BC) != 1 || loaded.SMask.BC[0] != 1 {
+		t.Errorf("Invalid backdrop color: %v", loaded.SMask.BC)
+	}
+	if loaded.SMask.TR == nil {
+		t.Fatalf("Transfer function not loaded")
+	}
+	if out, err := loaded.SMask.TR.Evaluate([]float64{0.25}); err != nil || len(out) != 1 || out[0] != 0.75 {
+		t.Errorf("Invalid transfer function output: %v %v", out, err)
+	}
+
+	loadedGroup, err := NewPdfTransparencyGroupFromObject(loaded.SMask.G.Group)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !loadedGroup.I || loadedGroup.K || loadedGroup.CS == nil || loadedGroup.CS.GetNumComponents() != 1 {
+		t.Errorf("Invalid transparency group: %+v", loadedGroup)
+	}
+
+	// Soft mask entries which could not be loaded are kept when the graphics state is written.
+	d := MakeDict()
+	d.Set("S", MakeName("Luminosity"))
+	d.Set("G", group.ToPdfObject())
+	d.Set("BC", MakeArray(MakeName("Black")))
+	d.Set("TR", MakeName("Unknown"))
+	gsDict := MakeDict()
+	gsDict.Set("SMask", d)
+	gs, err = NewPdfExtGStateFromObject(gsDict)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if gs.SMask == nil || gs.SMask.BC != nil || gs.SMask.TR != nil {
+		t.Fatalf("Invalid soft mask: %+v", gs.SMask)
+	}
+	gs.SetOpacity(0.5)
+	gs.ToPdfObject()
+	if d.Get("BC") == nil || d.Get("TR") == nil {
+		t.Errorf("Soft mask entries removed: %s", d.DefaultWriteString())
+	}
+
+	// Groups without transparency group attributes are made transparency groups.
+	alpha := NewPdfSoftMask(SoftMaskAlpha, NewXObjectForm())
+	if _, err := NewPdfTransparencyGroupFromObject(alpha.G.Group); err != nil {
+		t.Errorf("Error: %v", err)
+	}
+}
+
+func TestExtGStateNames(t *testing.T) {
+	res := NewPdfPageResources()
+	res.AddExtGState("GS0", MakeDict())
+	res.AddExtGState("GS1", MakeDict())
+	res.SetFontByName("GS2", MakeDict())
+
+	if !res.HasExtGState("GS1") || res.HasExtGState("GS2") {
+		t.Errorf("Invalid ExtGState lookup")
+	}
+	if name := res.GenerateExtGStateName("GS"); name != "GS2" {
+		t.Errorf("Name %s, expected GS2", name)
+	}
+
+	xobj, err := MakeStream(nil, nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	res.SetXObjectByName("Im0", xobj)
+	if name := res.GenerateXObjectName("Im"); name != "Im1" {
+		t.Errorf("Name %s, expected Im1", name)
+	}
+	if name := res.GenerateFontName("GS"); name != "GS0" {
+		t.Errorf("Name %s, expected GS0", name)
+	}
+	if name := res.GenerateFontName("GS2"); name != "GS20" {
+		t.Errorf("Name %s, expected GS20", name)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// AFRelationship values: the relationship of an associated file to the PDF component that refers to it
+// (Table 43 in ISO 32000-2).
+const (
+	AFRelationshipSource           = "Source"
+	AFRelationshipData             = "Data"
+	AFRelationshipAlternative      = "Alternative"
+	AFRelationshipSupplement       = "Supplement"
+	AFRelationshipEncryptedPayload = "EncryptedPayload"
+	AFRelationshipFormData         = "FormData"
+	AFRelationshipSchema           = "Schema"
+	AFRelationshipUnspecified      = "Unspecified"
+)
+
+// PdfFileSpec represents a file specification dictionary (7.11.3) with its embedded file stream (7.11.4), as
+// used for document attachments (EmbeddedFiles), associated files (AF) and file attachment annotations.
+type PdfFileSpec struct {
+	// FileName is the name of the file (F and UF).
+	FileName string
+	// Description is the description of the file (Desc).
+	Description string
+	// AFRelationship is the relationship of an associated file to the referring component, e.g.
+	// AFRelationshipData.  Empty if unspecified.
+	AFRelationship string
+
+	// Subtype is the MIME type of the embedded file, e.g. "text/xml".
+	Subtype string
+	// Size is the size of the uncompressed file in bytes, or -1 if unknown.
+	Size int64
+	// CheckSum is the MD5 checksum of the uncompressed file, nil if unknown.
+	CheckSum     []byte
+	CreationDate *PdfDate
+	ModDate      *PdfDate
+
+	stream    *PdfObjectStream
+	container *PdfIndirectObject
+}
+
+// NewPdfFileSpec returns a file specification embedding `data` as file `fileName` of MIME type `mimeType`.
+// The size and checksum are computed from the data.
+func NewPdfFileSpec(fileName string, data []byte, mimeType string) (*PdfFileSpec, error) {
+	stream, err := MakeStream(data, NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	checksum := md5.Sum(data)
+	return &PdfFileSpec{
+		FileName:  fileName,
+		Subtype:   mimeType,
+		Size:      int64(len(data)),
+		CheckSum:  checksum[:],
+		stream:    stream,
+		container: MakeIndirectObject(MakeDict()),
+	}, nil
+}
+
+// newPdfFileSpecFromObject loads a file specification from `obj`: a file specification dictionary or a file
+// specification string.
+func newPdfFileSpecFromObject(obj PdfObject) (*PdfFileSpec, error) {
+	spec := &PdfFileSpec{Size: -1}
+
+	var dict *PdfObjectDictionary
+	switch t := TraceToDirectObject(obj).(type) {
+	case *PdfObjectString:
+		// File specification string.  The dictionary replaces it only where the spec is written back, the
+		// loaded object is left unchanged.
+		spec.FileName = string(*t)
+		spec.container = MakeIndirectObject(MakeDict())
+		return spec, nil
+	case *PdfObjectDictionary:
+		dict = t
+	default:
+		return nil, fmt.Errorf("Invalid file specification (%T)", obj)
+	}
+
+	if container, isIndirect := obj.(*PdfIndirectObject); isIndirect {
+		spec.container = container
+	} else {
+		spec.container = MakeIndirectObject(obj)
+	}
+
+	// The UF entry (text string) takes precedence over F.
+	for _, key := range []PdfObjectName{"UF", "F", "Unix", "DOS", "Mac"} {
+		if str, ok := TraceToDirectObject(dict.Get(key)).(*PdfObjectString); ok {
+			spec.FileName = string(*str)
+			break
+		}
+	}
+	if str, ok := TraceToDirectObject(dict.Get("Desc")).(*PdfObjectString); ok {
+		spec.Description = string(*str)
+	}
+	if name, ok := TraceToDirectObject(dict.Get("AFRelationship")).(*PdfObjectName); ok {
+		spec.AFRelationship = string(*name)
+	}
+
+	ef, ok := TraceToDirectObject(dict.Get("EF")).(*PdfObjectDictionary)
+	if !ok {
+		// Not embedded.
+		return spec, nil
+	}
+	for _, key := range []PdfObjectName{"UF", "F"} {
+		if stream, ok := TraceToDirectObject(ef.Get(key)).(*PdfObjectStream); ok {
+			spec.stream = stream
+			break
+		}
+	}
+	if spec.stream == nil {
+		common.Log.Debug("ERROR: Missing embedded file stream")
+		return spec, nil
+	}
+
+	if name, ok := TraceToDirectObject(spec.stream.Get("Subtype")).(*PdfObjectName); ok {
+		spec.Subtype = string(*name)
+	}
+	params, ok := TraceToDirectObject(spec.stream.Get("Params")).(*PdfObjectDictionary)
+	if !ok {
+		return spec, nil
+	}
+	if size, ok := TraceToDirectObject(params.Get("Size")).(*PdfObjectInteger); ok {
+		spec.Size = int64(*size)
+	}
+	if checksum, ok := TraceToDirectObject(params.Get("CheckSum")).(*PdfObjectString); ok {
+		spec.CheckSum = []byte(*checksum)
+	}
+	for key, date := range map[PdfObjectName]**PdfDate{"CreationDate": &spec.CreationDate, "ModDate": &spec.ModDate} {
+		str, ok := TraceToDirectObject(params.Get(key)).(*PdfObjectString)
+		if !ok {
+			continue
+		}
+		d, err := NewPdfDate(string(*str))
+		if err != nil {
+			common.Log.Debug("Invalid %s: %v", key, err)
+			continue
+		}
+		*date = &d
+	}
+	return spec, nil
+}
+
+// IsEmbedded returns true if the file is embedded in the document.
+func (spec *PdfFileSpec) IsEmbedded() bool {
+	return spec.stream != nil
+}
+
+// GetData returns the decoded contents of the embedded file.
+func (spec *PdfFileSpec) GetData() ([]byte, error) {
+	if spec.stream == nil {
+		return nil, errors.New("File not embedded")
+	}
+	return DecodeStream(spec.stream)
+}
+
+// VerifyCheckSum returns false if the embedded file does not match its checksum.  Files without a checksum
+// are considered valid.
+func (spec *PdfFileSpec) VerifyCheckSum() (bool, error) {
+	if spec.CheckSum == nil {
+		return true, nil
+	}
+	data, err := spec.GetData()
+	if err != nil {
+		return false, err
+	}
+	checksum := md5.Sum(data)
+	return string(checksum[:]) == string(spec.CheckSum), nil
+}
+
+// GetContainingPdfObject returns the indirect object containing the file specification dictionary.
+func (spec *PdfFileSpec) GetContainingPdfObject() PdfObject {
+	return spec.container
+}
+
+// ToPdfObject returns the file specification dictionary (in an indirect object) with the embedded file
+// stream updated from the fields.
+func (spec *PdfFileSpec) ToPdfObject() PdfObject {
+	dict, ok := spec.container.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		dict = MakeDict()
+		spec.container.PdfObject = dict
+	}
+
+	dict.Set("Type", MakeName("Filespec"))
+	dict.Set("F", MakeString(spec.FileName))
+	dict.Set("UF", MakeString(spec.FileName))
+	if spec.Description != "" {
+		dict.Set("Desc", MakeString(spec.Description))
+	} else {
+		dict.Remove("Desc")
+	}
+	if spec.AFRelationship != "" {
+		dict.Set("AFRelationship", MakeName(spec.AFRelationship))
+	} else {
+		dict.Remove("AFRelationship")
+	}
+
+	if spec.stream == nil {
+		return spec.container
+	}
+
+	stream := spec.stream
+	stream.Set("Type", MakeName("EmbeddedFile"))
+	if spec.Subtype != "" {
+		stream.Set("Subtype", MakeName(spec.Subtype))
+	} else {
+		stream.Remove("Subtype")
+	}
+	params, ok := TraceToDirectObject(stream.Get("Params")).(*PdfObjectDictionary)
+	if !ok {
+		params = MakeDict()
+		stream.Set("Params", params)
+	}
+	if spec.Size >= 0 {
+		params.Set("Size", MakeInteger(spec.Size))
+	}
+	if spec.CheckSum != nil {
+		params.Set("CheckSum", MakeString(string(spec.CheckSum)))
+	}
+	if spec.CreationDate != nil {
+		params.Set("CreationDate", spec.CreationDate.ToPdfObject())
+	}
+	if spec.ModDate != nil {
+		params.Set("ModDate", spec.ModDate.ToPdfObject())
+	}
+
+	ef := MakeDict()
+	ef.Set("F", stream)
+	ef.Set("UF", stream)
+	dict.Set("EF", ef)
+	return spec.container
+}
+
+// GetFileSpec returns the file specification of the attached file.
+func (this *PdfAnnotationFileAttachment) GetFileSpec() (*PdfFileSpec, error) {
+	if this.FS == nil {
+		return nil, errors.New("Missing file specification")
+	}
+	return newPdfFileSpecFromObject(this.FS)
+}
+
+// SetFileSpec sets the file specification of the attached file.
+func (this *PdfAnnotationFileAttachment) SetFileSpec(spec *PdfFileSpec) {
+	this.FS = spec.ToPdfObject()
+}
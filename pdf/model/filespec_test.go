@@ -0,0 +1,148 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+func TestEmbeddedFiles(t *testing.T) {
+	invoice := []byte(`<?xml version="1.0" encoding="UTF-8"?><rsm:CrossIndustryInvoice/>`)
+	xml, err := NewPdfFileSpec("factur-x.xml", invoice, "text/xml")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	xml.Description = "Factur-X invoice"
+	xml.AFRelationship = AFRelationshipData
+	modDate := NewPdfDateFromTime(time.Date(2018, 5, 1, 12, 30, 0, 0, time.UTC))
+	xml.ModDate = &modDate
+
+	notes, err := NewPdfFileSpec("notes.txt", []byte("Some notes"), "text/plain")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	writer := NewPdfWriter()
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = NewPdfPageResources()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.AddEmbeddedFile("factur-x.xml", xml); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.AddEmbeddedFile("notes", notes); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.AddAssociatedFile(xml)
+
+	f, err := os.Create("/tmp/attachments.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/attachments.pdf")
+	files, err := reader.GetEmbeddedFiles()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Invalid number of files: %d", len(files))
+	}
+
+	spec := files["factur-x.xml"]
+	if spec == nil || !spec.IsEmbedded() {
+		t.Fatalf("Missing embedded file")
+	}
+	if spec.FileName != "factur-x.xml" || spec.Description != "Factur-X invoice" ||
+		spec.AFRelationship != AFRelationshipData || spec.Subtype != "text/xml" {
+		t.Errorf("Invalid file specification: %+v", spec)
+	}
+	if spec.Size != int64(len(invoice)) {
+		t.Errorf("Invalid size %d", spec.Size)
+	}
+	if spec.ModDate == nil || !spec.ModDate.ToGoTime().Equal(modDate.ToGoTime()) || spec.CreationDate != nil {
+		t.Errorf("Invalid dates: %v %v", spec.CreationDate, spec.ModDate)
+	}
+	data, err := spec.GetData()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if string(data) != string(invoice) {
+		t.Errorf("Invalid data: %s", data)
+	}
+	if valid, err := spec.VerifyCheckSum(); err != nil || !valid {
+		t.Errorf("Invalid checksum: %v", err)
+	}
+
+	if data, err := files["notes"].GetData(); err != nil || string(data) != "Some notes" {
+		t.Errorf("Invalid notes: %s (%v)", data, err)
+	}
+
+	associated, err := reader.GetAssociatedFiles()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(associated) != 1 || associated[0].GetContainingPdfObject() != spec.GetContainingPdfObject() {
+		t.Fatalf("Invalid associated files: %v", associated)
+	}
+
+	// Documents without attachments.
+	writer = NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err = os.Create("/tmp/no_attachments.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+	reader = readTestFile(t, "/tmp/no_attachments.pdf")
+	if files, err := reader.GetEmbeddedFiles(); err != nil || len(files) != 0 {
+		t.Errorf("No files expected: %v %v", files, err)
+	}
+	if files, err := reader.GetAssociatedFiles(); err != nil || len(files) != 0 {
+		t.Errorf("No files expected: %v %v", files, err)
+	}
+}
+
+func TestFileSpecFromString(t *testing.T) {
+	spec, err := newPdfFileSpecFromObject(MakeString("external.pdf"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if spec.FileName != "external.pdf" || spec.IsEmbedded() {
+		t.Errorf("Invalid file specification: %+v", spec)
+	}
+	if _, err := spec.GetData(); err == nil {
+		t.Errorf("File not embedded: should fail")
+	}
+
+	// Loading leaves an indirect file specification string unchanged.
+	obj := MakeIndirectObject(MakeString("external.pdf"))
+	spec, err = newPdfFileSpecFromObject(obj)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, isString := obj.PdfObject.(*PdfObjectString); !isString {
+		t.Errorf("Loaded object changed to %T", obj.PdfObject)
+	}
+	if spec.ToPdfObject() == obj {
+		t.Errorf("File specification dictionary written to the loaded object")
+	}
+}
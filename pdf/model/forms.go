@@ -362,24 +362,24 @@ func (this *PdfField) ToPdfObject() PdfObject {
 		dict.Set("Parent", this.Parent.GetContainingPdfObject())
 	}
 
-	if this.KidsF != nil {
+	if this.KidsF != nil || this.KidsA != nil {
 		// Create an array of the kids (fields or widgets).
 		common.Log.Trace("KidsF: %+v", this.KidsF)
+		common.Log.Trace("KidsA: %+v", this.KidsA)
 		arr := PdfObjectArray{}
 		for _, child := range this.KidsF {
 			arr = append(arr, child.ToPdfObject())
 		}
-		dict.Set("Kids", &arr)
-	}
-	if this.KidsA != nil {
-		common.Log.Trace("KidsA: %+v", this.KidsA)
-		_, hasKids := dict.Get("Kids").(*PdfObjectArray)
-		if !hasKids {
-			dict.Set("Kids", &PdfObjectArray{})
-		}
-		arr := dict.Get("Kids").(*PdfObjectArray)
 		for _, child := range this.KidsA {
-			*arr = append(*arr, child.GetContext().ToPdfObject())
+			obj := child.GetContext().ToPdfObject()
+			if obj == container {
+				// Widget merged into the field dictionary.
+				continue
+			}
+			arr = append(arr, obj)
+		}
+		if len(arr) > 0 {
+			dict.Set("Kids", &arr)
 		}
 	}
 
@@ -417,3 +417,18 @@ func (this *PdfField) ToPdfObject() PdfObject {
 
 	return container
 }
+
+// GetAdditionalAction returns the action of the field performed on trigger event `trigger`: K (keystroke),
+// F (format), V (validate) or C (calculate), or nil if it has none.
+func (this *PdfField) GetAdditionalAction(trigger PdfObjectName) (*PdfAction, error) {
+	return getAdditionalAction(this.AA, trigger)
+}
+
+// SetAdditionalAction sets the action of the field performed on trigger event `trigger` (see
+// GetAdditionalAction), removing it if `action` is nil.
+func (this *PdfField) SetAdditionalAction(trigger PdfObjectName, action *PdfAction) {
+	this.AA = setAdditionalAction(this.AA, trigger, action)
+	if this.AA == nil {
+		this.primitive.PdfObject.(*PdfObjectDictionary).Remove("AA")
+	}
+}
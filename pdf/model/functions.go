@@ -26,6 +26,12 @@ type PdfFunction interface {
 // - Stream: Type 0, Type 4
 // - Dictionary: Type 2, Type 3.
 
+// NewPdfFunctionFromPdfObject loads the function of `obj`, a function dictionary or stream, possibly in an
+// indirect object.
+func NewPdfFunctionFromPdfObject(obj PdfObject) (PdfFunction, error) {
+	return newPdfFunctionFromPdfObject(obj)
+}
+
 // Loads a PDF Function from a PdfObject (can be either stream or dictionary).
 func newPdfFunctionFromPdfObject(obj PdfObject) (PdfFunction, error) {
 	if stream, is := obj.(*PdfObjectStream); is {
@@ -566,11 +572,38 @@ func (this *PdfFunctionType3) Evaluate(x []float64) ([]float64, error) {
 		return nil, errors.New("Range check")
 	}
 
-	// Determine which function to use
+	if len(this.Domain) < 2 || len(this.Functions) == 0 || len(this.Bounds) != len(this.Functions)-1 ||
+		len(this.Encode) < 2*len(this.Functions) {
+		common.Log.Debug("Invalid stitching function")
+		return nil, errors.New("Range check")
+	}
+
+	// Clip to the domain.
+	v := math.Min(math.Max(x[0], this.Domain[0]), this.Domain[1])
 
-	// Encode
+	// Determine which function to use: function i applies in the interval Bounds[i-1] <= x < Bounds[i].
+	k := len(this.Functions)
+	i := 0
+	for i < k-1 && v >= this.Bounds[i] {
+		i++
+	}
+	low := this.Domain[0]
+	if i > 0 {
+		low = this.Bounds[i-1]
+	}
+	high := this.Domain[1]
+	if i < k-1 {
+		high = this.Bounds[i]
+	}
+
+	// Encode the subdomain to the domain of the function.
+	e0, e1 := this.Encode[2*i], this.Encode[2*i+1]
+	encoded := e0
+	if high > low {
+		encoded = e0 + (v-low)*(e1-e0)/(high-low)
+	}
 
-	return nil, errors.New("Not implemented yet")
+	return this.Functions[i].Evaluate([]float64{encoded})
 }
 
 func newPdfFunctionType3FromPdfObject(obj PdfObject) (*PdfFunctionType3, error) {
@@ -101,3 +101,35 @@ endobj
 
 	fmt.Printf("%s", stream.Stream)
 }
+
+func TestType3Function(t *testing.T) {
+	// Stitches a gray ramp 0 -> 1 over [0, 0.5] and a reversed ramp over [0.5, 1].
+	ramp := &PdfFunctionType2{Domain: []float64{0, 1}, C0: []float64{0}, C1: []float64{1}, N: 1}
+	fun := &PdfFunctionType3{
+		Domain:    []float64{0, 1},
+		Functions: []PdfFunction{ramp, ramp},
+		Bounds:    []float64{0.5},
+		Encode:    []float64{0, 1, 1, 0},
+	}
+
+	testcases := []struct {
+		Input    float64
+		Expected float64
+	}{
+		{0, 0},
+		{0.25, 0.5},
+		{0.5, 1},
+		{0.75, 0.5},
+		{1, 0},
+		{2, 0},
+	}
+	for _, tcase := range testcases {
+		outputs, err := fun.Evaluate([]float64{tcase.Input})
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if len(outputs) != 1 || math.Abs(outputs[0]-tcase.Expected) > 0.000001 {
+			t.Errorf("Evaluate(%v) = %v, expected %v", tcase.Input, outputs, tcase.Expected)
+		}
+	}
+}
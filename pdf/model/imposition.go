@@ -0,0 +1,323 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Crop mark dimensions (in points).
+const (
+	cropMarkLength = 12
+	cropMarkOffset = 3
+)
+
+// ToXObjectForm returns a form XObject with the contents and resources of the page.  The form bounding box
+// is the visible region of the page (crop box, or media box if not set), transformed by the form matrix so
+// that the page appears upright as displayed (with its rotation applied) with the lower left corner at the
+// origin.  Returns the form and the width and height of the displayed page.
+// Annotations are not part of the page contents and are not carried over.
+func (this *PdfPage) ToXObjectForm() (*XObjectForm, float64, float64, error) {
+	box, err := this.GetCropBox()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	matrix, err := this.GetDisplayMatrix()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	content, err := this.GetAllContentStreams()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	xform := NewXObjectForm()
+	xform.Filter = NewFlateEncoder()
+	if err := xform.SetContentStream([]byte(content), nil); err != nil {
+		return nil, 0, 0, err
+	}
+	xform.FormType = MakeInteger(1)
+	xform.BBox = box.ToPdfObject()
+	xform.Matrix = MakeArrayFromFloats(matrix)
+	xform.Resources = this.Resources
+	xform.Group = this.Group
+	xform.ToPdfObject() // update.
+
+	rect := transformRectangle(box, matrix)
+	return xform, rect.Width(), rect.Height(), nil
+}
+
+// ImpositionLayout specifies how pages are placed on sheets: in a grid of Columns x Rows cells of equal size
+// on a sheet of SheetWidth x SheetHeight points, filled left to right, top to bottom.
+type ImpositionLayout struct {
+	SheetWidth  float64
+	SheetHeight float64
+	Columns     int
+	Rows        int
+
+	// Margin around the grid.
+	Margin float64
+	// Horizontal and vertical space between the cells.
+	GutterX float64
+	GutterY float64
+
+	// Scale of the pages.  Pages are scaled to fit their cell if 0.  Pages are centered in their cell.
+	Scale float64
+
+	// Booklet places the pages in saddle-stitch booklet order: each sheet holds four pages, two on the front
+	// and two on the back side, such that the folded stacks of sheets (signatures) read in page order.  Requires a grid of
+	// 2 cells; the output has a page for the front and one for the back of each sheet.  The number of pages
+	// is padded with blank pages to a multiple of 4.
+	Booklet bool
+	// SheetsPerSignature is the number of sheets of each signature (folded stack of sheets) of a booklet,
+	// which are bound one after the other.  The last signature has fewer sheets if the pages do not fill it.
+	// All sheets form a single signature if 0.
+	SheetsPerSignature int
+	// Creep is the shift of the pages towards the spine per sheet from the outermost sheet of a signature,
+	// compensating for the thickness of the paper when folded.
+	Creep float64
+
+	// CropMarks adds marks at the corners of the cells for trimming.
+	CropMarks bool
+}
+
+// BookletOrder returns the page order for a saddle-stitch booklet of `numPages` pages in signatures of
+// `sheetsPerSignature` sheets (a single signature if 0): the pages (starting from 0) of the left and right
+// cells of each sheet side, front side first.  Blank pages are -1.
+func BookletOrder(numPages, sheetsPerSignature int) []int {
+	n := (numPages + 3) / 4 * 4
+	page := func(i int) int {
+		if i >= numPages {
+			return -1
+		}
+		return i
+	}
+	perSignature := n
+	if sheetsPerSignature > 0 {
+		perSignature = 4 * sheetsPerSignature
+	}
+
+	var order []int
+	for first := 0; first < n; first += perSignature {
+		// Pages first to last of the signature.
+		last := first + perSignature - 1
+		if last >= n {
+			last = n - 1
+		}
+		for s := 0; s < (last-first+1)/4; s++ {
+			order = append(order, page(last-2*s), page(first+2*s))
+			order = append(order, page(first+2*s+1), page(last-1-2*s))
+		}
+	}
+	return order
+}
+
+// ImposePages places `pages` on new sheets according to `layout`.  Returns the sheets as new pages.
+// The pages are placed as form XObjects, so that their contents and resources are shared, not copied, when
+// a page is placed more than once.
+func ImposePages(pages []*PdfPage, layout ImpositionLayout) ([]*PdfPage, error) {
+	if layout.SheetWidth <= 0 || layout.SheetHeight <= 0 {
+		return nil, errors.New("Invalid sheet size")
+	}
+	if layout.Columns < 1 || layout.Rows < 1 {
+		return nil, errors.New("Invalid grid")
+	}
+	cells := layout.Columns * layout.Rows
+	if layout.Booklet && cells != 2 {
+		return nil, errors.New("Booklet requires a grid of 2 cells")
+	}
+
+	cellWidth := (layout.SheetWidth - 2*layout.Margin - float64(layout.Columns-1)*layout.GutterX) / float64(layout.Columns)
+	cellHeight := (layout.SheetHeight - 2*layout.Margin - float64(layout.Rows-1)*layout.GutterY) / float64(layout.Rows)
+	if cellWidth <= 0 || cellHeight <= 0 {
+		return nil, errors.New("Margins and gutters exceed the sheet size")
+	}
+
+	order := make([]int, len(pages))
+	for i := range order {
+		order[i] = i
+	}
+	if layout.Booklet {
+		order = BookletOrder(len(pages), layout.SheetsPerSignature)
+	}
+
+	// Forms of the placed pages, shared by repeated placements.
+	type placed struct {
+		xform         *XObjectForm
+		width, height float64
+	}
+	forms := map[*PdfPage]*placed{}
+
+	var sheets []*PdfPage
+	for first := 0; first < len(order); first += cells {
+		sheet := NewPdfPage()
+		sheet.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: layout.SheetWidth, Ury: layout.SheetHeight}
+		sheet.Resources = NewPdfPageResources()
+
+		var content bytes.Buffer
+		for cell := 0; cell < cells && first+cell < len(order); cell++ {
+			col, row := cell%layout.Columns, cell/layout.Columns
+			x := layout.Margin + float64(col)*(cellWidth+layout.GutterX)
+			y := layout.SheetHeight - layout.Margin - float64(row+1)*cellHeight - float64(row)*layout.GutterY
+
+			if layout.CropMarks {
+				writeCropMarks(&content, x, y, cellWidth, cellHeight)
+			}
+
+			idx := order[first+cell]
+			if idx < 0 {
+				continue
+			}
+			page := pages[idx]
+			p, has := forms[page]
+			if !has {
+				xform, width, height, err := page.ToXObjectForm()
+				if err != nil {
+					return nil, err
+				}
+				p = &placed{xform, width, height}
+				forms[page] = p
+			}
+
+			scale := layout.Scale
+			if scale <= 0 {
+				scale = math.Min(cellWidth/p.width, cellHeight/p.height)
+			}
+			dx := x + (cellWidth-scale*p.width)/2
+			dy := y + (cellHeight-scale*p.height)/2
+			if layout.Booklet {
+				// Shift towards the spine between the two cells: horizontal for side by side cells,
+				// vertical for cells above each other.
+				// Creep restarts at the outermost sheet of each signature.
+				index := first / 4
+				if layout.SheetsPerSignature > 0 {
+					index %= layout.SheetsPerSignature
+				}
+				shift := layout.Creep * float64(index)
+				switch {
+				case layout.Columns == 2 && col == 0:
+					dx += shift
+				case layout.Columns == 2:
+					dx -= shift
+				case row == 0:
+					dy -= shift
+				default:
+					dy += shift
+				}
+			}
+
+			name := PdfObjectName(fmt.Sprintf("Pg%d", cell))
+			if err := sheet.Resources.SetXObjectFormByName(name, p.xform); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&content, "q\n%.4f %.4f %.4f %.4f re W n\n%.4f 0 0 %.4f %.4f %.4f cm\n/%s Do\nQ\n",
+				x, y, cellWidth, cellHeight, scale, scale, dx, dy, name)
+		}
+
+		sheet.AddContentStreamByString(content.String())
+		sheets = append(sheets, sheet)
+	}
+
+	return sheets, nil
+}
+
+// writeCropMarks writes crop marks at the corners of the rectangle at (`x`, `y`) of size `width` x `height`
+// to `content`.
+func writeCropMarks(content *bytes.Buffer, x, y, width, height float64) {
+	content.WriteString("q\n0.25 w 0 G\n")
+	for _, cx := range []float64{x, x + width} {
+		for _, cy := range []float64{y, y + height} {
+			// Marks point away from the rectangle.
+			sx, sy := -1.0, -1.0
+			if cx > x {
+				sx = 1
+			}
+			if cy > y {
+				sy = 1
+			}
+			fmt.Fprintf(content, "%.4f %.4f m %.4f %.4f l S\n",
+				cx+sx*cropMarkOffset, cy, cx+sx*(cropMarkOffset+cropMarkLength), cy)
+			fmt.Fprintf(content, "%.4f %.4f m %.4f %.4f l S\n",
+				cx, cy+sy*cropMarkOffset, cx, cy+sy*(cropMarkOffset+cropMarkLength))
+		}
+	}
+	content.WriteString("Q\n")
+}
+
+// TileLayout specifies how a page is split into tiles of TileWidth x TileHeight points, with neighbouring
+// tiles overlapping by Overlap points.
+type TileLayout struct {
+	TileWidth  float64
+	TileHeight float64
+	Overlap    float64
+
+	// Scale of the page before tiling (1 if 0).
+	Scale float64
+
+	// CropMarks adds marks at the corners of the overlap regions for aligning the tiles.
+	CropMarks bool
+}
+
+// TilePage splits `page` into tiles according to `layout`, e.g. for printing a large page on several
+// smaller sheets.  Returns the tiles as new pages, left to right, top to bottom.
+func TilePage(page *PdfPage, layout TileLayout) ([]*PdfPage, error) {
+	if layout.TileWidth <= 0 || layout.TileHeight <= 0 {
+		return nil, errors.New("Invalid tile size")
+	}
+	if layout.Overlap < 0 || layout.Overlap >= layout.TileWidth || layout.Overlap >= layout.TileHeight {
+		return nil, errors.New("Invalid tile overlap")
+	}
+	scale := layout.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	xform, width, height, err := page.ToXObjectForm()
+	if err != nil {
+		return nil, err
+	}
+	width *= scale
+	height *= scale
+
+	stepX := layout.TileWidth - layout.Overlap
+	stepY := layout.TileHeight - layout.Overlap
+	cols := int(math.Max(1, math.Ceil((width-layout.Overlap)/stepX-1e-9)))
+	rows := int(math.Max(1, math.Ceil((height-layout.Overlap)/stepY-1e-9)))
+
+	var tiles []*PdfPage
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			tile := NewPdfPage()
+			tile.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: layout.TileWidth, Ury: layout.TileHeight}
+			tile.Resources = NewPdfPageResources()
+			if err := tile.Resources.SetXObjectFormByName("Pg0", xform); err != nil {
+				return nil, err
+			}
+
+			// Offset of the page such that the tile shows the region starting at (col*stepX, row*stepY)
+			// from the top left corner of the page.
+			dx := -float64(col) * stepX
+			dy := layout.TileHeight - height + float64(row)*stepY
+
+			var content bytes.Buffer
+			fmt.Fprintf(&content, "q\n%.4f 0 0 %.4f %.4f %.4f cm\n/Pg0 Do\nQ\n", scale, scale, dx, dy)
+			if layout.CropMarks && layout.Overlap > 0 {
+				writeCropMarks(&content, layout.Overlap/2, layout.Overlap/2,
+					layout.TileWidth-layout.Overlap, layout.TileHeight-layout.Overlap)
+			}
+			tile.AddContentStreamByString(content.String())
+			tiles = append(tiles, tile)
+		}
+	}
+
+	return tiles, nil
+}
@@ -0,0 +1,220 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+func TestBookletOrder(t *testing.T) {
+	order := BookletOrder(8, 0)
+	expected := []int{7, 0, 1, 6, 5, 2, 3, 4}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("Invalid order: %v", order)
+	}
+
+	// Padded with blank pages.
+	order = BookletOrder(5, 0)
+	expected = []int{-1, 0, 1, -1, -1, 2, 3, 4}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("Invalid order: %v", order)
+	}
+
+	// Signatures of 2 sheets, the last one with a single sheet.
+	order = BookletOrder(11, 2)
+	expected = []int{7, 0, 1, 6, 5, 2, 3, 4, -1, 8, 9, 10}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("Invalid order: %v", order)
+	}
+}
+
+func TestPageToXObjectForm(t *testing.T) {
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 10, Lly: 20, Urx: 110, Ury: 220}
+	page.Resources = NewPdfPageResources()
+	rotate := int64(90)
+	page.Rotate = &rotate
+	page.AddContentStreamByString("0 0 m 10 10 l S")
+
+	xform, width, height, err := page.ToXObjectForm()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if width != 200 || height != 100 {
+		t.Errorf("Invalid size %v x %v", width, height)
+	}
+	matrix, err := xform.Matrix.(*PdfObjectArray).ToFloat64Array()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// The upper right corner of the unrotated page is the lower right corner when rotated.
+	x := matrix[0]*110 + matrix[2]*220 + matrix[4]
+	y := matrix[1]*110 + matrix[3]*220 + matrix[5]
+	if x != 200 || y != 0 {
+		t.Errorf("Invalid matrix: %v", matrix)
+	}
+	content, err := xform.GetContentStream()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if string(content) != "0 0 m 10 10 l S" {
+		t.Errorf("Invalid content: %s", content)
+	}
+}
+
+// writePages writes `pages` to `path` and returns a reader of the written file.
+func writePages(t *testing.T, pages []*PdfPage, path string) *PdfReader {
+	writer := NewPdfWriter()
+	for _, page := range pages {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return readTestFile(t, path)
+}
+
+func TestImposePages(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	editor, err := NewPdfEditor(readTestFile(t, "/tmp/editor_src.pdf"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	pages := editor.GetPages()
+
+	// 4-up.
+	sheets, err := ImposePages(pages, ImpositionLayout{
+		SheetWidth:  842,
+		SheetHeight: 595,
+		Columns:     2,
+		Rows:        2,
+		Margin:      20,
+		GutterX:     10,
+		GutterY:     10,
+		CropMarks:   true,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(sheets) != 1 {
+		t.Fatalf("Invalid number of sheets: %d", len(sheets))
+	}
+	out := writePages(t, sheets, "/tmp/imposed_4up.pdf")
+	sheet, err := out.GetPage(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, name := range []PdfObjectName{"Pg0", "Pg1", "Pg2"} {
+		if _, xtype := sheet.Resources.GetXObjectByName(name); xtype != XObjectTypeForm {
+			t.Errorf("Form %s missing", name)
+		}
+	}
+	if sheet.Resources.HasXObjectByName("Pg3") {
+		t.Errorf("Unexpected form for empty cell")
+	}
+
+	// Booklet: 3 pages padded to 4, one sheet with front and back.
+	sheets, err = ImposePages(pages, ImpositionLayout{
+		SheetWidth:  842,
+		SheetHeight: 595,
+		Columns:     2,
+		Rows:        1,
+		Booklet:     true,
+		Creep:       1,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(sheets) != 2 {
+		t.Fatalf("Invalid number of sheet sides: %d", len(sheets))
+	}
+	// Front: blank and page 1.
+	if sheets[0].Resources.HasXObjectByName("Pg0") || !sheets[0].Resources.HasXObjectByName("Pg1") {
+		t.Errorf("Invalid front side")
+	}
+	writePages(t, sheets, "/tmp/imposed_booklet.pdf")
+
+	// Creep shifts the pages of the second sheet towards the spine, which is horizontal in a 1x2 grid.
+	offsets := func(creep float64, sheetsPerSignature int) [][2]float64 {
+		sheets, err := ImposePages(append(pages, pages...), ImpositionLayout{
+			SheetWidth:         595,
+			SheetHeight:        842,
+			Columns:            1,
+			Rows:               2,
+			Booklet:            true,
+			Creep:              creep,
+			SheetsPerSignature: sheetsPerSignature,
+		})
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		content, err := sheets[2].GetAllContentStreams()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		var offsets [][2]float64
+		for _, line := range strings.Split(content, "\n") {
+			var a, b, c, d, e, f float64
+			if n, _ := fmt.Sscanf(line, "%f %f %f %f %f %f cm", &a, &b, &c, &d, &e, &f); n == 6 {
+				offsets = append(offsets, [2]float64{e, f})
+			}
+		}
+		return offsets
+	}
+	plain, shifted := offsets(0, 0), offsets(10, 0)
+	if len(plain) != 2 || len(shifted) != 2 {
+		t.Fatalf("Invalid placements: %v %v", plain, shifted)
+	}
+	want := [][2]float64{{plain[0][0], plain[0][1] - 10}, {plain[1][0], plain[1][1] + 10}}
+	for i := range want {
+		if math.Abs(shifted[i][0]-want[i][0]) > 1e-3 || math.Abs(shifted[i][1]-want[i][1]) > 1e-3 {
+			t.Errorf("Invalid creep: %v, expected %v", shifted, want)
+		}
+	}
+
+	// In signatures of one sheet, the second sheet is the outermost sheet of its signature and is not shifted.
+	if separate := offsets(10, 1); !reflect.DeepEqual(separate, offsets(0, 1)) {
+		t.Errorf("Invalid creep of signatures: %v", separate)
+	}
+
+	if _, err := ImposePages(pages, ImpositionLayout{SheetWidth: 842, SheetHeight: 595, Columns: 2, Rows: 2, Booklet: true}); err == nil {
+		t.Errorf("Booklet with 4 cells should fail")
+	}
+}
+
+func TestTilePage(t *testing.T) {
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 300, Ury: 200}
+	page.Resources = NewPdfPageResources()
+	page.AddContentStreamByString("0 0 300 200 re S")
+
+	tiles, err := TilePage(page, TileLayout{TileWidth: 120, TileHeight: 120, Overlap: 20, CropMarks: true})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(tiles) != 6 {
+		t.Fatalf("Invalid number of tiles: %d", len(tiles))
+	}
+	writePages(t, tiles, "/tmp/tiled.pdf")
+
+	if _, err := TilePage(page, TileLayout{TileWidth: 120, TileHeight: 120, Overlap: 120}); err == nil {
+		t.Errorf("Overlap exceeding the tile size should fail")
+	}
+}
@@ -0,0 +1,331 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// MergePdfDocuments merges the documents loaded by `readers` into a single document, returned as an editor
+// for further editing and writing.  Besides the pages, the merged document has:
+//   - an outline with an item per document, titled `titles[i]` (or the document title or "Document N" when
+//     not given), with the outline of the document nested under it,
+//   - the form fields of all documents, renaming top-level fields whose names clash with those of a
+//     previous document,
+//   - the named destinations of all documents, renaming clashing names,
+//   - the page labels and optional content groups of all documents.
+//
+// The loaded documents are modified where names are changed.
+func MergePdfDocuments(readers []*PdfReader, titles []string) (*PdfEditor, error) {
+	if len(readers) == 0 {
+		return nil, errors.New("No documents to merge")
+	}
+
+	merged := &PdfEditor{
+		dests:  map[string]PdfObject{},
+		labels: map[*PdfPage]pageLabel{},
+		known:  map[*PdfIndirectObject]*PdfPage{},
+	}
+	outline := NewPdfOutlineTree()
+	var forms []*PdfAcroForm
+	var ocProperties []PdfObject
+	fieldNames := map[string]bool{}
+
+	for i, reader := range readers {
+		editor, err := NewPdfEditor(reader)
+		if err != nil {
+			return nil, err
+		}
+		if editor.GetNumPages() == 0 {
+			common.Log.Debug("Skipping document %d without pages", i+1)
+			continue
+		}
+
+		for _, page := range editor.pages {
+			if err := merged.AddPage(page); err != nil {
+				return nil, err
+			}
+		}
+		for obj, page := range editor.known {
+			merged.known[obj] = page
+		}
+		for page, label := range editor.labels {
+			merged.labels[page] = label
+		}
+
+		// Named destinations.
+		renames := map[string]string{}
+		for name, dest := range editor.dests {
+			newName := name
+			for n := 2; ; n++ {
+				if existing, has := merged.dests[newName]; !has || existing == dest {
+					break
+				}
+				newName = fmt.Sprintf("%s_%d", name, n)
+			}
+			if newName != name {
+				common.Log.Debug("Renaming named destination %s to %s", name, newName)
+				renames[name] = newName
+			}
+			merged.dests[newName] = dest
+		}
+		if len(renames) > 0 {
+			editor.renameDests(renames)
+		}
+
+		// Outline item of the document.
+		item := NewPdfOutlineItem()
+		item.Title = getDocumentTitle(reader, titles, i)
+		item.SetDestination(NewDestinationFit(editor.pages[0].GetPageAsIndirectObject()))
+		if editor.outlineTree != nil {
+			for _, child := range editor.outlineTree.Children() {
+				child.Remove()
+				if err := item.AddChild(child); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := outline.AddChild(item); err != nil {
+			return nil, err
+		}
+
+		if editor.acroForm != nil {
+			renameFields(editor.acroForm, fieldNames)
+			forms = append(forms, editor.acroForm)
+		}
+		if editor.ocProperties != nil {
+			ocProperties = append(ocProperties, editor.ocProperties)
+		}
+	}
+
+	merged.outlineTree = &outline.PdfOutlineTreeNode
+	merged.acroForm = mergeForms(forms)
+	merged.ocProperties = mergeOCProperties(ocProperties)
+	return merged, nil
+}
+
+// getDocumentTitle returns the title of the outline item of document `i` in a merged document.
+func getDocumentTitle(reader *PdfReader, titles []string, i int) *PdfObjectString {
+	if i < len(titles) && titles[i] != "" {
+		return MakeString(titles[i])
+	}
+
+	if trailer, err := reader.GetTrailer(); err == nil {
+		if obj, err := reader.traceToObject(trailer.Get("Info")); err == nil {
+			if info, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary); isDict {
+				title, err := reader.traceToObject(info.Get("Title"))
+				if err == nil {
+					if str, isString := TraceToDirectObject(title).(*PdfObjectString); isString && len(*str) > 0 {
+						return str
+					}
+				}
+			}
+		}
+	}
+	return MakeString(fmt.Sprintf("Document %d", i+1))
+}
+
+// renameDests renames the named destinations referred to by the outline items and the links of the
+// document according to `renames`.
+func (e *PdfEditor) renameDests(renames map[string]string) {
+	rename := func(dest PdfObject) PdfObject {
+		switch t := TraceToDirectObject(dest).(type) {
+		case *PdfObjectString:
+			if newName, has := renames[string(*t)]; has {
+				return MakeString(newName)
+			}
+		case *PdfObjectName:
+			if newName, has := renames[string(*t)]; has {
+				return MakeName(newName)
+			}
+		}
+		return dest
+	}
+	renameAction := func(action PdfObject) {
+		dict, isDict := TraceToDirectObject(action).(*PdfObjectDictionary)
+		if !isDict {
+			return
+		}
+		if s, isName := TraceToDirectObject(dict.Get("S")).(*PdfObjectName); isName && *s == "GoTo" {
+			if d := dict.Get("D"); d != nil {
+				dict.Set("D", rename(d))
+			}
+		}
+	}
+
+	var renameItems func(node *PdfOutlineTreeNode)
+	renameItems = func(node *PdfOutlineTreeNode) {
+		for ; node != nil; node = outlineNext(node) {
+			item, isItem := node.context.(*PdfOutlineItem)
+			if !isItem {
+				continue
+			}
+			if item.Dest != nil {
+				item.Dest = rename(item.Dest)
+			}
+			if item.A != nil {
+				renameAction(item.A)
+			}
+			renameItems(item.First)
+		}
+	}
+	if e.outlineTree != nil {
+		renameItems(e.outlineTree.First)
+	}
+
+	for _, page := range e.pages {
+		for _, annot := range page.Annotations {
+			if link, isLink := annot.GetContext().(*PdfAnnotationLink); isLink {
+				if link.Dest != nil {
+					link.Dest = rename(link.Dest)
+				}
+				if link.A != nil {
+					renameAction(link.A)
+				}
+			}
+		}
+	}
+}
+
+// renameFields renames the top-level fields of `form` whose names are in `names`, and adds the (new) names
+// to `names`.
+func renameFields(form *PdfAcroForm, names map[string]bool) {
+	if form.Fields == nil {
+		return
+	}
+	for _, field := range *form.Fields {
+		t, isString := TraceToDirectObject(field.T).(*PdfObjectString)
+		if !isString {
+			continue
+		}
+		name := string(*t)
+		newName := name
+		for n := 2; names[newName]; n++ {
+			newName = fmt.Sprintf("%s_%d", name, n)
+		}
+		if newName != name {
+			common.Log.Debug("Renaming field %s to %s", name, newName)
+			field.T = MakeString(newName)
+		}
+		names[newName] = true
+	}
+}
+
+// mergeForms returns the interactive form with the fields of all `forms`.  The default resources are
+// merged, the other form attributes are taken from the first form that has them.
+func mergeForms(forms []*PdfAcroForm) *PdfAcroForm {
+	if len(forms) == 0 {
+		return nil
+	}
+
+	merged := NewPdfAcroForm()
+	fields := []*PdfField{}
+	co := PdfObjectArray{}
+	fonts := MakeDict()
+	for _, form := range forms {
+		if form.Fields != nil {
+			fields = append(fields, *form.Fields...)
+		}
+		if form.CO != nil {
+			co = append(co, *form.CO...)
+		}
+		if form.NeedAppearances != nil && bool(*form.NeedAppearances) {
+			merged.NeedAppearances = form.NeedAppearances
+		}
+		if form.SigFlags != nil {
+			flags := int64(*form.SigFlags)
+			if merged.SigFlags != nil {
+				flags |= int64(*merged.SigFlags)
+			}
+			merged.SigFlags = MakeInteger(flags)
+		}
+		if merged.DA == nil {
+			merged.DA = form.DA
+		}
+		if merged.Q == nil {
+			merged.Q = form.Q
+		}
+		if form.DR != nil {
+			if merged.DR == nil {
+				dr := *form.DR
+				merged.DR = &dr
+			}
+			if dict, isDict := TraceToDirectObject(form.DR.Font).(*PdfObjectDictionary); isDict {
+				for _, key := range dict.Keys() {
+					if fonts.Get(key) == nil {
+						fonts.Set(key, dict.Get(key))
+					}
+				}
+			}
+		}
+		if form.XFA != nil {
+			common.Log.Debug("Dropping XFA form of merged document")
+		}
+	}
+
+	merged.Fields = &fields
+	if len(co) > 0 {
+		merged.CO = &co
+	}
+	if merged.DR != nil && len(fonts.Keys()) > 0 {
+		merged.DR.Font = fonts
+	}
+	return merged
+}
+
+// mergeOCProperties returns the optional content properties with the optional content groups of all
+// `ocProperties`.  The default configuration is that of the first document, with the Order, ON and OFF
+// arrays of all documents.
+func mergeOCProperties(ocProperties []PdfObject) PdfObject {
+	if len(ocProperties) == 0 {
+		return nil
+	}
+	if len(ocProperties) == 1 {
+		return ocProperties[0]
+	}
+
+	ocgs := PdfObjectArray{}
+	config := MakeDict()
+	arrays := map[PdfObjectName]*PdfObjectArray{}
+	for i, obj := range ocProperties {
+		dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+		if !isDict {
+			continue
+		}
+		if arr, isArray := TraceToDirectObject(dict.Get("OCGs")).(*PdfObjectArray); isArray {
+			ocgs = append(ocgs, *arr...)
+		}
+		d, isDict := TraceToDirectObject(dict.Get("D")).(*PdfObjectDictionary)
+		if !isDict {
+			continue
+		}
+		if i == 0 {
+			for _, key := range d.Keys() {
+				config.Set(key, d.Get(key))
+			}
+		}
+		for _, key := range []PdfObjectName{"Order", "ON", "OFF"} {
+			if arr, isArray := TraceToDirectObject(d.Get(key)).(*PdfObjectArray); isArray {
+				if arrays[key] == nil {
+					arrays[key] = &PdfObjectArray{}
+				}
+				*arrays[key] = append(*arrays[key], *arr...)
+			}
+		}
+	}
+	for key, arr := range arrays {
+		config.Set(key, arr)
+	}
+
+	merged := MakeDict()
+	merged.Set("OCGs", &ocgs)
+	merged.Set("D", config)
+	return merged
+}
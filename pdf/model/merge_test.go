@@ -0,0 +1,101 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// getLabelNumbers returns the page label styles and numbers of the pages of `editor`.
+func getLabelNumbers(editor *PdfEditor) []string {
+	var labels []string
+	for _, page := range editor.pages {
+		label, has := editor.labels[page]
+		if !has {
+			labels = append(labels, "")
+			continue
+		}
+		labels = append(labels, string(label.labelRange.Style)+MakeInteger(label.number).String())
+	}
+	return labels
+}
+
+func TestMergeDocuments(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	readers := []*PdfReader{
+		readTestFile(t, "/tmp/editor_src.pdf"),
+		readTestFile(t, "/tmp/editor_src.pdf"),
+	}
+
+	merged, err := MergePdfDocuments(readers, []string{"First"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := merged.WriteToFile("/tmp/merged.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	out := readTestFile(t, "/tmp/merged.pdf")
+	widths := getPageWidths(t, out)
+	if len(widths) != 6 || widths[0] != 100 || widths[3] != 100 || widths[5] != 300 {
+		t.Fatalf("Invalid pages: %v", widths)
+	}
+
+	// Outline item per document with the document outline nested.
+	items := getOutlineItems(out.GetOutlineTree())
+	if len(items) != 2 || items[0].Title.String() != "First" || items[1].Title.String() != "Document 2" {
+		t.Fatalf("Invalid outline: %d items", len(items))
+	}
+	for i, item := range items {
+		children := getOutlineItems(&item.PdfOutlineTreeNode)
+		if len(children) != 3 || children[2].Title.String() != "Page 3" {
+			t.Fatalf("Invalid outline of document %d", i+1)
+		}
+		if (*children[2].Dest.(*PdfObjectArray))[0] != out.pageList[3*i+2] {
+			t.Errorf("Invalid outline destination of document %d", i+1)
+		}
+	}
+
+	// Clashing field names resolved.
+	if out.AcroForm == nil || out.AcroForm.Fields == nil || len(*out.AcroForm.Fields) != 2 {
+		t.Fatalf("Invalid fields")
+	}
+	fields := *out.AcroForm.Fields
+	if fields[0].GetFullName() != "Text1" || fields[1].GetFullName() != "Text1_2" {
+		t.Errorf("Invalid field names: %s %s", fields[0].GetFullName(), fields[1].GetFullName())
+	}
+
+	// Clashing named destinations renamed, page labels kept.
+	editor, err := NewPdfEditor(out)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(editor.dests) != 2 {
+		t.Fatalf("Invalid named destinations: %v", editor.dests)
+	}
+	for name, page := range map[string]int{"p3": 2, "p3_2": 5} {
+		dest, has := editor.dests[name].(*PdfObjectArray)
+		if !has || (*dest)[0] != out.pageList[page] {
+			t.Errorf("Invalid named destination %s", name)
+		}
+	}
+	labels := getLabelNumbers(editor)
+	expected := []string{"r1", "r2", "r3", "r1", "r2", "r3"}
+	for i := range expected {
+		if labels[i] != expected[i] {
+			t.Fatalf("Invalid page labels: %v", labels)
+		}
+	}
+}
+
+func TestMergeSamePage(t *testing.T) {
+	reader := readTestFile(t, "../../testfiles/minimal.pdf")
+	if _, err := MergePdfDocuments([]*PdfReader{reader, reader}, nil); err == nil {
+		t.Errorf("Merging a document with itself should fail")
+	}
+}
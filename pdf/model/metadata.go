@@ -0,0 +1,476 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfInfo represents the document information dictionary (14.3.3).
+type PdfInfo struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	// Creator is the application that created the original document.
+	Creator string
+	// Producer is the application that converted the document to PDF.
+	Producer     string
+	CreationDate *PdfDate
+	ModDate      *PdfDate
+}
+
+// NewPdfInfoFromObject loads the document information from dictionary `obj`.
+func NewPdfInfoFromObject(obj PdfObject) (*PdfInfo, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid document information dictionary (%T)", obj)
+	}
+
+	info := &PdfInfo{}
+	for key, field := range map[PdfObjectName]*string{
+		"Title":    &info.Title,
+		"Author":   &info.Author,
+		"Subject":  &info.Subject,
+		"Keywords": &info.Keywords,
+		"Creator":  &info.Creator,
+		"Producer": &info.Producer,
+	} {
+		if str, isString := TraceToDirectObject(dict.Get(key)).(*PdfObjectString); isString {
+			*field = DecodeTextString(string(*str))
+		}
+	}
+	for key, field := range map[PdfObjectName]**PdfDate{
+		"CreationDate": &info.CreationDate,
+		"ModDate":      &info.ModDate,
+	} {
+		str, isString := TraceToDirectObject(dict.Get(key)).(*PdfObjectString)
+		if !isString {
+			continue
+		}
+		date, err := NewPdfDate(string(*str))
+		if err != nil {
+			common.Log.Debug("Invalid %s: %v", key, err)
+			continue
+		}
+		*field = &date
+	}
+	return info, nil
+}
+
+// ToPdfObject returns the document information dictionary.
+func (info *PdfInfo) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	for _, field := range []struct {
+		key   PdfObjectName
+		value string
+	}{
+		{"Title", info.Title},
+		{"Author", info.Author},
+		{"Subject", info.Subject},
+		{"Keywords", info.Keywords},
+		{"Creator", info.Creator},
+		{"Producer", info.Producer},
+	} {
+		if field.value != "" {
+			dict.Set(field.key, MakeString(EncodeTextString(field.value)))
+		}
+	}
+	if info.CreationDate != nil {
+		dict.Set("CreationDate", info.CreationDate.ToPdfObject())
+	}
+	if info.ModDate != nil {
+		dict.Set("ModDate", info.ModDate.ToPdfObject())
+	}
+	return dict
+}
+
+// DecodeTextString returns text string `str` (7.9.2.2) as UTF-8: UTF-16BE with a byte order mark, or
+// PDFDocEncoding, approximated as Latin-1.
+func DecodeTextString(str string) string {
+	if len(str) >= 2 && str[0] == 0xfe && str[1] == 0xff {
+		var units []uint16
+		for i := 2; i+1 < len(str); i += 2 {
+			units = append(units, uint16(str[i])<<8|uint16(str[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, len(str))
+	for i := 0; i < len(str); i++ {
+		runes[i] = rune(str[i])
+	}
+	return string(runes)
+}
+
+// EncodeTextString returns UTF-8 string `str` as a text string (7.9.2.2): as is if ASCII, otherwise UTF-16BE
+// with a byte order mark.
+func EncodeTextString(str string) string {
+	isASCII := true
+	for i := 0; i < len(str); i++ {
+		if str[i] >= 0x80 {
+			isASCII = false
+			break
+		}
+	}
+	if isASCII {
+		return str
+	}
+
+	var b bytes.Buffer
+	b.WriteString("\xfe\xff")
+	for _, unit := range utf16.Encode([]rune(str)) {
+		b.WriteByte(byte(unit >> 8))
+		b.WriteByte(byte(unit))
+	}
+	return b.String()
+}
+
+// XMP namespaces.
+const (
+	xmpNamespaceRDF    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmpNamespaceDC     = "http://purl.org/dc/elements/1.1/"
+	xmpNamespaceXMP    = "http://ns.adobe.com/xap/1.0/"
+	xmpNamespacePDF    = "http://ns.adobe.com/pdf/1.3/"
+	xmpNamespacePDFAID = "http://www.aiim.org/pdfa/ns/id/"
+)
+
+// XmpMetadata represents the XMP metadata of a document (Metadata stream of the catalog, 14.3.2) with the
+// properties of the Dublin Core (dc), XMP basic (xmp), Adobe PDF (pdf) and PDF/A identification (pdfaid)
+// schemas.  Unset dates are zero.
+type XmpMetadata struct {
+	// Dublin Core.
+	Title       string   // dc:title
+	Creators    []string // dc:creator
+	Description string   // dc:description
+	Subjects    []string // dc:subject
+	Format      string   // dc:format
+
+	// XMP basic.
+	CreatorTool  string    // xmp:CreatorTool
+	CreateDate   time.Time // xmp:CreateDate
+	ModifyDate   time.Time // xmp:ModifyDate
+	MetadataDate time.Time // xmp:MetadataDate
+
+	// Adobe PDF.
+	Producer string // pdf:Producer
+	Keywords string // pdf:Keywords
+
+	// PDF/A identification: part (1, 2 or 3) and conformance level (A, B or U), or 0 if not PDF/A.
+	PdfAPart        int    // pdfaid:part
+	PdfAConformance string // pdfaid:conformance
+}
+
+// NewXmpMetadata returns new XMP metadata for a PDF document.
+func NewXmpMetadata() *XmpMetadata {
+	return &XmpMetadata{Format: "application/pdf"}
+}
+
+// ParseXmpMetadata parses the XMP packet `data`.  Properties are read from the rdf:Description elements,
+// either as attributes or as elements with simple values or rdf:Alt, rdf:Seq or rdf:Bag arrays.
+func ParseXmpMetadata(data []byte) (*XmpMetadata, error) {
+	props := map[xml.Name][]string{}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	var stack []xml.Name
+	var property *xml.Name // Property element being read.
+	var text, item bytes.Buffer
+	var items []string
+	inItem := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := xml.Name{}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			stack = append(stack, t.Name)
+
+			switch {
+			case t.Name.Space == xmpNamespaceRDF && t.Name.Local == "Description":
+				for _, attr := range t.Attr {
+					if attr.Name.Space != xmpNamespaceRDF && attr.Name.Space != "" && attr.Name.Space != "xmlns" {
+						props[attr.Name] = append(props[attr.Name], attr.Value)
+					}
+				}
+			case property == nil && parent.Space == xmpNamespaceRDF && parent.Local == "Description":
+				name := t.Name
+				property = &name
+				text.Reset()
+				items = nil
+			case property != nil && t.Name.Space == xmpNamespaceRDF && t.Name.Local == "li":
+				inItem = true
+				item.Reset()
+			}
+		case xml.CharData:
+			if inItem {
+				item.Write(t)
+			} else if property != nil {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			switch {
+			case inItem && t.Name.Space == xmpNamespaceRDF && t.Name.Local == "li":
+				items = append(items, strings.TrimSpace(item.String()))
+				inItem = false
+			case property != nil && t.Name == *property:
+				if items != nil {
+					props[*property] = append(props[*property], items...)
+				} else if value := strings.TrimSpace(text.String()); value != "" {
+					props[*property] = append(props[*property], value)
+				}
+				property = nil
+			}
+		}
+	}
+
+	get := func(space, local string) string {
+		values := props[xml.Name{Space: space, Local: local}]
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	getDate := func(space, local string) time.Time {
+		value := get(space, local)
+		if value == "" {
+			return time.Time{}
+		}
+		date, err := parseXmpDate(value)
+		if err != nil {
+			common.Log.Debug("Invalid XMP date %s:%s (%s)", space, local, value)
+		}
+		return date
+	}
+
+	xmp := &XmpMetadata{
+		Title:           get(xmpNamespaceDC, "title"),
+		Creators:        props[xml.Name{Space: xmpNamespaceDC, Local: "creator"}],
+		Description:     get(xmpNamespaceDC, "description"),
+		Subjects:        props[xml.Name{Space: xmpNamespaceDC, Local: "subject"}],
+		Format:          get(xmpNamespaceDC, "format"),
+		CreatorTool:     get(xmpNamespaceXMP, "CreatorTool"),
+		CreateDate:      getDate(xmpNamespaceXMP, "CreateDate"),
+		ModifyDate:      getDate(xmpNamespaceXMP, "ModifyDate"),
+		MetadataDate:    getDate(xmpNamespaceXMP, "MetadataDate"),
+		Producer:        get(xmpNamespacePDF, "Producer"),
+		Keywords:        get(xmpNamespacePDF, "Keywords"),
+		PdfAConformance: get(xmpNamespacePDFAID, "conformance"),
+	}
+	if part := get(xmpNamespacePDFAID, "part"); part != "" {
+		xmp.PdfAPart, _ = strconv.Atoi(part)
+	}
+	return xmp, nil
+}
+
+// parseXmpDate parses XMP date `value` (a subset of ISO 8601: YYYY[-MM[-DD[Thh:mm[:ss[.s]]TZD]]]).
+func parseXmpDate(value string) (time.Time, error) {
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02",
+		"2006-01",
+		"2006",
+	}
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date %s", value)
+}
+
+// SetInfo sets the properties corresponding to the entries of document information dictionary `info`
+// (Table 317 in ISO 32000-1 and ISO 16684-1), so that the metadata is consistent with the dictionary.  Empty
+// entries leave the properties unchanged, and the creators are kept if they are the author of `info`.
+func (xmp *XmpMetadata) SetInfo(info *PdfInfo) {
+	for _, field := range []struct {
+		prop  *string
+		value string
+	}{
+		{&xmp.Title, info.Title},
+		{&xmp.Description, info.Subject},
+		{&xmp.Keywords, info.Keywords},
+		{&xmp.CreatorTool, info.Creator},
+		{&xmp.Producer, info.Producer},
+	} {
+		if field.value != "" {
+			*field.prop = field.value
+		}
+	}
+	if info.Author != "" && info.Author != strings.Join(xmp.Creators, ", ") {
+		xmp.Creators = []string{info.Author}
+	}
+	if info.CreationDate != nil {
+		xmp.CreateDate = info.CreationDate.ToGoTime()
+	}
+	if info.ModDate != nil {
+		xmp.ModifyDate = info.ModDate.ToGoTime()
+		xmp.MetadataDate = xmp.ModifyDate
+	}
+}
+
+// fillEmpty sets the empty entries of the document information to those of `other`.
+func (info *PdfInfo) fillEmpty(other *PdfInfo) {
+	for _, field := range []struct {
+		value *string
+		other string
+	}{
+		{&info.Title, other.Title},
+		{&info.Author, other.Author},
+		{&info.Subject, other.Subject},
+		{&info.Keywords, other.Keywords},
+		{&info.Creator, other.Creator},
+		{&info.Producer, other.Producer},
+	} {
+		if *field.value == "" {
+			*field.value = field.other
+		}
+	}
+	if info.CreationDate == nil {
+		info.CreationDate = other.CreationDate
+	}
+	if info.ModDate == nil {
+		info.ModDate = other.ModDate
+	}
+}
+
+// GetInfo returns the document information dictionary corresponding to the metadata.
+func (xmp *XmpMetadata) GetInfo() *PdfInfo {
+	info := &PdfInfo{
+		Title:    xmp.Title,
+		Author:   strings.Join(xmp.Creators, ", "),
+		Subject:  xmp.Description,
+		Keywords: xmp.Keywords,
+		Creator:  xmp.CreatorTool,
+		Producer: xmp.Producer,
+	}
+	if !xmp.CreateDate.IsZero() {
+		date := NewPdfDateFromTime(xmp.CreateDate)
+		info.CreationDate = &date
+	}
+	if !xmp.ModifyDate.IsZero() {
+		date := NewPdfDateFromTime(xmp.ModifyDate)
+		info.ModDate = &date
+	}
+	return info
+}
+
+// Bytes returns the XMP packet of the metadata.
+func (xmp *XmpMetadata) Bytes() []byte {
+	var b bytes.Buffer
+	escape := func(str string) string {
+		var esc bytes.Buffer
+		xml.EscapeText(&esc, []byte(str))
+		return esc.String()
+	}
+	simple := func(name, value string) {
+		if value != "" {
+			b.WriteString(fmt.Sprintf("   <%s>%s</%s>\n", name, escape(value), name))
+		}
+	}
+	array := func(name, arrayType string, values []string, lang bool) {
+		if len(values) == 0 {
+			return
+		}
+		b.WriteString(fmt.Sprintf("   <%s>\n    <rdf:%s>\n", name, arrayType))
+		for _, value := range values {
+			if lang {
+				b.WriteString(fmt.Sprintf("     <rdf:li xml:lang=\"x-default\">%s</rdf:li>\n", escape(value)))
+			} else {
+				b.WriteString(fmt.Sprintf("     <rdf:li>%s</rdf:li>\n", escape(value)))
+			}
+		}
+		b.WriteString(fmt.Sprintf("    </rdf:%s>\n   </%s>\n", arrayType, name))
+	}
+	date := func(name string, value time.Time) {
+		if !value.IsZero() {
+			simple(name, value.Format(time.RFC3339))
+		}
+	}
+
+	b.WriteString("<?xpacket begin=\"\xef\xbb\xbf\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(fmt.Sprintf(" <rdf:RDF xmlns:rdf=\"%s\">\n", xmpNamespaceRDF))
+
+	b.WriteString(fmt.Sprintf("  <rdf:Description rdf:about=\"\" xmlns:dc=\"%s\">\n", xmpNamespaceDC))
+	simple("dc:format", xmp.Format)
+	if xmp.Title != "" {
+		array("dc:title", "Alt", []string{xmp.Title}, true)
+	}
+	array("dc:creator", "Seq", xmp.Creators, false)
+	if xmp.Description != "" {
+		array("dc:description", "Alt", []string{xmp.Description}, true)
+	}
+	array("dc:subject", "Bag", xmp.Subjects, false)
+	b.WriteString("  </rdf:Description>\n")
+
+	b.WriteString(fmt.Sprintf("  <rdf:Description rdf:about=\"\" xmlns:xmp=\"%s\">\n", xmpNamespaceXMP))
+	simple("xmp:CreatorTool", xmp.CreatorTool)
+	date("xmp:CreateDate", xmp.CreateDate)
+	date("xmp:ModifyDate", xmp.ModifyDate)
+	date("xmp:MetadataDate", xmp.MetadataDate)
+	b.WriteString("  </rdf:Description>\n")
+
+	b.WriteString(fmt.Sprintf("  <rdf:Description rdf:about=\"\" xmlns:pdf=\"%s\">\n", xmpNamespacePDF))
+	simple("pdf:Producer", xmp.Producer)
+	simple("pdf:Keywords", xmp.Keywords)
+	b.WriteString("  </rdf:Description>\n")
+
+	if xmp.PdfAPart > 0 {
+		b.WriteString(fmt.Sprintf("  <rdf:Description rdf:about=\"\" xmlns:pdfaid=\"%s\">\n", xmpNamespacePDFAID))
+		simple("pdfaid:part", strconv.Itoa(xmp.PdfAPart))
+		simple("pdfaid:conformance", xmp.PdfAConformance)
+		b.WriteString("  </rdf:Description>\n")
+	}
+
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	// Padding for in-place updates.
+	for i := 0; i < 20; i++ {
+		b.WriteString(strings.Repeat(" ", 99) + "\n")
+	}
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return b.Bytes()
+}
+
+// ToPdfObject returns the metadata stream of the metadata.  The stream is not compressed, so that the
+// metadata can be found by applications that do not understand PDF.
+func (xmp *XmpMetadata) ToPdfObject() PdfObject {
+	stream, err := MakeStream(xmp.Bytes(), nil)
+	if err != nil {
+		// Not expected with the raw encoder.
+		common.Log.Debug("ERROR: %v", err)
+		return nil
+	}
+	stream.Set("Type", MakeName("Metadata"))
+	stream.Set("Subtype", MakeName("XML"))
+	return stream
+}
@@ -0,0 +1,185 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTextStrings(t *testing.T) {
+	for _, str := range []string{"Simple", "Résumé ✓", ""} {
+		if decoded := DecodeTextString(EncodeTextString(str)); decoded != str {
+			t.Errorf("%q != %q", decoded, str)
+		}
+	}
+	if encoded := EncodeTextString("é"); encoded != "\xfe\xff\x00\xe9" {
+		t.Errorf("Invalid encoding % x", encoded)
+	}
+	// PDFDocEncoding.
+	if decoded := DecodeTextString("caf\xe9"); decoded != "café" {
+		t.Errorf("Invalid decoding %q", decoded)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	created := NewPdfDateFromTime(time.Date(2018, 3, 4, 10, 20, 30, 0, time.FixedZone("", 3600)))
+	modified := NewPdfDateFromTime(time.Date(2018, 3, 5, 8, 0, 0, 0, time.UTC))
+
+	writer := NewPdfWriter()
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = NewPdfPageResources()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	info := writer.GetPdfInfo()
+	if info.Producer == "" {
+		t.Errorf("Missing default producer")
+	}
+	info.Title = "Résumé <draft> & notes"
+	info.Author = "Jane Doe"
+	info.Subject = "Testing"
+	info.Keywords = "pdf, xmp"
+	info.CreationDate = &created
+	info.ModDate = &modified
+	xmp := NewXmpMetadata()
+	xmp.PdfAPart = 3
+	xmp.PdfAConformance = "B"
+	xmp.Subjects = []string{"pdf", "xmp"}
+	writer.SetXmpMetadata(xmp)
+
+	f, err := os.Create("/tmp/metadata.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/metadata.pdf")
+	loaded, err := reader.GetPdfInfo()
+	if err != nil || loaded == nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if loaded.Title != info.Title || loaded.Author != info.Author || loaded.Subject != info.Subject ||
+		loaded.Keywords != info.Keywords || loaded.Producer != info.Producer || loaded.Creator != info.Creator {
+		t.Errorf("Invalid document information: %+v", loaded)
+	}
+	if loaded.CreationDate == nil || !loaded.CreationDate.ToGoTime().Equal(created.ToGoTime()) ||
+		loaded.ModDate == nil || !loaded.ModDate.ToGoTime().Equal(modified.ToGoTime()) {
+		t.Errorf("Invalid dates: %v %v", loaded.CreationDate, loaded.ModDate)
+	}
+
+	meta, err := reader.GetXmpMetadata()
+	if err != nil || meta == nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if meta.Title != info.Title || len(meta.Creators) != 1 || meta.Creators[0] != info.Author ||
+		meta.Description != info.Subject || meta.Keywords != info.Keywords || meta.Producer != info.Producer ||
+		meta.CreatorTool != info.Creator || meta.Format != "application/pdf" {
+		t.Errorf("Invalid XMP metadata: %+v", meta)
+	}
+	if !meta.CreateDate.Equal(created.ToGoTime()) || !meta.ModifyDate.Equal(modified.ToGoTime()) {
+		t.Errorf("Invalid XMP dates: %v %v", meta.CreateDate, meta.ModifyDate)
+	}
+	if meta.PdfAPart != 3 || meta.PdfAConformance != "B" {
+		t.Errorf("Invalid PDF/A identification: %d%s", meta.PdfAPart, meta.PdfAConformance)
+	}
+	if len(meta.Subjects) != 2 || meta.Subjects[1] != "xmp" {
+		t.Errorf("Invalid subjects: %v", meta.Subjects)
+	}
+
+	// Consistent with the document information.
+	fromXmp := meta.GetInfo()
+	if fromXmp.Title != loaded.Title || !fromXmp.CreationDate.ToGoTime().Equal(loaded.CreationDate.ToGoTime()) {
+		t.Errorf("Inconsistent metadata: %+v", fromXmp)
+	}
+}
+
+func TestMetadataFromXmp(t *testing.T) {
+	writer := NewPdfWriter()
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = NewPdfPageResources()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// Properties set only in the metadata are kept and copied to the document information.
+	writer.GetPdfInfo().Subject = "From info"
+	xmp := NewXmpMetadata()
+	xmp.Title = "From XMP"
+	xmp.Creators = []string{"First", "Second"}
+	writer.SetXmpMetadata(xmp)
+
+	f, err := os.Create("/tmp/metadata_xmp.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/metadata_xmp.pdf")
+	loaded, err := reader.GetPdfInfo()
+	if err != nil || loaded == nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if loaded.Title != "From XMP" || loaded.Author != "First, Second" || loaded.Subject != "From info" {
+		t.Errorf("Invalid document information: %+v", loaded)
+	}
+	meta, err := reader.GetXmpMetadata()
+	if err != nil || meta == nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if meta.Title != "From XMP" || len(meta.Creators) != 2 || meta.Creators[1] != "Second" ||
+		meta.Description != "From info" {
+		t.Errorf("Invalid XMP metadata: %+v", meta)
+	}
+}
+
+func TestParseXmpMetadata(t *testing.T) {
+	packet := `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="Adobe XMP Core">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+  pdf:Producer="Acrobat Distiller" xmp:CreateDate="2017-01-02T03:04:05+01:00" xmp:ModifyDate="2017-02">
+</rdf:Description>
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <dc:format>application/pdf</dc:format>
+  <dc:title><rdf:Alt><rdf:li xml:lang="x-default">A &amp; B</rdf:li></rdf:Alt></dc:title>
+  <dc:creator><rdf:Seq><rdf:li>First</rdf:li><rdf:li>Second</rdf:li></rdf:Seq></dc:creator>
+</rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="r"?>`
+
+	xmp, err := ParseXmpMetadata([]byte(packet))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if xmp.Producer != "Acrobat Distiller" || xmp.Title != "A & B" || xmp.Format != "application/pdf" {
+		t.Errorf("Invalid metadata: %+v", xmp)
+	}
+	if len(xmp.Creators) != 2 || xmp.Creators[0] != "First" || xmp.Creators[1] != "Second" {
+		t.Errorf("Invalid creators: %v", xmp.Creators)
+	}
+	if !xmp.CreateDate.Equal(time.Date(2017, 1, 2, 2, 4, 5, 0, time.UTC)) {
+		t.Errorf("Invalid creation date: %v", xmp.CreateDate)
+	}
+	if xmp.ModifyDate.Year() != 2017 || xmp.ModifyDate.Month() != 2 {
+		t.Errorf("Invalid modification date: %v", xmp.ModifyDate)
+	}
+	if xmp.PdfAPart != 0 {
+		t.Errorf("Not PDF/A")
+	}
+	if info := xmp.GetInfo(); info.Author != "First, Second" || info.ModDate == nil {
+		t.Errorf("Invalid document information: %+v", info)
+	}
+}
@@ -0,0 +1,517 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// treeNodeSize is the maximum number of entries or kids of the nodes of written name and number trees.
+const treeNodeSize = 32
+
+// PdfNameTree represents a name tree (7.9.6): a map from strings to objects, such as the named destinations
+// (Dests) or embedded files (EmbeddedFiles) of the name dictionary.
+// A tree loaded from a file is read lazily: Get and Walk only load the nodes they need.  The entries are
+// loaded in memory when the tree is modified.
+type PdfNameTree struct {
+	reader  *PdfReader
+	root    PdfObject            // Root of a loaded tree, nil once the entries are in memory.
+	entries map[string]PdfObject // In memory entries.
+}
+
+// NewPdfNameTree returns a new empty name tree.
+func NewPdfNameTree() *PdfNameTree {
+	return &PdfNameTree{entries: map[string]PdfObject{}}
+}
+
+// newPdfNameTreeFromObject returns the name tree with root node `root` read by `reader`.
+func newPdfNameTreeFromObject(reader *PdfReader, root PdfObject) *PdfNameTree {
+	return &PdfNameTree{reader: reader, root: root}
+}
+
+// Get returns the value of `name`, or nil if not in the tree.
+func (tree *PdfNameTree) Get(name string) (PdfObject, error) {
+	if tree.root == nil {
+		return tree.entries[name], nil
+	}
+
+	compare := func(key PdfObject) (int, bool) {
+		str, ok := key.(*PdfObjectString)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case string(*str) < name:
+			return -1, true
+		case string(*str) > name:
+			return 1, true
+		}
+		return 0, true
+	}
+	value, err := lookupTree(tree.reader, tree.root, "Names", compare, map[PdfObject]bool{})
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return resolveTreeValue(tree.reader, value)
+}
+
+// Walk calls `visit` for the entries of the tree in key order (in file order for loaded trees), until `visit`
+// returns false.
+func (tree *PdfNameTree) Walk(visit func(name string, value PdfObject) bool) error {
+	if tree.root == nil {
+		names := make([]string, 0, len(tree.entries))
+		for name := range tree.entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !visit(name, tree.entries[name]) {
+				break
+			}
+		}
+		return nil
+	}
+
+	var err error
+	_, walkErr := walkTree(tree.reader, tree.root, "Names", func(key, value PdfObject) bool {
+		str, ok := key.(*PdfObjectString)
+		if !ok {
+			common.Log.Debug("ERROR: Invalid name tree key (%T)", key)
+			return true
+		}
+		value, err = resolveTreeValue(tree.reader, value)
+		if err != nil {
+			return false
+		}
+		return visit(string(*str), value)
+	}, map[PdfObject]bool{})
+	if walkErr != nil {
+		return walkErr
+	}
+	return err
+}
+
+// Keys returns the names of the tree in order.
+func (tree *PdfNameTree) Keys() ([]string, error) {
+	var names []string
+	err := tree.Walk(func(name string, value PdfObject) bool {
+		names = append(names, name)
+		return true
+	})
+	return names, err
+}
+
+// load loads the entries of a tree read from a file in memory.
+func (tree *PdfNameTree) load() error {
+	if tree.root == nil {
+		return nil
+	}
+	entries := map[string]PdfObject{}
+	err := tree.Walk(func(name string, value PdfObject) bool {
+		entries[name] = value
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	tree.root = nil
+	tree.entries = entries
+	return nil
+}
+
+// Set sets the value of `name` to `value`.
+func (tree *PdfNameTree) Set(name string, value PdfObject) error {
+	if err := tree.load(); err != nil {
+		return err
+	}
+	tree.entries[name] = value
+	return nil
+}
+
+// Remove removes `name` from the tree.
+func (tree *PdfNameTree) Remove(name string) error {
+	if err := tree.load(); err != nil {
+		return err
+	}
+	delete(tree.entries, name)
+	return nil
+}
+
+// ToPdfObject returns the root of a balanced name tree with the entries of the tree.
+func (tree *PdfNameTree) ToPdfObject() (*PdfIndirectObject, error) {
+	if err := tree.load(); err != nil {
+		return nil, err
+	}
+	var keys, values []PdfObject
+	err := tree.Walk(func(name string, value PdfObject) bool {
+		keys = append(keys, MakeString(name))
+		values = append(values, value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return makeTree("Names", keys, values), nil
+}
+
+// PdfNumberTree represents a number tree (7.9.7): a map from integers to objects, such as the page labels
+// (PageLabels) or the structural parent tree (ParentTree).  Loaded trees are read lazily as PdfNameTree.
+type PdfNumberTree struct {
+	reader  *PdfReader
+	root    PdfObject           // Root of a loaded tree, nil once the entries are in memory.
+	entries map[int64]PdfObject // In memory entries.
+}
+
+// NewPdfNumberTree returns a new empty number tree.
+func NewPdfNumberTree() *PdfNumberTree {
+	return &PdfNumberTree{entries: map[int64]PdfObject{}}
+}
+
+// newPdfNumberTreeFromObject returns the number tree with root node `root` read by `reader`.
+func newPdfNumberTreeFromObject(reader *PdfReader, root PdfObject) *PdfNumberTree {
+	return &PdfNumberTree{reader: reader, root: root}
+}
+
+// Get returns the value of `num`, or nil if not in the tree.
+func (tree *PdfNumberTree) Get(num int64) (PdfObject, error) {
+	if tree.root == nil {
+		return tree.entries[num], nil
+	}
+
+	compare := func(key PdfObject) (int, bool) {
+		val, ok := key.(*PdfObjectInteger)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case int64(*val) < num:
+			return -1, true
+		case int64(*val) > num:
+			return 1, true
+		}
+		return 0, true
+	}
+	value, err := lookupTree(tree.reader, tree.root, "Nums", compare, map[PdfObject]bool{})
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return resolveTreeValue(tree.reader, value)
+}
+
+// Walk calls `visit` for the entries of the tree in key order (in file order for loaded trees), until `visit`
+// returns false.
+func (tree *PdfNumberTree) Walk(visit func(num int64, value PdfObject) bool) error {
+	if tree.root == nil {
+		nums := make([]int64, 0, len(tree.entries))
+		for num := range tree.entries {
+			nums = append(nums, num)
+		}
+		sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+		for _, num := range nums {
+			if !visit(num, tree.entries[num]) {
+				break
+			}
+		}
+		return nil
+	}
+
+	var err error
+	_, walkErr := walkTree(tree.reader, tree.root, "Nums", func(key, value PdfObject) bool {
+		num, ok := key.(*PdfObjectInteger)
+		if !ok {
+			common.Log.Debug("ERROR: Invalid number tree key (%T)", key)
+			return true
+		}
+		value, err = resolveTreeValue(tree.reader, value)
+		if err != nil {
+			return false
+		}
+		return visit(int64(*num), value)
+	}, map[PdfObject]bool{})
+	if walkErr != nil {
+		return walkErr
+	}
+	return err
+}
+
+// Keys returns the numbers of the tree in order.
+func (tree *PdfNumberTree) Keys() ([]int64, error) {
+	var nums []int64
+	err := tree.Walk(func(num int64, value PdfObject) bool {
+		nums = append(nums, num)
+		return true
+	})
+	return nums, err
+}
+
+// load loads the entries of a tree read from a file in memory.
+func (tree *PdfNumberTree) load() error {
+	if tree.root == nil {
+		return nil
+	}
+	entries := map[int64]PdfObject{}
+	err := tree.Walk(func(num int64, value PdfObject) bool {
+		entries[num] = value
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	tree.root = nil
+	tree.entries = entries
+	return nil
+}
+
+// Set sets the value of `num` to `value`.
+func (tree *PdfNumberTree) Set(num int64, value PdfObject) error {
+	if err := tree.load(); err != nil {
+		return err
+	}
+	tree.entries[num] = value
+	return nil
+}
+
+// Remove removes `num` from the tree.
+func (tree *PdfNumberTree) Remove(num int64) error {
+	if err := tree.load(); err != nil {
+		return err
+	}
+	delete(tree.entries, num)
+	return nil
+}
+
+// ToPdfObject returns the root of a balanced number tree with the entries of the tree.
+func (tree *PdfNumberTree) ToPdfObject() (*PdfIndirectObject, error) {
+	if err := tree.load(); err != nil {
+		return nil, err
+	}
+	var keys, values []PdfObject
+	err := tree.Walk(func(num int64, value PdfObject) bool {
+		keys = append(keys, MakeInteger(num))
+		values = append(values, value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return makeTree("Nums", keys, values), nil
+}
+
+// resolveTreeObject returns the direct object of `obj`, loading references with `reader` if not nil.
+func resolveTreeObject(reader *PdfReader, obj PdfObject) (PdfObject, error) {
+	if reader != nil {
+		var err error
+		obj, err = reader.traceToObject(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return TraceToDirectObject(obj), nil
+}
+
+// resolveTreeValue returns tree entry value `value` with its references resolved, such that it can be
+// written.
+func resolveTreeValue(reader *PdfReader, value PdfObject) (PdfObject, error) {
+	if reader == nil {
+		return value, nil
+	}
+	value, err := reader.traceToObject(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := reader.traverseObjectData(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// walkTree calls `visit` for the key and value of the entries of tree node `node` and its kids, where
+// `entriesKey` is Names for name trees and Nums for number trees.  Returns false if `visit` stopped the walk.
+func walkTree(reader *PdfReader, node PdfObject, entriesKey PdfObjectName, visit func(key, value PdfObject) bool,
+	visited map[PdfObject]bool) (bool, error) {
+	obj, err := resolveTreeObject(reader, node)
+	if err != nil {
+		return false, err
+	}
+	dict, isDict := obj.(*PdfObjectDictionary)
+	if !isDict || visited[dict] {
+		return true, nil
+	}
+	visited[dict] = true
+
+	obj, err = resolveTreeObject(reader, dict.Get(entriesKey))
+	if err != nil {
+		return false, err
+	}
+	if entries, isArray := obj.(*PdfObjectArray); isArray {
+		for i := 0; i+1 < len(*entries); i += 2 {
+			key, err := resolveTreeObject(reader, (*entries)[i])
+			if err != nil {
+				return false, err
+			}
+			if !visit(key, (*entries)[i+1]) {
+				return false, nil
+			}
+		}
+	}
+
+	obj, err = resolveTreeObject(reader, dict.Get("Kids"))
+	if err != nil {
+		return false, err
+	}
+	if kids, isArray := obj.(*PdfObjectArray); isArray {
+		for _, kid := range *kids {
+			cont, err := walkTree(reader, kid, entriesKey, visit, visited)
+			if err != nil || !cont {
+				return cont, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// lookupTree returns the value of the entry of tree node `node` whose key compares equal, or nil if not
+// found.  `compare` compares a key to the key looked for, returning false if the key is invalid.  Only the
+// kids whose Limits include the key are searched.
+func lookupTree(reader *PdfReader, node PdfObject, entriesKey PdfObjectName, compare func(key PdfObject) (int, bool),
+	visited map[PdfObject]bool) (PdfObject, error) {
+	obj, err := resolveTreeObject(reader, node)
+	if err != nil {
+		return nil, err
+	}
+	dict, isDict := obj.(*PdfObjectDictionary)
+	if !isDict || visited[dict] {
+		return nil, nil
+	}
+	visited[dict] = true
+
+	obj, err = resolveTreeObject(reader, dict.Get(entriesKey))
+	if err != nil {
+		return nil, err
+	}
+	if entries, isArray := obj.(*PdfObjectArray); isArray {
+		for i := 0; i+1 < len(*entries); i += 2 {
+			key, err := resolveTreeObject(reader, (*entries)[i])
+			if err != nil {
+				return nil, err
+			}
+			if cmp, ok := compare(key); ok && cmp == 0 {
+				return (*entries)[i+1], nil
+			}
+		}
+	}
+
+	obj, err = resolveTreeObject(reader, dict.Get("Kids"))
+	if err != nil {
+		return nil, err
+	}
+	kids, isArray := obj.(*PdfObjectArray)
+	if !isArray {
+		return nil, nil
+	}
+	for _, kid := range *kids {
+		obj, err := resolveTreeObject(reader, kid)
+		if err != nil {
+			return nil, err
+		}
+		if kidDict, isDict := obj.(*PdfObjectDictionary); isDict {
+			limits, err := resolveTreeObject(reader, kidDict.Get("Limits"))
+			if err != nil {
+				return nil, err
+			}
+			if arr, isArray := limits.(*PdfObjectArray); isArray && len(*arr) == 2 {
+				first, err := resolveTreeObject(reader, (*arr)[0])
+				if err != nil {
+					return nil, err
+				}
+				last, err := resolveTreeObject(reader, (*arr)[1])
+				if err != nil {
+					return nil, err
+				}
+				cmpFirst, ok1 := compare(first)
+				cmpLast, ok2 := compare(last)
+				if ok1 && ok2 && (cmpFirst > 0 || cmpLast < 0) {
+					continue
+				}
+			}
+		}
+		value, err := lookupTree(reader, kid, entriesKey, compare, visited)
+		if err != nil || value != nil {
+			return value, err
+		}
+	}
+	return nil, nil
+}
+
+// makeTree returns the root of a balanced name or number tree (`entriesKey` Names or Nums) with sorted keys
+// `keys` and values `values`.  The nodes have at most treeNodeSize entries or kids.
+func makeTree(entriesKey PdfObjectName, keys, values []PdfObject) *PdfIndirectObject {
+	type treeNode struct {
+		dict        *PdfObjectDictionary
+		first, last PdfObject
+	}
+	// split splits `n` items in balanced groups of at most treeNodeSize items, returning the group bounds.
+	split := func(n int) []int {
+		groups := (n + treeNodeSize - 1) / treeNodeSize
+		bounds := make([]int, groups+1)
+		for i := range bounds {
+			bounds[i] = i * n / groups
+		}
+		return bounds
+	}
+
+	if len(keys) <= treeNodeSize {
+		entries := PdfObjectArray{}
+		for i := range keys {
+			entries = append(entries, keys[i], values[i])
+		}
+		dict := MakeDict()
+		dict.Set(entriesKey, &entries)
+		return MakeIndirectObject(dict)
+	}
+
+	var nodes []treeNode
+	bounds := split(len(keys))
+	for i := 0; i+1 < len(bounds); i++ {
+		entries := PdfObjectArray{}
+		for j := bounds[i]; j < bounds[i+1]; j++ {
+			entries = append(entries, keys[j], values[j])
+		}
+		dict := MakeDict()
+		dict.Set(entriesKey, &entries)
+		nodes = append(nodes, treeNode{dict: dict, first: keys[bounds[i]], last: keys[bounds[i+1]-1]})
+	}
+
+	for {
+		if len(nodes) <= treeNodeSize {
+			kids := PdfObjectArray{}
+			for _, kid := range nodes {
+				kid.dict.Set("Limits", MakeArray(kid.first, kid.last))
+				kids = append(kids, MakeIndirectObject(kid.dict))
+			}
+			dict := MakeDict()
+			dict.Set("Kids", &kids)
+			return MakeIndirectObject(dict)
+		}
+
+		var parents []treeNode
+		bounds := split(len(nodes))
+		for i := 0; i+1 < len(bounds); i++ {
+			kids := PdfObjectArray{}
+			for _, kid := range nodes[bounds[i]:bounds[i+1]] {
+				kid.dict.Set("Limits", MakeArray(kid.first, kid.last))
+				kids = append(kids, MakeIndirectObject(kid.dict))
+			}
+			dict := MakeDict()
+			dict.Set("Kids", &kids)
+			parents = append(parents, treeNode{dict: dict, first: nodes[bounds[i]].first, last: nodes[bounds[i+1]-1].last})
+		}
+		nodes = parents
+	}
+}
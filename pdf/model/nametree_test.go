@@ -0,0 +1,178 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// checkTreeLimits checks the Limits of the kids of tree node `node` against their entries, returning the
+// depth of the tree.
+func checkTreeLimits(t *testing.T, node PdfObject, entriesKey PdfObjectName, isRoot bool) int {
+	dict, ok := TraceToDirectObject(node).(*PdfObjectDictionary)
+	if !ok {
+		t.Fatalf("Invalid tree node (%T)", node)
+	}
+	if isRoot && dict.Get("Limits") != nil {
+		t.Errorf("Root should not have Limits")
+	}
+	if !isRoot && dict.Get("Limits") == nil {
+		t.Errorf("Missing Limits")
+	}
+
+	var keys []PdfObject
+	if entries, ok := dict.Get(entriesKey).(*PdfObjectArray); ok {
+		if len(*entries) > 2*treeNodeSize {
+			t.Errorf("Too many entries: %d", len(*entries)/2)
+		}
+		for i := 0; i < len(*entries); i += 2 {
+			keys = append(keys, (*entries)[i])
+		}
+	}
+	depth := 0
+	if kids, ok := dict.Get("Kids").(*PdfObjectArray); ok {
+		if len(*kids) > treeNodeSize {
+			t.Errorf("Too many kids: %d", len(*kids))
+		}
+		for _, kid := range *kids {
+			depth = checkTreeLimits(t, kid, entriesKey, false)
+			limits := TraceToDirectObject(kid).(*PdfObjectDictionary).Get("Limits").(*PdfObjectArray)
+			keys = append(keys, (*limits)[0], (*limits)[1])
+		}
+	}
+	if limits, ok := dict.Get("Limits").(*PdfObjectArray); ok && len(keys) > 0 {
+		if (*limits)[0].DefaultWriteString() != keys[0].DefaultWriteString() ||
+			(*limits)[1].DefaultWriteString() != keys[len(keys)-1].DefaultWriteString() {
+			t.Errorf("Invalid Limits %s (%s - %s)", limits.DefaultWriteString(), keys[0].DefaultWriteString(),
+				keys[len(keys)-1].DefaultWriteString())
+		}
+	}
+	return depth + 1
+}
+
+func TestNameTree(t *testing.T) {
+	tree := NewPdfNameTree()
+	for i := 0; i < 1500; i++ {
+		tree.Set(fmt.Sprintf("name%04d", i), MakeInteger(int64(i)))
+	}
+	tree.Remove("name0010")
+
+	root, err := tree.ToPdfObject()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if depth := checkTreeLimits(t, root, "Names", true); depth != 3 {
+		t.Errorf("Invalid tree depth %d", depth)
+	}
+
+	writer := NewPdfWriter()
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = NewPdfPageResources()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.SetNameTree("JavaScript", tree); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err := os.Create("/tmp/nametree.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/nametree.pdf")
+	if tree, err := reader.GetNameTree("Dests"); err != nil || tree != nil {
+		t.Errorf("No Dests expected: %v %v", tree, err)
+	}
+	loaded, err := reader.GetNameTree("JavaScript")
+	if err != nil || loaded == nil {
+		t.Fatalf("Error: %v", err)
+	}
+	for _, i := range []int{0, 11, 750, 1499} {
+		value, err := loaded.Get(fmt.Sprintf("name%04d", i))
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if num, ok := value.(*PdfObjectInteger); !ok || int(*num) != i {
+			t.Errorf("Invalid value of name%04d: %v", i, value)
+		}
+	}
+	if value, err := loaded.Get("name0010"); err != nil || value != nil {
+		t.Errorf("Removed name found: %v %v", value, err)
+	}
+
+	keys, err := loaded.Keys()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(keys) != 1499 || keys[0] != "name0000" || keys[10] != "name0011" {
+		t.Errorf("Invalid keys (%d)", len(keys))
+	}
+
+	// The walk stops when asked to.
+	visited := 0
+	loaded.Walk(func(name string, value PdfObject) bool {
+		visited++
+		return visited < 5
+	})
+	if visited != 5 {
+		t.Errorf("Walk not stopped: %d", visited)
+	}
+
+	// Modifying a loaded tree.
+	loaded.Set("extra", MakeInteger(-1))
+	if value, _ := loaded.Get("extra"); value == nil {
+		t.Errorf("Missing extra entry")
+	}
+	if keys, _ := loaded.Keys(); len(keys) != 1500 || keys[0] != "extra" {
+		t.Errorf("Invalid keys after modification (%d)", len(keys))
+	}
+}
+
+func TestNumberTree(t *testing.T) {
+	tree := NewPdfNumberTree()
+	for i := 0; i < 100; i++ {
+		tree.Set(int64(10*i), MakeName(fmt.Sprintf("N%d", i)))
+	}
+	root, err := tree.ToPdfObject()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if depth := checkTreeLimits(t, root, "Nums", true); depth != 2 {
+		t.Errorf("Invalid tree depth %d", depth)
+	}
+
+	loaded := newPdfNumberTreeFromObject(nil, root)
+	value, err := loaded.Get(420)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if name, ok := value.(*PdfObjectName); !ok || *name != "N42" {
+		t.Errorf("Invalid value: %v", value)
+	}
+	if value, _ := loaded.Get(421); value != nil {
+		t.Errorf("Unexpected value: %v", value)
+	}
+
+	// Small trees have a single node.
+	small := NewPdfNumberTree()
+	small.Set(0, MakeName("A"))
+	root, err = small.ToPdfObject()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s := root.PdfObject.DefaultWriteString(); s != "<</Nums [0 /A]>>" {
+		t.Errorf("Invalid tree: %s", s)
+	}
+}
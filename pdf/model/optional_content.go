@@ -0,0 +1,803 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// OCState is a state of optional content: the usage states of optional content groups (Table 102) and the
+// base state of optional content configurations (Table 101).
+type OCState string
+
+const (
+	// OCStateUnspecified means that the state is not specified.
+	OCStateUnspecified OCState = ""
+	OCStateOn          OCState = "ON"
+	OCStateOff         OCState = "OFF"
+	// OCStateUnchanged is only valid as the base state of an alternate configuration: the states of the
+	// groups are left unchanged when the configuration is applied.
+	OCStateUnchanged OCState = "Unchanged"
+)
+
+// OCPolicy is the visibility policy of an optional content membership dictionary (Table 99).
+type OCPolicy string
+
+const (
+	// OCPolicyAllOn: visible only if all the groups are ON.
+	OCPolicyAllOn OCPolicy = "AllOn"
+	// OCPolicyAnyOn: visible if any of the groups is ON (default).
+	OCPolicyAnyOn OCPolicy = "AnyOn"
+	// OCPolicyAnyOff: visible if any of the groups is OFF.
+	OCPolicyAnyOff OCPolicy = "AnyOff"
+	// OCPolicyAllOff: visible only if all the groups are OFF.
+	OCPolicyAllOff OCPolicy = "AllOff"
+)
+
+// PdfOptionalContentGroup represents an optional content group (8.11.2), i.e. a layer: a named collection
+// of content that can be shown or hidden.
+type PdfOptionalContentGroup struct {
+	Name string
+	// Intent is the intended use of the group: "View" (default), "Design" or both.
+	Intent []string
+
+	// Usage states of the group (Table 102) that are applied automatically on viewing, printing and
+	// exporting by the AS entry of the configuration.  E.g. a group with ViewState OCStateOff and PrintState
+	// OCStateOn is only visible when printing.
+	ViewState   OCState
+	PrintState  OCState
+	ExportState OCState
+
+	container *PdfIndirectObject
+}
+
+// NewPdfOptionalContentGroup returns a new optional content group named `name`.
+func NewPdfOptionalContentGroup(name string) *PdfOptionalContentGroup {
+	return &PdfOptionalContentGroup{Name: name, container: MakeIndirectObject(MakeDict())}
+}
+
+// NewPdfOptionalContentGroupFromObject loads an optional content group from `obj`.
+func NewPdfOptionalContentGroupFromObject(obj PdfObject) (*PdfOptionalContentGroup, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid optional content group (%T)", obj)
+	}
+	if t, isName := dict.Get("Type").(*PdfObjectName); isName && *t != "OCG" {
+		return nil, fmt.Errorf("Invalid optional content group type (%s)", *t)
+	}
+
+	group := &PdfOptionalContentGroup{}
+	if container, isIndirect := obj.(*PdfIndirectObject); isIndirect {
+		group.container = container
+	} else {
+		group.container = MakeIndirectObject(dict)
+	}
+
+	if name, isString := TraceToDirectObject(dict.Get("Name")).(*PdfObjectString); isString {
+		group.Name = DecodeTextString(string(*name))
+	}
+	group.Intent = getOCIntent(dict.Get("Intent"))
+
+	usage, isDict := TraceToDirectObject(dict.Get("Usage")).(*PdfObjectDictionary)
+	if !isDict {
+		return group, nil
+	}
+	for _, entry := range []struct {
+		key   PdfObjectName
+		state *OCState
+	}{{"View", &group.ViewState}, {"Print", &group.PrintState}, {"Export", &group.ExportState}} {
+		d, isDict := TraceToDirectObject(usage.Get(entry.key)).(*PdfObjectDictionary)
+		if !isDict {
+			continue
+		}
+		if state, isName := TraceToDirectObject(d.Get(entry.key + "State")).(*PdfObjectName); isName {
+			*entry.state = OCState(*state)
+		}
+	}
+	return group, nil
+}
+
+// GetContainingPdfObject returns the indirect object containing the optional content group dictionary.
+func (ocg *PdfOptionalContentGroup) GetContainingPdfObject() PdfObject {
+	return ocg.container
+}
+
+// ToPdfObject returns the optional content group dictionary (in an indirect object) updated from the
+// fields.  Other usage entries are kept.
+func (ocg *PdfOptionalContentGroup) ToPdfObject() PdfObject {
+	dict, isDict := ocg.container.PdfObject.(*PdfObjectDictionary)
+	if !isDict {
+		dict = MakeDict()
+		ocg.container.PdfObject = dict
+	}
+
+	dict.Set("Type", MakeName("OCG"))
+	dict.Set("Name", MakeString(EncodeTextString(ocg.Name)))
+	if len(ocg.Intent) > 0 {
+		dict.Set("Intent", makeNameArray(ocg.Intent))
+	} else {
+		dict.Remove("Intent")
+	}
+
+	usage, isDict := TraceToDirectObject(dict.Get("Usage")).(*PdfObjectDictionary)
+	if !isDict {
+		usage = MakeDict()
+	}
+	for _, entry := range []struct {
+		key   PdfObjectName
+		state OCState
+	}{{"View", ocg.ViewState}, {"Print", ocg.PrintState}, {"Export", ocg.ExportState}} {
+		if entry.state == OCStateUnspecified {
+			usage.Remove(entry.key)
+			continue
+		}
+		d := MakeDict()
+		d.Set(entry.key+"State", MakeName(string(entry.state)))
+		usage.Set(entry.key, d)
+	}
+	if len(usage.Keys()) > 0 {
+		dict.Set("Usage", usage)
+	} else {
+		dict.Remove("Usage")
+	}
+	return ocg.container
+}
+
+// PdfOCMembership represents an optional content membership dictionary (8.11.2.2): the visibility of
+// content depends on the states of several optional content groups.
+type PdfOCMembership struct {
+	OCGs []*PdfOptionalContentGroup
+	// Policy is the visibility policy used with OCGs.  Ignored if VE is set.
+	Policy OCPolicy
+	// VE is the visibility expression (PDF 1.6), an array such as [/And ocg1 [/Not ocg2]], or nil.
+	VE PdfObject
+
+	container *PdfIndirectObject
+}
+
+// NewPdfOCMembership returns a membership dictionary for groups `ocgs` with policy `policy`.
+func NewPdfOCMembership(policy OCPolicy, ocgs ...*PdfOptionalContentGroup) *PdfOCMembership {
+	return &PdfOCMembership{OCGs: ocgs, Policy: policy, container: MakeIndirectObject(MakeDict())}
+}
+
+// NewPdfOCMembershipFromObject loads an optional content membership dictionary from `obj`.
+func NewPdfOCMembershipFromObject(obj PdfObject) (*PdfOCMembership, error) {
+	return newOCLoader().loadMembership(obj)
+}
+
+// GetContainingPdfObject returns the indirect object containing the membership dictionary.
+func (ocmd *PdfOCMembership) GetContainingPdfObject() PdfObject {
+	return ocmd.container
+}
+
+// IsVisible returns true if content belonging to the membership dictionary is visible when the states of
+// the groups are given by `isOn`.
+func (ocmd *PdfOCMembership) IsVisible(isOn func(ocg *PdfOptionalContentGroup) bool) bool {
+	if ocmd.VE != nil {
+		visible, err := ocmd.evalVisibilityExpression(ocmd.VE, isOn)
+		if err == nil {
+			return visible
+		}
+		common.Log.Debug("ERROR: Invalid visibility expression: %v", err)
+	}
+
+	numOn := 0
+	for _, ocg := range ocmd.OCGs {
+		if isOn(ocg) {
+			numOn++
+		}
+	}
+	switch ocmd.Policy {
+	case OCPolicyAllOn:
+		return numOn == len(ocmd.OCGs)
+	case OCPolicyAnyOff:
+		return numOn < len(ocmd.OCGs)
+	case OCPolicyAllOff:
+		return numOn == 0
+	}
+	// An empty membership dictionary has no effect on visibility.
+	return numOn > 0 || len(ocmd.OCGs) == 0
+}
+
+// evalVisibilityExpression evaluates visibility expression `ve` (8.11.2.2).
+func (ocmd *PdfOCMembership) evalVisibilityExpression(ve PdfObject, isOn func(ocg *PdfOptionalContentGroup) bool) (bool, error) {
+	if arr, isArray := TraceToDirectObject(ve).(*PdfObjectArray); isArray {
+		if len(*arr) < 2 {
+			return false, errors.New("Visibility expression too short")
+		}
+		op, isName := TraceToDirectObject((*arr)[0]).(*PdfObjectName)
+		if !isName {
+			return false, errors.New("Missing visibility expression operator")
+		}
+		var values []bool
+		for _, operand := range (*arr)[1:] {
+			value, err := ocmd.evalVisibilityExpression(operand, isOn)
+			if err != nil {
+				return false, err
+			}
+			values = append(values, value)
+		}
+		switch *op {
+		case "Not":
+			return !values[0], nil
+		case "And":
+			for _, value := range values {
+				if !value {
+					return false, nil
+				}
+			}
+			return true, nil
+		case "Or":
+			for _, value := range values {
+				if value {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return false, fmt.Errorf("Invalid visibility expression operator %s", *op)
+	}
+
+	// Optional content group, looked up in OCGs first for the group states.
+	for _, ocg := range ocmd.OCGs {
+		if ocg.container == ve {
+			return isOn(ocg), nil
+		}
+	}
+	ocg, err := NewPdfOptionalContentGroupFromObject(ve)
+	if err != nil {
+		return false, err
+	}
+	return isOn(ocg), nil
+}
+
+// ToPdfObject returns the membership dictionary (in an indirect object) updated from the fields.
+func (ocmd *PdfOCMembership) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("Type", MakeName("OCMD"))
+	if len(ocmd.OCGs) == 1 {
+		dict.Set("OCGs", ocmd.OCGs[0].ToPdfObject())
+	} else if len(ocmd.OCGs) > 1 {
+		dict.Set("OCGs", makeOCGArray(ocmd.OCGs))
+	}
+	if ocmd.Policy != "" && ocmd.Policy != OCPolicyAnyOn {
+		dict.Set("P", MakeName(string(ocmd.Policy)))
+	}
+	if ocmd.VE != nil {
+		dict.Set("VE", ocmd.VE)
+	}
+	ocmd.container.PdfObject = dict
+	return ocmd.container
+}
+
+// PdfOCOrderItem is an item of the Order array of an optional content configuration, i.e. a node of the
+// layer tree shown in viewers: an optional content group, or a label if Group is nil, with the items
+// nested below it.
+type PdfOCOrderItem struct {
+	Group    *PdfOptionalContentGroup
+	Label    string
+	Children []*PdfOCOrderItem
+}
+
+// PdfOCConfig represents an optional content configuration dictionary (8.11.4.3): the states of the
+// optional content groups and how they are presented in viewers.
+type PdfOCConfig struct {
+	Name    string
+	Creator string
+	// BaseState is the state of the groups that are in neither ON nor OFF.  OCStateOn if unspecified.
+	BaseState OCState
+	ON        []*PdfOptionalContentGroup
+	OFF       []*PdfOptionalContentGroup
+	// Intent is the intent of the configuration: "View" (default), "Design" or both.
+	Intent []string
+	// Order is the order of the groups in viewers.
+	Order []*PdfOCOrderItem
+	// ListMode is "AllPages" (default) or "VisiblePages": the groups listed in viewers.
+	ListMode string
+	// RBGroups are sets of groups of which at most one can be ON at a time (radio buttons).
+	RBGroups [][]*PdfOptionalContentGroup
+	// Locked are the groups that viewers do not allow to change the state of.
+	Locked []*PdfOptionalContentGroup
+	// AS is the array of usage application dictionaries.  If nil, it is generated from the usage states of
+	// the groups of the optional content properties, for the default configuration.
+	AS PdfObject
+}
+
+// IsVisible returns true if group `ocg` is ON in the configuration.
+func (config *PdfOCConfig) IsVisible(ocg *PdfOptionalContentGroup) bool {
+	if containsOCG(config.ON, ocg) {
+		return true
+	}
+	if containsOCG(config.OFF, ocg) {
+		return false
+	}
+	return config.BaseState != OCStateOff
+}
+
+// SetVisible sets the state of group `ocg` to ON if `visible` is true, OFF otherwise.
+func (config *PdfOCConfig) SetVisible(ocg *PdfOptionalContentGroup, visible bool) {
+	config.ON = removeOCG(config.ON, ocg)
+	config.OFF = removeOCG(config.OFF, ocg)
+	if visible {
+		config.ON = append(config.ON, ocg)
+	} else {
+		config.OFF = append(config.OFF, ocg)
+	}
+}
+
+// IsLocked returns true if the state of group `ocg` cannot be changed in viewers.
+func (config *PdfOCConfig) IsLocked(ocg *PdfOptionalContentGroup) bool {
+	return containsOCG(config.Locked, ocg)
+}
+
+// SetLocked locks the state of group `ocg` if `locked` is true, unlocks it otherwise.
+func (config *PdfOCConfig) SetLocked(ocg *PdfOptionalContentGroup, locked bool) {
+	config.Locked = removeOCG(config.Locked, ocg)
+	if locked {
+		config.Locked = append(config.Locked, ocg)
+	}
+}
+
+// ToPdfObject returns the configuration dictionary.
+func (config *PdfOCConfig) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	if config.Name != "" {
+		dict.Set("Name", MakeString(EncodeTextString(config.Name)))
+	}
+	if config.Creator != "" {
+		dict.Set("Creator", MakeString(EncodeTextString(config.Creator)))
+	}
+	if config.BaseState != OCStateUnspecified {
+		dict.Set("BaseState", MakeName(string(config.BaseState)))
+	}
+	if len(config.ON) > 0 {
+		dict.Set("ON", makeOCGArray(config.ON))
+	}
+	if len(config.OFF) > 0 {
+		dict.Set("OFF", makeOCGArray(config.OFF))
+	}
+	if len(config.Intent) > 0 {
+		dict.Set("Intent", makeNameArray(config.Intent))
+	}
+	if config.AS != nil {
+		dict.Set("AS", config.AS)
+	}
+	if len(config.Order) > 0 {
+		dict.Set("Order", makeOCOrderArray(config.Order))
+	}
+	if config.ListMode != "" {
+		dict.Set("ListMode", MakeName(config.ListMode))
+	}
+	if len(config.RBGroups) > 0 {
+		rbGroups := PdfObjectArray{}
+		for _, rbGroup := range config.RBGroups {
+			rbGroups = append(rbGroups, makeOCGArray(rbGroup))
+		}
+		dict.Set("RBGroups", &rbGroups)
+	}
+	if len(config.Locked) > 0 {
+		dict.Set("Locked", makeOCGArray(config.Locked))
+	}
+	return dict
+}
+
+// PdfOCProperties represents the optional content properties dictionary of a document (8.11.4.2).
+type PdfOCProperties struct {
+	// OCGs are all the optional content groups of the document.
+	OCGs []*PdfOptionalContentGroup
+	// D is the default configuration, the initial states of the groups.
+	D *PdfOCConfig
+	// Configs are the alternate configurations.
+	Configs []*PdfOCConfig
+
+	loader ocLoader
+}
+
+// NewPdfOCProperties returns empty optional content properties.
+func NewPdfOCProperties() *PdfOCProperties {
+	return &PdfOCProperties{D: &PdfOCConfig{}, loader: newOCLoader()}
+}
+
+// NewPdfOCPropertiesFromObject loads the optional content properties from dictionary `obj`.
+func NewPdfOCPropertiesFromObject(obj PdfObject) (*PdfOCProperties, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid optional content properties (%T)", obj)
+	}
+
+	props := NewPdfOCProperties()
+	ocgs, err := props.loader.loadGroups(dict.Get("OCGs"))
+	if err != nil {
+		return nil, err
+	}
+	props.OCGs = ocgs
+
+	if d := dict.Get("D"); d != nil {
+		config, err := props.loader.loadConfig(d)
+		if err != nil {
+			return nil, err
+		}
+		props.D = config
+	}
+	if configs, isArray := TraceToDirectObject(dict.Get("Configs")).(*PdfObjectArray); isArray {
+		for _, obj := range *configs {
+			config, err := props.loader.loadConfig(obj)
+			if err != nil {
+				return nil, err
+			}
+			props.Configs = append(props.Configs, config)
+		}
+	}
+	return props, nil
+}
+
+// AddGroup adds a new optional content group named `name` at the end of the Order of the default
+// configuration, and returns it.
+func (props *PdfOCProperties) AddGroup(name string) *PdfOptionalContentGroup {
+	ocg := NewPdfOptionalContentGroup(name)
+	props.OCGs = append(props.OCGs, ocg)
+	props.D.Order = append(props.D.Order, &PdfOCOrderItem{Group: ocg})
+	return ocg
+}
+
+// GetGroupByName returns the first optional content group named `name`, or nil if not found.
+func (props *PdfOCProperties) GetGroupByName(name string) *PdfOptionalContentGroup {
+	for _, ocg := range props.OCGs {
+		if ocg.Name == name {
+			return ocg
+		}
+	}
+	return nil
+}
+
+// GetGroup returns the optional content group of the document with dictionary `obj`.
+func (props *PdfOCProperties) GetGroup(obj PdfObject) (*PdfOptionalContentGroup, error) {
+	return props.getLoader().loadGroup(obj)
+}
+
+// GetMembership returns the membership dictionary `obj`, with the groups of the document.
+func (props *PdfOCProperties) GetMembership(obj PdfObject) (*PdfOCMembership, error) {
+	return props.getLoader().loadMembership(obj)
+}
+
+// getLoader returns the loader of the optional content objects of the document, which knows the groups
+// in OCGs.
+func (props *PdfOCProperties) getLoader() ocLoader {
+	if props.loader == nil {
+		props.loader = newOCLoader()
+	}
+	for _, ocg := range props.OCGs {
+		props.loader[ocg.container] = ocg
+	}
+	return props.loader
+}
+
+// IsVisible returns true if group `ocg` is ON in the default configuration.
+func (props *PdfOCProperties) IsVisible(ocg *PdfOptionalContentGroup) bool {
+	return props.D.IsVisible(ocg)
+}
+
+// SetVisible sets the state of group `ocg` in the default configuration, i.e. whether it is visible
+// when the document is opened.
+func (props *PdfOCProperties) SetVisible(ocg *PdfOptionalContentGroup, visible bool) {
+	props.D.SetVisible(ocg, visible)
+}
+
+// IsContentVisible returns true if content marked with optional content group or membership dictionary
+// `obj` is visible in the default configuration.
+func (props *PdfOCProperties) IsContentVisible(obj PdfObject) (bool, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return false, fmt.Errorf("Invalid optional content (%T)", obj)
+	}
+	if t, isName := dict.Get("Type").(*PdfObjectName); isName && *t == "OCMD" {
+		ocmd, err := props.GetMembership(obj)
+		if err != nil {
+			return false, err
+		}
+		return ocmd.IsVisible(props.IsVisible), nil
+	}
+	ocg, err := props.GetGroup(obj)
+	if err != nil {
+		return false, err
+	}
+	return props.IsVisible(ocg), nil
+}
+
+// ToPdfObject returns the optional content properties dictionary.
+func (props *PdfOCProperties) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	dict.Set("OCGs", makeOCGArray(props.OCGs))
+
+	d := props.D.ToPdfObject().(*PdfObjectDictionary)
+	if props.D.AS == nil {
+		if as := props.makeUsageApplications(); as != nil {
+			d.Set("AS", as)
+		}
+	}
+	dict.Set("D", d)
+
+	if len(props.Configs) > 0 {
+		configs := PdfObjectArray{}
+		for _, config := range props.Configs {
+			configs = append(configs, config.ToPdfObject())
+		}
+		dict.Set("Configs", &configs)
+	}
+	return dict
+}
+
+// makeUsageApplications returns the usage application dictionaries (8.11.4.4) that apply the usage states
+// of the groups, or nil if no group has usage states.
+func (props *PdfOCProperties) makeUsageApplications() PdfObject {
+	as := PdfObjectArray{}
+	for _, event := range []struct {
+		name  string
+		state func(ocg *PdfOptionalContentGroup) OCState
+	}{
+		{"View", func(ocg *PdfOptionalContentGroup) OCState { return ocg.ViewState }},
+		{"Print", func(ocg *PdfOptionalContentGroup) OCState { return ocg.PrintState }},
+		{"Export", func(ocg *PdfOptionalContentGroup) OCState { return ocg.ExportState }},
+	} {
+		var ocgs []*PdfOptionalContentGroup
+		for _, ocg := range props.OCGs {
+			if event.state(ocg) != OCStateUnspecified {
+				ocgs = append(ocgs, ocg)
+			}
+		}
+		if len(ocgs) == 0 {
+			continue
+		}
+		app := MakeDict()
+		app.Set("Event", MakeName(event.name))
+		app.Set("OCGs", makeOCGArray(ocgs))
+		app.Set("Category", MakeArray(MakeName(event.name)))
+		as = append(as, app)
+	}
+	if len(as) == 0 {
+		return nil
+	}
+	return &as
+}
+
+// ocLoader loads optional content objects, with a single PdfOptionalContentGroup per group dictionary.
+type ocLoader map[PdfObject]*PdfOptionalContentGroup
+
+func newOCLoader() ocLoader {
+	return ocLoader{}
+}
+
+func (loader ocLoader) loadGroup(obj PdfObject) (*PdfOptionalContentGroup, error) {
+	if ocg, has := loader[obj]; has {
+		return ocg, nil
+	}
+	ocg, err := NewPdfOptionalContentGroupFromObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	loader[obj] = ocg
+	return ocg, nil
+}
+
+// loadGroups loads an array of optional content groups.  A single group is accepted as well.
+func (loader ocLoader) loadGroups(obj PdfObject) ([]*PdfOptionalContentGroup, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	arr, isArray := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !isArray {
+		ocg, err := loader.loadGroup(obj)
+		if err != nil {
+			return nil, err
+		}
+		return []*PdfOptionalContentGroup{ocg}, nil
+	}
+
+	var ocgs []*PdfOptionalContentGroup
+	for _, o := range *arr {
+		if isNullObject(o) {
+			continue
+		}
+		ocg, err := loader.loadGroup(o)
+		if err != nil {
+			return nil, err
+		}
+		ocgs = append(ocgs, ocg)
+	}
+	return ocgs, nil
+}
+
+func (loader ocLoader) loadMembership(obj PdfObject) (*PdfOCMembership, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid optional content membership dictionary (%T)", obj)
+	}
+
+	ocmd := &PdfOCMembership{Policy: OCPolicyAnyOn}
+	if container, isIndirect := obj.(*PdfIndirectObject); isIndirect {
+		ocmd.container = container
+	} else {
+		ocmd.container = MakeIndirectObject(dict)
+	}
+
+	ocgs, err := loader.loadGroups(dict.Get("OCGs"))
+	if err != nil {
+		return nil, err
+	}
+	ocmd.OCGs = ocgs
+	if policy, isName := TraceToDirectObject(dict.Get("P")).(*PdfObjectName); isName {
+		ocmd.Policy = OCPolicy(*policy)
+	}
+	ocmd.VE = dict.Get("VE")
+	return ocmd, nil
+}
+
+func (loader ocLoader) loadConfig(obj PdfObject) (*PdfOCConfig, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid optional content configuration (%T)", obj)
+	}
+
+	config := &PdfOCConfig{}
+	if name, isString := TraceToDirectObject(dict.Get("Name")).(*PdfObjectString); isString {
+		config.Name = DecodeTextString(string(*name))
+	}
+	if creator, isString := TraceToDirectObject(dict.Get("Creator")).(*PdfObjectString); isString {
+		config.Creator = DecodeTextString(string(*creator))
+	}
+	if state, isName := TraceToDirectObject(dict.Get("BaseState")).(*PdfObjectName); isName {
+		config.BaseState = OCState(*state)
+	}
+	if listMode, isName := TraceToDirectObject(dict.Get("ListMode")).(*PdfObjectName); isName {
+		config.ListMode = string(*listMode)
+	}
+	config.Intent = getOCIntent(dict.Get("Intent"))
+	config.AS = dict.Get("AS")
+
+	var err error
+	for _, entry := range []struct {
+		key  PdfObjectName
+		ocgs *[]*PdfOptionalContentGroup
+	}{{"ON", &config.ON}, {"OFF", &config.OFF}, {"Locked", &config.Locked}} {
+		if *entry.ocgs, err = loader.loadGroups(dict.Get(entry.key)); err != nil {
+			return nil, err
+		}
+	}
+
+	if rbGroups, isArray := TraceToDirectObject(dict.Get("RBGroups")).(*PdfObjectArray); isArray {
+		for _, obj := range *rbGroups {
+			rbGroup, err := loader.loadGroups(obj)
+			if err != nil {
+				return nil, err
+			}
+			config.RBGroups = append(config.RBGroups, rbGroup)
+		}
+	}
+
+	if order, isArray := TraceToDirectObject(dict.Get("Order")).(*PdfObjectArray); isArray {
+		if config.Order, err = loader.loadOrder(order); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// loadOrder loads the items of Order array `arr`.  An array following a group holds the items nested below
+// the group, an array starting with a text string is a labelled set of items.
+func (loader ocLoader) loadOrder(arr *PdfObjectArray) ([]*PdfOCOrderItem, error) {
+	var items []*PdfOCOrderItem
+	for _, obj := range *arr {
+		nested, isArray := TraceToDirectObject(obj).(*PdfObjectArray)
+		if !isArray {
+			ocg, err := loader.loadGroup(obj)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, &PdfOCOrderItem{Group: ocg})
+			continue
+		}
+
+		var label string
+		hasLabel := false
+		if len(*nested) > 0 {
+			if str, isString := TraceToDirectObject((*nested)[0]).(*PdfObjectString); isString {
+				label = DecodeTextString(string(*str))
+				hasLabel = true
+				rest := (*nested)[1:]
+				nested = &rest
+			}
+		}
+		children, err := loader.loadOrder(nested)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasLabel && len(items) > 0 && items[len(items)-1].Group != nil && items[len(items)-1].Children == nil {
+			items[len(items)-1].Children = children
+		} else {
+			items = append(items, &PdfOCOrderItem{Label: label, Children: children})
+		}
+	}
+	return items, nil
+}
+
+// makeOCOrderArray returns the Order array of `items`.
+func makeOCOrderArray(items []*PdfOCOrderItem) *PdfObjectArray {
+	arr := PdfObjectArray{}
+	for _, item := range items {
+		if item.Group != nil {
+			arr = append(arr, item.Group.ToPdfObject())
+			if len(item.Children) > 0 {
+				arr = append(arr, makeOCOrderArray(item.Children))
+			}
+			continue
+		}
+		nested := PdfObjectArray{}
+		if item.Label != "" {
+			nested = append(nested, MakeString(EncodeTextString(item.Label)))
+		}
+		nested = append(nested, *makeOCOrderArray(item.Children)...)
+		arr = append(arr, &nested)
+	}
+	return &arr
+}
+
+// makeOCGArray returns an array of the group dictionaries of `ocgs`.
+func makeOCGArray(ocgs []*PdfOptionalContentGroup) *PdfObjectArray {
+	arr := PdfObjectArray{}
+	for _, ocg := range ocgs {
+		arr = append(arr, ocg.ToPdfObject())
+	}
+	return &arr
+}
+
+func makeNameArray(names []string) *PdfObjectArray {
+	arr := PdfObjectArray{}
+	for _, name := range names {
+		arr = append(arr, MakeName(name))
+	}
+	return &arr
+}
+
+// getOCIntent returns the intent names of Intent entry `obj`: a name or an array of names.
+func getOCIntent(obj PdfObject) []string {
+	switch t := TraceToDirectObject(obj).(type) {
+	case *PdfObjectName:
+		return []string{string(*t)}
+	case *PdfObjectArray:
+		var intent []string
+		for _, o := range *t {
+			if name, isName := TraceToDirectObject(o).(*PdfObjectName); isName {
+				intent = append(intent, string(*name))
+			}
+		}
+		return intent
+	}
+	return nil
+}
+
+func containsOCG(ocgs []*PdfOptionalContentGroup, ocg *PdfOptionalContentGroup) bool {
+	for _, o := range ocgs {
+		if o == ocg || o.container == ocg.container {
+			return true
+		}
+	}
+	return false
+}
+
+func removeOCG(ocgs []*PdfOptionalContentGroup, ocg *PdfOptionalContentGroup) []*PdfOptionalContentGroup {
+	var kept []*PdfOptionalContentGroup
+	for _, o := range ocgs {
+		if o != ocg && o.container != ocg.container {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,140 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+func TestOptionalContentProperties(t *testing.T) {
+	props := NewPdfOCProperties()
+	english := props.AddGroup("English")
+	french := props.AddGroup("Français")
+	watermark := props.AddGroup("Watermark")
+	watermark.ViewState = OCStateOff
+	watermark.PrintState = OCStateOn
+	props.D.Order = []*PdfOCOrderItem{
+		{Label: "Languages", Children: []*PdfOCOrderItem{{Group: english}, {Group: french}}},
+		{Group: watermark},
+	}
+	props.D.RBGroups = [][]*PdfOptionalContentGroup{{english, french}}
+	props.SetVisible(french, false)
+	props.SetVisible(watermark, false)
+	props.D.SetLocked(watermark, true)
+
+	writer := NewPdfWriter()
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = NewPdfPageResources()
+	page.Resources.SetPropertiesByName("OC1", english.ToPdfObject())
+	page.Resources.SetPropertiesByName("MC1", NewPdfOCMembership(OCPolicyAllOff, english, french).ToPdfObject())
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.SetOptionalContentProperties(props); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err := os.Create("/tmp/optional_content.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/optional_content.pdf")
+	props, err = reader.GetOptionalContentProperties()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if props == nil || len(props.OCGs) != 3 {
+		t.Fatalf("Invalid optional content properties: %+v", props)
+	}
+	english = props.GetGroupByName("English")
+	french = props.GetGroupByName("Français")
+	watermark = props.GetGroupByName("Watermark")
+	if english == nil || french == nil || watermark == nil {
+		t.Fatalf("Missing groups")
+	}
+	if !props.IsVisible(english) || props.IsVisible(french) || props.IsVisible(watermark) {
+		t.Errorf("Invalid visibility")
+	}
+	if watermark.ViewState != OCStateOff || watermark.PrintState != OCStateOn || english.ViewState != OCStateUnspecified {
+		t.Errorf("Invalid usage: %+v", watermark)
+	}
+	if !props.D.IsLocked(watermark) || props.D.IsLocked(english) {
+		t.Errorf("Invalid locked groups")
+	}
+	if len(props.D.RBGroups) != 1 || len(props.D.RBGroups[0]) != 2 || props.D.RBGroups[0][1] != french {
+		t.Errorf("Invalid RBGroups: %v", props.D.RBGroups)
+	}
+	order := props.D.Order
+	if len(order) != 2 || order[0].Label != "Languages" || len(order[0].Children) != 2 ||
+		order[0].Children[0].Group != english || order[1].Group != watermark {
+		t.Errorf("Invalid order: %v", order)
+	}
+	if as, ok := props.D.AS.(*PdfObjectArray); !ok || len(*as) != 2 {
+		t.Errorf("Invalid usage applications: %v", props.D.AS)
+	}
+
+	// The page resources refer to the groups of the document.
+	pages := reader.PageList
+	if len(pages) != 1 {
+		t.Fatalf("Invalid number of pages: %d", len(pages))
+	}
+	obj, found := pages[0].Resources.GetPropertiesByName("OC1")
+	if !found {
+		t.Fatalf("Missing property list")
+	}
+	if ocg, err := props.GetGroup(obj); err != nil || ocg != english {
+		t.Errorf("Invalid group: %v (%v)", ocg, err)
+	}
+	obj, _ = pages[0].Resources.GetPropertiesByName("MC1")
+	ocmd, err := props.GetMembership(obj)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if ocmd.Policy != OCPolicyAllOff || len(ocmd.OCGs) != 2 || ocmd.OCGs[0] != english {
+		t.Errorf("Invalid membership: %+v", ocmd)
+	}
+	if visible, err := props.IsContentVisible(obj); err != nil || visible {
+		t.Errorf("Membership should be hidden (%v)", err)
+	}
+	props.SetVisible(english, false)
+	if visible, err := props.IsContentVisible(obj); err != nil || !visible {
+		t.Errorf("Membership should be visible (%v)", err)
+	}
+}
+
+func TestOCVisibilityExpression(t *testing.T) {
+	a := NewPdfOptionalContentGroup("A")
+	b := NewPdfOptionalContentGroup("B")
+	ocmd := NewPdfOCMembership(OCPolicyAnyOn, a, b)
+	ocmd.VE = MakeArray(MakeName("And"), a.ToPdfObject(), MakeArray(MakeName("Not"), b.ToPdfObject()))
+
+	testcases := []struct {
+		aOn, bOn, visible bool
+	}{
+		{true, false, true},
+		{true, true, false},
+		{false, false, false},
+	}
+	for _, tcase := range testcases {
+		isOn := func(ocg *PdfOptionalContentGroup) bool {
+			if ocg == a {
+				return tcase.aOn
+			}
+			return tcase.bOn
+		}
+		if visible := ocmd.IsVisible(isOn); visible != tcase.visible {
+			t.Errorf("%v %v: visible %v", tcase.aOn, tcase.bOn, visible)
+		}
+	}
+}
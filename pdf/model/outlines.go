@@ -6,6 +6,7 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/unidoc/unidoc/common"
@@ -51,13 +52,14 @@ func NewPdfOutline() *PdfOutline {
 	container.PdfObject = MakeDict()
 
 	outline.primitive = container
+	outline.context = outline
 
 	return outline
 }
 
 func NewPdfOutlineTree() *PdfOutline {
 	outlineTree := NewPdfOutline()
-	outlineTree.context = &outlineTree
+	outlineTree.context = outlineTree
 	return outlineTree
 }
 
@@ -68,21 +70,268 @@ func NewPdfOutlineItem() *PdfOutlineItem {
 	container.PdfObject = MakeDict()
 
 	outlineItem.primitive = container
+	outlineItem.context = outlineItem
 	return outlineItem
 }
 
 func NewOutlineBookmark(title string, page *PdfIndirectObject) *PdfOutlineItem {
-	bookmark := PdfOutlineItem{}
-	bookmark.context = &bookmark
+	bookmark := NewPdfOutlineItem()
 
 	bookmark.Title = MakeString(title)
 
-	destArray := PdfObjectArray{}
-	destArray = append(destArray, page)
-	destArray = append(destArray, MakeName("Fit"))
-	bookmark.Dest = &destArray
+	bookmark.Dest = NewDestinationFit(page).ToPdfObject()
 
-	return &bookmark
+	return bookmark
+}
+
+// OutlineItemFlag is a style flag of an outline item (Table 154 in 12.3.3).
+type OutlineItemFlag int64
+
+const (
+	// OutlineItemFlagItalic displays the item in italic.
+	OutlineItemFlagItalic OutlineItemFlag = 1
+	// OutlineItemFlagBold displays the item in bold.
+	OutlineItemFlagBold OutlineItemFlag = 2
+)
+
+// GetDestination returns the destination of the item, or nil if it has none.
+func (this *PdfOutlineItem) GetDestination() (*PdfDestination, error) {
+	if this.Dest == nil {
+		return nil, nil
+	}
+	return NewPdfDestinationFromObject(this.Dest)
+}
+
+// SetDestination sets the destination of the item, removing its action.
+func (this *PdfOutlineItem) SetDestination(dest *PdfDestination) {
+	this.Dest = nil
+	if dest != nil {
+		this.Dest = dest.ToPdfObject()
+	}
+	this.A = nil
+}
+
+// GetAction returns the action performed when the item is activated, or nil if it has none.
+func (this *PdfOutlineItem) GetAction() (*PdfAction, error) {
+	if this.A == nil {
+		return nil, nil
+	}
+	return NewPdfActionFromObject(this.A)
+}
+
+// SetAction sets the action performed when the item is activated, removing its destination.
+func (this *PdfOutlineItem) SetAction(action *PdfAction) {
+	this.A = nil
+	if action != nil {
+		this.A = action.ToPdfObject()
+	}
+	this.Dest = nil
+}
+
+// GetColor returns the RGB color of the item text.  Defaults to black.
+func (this *PdfOutlineItem) GetColor() (float64, float64, float64) {
+	arr, ok := TraceToDirectObject(this.C).(*PdfObjectArray)
+	if !ok {
+		return 0, 0, 0
+	}
+	rgb, err := arr.ToFloat64Array()
+	if err != nil || len(rgb) != 3 {
+		common.Log.Debug("Invalid outline item color: %v", this.C)
+		return 0, 0, 0
+	}
+	return rgb[0], rgb[1], rgb[2]
+}
+
+// SetColor sets the RGB color of the item text, with components in the range 0 to 1.
+func (this *PdfOutlineItem) SetColor(r, g, b float64) {
+	if r == 0 && g == 0 && b == 0 {
+		this.C = nil
+		return
+	}
+	this.C = MakeArrayFromFloats([]float64{r, g, b})
+}
+
+// GetFlags returns the style flags of the item.
+func (this *PdfOutlineItem) GetFlags() OutlineItemFlag {
+	flags, err := getNumberAsInt64(TraceToDirectObject(this.F))
+	if err != nil {
+		return 0
+	}
+	return OutlineItemFlag(flags)
+}
+
+// SetFlags sets the style flags of the item.
+func (this *PdfOutlineItem) SetFlags(flags OutlineItemFlag) {
+	if flags == 0 {
+		this.F = nil
+		return
+	}
+	this.F = MakeInteger(int64(flags))
+}
+
+// IsOpen returns true if the children of the item are shown (the item is expanded).
+func (this *PdfOutlineItem) IsOpen() bool {
+	return this.Count == nil || *this.Count >= 0
+}
+
+// SetOpen sets whether the children of the item are shown (the item is expanded).
+func (this *PdfOutlineItem) SetOpen(open bool) {
+	count := int64(0)
+	if this.Count != nil {
+		count = *this.Count
+	}
+	if count < 0 {
+		count = -count
+	}
+	if !open {
+		count = -count
+		if count == 0 {
+			count = -1
+		}
+	}
+	this.Count = &count
+}
+
+// outlineNext returns the next sibling of outline tree node `node`.
+func outlineNext(node *PdfOutlineTreeNode) *PdfOutlineTreeNode {
+	if item, isItem := node.context.(*PdfOutlineItem); isItem {
+		return item.Next
+	}
+	return nil
+}
+
+// Children returns the child items of the node.
+func (this *PdfOutlineTreeNode) Children() []*PdfOutlineItem {
+	var children []*PdfOutlineItem
+	for node := this.First; node != nil; node = outlineNext(node) {
+		if item, isItem := node.context.(*PdfOutlineItem); isItem {
+			children = append(children, item)
+		}
+	}
+	return children
+}
+
+// AddChild appends `item` to the children of the node.
+func (this *PdfOutlineTreeNode) AddChild(item *PdfOutlineItem) error {
+	return this.InsertChild(len(this.Children()), item)
+}
+
+// InsertChild inserts `item` as child `index` (starting from 0) of the node.  With index equal to the number
+// of children the item is appended.  The item must not be in an outline tree (see PdfOutlineItem.Remove).
+func (this *PdfOutlineTreeNode) InsertChild(index int, item *PdfOutlineItem) error {
+	if item.Parent != nil || item.Prev != nil || item.Next != nil {
+		return errors.New("Outline item already in an outline tree")
+	}
+	if this.hasAncestor(item) {
+		return errors.New("Outline item cannot be inserted in its own descendants")
+	}
+	children := this.Children()
+	if index < 0 || index > len(children) {
+		return fmt.Errorf("Invalid outline item index %d (%d children)", index, len(children))
+	}
+
+	node := &item.PdfOutlineTreeNode
+	item.Parent = this
+	if index > 0 {
+		prev := children[index-1]
+		prev.Next = node
+		item.Prev = &prev.PdfOutlineTreeNode
+	} else {
+		this.First = node
+	}
+	if index < len(children) {
+		next := children[index]
+		next.Prev = node
+		item.Next = &next.PdfOutlineTreeNode
+	} else {
+		this.Last = node
+	}
+	return nil
+}
+
+// hasAncestor checks whether `item` is the node or one of its ancestors.
+func (this *PdfOutlineTreeNode) hasAncestor(item *PdfOutlineItem) bool {
+	for node := this; node != nil; {
+		current, isItem := node.context.(*PdfOutlineItem)
+		if !isItem {
+			return false
+		}
+		if current == item {
+			return true
+		}
+		node = current.Parent
+	}
+	return false
+}
+
+// Remove removes the item, with its descendants, from its outline tree.
+func (this *PdfOutlineItem) Remove() {
+	parent := this.Parent
+	if this.Prev != nil {
+		this.Prev.context.(*PdfOutlineItem).Next = this.Next
+	} else if parent != nil {
+		parent.First = this.Next
+	}
+	if this.Next != nil {
+		this.Next.context.(*PdfOutlineItem).Prev = this.Prev
+	} else if parent != nil {
+		parent.Last = this.Prev
+	}
+	this.Parent = nil
+	this.Prev = nil
+	this.Next = nil
+}
+
+// MoveTo moves the item, with its descendants, to child `index` (starting from 0) of `parent`, where the
+// index is that after removing the item from its current position.
+func (this *PdfOutlineItem) MoveTo(parent *PdfOutlineTreeNode, index int) error {
+	if parent.hasAncestor(this) {
+		return errors.New("Outline item cannot be moved to its own descendants")
+	}
+	parentPrev, prevPrev, nextPrev := this.Parent, this.Prev, this.Next
+
+	this.Remove()
+	if err := parent.InsertChild(index, this); err != nil {
+		// Restore.
+		this.Parent, this.Prev, this.Next = parentPrev, prevPrev, nextPrev
+		if prevPrev != nil {
+			prevPrev.context.(*PdfOutlineItem).Next = &this.PdfOutlineTreeNode
+		} else if parentPrev != nil {
+			parentPrev.First = &this.PdfOutlineTreeNode
+		}
+		if nextPrev != nil {
+			nextPrev.context.(*PdfOutlineItem).Prev = &this.PdfOutlineTreeNode
+		} else if parentPrev != nil {
+			parentPrev.Last = &this.PdfOutlineTreeNode
+		}
+		return err
+	}
+	return nil
+}
+
+// updateCounts updates the Count of the descendant items of the node, keeping their open state.
+// Returns the number of visible descendants.
+func (this *PdfOutlineTreeNode) updateCounts() int64 {
+	visible := int64(0)
+	for _, item := range this.Children() {
+		open := item.IsOpen()
+		descendants := item.PdfOutlineTreeNode.updateCounts()
+		if item.First == nil {
+			item.Count = nil
+		} else {
+			count := descendants
+			if !open {
+				count = -count
+			}
+			item.Count = &count
+		}
+
+		visible++
+		if open {
+			visible += descendants
+		}
+	}
+	return visible
 }
 
 // Does not traverse the tree.
@@ -236,13 +485,25 @@ func (this *PdfOutline) ToPdfObject() PdfObject {
 
 	dict.Set("Type", MakeName("Outlines"))
 
+	count := this.updateCounts()
+	this.Count = &count
+	if count > 0 {
+		dict.Set("Count", MakeInteger(count))
+	} else {
+		dict.Remove("Count")
+	}
+
 	if this.First != nil {
 		dict.Set("First", this.First.ToPdfObject())
+	} else {
+		dict.Remove("First")
 	}
 
 	if this.Last != nil {
 		dict.Set("Last", this.Last.getOuter().GetContainingPdfObject())
 		//PdfObjectConverterCache[this.Last.getOuter()]
+	} else {
+		dict.Remove("Last")
 	}
 
 	if this.Parent != nil {
@@ -265,6 +526,8 @@ func (this *PdfOutlineItem) ToPdfObject() PdfObject {
 	dict.Set("Title", this.Title)
 	if this.A != nil {
 		dict.Set("A", this.A)
+	} else {
+		dict.Remove("A")
 	}
 	if obj := dict.Get("SE"); obj != nil {
 		// XXX: Currently not supporting structure element hierarchy.
@@ -279,29 +542,45 @@ func (this *PdfOutlineItem) ToPdfObject() PdfObject {
 	*/
 	if this.C != nil {
 		dict.Set("C", this.C)
+	} else {
+		dict.Remove("C")
 	}
 	if this.Dest != nil {
 		dict.Set("Dest", this.Dest)
+	} else {
+		dict.Remove("Dest")
 	}
 	if this.F != nil {
 		dict.Set("F", this.F)
+	} else {
+		dict.Remove("F")
 	}
 	if this.Count != nil {
 		dict.Set("Count", MakeInteger(*this.Count))
+	} else {
+		dict.Remove("Count")
 	}
 	if this.Next != nil {
 		dict.Set("Next", this.Next.ToPdfObject())
+	} else {
+		dict.Remove("Next")
 	}
 	if this.First != nil {
 		dict.Set("First", this.First.ToPdfObject())
+	} else {
+		dict.Remove("First")
 	}
 	if this.Prev != nil {
 		dict.Set("Prev", this.Prev.getOuter().GetContainingPdfObject())
 		//PdfObjectConverterCache[this.Prev.getOuter()]
+	} else {
+		dict.Remove("Prev")
 	}
 	if this.Last != nil {
 		dict.Set("Last", this.Last.getOuter().GetContainingPdfObject())
 		// PdfObjectConverterCache[this.Last.getOuter()]
+	} else {
+		dict.Remove("Last")
 	}
 	if this.Parent != nil {
 		dict.Set("Parent", this.Parent.getOuter().GetContainingPdfObject())
@@ -0,0 +1,190 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// getOutlineTitles returns the titles of the children of `node`.
+func getOutlineTitles(node *PdfOutlineTreeNode) []string {
+	var titles []string
+	for _, item := range node.Children() {
+		titles = append(titles, item.Title.String())
+	}
+	return titles
+}
+
+func equalTitles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDestinationRoundTrip(t *testing.T) {
+	page := MakeIndirectObject(MakeDict())
+	dests := []*PdfDestination{
+		NewDestinationXYZ(page, 10, 20, 0),
+		NewDestinationFit(page),
+		NewDestinationFitH(page, 30),
+		NewDestinationFitV(page, 40),
+		NewDestinationFitR(page, PdfRectangle{Llx: 1, Lly: 2, Urx: 3, Ury: 4}),
+		NewNamedDestination("chapter1"),
+	}
+	for _, dest := range dests {
+		loaded, err := NewPdfDestinationFromObject(dest.ToPdfObject())
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if loaded.ToPdfObject().DefaultWriteString() != dest.ToPdfObject().DefaultWriteString() {
+			t.Errorf("Destination mismatch: %s vs %s", loaded.ToPdfObject().DefaultWriteString(),
+				dest.ToPdfObject().DefaultWriteString())
+		}
+	}
+
+	// Null zoom.
+	dest, err := NewPdfDestinationFromObject(MakeArray(page, MakeName("XYZ"), MakeNull(), MakeInteger(5), MakeNull()))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if dest.Type != DestinationTypeXYZ || dest.Left != nil || dest.Top == nil || *dest.Top != 5 || dest.Zoom != nil {
+		t.Errorf("Invalid destination: %+v", dest)
+	}
+
+	if _, err := NewPdfDestinationFromObject(MakeArray(page, MakeName("Invalid"))); err == nil {
+		t.Errorf("Invalid destination type should fail")
+	}
+}
+
+func TestOutlineEditing(t *testing.T) {
+	var pages []*PdfPage
+	for i := 0; i < 3; i++ {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+		page.Resources = NewPdfPageResources()
+		pages = append(pages, page)
+	}
+
+	outline := NewPdfOutlineTree()
+	newItem := func(parent *PdfOutlineTreeNode, title string, dest *PdfDestination) *PdfOutlineItem {
+		item := NewPdfOutlineItem()
+		item.Title = MakeString(title)
+		item.SetDestination(dest)
+		if err := parent.AddChild(item); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		return item
+	}
+	a := newItem(&outline.PdfOutlineTreeNode, "A", NewDestinationXYZ(pages[0].GetPageAsIndirectObject(), 0, 100, 2))
+	a.SetColor(1, 0, 0)
+	a.SetFlags(OutlineItemFlagBold | OutlineItemFlagItalic)
+	b := newItem(&outline.PdfOutlineTreeNode, "B", NewDestinationFitR(pages[1].GetPageAsIndirectObject(), PdfRectangle{Llx: 10, Lly: 10, Urx: 50, Ury: 50}))
+	newItem(&b.PdfOutlineTreeNode, "B1", NewNamedDestination("p3"))
+	newItem(&b.PdfOutlineTreeNode, "B2", NewDestinationFitH(pages[2].GetPageAsIndirectObject(), 80))
+	b.SetOpen(false)
+	newItem(&outline.PdfOutlineTreeNode, "C", NewDestinationFit(pages[2].GetPageAsIndirectObject()))
+
+	if err := a.MoveTo(&a.PdfOutlineTreeNode, 0); err == nil {
+		t.Errorf("Moving an item into itself should fail")
+	}
+	if err := b.PdfOutlineTreeNode.AddChild(a); err == nil {
+		t.Errorf("Adding an item already in the tree should fail")
+	}
+
+	writer := NewPdfWriter()
+	for _, page := range pages {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	writer.AddOutlineTree(&outline.PdfOutlineTreeNode)
+	f, err := os.Create("/tmp/outlines.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/outlines.pdf")
+	root := reader.GetOutlineTree()
+	if titles := getOutlineTitles(root); !equalTitles(titles, []string{"A", "B", "C"}) {
+		t.Fatalf("Invalid outline: %v", titles)
+	}
+	// B is closed: 3 visible items.
+	if count := root.context.(*PdfOutline).Count; count == nil || *count != 3 {
+		t.Errorf("Invalid outline count: %v", count)
+	}
+	items := root.Children()
+	if r, g, b := items[0].GetColor(); r != 1 || g != 0 || b != 0 {
+		t.Errorf("Invalid color: %v %v %v", r, g, b)
+	}
+	if flags := items[0].GetFlags(); flags != OutlineItemFlagBold|OutlineItemFlagItalic {
+		t.Errorf("Invalid flags: %d", flags)
+	}
+	dest, err := items[0].GetDestination()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if dest.Type != DestinationTypeXYZ || *dest.Top != 100 || *dest.Zoom != 2 || dest.Page != reader.pageList[0] {
+		t.Errorf("Invalid destination: %+v", dest)
+	}
+	if items[1].IsOpen() || *items[1].Count != -2 {
+		t.Errorf("Item B should be closed with 2 children")
+	}
+	dest, err = items[1].Children()[0].GetDestination()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if dest.Type != DestinationTypeNamed || dest.Name != "p3" {
+		t.Errorf("Invalid named destination: %+v", dest)
+	}
+
+	// Edit the loaded outline: D A B(C B1 B2), open B, remove A.
+	d := NewOutlineBookmark("D", reader.pageList[1])
+	if err := root.InsertChild(0, d); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := items[2].MoveTo(&items[1].PdfOutlineTreeNode, 0); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	items[1].SetOpen(true)
+	items[0].Remove()
+
+	editor, err := NewPdfEditor(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.WriteToFile("/tmp/outlines_edited.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	out := readTestFile(t, "/tmp/outlines_edited.pdf")
+	root = out.GetOutlineTree()
+	if titles := getOutlineTitles(root); !equalTitles(titles, []string{"D", "B"}) {
+		t.Fatalf("Invalid outline: %v", titles)
+	}
+	b = root.Children()[1]
+	if titles := getOutlineTitles(&b.PdfOutlineTreeNode); !equalTitles(titles, []string{"C", "B1", "B2"}) {
+		t.Fatalf("Invalid outline of B: %v", titles)
+	}
+	if b.Count == nil || *b.Count != 3 {
+		t.Errorf("Invalid count of B: %v", b.Count)
+	}
+	if count := root.context.(*PdfOutline).Count; count == nil || *count != 5 {
+		t.Errorf("Invalid outline count: %v", count)
+	}
+}
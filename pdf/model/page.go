@@ -400,6 +400,39 @@ func (this *PdfPage) GetMediaBox() (*PdfRectangle, error) {
 	return nil, errors.New("Media box not defined")
 }
 
+// GetRotate returns the inheritable page rotation in degrees, either from the page or a higher up page/pages
+// struct.  Defaults to 0 if not defined.
+func (this *PdfPage) GetRotate() (int64, error) {
+	if this.Rotate != nil {
+		return *this.Rotate, nil
+	}
+
+	node := this.Parent
+	for node != nil {
+		dictObj, ok := node.(*PdfIndirectObject)
+		if !ok {
+			return 0, errors.New("Invalid parent object")
+		}
+
+		dict, ok := dictObj.PdfObject.(*PdfObjectDictionary)
+		if !ok {
+			return 0, errors.New("Invalid parent objects dictionary")
+		}
+
+		if obj := dict.Get("Rotate"); obj != nil {
+			rotate, ok := TraceToDirectObject(obj).(*PdfObjectInteger)
+			if !ok {
+				return 0, errors.New("Invalid rotate value")
+			}
+			return int64(*rotate), nil
+		}
+
+		node = dict.Get("Parent")
+	}
+
+	return 0, nil
+}
+
 // Get the inheritable resources, either from the page or or a higher up page/pages struct.
 func (this *PdfPage) getResources() (*PdfPageResources, error) {
 	if this.Resources != nil {
@@ -698,17 +731,10 @@ func (this *PdfPage) AddWatermarkImage(ximg *XObjectImage, opt WatermarkImageOpt
 		return err
 	}
 
-	i = 0
-	gsName := PdfObjectName(fmt.Sprintf("GS%d", i))
-	for this.HasExtGState(gsName) {
-		i++
-		gsName = PdfObjectName(fmt.Sprintf("GS%d", i))
-	}
-	gs0 := MakeDict()
-	gs0.Set("BM", MakeName("Normal"))
-	gs0.Set("CA", MakeFloat(opt.Alpha))
-	gs0.Set("ca", MakeFloat(opt.Alpha))
-	err = this.AddExtGState(gsName, gs0)
+	gs0 := NewPdfExtGState()
+	gs0.BM = "Normal"
+	gs0.SetOpacity(opt.Alpha)
+	gsName, err := this.Resources.AddNewExtGState("GS", gs0)
 	if err != nil {
 		return err
 	}
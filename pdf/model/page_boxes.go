@@ -0,0 +1,345 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PageBox identifies one of the page boundaries (section 14.11.2 of ISO 32000-1).
+type PageBox int
+
+const (
+	// PageBoxMedia is the boundary of the physical medium.
+	PageBoxMedia PageBox = iota
+	// PageBoxCrop is the visible region of the page when displayed or printed.  Defaults to the media box.
+	PageBoxCrop
+	// PageBoxBleed is the region to which the page contents are clipped in production.  Defaults to the crop box.
+	PageBoxBleed
+	// PageBoxTrim is the intended dimensions of the finished page after trimming.  Defaults to the crop box.
+	PageBoxTrim
+	// PageBoxArt is the extent of the meaningful content of the page.  Defaults to the crop box.
+	PageBoxArt
+)
+
+// String returns the name of the page box as used in the page dictionary.
+func (box PageBox) String() string {
+	switch box {
+	case PageBoxMedia:
+		return "MediaBox"
+	case PageBoxCrop:
+		return "CropBox"
+	case PageBoxBleed:
+		return "BleedBox"
+	case PageBoxTrim:
+		return "TrimBox"
+	case PageBoxArt:
+		return "ArtBox"
+	}
+	return fmt.Sprintf("PageBox(%d)", int(box))
+}
+
+// getInheritedAttribute returns the inheritable attribute `key` from the page parents (page tree nodes), or
+// nil if not defined.
+func (this *PdfPage) getInheritedAttribute(key PdfObjectName) (PdfObject, error) {
+	node := this.Parent
+	for node != nil {
+		dictObj, ok := node.(*PdfIndirectObject)
+		if !ok {
+			return nil, errors.New("Invalid parent object")
+		}
+
+		dict, ok := dictObj.PdfObject.(*PdfObjectDictionary)
+		if !ok {
+			return nil, errors.New("Invalid parent objects dictionary")
+		}
+
+		if obj := dict.Get(key); obj != nil {
+			return obj, nil
+		}
+
+		node = dict.Get("Parent")
+	}
+	return nil, nil
+}
+
+// GetCropBox returns the crop box of the page, either from the page or a higher up page/pages struct.
+// Defaults to the media box if not defined.  The crop box is clipped to the media box.
+func (this *PdfPage) GetCropBox() (*PdfRectangle, error) {
+	mbox, err := this.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+
+	cbox := this.CropBox
+	if cbox == nil {
+		obj, err := this.getInheritedAttribute("CropBox")
+		if err != nil {
+			return nil, err
+		}
+		if arr, ok := TraceToDirectObject(obj).(*PdfObjectArray); ok {
+			cbox, err = NewPdfRectangle(*arr)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cbox == nil {
+		return mbox, nil
+	}
+
+	return intersectRectangles(cbox.Normalized(), mbox.Normalized()), nil
+}
+
+// GetBox returns page box `box` of the page, with its default value if not defined.  The boxes other than
+// the media box are clipped to the media box.
+func (this *PdfPage) GetBox(box PageBox) (*PdfRectangle, error) {
+	var rect *PdfRectangle
+	switch box {
+	case PageBoxMedia:
+		return this.GetMediaBox()
+	case PageBoxCrop:
+		return this.GetCropBox()
+	case PageBoxBleed:
+		rect = this.BleedBox
+	case PageBoxTrim:
+		rect = this.TrimBox
+	case PageBoxArt:
+		rect = this.ArtBox
+	default:
+		return nil, fmt.Errorf("Invalid page box %d", int(box))
+	}
+
+	cbox, err := this.GetCropBox()
+	if err != nil {
+		return nil, err
+	}
+	if rect == nil {
+		return cbox, nil
+	}
+	mbox, err := this.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	return intersectRectangles(rect.Normalized(), mbox.Normalized()), nil
+}
+
+// GetBleedBox returns the bleed box of the page.  Defaults to the crop box if not defined.
+func (this *PdfPage) GetBleedBox() (*PdfRectangle, error) {
+	return this.GetBox(PageBoxBleed)
+}
+
+// GetTrimBox returns the trim box of the page.  Defaults to the crop box if not defined.
+func (this *PdfPage) GetTrimBox() (*PdfRectangle, error) {
+	return this.GetBox(PageBoxTrim)
+}
+
+// GetArtBox returns the art box of the page.  Defaults to the crop box if not defined.
+func (this *PdfPage) GetArtBox() (*PdfRectangle, error) {
+	return this.GetBox(PageBoxArt)
+}
+
+// SetBox sets page box `box` of the page to `rect`, or removes it (the default applies) if nil.  The media
+// box cannot be removed.
+func (this *PdfPage) SetBox(box PageBox, rect *PdfRectangle) error {
+	if rect != nil {
+		rect = rect.Normalized()
+		if rect.Width() <= 0 || rect.Height() <= 0 {
+			return fmt.Errorf("Empty %s", box)
+		}
+	}
+
+	switch box {
+	case PageBoxMedia:
+		if rect == nil {
+			return errors.New("MediaBox required")
+		}
+		this.MediaBox = rect
+	case PageBoxCrop:
+		this.CropBox = rect
+	case PageBoxBleed:
+		this.BleedBox = rect
+	case PageBoxTrim:
+		this.TrimBox = rect
+	case PageBoxArt:
+		this.ArtBox = rect
+	default:
+		return fmt.Errorf("Invalid page box %d", int(box))
+	}
+	return nil
+}
+
+// GetUserUnit returns the size of default user space units in multiples of 1/72 inch.  Defaults to 1.
+func (this *PdfPage) GetUserUnit() float64 {
+	if this.UserUnit == nil {
+		return 1
+	}
+	unit, err := getNumberAsFloat(TraceToDirectObject(this.UserUnit))
+	if err != nil || unit <= 0 {
+		common.Log.Debug("Invalid UserUnit (%v)", this.UserUnit)
+		return 1
+	}
+	return unit
+}
+
+// GetDisplayMatrix returns the transformation matrix [a b c d e f] from default user space to the page as
+// displayed: with the page rotation applied and the lower left corner of the crop box at the origin.
+// The user unit is not applied.
+func (this *PdfPage) GetDisplayMatrix() ([]float64, error) {
+	box, err := this.GetCropBox()
+	if err != nil {
+		return nil, err
+	}
+	rotate, err := this.GetRotate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Offsets written as 0 - x to avoid negative zeros in content streams.
+	switch (rotate%360 + 360) % 360 {
+	case 90:
+		return []float64{0, -1, 1, 0, 0 - box.Lly, box.Urx}, nil
+	case 180:
+		return []float64{-1, 0, 0, -1, box.Urx, box.Ury}, nil
+	case 270:
+		return []float64{0, 1, -1, 0, box.Ury, 0 - box.Llx}, nil
+	case 0:
+		return []float64{1, 0, 0, 1, 0 - box.Llx, 0 - box.Lly}, nil
+	}
+	return nil, fmt.Errorf("Invalid page rotation %d", rotate)
+}
+
+// GetVisibleArea returns the visible region of the page as displayed, in points: the crop box with the page
+// rotation and the user unit applied, with the lower left corner at the origin.
+func (this *PdfPage) GetVisibleArea() (*PdfRectangle, error) {
+	box, err := this.GetCropBox()
+	if err != nil {
+		return nil, err
+	}
+	matrix, err := this.GetDisplayMatrix()
+	if err != nil {
+		return nil, err
+	}
+
+	rect := transformRectangle(box, matrix)
+	unit := this.GetUserUnit()
+	return &PdfRectangle{Llx: 0, Lly: 0, Urx: rect.Width() * unit, Ury: rect.Height() * unit}, nil
+}
+
+// Normalize bakes the page rotation into the page: the contents, page boxes and annotations are transformed
+// such that the page appears as before with Rotate 0.  The lower left corner of the crop box is moved to the
+// origin.  Annotation appearances are not rotated.
+func (this *PdfPage) Normalize() error {
+	rotate, err := this.GetRotate()
+	if err != nil {
+		return err
+	}
+	matrix, err := this.GetDisplayMatrix()
+	if err != nil {
+		return err
+	}
+	mbox, err := this.GetMediaBox()
+	if err != nil {
+		return err
+	}
+	cbox, err := this.GetCropBox()
+	if err != nil {
+		return err
+	}
+
+	if rotate%360 != 0 || matrix[4] != 0 || matrix[5] != 0 {
+		cstreams, err := this.GetContentStreams()
+		if err != nil {
+			return err
+		}
+		if len(cstreams) > 0 {
+			prefix := fmt.Sprintf("q\n%.4f %.4f %.4f %.4f %.4f %.4f cm\n",
+				matrix[0], matrix[1], matrix[2], matrix[3], matrix[4], matrix[5])
+			cstreams = append(append([]string{prefix}, cstreams...), "\nQ")
+			if err := this.SetContentStreams(cstreams, NewFlateEncoder()); err != nil {
+				return err
+			}
+		}
+	}
+
+	this.MediaBox = transformRectangle(mbox, matrix)
+	this.CropBox = transformRectangle(cbox, matrix)
+	if this.BleedBox != nil {
+		this.BleedBox = transformRectangle(this.BleedBox, matrix)
+	}
+	if this.TrimBox != nil {
+		this.TrimBox = transformRectangle(this.TrimBox, matrix)
+	}
+	if this.ArtBox != nil {
+		this.ArtBox = transformRectangle(this.ArtBox, matrix)
+	}
+
+	for _, annot := range this.Annotations {
+		transformAnnotation(annot, matrix)
+	}
+
+	zero := int64(0)
+	this.Rotate = &zero
+	return nil
+}
+
+// Width returns the width of the rectangle.
+func (rect *PdfRectangle) Width() float64 {
+	return math.Abs(rect.Urx - rect.Llx)
+}
+
+// Height returns the height of the rectangle.
+func (rect *PdfRectangle) Height() float64 {
+	return math.Abs(rect.Ury - rect.Lly)
+}
+
+// Normalized returns the rectangle with the lower left and upper right corners ordered.
+func (rect *PdfRectangle) Normalized() *PdfRectangle {
+	return &PdfRectangle{
+		Llx: math.Min(rect.Llx, rect.Urx),
+		Lly: math.Min(rect.Lly, rect.Ury),
+		Urx: math.Max(rect.Llx, rect.Urx),
+		Ury: math.Max(rect.Lly, rect.Ury),
+	}
+}
+
+// intersectRectangles returns the intersection of normalized rectangles `a` and `b`, or an empty rectangle
+// at the lower left corner of `b` if they do not intersect.
+func intersectRectangles(a, b *PdfRectangle) *PdfRectangle {
+	rect := &PdfRectangle{
+		Llx: math.Max(a.Llx, b.Llx),
+		Lly: math.Max(a.Lly, b.Lly),
+		Urx: math.Min(a.Urx, b.Urx),
+		Ury: math.Min(a.Ury, b.Ury),
+	}
+	if rect.Llx > rect.Urx || rect.Lly > rect.Ury {
+		return &PdfRectangle{Llx: b.Llx, Lly: b.Lly, Urx: b.Llx, Ury: b.Lly}
+	}
+	return rect
+}
+
+// transformRectangle returns the bounding box of `rect` transformed by `matrix` [a b c d e f].
+func transformRectangle(rect *PdfRectangle, matrix []float64) *PdfRectangle {
+	var result *PdfRectangle
+	for _, p := range [][2]float64{{rect.Llx, rect.Lly}, {rect.Urx, rect.Lly}, {rect.Llx, rect.Ury}, {rect.Urx, rect.Ury}} {
+		x := matrix[0]*p[0] + matrix[2]*p[1] + matrix[4]
+		y := matrix[1]*p[0] + matrix[3]*p[1] + matrix[5]
+		if result == nil {
+			result = &PdfRectangle{Llx: x, Lly: y, Urx: x, Ury: y}
+			continue
+		}
+		result.Llx = math.Min(result.Llx, x)
+		result.Lly = math.Min(result.Lly, y)
+		result.Urx = math.Max(result.Urx, x)
+		result.Ury = math.Max(result.Ury, y)
+	}
+	return result
+}
@@ -0,0 +1,307 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// ResizeMode specifies how the contents of a page are placed on a page of a different size.
+type ResizeMode int
+
+const (
+	// ResizeModeFit scales the contents uniformly to fit the page, centered.
+	ResizeModeFit ResizeMode = iota
+	// ResizeModeFill scales the contents uniformly to fill the page, centered.  Contents outside of the page are
+	// cropped.
+	ResizeModeFill
+	// ResizeModeCenter centers the contents on the page without scaling.
+	ResizeModeCenter
+)
+
+// Resize resizes the page to `width` x `height` points, placing the visible region of the page (see
+// GetVisibleArea) according to `mode`.  The page is normalized first (see Normalize).  The contents, page
+// boxes and annotations are transformed accordingly; destinations referring to the page are not (see
+// PdfEditor.ResizePages).
+func (this *PdfPage) Resize(width, height float64, mode ResizeMode) error {
+	_, err := this.resize(width, height, mode)
+	return err
+}
+
+// resize resizes the page, returning the transformation matrix from the original to the resized page.
+func (this *PdfPage) resize(width, height float64, mode ResizeMode) ([]float64, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("Invalid page size")
+	}
+
+	display, err := this.GetDisplayMatrix()
+	if err != nil {
+		return nil, err
+	}
+	if err := this.Normalize(); err != nil {
+		return nil, err
+	}
+	box, err := this.GetCropBox()
+	if err != nil {
+		return nil, err
+	}
+
+	// The page size in user space units.
+	unit := this.GetUserUnit()
+	width /= unit
+	height /= unit
+
+	var scale float64
+	switch mode {
+	case ResizeModeFit:
+		scale = math.Min(width/box.Width(), height/box.Height())
+	case ResizeModeFill:
+		scale = math.Max(width/box.Width(), height/box.Height())
+	case ResizeModeCenter:
+		scale = 1
+	default:
+		return nil, fmt.Errorf("Invalid resize mode %d", int(mode))
+	}
+	tx := (width-scale*box.Width())/2 - scale*box.Llx
+	ty := (height-scale*box.Height())/2 - scale*box.Lly
+	matrix := []float64{scale, 0, 0, scale, tx, ty}
+
+	cstreams, err := this.GetContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	if len(cstreams) > 0 {
+		prefix := fmt.Sprintf("q\n%.4f 0 0 %.4f %.4f %.4f cm\n", scale, scale, tx, ty)
+		cstreams = append(append([]string{prefix}, cstreams...), "\nQ")
+		if err := this.SetContentStreams(cstreams, NewFlateEncoder()); err != nil {
+			return nil, err
+		}
+	}
+
+	mbox := &PdfRectangle{Llx: 0, Lly: 0, Urx: width, Ury: height}
+	this.MediaBox = mbox
+	this.CropBox = nil
+	if this.BleedBox != nil {
+		this.BleedBox = intersectRectangles(transformRectangle(this.BleedBox, matrix), mbox)
+	}
+	if this.TrimBox != nil {
+		this.TrimBox = intersectRectangles(transformRectangle(this.TrimBox, matrix), mbox)
+	}
+	if this.ArtBox != nil {
+		this.ArtBox = intersectRectangles(transformRectangle(this.ArtBox, matrix), mbox)
+	}
+
+	for _, annot := range this.Annotations {
+		transformAnnotation(annot, matrix)
+	}
+
+	return multiplyMatrices(display, matrix), nil
+}
+
+// ResizePages resizes all pages of the document to `width` x `height` points according to `mode` (see
+// PdfPage.Resize).  The destinations of the outlines, links and named destinations referring to the pages
+// are transformed accordingly.
+func (e *PdfEditor) ResizePages(width, height float64, mode ResizeMode) error {
+	matrices := map[PdfObject][]float64{}
+	for _, page := range e.pages {
+		matrix, err := page.resize(width, height, mode)
+		if err != nil {
+			return err
+		}
+		matrices[page.GetPageAsIndirectObject()] = matrix
+	}
+
+	done := map[PdfObject]bool{}
+	transformAction := func(action PdfObject) {
+		dict, isDict := TraceToDirectObject(action).(*PdfObjectDictionary)
+		if !isDict {
+			return
+		}
+		if s, isName := TraceToDirectObject(dict.Get("S")).(*PdfObjectName); isName && *s == "GoTo" {
+			transformDestination(dict.Get("D"), matrices, done)
+		}
+	}
+
+	var transformItems func(node *PdfOutlineTreeNode)
+	transformItems = func(node *PdfOutlineTreeNode) {
+		for ; node != nil; node = outlineNext(node) {
+			item, isItem := node.context.(*PdfOutlineItem)
+			if !isItem {
+				continue
+			}
+			transformDestination(item.Dest, matrices, done)
+			transformAction(item.A)
+			transformItems(item.First)
+		}
+	}
+	if e.outlineTree != nil {
+		transformItems(e.outlineTree.First)
+	}
+
+	for _, page := range e.pages {
+		for _, annot := range page.Annotations {
+			if link, isLink := annot.GetContext().(*PdfAnnotationLink); isLink {
+				transformDestination(link.Dest, matrices, done)
+				transformAction(link.A)
+			}
+		}
+	}
+
+	for _, dest := range e.dests {
+		if dict, isDict := TraceToDirectObject(dest).(*PdfObjectDictionary); isDict {
+			dest = dict.Get("D")
+		}
+		transformDestination(dest, matrices, done)
+	}
+
+	return nil
+}
+
+// transformDestination transforms the coordinates of explicit destination `dest` ([page /XYZ left top zoom]
+// etc.) by the matrix of its page in `matrices`.  Destinations in `done` are skipped.
+func transformDestination(dest PdfObject, matrices map[PdfObject][]float64, done map[PdfObject]bool) {
+	arr, isArray := TraceToDirectObject(dest).(*PdfObjectArray)
+	if !isArray || len(*arr) < 2 || done[arr] {
+		return
+	}
+	matrix, has := matrices[(*arr)[0]]
+	if !has {
+		return
+	}
+	done[arr] = true
+
+	fitType, isName := TraceToDirectObject((*arr)[1]).(*PdfObjectName)
+	if !isName {
+		return
+	}
+	// Number at index `i`, or 0 and false if null or missing.
+	number := func(i int) (float64, bool) {
+		if i >= len(*arr) {
+			return 0, false
+		}
+		val, err := getNumberAsFloat(TraceToDirectObject((*arr)[i]))
+		return val, err == nil
+	}
+	set := func(i int, val float64, valid bool) {
+		if i < len(*arr) && valid {
+			(*arr)[i] = MakeFloat(val)
+		}
+	}
+	transform := func(x, y float64) (float64, float64) {
+		return matrix[0]*x + matrix[2]*y + matrix[4], matrix[1]*x + matrix[3]*y + matrix[5]
+	}
+
+	switch *fitType {
+	case "XYZ":
+		left, hasLeft := number(2)
+		top, hasTop := number(3)
+		x, y := transform(left, top)
+		set(2, x, hasLeft)
+		set(3, y, hasTop)
+		if zoom, hasZoom := number(4); hasZoom && zoom != 0 {
+			set(4, zoom/math.Hypot(matrix[0], matrix[1]), true)
+		}
+	case "FitH", "FitBH":
+		top, hasTop := number(2)
+		_, y := transform(0, top)
+		set(2, y, hasTop)
+	case "FitV", "FitBV":
+		left, hasLeft := number(2)
+		x, _ := transform(left, 0)
+		set(2, x, hasLeft)
+	case "FitR":
+		l, ok1 := number(2)
+		b, ok2 := number(3)
+		r, ok3 := number(4)
+		t, ok4 := number(5)
+		if ok1 && ok2 && ok3 && ok4 {
+			rect := transformRectangle(&PdfRectangle{Llx: l, Lly: b, Urx: r, Ury: t}, matrix)
+			set(2, rect.Llx, true)
+			set(3, rect.Lly, true)
+			set(4, rect.Urx, true)
+			set(5, rect.Ury, true)
+		}
+	}
+}
+
+// transformAnnotation transforms the rectangle and the coordinates of annotation `annot` by `matrix`.
+// The appearance streams are mapped to the transformed rectangle by viewers.
+func transformAnnotation(annot *PdfAnnotation, matrix []float64) {
+	if arr, ok := TraceToDirectObject(annot.Rect).(*PdfObjectArray); ok {
+		rect, err := NewPdfRectangle(*arr)
+		if err != nil {
+			common.Log.Debug("Invalid annotation rectangle: %v", err)
+		} else {
+			annot.Rect = transformRectangle(rect, matrix).ToPdfObject()
+		}
+	}
+
+	switch t := annot.GetContext().(type) {
+	case *PdfAnnotationLink:
+		t.QuadPoints = transformPoints(t.QuadPoints, matrix)
+	case *PdfAnnotationFreeText:
+		t.CL = transformPoints(t.CL, matrix)
+	case *PdfAnnotationLine:
+		t.L = transformPoints(t.L, matrix)
+	case *PdfAnnotationPolygon:
+		t.Vertices = transformPoints(t.Vertices, matrix)
+	case *PdfAnnotationPolyLine:
+		t.Vertices = transformPoints(t.Vertices, matrix)
+	case *PdfAnnotationHighlight:
+		t.QuadPoints = transformPoints(t.QuadPoints, matrix)
+	case *PdfAnnotationUnderline:
+		t.QuadPoints = transformPoints(t.QuadPoints, matrix)
+	case *PdfAnnotationSquiggly:
+		t.QuadPoints = transformPoints(t.QuadPoints, matrix)
+	case *PdfAnnotationStrikeOut:
+		t.QuadPoints = transformPoints(t.QuadPoints, matrix)
+	case *PdfAnnotationInk:
+		if inkList, ok := TraceToDirectObject(t.InkList).(*PdfObjectArray); ok {
+			list := PdfObjectArray{}
+			for _, path := range *inkList {
+				list = append(list, transformPoints(path, matrix))
+			}
+			t.InkList = &list
+		}
+	}
+}
+
+// transformPoints returns the array of coordinates (x1 y1 x2 y2 ...) `obj` transformed by `matrix`.
+// Returns `obj` unchanged if not an array of numbers.
+func transformPoints(obj PdfObject, matrix []float64) PdfObject {
+	arr, ok := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !ok {
+		return obj
+	}
+	points, err := arr.ToFloat64Array()
+	if err != nil || len(points)%2 != 0 {
+		return obj
+	}
+
+	for i := 0; i < len(points); i += 2 {
+		x, y := points[i], points[i+1]
+		points[i] = matrix[0]*x + matrix[2]*y + matrix[4]
+		points[i+1] = matrix[1]*x + matrix[3]*y + matrix[5]
+	}
+	return MakeArrayFromFloats(points)
+}
+
+// multiplyMatrices returns the matrix transforming by `a`, then by `b`.
+func multiplyMatrices(a, b []float64) []float64 {
+	return []float64{
+		a[0]*b[0] + a[1]*b[2],
+		a[0]*b[1] + a[1]*b[3],
+		a[2]*b[0] + a[3]*b[2],
+		a[2]*b[1] + a[3]*b[3],
+		a[4]*b[0] + a[5]*b[2] + b[4],
+		a[4]*b[1] + a[5]*b[3] + b[5],
+	}
+}
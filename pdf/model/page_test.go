@@ -6,6 +6,7 @@
 package model
 
 import (
+	"strings"
 	"testing"
 
 	. "github.com/unidoc/unidoc/pdf/core"
@@ -308,3 +309,196 @@ func TestRect(t *testing.T) {
 		return
 	}
 }
+
+func TestPageBoxes(t *testing.T) {
+	parent := MakeDict()
+	parent.Set("MediaBox", MakeArrayFromFloats([]float64{0, 0, 600, 800}))
+	parent.Set("CropBox", MakeArrayFromFloats([]float64{-10, 50, 550, 750}))
+
+	page := NewPdfPage()
+	page.Parent = MakeIndirectObject(parent)
+	page.TrimBox = &PdfRectangle{Llx: 500, Lly: 700, Urx: 100, Ury: 100}
+
+	mbox, err := page.GetBox(PageBoxMedia)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if *mbox != (PdfRectangle{0, 0, 600, 800}) {
+		t.Errorf("Invalid inherited media box: %v", *mbox)
+	}
+	// Inherited and clipped to the media box.
+	cbox, err := page.GetCropBox()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if *cbox != (PdfRectangle{0, 50, 550, 750}) {
+		t.Errorf("Invalid crop box: %v", *cbox)
+	}
+	// Defaults to the crop box.
+	bbox, err := page.GetBleedBox()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if *bbox != *cbox {
+		t.Errorf("Invalid bleed box: %v", *bbox)
+	}
+	// Normalized.
+	tbox, err := page.GetTrimBox()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if *tbox != (PdfRectangle{100, 100, 500, 700}) {
+		t.Errorf("Invalid trim box: %v", *tbox)
+	}
+
+	if err := page.SetBox(PageBoxArt, &PdfRectangle{10, 10, 10, 20}); err == nil {
+		t.Errorf("Empty box should fail")
+	}
+	if err := page.SetBox(PageBoxMedia, nil); err == nil {
+		t.Errorf("Removing the media box should fail")
+	}
+	if err := page.SetBox(PageBoxCrop, &PdfRectangle{0, 0, 300, 400}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if abox, _ := page.GetArtBox(); *abox != (PdfRectangle{0, 0, 300, 400}) {
+		t.Errorf("Invalid art box: %v", *abox)
+	}
+}
+
+func TestPageNormalize(t *testing.T) {
+	page := NewPdfPage()
+	page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 200}
+	rotate := int64(90)
+	page.Rotate = &rotate
+	page.UserUnit = MakeFloat(2)
+	page.AddContentStreamByString("0 0 m 100 200 l S")
+	annot := NewPdfAnnotation()
+	annot.Rect = MakeArrayFromFloats([]float64{10, 20, 30, 40})
+	page.Annotations = append(page.Annotations, annot)
+
+	area, err := page.GetVisibleArea()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if *area != (PdfRectangle{0, 0, 400, 200}) {
+		t.Errorf("Invalid visible area: %v", *area)
+	}
+
+	if err := page.Normalize(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if rotate, _ := page.GetRotate(); rotate != 0 {
+		t.Errorf("Rotation not reset: %d", rotate)
+	}
+	if *page.MediaBox != (PdfRectangle{0, 0, 200, 100}) {
+		t.Errorf("Invalid media box: %v", *page.MediaBox)
+	}
+	normalized, err := page.GetVisibleArea()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if *normalized != *area {
+		t.Errorf("Visible area changed: %v", *normalized)
+	}
+
+	// (10,20)-(30,40) rotated clockwise on a page of height 100: x' = y, y' = 100 - x.
+	rect, err := NewPdfRectangle(*annot.Rect.(*PdfObjectArray))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if *rect != (PdfRectangle{20, 70, 40, 90}) {
+		t.Errorf("Invalid annotation rectangle: %v", *rect)
+	}
+
+	content, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.HasPrefix(content, "q\n0.0000 -1.0000 1.0000 0.0000 0.0000 100.0000 cm\n") {
+		t.Errorf("Invalid content: %s", content)
+	}
+}
+
+func TestPageResize(t *testing.T) {
+	makePage := func() (*PdfPage, *PdfAnnotationLink) {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 200}
+		page.AddContentStreamByString("0 0 m 100 200 l S")
+		link := NewPdfAnnotationLink()
+		link.Rect = MakeArrayFromFloats([]float64{10, 20, 30, 40})
+		link.QuadPoints = MakeArrayFromFloats([]float64{10, 20, 30, 20, 30, 40, 10, 40})
+		page.Annotations = append(page.Annotations, link.PdfAnnotation)
+		return page, link
+	}
+
+	for _, test := range []struct {
+		mode     ResizeMode
+		expected PdfRectangle
+	}{
+		{ResizeModeFit, PdfRectangle{60, 20, 80, 40}},
+		{ResizeModeFill, PdfRectangle{20, -60, 60, -20}},
+		{ResizeModeCenter, PdfRectangle{60, 20, 80, 40}},
+	} {
+		page, link := makePage()
+		if err := page.Resize(200, 200, test.mode); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if *page.MediaBox != (PdfRectangle{0, 0, 200, 200}) {
+			t.Errorf("Invalid media box: %v", *page.MediaBox)
+		}
+		rect, err := NewPdfRectangle(*link.Rect.(*PdfObjectArray))
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if *rect != test.expected {
+			t.Errorf("Mode %d: invalid annotation rectangle %v", test.mode, *rect)
+		}
+		points, err := link.QuadPoints.(*PdfObjectArray).ToFloat64Array()
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if points[0] != test.expected.Llx || points[1] != test.expected.Lly {
+			t.Errorf("Mode %d: invalid quad points %v", test.mode, points)
+		}
+	}
+
+	page, _ := makePage()
+	if err := page.Resize(0, 200, ResizeModeFit); err == nil {
+		t.Errorf("Invalid size should fail")
+	}
+}
+
+func TestEditorResizePages(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	editor, err := NewPdfEditor(readTestFile(t, "/tmp/editor_src.pdf"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	page1, _ := editor.GetPage(1)
+	dest := MakeArray(page1.GetPageAsIndirectObject(), MakeName("XYZ"), MakeFloat(10), MakeFloat(20), MakeNull())
+	editor.dests["xyz"] = dest
+
+	if err := editor.ResizePages(400, 400, ResizeModeFit); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := editor.WriteToFile("/tmp/editor_resized.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	out := readTestFile(t, "/tmp/editor_resized.pdf")
+	widths := getPageWidths(t, out)
+	if len(widths) != 3 || widths[0] != 400 || widths[2] != 400 {
+		t.Errorf("Invalid page sizes: %v", widths)
+	}
+
+	// Page 1 (100 x 200) scaled by 2 and centered horizontally.
+	coords, err := getNumbersAsFloat((*dest)[2:4])
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if coords[0] != 120 || coords[1] != 40 {
+		t.Errorf("Invalid destination: %v", coords)
+	}
+	if _, isNull := (*dest)[4].(*PdfObjectNull); !isNull {
+		t.Errorf("Null zoom changed")
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PageLabelStyle is the numbering style of the page labels of a page labelling range (S entry in
+// Table 159).
+type PageLabelStyle string
+
+const (
+	// PageLabelStyleNone labels pages with the prefix only.
+	PageLabelStyleNone PageLabelStyle = ""
+	// PageLabelStyleDecimal numbers pages with decimal arabic numerals: 1, 2, 3.
+	PageLabelStyleDecimal PageLabelStyle = "D"
+	// PageLabelStyleRomanUpper numbers pages with uppercase roman numerals: I, II, III.
+	PageLabelStyleRomanUpper PageLabelStyle = "R"
+	// PageLabelStyleRomanLower numbers pages with lowercase roman numerals: i, ii, iii.
+	PageLabelStyleRomanLower PageLabelStyle = "r"
+	// PageLabelStyleLettersUpper numbers pages with uppercase letters: A to Z, then AA to ZZ, etc.
+	PageLabelStyleLettersUpper PageLabelStyle = "A"
+	// PageLabelStyleLettersLower numbers pages with lowercase letters: a to z, then aa to zz, etc.
+	PageLabelStyleLettersLower PageLabelStyle = "a"
+)
+
+// PdfPageLabelRange represents a page labelling range (12.4.2): the pages from StartPage up to the start of
+// the next range are labelled with Prefix followed by their number in Style, counting from Start.
+type PdfPageLabelRange struct {
+	// StartPage is the index of the first page of the range, starting from 0.
+	StartPage int
+	Style     PageLabelStyle
+	Prefix    string
+	// Start is the number of the first page of the range (1 or more).
+	Start int64
+}
+
+// NewPdfPageLabelRange returns a page labelling range starting at page index `startPage` (starting from 0)
+// with numbering style `style` counting from 1, without prefix.
+func NewPdfPageLabelRange(startPage int, style PageLabelStyle) *PdfPageLabelRange {
+	return &PdfPageLabelRange{StartPage: startPage, Style: style, Start: 1}
+}
+
+// newPdfPageLabelRangeFromObject loads the page labelling range starting at page index `startPage` from page
+// label dictionary `obj`.
+func newPdfPageLabelRangeFromObject(startPage int64, obj PdfObject) (*PdfPageLabelRange, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid page label dictionary (%T)", obj)
+	}
+
+	labelRange := NewPdfPageLabelRange(int(startPage), PageLabelStyleNone)
+	if style, isName := TraceToDirectObject(dict.Get("S")).(*PdfObjectName); isName {
+		labelRange.Style = PageLabelStyle(*style)
+	}
+	if prefix, isString := TraceToDirectObject(dict.Get("P")).(*PdfObjectString); isString {
+		labelRange.Prefix = string(*prefix)
+	}
+	if st, isInt := TraceToDirectObject(dict.Get("St")).(*PdfObjectInteger); isInt && *st >= 1 {
+		labelRange.Start = int64(*st)
+	}
+	return labelRange, nil
+}
+
+// GetLabel returns the label of page `pageIndex` (starting from 0) of the range.
+func (this *PdfPageLabelRange) GetLabel(pageIndex int) string {
+	return this.Prefix + formatPageLabelNumber(this.Style, this.Start+int64(pageIndex-this.StartPage))
+}
+
+// ToPdfObject returns the page label dictionary of the range.
+func (this *PdfPageLabelRange) ToPdfObject() PdfObject {
+	dict := MakeDict()
+	if this.Style != PageLabelStyleNone {
+		dict.Set("S", MakeName(string(this.Style)))
+	}
+	if this.Prefix != "" {
+		dict.Set("P", MakeString(this.Prefix))
+	}
+	if this.Start > 1 {
+		dict.Set("St", MakeInteger(this.Start))
+	}
+	return dict
+}
+
+// formatPageLabelNumber returns page number `num` in numbering style `style`.
+func formatPageLabelNumber(style PageLabelStyle, num int64) string {
+	switch style {
+	case PageLabelStyleDecimal:
+		return strconv.FormatInt(num, 10)
+	case PageLabelStyleRomanUpper:
+		return toRoman(num)
+	case PageLabelStyleRomanLower:
+		return strings.ToLower(toRoman(num))
+	case PageLabelStyleLettersUpper:
+		return toLetters(num)
+	case PageLabelStyleLettersLower:
+		return strings.ToLower(toLetters(num))
+	case PageLabelStyleNone:
+		return ""
+	}
+	common.Log.Debug("ERROR: Invalid page label style %s", style)
+	return strconv.FormatInt(num, 10)
+}
+
+// toRoman returns `num` in uppercase roman numerals.  Thousands are written as repeated M's.
+func toRoman(num int64) string {
+	values := []int64{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+
+	var b strings.Builder
+	for i, value := range values {
+		for num >= value {
+			b.WriteString(symbols[i])
+			num -= value
+		}
+	}
+	return b.String()
+}
+
+// toLetters returns `num` in uppercase letters: A to Z for 1 to 26, AA to ZZ for 27 to 52, etc.
+func toLetters(num int64) string {
+	if num < 1 {
+		return ""
+	}
+	letter := string(rune('A' + (num-1)%26))
+	return strings.Repeat(letter, int((num-1)/26+1))
+}
+
+// getPageLabel returns the label of page `pageIndex` (starting from 0) with the page labelling ranges
+// `ranges` sorted by start page.  Pages before the first range are labelled with their page number.
+func getPageLabel(ranges []*PdfPageLabelRange, pageIndex int) string {
+	idx := sort.Search(len(ranges), func(i int) bool { return ranges[i].StartPage > pageIndex }) - 1
+	if idx < 0 {
+		return strconv.Itoa(pageIndex + 1)
+	}
+	return ranges[idx].GetLabel(pageIndex)
+}
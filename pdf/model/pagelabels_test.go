@@ -0,0 +1,101 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPageLabelNumbers(t *testing.T) {
+	testcases := []struct {
+		style    PageLabelStyle
+		num      int64
+		expected string
+	}{
+		{PageLabelStyleDecimal, 12, "12"},
+		{PageLabelStyleRomanUpper, 1994, "MCMXCIV"},
+		{PageLabelStyleRomanLower, 4, "iv"},
+		{PageLabelStyleRomanLower, 49, "xlix"},
+		{PageLabelStyleLettersUpper, 1, "A"},
+		{PageLabelStyleLettersUpper, 26, "Z"},
+		{PageLabelStyleLettersUpper, 27, "AA"},
+		{PageLabelStyleLettersLower, 54, "bbb"},
+		{PageLabelStyleNone, 3, ""},
+	}
+	for _, tcase := range testcases {
+		if label := formatPageLabelNumber(tcase.style, tcase.num); label != tcase.expected {
+			t.Errorf("%q %d: %q != %q", tcase.style, tcase.num, label, tcase.expected)
+		}
+	}
+}
+
+// writeLabelTestFile writes a document with `numPages` pages and page labelling ranges `ranges` to `path`.
+func writeLabelTestFile(t *testing.T, path string, numPages int, ranges []*PdfPageLabelRange) {
+	writer := NewPdfWriter()
+	for i := 0; i < numPages; i++ {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+		page.Resources = NewPdfPageResources()
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	if err := writer.SetPageLabelRanges(ranges); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func TestPageLabels(t *testing.T) {
+	writeLabelTestFile(t, "/tmp/page_labels.pdf", 7, []*PdfPageLabelRange{
+		NewPdfPageLabelRange(0, PageLabelStyleRomanLower),
+		{StartPage: 2, Style: PageLabelStyleDecimal, Prefix: "A-", Start: 11},
+		{StartPage: 5, Prefix: "Cover"},
+		NewPdfPageLabelRange(6, PageLabelStyleLettersUpper),
+	})
+
+	reader := readTestFile(t, "/tmp/page_labels.pdf")
+	labels, err := reader.GetPageLabels()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := []string{"i", "ii", "A-11", "A-12", "A-13", "Cover", "A"}
+	if len(labels) != len(expected) {
+		t.Fatalf("Invalid labels: %v", labels)
+	}
+	for i := range expected {
+		if labels[i] != expected[i] {
+			t.Fatalf("Invalid labels: %v", labels)
+		}
+	}
+
+	ranges, err := reader.GetPageLabelRanges()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(ranges) != 4 || ranges[1].StartPage != 2 || ranges[1].Start != 11 || ranges[1].Prefix != "A-" {
+		t.Errorf("Invalid ranges: %v", ranges)
+	}
+
+	// Without page labels, pages are labelled with their page number.
+	writeLabelTestFile(t, "/tmp/no_page_labels.pdf", 3, nil)
+	reader = readTestFile(t, "/tmp/no_page_labels.pdf")
+	labels, err = reader.GetPageLabels()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(labels) != 3 || labels[0] != "1" || labels[2] != "3" {
+		t.Errorf("Invalid labels: %v", labels)
+	}
+}
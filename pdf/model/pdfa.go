@@ -0,0 +1,664 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PdfAConformance is a PDF/A (ISO 19005) conformance level of archival output.
+type PdfAConformance string
+
+const (
+	PdfAConformanceNone PdfAConformance = ""
+	PdfAConformanceA1B  PdfAConformance = "PDF/A-1b"
+	PdfAConformanceA2B  PdfAConformance = "PDF/A-2b"
+	PdfAConformanceA3B  PdfAConformance = "PDF/A-3b"
+)
+
+// Part returns the part of ISO 19005 of the conformance level (pdfaid:part), 0 if none.
+func (conformance PdfAConformance) Part() int {
+	switch conformance {
+	case PdfAConformanceA1B:
+		return 1
+	case PdfAConformanceA2B:
+		return 2
+	case PdfAConformanceA3B:
+		return 3
+	}
+	return 0
+}
+
+// Level returns the conformance level within the part (pdfaid:conformance), e.g. "B".
+func (conformance PdfAConformance) Level() string {
+	if conformance.Part() == 0 {
+		return ""
+	}
+	return "B"
+}
+
+// PdfAError is returned when writing PDF/A output that violates requirements of the conformance level which
+// cannot be fixed automatically, such as fonts that are not embedded.
+type PdfAError struct {
+	Conformance PdfAConformance
+	Violations  []string
+}
+
+func (err *PdfAError) Error() string {
+	return fmt.Sprintf("Output not %s conformant: %s", err.Conformance, strings.Join(err.Violations, "; "))
+}
+
+// PdfAForbiddenActions are the action types that are not allowed in PDF/A, with the first part forbidding
+// them.
+var PdfAForbiddenActions = map[string]int{
+	"Launch": 1, "Sound": 1, "Movie": 1, "ResetForm": 1, "ImportData": 1, "JavaScript": 1,
+	"Hide": 2, "SetOCGState": 2, "Rendition": 2, "Trans": 2, "GoTo3DView": 2,
+}
+
+// pdfAChecker collects the violations of a PDF/A conformance level in the objects of the output.
+type pdfAChecker struct {
+	conformance PdfAConformance
+	violations  []string
+	found       map[string]bool
+
+	// Substitutes of the non-embedded fonts by name, and their font dictionaries used for replacing fonts.
+	fonts       map[string]*PdfFont
+	substituted map[string]*PdfObjectDictionary
+}
+
+// addViolation adds a violation described by `format` and `args`, once.
+func (checker *pdfAChecker) addViolation(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if checker.found[msg] {
+		return
+	}
+	checker.found[msg] = true
+	checker.violations = append(checker.violations, msg)
+}
+
+// checkObject checks object `obj` of the output and the direct objects it contains.  The annotation flags
+// are fixed to be printable.
+func (checker *pdfAChecker) checkObject(obj PdfObject) {
+	switch t := obj.(type) {
+	case *PdfIndirectObject:
+		checker.checkObject(t.PdfObject)
+	case *PdfObjectStream:
+		checker.checkStream(t)
+		checker.checkDict(t.PdfObjectDictionary)
+	case *PdfObjectDictionary:
+		checker.checkDict(t)
+		for _, key := range t.Keys() {
+			checker.checkDirectObject(t.Get(key))
+		}
+	case *PdfObjectArray:
+		for _, o := range *t {
+			checker.checkDirectObject(o)
+		}
+	}
+}
+
+// checkDirectObject checks `obj` if it is a direct dictionary or array.  Indirect objects are checked as
+// objects of the output.
+func (checker *pdfAChecker) checkDirectObject(obj PdfObject) {
+	switch obj.(type) {
+	case *PdfObjectDictionary, *PdfObjectArray:
+		checker.checkObject(obj)
+	}
+}
+
+// checkStream checks the filters, embedded files, image color spaces and form and pattern contents of a
+// stream.
+func (checker *pdfAChecker) checkStream(stream *PdfObjectStream) {
+	var filters []PdfObject
+	switch f := TraceToDirectObject(stream.Get("Filter")).(type) {
+	case *PdfObjectName:
+		filters = append(filters, f)
+	case *PdfObjectArray:
+		filters = *f
+	}
+	for _, filter := range filters {
+		if name, ok := TraceToDirectObject(filter).(*PdfObjectName); ok && *name == "LZWDecode" {
+			checker.addViolation("LZW compression is not allowed")
+		}
+	}
+
+	if t, ok := stream.Get("Type").(*PdfObjectName); ok && *t == "EmbeddedFile" {
+		switch checker.conformance.Part() {
+		case 1:
+			checker.addViolation("Embedded files are not allowed")
+		case 2:
+			if subtype, ok := stream.Get("Subtype").(*PdfObjectName); !ok || *subtype != "application/pdf" {
+				checker.addViolation("Embedded files must be PDF/A documents")
+			}
+		}
+	}
+	if isDeviceCMYK(stream.Get("ColorSpace")) {
+		checker.addViolation("DeviceCMYK images are not allowed with an sRGB output intent")
+	}
+
+	subtype, _ := TraceToDirectObject(stream.Get("Subtype")).(*PdfObjectName)
+	patternType, _ := TraceToDirectObject(stream.Get("PatternType")).(*PdfObjectInteger)
+	if (subtype != nil && *subtype == "Form") || (patternType != nil && *patternType == 1) {
+		data, err := DecodeStream(stream)
+		if err != nil {
+			common.Log.Debug("ERROR: Unable to decode content stream: %v", err)
+			return
+		}
+		resources, _ := TraceToDirectObject(stream.Get("Resources")).(*PdfObjectDictionary)
+		checker.checkContent(data, resources)
+	}
+}
+
+// checkPageContents checks the content streams of page dictionary `dict`.
+func (checker *pdfAChecker) checkPageContents(dict *PdfObjectDictionary) {
+	var contents []PdfObject
+	switch t := TraceToDirectObject(dict.Get("Contents")).(type) {
+	case *PdfObjectStream:
+		contents = append(contents, t)
+	case *PdfObjectArray:
+		contents = *t
+	}
+
+	var data []byte
+	for _, obj := range contents {
+		stream, ok := TraceToDirectObject(obj).(*PdfObjectStream)
+		if !ok {
+			continue
+		}
+		decoded, err := DecodeStream(stream)
+		if err != nil {
+			common.Log.Debug("ERROR: Unable to decode content stream: %v", err)
+			continue
+		}
+		data = append(append(data, decoded...), '\n')
+	}
+	resources, _ := TraceToDirectObject(dict.Get("Resources")).(*PdfObjectDictionary)
+	checker.checkContent(data, resources)
+}
+
+// checkContent checks content stream `data` with resources `resources` for DeviceCMYK colors, which are not
+// allowed with an sRGB output intent.  The operators are scanned without the content stream parser, which
+// depends on this package.
+func (checker *pdfAChecker) checkContent(data []byte, resources *PdfObjectDictionary) {
+	var colorspaces *PdfObjectDictionary
+	if resources != nil {
+		colorspaces, _ = TraceToDirectObject(resources.Get("ColorSpace")).(*PdfObjectDictionary)
+	}
+	// isCMYK checks whether the color space named `name` in the content stream is DeviceCMYK.
+	isCMYK := func(name string) bool {
+		if name == "DeviceCMYK" || name == "CMYK" {
+			return true
+		}
+		return colorspaces != nil && isDeviceCMYK(colorspaces.Get(PdfObjectName(name)))
+	}
+
+	var operands []string
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case IsWhiteSpace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\r' && data[i] != '\n' {
+				i++
+			}
+		case c == '(':
+			i = skipContentString(data, i)
+			operands = append(operands, "")
+		case c == '<' && i+1 < len(data) && data[i+1] == '<':
+			i += 2
+		case c == '<':
+			for i < len(data) && data[i] != '>' {
+				i++
+			}
+			i++
+			operands = append(operands, "")
+		case c != '/' && IsDelimiter(c):
+			// Array and dictionary delimiters.
+			i++
+		default:
+			start := i
+			for i++; i < len(data) && !IsWhiteSpace(data[i]) && !IsDelimiter(data[i]); i++ {
+			}
+			token := string(data[start:i])
+			if c == '/' || IsFloatDigit(c) || c == '+' || c == '-' {
+				operands = append(operands, token)
+				continue
+			}
+
+			switch token {
+			case "k", "K":
+				checker.addViolation("DeviceCMYK colors are not allowed with an sRGB output intent")
+			case "cs", "CS":
+				if len(operands) > 0 && isCMYK(strings.TrimPrefix(operands[len(operands)-1], "/")) {
+					checker.addViolation("DeviceCMYK colors are not allowed with an sRGB output intent")
+				}
+			case "BI":
+				var cs string
+				cs, i = scanInlineImage(data, i)
+				if isCMYK(strings.TrimPrefix(cs, "/")) {
+					checker.addViolation("DeviceCMYK images are not allowed with an sRGB output intent")
+				}
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+// skipContentString returns the position after the literal string starting at position `i` of `data`.
+func skipContentString(data []byte, i int) int {
+	depth := 0
+	for ; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// scanInlineImage scans the inline image following the BI operator at position `i` of `data`.  Returns the
+// color space entry (CS or ColorSpace) of the image dictionary and the position after the EI operator.
+func scanInlineImage(data []byte, i int) (string, int) {
+	var cs, prev string
+	for i < len(data) {
+		if IsWhiteSpace(data[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i++; i < len(data) && !IsWhiteSpace(data[i]) && !IsDelimiter(data[i]); i++ {
+		}
+		token := string(data[start:i])
+		if token == "ID" {
+			// The image data follows a single white-space character and ends at EI.
+			for j := i + 1; j+1 < len(data); j++ {
+				if data[j] == 'E' && data[j+1] == 'I' && IsWhiteSpace(data[j-1]) &&
+					(j+2 == len(data) || IsWhiteSpace(data[j+2]) || IsDelimiter(data[j+2])) {
+					return cs, j + 2
+				}
+			}
+			return cs, len(data)
+		}
+		if prev == "/CS" || prev == "/ColorSpace" {
+			cs = token
+		}
+		prev = token
+	}
+	return cs, i
+}
+
+// isDeviceCMYK checks whether color space `obj` is DeviceCMYK or indexed in DeviceCMYK.
+func isDeviceCMYK(obj PdfObject) bool {
+	switch t := TraceToDirectObject(obj).(type) {
+	case *PdfObjectName:
+		return *t == "DeviceCMYK"
+	case *PdfObjectArray:
+		if len(*t) < 2 {
+			return false
+		}
+		if name, ok := TraceToDirectObject((*t)[0]).(*PdfObjectName); ok && (*name == "Indexed" || *name == "I") {
+			return isDeviceCMYK((*t)[1])
+		}
+	}
+	return false
+}
+
+// checkDict checks the fonts, page contents, actions, annotations and transparency of dictionary `dict`.
+func (checker *pdfAChecker) checkDict(dict *PdfObjectDictionary) {
+	t, _ := TraceToDirectObject(dict.Get("Type")).(*PdfObjectName)
+	subtype, _ := TraceToDirectObject(dict.Get("Subtype")).(*PdfObjectName)
+
+	if t != nil && *t == "Font" && subtype != nil && *subtype != "Type0" && *subtype != "Type3" {
+		embedded := false
+		if descriptor, ok := TraceToDirectObject(dict.Get("FontDescriptor")).(*PdfObjectDictionary); ok {
+			embedded = descriptor.Get("FontFile") != nil || descriptor.Get("FontFile2") != nil ||
+				descriptor.Get("FontFile3") != nil
+		}
+		if !embedded {
+			name := "(unnamed)"
+			if baseFont, ok := TraceToDirectObject(dict.Get("BaseFont")).(*PdfObjectName); ok {
+				name = string(*baseFont)
+			}
+			if !checker.substituteFont(dict, name) {
+				checker.addViolation("Font %s is not embedded", name)
+			}
+		}
+	}
+
+	if t != nil && *t == "Page" {
+		checker.checkPageContents(dict)
+	}
+
+	if s, ok := TraceToDirectObject(dict.Get("S")).(*PdfObjectName); ok && (t == nil || *t == "Action") {
+		if part, forbidden := PdfAForbiddenActions[string(*s)]; forbidden && checker.conformance.Part() >= part {
+			checker.addViolation("%s actions are not allowed", *s)
+		}
+	}
+	if dict.Get("JS") != nil {
+		checker.addViolation("JavaScript actions are not allowed")
+	}
+
+	if t != nil && *t == "Annot" && (subtype == nil || *subtype != "Popup") {
+		flags := int64(0)
+		if f, ok := TraceToDirectObject(dict.Get("F")).(*PdfObjectInteger); ok {
+			flags = int64(*f)
+		}
+		// Invisible (1), Hidden (2), NoView (6).
+		if flags&(1|2|32) != 0 {
+			checker.addViolation("Hidden annotations are not allowed")
+		}
+		// Print (3).
+		dict.Set("F", MakeInteger(flags|4))
+	}
+
+	if checker.conformance.Part() == 1 {
+		checker.checkTransparency(dict)
+	}
+}
+
+// substituteFont replaces the entries of non-embedded font dictionary `dict` named `name` with those of the
+// substitute font of the name, if any.  Only WinAnsi encoded fonts are replaced, as the substitutes are.
+// Returns true if replaced.
+func (checker *pdfAChecker) substituteFont(dict *PdfObjectDictionary, name string) bool {
+	font, has := checker.fonts[name]
+	if !has {
+		return false
+	}
+	if encoding, ok := TraceToDirectObject(dict.Get("Encoding")).(*PdfObjectName); !ok || *encoding != "WinAnsiEncoding" {
+		return false
+	}
+
+	fontDict, has := checker.substituted[name]
+	if !has {
+		fontDict, has = TraceToDirectObject(font.ToPdfObject()).(*PdfObjectDictionary)
+		if !has {
+			return false
+		}
+		checker.substituted[name] = fontDict
+	}
+	for _, key := range dict.Keys() {
+		dict.Remove(key)
+	}
+	for _, key := range fontDict.Keys() {
+		dict.Set(key, fontDict.Get(key))
+	}
+	return true
+}
+
+// checkTransparency checks the transparency of dictionary `dict`, not allowed in PDF/A-1.
+func (checker *pdfAChecker) checkTransparency(dict *PdfObjectDictionary) {
+	if smask := TraceToDirectObject(dict.Get("SMask")); smask != nil {
+		if name, ok := smask.(*PdfObjectName); !ok || *name != "None" {
+			checker.addViolation("Transparency (soft masks) is not allowed")
+		}
+	}
+	for _, key := range []PdfObjectName{"CA", "ca"} {
+		if alpha, err := getNumberAsFloat(TraceToDirectObject(dict.Get(key))); err == nil && alpha < 1 {
+			checker.addViolation("Transparency (constant alpha) is not allowed")
+		}
+	}
+	if bm, ok := TraceToDirectObject(dict.Get("BM")).(*PdfObjectName); ok && *bm != "Normal" && *bm != "Compatible" {
+		checker.addViolation("Transparency (blend mode %s) is not allowed", *bm)
+	}
+	if group, ok := TraceToDirectObject(dict.Get("Group")).(*PdfObjectDictionary); ok {
+		if s, ok := TraceToDirectObject(group.Get("S")).(*PdfObjectName); ok && *s == "Transparency" {
+			checker.addViolation("Transparency groups are not allowed")
+		}
+	}
+}
+
+// SetPdfAConformance sets the PDF/A conformance level of the output, or PdfAConformanceNone to disable it.
+// When writing PDF/A output, the XMP metadata identifies the conformance level, an sRGB output intent and a
+// document ID are added, annotations are made printable and the PDF version is set to 1.4 for PDF/A-1.
+// Embedded files are associated with the document for PDF/A-3.  Write fails with a *PdfAError listing the
+// violations that cannot be fixed: fonts that are not embedded and have no substitute (see
+// SetPdfAFontSubstitute), encryption, JavaScript and other forbidden actions, LZW compression, DeviceCMYK
+// images and colors, embedded files in PDF/A-1 and PDF/A-2 (except PDF files), and transparency and optional content in
+// PDF/A-1.
+func (this *PdfWriter) SetPdfAConformance(conformance PdfAConformance) {
+	this.pdfaConformance = conformance
+}
+
+// SetPdfAFontSubstitute sets embedded font `font` (e.g. loaded with NewPdfFontFromTTFFile) to replace the
+// non-embedded WinAnsi encoded fonts named `name`, e.g. the standard font "Helvetica" or the font of the
+// watermark of unlicensed copies, in PDF/A output.  The text is drawn with the glyph widths of the
+// substitute, so a font with the same metrics keeps the layout, e.g. Liberation Sans for Helvetica.
+func (this *PdfWriter) SetPdfAFontSubstitute(name string, font *PdfFont) {
+	if this.pdfaFonts == nil {
+		this.pdfaFonts = map[string]*PdfFont{}
+	}
+	this.pdfaFonts[name] = font
+}
+
+// preparePdfA fixes the output for the PDF/A conformance level and returns a *PdfAError with the
+// violations that cannot be fixed.
+func (this *PdfWriter) preparePdfA() error {
+	conformance := this.pdfaConformance
+	checker := &pdfAChecker{
+		conformance: conformance,
+		found:       map[string]bool{},
+		fonts:       this.pdfaFonts,
+		substituted: map[string]*PdfObjectDictionary{},
+	}
+
+	if this.crypter != nil {
+		checker.addViolation("Encryption is not allowed")
+	}
+	if conformance.Part() == 1 {
+		if this.catalog.Get("OCProperties") != nil {
+			checker.addViolation("Optional content is not allowed")
+		}
+		if len(this.embeddedFiles) > 0 || len(this.associatedFiles) > 0 {
+			checker.addViolation("Embedded files are not allowed")
+		}
+	}
+	if names, ok := TraceToDirectObject(this.catalog.Get("Names")).(*PdfObjectDictionary); ok {
+		if names.Get("JavaScript") != nil {
+			checker.addViolation("JavaScript actions are not allowed")
+		}
+	}
+
+	// Embedded files of PDF/A-3 must be associated files with a MIME type.
+	if conformance.Part() == 3 {
+		for _, spec := range this.embeddedFiles {
+			if spec.AFRelationship == "" {
+				spec.AFRelationship = AFRelationshipUnspecified
+			}
+			if spec.Subtype == "" {
+				spec.Subtype = "application/octet-stream"
+			}
+			associated := false
+			for _, af := range this.associatedFiles {
+				if af == spec {
+					associated = true
+					break
+				}
+			}
+			if !associated {
+				this.AddAssociatedFile(spec)
+			}
+		}
+	}
+	for _, spec := range this.embeddedFiles {
+		spec.ToPdfObject()
+		checker.checkObject(spec.stream)
+	}
+	for _, obj := range this.objects {
+		checker.checkObject(obj)
+	}
+	// The widths and font descriptors of the substitute fonts.
+	var substituted []string
+	for name := range checker.substituted {
+		substituted = append(substituted, name)
+	}
+	sort.Strings(substituted)
+	for _, name := range substituted {
+		if err := this.addObjects(checker.substituted[name]); err != nil {
+			return err
+		}
+	}
+
+	if len(checker.violations) > 0 {
+		return &PdfAError{Conformance: conformance, Violations: checker.violations}
+	}
+
+	if this.xmp == nil {
+		this.xmp = NewXmpMetadata()
+	}
+	this.xmp.PdfAPart = conformance.Part()
+	this.xmp.PdfAConformance = conformance.Level()
+
+	if this.catalog.Get("OutputIntents") == nil {
+		outputIntent, err := newSRGBOutputIntent()
+		if err != nil {
+			return err
+		}
+		outputIntents := MakeArray(outputIntent)
+		this.catalog.Set("OutputIntents", outputIntents)
+		if err := this.addObjects(outputIntents); err != nil {
+			return err
+		}
+	}
+
+	if this.ids == nil {
+		this.generateIDs()
+	}
+	if conformance.Part() == 1 {
+		this.SetVersion(1, 4)
+	}
+	return nil
+}
+
+// newSRGBOutputIntent returns a PDF/A output intent dictionary with an sRGB ICC profile.
+func newSRGBOutputIntent() (*PdfObjectDictionary, error) {
+	profile, err := MakeStream(makeSRGBProfile(), NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	profile.Set("N", MakeInteger(3))
+
+	outputIntent := MakeDict()
+	outputIntent.Set("Type", MakeName("OutputIntent"))
+	outputIntent.Set("S", MakeName("GTS_PDFA1"))
+	outputIntent.Set("OutputConditionIdentifier", MakeString("sRGB IEC61966-2.1"))
+	outputIntent.Set("RegistryName", MakeString("http://www.color.org"))
+	outputIntent.Set("Info", MakeString("sRGB IEC61966-2.1"))
+	outputIntent.Set("DestOutputProfile", profile)
+	return outputIntent, nil
+}
+
+// makeSRGBProfile returns an ICC version 2 display profile of the sRGB color space (IEC 61966-2.1), with
+// the colorants adapted to the D50 illuminant of the profile connection space.
+func makeSRGBProfile() []byte {
+	s15Fixed16 := func(vals ...float64) []byte {
+		b := make([]byte, 4*len(vals))
+		for i, val := range vals {
+			binary.BigEndian.PutUint32(b[4*i:], uint32(int32(math.Floor(val*65536+0.5))))
+		}
+		return b
+	}
+	typeHeader := func(sig string) []byte {
+		return append([]byte(sig), 0, 0, 0, 0)
+	}
+	xyz := func(x, y, z float64) []byte {
+		return append(typeHeader("XYZ "), s15Fixed16(x, y, z)...)
+	}
+
+	desc := "sRGB IEC61966-2.1"
+	descData := typeHeader("desc")
+	descData = append(descData, 0, 0, 0, byte(len(desc)+1))
+	descData = append(descData, desc...)
+	// Null terminator, empty Unicode and ScriptCode descriptions.
+	descData = append(descData, make([]byte, 1+8+3+67)...)
+
+	textData := append(typeHeader("text"), "No copyright, use freely"...)
+	textData = append(textData, 0)
+
+	// Tone reproduction curve of sRGB.
+	const curvePoints = 1024
+	curveData := typeHeader("curv")
+	curveData = append(curveData, 0, 0, byte(curvePoints>>8), byte(curvePoints&0xff))
+	for i := 0; i < curvePoints; i++ {
+		v := float64(i) / (curvePoints - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		val := uint16(math.Floor(v*65535 + 0.5))
+		curveData = append(curveData, byte(val>>8), byte(val&0xff))
+	}
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", descData},
+		{"cprt", textData},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", xyz(0.4360747, 0.2225045, 0.0139322)},
+		{"gXYZ", xyz(0.3850649, 0.7168786, 0.0971045)},
+		{"bXYZ", xyz(0.1430804, 0.0606169, 0.7141733)},
+		{"rTRC", curveData},
+		{"gTRC", curveData},
+		{"bTRC", curveData},
+	}
+
+	// Tag data follows the header and tag table, 4-byte aligned.  The TRC tags share the same data.
+	var data bytes.Buffer
+	table := make([]byte, 4+12*len(tags))
+	binary.BigEndian.PutUint32(table, uint32(len(tags)))
+	offset := 128 + len(table)
+	offsets := map[string]int{}
+	for i, tag := range tags {
+		tagOffset, shared := offsets[string(tag.data)]
+		if !shared {
+			tagOffset = offset + data.Len()
+			offsets[string(tag.data)] = tagOffset
+			data.Write(tag.data)
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+		}
+		entry := table[4+12*i:]
+		copy(entry, tag.sig)
+		binary.BigEndian.PutUint32(entry[4:], uint32(tagOffset))
+		binary.BigEndian.PutUint32(entry[8:], uint32(len(tag.data)))
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header, uint32(offset+data.Len()))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000) // Version 2.1.
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	// Date and time: 2018-01-01 00:00:00.
+	binary.BigEndian.PutUint16(header[24:], 2018)
+	binary.BigEndian.PutUint16(header[26:], 1)
+	binary.BigEndian.PutUint16(header[28:], 1)
+	copy(header[36:], "acsp")
+	// Perceptual rendering intent, D50 illuminant.
+	copy(header[68:], s15Fixed16(0.9642, 1.0, 0.8249))
+
+	profile := append(header, table...)
+	return append(profile, data.Bytes()...)
+}
@@ -0,0 +1,250 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+func TestSRGBProfile(t *testing.T) {
+	profile := makeSRGBProfile()
+	if int(binary.BigEndian.Uint32(profile)) != len(profile) {
+		t.Errorf("Invalid profile size %d != %d", binary.BigEndian.Uint32(profile), len(profile))
+	}
+	if string(profile[12:24]) != "mntrRGB XYZ " || string(profile[36:40]) != "acsp" {
+		t.Errorf("Invalid profile header")
+	}
+	numTags := int(binary.BigEndian.Uint32(profile[128:]))
+	if numTags != 9 {
+		t.Fatalf("Invalid number of tags: %d", numTags)
+	}
+	for i := 0; i < numTags; i++ {
+		entry := profile[132+12*i:]
+		offset, size := binary.BigEndian.Uint32(entry[4:]), binary.BigEndian.Uint32(entry[8:])
+		if offset%4 != 0 || int(offset+size) > len(profile) {
+			t.Errorf("Invalid tag %s: offset %d size %d", entry[:4], offset, size)
+		}
+	}
+}
+
+func TestPdfAViolations(t *testing.T) {
+	newWriter := func() PdfWriter {
+		// No pages: the watermark of unlicensed copies uses a standard font.
+		writer := NewPdfWriter()
+		gs := MakeDict()
+		gs.Set("Type", MakeName("ExtGState"))
+		gs.Set("ca", MakeFloat(0.5))
+		writer.addObject(MakeIndirectObject(gs))
+		action := MakeDict()
+		action.Set("S", MakeName("JavaScript"))
+		action.Set("JS", MakeString("app.alert('Hello');"))
+		writer.catalog.Set("OpenAction", action)
+		return writer
+	}
+
+	writer := newWriter()
+	writer.SetPdfAConformance(PdfAConformanceA1B)
+	if err := writer.Encrypt([]byte("user"), []byte("owner"), nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	err := writer.Write(&bufferWriteSeeker{})
+	pdfaErr, ok := err.(*PdfAError)
+	if !ok {
+		t.Fatalf("Expected PDF/A error, got %v", err)
+	}
+	expected := []string{
+		"Encryption is not allowed",
+		"JavaScript actions are not allowed",
+		"Transparency (constant alpha) is not allowed",
+	}
+	if len(pdfaErr.Violations) != len(expected) {
+		t.Fatalf("Invalid violations: %v", pdfaErr.Violations)
+	}
+	for i, violation := range expected {
+		if pdfaErr.Violations[i] != violation {
+			t.Errorf("Violation %d: %q != %q", i, pdfaErr.Violations[i], violation)
+		}
+	}
+
+	// Transparency is allowed in PDF/A-2.
+	writer = newWriter()
+	writer.SetPdfAConformance(PdfAConformanceA2B)
+	err = writer.Write(&bufferWriteSeeker{})
+	if pdfaErr, ok := err.(*PdfAError); !ok || len(pdfaErr.Violations) != 1 {
+		t.Errorf("Invalid violations: %v", err)
+	}
+}
+
+func TestPdfADeviceCMYK(t *testing.T) {
+	resources := MakeDict()
+	colorspaces := MakeDict()
+	colorspaces.Set("CS0", MakeName("DeviceCMYK"))
+	lookup := MakeString("\x00\x00\x00\xff")
+	colorspaces.Set("CS1", MakeArray(MakeName("Indexed"), MakeName("DeviceCMYK"), MakeInteger(0), lookup))
+	colorspaces.Set("CS2", MakeName("DeviceRGB"))
+	resources.Set("ColorSpace", colorspaces)
+
+	for content, violation := range map[string]bool{
+		"1 0 0 rg 0 0 10 10 re f 0.5 g": false,
+		"0 0 0 1 k 0 0 10 10 re f":      true,
+		"q 0 0 0 1 K Q":                 true,
+		"/DeviceCMYK cs 0 0 0 1 sc":     true,
+		"/CS0 CS 0 0 0 1 SC":            true,
+		"/CS1 cs 0 sc":                  true,
+		"/CS2 cs 1 0 0 sc":              false,
+		"BT (k K) Tj <6b> Tj ET /Span << /K 1 >> BDC EMC % k":  false,
+		"BI /W 1 /H 1 /CS /CMYK /BPC 8 ID \x00\x00\x00\xff EI": true,
+		"BI /W 1 /H 1 /CS /G /BPC 8 ID k EI 0 g":               false,
+		"BI /W 1 /H 1 /ColorSpace /CS0 /BPC 8 ID 0000 EI 0 g":  true,
+	} {
+		checker := &pdfAChecker{found: map[string]bool{}}
+		checker.checkContent([]byte(content), resources)
+		if (len(checker.violations) > 0) != violation {
+			t.Errorf("%q: violations %v", content, checker.violations)
+		}
+	}
+
+	// Page and form contents are checked.
+	form, err := MakeStream([]byte("0 0 0 1 k"), nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	form.Set("Subtype", MakeName("Form"))
+	checker := &pdfAChecker{found: map[string]bool{}}
+	checker.checkObject(form)
+	if len(checker.violations) != 1 {
+		t.Errorf("Invalid form violations: %v", checker.violations)
+	}
+	contents, err := MakeStream([]byte("/CS0 cs 1 0 0 0 sc"), nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	page := MakeDict()
+	page.Set("Type", MakeName("Page"))
+	page.Set("Contents", MakeArray(contents))
+	page.Set("Resources", resources)
+	checker = &pdfAChecker{found: map[string]bool{}}
+	checker.checkObject(page)
+	if len(checker.violations) != 1 {
+		t.Errorf("Invalid page violations: %v", checker.violations)
+	}
+}
+
+func TestPdfAFontSubstitute(t *testing.T) {
+	roboto, err := NewPdfFontFromTTFFile("../../testfiles/roboto/Roboto-Regular.ttf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	writer := NewPdfWriter()
+	page := NewPdfPage()
+	page.Resources = NewPdfPageResources()
+	page.Resources.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+	page.Resources.SetFontByName("F2", fonts.NewFontCourier().ToPdfObject())
+	page.AddContentStreamByString("BT /F1 12 Tf 10 10 Td (Archived) Tj /F2 12 Tf (Code) Tj ET")
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.SetPdfAConformance(PdfAConformanceA2B)
+	writer.SetPdfAFontSubstitute("Helvetica", roboto)
+	err = writer.Write(&bufferWriteSeeker{})
+	if pdfaErr, ok := err.(*PdfAError); !ok || len(pdfaErr.Violations) != 1 ||
+		pdfaErr.Violations[0] != "Font Courier is not embedded" {
+		t.Fatalf("Invalid violations: %v", err)
+	}
+
+	// Helvetica, also used by the watermark of unlicensed copies, is replaced by the embedded substitute.
+	writer = NewPdfWriter()
+	page = NewPdfPage()
+	page.Resources = NewPdfPageResources()
+	page.Resources.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+	page.AddContentStreamByString("BT /F1 12 Tf 10 10 Td (Archived) Tj ET")
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.SetPdfAConformance(PdfAConformanceA2B)
+	writer.SetPdfAFontSubstitute("Helvetica", roboto)
+	f, err := os.Create("/tmp/pdfa_fonts.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/pdfa_fonts.pdf")
+	outPage, err := reader.GetPage(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	fontObj, _ := outPage.Resources.GetFontByName("F1")
+	fontDict, ok := TraceToDirectObject(fontObj).(*PdfObjectDictionary)
+	if !ok {
+		t.Fatalf("Font missing")
+	}
+	if subtype, ok := fontDict.Get("Subtype").(*PdfObjectName); !ok || *subtype != "TrueType" {
+		t.Errorf("Invalid font subtype: %v", fontDict.Get("Subtype"))
+	}
+	descriptor, ok := TraceToDirectObject(fontDict.Get("FontDescriptor")).(*PdfObjectDictionary)
+	if !ok || descriptor.Get("FontFile2") == nil {
+		t.Errorf("Font not embedded: %v", fontDict)
+	}
+}
+
+func TestPdfA3AssociatedFiles(t *testing.T) {
+	writer := NewPdfWriter()
+	spec, err := NewPdfFileSpec("data.csv", []byte("a,b\n1,2\n"), "")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.AddEmbeddedFile("data.csv", spec); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.SetPdfAConformance(PdfAConformanceA3B)
+	f, err := os.Create("/tmp/pdfa3.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/pdfa3.pdf")
+	files, err := reader.GetAssociatedFiles()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(files) != 1 || files[0].AFRelationship != AFRelationshipUnspecified ||
+		files[0].Subtype != "application/octet-stream" {
+		t.Errorf("Invalid associated files: %+v", files)
+	}
+	xmp, err := reader.GetXmpMetadata()
+	if err != nil || xmp == nil || xmp.PdfAPart != 3 || xmp.PdfAConformance != "B" {
+		t.Errorf("Invalid XMP metadata: %+v (%v)", xmp, err)
+	}
+	trailer, err := reader.GetTrailer()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if ids, ok := TraceToDirectObject(trailer.Get("ID")).(*PdfObjectArray); !ok || len(*ids) != 2 {
+		t.Errorf("Missing document ID: %v", trailer.Get("ID"))
+	}
+	outputIntents, ok := TraceToDirectObject(reader.catalog.Get("OutputIntents")).(*PdfObjectArray)
+	if !ok || len(*outputIntents) != 1 {
+		t.Fatalf("Missing output intent")
+	}
+	outputIntent, ok := TraceToDirectObject((*outputIntents)[0]).(*PdfObjectDictionary)
+	if !ok || outputIntent.Get("DestOutputProfile") == nil {
+		t.Errorf("Invalid output intent: %v", (*outputIntents)[0])
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/unidoc/unidoc/common"
@@ -18,6 +19,7 @@ import (
 // PdfReader represents a PDF file reader. It is a frontend to the lower level parsing mechanism and provides
 // a higher level access to work with PDF structure and information, such as the page structure etc.
 type PdfReader struct {
+	rs          io.ReadSeeker
 	parser      *PdfParser
 	root        PdfObject
 	pages       *PdfObjectDictionary
@@ -39,6 +41,7 @@ type PdfReader struct {
 // not encrypted).
 func NewPdfReader(rs io.ReadSeeker) (*PdfReader, error) {
 	pdfReader := &PdfReader{}
+	pdfReader.rs = rs
 	pdfReader.traversed = map[PdfObject]bool{}
 
 	pdfReader.modelManager = NewModelManager()
@@ -760,6 +763,204 @@ func (this *PdfReader) GetOCProperties() (PdfObject, error) {
 	return obj, nil
 }
 
+// GetOptionalContentProperties returns the optional content properties of the document, or nil if the
+// document has no optional content.
+func (this *PdfReader) GetOptionalContentProperties() (*PdfOCProperties, error) {
+	obj, err := this.GetOCProperties()
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil || isNullObject(obj) {
+		return nil, nil
+	}
+	return NewPdfOCPropertiesFromObject(obj)
+}
+
+// IsTagged returns true if the document is a tagged PDF (Marked entry of the MarkInfo dictionary).
+func (this *PdfReader) IsTagged() (bool, error) {
+	obj, err := this.traceToObject(this.catalog.Get("MarkInfo"))
+	if err != nil {
+		return false, err
+	}
+	markInfo, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return false, nil
+	}
+	obj, err = this.traceToObject(markInfo.Get("Marked"))
+	if err != nil {
+		return false, err
+	}
+	marked, isBool := TraceToDirectObject(obj).(*PdfObjectBool)
+	return isBool && bool(*marked), nil
+}
+
+// GetStructTreeRoot returns the logical structure of the document (StructTreeRoot entry of the catalog), or
+// nil if not present.
+func (this *PdfReader) GetStructTreeRoot() (*PdfStructTreeRoot, error) {
+	obj := this.catalog.Get("StructTreeRoot")
+	if obj == nil {
+		return nil, nil
+	}
+	obj, err := this.traceToObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := this.traverseObjectData(obj); err != nil {
+		return nil, err
+	}
+	return newPdfStructTreeRootFromObject(this, obj)
+}
+
+// GetPdfInfo returns the document information (Info entry of the trailer), or nil if not present.
+func (this *PdfReader) GetPdfInfo() (*PdfInfo, error) {
+	trailer, err := this.GetTrailer()
+	if err != nil {
+		return nil, err
+	}
+	if trailer.Get("Info") == nil {
+		return nil, nil
+	}
+	obj, err := this.traceToObject(trailer.Get("Info"))
+	if err != nil {
+		return nil, err
+	}
+	return NewPdfInfoFromObject(obj)
+}
+
+// GetXmpMetadata returns the XMP metadata of the document (Metadata entry of the catalog), or nil if not
+// present.
+func (this *PdfReader) GetXmpMetadata() (*XmpMetadata, error) {
+	obj, err := this.traceToObject(this.catalog.Get("Metadata"))
+	if err != nil {
+		return nil, err
+	}
+	stream, isStream := obj.(*PdfObjectStream)
+	if !isStream {
+		return nil, nil
+	}
+	data, err := DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	return ParseXmpMetadata(data)
+}
+
+// GetNameTree returns name tree `name` (e.g. Dests, EmbeddedFiles, JavaScript or AP) of the name dictionary
+// of the catalog, or nil if not present.  The tree is read lazily.
+func (this *PdfReader) GetNameTree(name PdfObjectName) (*PdfNameTree, error) {
+	obj, err := this.traceToObject(this.catalog.Get("Names"))
+	if err != nil {
+		return nil, err
+	}
+	names, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict || names.Get(name) == nil {
+		return nil, nil
+	}
+	return newPdfNameTreeFromObject(this, names.Get(name)), nil
+}
+
+// GetPageLabelTree returns the page labels number tree (PageLabels entry of the catalog), or nil if not
+// present.  The tree is read lazily.
+func (this *PdfReader) GetPageLabelTree() (*PdfNumberTree, error) {
+	obj := this.catalog.Get("PageLabels")
+	if obj == nil {
+		return nil, nil
+	}
+	return newPdfNumberTreeFromObject(this, obj), nil
+}
+
+// GetEmbeddedFiles returns the files attached to the document (EmbeddedFiles name tree) by name.
+func (this *PdfReader) GetEmbeddedFiles() (map[string]*PdfFileSpec, error) {
+	files := map[string]*PdfFileSpec{}
+	tree, err := this.GetNameTree("EmbeddedFiles")
+	if err != nil || tree == nil {
+		return files, err
+	}
+
+	err = tree.Walk(func(name string, value PdfObject) bool {
+		var spec *PdfFileSpec
+		spec, err = newPdfFileSpecFromObject(value)
+		if err != nil {
+			return false
+		}
+		files[name] = spec
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// GetAssociatedFiles returns the files associated with the document (AF entry of the catalog).
+func (this *PdfReader) GetAssociatedFiles() ([]*PdfFileSpec, error) {
+	obj, err := this.traceToObject(this.catalog.Get("AF"))
+	if err != nil {
+		return nil, err
+	}
+	if err := this.traverseObjectData(obj); err != nil {
+		return nil, err
+	}
+	arr, isArray := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !isArray {
+		return nil, nil
+	}
+
+	var files []*PdfFileSpec
+	for _, obj := range *arr {
+		spec, err := newPdfFileSpecFromObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, spec)
+	}
+	return files, nil
+}
+
+// GetPageLabelRanges returns the page labelling ranges of the document sorted by start page, or nil if the
+// document has no page labels.
+func (this *PdfReader) GetPageLabelRanges() ([]*PdfPageLabelRange, error) {
+	tree, err := this.GetPageLabelTree()
+	if err != nil || tree == nil {
+		return nil, err
+	}
+
+	var ranges []*PdfPageLabelRange
+	err = tree.Walk(func(start int64, obj PdfObject) bool {
+		labelRange, err := newPdfPageLabelRangeFromObject(start, obj)
+		if err != nil {
+			common.Log.Debug("ERROR: %v", err)
+			return true
+		}
+		ranges = append(ranges, labelRange)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].StartPage < ranges[j].StartPage })
+	return ranges, nil
+}
+
+// GetPageLabels returns the labels of the pages of the document, e.g. "iii" or "A-12".  Pages are
+// labelled with their page number when the document has no page labels.
+func (this *PdfReader) GetPageLabels() ([]string, error) {
+	ranges, err := this.GetPageLabelRanges()
+	if err != nil {
+		return nil, err
+	}
+	numPages, err := this.GetNumPages()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]string, numPages)
+	for i := range labels {
+		labels[i] = getPageLabel(ranges, i)
+	}
+	return labels, nil
+}
+
 // Inspect inspects the object types, subtypes and content in the PDF file returning a map of
 // object type to number of instances of each.
 func (this *PdfReader) Inspect() (map[string]int, error) {
@@ -781,6 +982,30 @@ func (this *PdfReader) GetIndirectObjectByNumber(number int) (PdfObject, error)
 	return obj, err
 }
 
+// GetOpenAction returns the action performed when the document is opened (OpenAction entry of the
+// catalog), or nil if none.  A destination to display is returned as a GoTo action.
+func (this *PdfReader) GetOpenAction() (*PdfAction, error) {
+	obj := this.catalog.Get("OpenAction")
+	if obj == nil {
+		return nil, nil
+	}
+	obj, err := this.traceToObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := this.traverseObjectData(obj); err != nil {
+		return nil, err
+	}
+	if _, isArray := TraceToDirectObject(obj).(*PdfObjectArray); isArray {
+		dest, err := NewPdfDestinationFromObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		return NewPdfActionGoTo(dest).PdfAction, nil
+	}
+	return NewPdfActionFromObject(obj)
+}
+
 // GetTrailer returns the PDF's trailer dictionary.
 func (this *PdfReader) GetTrailer() (*PdfObjectDictionary, error) {
 	trailerDict := this.parser.GetTrailer()
@@ -7,6 +7,7 @@ package model
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/unidoc/unidoc/common"
 	. "github.com/unidoc/unidoc/pdf/core"
@@ -125,12 +126,75 @@ func (r *PdfPageResources) GetExtGState(keyName PdfObjectName) (PdfObject, bool)
 	}
 }
 
-// Check whether a font is defined by the specified keyName.
+// Check whether an ExtGState is defined by the specified keyName.
 func (r *PdfPageResources) HasExtGState(keyName PdfObjectName) bool {
-	_, has := r.GetFontByName(keyName)
+	_, has := r.GetExtGState(keyName)
 	return has
 }
 
+// GetExtGStateByName returns the graphics state parameter dictionary specified by keyName.  Returns a bool
+// indicating whether it was found and valid or not.
+func (r *PdfPageResources) GetExtGStateByName(keyName PdfObjectName) (*PdfExtGState, bool) {
+	obj, has := r.GetExtGState(keyName)
+	if !has {
+		return nil, false
+	}
+
+	gs, err := NewPdfExtGStateFromObject(obj)
+	if err != nil {
+		common.Log.Debug("ERROR: failed to load graphics state: %v", err)
+		return nil, false
+	}
+	return gs, true
+}
+
+// SetExtGStateByName sets the graphics state parameter dictionary specified by keyName to `gs`.
+func (r *PdfPageResources) SetExtGStateByName(keyName PdfObjectName, gs *PdfExtGState) error {
+	return r.AddExtGState(keyName, gs.ToPdfObject())
+}
+
+// AddNewExtGState adds graphics state parameter dictionary `gs` under a new name starting with `prefix`, see
+// GenerateExtGStateName, and returns the name.
+func (r *PdfPageResources) AddNewExtGState(prefix string, gs *PdfExtGState) (PdfObjectName, error) {
+	name := r.GenerateExtGStateName(prefix)
+	if err := r.SetExtGStateByName(name, gs); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// GenerateExtGStateName returns the first name of the form `prefix` followed by a number, counting from 0, that
+// is not in use by an ExtGState resource.
+func (r *PdfPageResources) GenerateExtGStateName(prefix string) PdfObjectName {
+	return generateResourceName(prefix, r.HasExtGState)
+}
+
+// GenerateXObjectName returns the first name of the form `prefix` followed by a number, counting from 0, that is
+// not in use by an XObject resource.
+func (r *PdfPageResources) GenerateXObjectName(prefix string) PdfObjectName {
+	return generateResourceName(prefix, func(name PdfObjectName) bool {
+		dict, isDict := TraceToDirectObject(r.XObject).(*PdfObjectDictionary)
+		return isDict && dict.Get(name) != nil
+	})
+}
+
+// GenerateFontName returns the first name of the form `prefix` followed by a number, counting from 0, that is
+// not in use by a Font resource.
+func (r *PdfPageResources) GenerateFontName(prefix string) PdfObjectName {
+	return generateResourceName(prefix, r.HasFontByName)
+}
+
+// generateResourceName returns the first name of the form `prefix` followed by a number for which `inUse` is
+// false.
+func generateResourceName(prefix string, inUse func(name PdfObjectName) bool) PdfObjectName {
+	for i := 0; ; i++ {
+		name := PdfObjectName(fmt.Sprintf("%s%d", prefix, i))
+		if !inUse(name) {
+			return name
+		}
+	}
+}
+
 // Get the shading specified by keyName.  Returns nil if not existing. The bool flag indicated whether it was found
 // or not.
 func (r *PdfPageResources) GetShadingByName(keyName PdfObjectName) (*PdfShading, bool) {
@@ -255,6 +319,42 @@ func (r *PdfPageResources) SetFontByName(keyName PdfObjectName, obj PdfObject) e
 	return nil
 }
 
+// GetPropertiesByName returns the property list specified by keyName, e.g. the optional content group of a
+// marked-content section "/OC /keyName BDC".  Returns a bool value indicating whether or not the entry was found.
+func (r *PdfPageResources) GetPropertiesByName(keyName PdfObjectName) (PdfObject, bool) {
+	if r.Properties == nil {
+		return nil, false
+	}
+
+	propsDict, has := TraceToDirectObject(r.Properties).(*PdfObjectDictionary)
+	if !has {
+		common.Log.Debug("ERROR: Properties not a dictionary! (got %T)", TraceToDirectObject(r.Properties))
+		return nil, false
+	}
+
+	if obj := propsDict.Get(keyName); obj != nil {
+		return obj, true
+	}
+	return nil, false
+}
+
+// SetPropertiesByName sets the property list specified by keyName to the given object.
+func (r *PdfPageResources) SetPropertiesByName(keyName PdfObjectName, obj PdfObject) error {
+	if r.Properties == nil {
+		// Create if not existing.
+		r.Properties = MakeDict()
+	}
+
+	propsDict, has := TraceToDirectObject(r.Properties).(*PdfObjectDictionary)
+	if !has {
+		common.Log.Debug("ERROR: Properties not a dictionary! (got %T)", TraceToDirectObject(r.Properties))
+		return ErrTypeError
+	}
+
+	propsDict.Set(keyName, obj)
+	return nil
+}
+
 func (r *PdfPageResources) GetColorspaceByName(keyName PdfObjectName) (PdfColorspace, bool) {
 	if r.ColorSpace == nil {
 		return nil, false
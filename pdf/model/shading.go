@@ -545,7 +545,7 @@ func newPdfShadingType4FromDictionary(dict *PdfObjectDictionary) (*PdfShadingTyp
 		common.Log.Debug("BitsPerFlag not an integer (got %T)", obj)
 		return nil, ErrTypeError
 	}
-	shading.BitsPerComponent = integer
+	shading.BitsPerFlag = integer
 
 	// Decode (required).
 	obj = dict.Get("Decode")
@@ -560,15 +560,19 @@ func newPdfShadingType4FromDictionary(dict *PdfObjectDictionary) (*PdfShadingTyp
 	}
 	shading.Decode = arr
 
-	// Function (required).
-	obj = dict.Get("Function")
-	if obj == nil {
-		common.Log.Debug("Required attribute missing:  Function")
-		return nil, ErrRequiredAttributeMissing
-	}
-	shading.Function = []PdfFunction{}
-	if array, is := obj.(*PdfObjectArray); is {
-		for _, obj := range *array {
+	// Function (optional).
+	if obj := dict.Get("Function"); obj != nil {
+		shading.Function = []PdfFunction{}
+		if array, is := obj.(*PdfObjectArray); is {
+			for _, obj := range *array {
+				function, err := newPdfFunctionFromPdfObject(obj)
+				if err != nil {
+					common.Log.Debug("Error parsing function: %v", err)
+					return nil, err
+				}
+				shading.Function = append(shading.Function, function)
+			}
+		} else {
 			function, err := newPdfFunctionFromPdfObject(obj)
 			if err != nil {
 				common.Log.Debug("Error parsing function: %v", err)
@@ -576,13 +580,6 @@ func newPdfShadingType4FromDictionary(dict *PdfObjectDictionary) (*PdfShadingTyp
 			}
 			shading.Function = append(shading.Function, function)
 		}
-	} else {
-		function, err := newPdfFunctionFromPdfObject(obj)
-		if err != nil {
-			common.Log.Debug("Error parsing function: %v", err)
-			return nil, err
-		}
-		shading.Function = append(shading.Function, function)
 	}
 
 	return &shading, nil
@@ -711,7 +708,7 @@ func newPdfShadingType6FromDictionary(dict *PdfObjectDictionary) (*PdfShadingTyp
 		common.Log.Debug("BitsPerFlag not an integer (got %T)", obj)
 		return nil, ErrTypeError
 	}
-	shading.BitsPerComponent = integer
+	shading.BitsPerFlag = integer
 
 	// Decode (required).
 	obj = dict.Get("Decode")
@@ -792,7 +789,7 @@ func newPdfShadingType7FromDictionary(dict *PdfObjectDictionary) (*PdfShadingTyp
 		common.Log.Debug("BitsPerFlag not an integer (got %T)", obj)
 		return nil, ErrTypeError
 	}
-	shading.BitsPerComponent = integer
+	shading.BitsPerFlag = integer
 
 	// Decode (required).
 	obj = dict.Get("Decode")
@@ -0,0 +1,8 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package sighandler implements digital signature handlers (model.SignatureHandler) for signing and
+// validating PDF documents: adbe.pkcs7.detached signatures and ETSI.RFC3161 document timestamps.
+package sighandler
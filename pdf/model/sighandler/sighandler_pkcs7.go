@@ -0,0 +1,218 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package sighandler
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"hash"
+	"time"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/pkcs7"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// adobePKCS7Detached implements the adbe.pkcs7.detached signature handler.  The signature is a DER encoded
+// CMS SignedData structure computed over the digest of the signed byte ranges, which are not embedded.
+type adobePKCS7Detached struct {
+	signer crypto.Signer
+	certs  []*x509.Certificate
+	hash   crypto.Hash
+
+	// Trusted root certificates for validation.  The system roots are used if nil.
+	roots *x509.CertPool
+}
+
+// NewAdobePKCS7Detached creates a new adbe.pkcs7.detached signature handler signing with `signer`.  The
+// certificate chain `certs` is embedded in the signature, the first certificate being the signing certificate
+// corresponding to `signer`.  The digest algorithm is SHA-256.
+func NewAdobePKCS7Detached(signer crypto.Signer, certs []*x509.Certificate) (model.SignatureHandler, error) {
+	if signer == nil {
+		return nil, errors.New("Signer missing")
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("Signing certificate missing")
+	}
+
+	return &adobePKCS7Detached{signer: signer, certs: certs, hash: crypto.SHA256}, nil
+}
+
+// NewAdobePKCS7DetachedValidator creates a new adbe.pkcs7.detached signature handler for validating signatures.
+// The signer certificate chains are verified against the trusted `roots`, or the system roots if nil.
+func NewAdobePKCS7DetachedValidator(roots *x509.CertPool) model.SignatureHandler {
+	return &adobePKCS7Detached{hash: crypto.SHA256, roots: roots}
+}
+
+// InitSignature initializes the PdfSignature.
+func (h *adobePKCS7Detached) InitSignature(sig *model.PdfSignature) error {
+	if h.signer == nil {
+		return errors.New("Signer missing")
+	}
+	sig.Handler = h
+	sig.Filter = core.MakeName("Adobe.PPKLite")
+	sig.SubFilter = core.MakeName("adbe.pkcs7.detached")
+	sig.Reference = nil
+
+	// Reserve room for the signature.
+	sig.Contents = core.MakeString(string(make([]byte, estimateSignatureSize(h.signer, h.certs))))
+	return nil
+}
+
+// NewDigest creates a new digest.  When validating, the digest algorithm of the signature in `sig` is used.
+func (h *adobePKCS7Detached) NewDigest(sig *model.PdfSignature) (model.Hasher, error) {
+	if sig.Contents != nil {
+		if sd, err := pkcs7.Parse([]byte(*sig.Contents)); err == nil {
+			return sd.Hash.New(), nil
+		}
+	}
+	return h.hash.New(), nil
+}
+
+// Sign sets the Contents fields of `sig` to the signature of `digest`.
+func (h *adobePKCS7Detached) Sign(sig *model.PdfSignature, digest model.Hasher) error {
+	hasher, ok := digest.(hash.Hash)
+	if !ok {
+		return errors.New("Invalid digest")
+	}
+
+	sd, err := pkcs7.Sign(hasher.Sum(nil), h.hash, h.signer, h.certs, &pkcs7.SignOptions{
+		SigningTime: getSigningTime(sig),
+	})
+	if err != nil {
+		return err
+	}
+	data, err := sd.Marshal()
+	if err != nil {
+		return err
+	}
+
+	sig.Contents = core.MakeString(string(data))
+	return nil
+}
+
+// Validate verifies the signature in the Contents of `sig` against `digest` and the certificate chain of the
+// signer.
+func (h *adobePKCS7Detached) Validate(sig *model.PdfSignature, digest model.Hasher) (model.SignatureValidationResult, error) {
+	res := model.SignatureValidationResult{}
+
+	hasher, ok := digest.(hash.Hash)
+	if !ok {
+		return res, errors.New("Invalid digest")
+	}
+	if sig.Contents == nil {
+		return res, errors.New("Signature contents missing")
+	}
+	sd, err := pkcs7.Parse([]byte(*sig.Contents))
+	if err != nil {
+		return res, err
+	}
+
+	signingTime, _ := sd.SigningTime()
+	res.SigningTime = signingTime
+
+	res.Certificates = getCertificateChain(sd)
+
+	err = sd.Verify(hasher.Sum(nil))
+	switch err {
+	case nil:
+		res.DigestValid = true
+		res.IsVerified = true
+	case pkcs7.ErrInvalidSignature:
+		// The message digest attribute matched the digest, only the signature value is invalid.  Without signed
+		// attributes the signature value is checked against the digest directly, which may not match.
+		res.DigestValid = sd.HasSignedAttributes()
+		return res, err
+	default:
+		return res, err
+	}
+
+	// Verify the certificate chain at the time of signing.
+	if err := verifyCertificateChain(res.Certificates, h.roots, signingTime, x509.ExtKeyUsageAny); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	} else {
+		res.IsTrusted = true
+	}
+
+	return res, nil
+}
+
+// IsApplicable returns true if the signature handler is applicable for the PdfSignature.
+func (h *adobePKCS7Detached) IsApplicable(sig *model.PdfSignature) bool {
+	if sig == nil || sig.Filter == nil || sig.SubFilter == nil {
+		return false
+	}
+	return (*sig.Filter == "Adobe.PPKLite" || *sig.Filter == "Adobe.PPKMS") && *sig.SubFilter == "adbe.pkcs7.detached"
+}
+
+// getSigningTime returns the signing time from the M entry of `sig` or the current time if not set.
+func getSigningTime(sig *model.PdfSignature) time.Time {
+	if sig.M != nil {
+		if date, err := model.NewPdfDate(string(*sig.M)); err == nil {
+			return date.ToGoTime()
+		}
+	}
+	return time.Now()
+}
+
+// getCertificateChain returns the certificates of `sd`, starting with the signer certificate.
+func getCertificateChain(sd *pkcs7.SignedData) []*x509.Certificate {
+	cert := sd.SignerCertificate()
+	if cert == nil {
+		return nil
+	}
+	chain := []*x509.Certificate{cert}
+	for _, c := range sd.Certificates {
+		if c != cert {
+			chain = append(chain, c)
+		}
+	}
+	return chain
+}
+
+// verifyCertificateChain verifies that the first certificate of `chain` chains up to one of the `roots` (the
+// system roots if nil) at time `t` (the current time if zero), the other certificates of `chain` being
+// possible intermediates.
+func verifyCertificateChain(chain []*x509.Certificate, roots *x509.CertPool, t time.Time, usage x509.ExtKeyUsage) error {
+	if len(chain) == 0 {
+		return errors.New("Signer certificate missing")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   t,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+	return err
+}
+
+// estimateSignatureSize returns an upper estimate of the size of the DER encoded SignedData structure
+// generated with `signer`, embedding `certs`.
+func estimateSignatureSize(signer crypto.Signer, certs []*x509.Certificate) int {
+	// Fixed overhead: ASN.1 structure, algorithm identifiers and signed attributes.
+	size := 1024
+	for _, cert := range certs {
+		size += len(cert.Raw)
+	}
+	size += len(certs[0].RawIssuer)
+
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		size += pub.Size()
+	case *ecdsa.PublicKey:
+		size += 2*((pub.Curve.Params().BitSize+7)/8) + 16
+	default:
+		size += 1024
+	}
+	return size
+}
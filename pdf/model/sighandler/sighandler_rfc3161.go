@@ -0,0 +1,190 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package sighandler
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/pkcs7"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// docTimeStampSize is the room reserved for the timestamp token in the signature contents.
+const docTimeStampSize = 8192
+
+// docTimeStamp implements the ETSI.RFC3161 signature handler for document timestamps (section 12.8.5 of
+// ISO 32000-2).  The signature contents is an RFC 3161 timestamp token over the digest of the signed byte
+// ranges.
+type docTimeStamp struct {
+	tsa  TimestampAuthority
+	hash crypto.Hash
+
+	// Trusted root certificates for validation.  The system roots are used if nil.
+	roots *x509.CertPool
+}
+
+// NewDocTimeStamp creates a new document timestamp signature handler requesting timestamp tokens from `tsa`
+// over the digest computed with `hash`.
+func NewDocTimeStamp(tsa TimestampAuthority, hash crypto.Hash) (model.SignatureHandler, error) {
+	if tsa == nil {
+		return nil, errors.New("Timestamp authority missing")
+	}
+	if !hash.Available() {
+		return nil, errors.New("Hash function not available")
+	}
+
+	return &docTimeStamp{tsa: tsa, hash: hash}, nil
+}
+
+// NewDocTimeStampValidator creates a new document timestamp signature handler for validating timestamps.
+// The chains of the authority certificates are verified against the trusted `roots`, or the system roots
+// if nil.
+func NewDocTimeStampValidator(roots *x509.CertPool) model.SignatureHandler {
+	return &docTimeStamp{hash: crypto.SHA256, roots: roots}
+}
+
+// InitSignature initializes the PdfSignature.
+func (h *docTimeStamp) InitSignature(sig *model.PdfSignature) error {
+	if h.tsa == nil {
+		return errors.New("Timestamp authority missing")
+	}
+	sig.Handler = h
+	sig.Type = core.MakeName("DocTimeStamp")
+	sig.Filter = core.MakeName("Adobe.PPKLite")
+	sig.SubFilter = core.MakeName("ETSI.RFC3161")
+	sig.Reference = nil
+
+	// Reserve room for the timestamp token.
+	sig.Contents = core.MakeString(string(make([]byte, docTimeStampSize)))
+	return nil
+}
+
+// NewDigest creates a new digest.  When validating, the digest algorithm of the timestamp token in `sig`
+// is used.
+func (h *docTimeStamp) NewDigest(sig *model.PdfSignature) (model.Hasher, error) {
+	if sig.Contents != nil {
+		if _, info, err := parseTimestampToken([]byte(*sig.Contents)); err == nil {
+			if hash, err := info.MessageImprint.Hash(); err == nil {
+				return hash.New(), nil
+			}
+		}
+	}
+	return h.hash.New(), nil
+}
+
+// Sign requests a timestamp token for `digest` and sets it as the Contents of `sig`.
+func (h *docTimeStamp) Sign(sig *model.PdfSignature, digest model.Hasher) error {
+	hasher, ok := digest.(hash.Hash)
+	if !ok {
+		return errors.New("Invalid digest")
+	}
+	sum := hasher.Sum(nil)
+
+	req, err := pkcs7.NewTimeStampReq(sum, h.hash)
+	if err != nil {
+		return err
+	}
+	reqData, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+
+	respData, err := h.tsa.Timestamp(reqData)
+	if err != nil {
+		return err
+	}
+	resp, err := pkcs7.ParseTimeStampResp(respData)
+	if err != nil {
+		return err
+	}
+	if resp.Status.Status != pkcs7.StatusGranted && resp.Status.Status != pkcs7.StatusGrantedWithMods {
+		return fmt.Errorf("Timestamp request rejected (status %d)", resp.Status.Status)
+	}
+
+	token := resp.TimeStampToken.FullBytes
+	_, info, err := parseTimestampToken(token)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(info.MessageImprint.HashedMessage, sum) {
+		return errors.New("Timestamp token message imprint mismatch")
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(req.Nonce) != 0 {
+		return errors.New("Timestamp token nonce mismatch")
+	}
+
+	sig.Contents = core.MakeString(string(token))
+	return nil
+}
+
+// Validate verifies the timestamp token in the Contents of `sig` against `digest` and the certificate chain
+// of the authority.
+func (h *docTimeStamp) Validate(sig *model.PdfSignature, digest model.Hasher) (model.SignatureValidationResult, error) {
+	res := model.SignatureValidationResult{}
+
+	hasher, ok := digest.(hash.Hash)
+	if !ok {
+		return res, errors.New("Invalid digest")
+	}
+	if sig.Contents == nil {
+		return res, errors.New("Signature contents missing")
+	}
+	sd, info, err := parseTimestampToken([]byte(*sig.Contents))
+	if err != nil {
+		return res, err
+	}
+
+	res.SigningTime = info.GenTime
+	res.Certificates = getCertificateChain(sd)
+
+	res.DigestValid = bytes.Equal(info.MessageImprint.HashedMessage, hasher.Sum(nil))
+	if !res.DigestValid {
+		return res, errors.New("Timestamp message imprint mismatch")
+	}
+
+	// The token signature covers the encapsulated TSTInfo.
+	h2 := sd.Hash.New()
+	h2.Write(sd.Content)
+	if err := sd.Verify(h2.Sum(nil)); err != nil {
+		return res, err
+	}
+	res.IsVerified = true
+
+	if err := verifyCertificateChain(res.Certificates, h.roots, info.GenTime, x509.ExtKeyUsageTimeStamping); err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	} else {
+		res.IsTrusted = true
+	}
+
+	return res, nil
+}
+
+// IsApplicable returns true if the signature handler is applicable for the PdfSignature.
+func (h *docTimeStamp) IsApplicable(sig *model.PdfSignature) bool {
+	if sig == nil || sig.Filter == nil || sig.SubFilter == nil {
+		return false
+	}
+	return *sig.Filter == "Adobe.PPKLite" && *sig.SubFilter == "ETSI.RFC3161"
+}
+
+// parseTimestampToken parses the DER encoded timestamp token `data`.
+func parseTimestampToken(data []byte) (*pkcs7.SignedData, *pkcs7.TSTInfo, error) {
+	sd, err := pkcs7.Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := sd.TSTInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sd, info, nil
+}
@@ -0,0 +1,294 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package sighandler
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/pkcs7"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// localTSA is an in-process time stamping authority for testing.
+type localTSA struct {
+	priv   *rsa.PrivateKey
+	cert   *x509.Certificate
+	serial int64
+}
+
+func newLocalTSA(t *testing.T) *localTSA {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "UniDoc Test TSA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return &localTSA{priv: priv, cert: cert}
+}
+
+// Timestamp implements the TimestampAuthority interface.
+func (tsa *localTSA) Timestamp(reqData []byte) ([]byte, error) {
+	req, err := pkcs7.ParseTimeStampReq(reqData)
+	if err != nil {
+		return nil, err
+	}
+
+	tsa.serial++
+	info := pkcs7.TSTInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   big.NewInt(tsa.serial),
+		GenTime:        time.Now().UTC().Truncate(time.Second),
+		Nonce:          req.Nonce,
+	}
+	content, err := info.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	h := crypto.SHA256.New()
+	h.Write(content)
+	sd, err := pkcs7.Sign(h.Sum(nil), crypto.SHA256, tsa.priv, []*x509.Certificate{tsa.cert}, &pkcs7.SignOptions{
+		ContentType: pkcs7.OIDTSTInfo,
+		Content:     content,
+	})
+	if err != nil {
+		return nil, err
+	}
+	token, err := sd.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := pkcs7.TimeStampResp{
+		Status:         pkcs7.PKIStatusInfo{Status: pkcs7.StatusGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	}
+	return resp.Marshal()
+}
+
+// addDocTimeStamp adds a document timestamp from `tsa` to `data` in an incremental update.
+func addDocTimeStamp(t *testing.T, data []byte, tsa TimestampAuthority) []byte {
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	handler, err := NewDocTimeStamp(tsa, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	sig := model.NewPdfSignature(handler)
+	if err := sig.Initialize(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	field := model.NewPdfFieldSignature(sig)
+	field.T = core.MakeString("Timestamp1")
+	if err := appender.Sign(1, field); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := appender.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDocTimeStamp(t *testing.T) {
+	tsa := newLocalTSA(t)
+	data := addDocTimeStamp(t, mustReadFile(t, testPdfFile1), tsa)
+
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(tsa.cert)
+	results, err := reader.ValidateSignatures([]model.SignatureHandler{NewDocTimeStampValidator(roots)})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 timestamp, got %d", len(results))
+	}
+	res := results[0]
+	if !res.ByteRangeValid || !res.DigestValid || !res.IsVerified || !res.IsTrusted || res.ModifiedAfterSigning {
+		t.Fatalf("Timestamp not valid: %s", res)
+	}
+	if res.SubFilter != "ETSI.RFC3161" {
+		t.Errorf("Invalid SubFilter: %s", res.SubFilter)
+	}
+	if time.Since(res.SigningTime) > time.Minute {
+		t.Errorf("Invalid timestamp time: %v", res.SigningTime)
+	}
+
+	fields, err := reader.GetSignatureFields()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if sigType := fields[0].V.Type; sigType == nil || *sigType != "DocTimeStamp" {
+		t.Errorf("Invalid signature type: %v", sigType)
+	}
+}
+
+func TestDocTimeStampRejected(t *testing.T) {
+	handler, err := NewDocTimeStamp(rejectingTSA{}, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	sig := model.NewPdfSignature(handler)
+	if err := sig.Initialize(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	digest, _ := handler.NewDigest(sig)
+	if err := handler.Sign(sig, digest); err == nil {
+		t.Errorf("Rejected timestamp request should fail")
+	}
+}
+
+// rejectingTSA rejects all requests.
+type rejectingTSA struct{}
+
+func (rejectingTSA) Timestamp(req []byte) ([]byte, error) {
+	resp := pkcs7.TimeStampResp{Status: pkcs7.PKIStatusInfo{Status: pkcs7.StatusRejection}}
+	return resp.Marshal()
+}
+
+// Long-term validation: signature, DSS with the validation data and a document timestamp covering both,
+// each in its own incremental update.
+func TestLongTermValidation(t *testing.T) {
+	data, cert := signTestFile(t)
+
+	// Add the DSS.
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	fields, err := reader.GetSignatureFields()
+	if err != nil || len(fields) != 1 {
+		t.Fatalf("Signature field not found: %v", err)
+	}
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	dss := model.NewPdfDSS()
+	crl := []byte{0x30, 0x03, 0x02, 0x01, 0x01}
+	if _, err := dss.AddVRI(fields[0].V, [][]byte{cert.Raw}, nil, [][]byte{crl}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// Adding the same certificate twice stores it once.
+	if _, err := dss.AddCerts([][]byte{cert.Raw}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := appender.SetDSS(dss); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := appender.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	// Document timestamp.
+	tsa := newLocalTSA(t)
+	data = addDocTimeStamp(t, buf.Bytes(), tsa)
+	if err := ioutil.WriteFile("/tmp/signed_ltv.pdf", data, 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reader, err = model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	roots.AddCert(tsa.cert)
+	results, err := reader.ValidateSignatures([]model.SignatureHandler{
+		NewAdobePKCS7DetachedValidator(roots),
+		NewDocTimeStampValidator(roots),
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 signatures, got %d", len(results))
+	}
+	if !results[0].IsVerified || !results[0].ModifiedAfterSigning {
+		t.Errorf("Invalid signature result: %s", results[0])
+	}
+	if !results[1].IsVerified || !results[1].IsTrusted || results[1].ModifiedAfterSigning {
+		t.Errorf("Invalid timestamp result: %s", results[1])
+	}
+
+	loaded, err := reader.GetDSS()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if loaded == nil {
+		t.Fatalf("DSS missing")
+	}
+	if len(loaded.Certs) != 1 || len(loaded.CRLs) != 1 || len(loaded.OCSPs) != 0 {
+		t.Fatalf("Invalid DSS: %d certs, %d CRLs, %d OCSPs", len(loaded.Certs), len(loaded.CRLs), len(loaded.OCSPs))
+	}
+	certData, err := core.DecodeStream(loaded.Certs[0])
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !bytes.Equal(certData, cert.Raw) {
+		t.Errorf("DSS certificate mismatch")
+	}
+
+	fields, err = reader.GetSignatureFields()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	key, err := model.GetVRIKey(fields[0].V)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	vri, has := loaded.VRI[key]
+	if !has {
+		t.Fatalf("VRI for signature missing")
+	}
+	if len(vri.Cert) != 1 || vri.Cert[0] != loaded.Certs[0] || len(vri.CRL) != 1 {
+		t.Errorf("Invalid VRI: %+v", vri)
+	}
+}
@@ -0,0 +1,461 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package sighandler
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"hash"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/unidoc/unidoc/pdf/annotator"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/pkcs7"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+const testPdfFile1 = "../../../testfiles/minimal.pdf"
+
+// generateTestCertificate returns a private key and a self-signed certificate for testing.
+func generateTestCertificate(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:   "UniDoc Test",
+			Organization: []string{"UniDoc"},
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	return priv, cert
+}
+
+// checkSignedOutput checks that the signature of the document `data` covers the whole file except for
+// the signature contents.
+func checkSignedOutput(t *testing.T, data []byte) {
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error reading signed output: %v", err)
+	}
+	if reader.AcroForm == nil || reader.AcroForm.Fields == nil || len(*reader.AcroForm.Fields) == 0 {
+		t.Fatalf("Signature field missing")
+	}
+	fields := *reader.AcroForm.Fields
+	field := fields[len(fields)-1]
+	if field.FT == nil || *field.FT != "Sig" {
+		t.Fatalf("Not a signature field: %v", field.FT)
+	}
+
+	sigDict, ok := core.TraceToDirectObject(field.V).(*core.PdfObjectDictionary)
+	if !ok {
+		t.Fatalf("Signature dictionary missing (%T)", field.V)
+	}
+	if subFilter, ok := sigDict.Get("SubFilter").(*core.PdfObjectName); !ok || *subFilter != "adbe.pkcs7.detached" {
+		t.Errorf("Invalid SubFilter: %v", sigDict.Get("SubFilter"))
+	}
+
+	byteRange, ok := sigDict.Get("ByteRange").(*core.PdfObjectArray)
+	if !ok {
+		t.Fatalf("ByteRange missing")
+	}
+	br, err := byteRange.ToIntegerArray()
+	if err != nil || len(br) != 4 {
+		t.Fatalf("Invalid ByteRange: %v", byteRange)
+	}
+	if br[0] != 0 || br[2]+br[3] != len(data) {
+		t.Errorf("ByteRange does not cover the file: %v (size %d)", br, len(data))
+	}
+	if data[br[1]] != '<' || data[br[2]-1] != '>' {
+		t.Errorf("ByteRange gap does not match the Contents string")
+	}
+
+	contents, ok := sigDict.Get("Contents").(*core.PdfObjectString)
+	if !ok || len(*contents) == 0 {
+		t.Fatalf("Contents missing")
+	}
+	// DER encoded SEQUENCE.
+	if (*contents)[0] != 0x30 {
+		t.Errorf("Contents not a DER encoded PKCS#7 structure")
+	}
+}
+
+func TestSignAppendPKCS7(t *testing.T) {
+	priv, cert := generateTestCertificate(t)
+
+	original, err := ioutil.ReadFile(testPdfFile1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	reader, err := model.NewPdfReader(bytes.NewReader(original))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	handler, err := NewAdobePKCS7Detached(priv, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	sig := model.NewPdfSignature(handler)
+	sig.SetName("Test Signer")
+	sig.SetReason("Testing")
+	sig.SetDate(time.Now())
+	if err := sig.Initialize(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	field, err := annotator.CreateSignatureField(sig, annotator.SignatureFieldDef{
+		X:      50,
+		Y:      50,
+		Width:  200,
+		Height: 50,
+		Lines: []annotator.SignatureLine{
+			{Desc: "Name", Text: "Test Signer"},
+			{Desc: "Reason", Text: "Testing"},
+		},
+		BorderEnabled: true,
+		BorderWidth:   1,
+		BorderColor:   model.NewPdfColorDeviceRGB(0, 0, 0),
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	field.T = core.MakeString("Signature1")
+
+	if err := appender.Sign(1, field); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := appender.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	data := buf.Bytes()
+
+	// Incremental update: the original bytes are unchanged.
+	if !bytes.HasPrefix(data, original) {
+		t.Fatalf("Original document not retained")
+	}
+	if err := ioutil.WriteFile("/tmp/signed_pkcs7_append.pdf", data, 0644); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	checkSignedOutput(t, data)
+}
+
+// signTestFile signs testPdfFile1 with a new self-signed certificate, returning the signed document and
+// the certificate.
+func signTestFile(t *testing.T) ([]byte, *x509.Certificate) {
+	priv, cert := generateTestCertificate(t)
+	handler, err := NewAdobePKCS7Detached(priv, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return signTestFileWith(t, handler), cert
+}
+
+// signTestFileWith signs testPdfFile1 with `handler`, returning the signed document.
+func signTestFileWith(t *testing.T, handler model.SignatureHandler) []byte {
+	reader, err := model.NewPdfReader(bytes.NewReader(mustReadFile(t, testPdfFile1)))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	sig := model.NewPdfSignature(handler)
+	sig.SetName("Test Signer")
+	sig.SetReason("Testing")
+	sig.SetDate(time.Now())
+	if err := sig.Initialize(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	field := model.NewPdfFieldSignature(sig)
+	field.T = core.MakeString("Signature1")
+	if err := appender.Sign(1, field); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := appender.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return data
+}
+
+// validateSignatures validates the signatures of `data`, trusting `cert`.
+func validateSignatures(t *testing.T, data []byte, cert *x509.Certificate) []model.SignatureValidationResult {
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	results, err := reader.ValidateSignatures([]model.SignatureHandler{NewAdobePKCS7DetachedValidator(roots)})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 signature, got %d", len(results))
+	}
+	return results
+}
+
+func TestValidatePKCS7(t *testing.T) {
+	data, cert := signTestFile(t)
+
+	res := validateSignatures(t, data, cert)[0]
+	if !res.ByteRangeValid || !res.DigestValid || !res.IsVerified || !res.IsTrusted {
+		t.Fatalf("Signature not valid: %s", res)
+	}
+	if res.ModifiedAfterSigning {
+		t.Errorf("Unexpected modification after signing")
+	}
+	if res.FieldName != "Signature1" || res.Name != "Test Signer" || res.Reason != "Testing" {
+		t.Errorf("Invalid signature information: %+v", res)
+	}
+	if res.SubFilter != "adbe.pkcs7.detached" {
+		t.Errorf("Invalid SubFilter: %s", res.SubFilter)
+	}
+	if len(res.Certificates) != 1 || !res.Certificates[0].Equal(cert) {
+		t.Errorf("Signer certificate missing")
+	}
+	if res.SigningTime.IsZero() {
+		t.Errorf("Signing time missing")
+	}
+
+	// Untrusted signer.
+	_, other := generateTestCertificate(t)
+	res = validateSignatures(t, data, other)[0]
+	if !res.IsVerified || res.IsTrusted {
+		t.Errorf("Expected verified but untrusted signature: %s", res)
+	}
+}
+
+func TestValidatePKCS7Tampered(t *testing.T) {
+	data, cert := signTestFile(t)
+
+	// Modify a byte in the signed part of the original document (the page content).
+	idx := bytes.Index(data, []byte("stream"))
+	if idx < 0 {
+		t.Fatalf("Content stream not found")
+	}
+	tampered := append([]byte{}, data...)
+	tampered[idx+10] ^= 0x01
+
+	res := validateSignatures(t, tampered, cert)[0]
+	if !res.ByteRangeValid {
+		t.Errorf("Byte range should still be valid: %s", res)
+	}
+	if res.DigestValid || res.IsVerified {
+		t.Errorf("Tampered document validated: %s", res)
+	}
+}
+
+// noAttributesPKCS7 is an adbe.pkcs7.detached handler signing the document digest directly, without signed
+// attributes.
+type noAttributesPKCS7 struct {
+	*adobePKCS7Detached
+}
+
+// InitSignature initializes the PdfSignature, keeping `h` as its handler.
+func (h noAttributesPKCS7) InitSignature(sig *model.PdfSignature) error {
+	if err := h.adobePKCS7Detached.InitSignature(sig); err != nil {
+		return err
+	}
+	sig.Handler = h
+	return nil
+}
+
+// Sign sets the Contents fields of `sig` to the signature of `digest`, without signed attributes.
+func (h noAttributesPKCS7) Sign(sig *model.PdfSignature, digest model.Hasher) error {
+	hasher, ok := digest.(hash.Hash)
+	if !ok {
+		return errors.New("Invalid digest")
+	}
+	sd, err := pkcs7.Sign(hasher.Sum(nil), h.hash, h.signer, h.certs, &pkcs7.SignOptions{NoSignedAttributes: true})
+	if err != nil {
+		return err
+	}
+	data, err := sd.Marshal()
+	if err != nil {
+		return err
+	}
+	sig.Contents = core.MakeString(string(data))
+	return nil
+}
+
+func TestValidatePKCS7NoSignedAttributes(t *testing.T) {
+	priv, cert := generateTestCertificate(t)
+	handler, err := NewAdobePKCS7Detached(priv, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	data := signTestFileWith(t, noAttributesPKCS7{handler.(*adobePKCS7Detached)})
+
+	res := validateSignatures(t, data, cert)[0]
+	if !res.ByteRangeValid || !res.DigestValid || !res.IsVerified || !res.IsTrusted {
+		t.Fatalf("Signature not valid: %s", res)
+	}
+
+	// Without signed attributes a modified document only fails the check of the signature value.
+	idx := bytes.Index(data, []byte("stream"))
+	if idx < 0 {
+		t.Fatalf("Content stream not found")
+	}
+	tampered := append([]byte{}, data...)
+	tampered[idx+10] ^= 0x01
+
+	res = validateSignatures(t, tampered, cert)[0]
+	if res.DigestValid || res.IsVerified {
+		t.Errorf("Tampered document validated: %s", res)
+	}
+}
+
+func TestValidatePKCS7ModifiedAfterSigning(t *testing.T) {
+	data, cert := signTestFile(t)
+
+	// Incremental update adding an annotation.
+	reader, err := model.NewPdfReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	annot, err := annotator.CreateRectangleAnnotation(annotator.RectangleAnnotationDef{
+		X: 10, Y: 10, Width: 50, Height: 20, BorderEnabled: true, BorderWidth: 1,
+		BorderColor: model.NewPdfColorDeviceRGB(1, 0, 0), Opacity: 1.0,
+	})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := appender.AddAnnotation(1, annot); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := appender.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	res := validateSignatures(t, buf.Bytes(), cert)[0]
+	if !res.IsVerified {
+		t.Errorf("Signed revision should still validate: %s", res)
+	}
+	if !res.ModifiedAfterSigning {
+		t.Errorf("Modification after signing not detected")
+	}
+}
+
+// newSignedWriter returns a writer of a new single page document with a signature field signed by a
+// PKCS#7 detached signature.
+func newSignedWriter(t *testing.T) *model.PdfWriter {
+	priv, cert := generateTestCertificate(t)
+
+	handler, err := NewAdobePKCS7Detached(priv, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	sig := model.NewPdfSignature(handler)
+	sig.SetName("Test Signer")
+	if err := sig.Initialize(); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	field := model.NewPdfFieldSignature(sig)
+	field.T = core.MakeString("Signature1")
+
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 612, Ury: 792}
+	page.Resources = model.NewPdfPageResources()
+	field.Widget.P = page.GetPageAsIndirectObject()
+	page.Annotations = append(page.Annotations, field.Widget.PdfAnnotation)
+
+	form := model.NewPdfAcroForm()
+	form.Fields = &[]*model.PdfField{field.PdfField}
+	form.SigFlags = core.MakeInteger(3)
+
+	writer := model.NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer.SetForms(form)
+	return &writer
+}
+
+func TestSignNewDocumentPKCS7(t *testing.T) {
+	writer := newSignedWriter(t)
+
+	f, err := os.Create("/tmp/signed_pkcs7_new.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	data, err := ioutil.ReadFile("/tmp/signed_pkcs7_new.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	checkSignedOutput(t, data)
+}
+
+func TestSignNewDocumentEncrypted(t *testing.T) {
+	writer := newSignedWriter(t)
+	if err := writer.Encrypt([]byte("user"), []byte("owner"), nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	f, err := os.Create("/tmp/signed_pkcs7_encrypted.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	// The signature dictionary would be written unencrypted.
+	if err := writer.Write(f); err == nil {
+		t.Errorf("Signing an encrypted document succeeded")
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package sighandler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// TimestampAuthority is a time stamping authority (TSA) issuing RFC 3161 timestamp tokens.
+type TimestampAuthority interface {
+	// Timestamp submits the DER encoded TimeStampReq `req` and returns the DER encoded TimeStampResp.
+	Timestamp(req []byte) ([]byte, error)
+}
+
+// httpTimestampAuthority requests timestamps over HTTP (section 3.4 of RFC 3161).
+type httpTimestampAuthority struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPTimestampAuthority returns a TimestampAuthority posting requests to the TSA at `url`.
+func NewHTTPTimestampAuthority(url string) TimestampAuthority {
+	return &httpTimestampAuthority{url: url, client: http.DefaultClient}
+}
+
+// Timestamp submits `req` to the TSA.
+func (tsa *httpTimestampAuthority) Timestamp(req []byte) ([]byte, error) {
+	resp, err := tsa.client.Post(tsa.url, "application/timestamp-query", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Timestamp request failed: %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,447 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Placeholder written for the ByteRange entry of signature dictionaries prior to signing.  Fixed width, so that
+// the actual byte range can be filled in without moving any of the surrounding bytes.
+const byteRangePlaceholder = "[0 0000000000 0000000000 0000000000]"
+
+// PdfSignature represents a PDF signature dictionary (section 12.8 of the PDF32000_2008 spec).
+// The signature dictionary is the value of a signature field (PdfFieldSignature).
+type PdfSignature struct {
+	// Handler generates (and later validates) the signature.  Sets the Filter/SubFilter entries on
+	// initialization.
+	Handler SignatureHandler
+
+	Type      *PdfObjectName
+	Filter    *PdfObjectName
+	SubFilter *PdfObjectName
+	Cert      PdfObject
+	Reference *PdfObjectArray
+	Changes   *PdfObjectArray
+
+	Name        *PdfObjectString
+	M           *PdfObjectString
+	Location    *PdfObjectString
+	Reason      *PdfObjectString
+	ContactInfo *PdfObjectString
+	R           *PdfObjectInteger
+	V           *PdfObjectInteger
+
+	PropBuild    *PdfObjectDictionary
+	PropAuthTime *PdfObjectInteger
+	PropAuthType *PdfObjectName
+
+	// The byte range covered by the signature, and the signature itself (e.g. DER encoded PKCS#7).
+	// When signing, Contents holds a placeholder of the reserved size until the document is written.
+	ByteRange *PdfObjectArray
+	Contents  *PdfObjectString
+
+	primitive *PdfIndirectObject
+}
+
+// NewPdfSignature returns a new signature dictionary which is signed with `handler` when written.
+func NewPdfSignature(handler SignatureHandler) *PdfSignature {
+	sig := &PdfSignature{
+		Type:    MakeName("Sig"),
+		Handler: handler,
+	}
+
+	dict := &pdfSignDictionary{
+		PdfObjectDictionary: MakeDict(),
+		signature:           sig,
+	}
+	sig.primitive = MakeIndirectObject(dict)
+
+	return sig
+}
+
+// Initialize lets the signature handler set up the signature: sets the Filter/SubFilter entries and
+// reserves room for the signature contents.  Must be called prior to writing.
+func (sig *PdfSignature) Initialize() error {
+	if sig.Handler == nil {
+		return errors.New("Signature handler not set")
+	}
+
+	return sig.Handler.InitSignature(sig)
+}
+
+// SetName sets the name of the person or authority signing the document.
+func (sig *PdfSignature) SetName(name string) {
+	sig.Name = MakeString(name)
+}
+
+// SetDate sets the signing time.
+func (sig *PdfSignature) SetDate(t time.Time) {
+	date := NewPdfDateFromTime(t)
+	str, _ := date.ToPdfObject().(*PdfObjectString)
+	sig.M = str
+}
+
+// SetReason sets the reason for the signing, e.g. "I agree".
+func (sig *PdfSignature) SetReason(reason string) {
+	sig.Reason = MakeString(reason)
+}
+
+// SetLocation sets the location of the signing, e.g. a city name.
+func (sig *PdfSignature) SetLocation(location string) {
+	sig.Location = MakeString(location)
+}
+
+// SetContactInfo sets information for contacting the signer.
+func (sig *PdfSignature) SetContactInfo(info string) {
+	sig.ContactInfo = MakeString(info)
+}
+
+// newPdfSignatureFromIndirect loads a signature dictionary model from `container`.
+func (this *PdfReader) newPdfSignatureFromIndirect(container *PdfIndirectObject) (*PdfSignature, error) {
+	dict, ok := container.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		common.Log.Debug("ERROR: Signature container not containing a dictionary (%T)", container.PdfObject)
+		return nil, ErrTypeError
+	}
+
+	// Check if cached.
+	if model := this.modelManager.GetModelFromPrimitive(dict); model != nil {
+		sig, ok := model.(*PdfSignature)
+		if !ok {
+			return nil, ErrTypeError
+		}
+		return sig, nil
+	}
+
+	sig := &PdfSignature{}
+	sig.primitive = container
+	this.modelManager.Register(dict, sig)
+
+	var err error
+	get := func(key PdfObjectName) PdfObject {
+		if err != nil {
+			return nil
+		}
+		var obj PdfObject
+		obj, err = this.traceToObject(dict.Get(key))
+		return TraceToDirectObject(obj)
+	}
+
+	sig.Type, _ = get("Type").(*PdfObjectName)
+	sig.Filter, ok = get("Filter").(*PdfObjectName)
+	if !ok {
+		common.Log.Debug("ERROR: Signature Filter attribute invalid or missing")
+		return nil, ErrRequiredAttributeMissing
+	}
+	sig.SubFilter, _ = get("SubFilter").(*PdfObjectName)
+	sig.Contents, ok = get("Contents").(*PdfObjectString)
+	if !ok {
+		common.Log.Debug("ERROR: Signature Contents attribute invalid or missing")
+		return nil, ErrRequiredAttributeMissing
+	}
+	sig.ByteRange, _ = get("ByteRange").(*PdfObjectArray)
+	sig.Cert = get("Cert")
+	sig.Reference, _ = get("Reference").(*PdfObjectArray)
+	sig.Changes, _ = get("Changes").(*PdfObjectArray)
+	sig.Name, _ = get("Name").(*PdfObjectString)
+	sig.M, _ = get("M").(*PdfObjectString)
+	sig.Location, _ = get("Location").(*PdfObjectString)
+	sig.Reason, _ = get("Reason").(*PdfObjectString)
+	sig.ContactInfo, _ = get("ContactInfo").(*PdfObjectString)
+	sig.R, _ = get("R").(*PdfObjectInteger)
+	sig.V, _ = get("V").(*PdfObjectInteger)
+	sig.PropBuild, _ = get("Prop_Build").(*PdfObjectDictionary)
+	sig.PropAuthTime, _ = get("Prop_AuthTime").(*PdfObjectInteger)
+	sig.PropAuthType, _ = get("Prop_AuthType").(*PdfObjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return sig, nil
+}
+
+// GetContainingPdfObject implements interface PdfModel.
+func (sig *PdfSignature) GetContainingPdfObject() PdfObject {
+	return sig.primitive
+}
+
+// ToPdfObject implements interface PdfModel.
+func (sig *PdfSignature) ToPdfObject() PdfObject {
+	container := sig.primitive
+
+	var dict *PdfObjectDictionary
+	if sd, isSignDict := container.PdfObject.(*pdfSignDictionary); isSignDict {
+		dict = sd.PdfObjectDictionary
+	} else {
+		dict = container.PdfObject.(*PdfObjectDictionary)
+	}
+
+	dict.SetIfNotNil("Type", sig.Type)
+	dict.SetIfNotNil("Filter", sig.Filter)
+	dict.SetIfNotNil("SubFilter", sig.SubFilter)
+	dict.SetIfNotNil("ByteRange", sig.ByteRange)
+	dict.SetIfNotNil("Contents", sig.Contents)
+	dict.SetIfNotNil("Cert", sig.Cert)
+	dict.SetIfNotNil("Reference", sig.Reference)
+	dict.SetIfNotNil("Changes", sig.Changes)
+	dict.SetIfNotNil("Name", sig.Name)
+	dict.SetIfNotNil("M", sig.M)
+	dict.SetIfNotNil("Location", sig.Location)
+	dict.SetIfNotNil("Reason", sig.Reason)
+	dict.SetIfNotNil("ContactInfo", sig.ContactInfo)
+	dict.SetIfNotNil("R", sig.R)
+	dict.SetIfNotNil("V", sig.V)
+	dict.SetIfNotNil("Prop_Build", sig.PropBuild)
+	dict.SetIfNotNil("Prop_AuthTime", sig.PropAuthTime)
+	dict.SetIfNotNil("Prop_AuthType", sig.PropAuthType)
+
+	return container
+}
+
+// pdfSignDictionary is the signature dictionary as written when signing.  The ByteRange and Contents entries
+// are written first as fixed size placeholders which are filled in once the whole output is known.
+type pdfSignDictionary struct {
+	*PdfObjectDictionary
+	signature *PdfSignature
+}
+
+// DefaultWriteString outputs the dictionary with the ByteRange and Contents placeholders.
+func (d *pdfSignDictionary) DefaultWriteString() string {
+	contentsLen := 0
+	if d.signature.Contents != nil {
+		contentsLen = len(*d.signature.Contents)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<<")
+	b.WriteString("/ByteRange ")
+	b.WriteString(byteRangePlaceholder)
+	b.WriteString(" /Contents <")
+	b.Write(bytes.Repeat([]byte("0"), 2*contentsLen))
+	b.WriteString(">")
+	for _, k := range d.Keys() {
+		if k == "ByteRange" || k == "Contents" {
+			continue
+		}
+		b.WriteString(k.DefaultWriteString())
+		b.WriteString(" ")
+		b.WriteString(d.Get(k).DefaultWriteString())
+	}
+	b.WriteString(">>")
+	return b.String()
+}
+
+// signPdfBytes signs the document `data` with the signature whose dictionary was written at `offset`.
+// Fills in the ByteRange placeholder, computes the digest over the covered ranges and writes the signature
+// into the reserved Contents placeholder.
+func signPdfBytes(data []byte, offset int64, sig *PdfSignature) error {
+	if sig.Handler == nil {
+		return errors.New("Signature handler not set")
+	}
+	if offset < 0 || offset >= int64(len(data)) {
+		return ErrRangeError
+	}
+
+	// Locate the placeholders.
+	brStart := bytes.Index(data[offset:], []byte("/ByteRange "+byteRangePlaceholder))
+	if brStart < 0 {
+		return errors.New("Signature ByteRange placeholder not found")
+	}
+	brStart += int(offset) + len("/ByteRange ")
+
+	cStart := bytes.Index(data[brStart:], []byte("/Contents <"))
+	if cStart < 0 {
+		return errors.New("Signature Contents placeholder not found")
+	}
+	cStart += brStart + len("/Contents ")
+	cEnd := bytes.IndexByte(data[cStart:], '>')
+	if cEnd < 0 {
+		return errors.New("Signature Contents placeholder not terminated")
+	}
+	cEnd += cStart + 1
+
+	// The signature covers the whole file except for the Contents hex string (including the angle brackets).
+	byteRange := []int64{0, int64(cStart), int64(cEnd), int64(len(data) - cEnd)}
+	brStr := fmt.Sprintf("[%d %d %d %d]", byteRange[0], byteRange[1], byteRange[2], byteRange[3])
+	if len(brStr) > len(byteRangePlaceholder) {
+		return errors.New("Signature ByteRange exceeds placeholder")
+	}
+	brStr += string(bytes.Repeat([]byte(" "), len(byteRangePlaceholder)-len(brStr)))
+	copy(data[brStart:], brStr)
+	sig.ByteRange = MakeArrayFromIntegers64(byteRange)
+
+	digest, err := sig.Handler.NewDigest(sig)
+	if err != nil {
+		return err
+	}
+	digest.Write(data[:cStart])
+	digest.Write(data[cEnd:])
+
+	reserved := (cEnd - cStart - 2) / 2
+	if err := sig.Handler.Sign(sig, digest); err != nil {
+		return err
+	}
+	if sig.Contents == nil {
+		return errors.New("Signature handler did not produce a signature")
+	}
+	if len(*sig.Contents) > reserved {
+		common.Log.Debug("ERROR: Signature size %d exceeds reserved size %d", len(*sig.Contents), reserved)
+		return errors.New("Signature exceeds reserved space")
+	}
+
+	hexSig := hex.EncodeToString([]byte(*sig.Contents))
+	copy(data[cStart+1:], hexSig)
+
+	return nil
+}
+
+// PdfFieldSignature represents a signature form field (field type Sig) along with its widget annotation.
+// The widget has an empty rectangle by default, i.e. the signature is invisible.  A visible signature
+// is obtained by setting the widget's Rect and appearance (AP).
+type PdfFieldSignature struct {
+	*PdfField
+	Widget *PdfAnnotationWidget
+
+	V *PdfSignature
+}
+
+// NewPdfFieldSignature returns a new signature field with `signature` as value.
+func NewPdfFieldSignature(signature *PdfSignature) *PdfFieldSignature {
+	field := NewPdfField()
+	field.FT = MakeName("Sig")
+
+	widget := NewPdfAnnotationWidget()
+	widget.Rect = MakeArray(MakeInteger(0), MakeInteger(0), MakeInteger(0), MakeInteger(0))
+	widget.F = MakeInteger(132) // Print | Locked.
+	widget.Parent = field.GetContainingPdfObject()
+	field.KidsA = append(field.KidsA, widget.PdfAnnotation)
+
+	sigField := &PdfFieldSignature{
+		PdfField: field,
+		Widget:   widget,
+		V:        signature,
+	}
+	if signature != nil {
+		field.V = signature.ToPdfObject()
+	}
+
+	return sigField
+}
+
+// ToPdfObject implements interface PdfModel.
+func (this *PdfFieldSignature) ToPdfObject() PdfObject {
+	if this.V != nil {
+		this.PdfField.V = this.V.ToPdfObject()
+	}
+	return this.PdfField.ToPdfObject()
+}
+
+// GetFullName returns the fully qualified name of the field, i.e. the partial names (T) of the field and its
+// ancestors separated by periods.
+func (this *PdfField) GetFullName() string {
+	name := ""
+	for field := this; field != nil; field = field.Parent {
+		str, ok := TraceToDirectObject(field.T).(*PdfObjectString)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = string(*str)
+		} else {
+			name = string(*str) + "." + name
+		}
+	}
+	return name
+}
+
+// getFieldType returns the (inheritable) field type of `field`.
+func getFieldType(field *PdfField) *PdfObjectName {
+	for ; field != nil; field = field.Parent {
+		if field.FT != nil {
+			return field.FT
+		}
+	}
+	return nil
+}
+
+// GetSignatureFields returns the signature fields of the interactive form, including unsigned fields
+// (where V is nil).
+func (this *PdfReader) GetSignatureFields() ([]*PdfFieldSignature, error) {
+	if this.AcroForm == nil || this.AcroForm.Fields == nil {
+		return nil, nil
+	}
+
+	var sigFields []*PdfFieldSignature
+	var collect func(fields []*PdfField) error
+	collect = func(fields []*PdfField) error {
+		for _, field := range fields {
+			// Kids without a partial name that are merged widget annotations are widgets of this field
+			// rather than descendant fields.
+			var kids []*PdfField
+			widgets := field.KidsA
+			for _, kid := range field.KidsF {
+				kidField, isField := kid.(*PdfField)
+				if !isField {
+					continue
+				}
+				if kidField.T == nil && len(kidField.KidsF) == 0 && len(kidField.KidsA) > 0 {
+					widgets = append(widgets, kidField.KidsA...)
+					continue
+				}
+				kids = append(kids, kidField)
+			}
+			if len(kids) > 0 {
+				if err := collect(kids); err != nil {
+					return err
+				}
+				continue
+			}
+
+			ft := getFieldType(field)
+			if ft == nil || *ft != "Sig" {
+				continue
+			}
+
+			sigField := &PdfFieldSignature{PdfField: field}
+			for _, annot := range widgets {
+				if widget, isWidget := annot.GetContext().(*PdfAnnotationWidget); isWidget {
+					sigField.Widget = widget
+					break
+				}
+			}
+
+			v, err := this.traceToObject(field.V)
+			if err != nil {
+				return err
+			}
+			switch t := v.(type) {
+			case *PdfIndirectObject:
+				sigField.V, err = this.newPdfSignatureFromIndirect(t)
+			case *PdfObjectDictionary:
+				sigField.V, err = this.newPdfSignatureFromIndirect(MakeIndirectObject(t))
+			}
+			if err != nil {
+				return err
+			}
+
+			sigFields = append(sigFields, sigField)
+		}
+		return nil
+	}
+
+	if err := collect(*this.AcroForm.Fields); err != nil {
+		return nil, err
+	}
+	return sigFields, nil
+}
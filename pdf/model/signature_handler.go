@@ -0,0 +1,269 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Hasher is the interface that wraps the basic Write method.  The signed byte ranges of a document are
+// written to it when signing.
+type Hasher interface {
+	Write(p []byte) (n int, err error)
+}
+
+// SignatureHandler interface defines the common functionality for PDF signature handlers, which
+// need to be capable of initializing, hashing and signing PDF signature dictionaries.
+// Implementations are available in the sighandler package.
+type SignatureHandler interface {
+	// IsApplicable returns true if the handler can process signature `sig`, based on its Filter/SubFilter.
+	IsApplicable(sig *PdfSignature) bool
+
+	// InitSignature sets the Filter/SubFilter entries and reserves room for the signature in the
+	// Contents entry of `sig`.
+	InitSignature(sig *PdfSignature) error
+
+	// NewDigest returns the hasher that the signed byte ranges are written to.
+	NewDigest(sig *PdfSignature) (Hasher, error)
+
+	// Sign generates the signature from `digest` and stores it in the Contents entry of `sig`.
+	Sign(sig *PdfSignature, digest Hasher) error
+
+	// Validate verifies the signature in the Contents entry of `sig` against `digest`, to which the signed
+	// byte ranges have been written.
+	Validate(sig *PdfSignature, digest Hasher) (SignatureValidationResult, error)
+}
+
+// SignatureValidationResult describes the outcome of validating a signature.
+type SignatureValidationResult struct {
+	// Information from the signature field and dictionary.
+	FieldName   string
+	Name        string
+	Reason      string
+	Location    string
+	ContactInfo string
+	Date        PdfDate
+	Filter      string
+	SubFilter   string
+
+	// IsSigned is true if the signature field has a signature value.
+	IsSigned bool
+	// ByteRangeValid is true if the signed byte ranges cover the file revision except for the Contents string.
+	ByteRangeValid bool
+	// DigestValid is true if the digest of the signed byte ranges matches the signed message digest.
+	DigestValid bool
+	// IsVerified is true if the signature value is valid for the signer certificate.
+	IsVerified bool
+	// IsTrusted is true if the signer certificate chains up to a trusted root.
+	IsTrusted bool
+	// ModifiedAfterSigning is true if the document has been updated after the signed revision.
+	ModifiedAfterSigning bool
+
+	// SigningTime as specified in the signature (if set).
+	SigningTime time.Time
+	// Certificates contains the certificate chain, starting with the signer certificate.
+	Certificates []*x509.Certificate
+
+	// Errors encountered during validation.
+	Errors []string
+}
+
+func (r SignatureValidationResult) String() string {
+	status := "invalid"
+	if r.ByteRangeValid && r.DigestValid && r.IsVerified {
+		status = "valid"
+	}
+	str := fmt.Sprintf("Signature %q: %s", r.FieldName, status)
+	if r.ModifiedAfterSigning {
+		str += " (modified after signing)"
+	}
+	for _, e := range r.Errors {
+		str += "\n - " + e
+	}
+	return str
+}
+
+// ValidateSignatures validates the signatures of the signed signature fields of the document with the
+// applicable handler among `handlers`.  A result is returned for each signed field.  Problems with a
+// single signature are reported in its result rather than as an error.
+func (this *PdfReader) ValidateSignatures(handlers []SignatureHandler) ([]SignatureValidationResult, error) {
+	if this.rs == nil {
+		return nil, errors.New("Reader source not available")
+	}
+
+	sigFields, err := this.GetSignatureFields()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SignatureValidationResult
+	for _, field := range sigFields {
+		if field.V == nil {
+			continue
+		}
+		sig := field.V
+
+		res := SignatureValidationResult{}
+		res.FieldName = field.GetFullName()
+		res.IsSigned = true
+		res.Filter = string(*sig.Filter)
+		if sig.SubFilter != nil {
+			res.SubFilter = string(*sig.SubFilter)
+		}
+		if sig.Name != nil {
+			res.Name = string(*sig.Name)
+		}
+		if sig.Reason != nil {
+			res.Reason = string(*sig.Reason)
+		}
+		if sig.Location != nil {
+			res.Location = string(*sig.Location)
+		}
+		if sig.ContactInfo != nil {
+			res.ContactInfo = string(*sig.ContactInfo)
+		}
+		if sig.M != nil {
+			if date, err := NewPdfDate(string(*sig.M)); err == nil {
+				res.Date = date
+			}
+		}
+
+		var handler SignatureHandler
+		for _, h := range handlers {
+			if h.IsApplicable(sig) {
+				handler = h
+				break
+			}
+		}
+		if handler == nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("No handler for %s/%s", res.Filter, res.SubFilter))
+			results = append(results, res)
+			continue
+		}
+
+		br, err := this.checkSignatureByteRange(sig)
+		if err != nil {
+			res.Errors = append(res.Errors, err.Error())
+			results = append(results, res)
+			continue
+		}
+		res.ByteRangeValid = true
+		res.ModifiedAfterSigning = int64(br[2]+br[3]) != this.parser.GetFileSize()
+
+		digest, err := handler.NewDigest(sig)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < 4; i += 2 {
+			if _, err := this.rs.Seek(int64(br[i]), io.SeekStart); err != nil {
+				return nil, err
+			}
+			if _, err := io.CopyN(digest, this.rs, int64(br[i+1])); err != nil {
+				return nil, err
+			}
+		}
+
+		vres, err := handler.Validate(sig, digest)
+		if err != nil {
+			common.Log.Debug("ERROR: Signature validation failed: %v", err)
+			res.Errors = append(res.Errors, err.Error())
+		}
+		res.DigestValid = vres.DigestValid
+		res.IsVerified = vres.IsVerified
+		res.IsTrusted = vres.IsTrusted
+		res.SigningTime = vres.SigningTime
+		res.Certificates = vres.Certificates
+		res.Errors = append(res.Errors, vres.Errors...)
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// checkSignatureByteRange checks that the ByteRange of `sig` covers a revision of the file, starting at
+// offset 0, with the only gap being the Contents hex string.  Returns the byte range on success.
+func (this *PdfReader) checkSignatureByteRange(sig *PdfSignature) ([]int, error) {
+	if sig.ByteRange == nil {
+		return nil, errors.New("ByteRange missing")
+	}
+	br, err := sig.ByteRange.ToIntegerArray()
+	if err != nil || len(br) != 4 {
+		return nil, errors.New("Invalid ByteRange")
+	}
+	if br[0] != 0 || br[1] <= 0 || br[2] <= br[1] || br[3] < 0 {
+		return nil, errors.New("ByteRange does not start at the beginning of the file")
+	}
+	if int64(br[2]+br[3]) > this.parser.GetFileSize() {
+		return nil, errors.New("ByteRange exceeds the file size")
+	}
+
+	// The gap must consist of exactly the hex encoded Contents string.
+	gap := make([]byte, br[2]-br[1])
+	if _, err := this.rs.Seek(int64(br[1]), io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(this.rs, gap); err != nil {
+		return nil, err
+	}
+	if len(gap) < 2 || gap[0] != '<' || gap[len(gap)-1] != '>' {
+		return nil, errors.New("ByteRange gap is not the Contents string")
+	}
+	hex := gap[1 : len(gap)-1]
+	if len(hex)%2 != 0 {
+		return nil, errors.New("ByteRange gap is not the Contents string")
+	}
+	contents := []byte(*sig.Contents)
+	for i := 0; i < len(hex); i += 2 {
+		hi, ok1 := fromHexDigit(hex[i])
+		lo, ok2 := fromHexDigit(hex[i+1])
+		if !ok1 || !ok2 || i/2 >= len(contents) || contents[i/2] != hi<<4|lo {
+			return nil, errors.New("ByteRange gap is not the Contents string")
+		}
+	}
+	if len(hex)/2 != len(contents) {
+		return nil, errors.New("ByteRange gap is not the Contents string")
+	}
+
+	return br, nil
+}
+
+// fromHexDigit returns the value of hexadecimal digit `c`.
+func fromHexDigit(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// getSignatureObjects returns the signature dictionaries among `objects` that are to be signed on output.
+// The signature dictionaries are updated from their models prior to writing.
+func getSignatureObjects(objects []PdfObject) map[PdfObject]*PdfSignature {
+	sigs := map[PdfObject]*PdfSignature{}
+	for _, obj := range objects {
+		io, isIndirect := obj.(*PdfIndirectObject)
+		if !isIndirect {
+			continue
+		}
+		if sd, isSignDict := io.PdfObject.(*pdfSignDictionary); isSignDict {
+			sd.signature.ToPdfObject()
+			sigs[obj] = sd.signature
+		}
+	}
+	return sigs
+}
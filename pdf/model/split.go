@@ -0,0 +1,239 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// PageRange is a range of pages from First to Last (inclusive, starting from 1).
+type PageRange struct {
+	First int
+	Last  int
+}
+
+// subset returns an editor for a part of the document with `pages`.  The part shares the outlines, form,
+// named destinations and page labels of the document, which are limited to the pages of the part when
+// written: outline items to pages of other parts are dropped.
+func (e *PdfEditor) subset(pages []*PdfPage) *PdfEditor {
+	part := &PdfEditor{
+		pages:         append([]*PdfPage{}, pages...),
+		pruneOutlines: true,
+		outlineTree:   e.outlineTree,
+		acroForm:      e.acroForm,
+		ocProperties:  e.ocProperties,
+		dests:         e.dests,
+		labels:        e.labels,
+		known:         map[*PdfIndirectObject]*PdfPage{},
+	}
+	for obj, page := range e.known {
+		part.known[obj] = page
+	}
+	return part
+}
+
+// SplitByPageRanges splits the document into a part per page range of `ranges`.
+func (e *PdfEditor) SplitByPageRanges(ranges []PageRange) ([]*PdfEditor, error) {
+	var parts []*PdfEditor
+	for _, r := range ranges {
+		if r.First < 1 || r.First > r.Last || r.Last > len(e.pages) {
+			return nil, fmt.Errorf("Invalid page range %d-%d (%d pages)", r.First, r.Last, len(e.pages))
+		}
+		parts = append(parts, e.subset(e.pages[r.First-1:r.Last]))
+	}
+	return parts, nil
+}
+
+// SplitByOutlineLevel splits the document at the pages the outline items at outline level `level` (1 for
+// the top-level items) refer to.  Each part starts at such a page and ends before the next one; pages
+// before the first of them form a part of their own.
+func (e *PdfEditor) SplitByOutlineLevel(level int) ([]*PdfEditor, error) {
+	if level < 1 {
+		return nil, errors.New("Invalid outline level")
+	}
+
+	pageIndex := map[PdfObject]int{}
+	for i, page := range e.pages {
+		pageIndex[page.GetPageAsIndirectObject()] = i
+	}
+
+	starts := map[int]bool{0: true}
+	var collect func(node *PdfOutlineTreeNode, depth int)
+	collect = func(node *PdfOutlineTreeNode, depth int) {
+		for ; node != nil; node = outlineNext(node) {
+			item, isItem := node.context.(*PdfOutlineItem)
+			if !isItem {
+				continue
+			}
+			if depth < level {
+				collect(item.First, depth+1)
+				continue
+			}
+			if page := e.getOutlineItemPage(item); page != nil {
+				if i, has := pageIndex[page]; has {
+					starts[i] = true
+				}
+			}
+		}
+	}
+	if e.outlineTree != nil {
+		collect(e.outlineTree.First, 1)
+	}
+
+	var indices []int
+	for i := range starts {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	var parts []*PdfEditor
+	for j, first := range indices {
+		last := len(e.pages)
+		if j+1 < len(indices) {
+			last = indices[j+1]
+		}
+		if first < last {
+			parts = append(parts, e.subset(e.pages[first:last]))
+		}
+	}
+	return parts, nil
+}
+
+// getOutlineItemPage returns the page object `item` refers to through its destination or GoTo action, or
+// nil if none.
+func (e *PdfEditor) getOutlineItemPage(item *PdfOutlineItem) PdfObject {
+	dest := item.Dest
+	if dest == nil {
+		action, isDict := TraceToDirectObject(item.A).(*PdfObjectDictionary)
+		if !isDict {
+			return nil
+		}
+		if s, isName := TraceToDirectObject(action.Get("S")).(*PdfObjectName); !isName || *s != "GoTo" {
+			return nil
+		}
+		dest = action.Get("D")
+	}
+
+	for i := 0; i < 2; i++ {
+		switch t := TraceToDirectObject(dest).(type) {
+		case *PdfObjectString:
+			dest = e.dests[string(*t)]
+		case *PdfObjectName:
+			dest = e.dests[string(*t)]
+		}
+		if dict, isDict := TraceToDirectObject(dest).(*PdfObjectDictionary); isDict {
+			dest = dict.Get("D")
+		}
+	}
+
+	arr, isArray := TraceToDirectObject(dest).(*PdfObjectArray)
+	if !isArray || len(*arr) == 0 || !isPageObject((*arr)[0]) {
+		return nil
+	}
+	return (*arr)[0]
+}
+
+// SplitBySize splits the document into parts of consecutive pages with an estimated file size of at most
+// `maxSize` bytes.  A page exceeding the size on its own forms a part of its own.  The estimate is based on
+// the objects used by the pages, resources shared by pages of a part are counted once.
+func (e *PdfEditor) SplitBySize(maxSize int64) ([]*PdfEditor, error) {
+	if maxSize <= 0 {
+		return nil, errors.New("Invalid maximum size")
+	}
+
+	// Objects are counted once per part, references to pages are not followed.
+	newSeen := func() map[PdfObject]bool {
+		seen := map[PdfObject]bool{}
+		for _, page := range e.pages {
+			seen[page.GetPageAsIndirectObject()] = true
+		}
+		return seen
+	}
+
+	var parts []*PdfEditor
+	var pages []*PdfPage
+	seen := newSeen()
+	size := int64(estimatedDocumentOverhead)
+	for _, page := range e.pages {
+		pageSize := estimatePageSize(page, seen)
+		if len(pages) > 0 && size+pageSize > maxSize {
+			parts = append(parts, e.subset(pages))
+			pages = nil
+			seen = newSeen()
+			size = estimatedDocumentOverhead
+			pageSize = estimatePageSize(page, seen)
+		}
+		pages = append(pages, page)
+		size += pageSize
+	}
+	if len(pages) > 0 {
+		parts = append(parts, e.subset(pages))
+	}
+	return parts, nil
+}
+
+const (
+	// estimatedDocumentOverhead is the estimated size of the header, catalog, page tree and trailer.
+	estimatedDocumentOverhead = 1024
+	// estimatedObjectOverhead is the estimated size of the object header, trailer and xref entry of an
+	// indirect object.
+	estimatedObjectOverhead = 40
+)
+
+// estimatePageSize returns the estimated size of `page` and the objects it uses not in `seen` when written.
+func estimatePageSize(page *PdfPage, seen map[PdfObject]bool) int64 {
+	size := int64(estimatedObjectOverhead)
+	dict := page.GetPageDict()
+	for _, key := range dict.Keys() {
+		if key == "Parent" {
+			continue
+		}
+		size += int64(len(key)) + 2 + estimateObjectSize(dict.Get(key), seen)
+	}
+	return size
+}
+
+// estimateObjectSize returns the estimated size of `obj` and the indirect objects it refers to not in
+// `seen` when written.
+func estimateObjectSize(obj PdfObject, seen map[PdfObject]bool) int64 {
+	switch t := obj.(type) {
+	case nil:
+		return 0
+	case *PdfIndirectObject:
+		if seen[t] {
+			return 10
+		}
+		seen[t] = true
+		return 10 + estimatedObjectOverhead + estimateObjectSize(t.PdfObject, seen)
+	case *PdfObjectStream:
+		if seen[t] {
+			return 10
+		}
+		seen[t] = true
+		size := int64(10 + estimatedObjectOverhead + len(t.Stream) + 20)
+		if t.PdfObjectDictionary != nil {
+			size += estimateObjectSize(t.PdfObjectDictionary, seen)
+		}
+		return size
+	case *PdfObjectDictionary:
+		size := int64(4)
+		for _, key := range t.Keys() {
+			size += int64(len(key)) + 2 + estimateObjectSize(t.Get(key), seen)
+		}
+		return size
+	case *PdfObjectArray:
+		size := int64(2)
+		for _, o := range *t {
+			size += 1 + estimateObjectSize(o, seen)
+		}
+		return size
+	}
+	return int64(len(obj.DefaultWriteString()))
+}
@@ -0,0 +1,150 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+// writeParts writes `parts` to /tmp/split_<i>.pdf and returns readers of the written files.
+func writeParts(t *testing.T, parts []*PdfEditor) []*PdfReader {
+	var readers []*PdfReader
+	for i, part := range parts {
+		path := fmt.Sprintf("/tmp/split_%d.pdf", i+1)
+		if err := part.WriteToFile(path); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		readers = append(readers, readTestFile(t, path))
+	}
+	return readers
+}
+
+func TestSplitByPageRanges(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	editor, err := NewPdfEditor(readTestFile(t, "/tmp/editor_src.pdf"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if _, err := editor.SplitByPageRanges([]PageRange{{2, 4}}); err == nil {
+		t.Errorf("Invalid page range should fail")
+	}
+	parts, err := editor.SplitByPageRanges([]PageRange{{1, 1}, {2, 3}})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	readers := writeParts(t, parts)
+
+	// Part 1: page 1 only, without the field, the named destination and the outline items of pages 2-3.
+	if widths := getPageWidths(t, readers[0]); len(widths) != 1 || widths[0] != 100 {
+		t.Fatalf("Invalid pages: %v", widths)
+	}
+	if items := getOutlineItems(readers[0].GetOutlineTree()); len(items) != 1 {
+		t.Errorf("Invalid outline: %d items", len(items))
+	}
+	if form := readers[0].AcroForm; form != nil && form.Fields != nil && len(*form.Fields) != 0 {
+		t.Errorf("Field on other page not removed")
+	}
+	page, _ := readers[0].GetPage(1)
+	if len(page.Annotations) != 0 {
+		t.Errorf("Link to other page not removed")
+	}
+	part1, err := NewPdfEditor(readers[0])
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(part1.dests) != 0 {
+		t.Errorf("Named destination to other page not removed")
+	}
+
+	// Part 2: pages 2-3 with the field and page labels continuing from ii.
+	if widths := getPageWidths(t, readers[1]); len(widths) != 2 || widths[0] != 200 {
+		t.Fatalf("Invalid pages: %v", widths)
+	}
+	if form := readers[1].AcroForm; form == nil || form.Fields == nil || len(*form.Fields) != 1 {
+		t.Errorf("Field missing")
+	}
+	part2, err := NewPdfEditor(readers[1])
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if labels := getLabelNumbers(part2); labels[0] != "r2" || labels[1] != "r3" {
+		t.Errorf("Invalid page labels: %v", labels)
+	}
+	if len(part2.dests) != 1 {
+		t.Errorf("Named destination missing")
+	}
+
+	// Splitting does not modify the document.
+	if err := editor.WriteToFile("/tmp/split_all.pdf"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	all := readTestFile(t, "/tmp/split_all.pdf")
+	if form := all.AcroForm; form == nil || form.Fields == nil || len(*form.Fields) != 1 {
+		t.Errorf("Field missing")
+	}
+	page, _ = all.GetPage(1)
+	if len(page.Annotations) != 1 {
+		t.Errorf("Link missing")
+	}
+}
+
+func TestSplitByOutlineLevel(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	editor, err := NewPdfEditor(readTestFile(t, "/tmp/editor_src.pdf"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	parts, err := editor.SplitByOutlineLevel(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("Invalid number of parts: %d", len(parts))
+	}
+	readers := writeParts(t, parts)
+	for i, reader := range readers {
+		items := getOutlineItems(reader.GetOutlineTree())
+		if len(items) != 1 || items[0].Title.String() != fmt.Sprintf("Page %d", i+1) {
+			t.Errorf("Invalid outline of part %d", i+1)
+		}
+	}
+
+	// No items at level 2.
+	parts, err = editor.SplitByOutlineLevel(2)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(parts) != 1 || parts[0].GetNumPages() != 3 {
+		t.Errorf("Invalid parts: %d", len(parts))
+	}
+}
+
+func TestSplitBySize(t *testing.T) {
+	makeEditorTestFile(t, "/tmp/editor_src.pdf")
+	editor, err := NewPdfEditor(readTestFile(t, "/tmp/editor_src.pdf"))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	parts, err := editor.SplitBySize(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(parts) != 3 {
+		t.Errorf("Invalid number of parts: %d", len(parts))
+	}
+
+	parts, err = editor.SplitBySize(1 << 20)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(parts) != 1 || parts[0].GetNumPages() != 3 {
+		t.Errorf("Invalid parts: %d", len(parts))
+	}
+}
@@ -0,0 +1,536 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Standard structure types (14.8.4).
+const (
+	StructTypeDocument   = "Document"
+	StructTypePart       = "Part"
+	StructTypeSect       = "Sect"
+	StructTypeDiv        = "Div"
+	StructTypeP          = "P"
+	StructTypeH          = "H"
+	StructTypeH1         = "H1"
+	StructTypeH2         = "H2"
+	StructTypeH3         = "H3"
+	StructTypeH4         = "H4"
+	StructTypeH5         = "H5"
+	StructTypeH6         = "H6"
+	StructTypeL          = "L"
+	StructTypeLI         = "LI"
+	StructTypeLbl        = "Lbl"
+	StructTypeLBody      = "LBody"
+	StructTypeTable      = "Table"
+	StructTypeTR         = "TR"
+	StructTypeTH         = "TH"
+	StructTypeTD         = "TD"
+	StructTypeTHead      = "THead"
+	StructTypeTBody      = "TBody"
+	StructTypeSpan       = "Span"
+	StructTypeLink       = "Link"
+	StructTypeAnnot      = "Annot"
+	StructTypeFigure     = "Figure"
+	StructTypeFormula    = "Formula"
+	StructTypeForm       = "Form"
+	StructTypeCaption    = "Caption"
+	StructTypeTOC        = "TOC"
+	StructTypeTOCI       = "TOCI"
+	StructTypeBlockQuote = "BlockQuote"
+	StructTypeNote       = "Note"
+)
+
+// PdfStructTreeRoot represents the structure tree root of a tagged document (14.7.2): the logical structure
+// of the document as a tree of structure elements, whose leaves are marked-content sequences of the page
+// contents identified by their MCID, and objects such as annotations.
+type PdfStructTreeRoot struct {
+	// K are the top-level structure elements, usually a single Document element.
+	K []*PdfStructElement
+	// RoleMap maps the structure types used in the document to standard structure types.
+	RoleMap map[string]string
+	// ClassMap is the class map of attribute objects, or nil.
+	ClassMap PdfObject
+
+	// ParentTree of a loaded structure tree, mapping the StructParents of pages to their structure elements.
+	parentTree *PdfNumberTree
+	elements   map[PdfObject]*PdfStructElement
+	container  *PdfIndirectObject
+}
+
+// PdfStructElement represents a structure element (14.7.2).
+type PdfStructElement struct {
+	// S is the structure type, e.g. StructTypeP.
+	S      string
+	Parent *PdfStructElement
+	// Page is the page on which the content of the element is drawn (Pg), or nil.
+	Page       *PdfPage
+	ID         string
+	Title      string
+	Lang       string
+	Alt        string
+	ActualText string
+	// E is the expanded form of an abbreviation.
+	E string
+	// Attributes are the attribute objects of the element (A), e.g. << /O /Layout /Placement /Block >>.
+	Attributes []*PdfObjectDictionary
+	Kids       []*PdfStructKid
+
+	container *PdfIndirectObject
+}
+
+// PdfStructKid is a kid of a structure element: a structure element, a marked-content sequence or an
+// object.
+type PdfStructKid struct {
+	// Element is the kid structure element, nil if the kid is content.
+	Element *PdfStructElement
+	// MCID is the marked-content identifier of a marked-content sequence, -1 for other kids.
+	MCID int64
+	// Page is the page of the marked-content sequence or object, nil to use that of the parent element.
+	Page *PdfPage
+	// Stream is the content stream containing the marked-content sequence, e.g. a form XObject, if not in
+	// the page contents (Stm).
+	Stream PdfObject
+	// Object is the referenced object, e.g. an annotation.  Nil for marked-content sequences.
+	Object PdfObject
+}
+
+// NewPdfStructTreeRoot returns an empty structure tree root.
+func NewPdfStructTreeRoot() *PdfStructTreeRoot {
+	return &PdfStructTreeRoot{RoleMap: map[string]string{}, container: MakeIndirectObject(MakeDict())}
+}
+
+// NewPdfStructElement returns a new structure element of structure type `structType`.
+func NewPdfStructElement(structType string) *PdfStructElement {
+	return &PdfStructElement{S: structType, container: MakeIndirectObject(MakeDict())}
+}
+
+// AddKid adds structure element `elem` as the last kid of the element.
+func (elem *PdfStructElement) AddKid(kid *PdfStructElement) {
+	kid.Parent = elem
+	elem.Kids = append(elem.Kids, &PdfStructKid{Element: kid, MCID: -1})
+}
+
+// AddMarkedContent adds the marked-content sequence with identifier `mcid` of the contents of page `page`
+// as the last kid of the element.
+func (elem *PdfStructElement) AddMarkedContent(page *PdfPage, mcid int64) {
+	if elem.Page == nil && len(elem.Kids) == 0 {
+		elem.Page = page
+	}
+	kid := &PdfStructKid{MCID: mcid}
+	if page != elem.Page {
+		kid.Page = page
+	}
+	elem.Kids = append(elem.Kids, kid)
+}
+
+// AddObject adds object `obj` of page `page`, such as an annotation, as the last kid of the element.
+func (elem *PdfStructElement) AddObject(page *PdfPage, obj PdfObject) {
+	elem.Kids = append(elem.Kids, &PdfStructKid{MCID: -1, Page: page, Object: obj})
+}
+
+// GetMarkedContent returns the marked-content identifiers of the element's marked-content sequences in
+// the contents of page `page`.
+func (elem *PdfStructElement) GetMarkedContent(page *PdfPage) []int64 {
+	var mcids []int64
+	for _, kid := range elem.Kids {
+		if kid.MCID < 0 || kid.Stream != nil {
+			continue
+		}
+		kidPage := kid.Page
+		if kidPage == nil {
+			kidPage = elem.Page
+		}
+		if kidPage == page {
+			mcids = append(mcids, kid.MCID)
+		}
+	}
+	return mcids
+}
+
+// GetContainingPdfObject returns the indirect object containing the structure element dictionary.
+func (elem *PdfStructElement) GetContainingPdfObject() PdfObject {
+	return elem.container
+}
+
+// GetRole returns the standard structure type that structure type `structType` is mapped to by the role
+// map, or `structType` if not mapped.
+func (root *PdfStructTreeRoot) GetRole(structType string) string {
+	// Guard against circular mappings.
+	for i := 0; i < len(root.RoleMap); i++ {
+		role, has := root.RoleMap[structType]
+		if !has || role == structType {
+			break
+		}
+		structType = role
+	}
+	return structType
+}
+
+// Walk calls `visit` for each structure element of the tree in depth-first order, with its depth (0 for
+// the top-level elements).  Stops if `visit` returns false.
+func (root *PdfStructTreeRoot) Walk(visit func(elem *PdfStructElement, depth int) bool) {
+	var walk func(elems []*PdfStructElement, depth int) bool
+	walk = func(elems []*PdfStructElement, depth int) bool {
+		for _, elem := range elems {
+			if !visit(elem, depth) {
+				return false
+			}
+			var kids []*PdfStructElement
+			for _, kid := range elem.Kids {
+				if kid.Element != nil {
+					kids = append(kids, kid.Element)
+				}
+			}
+			if !walk(kids, depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(root.K, 0)
+}
+
+// GetElementByMCID returns the structure element of marked-content sequence `mcid` of the contents of page
+// `page`, looked up in the parent tree, or nil if not found.
+func (root *PdfStructTreeRoot) GetElementByMCID(page *PdfPage, mcid int64) (*PdfStructElement, error) {
+	key, isInt := TraceToDirectObject(page.StructParents).(*PdfObjectInteger)
+	if !isInt || root.parentTree == nil {
+		return nil, nil
+	}
+	obj, err := root.parentTree.Get(int64(*key))
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	arr, isArray := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !isArray {
+		return nil, fmt.Errorf("Invalid parent tree entry (%T)", obj)
+	}
+	if mcid < 0 || mcid >= int64(len(*arr)) {
+		return nil, nil
+	}
+	return root.elements[(*arr)[mcid]], nil
+}
+
+// newPdfStructTreeRootFromObject loads the structure tree root `obj` read by `reader`, with the pages of
+// the structure elements resolved with the pages of `reader`.
+func newPdfStructTreeRootFromObject(reader *PdfReader, obj PdfObject) (*PdfStructTreeRoot, error) {
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid structure tree root (%T)", obj)
+	}
+
+	root := NewPdfStructTreeRoot()
+	if container, isIndirect := obj.(*PdfIndirectObject); isIndirect {
+		root.container = container
+	}
+	root.elements = map[PdfObject]*PdfStructElement{}
+
+	if roleMap, isDict := TraceToDirectObject(dict.Get("RoleMap")).(*PdfObjectDictionary); isDict {
+		for _, key := range roleMap.Keys() {
+			if role, isName := TraceToDirectObject(roleMap.Get(key)).(*PdfObjectName); isName {
+				root.RoleMap[string(key)] = string(*role)
+			}
+		}
+	}
+	root.ClassMap = dict.Get("ClassMap")
+	if parentTree := dict.Get("ParentTree"); parentTree != nil {
+		root.parentTree = newPdfNumberTreeFromObject(reader, parentTree)
+	}
+
+	pages := map[PdfObject]*PdfPage{}
+	for _, page := range reader.PageList {
+		pages[page.GetContainingPdfObject()] = page
+	}
+
+	for _, kid := range getStructKids(dict.Get("K")) {
+		elem, err := root.loadElement(kid, nil, pages)
+		if err != nil {
+			return nil, err
+		}
+		if elem != nil {
+			root.K = append(root.K, elem)
+		}
+	}
+	return root, nil
+}
+
+// loadElement loads structure element `obj` with parent `parent`.
+func (root *PdfStructTreeRoot) loadElement(obj PdfObject, parent *PdfStructElement,
+	pages map[PdfObject]*PdfPage) (*PdfStructElement, error) {
+	if elem, has := root.elements[obj]; has {
+		// Guard against loops.
+		common.Log.Debug("ERROR: Structure element referenced more than once")
+		return elem, nil
+	}
+	dict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+	if !isDict {
+		return nil, fmt.Errorf("Invalid structure element (%T)", obj)
+	}
+
+	elem := &PdfStructElement{Parent: parent}
+	if container, isIndirect := obj.(*PdfIndirectObject); isIndirect {
+		elem.container = container
+	} else {
+		elem.container = MakeIndirectObject(dict)
+	}
+	root.elements[obj] = elem
+
+	if s, isName := TraceToDirectObject(dict.Get("S")).(*PdfObjectName); isName {
+		elem.S = string(*s)
+	}
+	elem.Page = pages[dict.Get("Pg")]
+	for key, str := range map[PdfObjectName]*string{
+		"ID": &elem.ID, "T": &elem.Title, "Lang": &elem.Lang, "Alt": &elem.Alt, "ActualText": &elem.ActualText,
+		"E": &elem.E,
+	} {
+		if s, isString := TraceToDirectObject(dict.Get(key)).(*PdfObjectString); isString {
+			*str = DecodeTextString(string(*s))
+		}
+	}
+
+	switch a := TraceToDirectObject(dict.Get("A")).(type) {
+	case *PdfObjectDictionary:
+		elem.Attributes = []*PdfObjectDictionary{a}
+	case *PdfObjectArray:
+		for _, obj := range *a {
+			// Attribute objects may be followed by revision numbers.
+			if attr, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary); isDict {
+				elem.Attributes = append(elem.Attributes, attr)
+			}
+		}
+	}
+
+	for _, obj := range getStructKids(dict.Get("K")) {
+		kid := &PdfStructKid{MCID: -1}
+		switch t := TraceToDirectObject(obj).(type) {
+		case *PdfObjectInteger:
+			kid.MCID = int64(*t)
+		case *PdfObjectDictionary:
+			kidType, _ := TraceToDirectObject(t.Get("Type")).(*PdfObjectName)
+			switch {
+			case kidType != nil && *kidType == "MCR":
+				mcid, isInt := TraceToDirectObject(t.Get("MCID")).(*PdfObjectInteger)
+				if !isInt {
+					common.Log.Debug("ERROR: Marked-content reference without MCID")
+					continue
+				}
+				kid.MCID = int64(*mcid)
+				kid.Page = pages[t.Get("Pg")]
+				kid.Stream = t.Get("Stm")
+			case kidType != nil && *kidType == "OBJR":
+				kid.Page = pages[t.Get("Pg")]
+				kid.Object = t.Get("Obj")
+			default:
+				kidElem, err := root.loadElement(obj, elem, pages)
+				if err != nil {
+					return nil, err
+				}
+				kid.Element = kidElem
+			}
+		default:
+			common.Log.Debug("ERROR: Invalid structure element kid (%T)", obj)
+			continue
+		}
+		elem.Kids = append(elem.Kids, kid)
+	}
+	return elem, nil
+}
+
+// getStructKids returns the kids of K entry `obj`: a single kid or an array of kids.
+func getStructKids(obj PdfObject) []PdfObject {
+	if obj == nil || isNullObject(obj) {
+		return nil
+	}
+	if arr, isArray := TraceToDirectObject(obj).(*PdfObjectArray); isArray {
+		return *arr
+	}
+	return []PdfObject{obj}
+}
+
+// ToPdfObject returns the structure tree root dictionary (in an indirect object), with the structure
+// element dictionaries and the parent tree generated from the elements.  The StructParents and
+// StructParent entries of the pages and objects of the elements are set.
+func (root *PdfStructTreeRoot) ToPdfObject() (PdfObject, error) {
+	dict := MakeDict()
+	dict.Set("Type", MakeName("StructTreeRoot"))
+
+	b := &structTreeBuilder{
+		pageEntries: map[*PdfPage][]PdfObject{},
+		parentTree:  NewPdfNumberTree(),
+	}
+	kids := PdfObjectArray{}
+	for _, elem := range root.K {
+		obj, err := b.build(elem, root.container)
+		if err != nil {
+			return nil, err
+		}
+		kids = append(kids, obj)
+	}
+	if len(kids) > 0 {
+		dict.Set("K", &kids)
+	}
+
+	for i, page := range b.pages {
+		key := int64(i)
+		page.StructParents = MakeInteger(key)
+		page.pageDict.Set("StructParents", page.StructParents)
+		entries := PdfObjectArray(b.pageEntries[page])
+		b.parentTree.entries[key] = &entries
+	}
+	for i, obj := range b.objects {
+		key := int64(len(b.pages) + i)
+		objDict, isDict := TraceToDirectObject(obj).(*PdfObjectDictionary)
+		if !isDict {
+			return nil, fmt.Errorf("Invalid structure object (%T)", obj)
+		}
+		objDict.Set("StructParent", MakeInteger(key))
+		b.parentTree.entries[key] = b.objectParents[i]
+	}
+	parentTree, err := b.parentTree.ToPdfObject()
+	if err != nil {
+		return nil, err
+	}
+	dict.Set("ParentTree", parentTree)
+	dict.Set("ParentTreeNextKey", MakeInteger(int64(len(b.pages)+len(b.objects))))
+
+	if len(root.RoleMap) > 0 {
+		roleMap := MakeDict()
+		for structType, role := range root.RoleMap {
+			roleMap.Set(PdfObjectName(structType), MakeName(role))
+		}
+		dict.Set("RoleMap", roleMap)
+	}
+	if root.ClassMap != nil {
+		dict.Set("ClassMap", root.ClassMap)
+	}
+
+	root.container.PdfObject = dict
+	return root.container, nil
+}
+
+// structTreeBuilder builds the structure element dictionaries and the parent tree of a structure tree.
+type structTreeBuilder struct {
+	// Pages with marked content, in order of first use, and their parent tree entries indexed by MCID.
+	pages       []*PdfPage
+	pageEntries map[*PdfPage][]PdfObject
+	// Objects of the structure tree and their parent elements.
+	objects       []PdfObject
+	objectParents []PdfObject
+	parentTree    *PdfNumberTree
+	// Elements built, to guard against loops.
+	built map[*PdfStructElement]bool
+}
+
+// build returns the structure element dictionary of `elem` with parent `parent`.
+func (b *structTreeBuilder) build(elem *PdfStructElement, parent PdfObject) (PdfObject, error) {
+	if b.built == nil {
+		b.built = map[*PdfStructElement]bool{}
+	}
+	if b.built[elem] {
+		return nil, errors.New("Structure element used more than once")
+	}
+	b.built[elem] = true
+
+	dict := MakeDict()
+	dict.Set("Type", MakeName("StructElem"))
+	dict.Set("S", MakeName(elem.S))
+	dict.Set("P", parent)
+	if elem.Page != nil {
+		dict.Set("Pg", elem.Page.GetContainingPdfObject())
+	}
+	for _, entry := range []struct {
+		key   PdfObjectName
+		value string
+	}{
+		{"ID", elem.ID}, {"T", elem.Title}, {"Lang", elem.Lang}, {"Alt", elem.Alt},
+		{"ActualText", elem.ActualText}, {"E", elem.E},
+	} {
+		if entry.value != "" {
+			dict.Set(entry.key, MakeString(EncodeTextString(entry.value)))
+		}
+	}
+	if len(elem.Attributes) == 1 {
+		dict.Set("A", elem.Attributes[0])
+	} else if len(elem.Attributes) > 1 {
+		attrs := PdfObjectArray{}
+		for _, attr := range elem.Attributes {
+			attrs = append(attrs, attr)
+		}
+		dict.Set("A", &attrs)
+	}
+	elem.container.PdfObject = dict
+
+	kids := PdfObjectArray{}
+	for _, kid := range elem.Kids {
+		page := kid.Page
+		if page == nil {
+			page = elem.Page
+		}
+
+		switch {
+		case kid.Element != nil:
+			obj, err := b.build(kid.Element, elem.container)
+			if err != nil {
+				return nil, err
+			}
+			kids = append(kids, obj)
+		case kid.Object != nil:
+			objr := MakeDict()
+			objr.Set("Type", MakeName("OBJR"))
+			if page != nil {
+				objr.Set("Pg", page.GetContainingPdfObject())
+			}
+			objr.Set("Obj", kid.Object)
+			kids = append(kids, objr)
+			b.objects = append(b.objects, kid.Object)
+			b.objectParents = append(b.objectParents, elem.container)
+		case kid.MCID >= 0:
+			if kid.Stream == nil && page != nil {
+				b.addMarkedContent(page, kid.MCID, elem.container)
+			}
+			if kid.Page == nil && kid.Stream == nil {
+				kids = append(kids, MakeInteger(kid.MCID))
+				continue
+			}
+			mcr := MakeDict()
+			mcr.Set("Type", MakeName("MCR"))
+			if kid.Page != nil {
+				mcr.Set("Pg", kid.Page.GetContainingPdfObject())
+			}
+			if kid.Stream != nil {
+				mcr.Set("Stm", kid.Stream)
+			}
+			mcr.Set("MCID", MakeInteger(kid.MCID))
+			kids = append(kids, mcr)
+		}
+	}
+	if len(kids) == 1 {
+		dict.Set("K", kids[0])
+	} else if len(kids) > 1 {
+		dict.Set("K", &kids)
+	}
+	return elem.container, nil
+}
+
+// addMarkedContent sets the parent tree entry of marked-content sequence `mcid` of page `page` to `elem`.
+func (b *structTreeBuilder) addMarkedContent(page *PdfPage, mcid int64, elem PdfObject) {
+	entries, has := b.pageEntries[page]
+	if !has {
+		b.pages = append(b.pages, page)
+	}
+	for int64(len(entries)) <= mcid {
+		entries = append(entries, MakeNull())
+	}
+	entries[mcid] = elem
+	b.pageEntries[page] = entries
+}
@@ -0,0 +1,118 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+func TestStructTree(t *testing.T) {
+	writer := NewPdfWriter()
+	var pages []*PdfPage
+	for i := 0; i < 2; i++ {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+		page.Resources = NewPdfPageResources()
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		pages = append(pages, page)
+	}
+
+	root := NewPdfStructTreeRoot()
+	root.RoleMap["Heading"] = StructTypeH1
+	doc := NewPdfStructElement(StructTypeDocument)
+	doc.Lang = "en-US"
+	root.K = append(root.K, doc)
+
+	heading := NewPdfStructElement("Heading")
+	heading.AddMarkedContent(pages[0], 0)
+	doc.AddKid(heading)
+	// Paragraph continued on the next page.
+	p := NewPdfStructElement(StructTypeP)
+	p.AddMarkedContent(pages[0], 1)
+	p.AddMarkedContent(pages[1], 0)
+	doc.AddKid(p)
+	figure := NewPdfStructElement(StructTypeFigure)
+	figure.Alt = "A red square"
+	layout := MakeDict()
+	layout.Set("O", MakeName("Layout"))
+	layout.Set("BBox", MakeArray(MakeInteger(10), MakeInteger(10), MakeInteger(50), MakeInteger(50)))
+	figure.Attributes = append(figure.Attributes, layout)
+	figure.AddMarkedContent(pages[1], 1)
+	doc.AddKid(figure)
+
+	writer.SetStructTreeRoot(root)
+	f, err := os.Create("/tmp/struct_tree.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader := readTestFile(t, "/tmp/struct_tree.pdf")
+	if tagged, err := reader.IsTagged(); err != nil || !tagged {
+		t.Errorf("Document should be tagged (%v)", err)
+	}
+	root, err = reader.GetStructTreeRoot()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if root == nil || len(root.K) != 1 || root.K[0].S != StructTypeDocument || root.K[0].Lang != "en-US" {
+		t.Fatalf("Invalid structure tree: %+v", root)
+	}
+	if root.GetRole("Heading") != StructTypeH1 || root.GetRole(StructTypeP) != StructTypeP {
+		t.Errorf("Invalid role map: %v", root.RoleMap)
+	}
+
+	var types []string
+	root.Walk(func(elem *PdfStructElement, depth int) bool {
+		types = append(types, elem.S)
+		return true
+	})
+	if len(types) != 4 || types[1] != "Heading" || types[2] != StructTypeP || types[3] != StructTypeFigure {
+		t.Fatalf("Invalid elements: %v", types)
+	}
+
+	page1, page2 := reader.PageList[0], reader.PageList[1]
+	doc = root.K[0]
+	p = doc.Kids[1].Element
+	if p.Parent != doc || p.Page != page1 {
+		t.Errorf("Invalid paragraph: %+v", p)
+	}
+	if mcids := p.GetMarkedContent(page2); len(mcids) != 1 || mcids[0] != 0 {
+		t.Errorf("Invalid marked content: %v", mcids)
+	}
+	figure = doc.Kids[2].Element
+	if figure.Alt != "A red square" || len(figure.Attributes) != 1 {
+		t.Errorf("Invalid figure: %+v", figure)
+	}
+
+	for _, tcase := range []struct {
+		page     *PdfPage
+		mcid     int64
+		expected *PdfStructElement
+	}{
+		{page1, 0, doc.Kids[0].Element},
+		{page1, 1, p},
+		{page2, 0, p},
+		{page2, 1, figure},
+		{page2, 2, nil},
+	} {
+		elem, err := root.GetElementByMCID(tcase.page, tcase.mcid)
+		if err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+		if elem != tcase.expected {
+			t.Errorf("MCID %d: %v != %v", tcase.mcid, elem, tcase.expected)
+		}
+	}
+}
@@ -57,6 +57,8 @@ type PdfWriter struct {
 	catalog     *PdfObjectDictionary
 	fields      []PdfObject
 	infoObj     *PdfIndirectObject
+	info        *PdfInfo
+	xmp         *XmpMetadata
 
 	// Encryption
 	crypter     *PdfCrypt
@@ -77,6 +79,19 @@ type PdfWriter struct {
 
 	// Forms.
 	acroForm *PdfAcroForm
+
+	// Attachments.
+	embeddedFiles   map[string]*PdfFileSpec
+	associatedFiles []*PdfFileSpec
+
+	// Logical structure.
+	structTreeRoot *PdfStructTreeRoot
+
+	// PDF/A conformance level of the output.
+	pdfaConformance PdfAConformance
+
+	// Embedded fonts replacing the non-embedded fonts in PDF/A output, by font name.
+	pdfaFonts map[string]*PdfFont
 }
 
 func NewPdfWriter() PdfWriter {
@@ -92,11 +107,9 @@ func NewPdfWriter() PdfWriter {
 	w.minorVersion = 3
 
 	// Creation info.
-	infoDict := MakeDict()
-	infoDict.Set("Producer", MakeString(getPdfProducer()))
-	infoDict.Set("Creator", MakeString(getPdfCreator()))
+	w.info = &PdfInfo{Producer: getPdfProducer(), Creator: getPdfCreator()}
 	infoObj := PdfIndirectObject{}
-	infoObj.PdfObject = infoDict
+	infoObj.PdfObject = w.info.ToPdfObject()
 	w.infoObj = &infoObj
 	w.addObject(&infoObj)
 
@@ -149,6 +162,15 @@ func (this *PdfWriter) SetOCProperties(ocProperties PdfObject) error {
 	return nil
 }
 
+// SetOptionalContentProperties sets the optional content properties of the document.  The PDF version is
+// raised to 1.5 if lower, as required for optional content.
+func (this *PdfWriter) SetOptionalContentProperties(props *PdfOCProperties) error {
+	if this.majorVersion == 1 && this.minorVersion < 5 {
+		this.minorVersion = 5
+	}
+	return this.SetOCProperties(props.ToPdfObject())
+}
+
 func (this *PdfWriter) hasObject(obj PdfObject) bool {
 	// Check if already added.
 	for _, o := range this.objects {
@@ -385,6 +407,133 @@ func (this *PdfWriter) AddOutlineTree(outlineTree *PdfOutlineTreeNode) {
 	this.outlineTree = outlineTree
 }
 
+// SetNameDictionary sets the name dictionary (Names entry of the catalog), e.g. with the named
+// destinations of the document.
+func (this *PdfWriter) SetNameDictionary(names PdfObject) error {
+	if names == nil {
+		return nil
+	}
+	this.catalog.Set("Names", names)
+	return this.addObjects(names)
+}
+
+// SetNameTree sets `tree` as entry `name` (e.g. Dests or EmbeddedFiles) of the name dictionary of the
+// catalog.
+func (this *PdfWriter) SetNameTree(name PdfObjectName, tree *PdfNameTree) error {
+	obj, err := tree.ToPdfObject()
+	if err != nil {
+		return err
+	}
+
+	names, isDict := TraceToDirectObject(this.catalog.Get("Names")).(*PdfObjectDictionary)
+	if !isDict {
+		names = MakeDict()
+		this.catalog.Set("Names", MakeIndirectObject(names))
+	}
+	names.Set(name, obj)
+	if err := this.addObjects(this.catalog.Get("Names")); err != nil {
+		return err
+	}
+	return this.addObjects(obj)
+}
+
+// GetPdfInfo returns the document information of the output, with the Producer and Creator set to unidoc
+// by default.  The returned information can be modified.
+func (this *PdfWriter) GetPdfInfo() *PdfInfo {
+	return this.info
+}
+
+// SetPdfInfo sets the document information of the output.
+func (this *PdfWriter) SetPdfInfo(info *PdfInfo) {
+	if info == nil {
+		info = &PdfInfo{}
+	}
+	this.info = info
+}
+
+// SetXmpMetadata sets the XMP metadata of the output (Metadata entry of the catalog).  When writing, the
+// document information and the metadata are merged, keeping both consistent: empty document information
+// entries are taken from the corresponding properties, and the properties are updated from the entries that
+// are set.
+func (this *PdfWriter) SetXmpMetadata(xmp *XmpMetadata) {
+	this.xmp = xmp
+}
+
+// AddEmbeddedFile attaches file `spec` to the document as `name` in the EmbeddedFiles name tree, replacing
+// any file attached with the same name.
+func (this *PdfWriter) AddEmbeddedFile(name string, spec *PdfFileSpec) error {
+	if !spec.IsEmbedded() {
+		return errors.New("File not embedded")
+	}
+	if this.embeddedFiles == nil {
+		this.embeddedFiles = map[string]*PdfFileSpec{}
+	}
+	this.embeddedFiles[name] = spec
+	return nil
+}
+
+// AddAssociatedFile associates file `spec` with the document (AF entry of the catalog), e.g. the invoice
+// data of a ZUGFeRD/Factur-X e-invoice with AFRelationship Data or Alternative.  Embedded associated files
+// should also be attached with AddEmbeddedFile.
+func (this *PdfWriter) AddAssociatedFile(spec *PdfFileSpec) {
+	this.associatedFiles = append(this.associatedFiles, spec)
+}
+
+// SetStructTreeRoot sets the logical structure of the document and marks it as a tagged PDF (MarkInfo).  The
+// structure tree is generated when writing, after all the pages have been added, and sets the StructParents
+// entries of the pages.  The PDF version is raised to 1.4 if lower.
+func (this *PdfWriter) SetStructTreeRoot(root *PdfStructTreeRoot) {
+	if this.majorVersion == 1 && this.minorVersion < 4 {
+		this.minorVersion = 4
+	}
+	this.structTreeRoot = root
+}
+
+// SetOpenAction sets the action performed when the document is opened (OpenAction entry of the catalog),
+// e.g. a GoTo action displaying a destination.
+func (this *PdfWriter) SetOpenAction(action *PdfAction) error {
+	if action == nil {
+		this.catalog.Remove("OpenAction")
+		return nil
+	}
+	obj := action.ToPdfObject()
+	this.catalog.Set("OpenAction", obj)
+	return this.addObjects(obj)
+}
+
+// SetPageLabels sets the page labels number tree (PageLabels entry of the catalog).
+func (this *PdfWriter) SetPageLabels(pageLabels PdfObject) error {
+	if pageLabels == nil {
+		return nil
+	}
+	this.catalog.Set("PageLabels", pageLabels)
+	return this.addObjects(pageLabels)
+}
+
+// SetPageLabelRanges sets the page labels of the document to page labelling ranges `ranges`.  The first
+// range should start at the first page (index 0).
+func (this *PdfWriter) SetPageLabelRanges(ranges []*PdfPageLabelRange) error {
+	tree := NewPdfNumberTree()
+	for _, labelRange := range ranges {
+		if labelRange.StartPage < 0 {
+			return errors.New("Invalid page label range start")
+		}
+		tree.entries[int64(labelRange.StartPage)] = labelRange.ToPdfObject()
+	}
+	if len(tree.entries) == 0 {
+		return nil
+	}
+	if _, has := tree.entries[0]; !has {
+		common.Log.Debug("Page labels do not start at the first page")
+	}
+
+	obj, err := tree.ToPdfObject()
+	if err != nil {
+		return err
+	}
+	return this.SetPageLabels(obj)
+}
+
 // Look for a specific key.  Returns a list of entries.
 // What if something appears on many pages?
 func (this *PdfWriter) seekByName(obj PdfObject, followKeys []string, key string) ([]PdfObject, error) {
@@ -490,7 +639,22 @@ const (
 	AES_256bit
 )
 
-// Encrypt the output file with a specified user/owner password.
+// generateIDs generates the file identifier of the trailer (ID).
+func (this *PdfWriter) generateIDs() {
+	hashcode := md5.Sum([]byte(time.Now().Format(time.RFC850)))
+	id0 := PdfObjectString(hashcode[:])
+	b := make([]byte, 100)
+	rand.Read(b)
+	hashcode = md5.Sum(b)
+	id1 := PdfObjectString(hashcode[:])
+	common.Log.Trace("Random b: % x", b)
+
+	this.ids = &PdfObjectArray{&id0, &id1}
+	common.Log.Trace("Gen Id 0: % x", id0)
+}
+
+// Encrypt the output file with a specified user/owner password.  Encrypted output cannot be signed, Write fails
+// if a signature field is added.
 func (this *PdfWriter) Encrypt(userPass, ownerPass []byte, options *EncryptOptions) error {
 	crypter := PdfCrypt{}
 	this.crypter = &crypter
@@ -551,16 +715,8 @@ func (this *PdfWriter) Encrypt(userPass, ownerPass []byte, options *EncryptOptio
 	this.encryptDict = ed
 
 	// Prepare the ID object for the trailer.
-	hashcode := md5.Sum([]byte(time.Now().Format(time.RFC850)))
-	id0 := PdfObjectString(hashcode[:])
-	b := make([]byte, 100)
-	rand.Read(b)
-	hashcode = md5.Sum(b)
-	id1 := PdfObjectString(hashcode[:])
-	common.Log.Trace("Random b: % x", b)
-
-	this.ids = &PdfObjectArray{&id0, &id1}
-	common.Log.Trace("Gen Id 0: % x", id0)
+	this.generateIDs()
+	id0 := *(*this.ids)[0].(*PdfObjectString)
 
 	// Generate encryption parameters
 	if crypter.R < 5 {
@@ -647,6 +803,66 @@ func (this *PdfWriter) Write(ws io.WriteSeeker) error {
 		}
 	}
 
+	// PDF/A conformance.
+	if this.pdfaConformance != PdfAConformanceNone {
+		if err := this.preparePdfA(); err != nil {
+			return err
+		}
+	}
+
+	// Document information and metadata.
+	info := this.info
+	if this.xmp != nil {
+		// Entries missing in the document information are taken from the metadata, which is then updated
+		// from the entries.
+		merged := *this.info
+		merged.fillEmpty(this.xmp.GetInfo())
+		info = &merged
+		this.xmp.SetInfo(info)
+		metadata := this.xmp.ToPdfObject()
+		this.catalog.Set("Metadata", metadata)
+		if err := this.addObjects(metadata); err != nil {
+			return err
+		}
+	}
+	this.infoObj.PdfObject = info.ToPdfObject()
+
+	// Attachments.
+	if len(this.embeddedFiles) > 0 {
+		tree := NewPdfNameTree()
+		for name, spec := range this.embeddedFiles {
+			tree.entries[name] = spec.ToPdfObject()
+		}
+		if err := this.SetNameTree("EmbeddedFiles", tree); err != nil {
+			return err
+		}
+	}
+	if len(this.associatedFiles) > 0 {
+		af := PdfObjectArray{}
+		for _, spec := range this.associatedFiles {
+			af = append(af, spec.ToPdfObject())
+		}
+		this.catalog.Set("AF", &af)
+		if err := this.addObjects(&af); err != nil {
+			return err
+		}
+	}
+
+	// Logical structure.
+	if this.structTreeRoot != nil {
+		structTreeRoot, err := this.structTreeRoot.ToPdfObject()
+		if err != nil {
+			return err
+		}
+		this.catalog.Set("StructTreeRoot", structTreeRoot)
+		markInfo := MakeDict()
+		markInfo.Set("Marked", MakeBool(true))
+		this.catalog.Set("MarkInfo", markInfo)
+		if err := this.addObjects(structTreeRoot); err != nil {
+			return err
+		}
+	}
+
 	// Check pending objects prior to write.
 	for pendingObj, pendingObjDict := range this.pendingObjects {
 		if !this.hasObject(pendingObj) {
@@ -664,6 +880,23 @@ func (this *PdfWriter) Write(ws io.WriteSeeker) error {
 	// Set version in the catalog.
 	this.catalog.Set("Version", MakeName(fmt.Sprintf("%d.%d", this.majorVersion, this.minorVersion)))
 
+	// Signatures are applied after the output has been generated, requiring the output to be buffered.
+	sigObjects := getSignatureObjects(this.objects)
+	if len(sigObjects) > 1 {
+		// Each signature covers the whole file including the other signatures.
+		return errors.New("Only one signature can be added per document revision")
+	}
+	if len(sigObjects) > 0 && this.crypter != nil {
+		// The signature dictionary is written as is, its strings are not encrypted.
+		return errors.New("Signing encrypted documents is not supported")
+	}
+	var sigBuf *bufferWriteSeeker
+	output := ws
+	if len(sigObjects) > 0 {
+		sigBuf = &bufferWriteSeeker{}
+		ws = sigBuf
+	}
+
 	w := bufio.NewWriter(ws)
 	this.writer = w
 
@@ -717,6 +950,8 @@ func (this *PdfWriter) Write(ws io.WriteSeeker) error {
 	// If encrypted!
 	if this.crypter != nil {
 		trailer.Set("Encrypt", this.encryptObj)
+	}
+	if this.ids != nil {
 		trailer.Set("ID", this.ids)
 		common.Log.Trace("Ids: %s", this.ids)
 	}
@@ -730,5 +965,54 @@ func (this *PdfWriter) Write(ws io.WriteSeeker) error {
 	this.writer.WriteString("%%EOF\n")
 	w.Flush()
 
+	if sigBuf != nil {
+		data := sigBuf.buf
+		for idx, obj := range this.objects {
+			if sig, isSig := sigObjects[obj]; isSig {
+				if err := signPdfBytes(data, offsets[idx], sig); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := output.Write(data); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// bufferWriteSeeker is an in-memory io.WriteSeeker for output that needs to be processed prior to writing.
+type bufferWriteSeeker struct {
+	buf []byte
+	pos int64
+}
+
+func (b *bufferWriteSeeker) Write(p []byte) (int, error) {
+	end := b.pos + int64(len(p))
+	if end > int64(len(b.buf)) {
+		b.buf = append(b.buf, make([]byte, end-int64(len(b.buf)))...)
+	}
+	copy(b.buf[b.pos:], p)
+	b.pos = end
+	return len(p), nil
+}
+
+func (b *bufferWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = b.pos + offset
+	case io.SeekEnd:
+		pos = int64(len(b.buf)) + offset
+	default:
+		return 0, errors.New("Invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("Negative position")
+	}
+	b.pos = pos
+	return pos, nil
+}
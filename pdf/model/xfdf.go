@@ -0,0 +1,663 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// XFDF namespace (ISO 19444-1).
+const xfdfNamespace = "http://ns.adobe.com/xfdf/"
+
+// XFDF element names of the supported markup annotation subtypes.
+var xfdfAnnotationElements = map[string]string{
+	"Text":      "text",
+	"FreeText":  "freetext",
+	"Line":      "line",
+	"Square":    "square",
+	"Circle":    "circle",
+	"Polygon":   "polygon",
+	"PolyLine":  "polyline",
+	"Highlight": "highlight",
+	"Underline": "underline",
+	"Squiggly":  "squiggly",
+	"StrikeOut": "strikeout",
+	"Caret":     "caret",
+	"Stamp":     "stamp",
+	"Ink":       "ink",
+}
+
+// XFDF names of the annotation flags (F) by bit position.
+var xfdfAnnotationFlags = []string{"invisible", "hidden", "print", "nozoom", "norotate", "noview", "readonly",
+	"locked", "togglenoview", "lockedcontents"}
+
+// XFDF justification values of the quadding (Q) entry.
+var xfdfJustifications = []string{"left", "centered", "right"}
+
+// AnnotationAppearanceGenerator generates the appearance streams (AP) of annotations, such as annotations
+// imported from XFDF which carry none.  The annotator package provides a generator for the markup
+// annotations it can create.
+type AnnotationAppearanceGenerator interface {
+	GenerateAppearance(annotation *PdfAnnotation) error
+}
+
+// XFDFAnnotation is an annotation imported from XFDF along with the index of its page (0-based).
+type XFDFAnnotation struct {
+	PageIndex  int
+	Annotation *PdfAnnotation
+}
+
+// xfdfElement is a generic XML element used for reading and writing XFDF.
+type xfdfElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr     `xml:",any,attr"`
+	Text     string         `xml:",chardata"`
+	Children []*xfdfElement `xml:",any"`
+}
+
+func newXFDFElement(name string) *xfdfElement {
+	return &xfdfElement{XMLName: xml.Name{Local: name}}
+}
+
+func (elem *xfdfElement) attr(name string) string {
+	for _, attr := range elem.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (elem *xfdfElement) setAttr(name, value string) {
+	elem.Attrs = append(elem.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+func (elem *xfdfElement) child(name string) *xfdfElement {
+	for _, child := range elem.Children {
+		if child.XMLName.Local == name {
+			return child
+		}
+	}
+	return nil
+}
+
+func (elem *xfdfElement) addChild(child *xfdfElement) {
+	elem.Children = append(elem.Children, child)
+}
+
+// ExportXFDF writes the markup annotations of all pages as XFDF to `w`: the type, page, rectangle, color,
+// contents, author, dates, flags and opacity, the type specific geometry such as quad points, ink paths and
+// vertices, popups and replies.  Annotations without a name (NM) are given one for replies to refer to.
+func (this *PdfReader) ExportXFDF(w io.Writer) error {
+	// Name the annotations first, replies can refer to annotations that come later.
+	names := map[PdfObject]string{}
+	for pageIdx, page := range this.PageList {
+		for idx, annot := range page.Annotations {
+			name := ""
+			if str, ok := TraceToDirectObject(annot.NM).(*PdfObjectString); ok {
+				name = DecodeTextString(string(*str))
+			}
+			if name == "" {
+				name = fmt.Sprintf("annot-%d-%d", pageIdx+1, idx+1)
+			}
+			names[annot.GetContainingPdfObject()] = name
+		}
+	}
+
+	annots := newXFDFElement("annots")
+	for pageIdx, page := range this.PageList {
+		for _, annot := range page.Annotations {
+			elem := newXFDFAnnotationElement(annot, pageIdx, names)
+			if elem != nil {
+				annots.addChild(elem)
+			}
+		}
+	}
+
+	root := newXFDFElement("xfdf")
+	root.setAttr("xmlns", xfdfNamespace)
+	root.Attrs = append(root.Attrs, xml.Attr{Name: xml.Name{Space: "http://www.w3.org/XML/1998/namespace",
+		Local: "space"}, Value: "preserve"})
+	root.addChild(annots)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Makes the XFDF element of a markup annotation from its dictionary.  Returns nil for unsupported annotations.
+func newXFDFAnnotationElement(annot *PdfAnnotation, pageIdx int, names map[PdfObject]string) *xfdfElement {
+	obj := annot.ToPdfObject()
+	if ctx := annot.GetContext(); ctx != nil {
+		obj = ctx.ToPdfObject()
+	}
+	container, ok := obj.(*PdfIndirectObject)
+	if !ok {
+		return nil
+	}
+	d, ok := container.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		return nil
+	}
+	subtype, ok := d.Get("Subtype").(*PdfObjectName)
+	if !ok {
+		return nil
+	}
+	elemName, supported := xfdfAnnotationElements[string(*subtype)]
+	if !supported {
+		common.Log.Debug("XFDF export of %s annotations not supported", *subtype)
+		return nil
+	}
+
+	elem := newXFDFElement(elemName)
+	elem.setAttr("page", strconv.Itoa(pageIdx))
+	if rect := formatXFDFNumbers(d.Get("Rect"), ","); rect != "" {
+		elem.setAttr("rect", rect)
+	}
+	if color := formatXFDFColor(d.Get("C")); color != "" {
+		elem.setAttr("color", color)
+	}
+	elem.setAttr("name", names[container])
+	setXFDFStringAttr(elem, "title", d.Get("T"))
+	setXFDFStringAttr(elem, "subject", d.Get("Subj"))
+	setXFDFStringAttr(elem, "date", d.Get("M"))
+	setXFDFStringAttr(elem, "creationdate", d.Get("CreationDate"))
+	if flags, err := getNumberAsInt64(TraceToDirectObject(d.Get("F"))); err == nil && flags != 0 {
+		flagNames := []string{}
+		for i, name := range xfdfAnnotationFlags {
+			if flags&(1<<uint(i)) != 0 {
+				flagNames = append(flagNames, name)
+			}
+		}
+		elem.setAttr("flags", strings.Join(flagNames, ","))
+	}
+	if opacity, err := getNumberAsFloat(TraceToDirectObject(d.Get("CA"))); err == nil {
+		elem.setAttr("opacity", formatXFDFNumber(opacity))
+	}
+	if irt := d.Get("IRT"); irt != nil {
+		if name, has := names[irt]; has {
+			elem.setAttr("inreplyto", name)
+			if rt, ok := TraceToDirectObject(d.Get("RT")).(*PdfObjectName); ok && *rt == "Group" {
+				elem.setAttr("replyType", "group")
+			}
+		}
+	}
+
+	// Type specific entries.
+	if coords := formatXFDFNumbers(d.Get("QuadPoints"), ","); coords != "" {
+		elem.setAttr("coords", coords)
+	}
+	if l, ok := TraceToDirectObject(d.Get("L")).(*PdfObjectArray); ok && len(*l) == 4 {
+		if vals, err := l.ToFloat64Array(); err == nil {
+			elem.setAttr("start", formatXFDFNumber(vals[0])+","+formatXFDFNumber(vals[1]))
+			elem.setAttr("end", formatXFDFNumber(vals[2])+","+formatXFDFNumber(vals[3]))
+		}
+	}
+	if le, ok := TraceToDirectObject(d.Get("LE")).(*PdfObjectArray); ok && len(*le) == 2 {
+		if head, ok := TraceToDirectObject((*le)[0]).(*PdfObjectName); ok {
+			elem.setAttr("head", string(*head))
+		}
+		if tail, ok := TraceToDirectObject((*le)[1]).(*PdfObjectName); ok {
+			elem.setAttr("tail", string(*tail))
+		}
+	}
+	if color := formatXFDFColor(d.Get("IC")); color != "" {
+		elem.setAttr("interior-color", color)
+	}
+	if bs, ok := TraceToDirectObject(d.Get("BS")).(*PdfObjectDictionary); ok {
+		if width, err := getNumberAsFloat(TraceToDirectObject(bs.Get("W"))); err == nil {
+			elem.setAttr("width", formatXFDFNumber(width))
+		}
+	}
+	if name, ok := TraceToDirectObject(d.Get("Name")).(*PdfObjectName); ok {
+		elem.setAttr("icon", string(*name))
+	}
+	setXFDFStringAttr(elem, "state", d.Get("State"))
+	setXFDFStringAttr(elem, "statemodel", d.Get("StateModel"))
+	if sy, ok := TraceToDirectObject(d.Get("Sy")).(*PdfObjectName); ok {
+		if *sy == "P" {
+			elem.setAttr("symbol", "paragraph")
+		} else {
+			elem.setAttr("symbol", "none")
+		}
+	}
+	if q, err := getNumberAsInt64(TraceToDirectObject(d.Get("Q"))); err == nil && q >= 0 && int(q) < len(xfdfJustifications) {
+		elem.setAttr("justification", xfdfJustifications[q])
+	}
+
+	if str, ok := TraceToDirectObject(d.Get("Contents")).(*PdfObjectString); ok {
+		contents := newXFDFElement("contents")
+		contents.Text = DecodeTextString(string(*str))
+		elem.addChild(contents)
+	}
+	if str, ok := TraceToDirectObject(d.Get("DA")).(*PdfObjectString); ok {
+		da := newXFDFElement("defaultappearance")
+		da.Text = string(*str)
+		elem.addChild(da)
+	}
+	if inkList, ok := TraceToDirectObject(d.Get("InkList")).(*PdfObjectArray); ok {
+		list := newXFDFElement("inklist")
+		for _, path := range *inkList {
+			gesture := newXFDFElement("gesture")
+			gesture.Text = formatXFDFPoints(path)
+			list.addChild(gesture)
+		}
+		elem.addChild(list)
+	}
+	if vertices := formatXFDFPoints(d.Get("Vertices")); vertices != "" {
+		v := newXFDFElement("vertices")
+		v.Text = vertices
+		elem.addChild(v)
+	}
+	if popupObj, ok := d.Get("Popup").(*PdfIndirectObject); ok {
+		if popupDict, ok := popupObj.PdfObject.(*PdfObjectDictionary); ok {
+			popup := newXFDFElement("popup")
+			popup.setAttr("page", strconv.Itoa(pageIdx))
+			if rect := formatXFDFNumbers(popupDict.Get("Rect"), ","); rect != "" {
+				popup.setAttr("rect", rect)
+			}
+			if open, ok := TraceToDirectObject(popupDict.Get("Open")).(*PdfObjectBool); ok && bool(*open) {
+				popup.setAttr("open", "yes")
+			}
+			elem.addChild(popup)
+		}
+	}
+
+	return elem
+}
+
+// ImportXFDF reads the annotations from XFDF and adds them to the annotations of the pages.  If `generator` is
+// not nil, it is used to generate the appearances of the imported annotations.
+func (this *PdfReader) ImportXFDF(r io.Reader, generator AnnotationAppearanceGenerator) error {
+	annots, err := ReadXFDF(r, generator)
+	if err != nil {
+		return err
+	}
+	for _, annot := range annots {
+		if annot.PageIndex < 0 || annot.PageIndex >= len(this.PageList) {
+			return fmt.Errorf("Annotation page %d out of range", annot.PageIndex)
+		}
+	}
+	for _, annot := range annots {
+		page := this.PageList[annot.PageIndex]
+		annot.Annotation.P = page.GetContainingPdfObject()
+		page.Annotations = append(page.Annotations, annot.Annotation)
+	}
+	return nil
+}
+
+// ReadXFDF reads the annotations from XFDF.  The annotations are created as the corresponding annotation types
+// with replies (inreplyto) referring to the annotations they reply to.  Popups are returned as annotations of
+// their own following their parent.  If `generator` is not nil, it is used to generate the appearances of the
+// annotations, the annotations it fails for are read without appearance.
+func ReadXFDF(r io.Reader, generator AnnotationAppearanceGenerator) ([]*XFDFAnnotation, error) {
+	root := &xfdfElement{}
+	if err := xml.NewDecoder(r).Decode(root); err != nil {
+		return nil, err
+	}
+	if root.XMLName.Local != "xfdf" {
+		return nil, errors.New("Not an XFDF document")
+	}
+
+	subtypes := map[string]string{}
+	for subtype, elemName := range xfdfAnnotationElements {
+		subtypes[elemName] = subtype
+	}
+
+	type xfdfImport struct {
+		elem      *xfdfElement
+		pageIndex int
+		container *PdfIndirectObject
+		popup     *PdfIndirectObject
+	}
+	imports := []*xfdfImport{}
+	names := map[string]*PdfIndirectObject{}
+
+	annots := root.child("annots")
+	if annots == nil {
+		return []*XFDFAnnotation{}, nil
+	}
+	for _, elem := range annots.Children {
+		subtype, supported := subtypes[elem.XMLName.Local]
+		if !supported {
+			common.Log.Debug("XFDF import of %s annotations not supported", elem.XMLName.Local)
+			continue
+		}
+		pageIndex, err := strconv.Atoi(elem.attr("page"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid page of %s annotation: %v", elem.XMLName.Local, err)
+		}
+		d, err := newXFDFAnnotationDict(elem, subtype)
+		if err != nil {
+			return nil, err
+		}
+		imp := &xfdfImport{elem: elem, pageIndex: pageIndex, container: MakeIndirectObject(d)}
+
+		if popupElem := elem.child("popup"); popupElem != nil {
+			popupDict := MakeDict()
+			popupDict.Set("Type", MakeName("Annot"))
+			popupDict.Set("Subtype", MakeName("Popup"))
+			rect, err := parseXFDFNumbers(popupElem.attr("rect"), ",")
+			if err != nil || len(rect) != 4 {
+				return nil, errors.New("Invalid popup rect")
+			}
+			popupDict.Set("Rect", MakeArrayFromFloats(rect))
+			popupDict.Set("Open", MakeBool(popupElem.attr("open") == "yes"))
+			popupDict.Set("Parent", imp.container)
+			imp.popup = MakeIndirectObject(popupDict)
+			d.Set("Popup", imp.popup)
+		}
+
+		if name := elem.attr("name"); name != "" {
+			names[name] = imp.container
+		}
+		imports = append(imports, imp)
+	}
+
+	// Resolve the replies now that all annotations are named.
+	for _, imp := range imports {
+		name := imp.elem.attr("inreplyto")
+		if name == "" {
+			continue
+		}
+		irt, has := names[name]
+		if !has {
+			common.Log.Debug("XFDF annotation %s in reply to unknown annotation %s", imp.elem.attr("name"), name)
+			continue
+		}
+		d := imp.container.PdfObject.(*PdfObjectDictionary)
+		d.Set("IRT", irt)
+		if strings.ToLower(imp.elem.attr("replyType")) == "group" {
+			d.Set("RT", MakeName("Group"))
+		}
+	}
+
+	// Load the annotation models as when reading a document.  No external references need to be resolved.
+	reader := &PdfReader{modelManager: NewModelManager(), traversed: map[PdfObject]bool{}}
+	result := []*XFDFAnnotation{}
+	for _, imp := range imports {
+		annot, err := reader.newPdfAnnotationFromIndirectObject(imp.container)
+		if err != nil {
+			return nil, err
+		}
+		if generator != nil && annot.AP == nil {
+			// An annotation whose appearance cannot be generated, e.g. a polygon without vertices, is imported
+			// without appearance.
+			if err := generator.GenerateAppearance(annot); err != nil {
+				common.Log.Debug("Unable to generate the appearance of XFDF annotation %s: %v",
+					imp.elem.XMLName.Local, err)
+			} else if ctx := annot.GetContext(); ctx != nil {
+				ctx.ToPdfObject()
+			}
+		}
+		result = append(result, &XFDFAnnotation{PageIndex: imp.pageIndex, Annotation: annot})
+
+		if imp.popup != nil {
+			popup, err := reader.newPdfAnnotationFromIndirectObject(imp.popup)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, &XFDFAnnotation{PageIndex: imp.pageIndex, Annotation: popup})
+		}
+	}
+
+	return result, nil
+}
+
+// Makes the annotation dictionary of an XFDF annotation element.
+func newXFDFAnnotationDict(elem *xfdfElement, subtype string) (*PdfObjectDictionary, error) {
+	d := MakeDict()
+	d.Set("Type", MakeName("Annot"))
+	d.Set("Subtype", MakeName(subtype))
+
+	rect, err := parseXFDFNumbers(elem.attr("rect"), ",")
+	if err != nil || len(rect) != 4 {
+		return nil, fmt.Errorf("Invalid rect of %s annotation", elem.XMLName.Local)
+	}
+	d.Set("Rect", MakeArrayFromFloats(rect))
+
+	if color := elem.attr("color"); color != "" {
+		rgb, err := parseXFDFColor(color)
+		if err != nil {
+			return nil, err
+		}
+		d.Set("C", MakeArrayFromFloats(rgb))
+	}
+	setXFDFStringEntry(d, "NM", elem.attr("name"))
+	setXFDFStringEntry(d, "T", elem.attr("title"))
+	setXFDFStringEntry(d, "Subj", elem.attr("subject"))
+	setXFDFStringEntry(d, "M", elem.attr("date"))
+	setXFDFStringEntry(d, "CreationDate", elem.attr("creationdate"))
+	if flags := elem.attr("flags"); flags != "" {
+		f := int64(0)
+		for _, flag := range strings.Split(flags, ",") {
+			for i, name := range xfdfAnnotationFlags {
+				if strings.TrimSpace(strings.ToLower(flag)) == name {
+					f |= 1 << uint(i)
+				}
+			}
+		}
+		d.Set("F", MakeInteger(f))
+	}
+	if opacity := elem.attr("opacity"); opacity != "" {
+		ca, err := strconv.ParseFloat(opacity, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid opacity: %v", err)
+		}
+		d.Set("CA", MakeFloat(ca))
+	}
+
+	// Type specific entries.
+	if coords := elem.attr("coords"); coords != "" {
+		vals, err := parseXFDFNumbers(coords, ",")
+		if err != nil || len(vals)%8 != 0 {
+			return nil, errors.New("Invalid coords")
+		}
+		d.Set("QuadPoints", MakeArrayFromFloats(vals))
+	}
+	if start, end := elem.attr("start"), elem.attr("end"); start != "" || end != "" {
+		vals, err := parseXFDFNumbers(start+","+end, ",")
+		if err != nil || len(vals) != 4 {
+			return nil, errors.New("Invalid line start or end")
+		}
+		d.Set("L", MakeArrayFromFloats(vals))
+	}
+	if head, tail := elem.attr("head"), elem.attr("tail"); head != "" || tail != "" {
+		if head == "" {
+			head = "None"
+		}
+		if tail == "" {
+			tail = "None"
+		}
+		d.Set("LE", MakeArray(MakeName(head), MakeName(tail)))
+	}
+	if color := elem.attr("interior-color"); color != "" {
+		rgb, err := parseXFDFColor(color)
+		if err != nil {
+			return nil, err
+		}
+		d.Set("IC", MakeArrayFromFloats(rgb))
+	}
+	if width := elem.attr("width"); width != "" {
+		w, err := strconv.ParseFloat(width, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid width: %v", err)
+		}
+		bs := NewBorderStyle()
+		bs.SetBorderWidth(w)
+		d.Set("BS", bs.ToPdfObject())
+	}
+	if icon := elem.attr("icon"); icon != "" {
+		d.Set("Name", MakeName(icon))
+	}
+	setXFDFStringEntry(d, "State", elem.attr("state"))
+	setXFDFStringEntry(d, "StateModel", elem.attr("statemodel"))
+	if symbol := elem.attr("symbol"); symbol != "" {
+		if strings.ToLower(symbol) == "paragraph" {
+			d.Set("Sy", MakeName("P"))
+		} else {
+			d.Set("Sy", MakeName("None"))
+		}
+	}
+	if justification := elem.attr("justification"); justification != "" {
+		for q, name := range xfdfJustifications {
+			if strings.ToLower(justification) == name {
+				d.Set("Q", MakeInteger(int64(q)))
+			}
+		}
+	}
+
+	if contents := elem.child("contents"); contents != nil {
+		setXFDFStringEntry(d, "Contents", contents.Text)
+	}
+	if da := elem.child("defaultappearance"); da != nil {
+		d.Set("DA", MakeString(strings.TrimSpace(da.Text)))
+	}
+	if inkList := elem.child("inklist"); inkList != nil {
+		paths := PdfObjectArray{}
+		for _, gesture := range inkList.Children {
+			if gesture.XMLName.Local != "gesture" {
+				continue
+			}
+			vals, err := parseXFDFNumbers(gesture.Text, ",;")
+			if err != nil || len(vals)%2 != 0 {
+				return nil, errors.New("Invalid ink gesture")
+			}
+			paths = append(paths, MakeArrayFromFloats(vals))
+		}
+		d.Set("InkList", &paths)
+	}
+	if vertices := elem.child("vertices"); vertices != nil {
+		vals, err := parseXFDFNumbers(vertices.Text, ",;")
+		if err != nil || len(vals)%2 != 0 {
+			return nil, errors.New("Invalid vertices")
+		}
+		d.Set("Vertices", MakeArrayFromFloats(vals))
+	}
+
+	return d, nil
+}
+
+// Sets an attribute to the value of a text string object if present.
+func setXFDFStringAttr(elem *xfdfElement, name string, obj PdfObject) {
+	if str, ok := TraceToDirectObject(obj).(*PdfObjectString); ok {
+		elem.setAttr(name, DecodeTextString(string(*str)))
+	}
+}
+
+// Sets a text string entry of the dictionary if the value is not empty.
+func setXFDFStringEntry(d *PdfObjectDictionary, key PdfObjectName, value string) {
+	if value != "" {
+		d.Set(key, MakeString(EncodeTextString(value)))
+	}
+}
+
+func formatXFDFNumber(val float64) string {
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
+
+// Formats an array of numbers separated by `sep`.  Returns an empty string if not an array of numbers.
+func formatXFDFNumbers(obj PdfObject, sep string) string {
+	arr, ok := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !ok {
+		return ""
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return ""
+	}
+	strs := make([]string, len(vals))
+	for i, val := range vals {
+		strs[i] = formatXFDFNumber(val)
+	}
+	return strings.Join(strs, sep)
+}
+
+// Formats an array of alternating x and y coordinates as points "x,y;x,y".
+func formatXFDFPoints(obj PdfObject) string {
+	arr, ok := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !ok {
+		return ""
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return ""
+	}
+	points := []string{}
+	for i := 0; i+1 < len(vals); i += 2 {
+		points = append(points, formatXFDFNumber(vals[i])+","+formatXFDFNumber(vals[i+1]))
+	}
+	return strings.Join(points, ";")
+}
+
+// Parses numbers separated by any of the characters in `seps`.
+func parseXFDFNumbers(str string, seps string) ([]float64, error) {
+	fields := strings.FieldsFunc(str, func(r rune) bool {
+		return strings.ContainsRune(seps, r)
+	})
+	vals := []float64{}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		val, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// Formats an RGB color array as #RRGGBB.  Returns an empty string for other colors.
+func formatXFDFColor(obj PdfObject) string {
+	arr, ok := TraceToDirectObject(obj).(*PdfObjectArray)
+	if !ok || len(*arr) != 3 {
+		return ""
+	}
+	vals, err := arr.ToFloat64Array()
+	if err != nil {
+		return ""
+	}
+	color := "#"
+	for _, val := range vals {
+		color += fmt.Sprintf("%02X", int(math.Floor(math.Max(0, math.Min(1, val))*255+0.5)))
+	}
+	return color
+}
+
+// Parses a #RRGGBB color to RGB components in the range 0-1.
+func parseXFDFColor(color string) ([]float64, error) {
+	if len(color) != 7 || color[0] != '#' {
+		return nil, fmt.Errorf("Invalid color %q", color)
+	}
+	rgb := []float64{}
+	for i := 1; i < 7; i += 2 {
+		val, err := strconv.ParseUint(color[i:i+2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid color %q", color)
+		}
+		rgb = append(rgb, float64(val)/255)
+	}
+	return rgb, nil
+}
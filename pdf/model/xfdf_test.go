@@ -0,0 +1,207 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/unidoc/unidoc/pdf/core"
+)
+
+// Sets a dummy appearance on the annotations.
+type testAppearanceGenerator struct {
+	count int
+}
+
+func (gen *testAppearanceGenerator) GenerateAppearance(annotation *PdfAnnotation) error {
+	gen.count++
+	annotation.AP = MakeDict()
+	return nil
+}
+
+// writeXFDFTestFile writes a document with two pages to `path` with a highlight, an ink annotation and a note
+// with a popup and a reply on the second page.
+func writeXFDFTestFile(t *testing.T, path string) {
+	writer := NewPdfWriter()
+	for i := 0; i < 2; i++ {
+		page := NewPdfPage()
+		page.MediaBox = &PdfRectangle{Llx: 0, Lly: 0, Urx: 200, Ury: 200}
+		page.Resources = NewPdfPageResources()
+		if i == 1 {
+			highlight := NewPdfAnnotationHighlight()
+			highlight.Rect = MakeArrayFromFloats([]float64{10, 10, 50, 20})
+			highlight.C = MakeArrayFromFloats([]float64{1, 1, 0})
+			highlight.QuadPoints = MakeArrayFromFloats([]float64{10, 20, 50, 20, 10, 10, 50, 10})
+			highlight.T = MakeString("Alice")
+			highlight.CA = MakeFloat(0.5)
+			highlight.F = MakeInteger(4)
+
+			ink := NewPdfAnnotationInk()
+			ink.Rect = MakeArrayFromFloats([]float64{60, 60, 90, 90})
+			ink.InkList = MakeArray(MakeArrayFromFloats([]float64{60, 60, 70, 80, 90, 90}))
+			bs := NewBorderStyle()
+			bs.SetBorderWidth(2)
+			ink.BS = bs.ToPdfObject()
+
+			note := NewPdfAnnotationText()
+			note.Rect = MakeArrayFromFloats([]float64{100, 100, 120, 120})
+			note.Contents = MakeString(EncodeTextString("Héllo"))
+			note.NM = MakeString("note-1")
+			note.Name = MakeName("Comment")
+			popup := NewPdfAnnotationPopup()
+			popup.Rect = MakeArrayFromFloats([]float64{120, 100, 190, 150})
+			popup.Open = MakeBool(true)
+			popup.Parent = note.GetContainingPdfObject()
+			note.Popup = popup
+
+			reply := NewPdfAnnotationText()
+			reply.Rect = MakeArrayFromFloats([]float64{100, 100, 120, 120})
+			reply.Contents = MakeString("Reply")
+			reply.IRT = note.GetContainingPdfObject()
+
+			page.Annotations = []*PdfAnnotation{highlight.PdfAnnotation, ink.PdfAnnotation, note.PdfAnnotation,
+				popup.PdfAnnotation, reply.PdfAnnotation}
+		}
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func TestXFDFRoundTrip(t *testing.T) {
+	writeXFDFTestFile(t, "/tmp/xfdf.pdf")
+	reader := readTestFile(t, "/tmp/xfdf.pdf")
+
+	var buf bytes.Buffer
+	if err := reader.ExportXFDF(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	xfdf := buf.String()
+	for _, expected := range []string{
+		`<xfdf xmlns="http://ns.adobe.com/xfdf/" xml:space="preserve">`,
+		`<highlight page="1" rect="10,10,50,20" color="#FFFF00" name="annot-2-1" title="Alice" flags="print" opacity="0.5" coords="10,20,50,20,10,10,50,10">`,
+		`<gesture>60,60;70,80;90,90</gesture>`,
+		`<contents>Héllo</contents>`,
+		`<popup page="1" rect="120,100,190,150" open="yes">`,
+		`inreplyto="note-1"`,
+	} {
+		if !strings.Contains(xfdf, expected) {
+			t.Errorf("Missing %s in %s", expected, xfdf)
+		}
+	}
+	// Popups are exported with their parent.
+	if strings.Count(xfdf, "<popup") != 1 {
+		t.Errorf("Invalid popups: %s", xfdf)
+	}
+
+	generator := &testAppearanceGenerator{}
+	annots, err := ReadXFDF(strings.NewReader(xfdf), generator)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(annots) != 5 || generator.count != 4 {
+		t.Fatalf("Invalid annotations %d (%d generated)", len(annots), generator.count)
+	}
+	for _, annot := range annots {
+		if annot.PageIndex != 1 || annot.Annotation.AP == nil && annot.Annotation != annots[3].Annotation {
+			t.Errorf("Invalid annotation %+v", annot)
+		}
+	}
+
+	highlight, ok := annots[0].Annotation.GetContext().(*PdfAnnotationHighlight)
+	if !ok {
+		t.Fatalf("Not a highlight: %T", annots[0].Annotation.GetContext())
+	}
+	if quads, _ := highlight.QuadPoints.(*PdfObjectArray).ToFloat64Array(); len(quads) != 8 || quads[0] != 10 {
+		t.Errorf("Invalid quad points %v", highlight.QuadPoints)
+	}
+	if *highlight.T.(*PdfObjectString) != "Alice" || *highlight.F.(*PdfObjectInteger) != 4 {
+		t.Errorf("Invalid highlight %v %v", highlight.T, highlight.F)
+	}
+
+	ink, ok := annots[1].Annotation.GetContext().(*PdfAnnotationInk)
+	if !ok || len(*ink.InkList.(*PdfObjectArray)) != 1 {
+		t.Fatalf("Invalid ink: %+v", annots[1].Annotation.GetContext())
+	}
+	if width, err := getNumberAsFloat(ink.BS.(*PdfObjectDictionary).Get("W")); err != nil || width != 2 {
+		t.Errorf("Invalid ink width %v", width)
+	}
+
+	note, ok := annots[2].Annotation.GetContext().(*PdfAnnotationText)
+	if !ok || DecodeTextString(string(*note.Contents.(*PdfObjectString))) != "Héllo" {
+		t.Fatalf("Invalid note: %+v", annots[2].Annotation.GetContext())
+	}
+	if note.Popup == nil || note.Popup.GetContainingPdfObject() != annots[3].Annotation.GetContainingPdfObject() {
+		t.Errorf("Invalid popup")
+	}
+	reply, ok := annots[4].Annotation.GetContext().(*PdfAnnotationText)
+	if !ok || reply.IRT != annots[2].Annotation.GetContainingPdfObject() {
+		t.Errorf("Invalid reply: %+v", annots[4].Annotation.GetContext())
+	}
+
+	// Import into the document without annotations and write it.
+	writeXFDFTestFile(t, "/tmp/xfdf.pdf")
+	reader = readTestFile(t, "/tmp/xfdf.pdf")
+	reader.PageList[1].Annotations = nil
+	if err := reader.ImportXFDF(strings.NewReader(xfdf), nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	writer := NewPdfWriter()
+	for _, page := range reader.PageList {
+		if err := writer.AddPage(page); err != nil {
+			t.Fatalf("Error: %v", err)
+		}
+	}
+	f, err := os.Create("/tmp/xfdf_imported.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	reader = readTestFile(t, "/tmp/xfdf_imported.pdf")
+	var reexported bytes.Buffer
+	if err := reader.ExportXFDF(&reexported); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if reexported.String() != xfdf {
+		t.Errorf("Invalid re-export:\n%s\nexpected:\n%s", reexported.String(), xfdf)
+	}
+}
+
+func TestXFDFInvalid(t *testing.T) {
+	testcases := []string{
+		`<fdf/>`,
+		`<xfdf><annots><highlight page="0" rect="1,2,3"/></annots></xfdf>`,
+		`<xfdf><annots><square page="x" rect="1,2,3,4"/></annots></xfdf>`,
+		`<xfdf><annots><square page="0" rect="1,2,3,4" color="red"/></annots></xfdf>`,
+	}
+	for _, xfdf := range testcases {
+		if _, err := ReadXFDF(strings.NewReader(xfdf), nil); err == nil {
+			t.Errorf("%s should fail", xfdf)
+		}
+	}
+
+	// Unsupported annotations are skipped.
+	annots, err := ReadXFDF(strings.NewReader(`<xfdf><annots><link page="0" rect="1,2,3,4"/></annots></xfdf>`), nil)
+	if err != nil || len(annots) != 0 {
+		t.Errorf("Invalid annotations %v: %v", annots, err)
+	}
+}
@@ -0,0 +1,684 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// formKey identifies a form XObject drawn with a transformation matrix.
+type formKey struct {
+	stream *core.PdfObjectStream
+	matrix contentstream.Matrix
+}
+
+// shownText holds the glyphs shown by a text showing operation, laid out by the interpreter.
+type shownText struct {
+	font   *fontfile.Font
+	glyphs []graphics.Glyph
+	ctm    contentstream.Matrix
+}
+
+// contentRedactor removes the content overlapping the redaction regions from a content stream and the
+// XObjects it draws.  It is the device of a graphics interpreter, which tracks the graphics and text state and
+// lays out the glyphs shown, and copies the operations interpreted without the removed content.
+type contentRedactor struct {
+	regions   []model.PdfRectangle
+	resources *model.PdfPageResources
+	// base maps the coordinate system at the start of the content stream to default user space.
+	base contentstream.Matrix
+	// parents are the streams of the forms being redacted, to stop at forms drawing themselves.
+	parents map[*core.PdfObjectStream]bool
+
+	state   graphics.State
+	stack   []graphics.State
+	fonts   map[core.PdfObject]*fontfile.Font
+	forms   map[formKey]core.PdfObjectName
+	changed bool
+
+	// out holds the operations of the redacted content.
+	out contentstream.ContentStreamOperations
+	// path holds the operations constructing the current path and clipOp the pending clipping operation.
+	path   []*contentstream.ContentStreamOperation
+	clipOp *contentstream.ContentStreamOperation
+	// text holds the glyphs of the current text showing operation.
+	text *shownText
+	// marked holds the operations starting the marked content sequences open at the current operation.
+	marked []*contentstream.ContentStreamOperation
+}
+
+func newContentRedactor(regions []model.PdfRectangle, resources *model.PdfPageResources, base contentstream.Matrix,
+	parents map[*core.PdfObjectStream]bool) *contentRedactor {
+	if resources == nil {
+		resources = model.NewPdfPageResources()
+	}
+	return &contentRedactor{
+		regions:   regions,
+		resources: resources,
+		base:      base,
+		parents:   parents,
+		state:     graphics.NewState(),
+		fonts:     map[core.PdfObject]*fontfile.Font{},
+		forms:     map[formKey]core.PdfObjectName{},
+	}
+}
+
+// overlapsRegions checks whether the box (llx, lly, urx, ury) in default user space overlaps a region.
+func (r *contentRedactor) overlapsRegions(llx, lly, urx, ury float64) bool {
+	for _, region := range r.regions {
+		if overlaps(region, llx, lly, urx, ury) {
+			return true
+		}
+	}
+	return false
+}
+
+// insideRegion checks whether the box (llx, lly, urx, ury) in default user space is inside a region.
+func (r *contentRedactor) insideRegion(llx, lly, urx, ury float64) bool {
+	for _, region := range r.regions {
+		if contains(region, llx, lly, urx, ury) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns the operations of `content` without the content overlapping the regions.
+func (r *contentRedactor) redact(content string) (contentstream.ContentStreamOperations, error) {
+	r.out = contentstream.ContentStreamOperations{}
+	interpreter := graphics.NewInterpreter(r, r.resources, nil, r.base)
+	if err := interpreter.Process(content, graphics.DefaultProcessorState()); err != nil {
+		return nil, err
+	}
+	return r.out, nil
+}
+
+// State returns the graphics state of the interpreted content.  Implements the graphics.Device interface.
+func (r *contentRedactor) State() *graphics.State {
+	return &r.state
+}
+
+// Save pushes the graphics state.  Implements the graphics.Device interface.
+func (r *contentRedactor) Save() {
+	r.stack = append(r.stack, r.state)
+}
+
+// Restore pops the graphics state.  Implements the graphics.Device interface.
+func (r *contentRedactor) Restore() {
+	if len(r.stack) > 0 {
+		r.state = r.stack[len(r.stack)-1]
+		r.stack = r.stack[:len(r.stack)-1]
+	}
+}
+
+// LoadFont returns the font of font dictionary `obj`, loaded once per dictionary.  Implements the
+// graphics.Device interface.
+func (r *contentRedactor) LoadFont(obj core.PdfObject) *fontfile.Font {
+	if font, has := r.fonts[obj]; has {
+		return font
+	}
+	font := fontfile.Load(obj)
+	r.fonts[obj] = font
+	return font
+}
+
+// SetExtGStateEntry ignores the graphics state parameters which do not affect the location of content.
+// Implements the graphics.Device interface.
+func (r *contentRedactor) SetExtGStateEntry(key core.PdfObjectName, val core.PdfObject, ctm contentstream.Matrix) {
+}
+
+// PaintPath does nothing, paths are redacted by HandleOperation.  Implements the graphics.Device interface.
+func (r *contentRedactor) PaintPath(p *graphics.Path, ctm contentstream.Matrix, fill bool, rule graphics.FillRule,
+	stroke bool) {
+}
+
+// ClipPath does nothing, clipping paths are copied by HandleOperation.  Implements the graphics.Device
+// interface.
+func (r *contentRedactor) ClipPath(p *graphics.Path, ctm contentstream.Matrix, rule graphics.FillRule) {
+}
+
+// DrawShading does nothing, shadings are kept as they fill the clipping region.  Implements the graphics.Device
+// interface.
+func (r *contentRedactor) DrawShading(name core.PdfObjectName, ctm contentstream.Matrix) {
+}
+
+// DrawXObject does nothing, XObjects are redacted by HandleOperation.  Implements the graphics.Device interface.
+func (r *contentRedactor) DrawXObject(name core.PdfObjectName, ctm contentstream.Matrix) {
+}
+
+// DrawInlineImage does nothing, inline images are redacted by HandleOperation.  Implements the graphics.Device
+// interface.
+func (r *contentRedactor) DrawInlineImage(inline *contentstream.ContentStreamInlineImage,
+	ctm contentstream.Matrix) {
+}
+
+// BeginText starts a text object.  Implements the graphics.Device interface.
+func (r *contentRedactor) BeginText() {
+}
+
+// EndText ends a text object.  Implements the graphics.Device interface.
+func (r *contentRedactor) EndText() {
+}
+
+// ShowText keeps the glyphs shown by the current text showing operation, which is redacted by
+// HandleOperation.  Implements the graphics.Device interface.
+func (r *contentRedactor) ShowText(font *fontfile.Font, tm contentstream.Matrix, glyphs []graphics.Glyph,
+	ctm contentstream.Matrix) {
+	r.text = &shownText{font: font, glyphs: glyphs, ctm: ctm}
+}
+
+// HandleOperation copies operation `op` to the redacted content, replacing the text, paths, images and forms
+// overlapping the regions.  Implements the graphics.OperationHandler interface.
+func (r *contentRedactor) HandleOperation(op *contentstream.ContentStreamOperation, ctm contentstream.Matrix) {
+	switch op.Operand {
+	case "m", "l", "c", "v", "y", "re", "h":
+		r.path = append(r.path, op)
+		return
+	case "W", "W*":
+		r.clipOp = op
+		return
+	case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+		r.out = append(r.out, r.paintPath(op, r.path, r.clipOp, r.state.LineWidth, ctm)...)
+		r.path = nil
+		r.clipOp = nil
+		return
+	case "Tj", "TJ", "'", "\"":
+		ops, removed := r.showText(op, r.text)
+		r.text = nil
+		if removed {
+			clearMarkedText(r.marked)
+		}
+		r.out = append(r.out, ops...)
+		return
+	case "Do":
+		r.out = append(r.out, r.drawXObject(op, ctm)...)
+		return
+	case "BI":
+		r.out = append(r.out, r.drawInlineImage(op, ctm)...)
+		return
+	case "BMC", "BDC":
+		r.marked = append(r.marked, op)
+	case "EMC":
+		if len(r.marked) > 0 {
+			r.marked = r.marked[:len(r.marked)-1]
+		}
+	}
+	r.out = append(r.out, op)
+}
+
+// showText returns the operations showing the text of the text showing operation `op` with the glyphs `text`
+// without the glyphs overlapping the regions, which are replaced by equivalent displacements.  The bool flag is
+// true if glyphs were removed.
+func (r *contentRedactor) showText(op *contentstream.ContentStreamOperation,
+	text *shownText) ([]*contentstream.ContentStreamOperation, bool) {
+	if text == nil {
+		return []*contentstream.ContentStreamOperation{op}, false
+	}
+	var items []core.PdfObject
+	switch op.Operand {
+	case "TJ":
+		if len(op.Params) == 1 {
+			if arr, ok := op.Params[0].(*core.PdfObjectArray); ok {
+				items = *arr
+			}
+		}
+	default:
+		if len(op.Params) > 0 {
+			items = op.Params[len(op.Params)-1:]
+		}
+	}
+
+	font := text.font
+	fs := r.state.FontSize
+
+	kept := core.PdfObjectArray{}
+	var str []byte
+	removed := false
+	// addDisplacement adds a displacement of `n` thousandths of text space units to the kept items.
+	addDisplacement := func(n float64) {
+		if len(str) > 0 {
+			kept = append(kept, core.MakeString(string(str)))
+			str = nil
+		}
+		if len(kept) > 0 {
+			if last, ok := kept[len(kept)-1].(*core.PdfObjectFloat); ok {
+				*last += core.PdfObjectFloat(n)
+				return
+			}
+		}
+		kept = append(kept, core.MakeFloat(n))
+	}
+
+	// The glyphs are in the order of the codes of the strings.
+	glyphs := text.glyphs
+	for _, item := range items {
+		switch t := item.(type) {
+		case *core.PdfObjectString:
+			for _, code := range font.SplitCodes([]byte(*t)) {
+				if len(glyphs) == 0 {
+					break
+				}
+				glyph := glyphs[0]
+				glyphs = glyphs[1:]
+
+				w0 := font.Width(glyph.Code)
+				trm := glyph.Trm.Mult(text.ctm)
+				if !r.overlapsRegions(trm.TransformRectangle(0, font.Descent, w0, font.Ascent)) {
+					str = append(str, code...)
+					continue
+				}
+				removed = true
+				advance := w0*fs + r.state.CharSpacing
+				if font.Vertical {
+					w1, _, _ := font.VerticalMetrics(glyph.Code)
+					advance = w1*fs + r.state.CharSpacing
+				}
+				if font.IsSpace(glyph.Code) {
+					advance += r.state.WordSpacing
+				}
+				if fs != 0 {
+					addDisplacement(-advance * 1000 / fs)
+				}
+			}
+		case *core.PdfObjectFloat, *core.PdfObjectInteger:
+			n, _ := graphics.GetNumber(t)
+			addDisplacement(n)
+		}
+	}
+
+	if !removed {
+		return []*contentstream.ContentStreamOperation{op}, false
+	}
+	r.changed = true
+	if len(str) > 0 {
+		kept = append(kept, core.MakeString(string(str)))
+	}
+
+	// The new line and spacing of ' and " are set separately, as the text is shown by TJ.
+	ops := []*contentstream.ContentStreamOperation{}
+	if op.Operand == "\"" && len(op.Params) == 3 {
+		ops = append(ops,
+			&contentstream.ContentStreamOperation{Operand: "Tw", Params: op.Params[0:1]},
+			&contentstream.ContentStreamOperation{Operand: "Tc", Params: op.Params[1:2]})
+	}
+	if op.Operand == "'" || op.Operand == "\"" {
+		ops = append(ops, &contentstream.ContentStreamOperation{Operand: "T*"})
+	}
+	// The array is shown even if all glyphs were removed, to move the text position past the text.
+	ops = append(ops, &contentstream.ContentStreamOperation{Operand: "TJ", Params: []core.PdfObject{&kept}})
+	return ops, true
+}
+
+// clearMarkedText removes the replacement and alternate texts of the marked content sequences started by
+// `marked`, as they may disclose removed glyphs.
+func clearMarkedText(marked []*contentstream.ContentStreamOperation) {
+	for _, op := range marked {
+		if op.Operand != "BDC" || len(op.Params) != 2 {
+			continue
+		}
+		if props, ok := op.Params[1].(*core.PdfObjectDictionary); ok {
+			props.Remove("ActualText")
+			props.Remove("Alt")
+			props.Remove("E")
+		}
+	}
+}
+
+// paintPath returns the operations painting the path built by `path` with the painting operation `op` and
+// the clipping operation `clipOp` (if not nil), such that nothing is painted in the regions.  Paths which
+// cannot be cut at the regions are removed.
+func (r *contentRedactor) paintPath(op *contentstream.ContentStreamOperation,
+	path []*contentstream.ContentStreamOperation, clipOp *contentstream.ContentStreamOperation, lineWidth float64,
+	ctm contentstream.Matrix) []*contentstream.ContentStreamOperation {
+	ops := []*contentstream.ContentStreamOperation{}
+	appendPath := func(paintOp *contentstream.ContentStreamOperation, clip bool) {
+		ops = append(ops, path...)
+		if clip && clipOp != nil {
+			ops = append(ops, clipOp)
+		}
+		ops = append(ops, paintOp)
+	}
+
+	if len(path) == 0 || op.Operand == "n" {
+		appendPath(op, true)
+		return ops
+	}
+
+	subpaths, valid := getSubpaths(path)
+	if valid {
+		// The bounding box of the path in default user space.
+		box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		for _, sp := range subpaths {
+			b := sp.box(ctm)
+			box = [4]float64{math.Min(box[0], b[0]), math.Min(box[1], b[1]), math.Max(box[2], b[2]),
+				math.Max(box[3], b[3])}
+		}
+		if op.Operand != "f" && op.Operand != "F" && op.Operand != "f*" {
+			// Strokes extend by half the line width around the path.
+			margin := lineWidth / 2 * ctm.ScalingFactor()
+			box = [4]float64{box[0] - margin, box[1] - margin, box[2] + margin, box[3] + margin}
+		}
+		if !r.overlapsRegions(box[0], box[1], box[2], box[3]) {
+			appendPath(op, true)
+			return ops
+		}
+		valid = !r.insideRegion(box[0], box[1], box[2], box[3])
+	} else {
+		common.Log.Debug("Invalid path, removing it")
+	}
+
+	clipped, ok := []*contentstream.ContentStreamOperation(nil), false
+	if valid {
+		clipped, ok = r.clipPath(op, path, subpaths, lineWidth, ctm)
+		if !ok {
+			common.Log.Debug("Unable to cut the path at the regions, removing it")
+		}
+	}
+	if ok {
+		ops = append(ops, clipped...)
+	} else {
+		r.changed = true
+	}
+
+	if clipOp != nil {
+		appendPath(&contentstream.ContentStreamOperation{Operand: "n"}, true)
+	}
+	return ops
+}
+
+// clipPath returns the operations painting the parts of the path built by `path`, with subpaths `subpaths`,
+// outside the regions with the painting operation `op`, or false if the path cannot be cut at the regions.
+// Rectangles are filled without their parts in the regions and segments are stroked without their parts within
+// the line width of the regions.  Subpaths not reaching the regions are painted unchanged.
+func (r *contentRedactor) clipPath(op *contentstream.ContentStreamOperation,
+	path []*contentstream.ContentStreamOperation, subpaths []*subpath, lineWidth float64,
+	ctm contentstream.Matrix) ([]*contentstream.ContentStreamOperation, bool) {
+
+	var fillOp, strokeOp string
+	switch op.Operand {
+	case "f", "F":
+		fillOp = "f"
+	case "f*":
+		fillOp = "f*"
+	case "S":
+		strokeOp = "S"
+	case "s":
+		strokeOp = "s"
+	case "B", "b":
+		fillOp, strokeOp = "f", op.Operand
+	case "B*", "b*":
+		fillOp, strokeOp = "f*", op.Operand
+	}
+	if strokeOp == "s" || strokeOp == "b" || strokeOp == "b*" {
+		for _, sp := range subpaths {
+			sp.closed = true
+		}
+	}
+
+	regions := make([][4]float64, len(r.regions))
+	for i, region := range r.regions {
+		regions[i] = [4]float64{region.Llx, region.Lly, region.Urx, region.Ury}
+	}
+	overlaps := func(box [4]float64, margin float64) bool {
+		return r.overlapsRegions(box[0]-margin, box[1]-margin, box[2]+margin, box[3]+margin)
+	}
+
+	cut := false
+	fillOps := []*contentstream.ContentStreamOperation{}
+	if fillOp != "" {
+		// The rectangles are cut in user space, where the regions are rectangles if the axes are kept.
+		inverse, invertible := ctm.Inverse()
+		axisAligned := (ctm[1] == 0 && ctm[2] == 0) || (ctm[0] == 0 && ctm[3] == 0)
+		for _, sp := range subpaths {
+			if !overlaps(sp.box(ctm), 0) {
+				fillOps = sp.appendTo(fillOps)
+				continue
+			}
+			if sp.rect == nil || !invertible || !axisAligned {
+				return nil, false
+			}
+			cut = true
+			x, y, w, h := sp.rect[0], sp.rect[1], sp.rect[2], sp.rect[3]
+			pieces := [][4]float64{{math.Min(x, x+w), math.Min(y, y+h), math.Max(x, x+w), math.Max(y, y+h)}}
+			for _, region := range regions {
+				llx, lly, urx, ury := inverse.TransformRectangle(region[0], region[1], region[2], region[3])
+				var remaining [][4]float64
+				for _, piece := range pieces {
+					remaining = append(remaining, subtractRect(piece, [4]float64{llx, lly, urx, ury})...)
+				}
+				pieces = remaining
+			}
+			cc := contentstream.NewContentCreator()
+			for _, piece := range pieces {
+				// The pieces keep the orientation of the rectangle for the nonzero winding number rule.
+				px, pw := piece[0], piece[2]-piece[0]
+				if w < 0 {
+					px, pw = piece[2], -pw
+				}
+				py, ph := piece[1], piece[3]-piece[1]
+				if h < 0 {
+					py, ph = piece[3], -ph
+				}
+				cc.Add_re(px, py, pw, ph)
+			}
+			fillOps = append(fillOps, *cc.Operations()...)
+		}
+	}
+
+	strokeOps := []*contentstream.ContentStreamOperation{}
+	if strokeOp != "" {
+		// Strokes extend by half the line width around the segments, and further at square caps and joins.
+		margin := lineWidth / 2 * ctm.ScalingFactor() * math.Sqrt2
+		expanded := make([][4]float64, len(regions))
+		for i, region := range regions {
+			expanded[i] = [4]float64{region[0] - margin, region[1] - margin, region[2] + margin, region[3] + margin}
+		}
+		for _, sp := range subpaths {
+			if !overlaps(sp.box(ctm), margin) {
+				strokeOps = sp.appendTo(strokeOps)
+				continue
+			}
+			if sp.curved {
+				return nil, false
+			}
+			polylines, spCut := sp.strokeOutside(expanded, ctm)
+			if !spCut {
+				strokeOps = sp.appendTo(strokeOps)
+				continue
+			}
+			cut = true
+			cc := contentstream.NewContentCreator()
+			for _, polyline := range polylines {
+				cc.Add_m(polyline[0][0], polyline[0][1])
+				for _, p := range polyline[1:] {
+					cc.Add_l(p[0], p[1])
+				}
+			}
+			strokeOps = append(strokeOps, *cc.Operations()...)
+		}
+	}
+
+	if !cut {
+		return append(path[:len(path):len(path)], op), true
+	}
+	r.changed = true
+	ops := []*contentstream.ContentStreamOperation{}
+	if len(fillOps) > 0 {
+		ops = append(ops, fillOps...)
+		ops = append(ops, &contentstream.ContentStreamOperation{Operand: fillOp})
+	}
+	if len(strokeOps) > 0 {
+		ops = append(ops, strokeOps...)
+		ops = append(ops, &contentstream.ContentStreamOperation{Operand: "S"})
+	}
+	return ops, true
+}
+
+// drawXObject returns the operations drawing the XObject of the Do operation `op` without the content
+// overlapping the regions.
+func (r *contentRedactor) drawXObject(op *contentstream.ContentStreamOperation,
+	ctm contentstream.Matrix) []*contentstream.ContentStreamOperation {
+	keep := []*contentstream.ContentStreamOperation{op}
+	if len(op.Params) != 1 {
+		return keep
+	}
+	name, ok := op.Params[0].(*core.PdfObjectName)
+	if !ok {
+		return keep
+	}
+
+	stream, xtype := r.resources.GetXObjectByName(*name)
+	switch xtype {
+	case model.XObjectTypeImage:
+		llx, lly, urx, ury := ctm.TransformRectangle(0, 0, 1, 1)
+		if !r.overlapsRegions(llx, lly, urx, ury) {
+			return keep
+		}
+		r.changed = true
+		if r.insideRegion(llx, lly, urx, ury) {
+			return nil
+		}
+		ximg, err := model.NewXObjectImageFromStream(stream)
+		if err == nil {
+			ximg, err = r.redactImage(ximg, ctm)
+		}
+		if err != nil {
+			common.Log.Debug("Unable to redact image %s, removing it: %v", *name, err)
+			return nil
+		}
+		return r.drawRedactedImage(ximg)
+	case model.XObjectTypeForm:
+		if r.parents[stream] {
+			common.Log.Debug("Form %s drawing itself, skipping over", *name)
+			return keep
+		}
+		xform, err := model.NewXObjectFormFromStream(stream)
+		if err != nil {
+			common.Log.Debug("Invalid form %s, removing it: %v", *name, err)
+			r.changed = true
+			return nil
+		}
+
+		matrix := ctm
+		if vals := graphics.GetNumbers(xform.Matrix); len(vals) == 6 {
+			matrix = contentstream.NewMatrix(vals[0], vals[1], vals[2], vals[3], vals[4], vals[5]).Mult(ctm)
+		}
+		if bbox := graphics.GetNumbers(xform.BBox); len(bbox) == 4 {
+			if !r.overlapsRegions(matrix.TransformRectangle(bbox[0], bbox[1], bbox[2], bbox[3])) {
+				return keep
+			}
+		}
+
+		key := formKey{stream, matrix}
+		if redactedName, has := r.forms[key]; has {
+			return []*contentstream.ContentStreamOperation{{Operand: "Do", Params: []core.PdfObject{&redactedName}}}
+		}
+		redacted, err := r.redactForm(xform, stream, matrix)
+		if err != nil {
+			common.Log.Debug("Unable to redact form %s, removing it: %v", *name, err)
+			r.changed = true
+			return nil
+		}
+		if redacted == nil {
+			return keep
+		}
+		r.changed = true
+
+		redactedName := r.resources.GenerateXObjectName("RedactedForm")
+		if err := r.resources.SetXObjectFormByName(redactedName, redacted); err != nil {
+			common.Log.Debug("Unable to add form, removing it: %v", err)
+			return nil
+		}
+		r.forms[key] = redactedName
+		return []*contentstream.ContentStreamOperation{{Operand: "Do", Params: []core.PdfObject{&redactedName}}}
+	}
+	return keep
+}
+
+// redactForm returns a copy of form XObject `xform` without the content overlapping the regions when drawn with
+// `matrix`, or nil if no content overlaps.
+func (r *contentRedactor) redactForm(xform *model.XObjectForm, stream *core.PdfObjectStream,
+	matrix contentstream.Matrix) (*model.XObjectForm, error) {
+	content, err := xform.GetContentStream()
+	if err != nil {
+		return nil, err
+	}
+
+	parents := map[*core.PdfObjectStream]bool{stream: true}
+	for parent := range r.parents {
+		parents[parent] = true
+	}
+	resources := xform.Resources
+	if resources == nil {
+		resources = r.resources
+	}
+	sub := newContentRedactor(r.regions, resources, matrix, parents)
+	ops, err := sub.redact(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if !sub.changed {
+		return nil, nil
+	}
+
+	redacted := model.NewXObjectForm()
+	redacted.FormType = xform.FormType
+	redacted.BBox = xform.BBox
+	redacted.Matrix = xform.Matrix
+	redacted.Resources = xform.Resources
+	redacted.Group = xform.Group
+	redacted.StructParent = xform.StructParent
+	redacted.StructParents = xform.StructParents
+	redacted.OC = xform.OC
+	redacted.Filter = core.NewFlateEncoder()
+	err = redacted.SetContentStream(ops.Bytes(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return redacted, nil
+}
+
+// drawInlineImage returns the operations drawing the inline image of operation `op` without the pixels
+// overlapping the regions.  Redacted inline images are drawn as image XObjects.
+func (r *contentRedactor) drawInlineImage(op *contentstream.ContentStreamOperation,
+	ctm contentstream.Matrix) []*contentstream.ContentStreamOperation {
+	llx, lly, urx, ury := ctm.TransformRectangle(0, 0, 1, 1)
+	if !r.overlapsRegions(llx, lly, urx, ury) {
+		return []*contentstream.ContentStreamOperation{op}
+	}
+	r.changed = true
+	if r.insideRegion(llx, lly, urx, ury) || len(op.Params) != 1 {
+		return nil
+	}
+	inlineImage, ok := op.Params[0].(*contentstream.ContentStreamInlineImage)
+	if !ok {
+		return nil
+	}
+
+	ximg, err := r.redactInlineImage(inlineImage, ctm)
+	if err != nil {
+		common.Log.Debug("Unable to redact inline image, removing it: %v", err)
+		return nil
+	}
+	return r.drawRedactedImage(ximg)
+}
+
+// drawRedactedImage adds redacted image `ximg` to the resources and returns the operations drawing it.
+func (r *contentRedactor) drawRedactedImage(ximg *model.XObjectImage) []*contentstream.ContentStreamOperation {
+	name := r.resources.GenerateXObjectName("RedactedImage")
+	if err := r.resources.SetXObjectImageByName(name, ximg); err != nil {
+		common.Log.Debug("Unable to add image, removing it: %v", err)
+		return nil
+	}
+	return []*contentstream.ContentStreamOperation{{Operand: "Do", Params: []core.PdfObject{&name}}}
+}
@@ -0,0 +1,10 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package redactor applies redactions to PDF pages.  The text glyphs, image pixels and paths under the regions
+// marked by Redact annotations are removed from the page content streams and the form XObjects they draw, rather
+// than just covered, so that the redacted content cannot be recovered from the output.  The overlay of the
+// annotations is then painted over the regions and the annotations are removed.
+package redactor
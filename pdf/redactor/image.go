@@ -0,0 +1,173 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"errors"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// redactImage returns a copy of image XObject `ximg` with the pixels overlapping the regions cleared, when drawn
+// with `matrix`.  The soft mask is cleared alike.
+func (r *contentRedactor) redactImage(ximg *model.XObjectImage, matrix contentstream.Matrix) (*model.XObjectImage, error) {
+	isMask := false
+	if b, ok := core.TraceToDirectObject(ximg.ImageMask).(*core.PdfObjectBool); ok && bool(*b) {
+		isMask = true
+		// Stencil masks are 1 bit per component, the entry is optional.
+		bpc := int64(1)
+		ximg.BitsPerComponent = &bpc
+	}
+
+	img, err := ximg.ToImage()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.clearPixels(img, matrix, getClearedSample(ximg.Decode, isMask)); err != nil {
+		return nil, err
+	}
+
+	var cs model.PdfColorspace
+	if !isMask {
+		cs = ximg.ColorSpace
+	}
+	redacted, err := model.NewXObjectImageFromImage(img, cs, core.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	if isMask {
+		redacted.ColorSpace = nil
+	}
+	redacted.ImageMask = ximg.ImageMask
+	redacted.Decode = ximg.Decode
+	redacted.Intent = ximg.Intent
+	redacted.Interpolate = ximg.Interpolate
+	redacted.StructParent = ximg.StructParent
+	redacted.OC = ximg.OC
+	redacted.Matte = ximg.Matte
+	if _, isArr := core.TraceToDirectObject(ximg.Mask).(*core.PdfObjectArray); isArr {
+		// Color key masks are kept, stencil masks could disclose the shapes in the regions.
+		redacted.Mask = ximg.Mask
+	}
+
+	if smaskStream, ok := core.TraceToDirectObject(ximg.SMask).(*core.PdfObjectStream); ok {
+		smask, err := model.NewXObjectImageFromStream(smaskStream)
+		if err != nil {
+			return nil, err
+		}
+		smask, err = r.redactImage(smask, matrix)
+		if err != nil {
+			return nil, err
+		}
+		redacted.SMask = smask.ToPdfObject()
+	}
+
+	return redacted, nil
+}
+
+// redactInlineImage returns an image XObject of inline image `inlineImage` with the pixels overlapping the
+// regions cleared, when drawn with `matrix`.
+func (r *contentRedactor) redactInlineImage(inlineImage *contentstream.ContentStreamInlineImage,
+	matrix contentstream.Matrix) (*model.XObjectImage, error) {
+	isMask, err := inlineImage.IsMask()
+	if err != nil {
+		return nil, err
+	}
+	img, err := inlineImage.ToImage(r.resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var cs model.PdfColorspace
+	if !isMask {
+		cs, err = inlineImage.GetColorSpace(r.resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.clearPixels(img, matrix, getClearedSample(inlineImage.Decode, isMask)); err != nil {
+		return nil, err
+	}
+	redacted, err := model.NewXObjectImageFromImage(img, cs, core.NewFlateEncoder())
+	if err != nil {
+		return nil, err
+	}
+	if isMask {
+		redacted.ColorSpace = nil
+		redacted.ImageMask = core.MakeBool(true)
+	}
+	redacted.Decode = inlineImage.Decode
+	redacted.Intent = inlineImage.Intent
+	redacted.Interpolate = inlineImage.Interpolate
+	return redacted, nil
+}
+
+// getClearedSample returns the sample value of cleared pixels: unpainted for stencil masks and 0 otherwise.
+func getClearedSample(decode core.PdfObject, isMask bool) uint32 {
+	if !isMask {
+		return 0
+	}
+	// With the default decode array [0 1], samples of 1 leave the page unpainted.
+	if vals := graphics.GetNumbers(decode); len(vals) == 2 && vals[0] == 1 {
+		return 0
+	}
+	return 1
+}
+
+// clearPixels sets the samples of the pixels of `img` overlapping the regions to `value`, when the image is drawn
+// with `matrix`.
+func (r *contentRedactor) clearPixels(img *model.Image, matrix contentstream.Matrix, value uint32) error {
+	width := int(img.Width)
+	height := int(img.Height)
+	bpc := int(img.BitsPerComponent)
+	if width <= 0 || height <= 0 || bpc <= 0 || bpc > 16 {
+		return errors.New("Invalid image dimensions")
+	}
+	// Rows start at byte boundaries.
+	rowBits := width * img.ColorComponents * bpc
+	rowBytes := (rowBits + 7) / 8
+
+	for j := 0; j < height; j++ {
+		// Rows are stored from the top, the image space origin is at the bottom.
+		v0 := 1 - float64(j+1)/float64(height)
+		v1 := 1 - float64(j)/float64(height)
+		if !r.overlapsRegions(matrix.TransformRectangle(0, v0, 1, v1)) {
+			continue
+		}
+		for i := 0; i < width; i++ {
+			u0 := float64(i) / float64(width)
+			u1 := float64(i+1) / float64(width)
+			if !r.overlapsRegions(matrix.TransformRectangle(u0, v0, u1, v1)) {
+				continue
+			}
+			for k := 0; k < img.ColorComponents; k++ {
+				bitPos := j*rowBytes*8 + (i*img.ColorComponents+k)*bpc
+				setSample(img.Data, bitPos, bpc, value)
+			}
+		}
+	}
+	return nil
+}
+
+// setSample sets the sample of `bpc` bits starting at bit `bitPos` of `data` to `value`.
+func setSample(data []byte, bitPos int, bpc int, value uint32) {
+	for b := 0; b < bpc; b++ {
+		pos := bitPos + b
+		if pos/8 >= len(data) {
+			return
+		}
+		mask := byte(1) << uint(7-pos%8)
+		if (value>>uint(bpc-1-b))&1 == 1 {
+			data[pos/8] |= mask
+		} else {
+			data[pos/8] &^= mask
+		}
+	}
+}
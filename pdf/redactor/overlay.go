@@ -0,0 +1,200 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+	"github.com/unidoc/unidoc/pdf/model/textencoding"
+)
+
+// makeOverlay returns the operations painting the overlay of Redact annotation `redact` over `regions`: the form
+// XObject RO if specified, otherwise the regions filled with the interior color IC and the overlay text.  The
+// resources used by the overlay are added to `resources`.
+func makeOverlay(resources *model.PdfPageResources, redact *model.PdfAnnotationRedact,
+	regions []model.PdfRectangle) (contentstream.ContentStreamOperations, error) {
+	cc := contentstream.NewContentCreator()
+
+	if stream, ok := core.TraceToDirectObject(redact.RO).(*core.PdfObjectStream); ok {
+		xform, err := model.NewXObjectFormFromStream(stream)
+		if err != nil {
+			return nil, err
+		}
+		bbox := graphics.GetNumbers(xform.BBox)
+		if len(bbox) != 4 || bbox[0] == bbox[2] || bbox[1] == bbox[3] {
+			common.Log.Debug("Invalid overlay form bbox, skipping over")
+			return nil, nil
+		}
+		name := resources.GenerateXObjectName("RedactOverlay")
+		if err := resources.SetXObjectByName(name, stream); err != nil {
+			return nil, err
+		}
+		// The form bbox is fitted to each region, ignoring the form matrix.
+		sx := 1 / (bbox[2] - bbox[0])
+		sy := 1 / (bbox[3] - bbox[1])
+		for _, region := range regions {
+			cc.Add_q()
+			cc.Add_cm(region.Width()*sx, 0, 0, region.Height()*sy,
+				region.Llx-bbox[0]*region.Width()*sx, region.Lly-bbox[1]*region.Height()*sy)
+			cc.Add_Do(name)
+			cc.Add_Q()
+		}
+		return *cc.Operations(), nil
+	}
+
+	if color := graphics.GetNumbers(redact.IC); len(color) > 0 {
+		for _, region := range regions {
+			cc.Add_q()
+			addFillColor(cc, color)
+			cc.Add_re(region.Llx, region.Lly, region.Width(), region.Height())
+			cc.Add_f()
+			cc.Add_Q()
+		}
+	}
+
+	if str, ok := core.TraceToDirectObject(redact.OverlayText).(*core.PdfObjectString); ok && len(*str) > 0 {
+		err := addOverlayText(cc, resources, redact, model.DecodeTextString(string(*str)), regions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return *cc.Operations(), nil
+}
+
+// addFillColor adds the operation setting the fill color to the gray, RGB or CMYK `color`.
+func addFillColor(cc *contentstream.ContentCreator, color []float64) {
+	switch len(color) {
+	case 1:
+		cc.Add_g(color[0])
+	case 3:
+		cc.Add_rg(color[0], color[1], color[2])
+	case 4:
+		cc.Add_k(color[0], color[1], color[2], color[3])
+	}
+}
+
+// addOverlayText adds the operations showing `text` in each of `regions` with the font size, color and alignment
+// of Redact annotation `redact`.  The text is shown with Helvetica, which is added to `resources`.
+func addOverlayText(cc *contentstream.ContentCreator, resources *model.PdfPageResources,
+	redact *model.PdfAnnotationRedact, text string, regions []model.PdfRectangle) error {
+	fontSize, color := parseDefaultAppearance(redact.DA)
+	align, _ := graphics.GetNumber(redact.Q)
+	repeat := false
+	if b, ok := core.TraceToDirectObject(redact.Repeat).(*core.PdfObjectBool); ok {
+		repeat = bool(*b)
+	}
+
+	font := fonts.NewFontHelvetica()
+	encoder := textencoding.NewWinAnsiTextEncoder()
+	fontName := resources.GenerateFontName("RedactFont")
+	if err := resources.SetFontByName(fontName, font.ToPdfObject()); err != nil {
+		return err
+	}
+
+	// textWidth returns the width of `s` for a font size of 1.
+	textWidth := func(s string) float64 {
+		width := 0.0
+		for _, r := range s {
+			glyph, found := encoder.RuneToGlyph(r)
+			if !found {
+				continue
+			}
+			if metrics, found := font.GetGlyphCharMetrics(glyph); found {
+				width += metrics.Wx / 1000
+			}
+		}
+		return width
+	}
+
+	for _, region := range regions {
+		size := fontSize
+		width := textWidth(text)
+		if size <= 0 {
+			// Auto sized text fills the region.
+			size = region.Height() * 0.8
+			if width > 0 && width*size > region.Width() {
+				size = region.Width() / width
+			}
+		}
+		if size <= 0 {
+			continue
+		}
+
+		lines := []string{text}
+		if repeat {
+			// The text is repeated to fill the region.
+			line := text
+			for width > 0 && textWidth(line+" "+text)*size <= region.Width() {
+				line += " " + text
+			}
+			lines = []string{}
+			for y := region.Ury - size; y >= region.Lly-size*0.2 || len(lines) == 0; y -= size {
+				lines = append(lines, line)
+			}
+		}
+
+		cc.Add_q()
+		cc.Add_re(region.Llx, region.Lly, region.Width(), region.Height())
+		cc.Add_W().Add_n()
+		addFillColor(cc, color)
+		cc.Add_BT()
+		cc.Add_Tf(fontName, size)
+
+		// The block of lines is centered vertically with the cap height (0.718 for Helvetica) of each line.
+		top := region.Lly + (region.Height()+float64(len(lines))*size)/2
+		for i, line := range lines {
+			x := region.Llx
+			switch int(align) {
+			case 1:
+				x += (region.Width() - textWidth(line)*size) / 2
+			case 2:
+				x += region.Width() - textWidth(line)*size
+			}
+			y := top - float64(i)*size - (size+0.718*size)/2
+			cc.Add_Tm(1, 0, 0, 1, x, y)
+			cc.Add_Tj(core.PdfObjectString(encoder.Encode(line)))
+		}
+		cc.Add_ET()
+		cc.Add_Q()
+	}
+	return nil
+}
+
+// parseDefaultAppearance returns the font size and the fill color of default appearance string `da`, such as
+// "/Helv 12 Tf 1 0 0 rg".  The font size is 0 for auto sized text, the color defaults to black.
+func parseDefaultAppearance(da core.PdfObject) (float64, []float64) {
+	fontSize := 0.0
+	color := []float64{0}
+
+	str, ok := core.TraceToDirectObject(da).(*core.PdfObjectString)
+	if !ok {
+		return fontSize, color
+	}
+	ops, err := contentstream.NewContentStreamParser(string(*str)).Parse()
+	if err != nil {
+		common.Log.Debug("Invalid default appearance %q: %v", string(*str), err)
+		return fontSize, color
+	}
+	for _, op := range *ops {
+		nums := graphics.GetOperandNumbers(op.Params)
+		switch op.Operand {
+		case "Tf":
+			if len(op.Params) == 2 {
+				fontSize, _ = graphics.GetNumber(op.Params[1])
+			}
+		case "g", "rg", "k":
+			if len(nums) == len(op.Params) {
+				color = nums
+			}
+		}
+	}
+	return fontSize, color
+}
@@ -0,0 +1,221 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+)
+
+// subpath is a subpath of a path in user space with the operations building it.
+type subpath struct {
+	ops []*contentstream.ContentStreamOperation
+	// points are the end points of the segments, starting with the start point.
+	points [][2]float64
+	// coords are the end points and control points of the segments, which bound the subpath.
+	coords [][2]float64
+	// rect is the rectangle (x, y, width, height) of a subpath built by a re operation, nil otherwise.
+	rect   []float64
+	closed bool
+	curved bool
+}
+
+// getSubpaths returns the subpaths of the path built by operations `path` or false if the operations are
+// invalid.
+func getSubpaths(path []*contentstream.ContentStreamOperation) ([]*subpath, bool) {
+	subpaths := []*subpath{}
+	var current *subpath
+	for _, op := range path {
+		nums := graphics.GetOperandNumbers(op.Params)
+		switch op.Operand {
+		case "m":
+			if len(nums) != 2 {
+				return nil, false
+			}
+			current = &subpath{points: [][2]float64{{nums[0], nums[1]}}}
+			current.coords = append([][2]float64{}, current.points...)
+			subpaths = append(subpaths, current)
+		case "l", "c", "v", "y":
+			if current == nil || len(nums) == 0 || len(nums)%2 != 0 {
+				return nil, false
+			}
+			if current.closed {
+				// A segment following a closed subpath starts a new subpath at its start point.
+				current = &subpath{points: [][2]float64{current.points[0]}}
+				current.coords = append([][2]float64{}, current.points...)
+				subpaths = append(subpaths, current)
+			}
+			current.curved = current.curved || op.Operand != "l"
+			for i := 0; i < len(nums); i += 2 {
+				current.coords = append(current.coords, [2]float64{nums[i], nums[i+1]})
+			}
+			current.points = append(current.points, [2]float64{nums[len(nums)-2], nums[len(nums)-1]})
+		case "re":
+			if len(nums) != 4 {
+				return nil, false
+			}
+			x, y, w, h := nums[0], nums[1], nums[2], nums[3]
+			current = &subpath{
+				points: [][2]float64{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}},
+				rect:   nums,
+				closed: true,
+			}
+			current.coords = append([][2]float64{}, current.points...)
+			subpaths = append(subpaths, current)
+		case "h":
+			if current == nil {
+				return nil, false
+			}
+			current.closed = true
+		}
+		current.ops = append(current.ops, op)
+	}
+	return subpaths, true
+}
+
+// appendTo returns `ops` with the operations building the subpath appended.
+func (s *subpath) appendTo(ops []*contentstream.ContentStreamOperation) []*contentstream.ContentStreamOperation {
+	cc := contentstream.NewContentCreator()
+	if first := s.ops[0].Operand; first != "m" && first != "re" {
+		cc.Add_m(s.points[0][0], s.points[0][1])
+	}
+	ops = append(ops, *cc.Operations()...)
+	ops = append(ops, s.ops...)
+	if last := s.ops[len(s.ops)-1].Operand; s.closed && last != "h" && last != "re" {
+		ops = append(ops, &contentstream.ContentStreamOperation{Operand: "h"})
+	}
+	return ops
+}
+
+// box returns the bounding box (llx, lly, urx, ury) of the subpath in the coordinate system mapped by `ctm`.
+func (s *subpath) box(ctm contentstream.Matrix) [4]float64 {
+	box := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range s.coords {
+		x, y := ctm.Transform(p[0], p[1])
+		box = [4]float64{math.Min(box[0], x), math.Min(box[1], y), math.Max(box[2], x), math.Max(box[3], y)}
+	}
+	return box
+}
+
+// strokeOutside returns the polylines of the segments of the subpath outside rectangles `rects`
+// (llx, lly, urx, ury), in the coordinate system mapped by `ctm`, and whether any segment is cut.
+func (s *subpath) strokeOutside(rects [][4]float64, ctm contentstream.Matrix) ([][][2]float64, bool) {
+	points := s.points
+	if s.closed && len(points) > 1 {
+		points = append(points[:len(points):len(points)], points[0])
+	}
+
+	var polylines [][][2]float64
+	var polyline [][2]float64
+	cut := false
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		ax, ay := ctm.Transform(a[0], a[1])
+		bx, by := ctm.Transform(b[0], b[1])
+		// The intervals of the segment parameter outside the rectangles.  The parameter is the same in user
+		// space as the mapping is affine.
+		intervals := [][2]float64{{0, 1}}
+		for _, rect := range rects {
+			if t0, t1, ok := clipSegment(ax, ay, bx, by, rect); ok {
+				intervals = subtractInterval(intervals, t0, t1)
+			}
+		}
+		if len(intervals) != 1 || intervals[0] != [2]float64{0, 1} {
+			cut = true
+		}
+
+		for _, interval := range intervals {
+			end := interpolate(a, b, interval[1])
+			if polyline != nil && interval[0] == 0 {
+				polyline = append(polyline, end)
+				continue
+			}
+			if polyline != nil {
+				polylines = append(polylines, polyline)
+			}
+			polyline = [][2]float64{interpolate(a, b, interval[0]), end}
+		}
+		if polyline != nil && (len(intervals) == 0 || intervals[len(intervals)-1][1] != 1) {
+			polylines = append(polylines, polyline)
+			polyline = nil
+		}
+	}
+	if polyline != nil {
+		polylines = append(polylines, polyline)
+	}
+	return polylines, cut
+}
+
+// interpolate returns the point of the segment from `a` to `b` at parameter `t`.
+func interpolate(a, b [2]float64, t float64) [2]float64 {
+	return [2]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}
+}
+
+// clipSegment returns the interval [t0, t1] of the parameter of the segment from (ax, ay) to (bx, by) inside
+// rectangle `rect` (llx, lly, urx, ury), with false if the segment does not cross the interior of the
+// rectangle.
+func clipSegment(ax, ay, bx, by float64, rect [4]float64) (float64, float64, bool) {
+	t0, t1 := 0.0, 1.0
+	dx, dy := bx-ax, by-ay
+	// The segment is inside the rectangle where p*t < q for each edge.
+	edges := [][2]float64{{-dx, ax - rect[0]}, {dx, rect[2] - ax}, {-dy, ay - rect[1]}, {dy, rect[3] - ay}}
+	for _, edge := range edges {
+		p, q := edge[0], edge[1]
+		switch {
+		case p == 0:
+			if q <= 0 {
+				return 0, 0, false
+			}
+		case p < 0:
+			t0 = math.Max(t0, q/p)
+		default:
+			t1 = math.Min(t1, q/p)
+		}
+	}
+	return t0, t1, t0 < t1
+}
+
+// subtractInterval returns the parts of `intervals` outside the interval [t0, t1].
+func subtractInterval(intervals [][2]float64, t0, t1 float64) [][2]float64 {
+	out := [][2]float64{}
+	for _, interval := range intervals {
+		if t1 <= interval[0] || interval[1] <= t0 {
+			out = append(out, interval)
+			continue
+		}
+		if interval[0] < t0 {
+			out = append(out, [2]float64{interval[0], t0})
+		}
+		if t1 < interval[1] {
+			out = append(out, [2]float64{t1, interval[1]})
+		}
+	}
+	return out
+}
+
+// subtractRect returns the rectangles (llx, lly, urx, ury) covering `rect` outside `region`.
+func subtractRect(rect, region [4]float64) [][4]float64 {
+	if !(rect[0] < region[2] && region[0] < rect[2] && rect[1] < region[3] && region[1] < rect[3]) {
+		return [][4]float64{rect}
+	}
+	pieces := [][4]float64{}
+	if rect[0] < region[0] {
+		pieces = append(pieces, [4]float64{rect[0], rect[1], region[0], rect[3]})
+	}
+	if region[2] < rect[2] {
+		pieces = append(pieces, [4]float64{region[2], rect[1], rect[2], rect[3]})
+	}
+	llx, urx := math.Max(rect[0], region[0]), math.Min(rect[2], region[2])
+	if rect[1] < region[1] {
+		pieces = append(pieces, [4]float64{llx, rect[1], urx, region[1]})
+	}
+	if region[3] < rect[3] {
+		pieces = append(pieces, [4]float64{llx, region[3], urx, rect[3]})
+	}
+	return pieces
+}
@@ -0,0 +1,158 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"errors"
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// ApplyRedactions applies the Redact annotations of `page`.  The text glyphs, image pixels and paths overlapping
+// the regions of the annotations (given by QuadPoints, or Rect if missing) are removed from the page contents and
+// the form XObjects drawn by it.  The overlay of each annotation (RO, or IC and OverlayText) is painted over its
+// regions and the annotations are removed from the page with their pop-ups.
+// Returns the number of applied annotations.
+//
+// The appearances of other annotations in the regions are left unchanged.
+func ApplyRedactions(page *model.PdfPage) (int, error) {
+	redacts := []*model.PdfAnnotationRedact{}
+	allRegions := []model.PdfRectangle{}
+	regions := map[*model.PdfAnnotationRedact][]model.PdfRectangle{}
+	for _, annot := range page.Annotations {
+		redact, ok := annot.GetContext().(*model.PdfAnnotationRedact)
+		if !ok {
+			continue
+		}
+		annotRegions, err := getRedactRegions(redact)
+		if err != nil {
+			return 0, err
+		}
+		redacts = append(redacts, redact)
+		regions[redact] = annotRegions
+		allRegions = append(allRegions, annotRegions...)
+	}
+	if len(redacts) == 0 {
+		return 0, nil
+	}
+
+	if page.Resources == nil {
+		page.Resources = model.NewPdfPageResources()
+	}
+	overlay := contentstream.ContentStreamOperations{}
+	for _, redact := range redacts {
+		ops, err := makeOverlay(page.Resources, redact, regions[redact])
+		if err != nil {
+			return 0, err
+		}
+		overlay = append(overlay, ops...)
+	}
+
+	err := redactContents(page, allRegions, overlay)
+	if err != nil {
+		return 0, err
+	}
+
+	removeAnnotations(page, redacts)
+	return len(redacts), nil
+}
+
+// RedactRegions removes the text glyphs, image pixels and paths of `page` overlapping `regions`, given in default
+// user space, from the page contents and the form XObjects drawn by it.  Nothing is painted over the regions.
+func RedactRegions(page *model.PdfPage, regions []*model.PdfRectangle) error {
+	rects := []model.PdfRectangle{}
+	for _, region := range regions {
+		rects = append(rects, *region.Normalized())
+	}
+	if len(rects) == 0 {
+		return nil
+	}
+	if page.Resources == nil {
+		page.Resources = model.NewPdfPageResources()
+	}
+	return redactContents(page, rects, nil)
+}
+
+// redactContents replaces the contents of `page` by the contents without the content overlapping `regions`,
+// followed by the `overlay` operations.
+func redactContents(page *model.PdfPage, regions []model.PdfRectangle,
+	overlay contentstream.ContentStreamOperations) error {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return err
+	}
+
+	r := newContentRedactor(regions, page.Resources, contentstream.IdentityMatrix(),
+		map[*core.PdfObjectStream]bool{})
+	ops, err := r.redact(contents)
+	if err != nil {
+		return err
+	}
+	// The overlay is drawn in the initial graphics state.
+	ops.WrapIfNeeded()
+	ops = append(ops, overlay...)
+
+	return page.SetContentStreams([]string{string(ops.Bytes())}, core.NewFlateEncoder())
+}
+
+// getRedactRegions returns the regions of Redact annotation `redact` in default user space: the bounding boxes
+// of its quadrilaterals, or its rectangle if it has none.
+func getRedactRegions(redact *model.PdfAnnotationRedact) ([]model.PdfRectangle, error) {
+	regions := []model.PdfRectangle{}
+	quadPoints := graphics.GetNumbers(redact.QuadPoints)
+	for i := 0; i+7 < len(quadPoints); i += 8 {
+		region := model.PdfRectangle{Llx: quadPoints[i], Lly: quadPoints[i+1], Urx: quadPoints[i], Ury: quadPoints[i+1]}
+		for j := i + 2; j < i+8; j += 2 {
+			region.Llx = math.Min(region.Llx, quadPoints[j])
+			region.Lly = math.Min(region.Lly, quadPoints[j+1])
+			region.Urx = math.Max(region.Urx, quadPoints[j])
+			region.Ury = math.Max(region.Ury, quadPoints[j+1])
+		}
+		regions = append(regions, region)
+	}
+	if len(regions) > 0 {
+		return regions, nil
+	}
+
+	arr, ok := core.TraceToDirectObject(redact.Rect).(*core.PdfObjectArray)
+	if !ok {
+		return nil, errors.New("Redact annotation without QuadPoints and Rect")
+	}
+	rect, err := model.NewPdfRectangle(*arr)
+	if err != nil {
+		return nil, err
+	}
+	return []model.PdfRectangle{*rect.Normalized()}, nil
+}
+
+// removeAnnotations removes the annotations `redacts` and their pop-ups from `page`.
+func removeAnnotations(page *model.PdfPage, redacts []*model.PdfAnnotationRedact) {
+	removed := map[*model.PdfAnnotation]bool{}
+	containers := map[core.PdfObject]bool{}
+	for _, redact := range redacts {
+		removed[redact.PdfAnnotation] = true
+		containers[redact.PdfAnnotation.GetContainingPdfObject()] = true
+		if redact.Popup != nil {
+			removed[redact.Popup.PdfAnnotation] = true
+		}
+	}
+
+	annotations := []*model.PdfAnnotation{}
+	for _, annot := range page.Annotations {
+		if removed[annot] {
+			continue
+		}
+		if popup, ok := annot.GetContext().(*model.PdfAnnotationPopup); ok && containers[popup.Parent] {
+			continue
+		}
+		annotations = append(annotations, annot)
+	}
+	page.Annotations = annotations
+}
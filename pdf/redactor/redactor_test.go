@@ -0,0 +1,315 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/extractor"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+// newTestPage returns a page with Helvetica as font F1 and `content`.
+func newTestPage(content string) *model.PdfPage {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 400, Ury: 400}
+	page.Resources = model.NewPdfPageResources()
+	page.Resources.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+	page.AddContentStreamByString(content)
+	return page
+}
+
+// extractText returns the text of `page`.
+func extractText(t *testing.T, page *model.PdfPage) string {
+	e, err := extractor.New(page)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	text, err := e.ExtractText()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return text
+}
+
+// writeAndRead writes `page` to file `path` and returns the page read back.
+func writeAndRead(t *testing.T, page *model.PdfPage, path string) *model.PdfPage {
+	writer := model.NewPdfWriter()
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	page, err = reader.GetPage(1)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return page
+}
+
+func TestApplyRedactions(t *testing.T) {
+	// "Account:" is 46.692 and "Account: " 50.028 points wide in Helvetica 12.
+	page := newTestPage("BT /F1 12 Tf 50 300 Td (Public text) Tj 0 -20 Td [(Account: )-50(1234)] TJ ET " +
+		"BT /F1 12 Tf 50 200 Td (More public text) Tj ET")
+
+	redact := model.NewPdfAnnotationRedact()
+	redact.Rect = (&model.PdfRectangle{Llx: 98, Lly: 275, Urx: 140, Ury: 292}).ToPdfObject()
+	redact.IC = core.MakeArrayFromFloats([]float64{0, 0, 0})
+	redact.OverlayText = core.MakeString("XXX")
+	popup := model.NewPdfAnnotationPopup()
+	popup.Parent = redact.PdfAnnotation.GetContainingPdfObject()
+	redact.Popup = popup
+	page.Annotations = append(page.Annotations, redact.PdfAnnotation, popup.PdfAnnotation)
+
+	page = writeAndRead(t, page, "/tmp/redact.pdf")
+	if len(page.Annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, got %d", len(page.Annotations))
+	}
+
+	n, err := ApplyRedactions(page)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 applied redaction, got %d", n)
+	}
+	if len(page.Annotations) != 0 {
+		t.Errorf("Expected the annotations to be removed, got %d", len(page.Annotations))
+	}
+
+	page = writeAndRead(t, page, "/tmp/redact_applied.pdf")
+	text := extractText(t, page)
+	for _, expected := range []string{"Public text", "Account:", "More public text"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q in text %q", expected, text)
+		}
+	}
+	for _, redacted := range []string{"1234", "1", "4"} {
+		if strings.Contains(text, redacted) {
+			t.Errorf("Redacted %q found in text %q", redacted, text)
+		}
+	}
+
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if !strings.Contains(contents, "98.000000 275.000000 42.000000 17.000000 re") {
+		t.Errorf("Overlay not painted: %s", contents)
+	}
+}
+
+func TestRedactRegionsGraphicsStateFont(t *testing.T) {
+	// The font and size are set by a graphics state parameter dictionary.
+	page := newTestPage("/GS1 gs BT 50 300 Td (Secret) Tj ET BT /F1 12 Tf 50 200 Td (Public) Tj ET")
+	font, _ := page.Resources.GetFontByName("F1")
+	gs := core.MakeDict()
+	gs.Set("Font", core.MakeArray(font, core.MakeInteger(12)))
+	page.Resources.AddExtGState("GS1", gs)
+
+	// "Sec" is 20.676 points wide in Helvetica 12.
+	err := RedactRegions(page, []*model.PdfRectangle{{Llx: 72, Lly: 290, Urx: 200, Ury: 320}})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if strings.Contains(contents, "Secret") || !strings.Contains(contents, "[(Sec)") ||
+		!strings.Contains(contents, "(Public) Tj") {
+		t.Errorf("Unexpected contents: %s", contents)
+	}
+}
+
+func TestRedactRegionsForm(t *testing.T) {
+	page := newTestPage("q 1 0 0 1 100 100 cm /Fm1 Do Q")
+	xform := model.NewXObjectForm()
+	xform.BBox = core.MakeArrayFromFloats([]float64{0, 0, 200, 50})
+	xform.Resources = page.Resources
+	xform.SetContentStream([]byte("BT /F1 10 Tf 10 10 Td (Confidential) Tj ET 0 0 50 50 re f"), nil)
+	page.Resources.SetXObjectFormByName("Fm1", xform)
+
+	// The region covers the text and the rectangle in the form.
+	err := RedactRegions(page, []*model.PdfRectangle{{Llx: 100, Lly: 100, Urx: 300, Ury: 150}})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	text := extractText(t, page)
+	if strings.Contains(text, "Confidential") {
+		t.Errorf("Redacted text found: %q", text)
+	}
+	contents, _ := page.GetAllContentStreams()
+	if !strings.Contains(contents, "/RedactedForm0 Do") {
+		t.Fatalf("Redacted form not drawn: %s", contents)
+	}
+	redacted, err := page.Resources.GetXObjectFormByName("RedactedForm0")
+	if err != nil || redacted == nil {
+		t.Fatalf("Redacted form missing: %v", err)
+	}
+	content, err := redacted.GetContentStream()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if strings.Contains(string(content), "Confidential") || strings.Contains(string(content), "re f") {
+		t.Errorf("Redacted content left in form: %s", content)
+	}
+
+	// The original form is unchanged for other users.
+	original, _ := page.Resources.GetXObjectFormByName("Fm1")
+	content, _ = original.GetContentStream()
+	if !strings.Contains(string(content), "Confidential") {
+		t.Errorf("Original form changed: %s", content)
+	}
+}
+
+func TestRedactRegionsPaths(t *testing.T) {
+	page := newTestPage("0 0 400 400 re f 10 10 20 20 re f 300 300 10 10 re f")
+
+	err := RedactRegions(page, []*model.PdfRectangle{{Llx: 0, Lly: 0, Urx: 50, Ury: 50}})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	contents, _ := page.GetAllContentStreams()
+	ops, err := contentstream.NewContentStreamParser(contents).Parse()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	var rects [][]float64
+	for _, op := range *ops {
+		if op.Operand == "re" {
+			rects = append(rects, graphics.GetOperandNumbers(op.Params))
+		}
+	}
+
+	// The background is cut into the rectangles outside the region, the rectangle inside is removed and the
+	// one outside is kept.
+	expected := [][]float64{
+		{50, 0, 350, 400},
+		{0, 50, 50, 350},
+		{300, 300, 10, 10},
+	}
+	if fmt.Sprint(rects) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected rectangles: %v", rects)
+	}
+}
+
+func TestRedactRegionsStrokes(t *testing.T) {
+	// A line crossing the region, a rectangle around it, a curve crossing it and a line passing beside it,
+	// drawn scaled by 2.
+	page := newTestPage("2 0 0 2 0 0 cm 1 w 0 20 m 100 20 l S 10 10 40 40 re S " +
+		"0 30 m 50 30 50 30 100 30 c S 0 100 m 100 100 l S")
+
+	region := model.PdfRectangle{Llx: 40, Lly: 20, Urx: 80, Ury: 60}
+	if err := RedactRegions(page, []*model.PdfRectangle{&region}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	contents, _ := page.GetAllContentStreams()
+	ops, err := contentstream.NewContentStreamParser(contents).Parse()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// The segments of the remaining path data in default user space.
+	var segments [][4]float64
+	var x, y float64
+	for _, op := range *ops {
+		nums := graphics.GetOperandNumbers(op.Params)
+		switch op.Operand {
+		case "m":
+			x, y = 2*nums[0], 2*nums[1]
+		case "l":
+			segments = append(segments, [4]float64{x, y, 2 * nums[0], 2 * nums[1]})
+			x, y = 2*nums[0], 2*nums[1]
+		case "c":
+			t.Errorf("Curve crossing the region kept: %s", contents)
+		case "re":
+			t.Errorf("Rectangle crossing the region kept: %s", contents)
+		}
+	}
+
+	// Half the line width around the region must stay free.
+	margin := 1.0
+	for _, s := range segments {
+		if _, _, ok := clipSegment(s[0], s[1], s[2], s[3], [4]float64{region.Llx - margin, region.Lly - margin,
+			region.Urx + margin, region.Ury + margin}); ok {
+			t.Errorf("Segment %v extends into the region", s)
+		}
+	}
+	// The line is cut at the region, the sides of the rectangle outside the region and the line beside it are
+	// kept.
+	for _, expected := range [][4]float64{{0, 40, 38.5858, 40}, {20, 20, 38.5858, 20}, {20, 100, 20, 20},
+		{0, 200, 200, 200}} {
+		found := false
+		for _, s := range segments {
+			if fmt.Sprintf("%.4f", s) == fmt.Sprintf("%.4f", expected) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Missing segment %v in %v", expected, segments)
+		}
+	}
+	if strings.Contains(contents, "W") {
+		t.Errorf("Path clipped instead of cut: %s", contents)
+	}
+}
+
+func TestRedactRegionsImage(t *testing.T) {
+	page := newTestPage("q 40 0 0 40 0 0 cm /Im1 Do Q")
+	img := &model.Image{
+		Width:            4,
+		Height:           4,
+		BitsPerComponent: 8,
+		ColorComponents:  1,
+		Data:             bytes.Repeat([]byte{255}, 16),
+	}
+	ximg, err := model.NewXObjectImageFromImage(img, nil, nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	page.Resources.SetXObjectImageByName("Im1", ximg)
+
+	// The region covers the left half of the image.
+	err = RedactRegions(page, []*model.PdfRectangle{{Llx: -10, Lly: -10, Urx: 20, Ury: 50}})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	redacted, err := page.Resources.GetXObjectImageByName("RedactedImage0")
+	if err != nil || redacted == nil {
+		t.Fatalf("Redacted image missing: %v", err)
+	}
+	redactedImg, err := redacted.ToImage()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	expected := bytes.Repeat([]byte{0, 0, 255, 255}, 4)
+	if !bytes.Equal(redactedImg.Data, expected) {
+		t.Errorf("Unexpected image data % X", redactedImg.Data)
+	}
+}
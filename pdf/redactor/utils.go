@@ -0,0 +1,21 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package redactor
+
+import (
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// overlaps checks whether the rectangle (llx, lly, urx, ury) overlaps `rect` in an area.  Rectangles only
+// touching at the edges do not overlap.
+func overlaps(rect model.PdfRectangle, llx, lly, urx, ury float64) bool {
+	return llx < rect.Urx && rect.Llx < urx && lly < rect.Ury && rect.Lly < ury
+}
+
+// contains checks whether the rectangle (llx, lly, urx, ury) is inside `rect`.
+func contains(rect model.PdfRectangle, llx, lly, urx, ury float64) bool {
+	return rect.Llx <= llx && urx <= rect.Urx && rect.Lly <= lly && ury <= rect.Ury
+}
@@ -0,0 +1,24 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// colorToRGB returns the RGB components of color `color` of colorspace `cs`, black if it cannot be converted.
+func colorToRGB(cs model.PdfColorspace, color model.PdfColor) solidColor {
+	r, g, b, _ := graphics.ColorToRGB(cs, color)
+	return solidColor{r, g, b}
+}
+
+// floatsToRGB returns the RGB components of the color with components `vals` of colorspace `cs`, black if it
+// cannot be converted.
+func floatsToRGB(cs model.PdfColorspace, vals []float64) solidColor {
+	r, g, b, _ := graphics.FloatsToRGB(cs, vals)
+	return solidColor{r, g, b}
+}
@@ -0,0 +1,493 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"image"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// flatness is the maximum deviation in device pixels of the polylines approximating curves.
+const flatness = 0.2
+
+// maxDepth is the maximum nesting depth of forms, patterns and Type 3 glyphs drawn.
+const maxDepth = 32
+
+// graphicsState holds the graphics state used for rendering: the parameters set by the operators and the
+// parameters of transparency and clipping.
+type graphicsState struct {
+	graphics.State
+	blendMode string
+	// softMask and clip are the current soft mask and clipping path as alpha masks in device space, nil for
+	// none.
+	softMask *image.Alpha
+	clip     *image.Alpha
+}
+
+// newGraphicsState returns the graphics state at the start of a page.
+func newGraphicsState() graphicsState {
+	return graphicsState{State: graphics.NewState()}
+}
+
+// contentRenderer draws a content stream onto a canvas.  It is the device of the interpreter it embeds.
+type contentRenderer struct {
+	*graphics.Interpreter
+	r     *Renderer
+	dst   *image.RGBA
+	state graphicsState
+	stack []graphicsState
+	depth int
+	// textClip holds the glyph outlines in device space added to the clipping path at the end of the text
+	// object.
+	textClip *graphics.Path
+}
+
+// newContentRenderer returns a renderer of content streams with resources `resources` onto `dst`, where `base`
+// maps their coordinate system to device space.
+func newContentRenderer(r *Renderer, dst *image.RGBA, resources *model.PdfPageResources,
+	base contentstream.Matrix, state graphicsState, depth int) *contentRenderer {
+	cr := &contentRenderer{r: r, dst: dst, state: state, depth: depth}
+	cr.Interpreter = graphics.NewInterpreter(cr, resources, r.OCProperties, base)
+	return cr
+}
+
+// State returns the parameters of the graphics state set by the operators.  Implements the graphics.Device
+// interface.
+func (cr *contentRenderer) State() *graphics.State {
+	return &cr.state.State
+}
+
+// Save saves the graphics state.  Implements the graphics.Device interface.
+func (cr *contentRenderer) Save() {
+	cr.stack = append(cr.stack, cr.state)
+}
+
+// Restore restores the last saved graphics state.  Implements the graphics.Device interface.
+func (cr *contentRenderer) Restore() {
+	if len(cr.stack) > 0 {
+		cr.state = cr.stack[len(cr.stack)-1]
+		cr.stack = cr.stack[:len(cr.stack)-1]
+	}
+}
+
+// LoadFont returns the font of font dictionary `obj`.  Implements the graphics.Device interface.
+func (cr *contentRenderer) LoadFont(obj core.PdfObject) *fontfile.Font {
+	return cr.r.loadFont(obj)
+}
+
+// SetExtGStateEntry sets the blend mode BM and the soft mask SMask.  Implements the graphics.Device
+// interface.
+func (cr *contentRenderer) SetExtGStateEntry(key core.PdfObjectName, val core.PdfObject,
+	ctm contentstream.Matrix) {
+	switch key {
+	case "BM":
+		cr.state.blendMode = getBlendMode(val)
+	case "SMask":
+		cr.state.softMask = nil
+		if smask, ok := core.TraceToDirectObject(val).(*core.PdfObjectDictionary); ok {
+			cr.state.softMask = cr.softMask(smask, ctm)
+		}
+	}
+}
+
+// bounds returns the region of the canvas that can be painted: within the clipping path.
+func (cr *contentRenderer) bounds() image.Rectangle {
+	if cr.state.clip != nil {
+		return cr.dst.Rect.Intersect(cr.state.clip.Rect)
+	}
+	return cr.dst.Rect
+}
+
+// paint paints `src` within `rect` with coverage `mask` (nil for full coverage) and constant opacity `alpha`,
+// with the clipping path, soft mask and blend mode of the graphics state.
+func (cr *contentRenderer) paint(rect image.Rectangle, mask *image.Alpha, src paintSource, alpha float64) {
+	if cr.IsHidden() {
+		return
+	}
+	c := compositor{dst: cr.dst, mode: cr.state.blendMode}
+	c.composite(rect, mask, cr.state.clip, cr.state.softMask, src, alpha)
+}
+
+// fillSource returns the paint source of the nonstroking color for painting within `rect`, nil if nothing is
+// to be painted.
+func (cr *contentRenderer) fillSource(rect image.Rectangle) paintSource {
+	return cr.colorSource(cr.GS.ColorspaceNonStroking, cr.GS.ColorNonStroking, rect)
+}
+
+// strokeSource returns the paint source of the stroking color for painting within `rect`, nil if nothing is
+// to be painted.
+func (cr *contentRenderer) strokeSource(rect image.Rectangle) paintSource {
+	return cr.colorSource(cr.GS.ColorspaceStroking, cr.GS.ColorStroking, rect)
+}
+
+// colorSource returns the paint source of `color` in colorspace `cs`: a solid color or a pattern.
+func (cr *contentRenderer) colorSource(cs model.PdfColorspace, color model.PdfColor,
+	rect image.Rectangle) paintSource {
+	if cs == nil || color == nil {
+		return solidColor{}
+	}
+	if patternCS, ok := cs.(*model.PdfColorspaceSpecialPattern); ok {
+		pcolor, ok := color.(*model.PdfColorPattern)
+		if !ok {
+			return nil
+		}
+		return cr.patternSource(patternCS, pcolor, rect)
+	}
+	return colorToRGB(cs, color)
+}
+
+// PaintPath fills and strokes path `p`.  Implements the graphics.Device interface.
+func (cr *contentRenderer) PaintPath(p *graphics.Path, ctm contentstream.Matrix, fill bool, rule graphics.FillRule,
+	stroke bool) {
+	if fill {
+		cr.fillPath(p, ctm, rule)
+	}
+	if stroke {
+		cr.strokePath(p, ctm)
+	}
+}
+
+// ClipPath intersects the clipping path with path `p`.  Implements the graphics.Device interface.
+func (cr *contentRenderer) ClipPath(p *graphics.Path, ctm contentstream.Matrix, rule graphics.FillRule) {
+	cr.clipPath(p.Transform(ctm), rule)
+}
+
+// fillPath fills path `p` in user space, transformed by `ctm` to device space, with the nonstroking color.
+func (cr *contentRenderer) fillPath(p *graphics.Path, ctm contentstream.Matrix, rule graphics.FillRule) {
+	if p.IsEmpty() || cr.IsHidden() {
+		return
+	}
+	mask := rasterize(flatten(p.Transform(ctm), flatness), rule, cr.bounds())
+	if mask == nil {
+		return
+	}
+	if src := cr.fillSource(mask.Rect); src != nil {
+		cr.paint(mask.Rect, mask, src, cr.state.FillAlpha)
+	}
+}
+
+// strokePath strokes path `p` in user space, transformed by `ctm` to device space, with the stroking color.
+// Lines are at least one pixel wide.
+func (cr *contentRenderer) strokePath(p *graphics.Path, ctm contentstream.Matrix) {
+	if p.IsEmpty() || cr.IsHidden() {
+		return
+	}
+	mask := cr.strokeMask(p, ctm, cr.bounds())
+	if mask == nil {
+		return
+	}
+	if src := cr.strokeSource(mask.Rect); src != nil {
+		cr.paint(mask.Rect, mask, src, cr.state.StrokeAlpha)
+	}
+}
+
+// strokeMask returns the coverage of the stroke of path `p` within `bounds`, nil if empty.  The stroke is
+// computed in user space, where the line width and dash pattern apply.
+func (cr *contentRenderer) strokeMask(p *graphics.Path, ctm contentstream.Matrix,
+	bounds image.Rectangle) *image.Alpha {
+	scale := ctm.ScalingFactor()
+	if scale == 0 {
+		return nil
+	}
+	style := newStrokeStyle(&cr.state.State)
+	if style.width*scale < 1 {
+		style.width = 1 / scale
+	}
+	tolerance := flatness / scale
+	polygons := strokePolygons(flatten(p, tolerance), style, tolerance)
+	for _, polygon := range polygons {
+		for i, pt := range polygon.points {
+			polygon.points[i] = pt.transform(ctm)
+		}
+	}
+	return rasterize(polygons, graphics.NonZeroWinding, bounds)
+}
+
+// clipPath intersects the clipping path with `p` in device space.
+func (cr *contentRenderer) clipPath(p *graphics.Path, rule graphics.FillRule) {
+	mask := rasterize(flatten(p, flatness), rule, cr.bounds())
+	if mask == nil {
+		mask = image.NewAlpha(image.Rectangle{})
+	}
+	cr.state.clip = intersectMasks(cr.state.clip, mask)
+}
+
+// getBlendMode returns the blend mode of BM entry `obj`: the first supported mode of an array of modes.
+func getBlendMode(obj core.PdfObject) string {
+	obj = core.TraceToDirectObject(obj)
+	names := []core.PdfObject{obj}
+	if arr, ok := obj.(*core.PdfObjectArray); ok {
+		names = *arr
+	}
+	for _, n := range names {
+		name, ok := core.TraceToDirectObject(n).(*core.PdfObjectName)
+		if !ok {
+			continue
+		}
+		mode := string(*name)
+		if mode == "Normal" || mode == "Compatible" {
+			return ""
+		}
+		if _, has := separableBlendModes[mode]; has {
+			return mode
+		}
+		if _, ok := nonSeparableBlend(mode, [3]float64{}, [3]float64{}); ok {
+			return mode
+		}
+	}
+	return ""
+}
+
+// DrawShading paints shading `name` of the resources over the clipping region, as by the sh operator.
+// Implements the graphics.Device interface.
+func (cr *contentRenderer) DrawShading(name core.PdfObjectName, ctm contentstream.Matrix) {
+	if cr.IsHidden() {
+		return
+	}
+	shading, found := cr.Resources.GetShadingByName(name)
+	if !found || shading == nil {
+		common.Log.Debug("Shading %s not found", name)
+		return
+	}
+	rect := cr.bounds()
+	src, err := newShadingSource(shading, ctm, rect, false)
+	if err != nil {
+		common.Log.Debug("Invalid shading %s, skipping: %v", name, err)
+		return
+	}
+	var mask *image.Alpha
+	if shading.BBox != nil {
+		b := shading.BBox
+		p := graphics.NewRectangle(b.Llx, b.Lly, b.Urx, b.Ury).Transform(ctm)
+		mask = rasterize(flatten(p, flatness), graphics.NonZeroWinding, rect)
+		if mask == nil {
+			return
+		}
+	}
+	cr.paint(rect, mask, src, cr.state.FillAlpha)
+}
+
+// DrawXObject draws XObject `name` of the resources, an image or a form.  Implements the graphics.Device
+// interface.
+func (cr *contentRenderer) DrawXObject(name core.PdfObjectName, ctm contentstream.Matrix) {
+	stream, xtype := cr.Resources.GetXObjectByName(name)
+	if stream == nil {
+		common.Log.Debug("XObject %s not found", name)
+		return
+	}
+	if !cr.IsVisible(stream.PdfObjectDictionary.Get("OC")) {
+		return
+	}
+
+	switch xtype {
+	case model.XObjectTypeImage:
+		if cr.IsHidden() {
+			return
+		}
+		img, err := cr.r.loadImage(stream)
+		if err != nil {
+			common.Log.Debug("Invalid image %s, skipping: %v", name, err)
+			return
+		}
+		cr.drawImage(img, ctm)
+	case model.XObjectTypeForm:
+		if cr.IsHidden() {
+			return
+		}
+		cr.drawForm(stream, ctm)
+	default:
+		common.Log.Debug("Unsupported XObject %s, skipping", name)
+	}
+}
+
+// DrawInlineImage draws inline image `inline`.  Implements the graphics.Device interface.
+func (cr *contentRenderer) DrawInlineImage(inline *contentstream.ContentStreamInlineImage,
+	ctm contentstream.Matrix) {
+	if cr.IsHidden() {
+		return
+	}
+	p, err := imageParamsFromInline(inline, cr.Resources)
+	if err == nil {
+		var img *decodedImage
+		img, err = p.decode()
+		if err == nil {
+			cr.drawImage(img, ctm)
+		}
+	}
+	if err != nil {
+		common.Log.Debug("Invalid inline image, skipping: %v", err)
+	}
+}
+
+// drawForm draws form XObject `stream`, in a transparency group if needed.
+func (cr *contentRenderer) drawForm(stream *core.PdfObjectStream, ctm contentstream.Matrix) {
+	if cr.depth >= maxDepth {
+		common.Log.Debug("Forms nested too deeply, skipping")
+		return
+	}
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid form, skipping: %v", err)
+		return
+	}
+	content, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid form stream, skipping: %v", err)
+		return
+	}
+
+	formCTM := graphics.GetMatrix(xform.Matrix).Mult(ctm)
+	state := cr.state
+	if bbox := graphics.GetNumbers(xform.BBox); len(bbox) == 4 {
+		saved := cr.state.clip
+		cr.ClipRectangle(math.Min(bbox[0], bbox[2]), math.Min(bbox[1], bbox[3]),
+			math.Max(bbox[0], bbox[2]), math.Max(bbox[1], bbox[3]), formCTM)
+		state.clip, cr.state.clip = cr.state.clip, saved
+	}
+
+	gs := cr.GS
+	gs.CTM = contentstream.IdentityMatrix()
+
+	isGroup, isolated := false, false
+	if group, ok := core.TraceToDirectObject(xform.Group).(*core.PdfObjectDictionary); ok {
+		if s, ok := core.TraceToDirectObject(group.Get("S")).(*core.PdfObjectName); ok && *s == "Transparency" {
+			isGroup = true
+			if i, ok := core.TraceToDirectObject(group.Get("I")).(*core.PdfObjectBool); ok {
+				isolated = bool(*i)
+			}
+		}
+	}
+
+	if !isGroup || !isolated && cr.state.FillAlpha >= 1 && cr.state.blendMode == "" &&
+		cr.state.softMask == nil {
+		// The form is painted directly, with the graphics state restored afterwards.
+		child := newContentRenderer(cr.r, cr.dst, xform.Resources, formCTM, state, cr.depth+1)
+		if err := child.Process(string(content), gs); err != nil {
+			common.Log.Debug("Error drawing form: %v", err)
+		}
+		return
+	}
+
+	// Transparency groups are painted onto a layer, which is composited as a whole.
+	rect := cr.bounds()
+	if state.clip != nil {
+		rect = rect.Intersect(state.clip.Rect)
+	}
+	if rect.Empty() {
+		return
+	}
+	layer := image.NewRGBA(rect)
+	state.FillAlpha, state.StrokeAlpha = 1, 1
+	state.blendMode = ""
+	state.softMask = nil
+	child := newContentRenderer(cr.r, layer, xform.Resources, formCTM, state, cr.depth+1)
+	if err := child.Process(string(content), gs); err != nil {
+		common.Log.Debug("Error drawing transparency group: %v", err)
+	}
+	cr.paint(rect, nil, layerSource{layer}, cr.state.FillAlpha)
+}
+
+// softMask returns the alpha mask of soft mask dictionary `smask` drawn with matrix `ctm`, nil if invalid.
+func (cr *contentRenderer) softMask(smask *core.PdfObjectDictionary, ctm contentstream.Matrix) *image.Alpha {
+	stream, ok := core.TraceToDirectObject(smask.Get("G")).(*core.PdfObjectStream)
+	if !ok {
+		common.Log.Debug("Invalid soft mask group, skipping")
+		return nil
+	}
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid soft mask group, skipping: %v", err)
+		return nil
+	}
+	content, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid soft mask group, skipping: %v", err)
+		return nil
+	}
+	luminosity := true
+	if s, ok := core.TraceToDirectObject(smask.Get("S")).(*core.PdfObjectName); ok && *s == "Alpha" {
+		luminosity = false
+	}
+
+	// Luminosity masks are drawn over the backdrop color, black by default.
+	layer := image.NewRGBA(cr.dst.Rect)
+	if luminosity {
+		bg := solidColor{}
+		if bc := graphics.GetNumbers(smask.Get("BC")); len(bc) > 0 {
+			cs := model.PdfColorspace(model.NewPdfColorspaceDeviceGray())
+			if group, ok := core.TraceToDirectObject(xform.Group).(*core.PdfObjectDictionary); ok {
+				if obj := group.Get("CS"); obj != nil {
+					if groupCS, err := model.NewPdfColorspaceFromPdfObject(obj); err == nil {
+						cs = groupCS
+					}
+				}
+			}
+			if len(bc) == cs.GetNumComponents() {
+				bg = floatsToRGB(cs, bc)
+			}
+		}
+		compositor{dst: layer}.composite(layer.Rect, nil, nil, nil, bg, 1)
+	}
+
+	formCTM := graphics.GetMatrix(xform.Matrix).Mult(ctm)
+	child := newContentRenderer(cr.r, layer, xform.Resources, formCTM, newGraphicsState(), cr.depth+1)
+	if bbox := graphics.GetNumbers(xform.BBox); len(bbox) == 4 {
+		child.ClipRectangle(math.Min(bbox[0], bbox[2]), math.Min(bbox[1], bbox[3]),
+			math.Max(bbox[0], bbox[2]), math.Max(bbox[1], bbox[3]), formCTM)
+	}
+	if err := child.Process(string(content), graphics.DefaultProcessorState()); err != nil {
+		common.Log.Debug("Error drawing soft mask: %v", err)
+	}
+
+	// The transfer function maps the mask values.
+	transfer := transferTable(smask.Get("TR"))
+
+	mask := image.NewAlpha(layer.Rect)
+	for i := range mask.Pix {
+		pix := layer.Pix[4*i : 4*i+4 : 4*i+4]
+		var v uint8
+		if luminosity {
+			v = clampByte(0.3*float64(pix[0]) + 0.59*float64(pix[1]) + 0.11*float64(pix[2]))
+		} else {
+			v = pix[3]
+		}
+		if transfer != nil {
+			v = transfer[v]
+		}
+		mask.Pix[i] = v
+	}
+	return mask
+}
+
+// transferTable returns the values of transfer function `obj` for the byte values, nil for the identity or an
+// invalid function.
+func transferTable(obj core.PdfObject) []uint8 {
+	if obj == nil || isName(obj) {
+		return nil
+	}
+	fn, err := model.NewPdfFunctionFromPdfObject(obj)
+	if err != nil {
+		common.Log.Debug("Invalid transfer function, skipping: %v", err)
+		return nil
+	}
+	table := make([]uint8, 256)
+	for i := range table {
+		out, err := fn.Evaluate([]float64{float64(i) / 255})
+		if err != nil || len(out) == 0 {
+			common.Log.Debug("Invalid transfer function, skipping: %v", err)
+			return nil
+		}
+		table[i] = clampByte(graphics.Clamp01(out[0]) * 255)
+	}
+	return table
+}
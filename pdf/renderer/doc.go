@@ -0,0 +1,18 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package renderer renders PDF pages to images in pure Go.  Paths, clipping, images with their masks, text in
+// embedded TrueType, Type 1 and CFF fonts or in substitutes of the standard 14 fonts, shadings, patterns, form
+// XObjects and transparency groups with blend modes and soft masks are drawn, followed by the appearances of
+// the annotations of the page.
+//
+// Example:
+//
+//	r, err := renderer.New(page)
+//	if err != nil {
+//		return err
+//	}
+//	img, err := r.Render(150)
+package renderer
@@ -0,0 +1,437 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// decodedImage is an image decoded to non-premultiplied RGBA pixels.  Stencil masks are decoded to the alpha
+// values of their painted pixels only, and painted with the fill color.
+type decodedImage struct {
+	width, height int
+	// pix holds 4 bytes per pixel, or 1 byte per pixel for stencil masks.
+	pix     []uint8
+	stencil bool
+}
+
+// imageParams are the entries of an image dictionary, of an image XObject or an inline image.
+type imageParams struct {
+	width, height int
+	bpc           int
+	cs            model.PdfColorspace
+	isMask        bool
+	decodeArray   []float64
+	data          []byte
+}
+
+// sampleReader reads the samples of image data, each row starting at a byte boundary.
+type sampleReader struct {
+	data     []byte
+	bpc      int
+	rowBytes int
+}
+
+// sample returns sample `i` of row `row`.
+func (r sampleReader) sample(row, i int) uint32 {
+	switch r.bpc {
+	case 8:
+		pos := row*r.rowBytes + i
+		if pos >= len(r.data) {
+			return 0
+		}
+		return uint32(r.data[pos])
+	case 16:
+		pos := row*r.rowBytes + 2*i
+		if pos+1 >= len(r.data) {
+			return 0
+		}
+		return uint32(r.data[pos])<<8 | uint32(r.data[pos+1])
+	}
+	bit := i * r.bpc
+	pos := row*r.rowBytes + bit/8
+	if pos >= len(r.data) {
+		return 0
+	}
+	shift := uint(8 - bit%8 - r.bpc)
+	return uint32(r.data[pos]>>shift) & (1<<uint(r.bpc) - 1)
+}
+
+// decode decodes the samples of image `p` to RGBA pixels, or to alpha values for stencil masks.
+func (p imageParams) decode() (*decodedImage, error) {
+	if p.width <= 0 || p.height <= 0 || p.width*p.height > 1<<28 {
+		return nil, fmt.Errorf("Invalid image size %d x %d", p.width, p.height)
+	}
+	if p.isMask {
+		p.bpc = 1
+	}
+	switch p.bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("Invalid bits per component %d", p.bpc)
+	}
+	n := 1
+	if !p.isMask {
+		if p.cs == nil {
+			return nil, errors.New("Image colorspace missing")
+		}
+		n = p.cs.GetNumComponents()
+	}
+	r := sampleReader{data: p.data, bpc: p.bpc, rowBytes: (p.width*n*p.bpc + 7) / 8}
+	maxVal := float64(uint32(1)<<uint(p.bpc) - 1)
+
+	img := &decodedImage{width: p.width, height: p.height, stencil: p.isMask}
+	if p.isMask {
+		// Samples of 0 are painted, unless the decode array is [1 0].
+		painted := uint32(0)
+		if len(p.decodeArray) == 2 && p.decodeArray[0] > p.decodeArray[1] {
+			painted = 1
+		}
+		img.pix = make([]uint8, p.width*p.height)
+		for y := 0; y < p.height; y++ {
+			for x := 0; x < p.width; x++ {
+				if r.sample(y, x) == painted {
+					img.pix[y*p.width+x] = 255
+				}
+			}
+		}
+		return img, nil
+	}
+
+	// The decode array maps the samples to color component values, default from 0 to 1 or to the maximum index
+	// for Indexed.
+	decode := p.decodeArray
+	if len(decode) != 2*n {
+		decode = p.cs.DecodeArray()
+		if _, isIndexed := p.cs.(*model.PdfColorspaceSpecialIndexed); isIndexed {
+			decode = []float64{0, maxVal}
+		}
+		if len(decode) != 2*n {
+			decode = make([]float64, 2*n)
+			for i := 0; i < n; i++ {
+				decode[2*i+1] = 1
+			}
+		}
+	}
+	// The component values of each sample value by component, for the bit depths with few sample values.
+	var tables [][]float64
+	if p.bpc <= 8 {
+		tables = make([][]float64, n)
+		for k := range tables {
+			tables[k] = make([]float64, int(maxVal)+1)
+			for s := range tables[k] {
+				tables[k][s] = decode[2*k] + float64(s)*(decode[2*k+1]-decode[2*k])/maxVal
+			}
+		}
+	}
+	component := func(y, x, k int) float64 {
+		s := r.sample(y, x*n+k)
+		if tables != nil {
+			return tables[k][s]
+		}
+		return decode[2*k] + float64(s)*(decode[2*k+1]-decode[2*k])/maxVal
+	}
+
+	img.pix = make([]uint8, 4*p.width*p.height)
+	toRGB := rgbConverter(p.cs)
+	vals := make([]float64, n)
+	for y := 0; y < p.height; y++ {
+		for x := 0; x < p.width; x++ {
+			for k := range vals {
+				vals[k] = component(y, x, k)
+			}
+			c := toRGB(vals)
+			i := 4 * (y*p.width + x)
+			img.pix[i] = clampByte(c.r * 255)
+			img.pix[i+1] = clampByte(c.g * 255)
+			img.pix[i+2] = clampByte(c.b * 255)
+			img.pix[i+3] = 255
+		}
+	}
+	return img, nil
+}
+
+// rgbConverter returns a function converting colors of colorspace `cs` to RGB, caching the conversions of
+// colorspaces converted through functions or lookup tables.
+func rgbConverter(cs model.PdfColorspace) func(vals []float64) solidColor {
+	switch cs.(type) {
+	case *model.PdfColorspaceDeviceGray, *model.PdfColorspaceDeviceRGB, *model.PdfColorspaceDeviceCMYK,
+		*model.PdfColorspaceCalGray, *model.PdfColorspaceCalRGB, *model.PdfColorspaceICCBased:
+		return func(vals []float64) solidColor {
+			return floatsToRGB(cs, vals)
+		}
+	}
+	cache := map[string]solidColor{}
+	return func(vals []float64) solidColor {
+		key := fmt.Sprint(vals)
+		if c, has := cache[key]; has {
+			return c
+		}
+		c := floatsToRGB(cs, vals)
+		if len(cache) < 1<<16 {
+			cache[key] = c
+		}
+		return c
+	}
+}
+
+// applyColorKeyMask makes the pixels of `img` transparent whose samples are in the ranges of color key mask
+// `ranges` (the Mask array of the image).
+func applyColorKeyMask(img *decodedImage, p imageParams, ranges []float64) {
+	n := p.cs.GetNumComponents()
+	if len(ranges) != 2*n {
+		common.Log.Debug("Invalid color key mask %v", ranges)
+		return
+	}
+	r := sampleReader{data: p.data, bpc: p.bpc, rowBytes: (p.width*n*p.bpc + 7) / 8}
+	for y := 0; y < p.height; y++ {
+		for x := 0; x < p.width; x++ {
+			masked := true
+			for k := 0; k < n && masked; k++ {
+				s := float64(r.sample(y, x*n+k))
+				masked = s >= ranges[2*k] && s <= ranges[2*k+1]
+			}
+			if masked {
+				img.pix[4*(y*p.width+x)+3] = 0
+			}
+		}
+	}
+}
+
+// applyAlpha multiplies the alpha values of `img` by those of `mask`, which is scaled to the size of the image.
+// The mask is a soft mask decoded as gray image if `fromGray` is set, otherwise a stencil mask.
+func applyAlpha(img, mask *decodedImage, fromGray bool) {
+	for y := 0; y < img.height; y++ {
+		my := y * mask.height / img.height
+		for x := 0; x < img.width; x++ {
+			mx := x * mask.width / img.width
+			var a uint8
+			if fromGray {
+				a = mask.pix[4*(my*mask.width+mx)]
+			} else {
+				a = mask.pix[my*mask.width+mx]
+			}
+			i := 4*(y*img.width+x) + 3
+			img.pix[i] = uint8(int(img.pix[i]) * int(a) / 255)
+		}
+	}
+}
+
+// imageParamsFromXObject returns the parameters and decoded data of image XObject `stream`.
+func imageParamsFromXObject(stream *core.PdfObjectStream) (imageParams, *model.XObjectImage, error) {
+	ximg, err := model.NewXObjectImageFromStream(stream)
+	if err != nil {
+		return imageParams{}, nil, err
+	}
+	data, err := core.DecodeStream(stream)
+	if err != nil {
+		return imageParams{}, nil, err
+	}
+	p := imageParams{
+		width:       int(*ximg.Width),
+		height:      int(*ximg.Height),
+		bpc:         8,
+		cs:          ximg.ColorSpace,
+		decodeArray: graphics.GetNumbers(ximg.Decode),
+		data:        data,
+	}
+	if ximg.BitsPerComponent != nil {
+		p.bpc = int(*ximg.BitsPerComponent)
+	}
+	if b, ok := core.TraceToDirectObject(ximg.ImageMask).(*core.PdfObjectBool); ok && bool(*b) {
+		p.isMask = true
+	}
+	return p, ximg, nil
+}
+
+// loadImage decodes image XObject `stream` with its stencil, color key or soft mask applied.
+func (r *Renderer) loadImage(stream *core.PdfObjectStream) (*decodedImage, error) {
+	if img, has := r.images[stream]; has {
+		return img, nil
+	}
+	img, err := decodeImageXObject(stream)
+	if err != nil {
+		return nil, err
+	}
+	r.images[stream] = img
+	return img, nil
+}
+
+// decodeImageXObject decodes image XObject `stream` with its stencil, color key or soft mask applied.
+func decodeImageXObject(stream *core.PdfObjectStream) (*decodedImage, error) {
+	p, ximg, err := imageParamsFromXObject(stream)
+	if err != nil {
+		return nil, err
+	}
+	img, err := p.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isMask {
+		// Soft masks take precedence over the Mask entry.
+		if smask, ok := core.TraceToDirectObject(ximg.SMask).(*core.PdfObjectStream); ok {
+			mp, _, err := imageParamsFromXObject(smask)
+			if err == nil {
+				mp.cs = model.NewPdfColorspaceDeviceGray()
+				var mimg *decodedImage
+				mimg, err = mp.decode()
+				if err == nil {
+					applyAlpha(img, mimg, true)
+				}
+			}
+			if err != nil {
+				common.Log.Debug("Invalid image soft mask, skipping: %v", err)
+			}
+		} else {
+			switch mask := core.TraceToDirectObject(ximg.Mask).(type) {
+			case *core.PdfObjectStream:
+				mp, _, err := imageParamsFromXObject(mask)
+				if err == nil {
+					mp.isMask = true
+					var mimg *decodedImage
+					mimg, err = mp.decode()
+					if err == nil {
+						applyAlpha(img, mimg, false)
+					}
+				}
+				if err != nil {
+					common.Log.Debug("Invalid image mask, skipping: %v", err)
+				}
+			case *core.PdfObjectArray:
+				applyColorKeyMask(img, p, graphics.GetNumbers(mask))
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// DecodeImage decodes image XObject `stream` with its stencil, color key or soft mask applied, as drawn on
+// pages.  Images are decoded to an *image.NRGBA, stencil masks to an *image.Alpha of their painted pixels.
+func DecodeImage(stream *core.PdfObjectStream) (image.Image, error) {
+	img, err := decodeImageXObject(stream)
+	if err != nil {
+		return nil, err
+	}
+	return img.toImage(), nil
+}
+
+// DecodeInlineImage decodes inline image `inline` of a content stream with resources `resources`, as
+// DecodeImage.
+func DecodeInlineImage(inline *contentstream.ContentStreamInlineImage,
+	resources *model.PdfPageResources) (image.Image, error) {
+	p, err := imageParamsFromInline(inline, resources)
+	if err != nil {
+		return nil, err
+	}
+	img, err := p.decode()
+	if err != nil {
+		return nil, err
+	}
+	return img.toImage(), nil
+}
+
+// toImage returns the pixels of `img` as an *image.NRGBA, or an *image.Alpha for stencil masks.
+func (img *decodedImage) toImage() image.Image {
+	rect := image.Rect(0, 0, img.width, img.height)
+	if img.stencil {
+		return &image.Alpha{Pix: img.pix, Stride: img.width, Rect: rect}
+	}
+	return &image.NRGBA{Pix: img.pix, Stride: 4 * img.width, Rect: rect}
+}
+
+// imageParamsFromInline returns the parameters and decoded data of inline image `inline`.
+func imageParamsFromInline(inline *contentstream.ContentStreamInlineImage,
+	resources *model.PdfPageResources) (imageParams, error) {
+	img, err := inline.ToImage(resources)
+	if err != nil {
+		return imageParams{}, err
+	}
+	p := imageParams{
+		width:       int(img.Width),
+		height:      int(img.Height),
+		bpc:         int(img.BitsPerComponent),
+		decodeArray: graphics.GetNumbers(inline.Decode),
+		data:        img.Data,
+	}
+	p.isMask, err = inline.IsMask()
+	if err != nil {
+		return imageParams{}, err
+	}
+	if !p.isMask {
+		p.cs = model.NewPdfColorspaceDeviceGray()
+		if inline.ColorSpace != nil {
+			p.cs, err = inline.GetColorSpace(resources)
+			if err != nil {
+				return imageParams{}, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// imageSource is the paint source of an image drawn with a matrix mapping the unit square to device space.
+// Stencil masks are painted with `fill`.
+type imageSource struct {
+	img  *decodedImage
+	inv  contentstream.Matrix
+	fill paintSource
+}
+
+func (s imageSource) colorAt(x, y int) (float64, float64, float64, float64) {
+	u, v := s.inv.Transform(float64(x)+0.5, float64(y)+0.5)
+	if u < 0 || u >= 1 || v <= 0 || v > 1 {
+		return 0, 0, 0, 0
+	}
+	// Image rows go from top to bottom of the unit square.
+	col := int(u * float64(s.img.width))
+	row := int((1 - v) * float64(s.img.height))
+	if col >= s.img.width {
+		col = s.img.width - 1
+	}
+	if row >= s.img.height {
+		row = s.img.height - 1
+	}
+	if s.img.stencil {
+		a := s.img.pix[row*s.img.width+col]
+		if a == 0 {
+			return 0, 0, 0, 0
+		}
+		r, g, b, fa := s.fill.colorAt(x, y)
+		return r, g, b, fa * float64(a) / 255
+	}
+	i := 4 * (row*s.img.width + col)
+	p := s.img.pix[i : i+4 : i+4]
+	return float64(p[0]) / 255, float64(p[1]) / 255, float64(p[2]) / 255, float64(p[3]) / 255
+}
+
+// drawImage paints image `img` onto the unit square transformed by `ctm` to device space.
+func (cr *contentRenderer) drawImage(img *decodedImage, ctm contentstream.Matrix) {
+	inv, ok := ctm.Inverse()
+	if !ok {
+		return
+	}
+	llx, lly, urx, ury := ctm.TransformRectangle(0, 0, 1, 1)
+	rect := image.Rect(int(math.Floor(llx)), int(math.Floor(lly)), int(math.Ceil(urx)), int(math.Ceil(ury)))
+	src := imageSource{img: img, inv: inv}
+	if img.stencil {
+		src.fill = cr.fillSource(rect)
+		if src.fill == nil {
+			return
+		}
+	}
+	cr.paint(rect, nil, src, cr.state.FillAlpha)
+}
@@ -0,0 +1,235 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"image"
+	"math"
+)
+
+// paintSource is the source of the colors painted: a solid color, shading, pattern or image.
+type paintSource interface {
+	// colorAt returns the color of device pixel (x, y) as non-premultiplied red, green, blue and alpha values
+	// in the range [0, 1].
+	colorAt(x, y int) (r, g, b, a float64)
+}
+
+// solidColor is a paint source of a single opaque color.
+type solidColor struct {
+	r, g, b float64
+}
+
+func (c solidColor) colorAt(x, y int) (float64, float64, float64, float64) {
+	return c.r, c.g, c.b, 1
+}
+
+// layerSource is a paint source reading the pixels of a premultiplied image, such as a transparency group.
+type layerSource struct {
+	img *image.RGBA
+}
+
+func (s layerSource) colorAt(x, y int) (float64, float64, float64, float64) {
+	if !(image.Point{x, y}.In(s.img.Rect)) {
+		return 0, 0, 0, 0
+	}
+	i := s.img.PixOffset(x, y)
+	a := float64(s.img.Pix[i+3])
+	if a == 0 {
+		return 0, 0, 0, 0
+	}
+	return float64(s.img.Pix[i]) / a, float64(s.img.Pix[i+1]) / a, float64(s.img.Pix[i+2]) / a, a / 255
+}
+
+// blendFunc is a separable blend mode function of backdrop and source color components.
+type blendFunc func(cb, cs float64) float64
+
+// separableBlendModes holds the separable blend modes other than Normal (11.3.5.2 in the PDF reference).
+var separableBlendModes = map[string]blendFunc{
+	"Multiply": func(cb, cs float64) float64 { return cb * cs },
+	"Screen":   func(cb, cs float64) float64 { return cb + cs - cb*cs },
+	"Overlay":  func(cb, cs float64) float64 { return hardLight(cs, cb) },
+	"Darken":   math.Min,
+	"Lighten":  math.Max,
+	"ColorDodge": func(cb, cs float64) float64 {
+		if cb == 0 {
+			return 0
+		}
+		if cs >= 1 {
+			return 1
+		}
+		return math.Min(1, cb/(1-cs))
+	},
+	"ColorBurn": func(cb, cs float64) float64 {
+		if cb >= 1 {
+			return 1
+		}
+		if cs <= 0 {
+			return 0
+		}
+		return 1 - math.Min(1, (1-cb)/cs)
+	},
+	"HardLight": hardLight,
+	"SoftLight": func(cb, cs float64) float64 {
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		}
+		return cb + (2*cs-1)*(d-cb)
+	},
+	"Difference": func(cb, cs float64) float64 { return math.Abs(cb - cs) },
+	"Exclusion":  func(cb, cs float64) float64 { return cb + cs - 2*cb*cs },
+}
+
+func hardLight(cb, cs float64) float64 {
+	if cs <= 0.5 {
+		return cb * 2 * cs
+	}
+	return cb + (2*cs - 1) - cb*(2*cs-1)
+}
+
+// nonSeparableBlend returns the result of non-separable blend mode `mode` of backdrop and source colors, and
+// false if the mode is not a non-separable mode.
+func nonSeparableBlend(mode string, cb, cs [3]float64) ([3]float64, bool) {
+	switch mode {
+	case "Hue":
+		return setLum(setSat(cs, sat(cb)), lum(cb)), true
+	case "Saturation":
+		return setLum(setSat(cb, sat(cs)), lum(cb)), true
+	case "Color":
+		return setLum(cs, lum(cb)), true
+	case "Luminosity":
+		return setLum(cb, lum(cs)), true
+	}
+	return cb, false
+}
+
+func lum(c [3]float64) float64 {
+	return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+}
+
+func setLum(c [3]float64, l float64) [3]float64 {
+	d := l - lum(c)
+	c = [3]float64{c[0] + d, c[1] + d, c[2] + d}
+	l = lum(c)
+	n := math.Min(c[0], math.Min(c[1], c[2]))
+	x := math.Max(c[0], math.Max(c[1], c[2]))
+	for i := range c {
+		if n < 0 && l != n {
+			c[i] = l + (c[i]-l)*l/(l-n)
+		}
+		if x > 1 && x != l {
+			c[i] = l + (c[i]-l)*(1-l)/(x-l)
+		}
+	}
+	return c
+}
+
+func sat(c [3]float64) float64 {
+	return math.Max(c[0], math.Max(c[1], c[2])) - math.Min(c[0], math.Min(c[1], c[2]))
+}
+
+func setSat(c [3]float64, s float64) [3]float64 {
+	// Sort the component indices by value.
+	imin, imid, imax := 0, 1, 2
+	if c[imin] > c[imid] {
+		imin, imid = imid, imin
+	}
+	if c[imid] > c[imax] {
+		imid, imax = imax, imid
+	}
+	if c[imin] > c[imid] {
+		imin, imid = imid, imin
+	}
+	out := [3]float64{}
+	if c[imax] > c[imin] {
+		out[imid] = (c[imid] - c[imin]) * s / (c[imax] - c[imin])
+		out[imax] = s
+	}
+	return out
+}
+
+// compositor composites a paint source onto a canvas.
+type compositor struct {
+	dst *image.RGBA
+	// mode is the blend mode, "" or "Normal" for simply painting over.
+	mode string
+}
+
+// composite paints `src` onto the canvas within `rect`, with coverage `mask` (nil for full coverage), clip
+// `clip` and soft mask `smask` (nil for none) and constant opacity `alpha`.
+func (c compositor) composite(rect image.Rectangle, mask, clip, smask *image.Alpha, src paintSource, alpha float64) {
+	rect = rect.Intersect(c.dst.Rect)
+	for _, m := range []*image.Alpha{mask, clip, smask} {
+		if m != nil {
+			rect = rect.Intersect(m.Rect)
+		}
+	}
+	if rect.Empty() || alpha <= 0 {
+		return
+	}
+	separable := separableBlendModes[c.mode]
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			cov := alpha
+			if mask != nil {
+				cov *= float64(mask.Pix[mask.PixOffset(x, y)]) / 255
+			}
+			if clip != nil {
+				cov *= float64(clip.Pix[clip.PixOffset(x, y)]) / 255
+			}
+			if smask != nil {
+				cov *= float64(smask.Pix[smask.PixOffset(x, y)]) / 255
+			}
+			if cov <= 0 {
+				continue
+			}
+			r, g, b, a := src.colorAt(x, y)
+			as := cov * a
+			if as <= 0 {
+				continue
+			}
+
+			i := c.dst.PixOffset(x, y)
+			pix := c.dst.Pix[i : i+4 : i+4]
+			ab := float64(pix[3]) / 255
+			cs := [3]float64{r, g, b}
+			if ab > 0 && c.mode != "" && c.mode != "Normal" {
+				// The blended color replaces the source color where the backdrop is opaque.
+				cb := [3]float64{float64(pix[0]) / 255 / ab, float64(pix[1]) / 255 / ab, float64(pix[2]) / 255 / ab}
+				var blended [3]float64
+				if separable != nil {
+					for k := range blended {
+						blended[k] = separable(math.Min(cb[k], 1), cs[k])
+					}
+				} else {
+					blended, _ = nonSeparableBlend(c.mode, cb, cs)
+				}
+				for k := range cs {
+					cs[k] = (1-ab)*cs[k] + ab*blended[k]
+				}
+			}
+			for k := 0; k < 3; k++ {
+				pix[k] = clampByte(cs[k]*as*255 + float64(pix[k])*(1-as))
+			}
+			pix[3] = clampByte(as*255 + float64(pix[3])*(1-as))
+		}
+	}
+}
+
+// clampByte returns `v` rounded and clamped to the range of a byte.
+func clampByte(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
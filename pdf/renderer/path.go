@@ -0,0 +1,78 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+)
+
+// point is a point in user or device space.
+type point struct {
+	x, y float64
+}
+
+// transform returns the point transformed by `m`.
+func (p point) transform(m contentstream.Matrix) point {
+	x, y := m.Transform(p.x, p.y)
+	return point{x, y}
+}
+
+// polyline is a flattened subpath.
+type polyline struct {
+	points []point
+	closed bool
+}
+
+// flatten returns the subpaths of path `p` as polylines, with the curves approximated by line segments
+// deviating at most about `tolerance` from them.
+func flatten(p *graphics.Path, tolerance float64) []polyline {
+	lines := make([]polyline, 0, len(p.Subpaths))
+	for _, sp := range p.Subpaths {
+		start := point{sp.Start.X, sp.Start.Y}
+		points := []point{start}
+		prev := start
+		for _, seg := range sp.Segments {
+			end := point{seg.End.X, seg.End.Y}
+			if seg.Curve {
+				points = flattenCubic(points, prev, point{seg.C1.X, seg.C1.Y}, point{seg.C2.X, seg.C2.Y}, end,
+					tolerance)
+			} else {
+				points = append(points, end)
+			}
+			prev = end
+		}
+		lines = append(lines, polyline{points: points, closed: sp.Closed})
+	}
+	return lines
+}
+
+// flattenCubic appends the points approximating the cubic Bézier curve p0 p1 p2 p3 to `points`, excluding p0.
+// The number of line segments is chosen from the second differences of the control points, which bound the
+// deviation of the segments from the curve.
+func flattenCubic(points []point, p0, p1, p2, p3 point, tolerance float64) []point {
+	ddx := math.Max(math.Abs(p0.x-2*p1.x+p2.x), math.Abs(p1.x-2*p2.x+p3.x))
+	ddy := math.Max(math.Abs(p0.y-2*p1.y+p2.y), math.Abs(p1.y-2*p2.y+p3.y))
+	dd := math.Hypot(ddx, ddy)
+	n := int(math.Ceil(math.Sqrt(0.75 * dd / tolerance)))
+	if n < 1 {
+		n = 1
+	} else if n > 200 {
+		n = 200
+	}
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+		points = append(points, point{
+			a*p0.x + b*p1.x + c*p2.x + d*p3.x,
+			a*p0.y + b*p1.y + c*p2.y + d*p3.y,
+		})
+	}
+	return points
+}
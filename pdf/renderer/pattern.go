@@ -0,0 +1,181 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"image"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// maxCellPixels is the maximum number of pixels of the image of a tiling pattern cell.
+const maxCellPixels = 1 << 22
+
+// tilingKey identifies a tiling pattern, by its stream, drawn with a matrix from pattern space to device space.
+type tilingKey struct {
+	pattern core.PdfObject
+	matrix  contentstream.Matrix
+}
+
+// tilingCell is a pattern cell drawn to an image, covering one period of the tiling.
+type tilingCell struct {
+	img *image.RGBA
+	// inv maps device space to the pixel coordinates of the cell image, in which the periods of the tiling are
+	// xstep and ystep.
+	inv          contentstream.Matrix
+	xstep, ystep float64
+}
+
+// tilingSource is the paint source of a tiling pattern.  Uncolored patterns are painted with `color`, with the
+// alpha values of the cell.
+type tilingSource struct {
+	cell  *tilingCell
+	color *solidColor
+}
+
+func (s tilingSource) colorAt(x, y int) (float64, float64, float64, float64) {
+	u, v := s.cell.inv.Transform(float64(x)+0.5, float64(y)+0.5)
+	u -= math.Floor(u/s.cell.xstep) * s.cell.xstep
+	v -= math.Floor(v/s.cell.ystep) * s.cell.ystep
+	r, g, b, a := layerSource{s.cell.img}.colorAt(int(u), int(v))
+	if s.color != nil {
+		return s.color.r, s.color.g, s.color.b, a
+	}
+	return r, g, b, a
+}
+
+// patternSource returns the paint source of pattern color `color` for painting within `rect`, nil if the
+// pattern is invalid.
+func (cr *contentRenderer) patternSource(cs *model.PdfColorspaceSpecialPattern, color *model.PdfColorPattern,
+	rect image.Rectangle) paintSource {
+	pattern, found := cr.Resources.GetPatternByName(color.PatternName)
+	if !found || pattern == nil {
+		common.Log.Debug("Pattern %s not found", color.PatternName)
+		return nil
+	}
+	// The pattern matrix maps pattern space to the default coordinate space of the content stream.
+	var matrix contentstream.Matrix
+
+	switch {
+	case pattern.IsShading():
+		sp := pattern.GetAsShadingPattern()
+		if sp.Matrix != nil {
+			matrix = graphics.GetMatrix(sp.Matrix)
+		} else {
+			matrix = contentstream.IdentityMatrix()
+		}
+		if sp.Shading == nil {
+			return nil
+		}
+		src, err := newShadingSource(sp.Shading, matrix.Mult(cr.Base), rect, true)
+		if err != nil {
+			common.Log.Debug("Invalid shading pattern %s, skipping: %v", color.PatternName, err)
+			return nil
+		}
+		return src
+	case pattern.IsTiling():
+		tp := pattern.GetAsTilingPattern()
+		if tp.Matrix != nil {
+			matrix = graphics.GetMatrix(tp.Matrix)
+		} else {
+			matrix = contentstream.IdentityMatrix()
+		}
+		cell := cr.tilingCell(tp, matrix.Mult(cr.Base))
+		if cell == nil {
+			return nil
+		}
+		src := tilingSource{cell: cell}
+		if !tp.IsColored() {
+			if cs.UnderlyingCS == nil || color.Color == nil {
+				common.Log.Debug("Uncolored pattern without color, skipping")
+				return nil
+			}
+			c := colorToRGB(cs.UnderlyingCS, color.Color)
+			src.color = &c
+		}
+		return src
+	}
+	return nil
+}
+
+// tilingCell returns the cell of tiling pattern `tp` drawn with matrix `m` from pattern space to device space,
+// nil if invalid.
+func (cr *contentRenderer) tilingCell(tp *model.PdfTilingPattern, m contentstream.Matrix) *tilingCell {
+	key := tilingKey{tp.GetContainingPdfObject(), m}
+	if cell, has := cr.r.tilings[key]; has {
+		return cell
+	}
+	cell := cr.drawTilingCell(tp, m)
+	cr.r.tilings[key] = cell
+	return cell
+}
+
+// drawTilingCell draws the cell of tiling pattern `tp` with matrix `m` to an image.  The cell is drawn at the
+// resolution of device space, with the neighboring cells overlapping it.
+func (cr *contentRenderer) drawTilingCell(tp *model.PdfTilingPattern, m contentstream.Matrix) *tilingCell {
+	if cr.depth >= maxDepth {
+		common.Log.Debug("Patterns nested too deeply, skipping")
+		return nil
+	}
+	if tp.BBox == nil || tp.XStep == nil || tp.YStep == nil {
+		common.Log.Debug("Invalid tiling pattern, skipping")
+		return nil
+	}
+	xstep, ystep := math.Abs(float64(*tp.XStep)), math.Abs(float64(*tp.YStep))
+	if xstep == 0 || ystep == 0 {
+		common.Log.Debug("Invalid tiling pattern steps, skipping")
+		return nil
+	}
+	content, err := tp.GetContentStream()
+	if err != nil {
+		common.Log.Debug("Invalid tiling pattern stream, skipping: %v", err)
+		return nil
+	}
+	inv, ok := m.Inverse()
+	if !ok {
+		return nil
+	}
+
+	// The cell image has the resolution of device space along the pattern space axes.
+	sx, sy := math.Hypot(m[0], m[1]), math.Hypot(m[2], m[3])
+	w, h := xstep*sx, ystep*sy
+	if w*h > maxCellPixels {
+		f := math.Sqrt(maxCellPixels / (w * h))
+		sx, sy, w, h = sx*f, sy*f, w*f, h*f
+	}
+	if w < 1 {
+		sx, w = sx/w, 1
+	}
+	if h < 1 {
+		sy, h = sy/h, 1
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(w)), int(math.Ceil(h))))
+
+	// Cell pixel coordinates start at the lower left corner of the bounding box, with y pointing down.
+	bbox := tp.BBox
+	cellMatrix := contentstream.TranslationMatrix(-bbox.Llx, -bbox.Lly).
+		Mult(contentstream.NewMatrix(sx, 0, 0, -sy, 0, h))
+
+	gs := graphics.DefaultProcessorState()
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			base := contentstream.TranslationMatrix(float64(dx)*xstep, float64(dy)*ystep).Mult(cellMatrix)
+			child := newContentRenderer(cr.r, img, tp.Resources, base, newGraphicsState(), cr.depth+1)
+			child.ClipRectangle(bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury, base)
+			if err := child.Process(string(content), gs); err != nil {
+				common.Log.Debug("Error drawing tiling pattern: %v", err)
+				return nil
+			}
+		}
+	}
+
+	return &tilingCell{img: img, inv: inv.Mult(cellMatrix), xstep: w, ystep: h}
+}
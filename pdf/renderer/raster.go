@@ -0,0 +1,220 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+)
+
+// inside checks whether winding number `winding` is inside by fill rule `rule`.
+func inside(rule graphics.FillRule, winding int) bool {
+	if rule == graphics.EvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+// subScanlines is the number of samples per pixel row.  Coverage is exact horizontally and sampled vertically.
+const subScanlines = 5
+
+// edge is a non-horizontal polygon edge with y0 < y1.  `dir` is +1 for edges going up in device space and -1
+// for edges going down.
+type edge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+// crossing is the intersection of an edge with a sample row.
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// rasterize returns the anti-aliased coverage of the polygons `polygons` (in device space, implicitly closed)
+// filled with `rule`, restricted to `bounds`.  Returns nil if nothing is covered.
+func rasterize(polygons []polyline, rule graphics.FillRule, bounds image.Rectangle) *image.Alpha {
+	edges := []edge{}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, poly := range polygons {
+		n := len(poly.points)
+		if n < 2 {
+			continue
+		}
+		for i, p := range poly.points {
+			q := poly.points[(i+1)%n]
+			if math.IsNaN(p.x) || math.IsNaN(p.y) || math.IsInf(p.x, 0) || math.IsInf(p.y, 0) {
+				return nil
+			}
+			minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+			minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+			switch {
+			case p.y < q.y:
+				edges = append(edges, edge{p.x, p.y, q.x, q.y, 1})
+			case p.y > q.y:
+				edges = append(edges, edge{q.x, q.y, p.x, p.y, -1})
+			}
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+	rect := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1,
+		int(math.Ceil(maxY))+1).Intersect(bounds)
+	if rect.Empty() {
+		return nil
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	mask := image.NewAlpha(rect)
+	width := rect.Dx()
+	acc := make([]float32, width)
+	active := []int{}
+	crossings := []crossing{}
+	next := 0
+	covered := false
+	for py := rect.Min.Y; py < rect.Max.Y; py++ {
+		for i := range acc {
+			acc[i] = 0
+		}
+		rowCovered := false
+		for s := 0; s < subScanlines; s++ {
+			y := float64(py) + (float64(s)+0.5)/subScanlines
+			for next < len(edges) && edges[next].y0 <= y {
+				active = append(active, next)
+				next++
+			}
+
+			crossings = crossings[:0]
+			k := 0
+			for _, i := range active {
+				e := &edges[i]
+				if e.y1 <= y {
+					continue
+				}
+				active[k] = i
+				k++
+				x := e.x0 + (y-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+				crossings = append(crossings, crossing{x, e.dir})
+			}
+			active = active[:k]
+			if len(crossings) == 0 {
+				continue
+			}
+			// Insertion sort, the crossings are nearly sorted from the previous row in most cases.
+			for i := 1; i < len(crossings); i++ {
+				for j := i; j > 0 && crossings[j].x < crossings[j-1].x; j-- {
+					crossings[j], crossings[j-1] = crossings[j-1], crossings[j]
+				}
+			}
+
+			winding := 0
+			start := 0.0
+			for _, c := range crossings {
+				wasInside := inside(rule, winding)
+				winding += c.dir
+				isInside := inside(rule, winding)
+				if !wasInside && isInside {
+					start = c.x
+				} else if wasInside && !isInside {
+					if addSpan(acc, start-float64(rect.Min.X), c.x-float64(rect.Min.X)) {
+						rowCovered = true
+					}
+				}
+			}
+		}
+		if !rowCovered {
+			continue
+		}
+		covered = true
+		row := mask.Pix[(py-rect.Min.Y)*mask.Stride:]
+		for x, v := range acc {
+			if v > 0 {
+				c := v * 255 / subScanlines
+				if c > 255 {
+					c = 255
+				}
+				row[x] = uint8(c + 0.5)
+			}
+		}
+	}
+	if !covered {
+		return nil
+	}
+	return mask
+}
+
+// addSpan adds the coverage of the span from `x0` to `x1` of a sample row to `acc`, with exact coverage of the
+// partially covered pixels at the ends.  Returns false if the span is outside `acc`.
+func addSpan(acc []float32, x0, x1 float64) bool {
+	x0 = math.Max(x0, 0)
+	x1 = math.Min(x1, float64(len(acc)))
+	if x1 <= x0 {
+		return false
+	}
+	i0, i1 := int(x0), int(x1)
+	if i0 == i1 {
+		acc[i0] += float32(x1 - x0)
+		return true
+	}
+	acc[i0] += float32(float64(i0+1) - x0)
+	for i := i0 + 1; i < i1; i++ {
+		acc[i]++
+	}
+	if i1 < len(acc) {
+		acc[i1] += float32(x1 - float64(i1))
+	}
+	return true
+}
+
+// intersectMasks returns the intersection of coverage masks `a` and `b`, nil meaning full coverage.  Returns
+// an empty mask if they do not overlap.
+func intersectMasks(a, b *image.Alpha) *image.Alpha {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	rect := a.Rect.Intersect(b.Rect)
+	mask := image.NewAlpha(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		ra := a.Pix[a.PixOffset(rect.Min.X, y):]
+		rb := b.Pix[b.PixOffset(rect.Min.X, y):]
+		out := mask.Pix[mask.PixOffset(rect.Min.X, y):]
+		for x := 0; x < rect.Dx(); x++ {
+			out[x] = uint8((int(ra[x])*int(rb[x]) + 127) / 255)
+		}
+	}
+	return mask
+}
+
+// unionMasks returns the union of coverage masks `a` and `b`.  Nil masks are empty.
+func unionMasks(a, b *image.Alpha) *image.Alpha {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	rect := a.Rect.Union(b.Rect)
+	mask := image.NewAlpha(rect)
+	for _, m := range []*image.Alpha{a, b} {
+		for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+			in := m.Pix[m.PixOffset(m.Rect.Min.X, y):]
+			out := mask.Pix[mask.PixOffset(m.Rect.Min.X, y):]
+			for x := 0; x < m.Rect.Dx(); x++ {
+				// Coverages combine as for painting one shape over the other.
+				out[x] = uint8(int(out[x]) + (255-int(out[x]))*int(in[x])/255)
+			}
+		}
+	}
+	return mask
+}
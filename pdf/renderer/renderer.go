@@ -0,0 +1,136 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Renderer renders a PDF page to images.
+type Renderer struct {
+	// Background is the color of the page, white by default.  Pages are rendered with a transparent background
+	// if nil.
+	Background color.Color
+	// SkipAnnotations disables drawing the appearances of the annotations of the page.
+	SkipAnnotations bool
+	// OCProperties are the optional content properties of the document, content of optional content groups
+	// which are OFF in their default configuration is not drawn.  All content is drawn if nil.
+	OCProperties *model.PdfOCProperties
+
+	page     *model.PdfPage
+	contents string
+
+	// Caches of the fonts, images and tiling pattern cells, kept across renderings.
+	fonts        map[core.PdfObject]*fontfile.Font
+	missingFonts map[*fontfile.Font]bool
+	images       map[*core.PdfObjectStream]*decodedImage
+	tilings      map[tilingKey]*tilingCell
+}
+
+// New returns a Renderer for rendering PDF page `page`.
+func New(page *model.PdfPage) (*Renderer, error) {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	return &Renderer{
+		Background:   color.White,
+		page:         page,
+		contents:     contents,
+		fonts:        map[core.PdfObject]*fontfile.Font{},
+		missingFonts: map[*fontfile.Font]bool{},
+		images:       map[*core.PdfObjectStream]*decodedImage{},
+		tilings:      map[tilingKey]*tilingCell{},
+	}, nil
+}
+
+// RegisterFont registers font program `data` (TrueType, OpenType, CFF or Type 1) for drawing the text of
+// non-embedded fonts named `name`, such as "Helvetica" or "Arial,Bold".  Fonts of the same family and aliases
+// of the standard 14 fonts use the program of their base name when none of their own is registered.  The
+// program registered with an empty name is used for all other non-embedded fonts.
+func RegisterFont(name string, data []byte) error {
+	return fontfile.RegisterSubstitute(name, data)
+}
+
+// Render renders the page at a resolution of `dpi` pixels per inch.  The image covers the crop box of the
+// page, rotated as displayed.  Invalid content is skipped over, so that what can be drawn is drawn.
+func (r *Renderer) Render(dpi float64) (*image.RGBA, error) {
+	if dpi <= 0 {
+		return nil, errors.New("Resolution must be positive")
+	}
+	area, err := r.page.GetVisibleArea()
+	if err != nil {
+		return nil, err
+	}
+	display, err := r.page.GetDisplayMatrix()
+	if err != nil {
+		return nil, err
+	}
+
+	scale := dpi / 72
+	width := int(math.Ceil(area.Width()*scale - 1e-6))
+	height := int(math.Ceil(area.Height()*scale - 1e-6))
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("Empty page")
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	if r.Background != nil {
+		draw.Draw(canvas, canvas.Rect, image.NewUniform(r.Background), image.ZP, draw.Src)
+	}
+
+	// Device space has its origin at the top left corner of the page as displayed, with y pointing down.
+	unitScale := scale * r.page.GetUserUnit()
+	device := contentstream.NewMatrix(display[0], display[1], display[2], display[3], display[4], display[5]).
+		Mult(contentstream.NewMatrix(unitScale, 0, 0, -unitScale, 0, float64(height)))
+
+	cr := newContentRenderer(r, canvas, r.page.Resources, device, newGraphicsState(), 0)
+	if err := cr.Process(r.contents, graphics.DefaultProcessorState()); err != nil {
+		common.Log.Debug("Error rendering page contents: %v", err)
+	}
+
+	if !r.SkipAnnotations {
+		for _, annot := range r.page.Annotations {
+			r.drawAnnotation(canvas, annot, device)
+		}
+	}
+	return canvas, nil
+}
+
+// loadFont returns the font of font dictionary `obj`.
+func (r *Renderer) loadFont(obj core.PdfObject) *fontfile.Font {
+	if font, has := r.fonts[obj]; has {
+		return font
+	}
+	font := fontfile.Load(obj)
+	r.fonts[obj] = font
+	return font
+}
+
+// drawAnnotation draws the normal appearance of annotation `annot`, if it is displayed.
+func (r *Renderer) drawAnnotation(canvas *image.RGBA, annot *model.PdfAnnotation, device contentstream.Matrix) {
+	stream, fit, ok := graphics.GetAppearance(annot)
+	if !ok {
+		return
+	}
+
+	cr := newContentRenderer(r, canvas, r.page.Resources, device, newGraphicsState(), 0)
+	if !cr.IsVisible(annot.OC) {
+		return
+	}
+	cr.GS = graphics.DefaultProcessorState()
+	cr.drawForm(stream, fit.Mult(device))
+}
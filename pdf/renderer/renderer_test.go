@@ -0,0 +1,259 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"image"
+	"io/ioutil"
+	"testing"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+func init() {
+	common.SetLogger(common.NewConsoleLogger(common.LogLevelDebug))
+}
+
+// renderContent renders a 100x100 page drawn by `content` with resources `res`, if any, at 72 dpi, so that
+// pixels have the size of user space units.
+func renderContent(t *testing.T, content string, res *model.PdfPageResources) *image.RGBA {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = res
+	if res == nil {
+		page.Resources = model.NewPdfPageResources()
+	}
+	page.AddContentStreamByString(content)
+
+	r, err := New(page)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	img, err := r.Render(72)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return img
+}
+
+// checkPixel checks that the pixel at user space point (`x`, `y`) of a page rendered at 72 dpi has color
+// `want`, with a tolerance of 2 per component.
+func checkPixel(t *testing.T, img *image.RGBA, x, y int, want [3]uint8) {
+	c := img.RGBAAt(x, img.Rect.Dy()-1-y)
+	got := [3]uint8{c.R, c.G, c.B}
+	for i := range got {
+		d := int(got[i]) - int(want[i])
+		if d < -2 || d > 2 {
+			t.Errorf("Pixel (%d, %d): got %v, expected %v", x, y, got, want)
+			return
+		}
+	}
+}
+
+var (
+	white = [3]uint8{255, 255, 255}
+	black = [3]uint8{0, 0, 0}
+	red   = [3]uint8{255, 0, 0}
+	blue  = [3]uint8{0, 0, 255}
+)
+
+func TestRenderFill(t *testing.T) {
+	img := renderContent(t, `
+1 0 0 rg 10 10 30 30 re f
+0 0 1 rg 50 10 m 90 10 l 90 50 l 50 50 l h 60 20 m 80 20 l 80 40 l 60 40 l h f*
+0 g 50 60 m 90 60 l 90 90 l 50 90 l h 60 70 m 80 70 l 80 80 l 60 80 l h f`, nil)
+
+	checkPixel(t, img, 25, 25, red)
+	checkPixel(t, img, 5, 5, white)
+	checkPixel(t, img, 45, 25, white)
+	// Even-odd rule leaves the inner square unfilled, nonzero winding fills it.
+	checkPixel(t, img, 55, 15, blue)
+	checkPixel(t, img, 70, 30, white)
+	checkPixel(t, img, 70, 75, black)
+}
+
+func TestRenderStroke(t *testing.T) {
+	img := renderContent(t, `
+1 0 0 RG 10 w 0 J 10 50 m 90 50 l S
+0 0 1 RG 4 w [10 10] 0 d 10 20 m 90 20 l S`, nil)
+
+	checkPixel(t, img, 50, 53, red)
+	checkPixel(t, img, 50, 57, white)
+	// Butt caps end the line at its end points.
+	checkPixel(t, img, 7, 50, white)
+	// Dashes alternate with gaps of the same length.
+	checkPixel(t, img, 15, 20, blue)
+	checkPixel(t, img, 25, 20, white)
+	checkPixel(t, img, 35, 20, blue)
+}
+
+func TestRenderClip(t *testing.T) {
+	img := renderContent(t, `
+q 20 20 40 40 re W n 1 0 0 rg 0 0 100 100 re f Q
+0 0 1 rg 70 70 20 20 re f`, nil)
+
+	checkPixel(t, img, 40, 40, red)
+	checkPixel(t, img, 10, 10, white)
+	checkPixel(t, img, 65, 40, white)
+	// The clipping path is restored with the graphics state.
+	checkPixel(t, img, 80, 80, blue)
+}
+
+func TestRenderTransparency(t *testing.T) {
+	res := model.NewPdfPageResources()
+	gs := core.MakeDict()
+	gs.Set("ca", core.MakeFloat(0.5))
+	res.AddExtGState("GS1", gs)
+	multiply := core.MakeDict()
+	multiply.Set("BM", core.MakeName("Multiply"))
+	res.AddExtGState("GS2", multiply)
+
+	img := renderContent(t, `
+q /GS1 gs 1 0 0 rg 10 10 30 30 re f Q
+q 1 1 0 rg 50 10 40 40 re f /GS2 gs 0 1 1 rg 50 10 20 40 re f Q`, res)
+
+	checkPixel(t, img, 25, 25, [3]uint8{255, 128, 128})
+	// Yellow multiplied by cyan is green.
+	checkPixel(t, img, 60, 30, [3]uint8{0, 255, 0})
+	checkPixel(t, img, 80, 30, [3]uint8{255, 255, 0})
+}
+
+func TestRenderShading(t *testing.T) {
+	fn := core.MakeDict()
+	fn.Set("FunctionType", core.MakeInteger(2))
+	fn.Set("Domain", core.MakeArrayFromFloats([]float64{0, 1}))
+	fn.Set("C0", core.MakeArrayFromFloats([]float64{1, 0, 0}))
+	fn.Set("C1", core.MakeArrayFromFloats([]float64{0, 0, 1}))
+	fn.Set("N", core.MakeFloat(1))
+	sh := core.MakeDict()
+	sh.Set("ShadingType", core.MakeInteger(2))
+	sh.Set("ColorSpace", core.MakeName("DeviceRGB"))
+	sh.Set("Coords", core.MakeArrayFromFloats([]float64{0, 0, 100, 0}))
+	sh.Set("Function", fn)
+	res := model.NewPdfPageResources()
+	res.SetShadingByName("Sh1", sh)
+
+	img := renderContent(t, `q 0 0 100 50 re W n /Sh1 sh Q`, res)
+
+	checkPixel(t, img, 0, 25, red)
+	checkPixel(t, img, 50, 25, [3]uint8{128, 0, 128})
+	checkPixel(t, img, 99, 25, blue)
+	checkPixel(t, img, 50, 75, white)
+}
+
+func TestRenderInlineImage(t *testing.T) {
+	// A 2x1 RGB image of a red and a blue pixel, scaled to 80x40.
+	img := renderContent(t, `
+q 80 0 0 40 10 30 cm
+BI /W 2 /H 1 /CS /RGB /BPC 8 /F /AHx ID ff00000000ff> EI
+Q`, nil)
+
+	checkPixel(t, img, 30, 50, red)
+	checkPixel(t, img, 70, 50, blue)
+	checkPixel(t, img, 50, 80, white)
+}
+
+func TestRenderText(t *testing.T) {
+	data, err := ioutil.ReadFile("../../testfiles/roboto/Roboto-Regular.ttf")
+	if err != nil {
+		t.Skipf("Font not available: %v", err)
+	}
+	if err := RegisterFont("Helvetica", data); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	res := model.NewPdfPageResources()
+	res.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+
+	// The vertical bar of a large I covers the middle of its advance.
+	img := renderContent(t, `BT /F1 80 Tf 30 10 Td (I) Tj ET`, res)
+
+	dark := 0
+	for x := 30; x < 60; x++ {
+		if c := img.RGBAAt(x, 100-1-40); c.R < 64 {
+			dark++
+		}
+	}
+	if dark == 0 {
+		t.Errorf("Text not drawn")
+	}
+	checkPixel(t, img, 5, 95, white)
+}
+
+func TestRenderResolution(t *testing.T) {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 200}
+	page.Resources = model.NewPdfPageResources()
+	page.AddContentStreamByString(`1 0 0 rg 0 0 50 100 re f`)
+	rotate := int64(90)
+	page.Rotate = &rotate
+
+	r, err := New(page)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	img, err := r.Render(144)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// The rotated page is displayed in landscape.
+	if w, h := img.Rect.Dx(), img.Rect.Dy(); w != 400 || h != 200 {
+		t.Fatalf("Image size %dx%d, expected 400x200", w, h)
+	}
+	// The lower left quarter of the page is displayed at the upper left after rotating clockwise.
+	if c := img.RGBAAt(50, 50); c.R != 255 || c.G != 0 {
+		t.Errorf("Pixel (50, 50): got %v, expected red", c)
+	}
+	if c := img.RGBAAt(350, 150); c.G != 255 {
+		t.Errorf("Pixel (350, 150): got %v, expected white", c)
+	}
+
+	if _, err := r.Render(0); err == nil {
+		t.Errorf("Expected error for zero resolution")
+	}
+}
+
+func TestRenderAnnotation(t *testing.T) {
+	appearance, err := core.MakeStream([]byte("1 0 0 rg 0 0 10 10 re f"), nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	appearance.PdfObjectDictionary.Set("Type", core.MakeName("XObject"))
+	appearance.PdfObjectDictionary.Set("Subtype", core.MakeName("Form"))
+	appearance.PdfObjectDictionary.Set("BBox", core.MakeArrayFromFloats([]float64{0, 0, 10, 10}))
+	ap := core.MakeDict()
+	ap.Set("N", appearance)
+
+	square := model.NewPdfAnnotationSquare()
+	square.Rect = core.MakeArrayFromFloats([]float64{50, 50, 90, 90})
+	square.AP = ap
+
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = model.NewPdfPageResources()
+	page.Annotations = append(page.Annotations, square.PdfAnnotation)
+
+	r, err := New(page)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	img, err := r.Render(72)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	// The appearance is scaled to the annotation rectangle.
+	checkPixel(t, img, 70, 70, red)
+	checkPixel(t, img, 40, 40, white)
+
+	r.SkipAnnotations = true
+	img, err = r.Render(72)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	checkPixel(t, img, 70, 70, white)
+}
@@ -0,0 +1,652 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// shadingColors evaluates the colors of a shading from its parametric variables or color components.
+type shadingColors struct {
+	cs        model.PdfColorspace
+	functions []model.PdfFunction
+}
+
+// color returns the color of the shading for input values `t`: the parametric variables if the shading has
+// functions, otherwise the color components.  Returns false if the functions cannot be evaluated.
+func (sc shadingColors) color(t []float64) (solidColor, bool) {
+	if len(sc.functions) == 0 {
+		return floatsToRGB(sc.cs, t), true
+	}
+	vals := []float64{}
+	for _, f := range sc.functions {
+		out, err := f.Evaluate(t)
+		if err != nil {
+			common.Log.Debug("Failed evaluating shading function: %v", err)
+			return solidColor{}, false
+		}
+		vals = append(vals, out...)
+	}
+	return floatsToRGB(sc.cs, vals), true
+}
+
+// lookupTable returns the colors of 1-input shading functions at `n` evenly spaced values from `t0` to `t1`.
+func (sc shadingColors) lookupTable(t0, t1 float64, n int) []solidColor {
+	lut := make([]solidColor, n)
+	for i := range lut {
+		t := t0 + (t1-t0)*float64(i)/float64(n-1)
+		lut[i], _ = sc.color([]float64{t})
+	}
+	return lut
+}
+
+// lookupTableSize is the number of entries of the color lookup tables of axial and radial shadings.
+const lookupTableSize = 1024
+
+// axialSource is the paint source of an axial shading.
+type axialSource struct {
+	// inv maps device space to shading space.
+	inv            contentstream.Matrix
+	x0, y0, dx, dy float64
+	extend         [2]bool
+	lut            []solidColor
+}
+
+func (s *axialSource) colorAt(x, y int) (float64, float64, float64, float64) {
+	px, py := s.inv.Transform(float64(x)+0.5, float64(y)+0.5)
+	t := ((px-s.x0)*s.dx + (py-s.y0)*s.dy) / (s.dx*s.dx + s.dy*s.dy)
+	return lookupColor(s.lut, t, s.extend)
+}
+
+// lookupColor returns the color of `lut` at parametric value `t` in [0, 1], which is transparent outside of the
+// range unless the shading is extended.
+func lookupColor(lut []solidColor, t float64, extend [2]bool) (float64, float64, float64, float64) {
+	if t < 0 {
+		if !extend[0] {
+			return 0, 0, 0, 0
+		}
+		t = 0
+	} else if t > 1 {
+		if !extend[1] {
+			return 0, 0, 0, 0
+		}
+		t = 1
+	}
+	c := lut[int(t*float64(len(lut)-1)+0.5)]
+	return c.r, c.g, c.b, 1
+}
+
+// radialSource is the paint source of a radial shading.
+type radialSource struct {
+	inv        contentstream.Matrix
+	x0, y0, r0 float64
+	dx, dy, dr float64
+	extend     [2]bool
+	lut        []solidColor
+}
+
+// colorAt finds the largest parametric value s for which the circle with center (x0, y0) + s (dx, dy) and
+// radius r0 + s dr passes through the point, solving |p - c(s)| = r(s).
+func (s *radialSource) colorAt(x, y int) (float64, float64, float64, float64) {
+	px, py := s.inv.Transform(float64(x)+0.5, float64(y)+0.5)
+	px -= s.x0
+	py -= s.y0
+	a := s.dx*s.dx + s.dy*s.dy - s.dr*s.dr
+	b := px*s.dx + py*s.dy + s.r0*s.dr
+	c := px*px + py*py - s.r0*s.r0
+
+	var candidates [2]float64
+	n := 0
+	if math.Abs(a) < 1e-12 {
+		if b == 0 {
+			return 0, 0, 0, 0
+		}
+		candidates[0] = c / (2 * b)
+		n = 1
+	} else {
+		disc := b*b - a*c
+		if disc < 0 {
+			return 0, 0, 0, 0
+		}
+		sq := math.Sqrt(disc)
+		s1, s2 := (b+sq)/a, (b-sq)/a
+		if s1 < s2 {
+			s1, s2 = s2, s1
+		}
+		candidates = [2]float64{s1, s2}
+		n = 2
+	}
+	for _, t := range candidates[:n] {
+		if s.r0+t*s.dr < 0 {
+			continue
+		}
+		if (t < 0 && !s.extend[0]) || (t > 1 && !s.extend[1]) {
+			continue
+		}
+		return lookupColor(s.lut, t, s.extend)
+	}
+	return 0, 0, 0, 0
+}
+
+// functionSource is the paint source of a function-based shading.
+type functionSource struct {
+	// inv maps device space to the domain of the function.
+	inv    contentstream.Matrix
+	domain [4]float64
+	colors shadingColors
+}
+
+func (s *functionSource) colorAt(x, y int) (float64, float64, float64, float64) {
+	u, v := s.inv.Transform(float64(x)+0.5, float64(y)+0.5)
+	if u < s.domain[0] || u > s.domain[1] || v < s.domain[2] || v > s.domain[3] {
+		return 0, 0, 0, 0
+	}
+	c, ok := s.colors.color([]float64{u, v})
+	if !ok {
+		return 0, 0, 0, 0
+	}
+	return c.r, c.g, c.b, 1
+}
+
+// backgroundSource paints the background color of a shading where the shading itself is not painted.
+type backgroundSource struct {
+	src paintSource
+	bg  solidColor
+}
+
+func (s backgroundSource) colorAt(x, y int) (float64, float64, float64, float64) {
+	r, g, b, a := s.src.colorAt(x, y)
+	if a >= 1 {
+		return r, g, b, a
+	}
+	return r*a + s.bg.r*(1-a), g*a + s.bg.g*(1-a), b*a + s.bg.b*(1-a), 1
+}
+
+// newShadingSource returns the paint source of `shading` drawn with matrix `m` from shading space to device
+// space.  Mesh shadings are rasterized within `bounds`.  The Background color is painted outside of the
+// shading if `withBackground` is set, as for shading patterns.
+func newShadingSource(shading *model.PdfShading, m contentstream.Matrix, bounds image.Rectangle,
+	withBackground bool) (paintSource, error) {
+	inv, ok := m.Inverse()
+	if !ok {
+		return nil, errors.New("Degenerate shading matrix")
+	}
+
+	var src paintSource
+	var err error
+	switch t := shading.GetContext().(type) {
+	case *model.PdfShadingType1:
+		s := &functionSource{domain: [4]float64{0, 1, 0, 1}, colors: shadingColors{shading.ColorSpace, t.Function}}
+		if domain := graphics.GetNumbers(t.Domain); len(domain) == 4 {
+			copy(s.domain[:], domain)
+		}
+		// The shading Matrix maps the domain to shading space.
+		domainMatrix := contentstream.IdentityMatrix()
+		if matrix := graphics.GetNumbers(t.Matrix); len(matrix) == 6 {
+			domainMatrix = contentstream.NewMatrix(matrix[0], matrix[1], matrix[2], matrix[3], matrix[4], matrix[5])
+		}
+		s.inv, ok = domainMatrix.Mult(m).Inverse()
+		if !ok {
+			return nil, errors.New("Degenerate shading matrix")
+		}
+		src = s
+	case *model.PdfShadingType2:
+		coords := graphics.GetNumbers(t.Coords)
+		if len(coords) != 4 {
+			return nil, errors.New("Invalid axial shading coordinates")
+		}
+		s := &axialSource{inv: inv, x0: coords[0], y0: coords[1], dx: coords[2] - coords[0], dy: coords[3] - coords[1]}
+		if s.dx == 0 && s.dy == 0 {
+			return nil, errors.New("Degenerate axial shading")
+		}
+		s.extend = graphics.GetExtend(t.Extend)
+		t0, t1 := getDomain(t.Domain)
+		s.lut = shadingColors{shading.ColorSpace, t.Function}.lookupTable(t0, t1, lookupTableSize)
+		src = s
+	case *model.PdfShadingType3:
+		coords := graphics.GetNumbers(t.Coords)
+		if len(coords) != 6 {
+			return nil, errors.New("Invalid radial shading coordinates")
+		}
+		s := &radialSource{
+			inv: inv,
+			x0:  coords[0], y0: coords[1], r0: coords[2],
+			dx: coords[3] - coords[0], dy: coords[4] - coords[1], dr: coords[5] - coords[2],
+		}
+		s.extend = graphics.GetExtend(t.Extend)
+		t0, t1 := getDomain(t.Domain)
+		s.lut = shadingColors{shading.ColorSpace, t.Function}.lookupTable(t0, t1, lookupTableSize)
+		src = s
+	case *model.PdfShadingType4, *model.PdfShadingType5, *model.PdfShadingType6, *model.PdfShadingType7:
+		src, err = newMeshSource(shading, m, bounds)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("Unsupported shading type")
+	}
+
+	if withBackground && shading.Background != nil {
+		bg := graphics.GetNumbers(shading.Background)
+		if len(bg) == shading.ColorSpace.GetNumComponents() {
+			src = backgroundSource{src: src, bg: floatsToRGB(shading.ColorSpace, bg)}
+		}
+	}
+	return src, nil
+}
+
+// getDomain returns the values of the Domain entry of an axial or radial shading, [0 1] by default.
+func getDomain(obj *core.PdfObjectArray) (float64, float64) {
+	if domain := graphics.GetNumbers(obj); len(domain) == 2 {
+		return domain[0], domain[1]
+	}
+	return 0, 1
+}
+
+// meshVertex is a vertex of a mesh shading in device space with its color values.
+type meshVertex struct {
+	x, y float64
+	c    []float64
+}
+
+// bitReader reads the bit packed values of mesh shading streams.
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) hasData() bool {
+	return r.pos < len(r.data)*8
+}
+
+func (r *bitReader) read(bits int) (uint64, bool) {
+	if bits <= 0 || bits > 32 || r.pos+bits > len(r.data)*8 {
+		return 0, false
+	}
+	v := uint64(0)
+	for i := 0; i < bits; i++ {
+		bit := r.data[(r.pos+i)/8] >> uint(7-(r.pos+i)%8) & 1
+		v = v<<1 | uint64(bit)
+	}
+	r.pos += bits
+	return v, true
+}
+
+// align skips to the next byte boundary.
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) / 8 * 8
+}
+
+// meshReader reads the vertices of mesh shadings.
+type meshReader struct {
+	bitReader
+	bitsPerCoordinate int
+	bitsPerComponent  int
+	bitsPerFlag       int
+	decode            []float64
+	numComponents     int
+	// m maps shading space to device space.
+	m contentstream.Matrix
+}
+
+// readValue reads a value of `bits` bits and maps it to the range [dmin, dmax].
+func (r *meshReader) readValue(bits int, dmin, dmax float64) (float64, bool) {
+	v, ok := r.read(bits)
+	if !ok {
+		return 0, false
+	}
+	return dmin + float64(v)*(dmax-dmin)/(math.Pow(2, float64(bits))-1), true
+}
+
+// readPoint reads a point and maps it to device space.
+func (r *meshReader) readPoint() (point, bool) {
+	x, ok1 := r.readValue(r.bitsPerCoordinate, r.decode[0], r.decode[1])
+	y, ok2 := r.readValue(r.bitsPerCoordinate, r.decode[2], r.decode[3])
+	if !ok1 || !ok2 {
+		return point{}, false
+	}
+	return point{x, y}.transform(r.m), true
+}
+
+// readColor reads the color values of a vertex.
+func (r *meshReader) readColor() ([]float64, bool) {
+	c := make([]float64, r.numComponents)
+	for i := range c {
+		v, ok := r.readValue(r.bitsPerComponent, r.decode[4+2*i], r.decode[5+2*i])
+		if !ok {
+			return nil, false
+		}
+		c[i] = v
+	}
+	return c, true
+}
+
+func (r *meshReader) readVertex() (meshVertex, bool) {
+	p, ok := r.readPoint()
+	if !ok {
+		return meshVertex{}, false
+	}
+	c, ok := r.readColor()
+	return meshVertex{p.x, p.y, c}, ok
+}
+
+// newMeshSource returns the paint source of mesh shading `shading` (types 4 to 7) drawn with matrix `m`,
+// rasterized within `bounds`.
+func newMeshSource(shading *model.PdfShading, m contentstream.Matrix, bounds image.Rectangle) (paintSource, error) {
+	stream, ok := shading.GetContainingPdfObject().(*core.PdfObjectStream)
+	if !ok {
+		return nil, errors.New("Mesh shading not a stream")
+	}
+	data, err := core.DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	var bpc, bpf, vpr *core.PdfObjectInteger
+	var bpcoord *core.PdfObjectInteger
+	var decode *core.PdfObjectArray
+	var functions []model.PdfFunction
+	switch t := shading.GetContext().(type) {
+	case *model.PdfShadingType4:
+		bpcoord, bpc, bpf, decode, functions = t.BitsPerCoordinate, t.BitsPerComponent, t.BitsPerFlag, t.Decode, t.Function
+	case *model.PdfShadingType5:
+		bpcoord, bpc, vpr, decode, functions = t.BitsPerCoordinate, t.BitsPerComponent, t.VerticesPerRow, t.Decode, t.Function
+	case *model.PdfShadingType6:
+		bpcoord, bpc, bpf, decode, functions = t.BitsPerCoordinate, t.BitsPerComponent, t.BitsPerFlag, t.Decode, t.Function
+	case *model.PdfShadingType7:
+		bpcoord, bpc, bpf, decode, functions = t.BitsPerCoordinate, t.BitsPerComponent, t.BitsPerFlag, t.Decode, t.Function
+	}
+
+	r := &meshReader{bitReader: bitReader{data: data}, m: m, decode: graphics.GetNumbers(decode)}
+	if bpcoord != nil {
+		r.bitsPerCoordinate = int(*bpcoord)
+	}
+	if bpc != nil {
+		r.bitsPerComponent = int(*bpc)
+	}
+	if bpf != nil {
+		r.bitsPerFlag = int(*bpf)
+	}
+	r.numComponents = shading.ColorSpace.GetNumComponents()
+	if len(functions) > 0 {
+		r.numComponents = 1
+	}
+	if len(r.decode) < 4+2*r.numComponents {
+		return nil, errors.New("Invalid mesh shading decode array")
+	}
+
+	mesh := &meshRasterizer{
+		img:    image.NewRGBA(bounds),
+		colors: shadingColors{shading.ColorSpace, functions},
+	}
+	if len(functions) > 0 {
+		mesh.lut = mesh.colors.lookupTable(r.decode[4], r.decode[5], lookupTableSize)
+		mesh.t0, mesh.t1 = r.decode[4], r.decode[5]
+	}
+
+	switch shading.GetContext().(type) {
+	case *model.PdfShadingType4:
+		mesh.freeFormTriangles(r)
+	case *model.PdfShadingType5:
+		if vpr == nil || *vpr < 2 {
+			return nil, errors.New("Invalid lattice mesh shading")
+		}
+		mesh.latticeTriangles(r, int(*vpr))
+	case *model.PdfShadingType6:
+		mesh.patches(r, false)
+	case *model.PdfShadingType7:
+		mesh.patches(r, true)
+	}
+	return layerSource{mesh.img}, nil
+}
+
+// meshRasterizer rasterizes the Gouraud-shaded triangles of mesh shadings.
+type meshRasterizer struct {
+	img    *image.RGBA
+	colors shadingColors
+	// lut holds the colors of the parametric values from t0 to t1 if the shading has functions.
+	lut    []solidColor
+	t0, t1 float64
+}
+
+// freeFormTriangles draws the triangles of a free-form triangle mesh.  A vertex with flag 0 starts a new
+// triangle, the flags of its next two vertices are ignored.  Flags 1 and 2 form a triangle of the new vertex with
+// an edge of the previous triangle.
+func (mesh *meshRasterizer) freeFormTriangles(r *meshReader) {
+	var va, vb, vc meshVertex
+	pending := []meshVertex{}
+	started := false
+	for r.hasData() {
+		flag, ok := r.read(r.bitsPerFlag)
+		if !ok {
+			return
+		}
+		v, ok := r.readVertex()
+		if !ok {
+			return
+		}
+		r.align()
+		switch {
+		case len(pending) > 0 || flag == 0 || !started:
+			pending = append(pending, v)
+			if len(pending) < 3 {
+				continue
+			}
+			va, vb, vc = pending[0], pending[1], pending[2]
+			pending = pending[:0]
+			started = true
+		case flag == 1:
+			va, vb, vc = vb, vc, v
+		default:
+			vb, vc = vc, v
+		}
+		mesh.triangle(va, vb, vc)
+	}
+}
+
+// latticeTriangles draws the triangles of a lattice-form triangle mesh with `perRow` vertices per row.
+func (mesh *meshRasterizer) latticeTriangles(r *meshReader, perRow int) {
+	var prev []meshVertex
+	for r.hasData() {
+		row := make([]meshVertex, perRow)
+		for i := range row {
+			v, ok := r.readVertex()
+			if !ok {
+				return
+			}
+			row[i] = v
+		}
+		if prev != nil {
+			for i := 0; i+1 < perRow; i++ {
+				mesh.triangle(prev[i], prev[i+1], row[i])
+				mesh.triangle(prev[i+1], row[i+1], row[i])
+			}
+		}
+		prev = row
+	}
+}
+
+// patchGrid is the number of subdivisions of the sides of patches into triangles.
+const patchGrid = 16
+
+// patches draws the patches of a Coons patch mesh or, if `tensor` is set, a tensor-product patch mesh.
+func (mesh *meshRasterizer) patches(r *meshReader, tensor bool) {
+	// The control points in stream order, in the positions of the 4 x 4 tensor-product control point grid.
+	order := [][2]int{{0, 0}, {0, 1}, {0, 2}, {0, 3}, {1, 3}, {2, 3}, {3, 3}, {3, 2}, {3, 1}, {3, 0}, {2, 0}, {1, 0},
+		{1, 1}, {1, 2}, {2, 2}, {2, 1}}
+	numPoints := 12
+	if tensor {
+		numPoints = 16
+	}
+
+	var p [4][4]point
+	// The colors of the corners p00, p03, p33 and p30.
+	var c [4][]float64
+	havePrev := false
+	for r.hasData() {
+		flag, ok := r.read(r.bitsPerFlag)
+		if !ok {
+			return
+		}
+		first := 0
+		firstColor := 0
+		if flag != 0 {
+			if !havePrev {
+				return
+			}
+			// The first edge and its corner colors are those of an edge of the previous patch.
+			var edge [4]point
+			var colors [2][]float64
+			switch flag {
+			case 1:
+				edge = [4]point{p[0][3], p[1][3], p[2][3], p[3][3]}
+				colors = [2][]float64{c[1], c[2]}
+			case 2:
+				edge = [4]point{p[3][3], p[3][2], p[3][1], p[3][0]}
+				colors = [2][]float64{c[2], c[3]}
+			default:
+				edge = [4]point{p[3][0], p[2][0], p[1][0], p[0][0]}
+				colors = [2][]float64{c[3], c[0]}
+			}
+			for i, e := range edge {
+				p[0][i] = e
+			}
+			c[0], c[1] = colors[0], colors[1]
+			first, firstColor = 4, 2
+		}
+		for i := first; i < numPoints; i++ {
+			pt, ok := r.readPoint()
+			if !ok {
+				return
+			}
+			p[order[i][0]][order[i][1]] = pt
+		}
+		for i := firstColor; i < 4; i++ {
+			col, ok := r.readColor()
+			if !ok {
+				return
+			}
+			c[i] = col
+		}
+		r.align()
+		havePrev = true
+
+		if !tensor {
+			setCoonsInteriorPoints(&p)
+		}
+		mesh.patch(p, c)
+	}
+}
+
+// setCoonsInteriorPoints sets the four interior control points of Coons patch `p`, making it the equivalent
+// tensor-product patch.
+func setCoonsInteriorPoints(p *[4][4]point) {
+	combine := func(weights []float64, pts ...point) point {
+		q := point{}
+		for i, w := range weights {
+			q.x += w * pts[i].x / 9
+			q.y += w * pts[i].y / 9
+		}
+		return q
+	}
+	w := []float64{-4, 6, 6, -2, -2, 3, 3, -1}
+	p[1][1] = combine(w, p[0][0], p[0][1], p[1][0], p[0][3], p[3][0], p[3][1], p[1][3], p[3][3])
+	p[1][2] = combine(w, p[0][3], p[0][2], p[1][3], p[0][0], p[3][3], p[3][2], p[1][0], p[3][0])
+	p[2][2] = combine(w, p[3][3], p[3][2], p[2][3], p[3][0], p[0][3], p[0][2], p[2][0], p[0][0])
+	p[2][1] = combine(w, p[3][0], p[3][1], p[2][0], p[3][3], p[0][0], p[0][1], p[2][3], p[0][3])
+}
+
+// patch draws tensor-product patch `p` with corner colors `c`, subdividing it into triangles.
+func (mesh *meshRasterizer) patch(p [4][4]point, c [4][]float64) {
+	bernstein := func(t float64) [4]float64 {
+		u := 1 - t
+		return [4]float64{u * u * u, 3 * u * u * t, 3 * u * t * t, t * t * t}
+	}
+	var grid [patchGrid + 1][patchGrid + 1]meshVertex
+	for i := 0; i <= patchGrid; i++ {
+		u := float64(i) / patchGrid
+		bu := bernstein(u)
+		for j := 0; j <= patchGrid; j++ {
+			v := float64(j) / patchGrid
+			bv := bernstein(v)
+			x, y := 0.0, 0.0
+			for a := 0; a < 4; a++ {
+				for b := 0; b < 4; b++ {
+					x += p[a][b].x * bu[a] * bv[b]
+					y += p[a][b].y * bu[a] * bv[b]
+				}
+			}
+			col := make([]float64, len(c[0]))
+			for k := range col {
+				col[k] = (1-u)*(1-v)*c[0][k] + (1-u)*v*c[1][k] + u*v*c[2][k] + u*(1-v)*c[3][k]
+			}
+			grid[i][j] = meshVertex{x, y, col}
+		}
+	}
+	for i := 0; i < patchGrid; i++ {
+		for j := 0; j < patchGrid; j++ {
+			mesh.triangle(grid[i][j], grid[i+1][j], grid[i+1][j+1])
+			mesh.triangle(grid[i][j], grid[i+1][j+1], grid[i][j+1])
+		}
+	}
+}
+
+// triangle draws the Gouraud-shaded triangle with vertices `a`, `b` and `c`, interpolating the colors at the
+// pixel centers inside.
+func (mesh *meshRasterizer) triangle(a, b, c meshVertex) {
+	det := (b.x-a.x)*(c.y-a.y) - (c.x-a.x)*(b.y-a.y)
+	if det == 0 || len(a.c) == 0 || len(a.c) != len(b.c) || len(a.c) != len(c.c) {
+		return
+	}
+	rect := image.Rect(
+		int(math.Floor(math.Min(a.x, math.Min(b.x, c.x)))),
+		int(math.Floor(math.Min(a.y, math.Min(b.y, c.y)))),
+		int(math.Ceil(math.Max(a.x, math.Max(b.x, c.x))))+1,
+		int(math.Ceil(math.Max(a.y, math.Max(b.y, c.y))))+1,
+	).Intersect(mesh.img.Rect)
+
+	vals := make([]float64, len(a.c))
+	const eps = 1e-9
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		py := float64(y) + 0.5
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			px := float64(x) + 0.5
+			// Barycentric coordinates of the pixel center.
+			wb := ((px-a.x)*(c.y-a.y) - (c.x-a.x)*(py-a.y)) / det
+			wc := ((b.x-a.x)*(py-a.y) - (px-a.x)*(b.y-a.y)) / det
+			wa := 1 - wb - wc
+			if wa < -eps || wb < -eps || wc < -eps {
+				continue
+			}
+			for k := range vals {
+				vals[k] = wa*a.c[k] + wb*b.c[k] + wc*c.c[k]
+			}
+			var col solidColor
+			if mesh.lut != nil {
+				t := 0.0
+				if mesh.t1 != mesh.t0 {
+					t = (vals[0] - mesh.t0) / (mesh.t1 - mesh.t0)
+				}
+				col = mesh.lut[int(graphics.Clamp01(t)*float64(len(mesh.lut)-1)+0.5)]
+			} else {
+				col, _ = mesh.colors.color(vals)
+			}
+			i := mesh.img.PixOffset(x, y)
+			mesh.img.Pix[i] = clampByte(col.r * 255)
+			mesh.img.Pix[i+1] = clampByte(col.g * 255)
+			mesh.img.Pix[i+2] = clampByte(col.b * 255)
+			mesh.img.Pix[i+3] = 255
+		}
+	}
+}
@@ -0,0 +1,324 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"math"
+
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+)
+
+// Line cap and join styles (8.4.3.3 and 8.4.3.4 in the PDF reference).
+const (
+	capButt   = 0
+	capRound  = 1
+	capSquare = 2
+
+	joinMiter = 0
+	joinRound = 1
+	joinBevel = 2
+)
+
+// strokeStyle holds the graphics state parameters of stroking, in user space units.
+type strokeStyle struct {
+	width      float64
+	cap        int
+	join       int
+	miterLimit float64
+	dash       []float64
+	dashPhase  float64
+}
+
+// newStrokeStyle returns the stroke style of graphics state `s`.
+func newStrokeStyle(s *graphics.State) strokeStyle {
+	return strokeStyle{
+		width:      s.LineWidth,
+		cap:        s.LineCap,
+		join:       s.LineJoin,
+		miterLimit: s.MiterLimit,
+		dash:       s.Dash,
+		dashPhase:  s.DashPhase,
+	}
+}
+
+// strokePolygons returns the polygons covering the stroke of the polylines `lines` with `style`.  The
+// polygons are positively oriented and overlap, so that they are filled with the nonzero winding rule.
+// `tolerance` is the maximum deviation of the polygons approximating round caps and joins.
+func strokePolygons(lines []polyline, style strokeStyle, tolerance float64) []polyline {
+	hw := style.width / 2
+	if hw <= 0 {
+		return nil
+	}
+	s := &stroker{style: style, hw: hw, arcSteps: arcSteps(hw, tolerance)}
+	for _, line := range lines {
+		line = removeDuplicatePoints(line)
+		if len(line.points) == 1 {
+			s.dot(line.points[0])
+			continue
+		}
+		for _, dashed := range dashLine(line, style.dash, style.dashPhase) {
+			s.strokeLine(dashed)
+		}
+	}
+	return s.polygons
+}
+
+// arcSteps returns the number of segments of a full circle of radius `r` deviating at most `tolerance` from it.
+func arcSteps(r, tolerance float64) int {
+	if r <= tolerance {
+		return 8
+	}
+	n := int(math.Ceil(math.Pi / math.Acos(1-tolerance/r)))
+	if n < 8 {
+		n = 8
+	} else if n > 256 {
+		n = 256
+	}
+	return n
+}
+
+// removeDuplicatePoints returns the polyline without consecutive equal points.  For closed polylines, the last
+// point is removed if equal to the first one.
+func removeDuplicatePoints(line polyline) polyline {
+	points := make([]point, 0, len(line.points))
+	for _, p := range line.points {
+		if len(points) == 0 || p != points[len(points)-1] {
+			points = append(points, p)
+		}
+	}
+	if line.closed && len(points) > 1 && points[0] == points[len(points)-1] {
+		points = points[:len(points)-1]
+	}
+	return polyline{points: points, closed: line.closed}
+}
+
+// dashLine splits polyline `line` into the dashes of dash array `dash` starting at `phase`.  Returns the line
+// unchanged if not dashed.
+func dashLine(line polyline, dash []float64, phase float64) []polyline {
+	total := 0.0
+	for _, d := range dash {
+		if d < 0 {
+			return []polyline{line}
+		}
+		total += d
+	}
+	if total <= 0 {
+		return []polyline{line}
+	}
+
+	points := line.points
+	if line.closed {
+		points = append(append([]point{}, points...), points[0])
+	}
+
+	// Find the dash at the phase.
+	index := 0
+	on := true
+	remaining := dash[0]
+	// Arrays of odd length repeat with the dashes and gaps swapped.
+	period := total * float64(1+len(dash)%2)
+	phase = math.Mod(phase, period)
+	if phase < 0 {
+		phase += period
+	}
+	for phase > 0 {
+		if phase < remaining {
+			remaining -= phase
+			break
+		}
+		phase -= remaining
+		index = (index + 1) % len(dash)
+		on = !on
+		remaining = dash[index]
+	}
+
+	dashes := []polyline{}
+	var current []point
+	if on {
+		current = []point{points[0]}
+	}
+	for i := 0; i+1 < len(points); i++ {
+		p, q := points[i], points[i+1]
+		length := math.Hypot(q.x-p.x, q.y-p.y)
+		pos := 0.0
+		for length-pos > remaining {
+			pos += remaining
+			t := pos / length
+			split := point{p.x + t*(q.x-p.x), p.y + t*(q.y-p.y)}
+			if on {
+				dashes = append(dashes, polyline{points: append(current, split)})
+				current = nil
+			} else {
+				current = []point{split}
+			}
+			on = !on
+			index = (index + 1) % len(dash)
+			remaining = dash[index]
+		}
+		remaining -= length - pos
+		if on {
+			current = append(current, q)
+		}
+	}
+	if on && len(current) > 0 {
+		dashes = append(dashes, polyline{points: current})
+	}
+	return dashes
+}
+
+// stroker builds the polygons of a stroke.
+type stroker struct {
+	style    strokeStyle
+	hw       float64
+	arcSteps int
+	polygons []polyline
+}
+
+// add adds polygon `points`, reversing it if negatively oriented.
+func (s *stroker) add(points ...point) {
+	area := 0.0
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		area += p.x*q.y - q.x*p.y
+	}
+	if area < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+	s.polygons = append(s.polygons, polyline{points: points, closed: true})
+}
+
+// circle adds a circle of the stroke width centered at `c`.
+func (s *stroker) circle(c point) {
+	points := make([]point, s.arcSteps)
+	for i := range points {
+		a := 2 * math.Pi * float64(i) / float64(s.arcSteps)
+		points[i] = point{c.x + s.hw*math.Cos(a), c.y + s.hw*math.Sin(a)}
+	}
+	s.add(points...)
+}
+
+// dot draws the cap of a degenerate subpath consisting of the single point `p`.
+func (s *stroker) dot(p point) {
+	switch s.style.cap {
+	case capRound:
+		s.circle(p)
+	case capSquare:
+		s.add(point{p.x - s.hw, p.y - s.hw}, point{p.x + s.hw, p.y - s.hw}, point{p.x + s.hw, p.y + s.hw},
+			point{p.x - s.hw, p.y + s.hw})
+	}
+}
+
+// strokeLine adds the polygons of the stroke of polyline `line` without consecutive equal points.
+func (s *stroker) strokeLine(line polyline) {
+	points := line.points
+	n := len(points)
+	if n < 2 {
+		if n == 1 {
+			s.dot(points[0])
+		}
+		return
+	}
+
+	// The body of each segment.
+	for i := 0; i+1 < n; i++ {
+		s.segment(points[i], points[i+1])
+	}
+	if line.closed && n > 2 {
+		s.segment(points[n-1], points[0])
+	}
+
+	// The joins between the segments.
+	for i := 1; i+1 < n; i++ {
+		s.joinAt(points[i-1], points[i], points[i+1])
+	}
+	if line.closed && n > 2 {
+		s.joinAt(points[n-2], points[n-1], points[0])
+		s.joinAt(points[n-1], points[0], points[1])
+		return
+	}
+
+	// The caps at the ends.
+	s.capAt(points[1], points[0])
+	s.capAt(points[n-2], points[n-1])
+}
+
+// normal returns the normal of the segment from `p` to `q` with the length of half the stroke width, pointing
+// to the left of the direction of the segment.
+func (s *stroker) normal(p, q point) point {
+	dx, dy := q.x-p.x, q.y-p.y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return point{}
+	}
+	return point{-dy / l * s.hw, dx / l * s.hw}
+}
+
+// segment adds the rectangle covering the stroke of the segment from `p` to `q`.
+func (s *stroker) segment(p, q point) {
+	n := s.normal(p, q)
+	s.add(point{p.x + n.x, p.y + n.y}, point{q.x + n.x, q.y + n.y}, point{q.x - n.x, q.y - n.y},
+		point{p.x - n.x, p.y - n.y})
+}
+
+// capAt adds the cap at end point `end` of the segment from `from`.
+func (s *stroker) capAt(from, end point) {
+	switch s.style.cap {
+	case capRound:
+		s.circle(end)
+	case capSquare:
+		n := s.normal(from, end)
+		// The direction of the segment, scaled to half the width.
+		d := point{n.y, -n.x}
+		s.add(point{end.x + n.x, end.y + n.y}, point{end.x + n.x + d.x, end.y + n.y + d.y},
+			point{end.x - n.x + d.x, end.y - n.y + d.y}, point{end.x - n.x, end.y - n.y})
+	}
+}
+
+// joinAt adds the join at point `p` between the segments from `a` to `p` and from `p` to `b`.
+func (s *stroker) joinAt(a, p, b point) {
+	n1 := s.normal(a, p)
+	n2 := s.normal(p, b)
+	cross := (p.x-a.x)*(b.y-p.y) - (p.y-a.y)*(b.x-p.x)
+	if cross == 0 && n1.x*n2.x+n1.y*n2.y > 0 {
+		// Collinear segments continuing in the same direction need no join.
+		return
+	}
+	if s.style.join == joinRound {
+		s.circle(p)
+		return
+	}
+
+	// The join is on the outer side of the turn: to the right of a left turn and to the left of a right turn.
+	if cross > 0 {
+		n1, n2 = point{-n1.x, -n1.y}, point{-n2.x, -n2.y}
+	}
+	o1 := point{p.x + n1.x, p.y + n1.y}
+	o2 := point{p.x + n2.x, p.y + n2.y}
+
+	if s.style.join == joinMiter {
+		// The miter length ratio is 1/sin(phi/2) for angle phi between the segments.
+		cosTheta := (n1.x*n2.x + n1.y*n2.y) / (s.hw * s.hw)
+		sinHalfPhi := math.Sqrt(math.Max(0, (1+cosTheta)/2))
+		limit := s.style.miterLimit
+		if limit < 1 {
+			limit = 10
+		}
+		if sinHalfPhi > 0 && 1/sinHalfPhi <= limit {
+			// The miter tip is along the bisector of the normals, at distance hw/sin(phi/2) from `p`.
+			bx, by := n1.x+n2.x, n1.y+n2.y
+			bl := math.Hypot(bx, by)
+			if bl > 0 {
+				d := s.hw / sinHalfPhi
+				tip := point{p.x + bx/bl*d, p.y + by/bl*d}
+				s.add(p, o1, tip, o2)
+				return
+			}
+		}
+	}
+	s.add(p, o1, o2)
+}
@@ -0,0 +1,109 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+)
+
+// BeginText starts a text object.  Implements the graphics.Device interface.
+func (cr *contentRenderer) BeginText() {
+	cr.textClip = nil
+}
+
+// EndText ends a text object, adding the glyphs shown with a clipping text rendering mode to the clipping path.
+// Implements the graphics.Device interface.
+func (cr *contentRenderer) EndText() {
+	if cr.textClip != nil {
+		cr.clipPath(cr.textClip, graphics.NonZeroWinding)
+		cr.textClip = nil
+	}
+}
+
+// ShowText draws the glyphs `glyphs` of font `font`.  Implements the graphics.Device interface.
+func (cr *contentRenderer) ShowText(font *fontfile.Font, tm contentstream.Matrix, glyphs []graphics.Glyph,
+	ctm contentstream.Matrix) {
+	for _, g := range glyphs {
+		cr.drawGlyph(font, g.Code, g.Trm, ctm)
+	}
+}
+
+// drawGlyph draws the glyph of character code `code` with matrix `trm` from text space for a font size of 1 to
+// user space, according to the text rendering mode.
+func (cr *contentRenderer) drawGlyph(font *fontfile.Font, code int, trm, ctm contentstream.Matrix) {
+	mode := cr.state.RenderMode
+	if mode == graphics.TextInvisible || cr.IsHidden() && mode < graphics.TextFillClip {
+		return
+	}
+
+	if font.Type3 != nil {
+		if mode != graphics.TextClip && !cr.IsHidden() {
+			cr.drawType3Glyph(font.Type3, code, trm, ctm)
+		}
+		return
+	}
+
+	outline, ok := font.Glyph(code)
+	if !ok {
+		if !cr.r.missingFonts[font] {
+			common.Log.Debug("No glyph outlines for font %q, text not drawn", font.BaseFont)
+			cr.r.missingFonts[font] = true
+		}
+		return
+	}
+	p := &graphics.Path{}
+	p.AppendOutline(outline, trm)
+
+	switch mode {
+	case graphics.TextFill, graphics.TextFillStroke, graphics.TextFillClip, graphics.TextFillStrokeClip:
+		cr.fillPath(p, ctm, graphics.NonZeroWinding)
+	}
+	switch mode {
+	case graphics.TextStroke, graphics.TextFillStroke, graphics.TextStrokeClip, graphics.TextFillStrokeClip:
+		cr.strokePath(p, ctm)
+	}
+	if mode >= graphics.TextFillClip {
+		if cr.textClip == nil {
+			cr.textClip = &graphics.Path{}
+		}
+		cr.textClip.AppendOutline(outline, trm.Mult(ctm))
+	}
+}
+
+// drawType3Glyph draws the glyph description of character code `code` of a Type 3 font, with matrix `trm` from
+// text space to user space.
+func (cr *contentRenderer) drawType3Glyph(font *fontfile.Type3, code int, trm, ctm contentstream.Matrix) {
+	stream, has := font.CharProcs[code]
+	if !has {
+		return
+	}
+	if cr.depth >= maxDepth {
+		common.Log.Debug("Type 3 glyphs nested too deeply, skipping")
+		return
+	}
+	content, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid Type 3 glyph stream, skipping: %v", err)
+		return
+	}
+	m := font.Matrix
+	base := contentstream.NewMatrix(m[0], m[1], m[2], m[3], m[4], m[5]).Mult(trm).Mult(ctm)
+	resources := font.Resources
+	if resources == nil {
+		resources = cr.Resources
+	}
+
+	gs := cr.GS
+	gs.CTM = contentstream.IdentityMatrix()
+	child := newContentRenderer(cr.r, cr.dst, resources, base, cr.state, cr.depth+1)
+	if err := child.Process(string(content), gs); err != nil {
+		common.Log.Debug("Error drawing Type 3 glyph: %v", err)
+	}
+}
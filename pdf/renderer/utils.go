@@ -0,0 +1,16 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package renderer
+
+import (
+	"github.com/unidoc/unidoc/pdf/core"
+)
+
+// isName checks whether `obj` is a name object.
+func isName(obj core.PdfObject) bool {
+	_, ok := core.TraceToDirectObject(obj).(*core.PdfObjectName)
+	return ok
+}
@@ -0,0 +1,523 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package svg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// maxDepth is the maximum nesting depth of forms, patterns and Type 3 glyphs converted.
+const maxDepth = 32
+
+// graphicsState is the graphics state used for converting.
+type graphicsState struct {
+	graphics.State
+	// blendMode is the CSS blend mode, "" for normal.
+	blendMode string
+	// clip and mask are the IDs of the clipPath and mask elements of the clipping path and soft mask, "" for
+	// none.
+	clip string
+	mask string
+}
+
+// newGraphicsState returns the graphics state at the start of a page.
+func newGraphicsState() graphicsState {
+	return graphicsState{State: graphics.NewState()}
+}
+
+// contentConverter converts a content stream to SVG elements.  It is the graphics.Device of the interpreter
+// of the content stream.
+type contentConverter struct {
+	*graphics.Interpreter
+	d   *document
+	dst *canvas
+	// area is the region of the canvas that can be painted.
+	area  *model.PdfRectangle
+	state graphicsState
+	stack []graphicsState
+	depth int
+	// color, if not empty, replaces the colors of the content stream, for uncolored tiling patterns.
+	color string
+	// textClip holds the elements of the glyphs added to the clipping path at the end of the text object.
+	textClip *bytes.Buffer
+}
+
+// newContentConverter returns a converter of content streams with resources `resources`, drawn with matrix
+// `base` to `dst` with graphics state `state`.
+func newContentConverter(d *document, dst *canvas, resources *model.PdfPageResources, base contentstream.Matrix,
+	area *model.PdfRectangle, state graphicsState, depth int) *contentConverter {
+	cr := &contentConverter{d: d, dst: dst, area: area, state: state, depth: depth}
+	cr.Interpreter = graphics.NewInterpreter(cr, resources, d.c.OCProperties, base)
+	return cr
+}
+
+// child returns a converter for a content stream with resources `resources`, drawn with matrix `base` to `dst`
+// with graphics state `state`.
+func (cr *contentConverter) child(dst *canvas, resources *model.PdfPageResources, base contentstream.Matrix,
+	state graphicsState) *contentConverter {
+	child := newContentConverter(cr.d, dst, resources, base, cr.area, state, cr.depth+1)
+	child.color = cr.color
+	return child
+}
+
+// State returns the graphics state parameters set by the operators.  Implements the graphics.Device interface.
+func (cr *contentConverter) State() *graphics.State {
+	return &cr.state.State
+}
+
+// Save pushes the graphics state.  Implements the graphics.Device interface.
+func (cr *contentConverter) Save() {
+	cr.stack = append(cr.stack, cr.state)
+}
+
+// Restore pops the graphics state.  Implements the graphics.Device interface.
+func (cr *contentConverter) Restore() {
+	if len(cr.stack) > 0 {
+		cr.state = cr.stack[len(cr.stack)-1]
+		cr.stack = cr.stack[:len(cr.stack)-1]
+	}
+}
+
+// LoadFont returns the font of font dictionary `obj`.  Implements the graphics.Device interface.
+func (cr *contentConverter) LoadFont(obj core.PdfObject) *fontfile.Font {
+	return cr.d.loadFont(obj)
+}
+
+// SetExtGStateEntry sets the blend mode and soft mask of the graphics state.  Implements the graphics.Device
+// interface.
+func (cr *contentConverter) SetExtGStateEntry(key core.PdfObjectName, val core.PdfObject,
+	ctm contentstream.Matrix) {
+	switch key {
+	case "BM":
+		cr.state.blendMode = getBlendMode(val)
+	case "SMask":
+		cr.state.mask = ""
+		if smask, ok := core.TraceToDirectObject(val).(*core.PdfObjectDictionary); ok {
+			cr.state.mask = cr.softMask(smask, ctm)
+		}
+	}
+}
+
+// PaintPath adds path `p`.  Implements the graphics.Device interface.
+func (cr *contentConverter) PaintPath(p *graphics.Path, ctm contentstream.Matrix, fill bool,
+	rule graphics.FillRule, stroke bool) {
+	fillRule := ""
+	if fill {
+		fillRule = svgFillRule(rule)
+	}
+	cr.drawPath(p, ctm, fillRule, stroke)
+}
+
+// ClipPath intersects the clipping path with path `p`.  Implements the graphics.Device interface.
+func (cr *contentConverter) ClipPath(p *graphics.Path, ctm contentstream.Matrix, rule graphics.FillRule) {
+	cr.clipPath(fmt.Sprintf(`<path%s d="%s" clip-rule="%s"/>`, transformAttr(ctm), pathData(p),
+		svgFillRule(rule)))
+}
+
+// svgFillRule returns the value of the fill-rule and clip-rule attributes of fill rule `rule`.
+func svgFillRule(rule graphics.FillRule) string {
+	if rule == graphics.EvenOdd {
+		return "evenodd"
+	}
+	return "nonzero"
+}
+
+// wrapper returns the attributes of the group applying the clipping path and soft mask of the graphics state.
+func (cr *contentConverter) wrapper() string {
+	var attrs string
+	if cr.state.clip != "" {
+		attrs += fmt.Sprintf(` clip-path="url(#%s)"`, cr.state.clip)
+	}
+	if cr.state.mask != "" {
+		attrs += fmt.Sprintf(` mask="url(#%s)"`, cr.state.mask)
+	}
+	return attrs
+}
+
+// add adds `element` to the canvas, with the clipping path and soft mask of the graphics state.
+func (cr *contentConverter) add(element string) {
+	if cr.IsHidden() {
+		return
+	}
+	cr.dst.add(cr.wrapper(), element)
+}
+
+// blendAttr returns the attribute setting the blend mode of the graphics state, "" for normal.
+func (cr *contentConverter) blendAttr() string {
+	if cr.state.blendMode == "" {
+		return ""
+	}
+	return fmt.Sprintf(` style="mix-blend-mode:%s"`, cr.state.blendMode)
+}
+
+// drawPath adds path `p` in user space, transformed by `ctm`, filled with fill rule `fill` unless empty and
+// stroked if `stroke` is set.
+func (cr *contentConverter) drawPath(p *graphics.Path, ctm contentstream.Matrix, fill string, stroke bool) {
+	if p.IsEmpty() || fill == "" && !stroke || cr.IsHidden() {
+		return
+	}
+	attrs := " fill=\"none\""
+	if fill != "" {
+		attrs = cr.fillAttrs(ctm)
+		if fill == "evenodd" {
+			attrs += ` fill-rule="evenodd"`
+		}
+	}
+	if stroke {
+		attrs += cr.strokeAttrs(ctm, 1)
+	}
+	cr.add(fmt.Sprintf(`<path%s d="%s"%s%s/>`, transformAttr(ctm), pathData(p), attrs, cr.blendAttr()))
+}
+
+// strokeAttrs returns the attributes stroking an element drawn with matrix `m` with the stroking color and
+// the line style of the graphics state.  The line width and dash lengths are in user space, which is scaled by
+// `scale` in the coordinate system of the element.
+func (cr *contentConverter) strokeAttrs(m contentstream.Matrix, scale float64) string {
+	paint, ok := cr.paintAttr(cr.GS.ColorspaceStroking, cr.GS.ColorStroking, m)
+	if !ok {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, ` stroke="%s"`, paint)
+	if cr.state.LineWidth == 0 {
+		// The thinnest line that can be drawn.
+		b.WriteString(` stroke-width="1" vector-effect="non-scaling-stroke"`)
+	} else if w := cr.state.LineWidth * scale; w != 1 {
+		fmt.Fprintf(&b, ` stroke-width="%s"`, formatNumber(w))
+	}
+	switch cr.state.LineCap {
+	case 1:
+		b.WriteString(` stroke-linecap="round"`)
+	case 2:
+		b.WriteString(` stroke-linecap="square"`)
+	}
+	switch cr.state.LineJoin {
+	case 1:
+		b.WriteString(` stroke-linejoin="round"`)
+	case 2:
+		b.WriteString(` stroke-linejoin="bevel"`)
+	default:
+		if cr.state.MiterLimit != 4 && cr.state.MiterLimit >= 1 {
+			fmt.Fprintf(&b, ` stroke-miterlimit="%s"`, formatNumber(cr.state.MiterLimit))
+		}
+	}
+	if len(cr.state.Dash) > 0 {
+		dash := make([]float64, len(cr.state.Dash))
+		for i, v := range cr.state.Dash {
+			dash[i] = v * scale
+		}
+		fmt.Fprintf(&b, ` stroke-dasharray="%s"`, formatNumbers(dash, ","))
+		if cr.state.DashPhase != 0 {
+			fmt.Fprintf(&b, ` stroke-dashoffset="%s"`, formatNumber(cr.state.DashPhase*scale))
+		}
+	}
+	if cr.state.StrokeAlpha < 1 {
+		fmt.Fprintf(&b, ` stroke-opacity="%s"`, formatNumber(cr.state.StrokeAlpha))
+	}
+	return b.String()
+}
+
+// fillAttrs returns the attributes filling an element drawn with matrix `m` with the nonstroking color.
+func (cr *contentConverter) fillAttrs(m contentstream.Matrix) string {
+	paint, ok := cr.paintAttr(cr.GS.ColorspaceNonStroking, cr.GS.ColorNonStroking, m)
+	if !ok {
+		return ` fill="none"`
+	}
+	attrs := fmt.Sprintf(` fill="%s"`, paint)
+	if cr.state.FillAlpha < 1 {
+		attrs += fmt.Sprintf(` fill-opacity="%s"`, formatNumber(cr.state.FillAlpha))
+	}
+	return attrs
+}
+
+// clipPath intersects the clipping path with the clipping path of `element`, in the coordinate system of the
+// canvas.
+func (cr *contentConverter) clipPath(element string) {
+	id := cr.d.newID("c")
+	attrs := ""
+	if cr.state.clip != "" {
+		attrs = fmt.Sprintf(` clip-path="url(#%s)"`, cr.state.clip)
+	}
+	fmt.Fprintf(&cr.d.defs, "<clipPath id=\"%s\"%s>\n%s\n</clipPath>\n", id, attrs, element)
+	cr.state.clip = id
+}
+
+// blendModes maps the PDF blend modes to CSS blend modes.
+var blendModes = map[string]string{
+	"Multiply":   "multiply",
+	"Screen":     "screen",
+	"Overlay":    "overlay",
+	"Darken":     "darken",
+	"Lighten":    "lighten",
+	"ColorDodge": "color-dodge",
+	"ColorBurn":  "color-burn",
+	"HardLight":  "hard-light",
+	"SoftLight":  "soft-light",
+	"Difference": "difference",
+	"Exclusion":  "exclusion",
+	"Hue":        "hue",
+	"Saturation": "saturation",
+	"Color":      "color",
+	"Luminosity": "luminosity",
+}
+
+// getBlendMode returns the CSS blend mode of BM entry `obj`: of the first supported mode of an array of modes.
+func getBlendMode(obj core.PdfObject) string {
+	obj = core.TraceToDirectObject(obj)
+	names := []core.PdfObject{obj}
+	if arr, ok := obj.(*core.PdfObjectArray); ok {
+		names = *arr
+	}
+	for _, n := range names {
+		name, ok := core.TraceToDirectObject(n).(*core.PdfObjectName)
+		if !ok {
+			continue
+		}
+		if *name == "Normal" || *name == "Compatible" {
+			return ""
+		}
+		if mode, has := blendModes[string(*name)]; has {
+			return mode
+		}
+	}
+	return ""
+}
+
+// DrawShading adds shading `name` of the resources painted over the clipping region, as by the sh operator.
+// Implements the graphics.Device interface.
+func (cr *contentConverter) DrawShading(name core.PdfObjectName, ctm contentstream.Matrix) {
+	if cr.IsHidden() {
+		return
+	}
+	shading, found := cr.Resources.GetShadingByName(name)
+	if !found || shading == nil {
+		common.Log.Debug("Shading %s not found", name)
+		return
+	}
+	id, ok := cr.d.gradient(shading, ctm)
+	if !ok {
+		common.Log.Debug("Unsupported shading %s, skipping", name)
+		return
+	}
+
+	saved := cr.state.clip
+	if b := shading.BBox; b != nil {
+		cr.ClipRectangle(b.Llx, b.Lly, b.Urx, b.Ury, ctm)
+	}
+	a := cr.area
+	attrs := ""
+	if cr.state.FillAlpha < 1 {
+		attrs = fmt.Sprintf(` fill-opacity="%s"`, formatNumber(cr.state.FillAlpha))
+	}
+	cr.add(fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="url(#%s)"%s%s/>`,
+		formatNumber(a.Llx), formatNumber(a.Lly), formatNumber(a.Width()), formatNumber(a.Height()), id, attrs,
+		cr.blendAttr()))
+	cr.state.clip = saved
+}
+
+// DrawXObject adds XObject `name` of the resources, an image or a form.  Implements the graphics.Device interface.
+func (cr *contentConverter) DrawXObject(name core.PdfObjectName, ctm contentstream.Matrix) {
+	stream, xtype := cr.Resources.GetXObjectByName(name)
+	if stream == nil {
+		common.Log.Debug("XObject %s not found", name)
+		return
+	}
+	if !cr.IsVisible(stream.PdfObjectDictionary.Get("OC")) || cr.IsHidden() {
+		return
+	}
+
+	switch xtype {
+	case model.XObjectTypeImage:
+		id := cr.d.image(stream)
+		if id == "" {
+			common.Log.Debug("Invalid image %s, skipping", name)
+			return
+		}
+		cr.drawImage(id, ctm)
+	case model.XObjectTypeForm:
+		cr.drawForm(stream, ctm)
+	default:
+		common.Log.Debug("Unsupported XObject %s, skipping", name)
+	}
+}
+
+// DrawInlineImage adds inline image `inline`.  Implements the graphics.Device interface.
+func (cr *contentConverter) DrawInlineImage(inline *contentstream.ContentStreamInlineImage,
+	ctm contentstream.Matrix) {
+	if cr.IsHidden() {
+		return
+	}
+	id := cr.d.inlineImage(inline, cr.Resources)
+	if id == "" {
+		common.Log.Debug("Invalid inline image, skipping")
+		return
+	}
+	cr.drawImage(id, ctm)
+}
+
+// drawImage adds the image with definition `id` drawn onto the unit square transformed by `ctm`.  Stencil
+// masks are painted with the nonstroking color.
+func (cr *contentConverter) drawImage(id string, ctm contentstream.Matrix) {
+	// The first row of the image is at the top of the unit square.
+	m := contentstream.NewMatrix(1, 0, 0, -1, 0, 1).Mult(ctm)
+	if mask, isStencil := cr.d.masks[id]; isStencil {
+		cr.add(fmt.Sprintf(`<rect%s width="1" height="1"%s mask="url(#%s)"%s/>`, transformAttr(m),
+			cr.fillAttrs(m), mask, cr.blendAttr()))
+		return
+	}
+	attrs := ""
+	if cr.state.FillAlpha < 1 {
+		attrs = fmt.Sprintf(` opacity="%s"`, formatNumber(cr.state.FillAlpha))
+	}
+	cr.add(fmt.Sprintf(`<use%s xlink:href="#%s"%s%s/>`, transformAttr(m), id, attrs, cr.blendAttr()))
+}
+
+// drawForm adds form XObject `stream`, as a group if it is a transparency group with opacity, blend mode or
+// soft mask.
+func (cr *contentConverter) drawForm(stream *core.PdfObjectStream, ctm contentstream.Matrix) {
+	if cr.depth >= maxDepth {
+		common.Log.Debug("Forms nested too deeply, skipping")
+		return
+	}
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid form, skipping: %v", err)
+		return
+	}
+	content, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid form stream, skipping: %v", err)
+		return
+	}
+
+	formCTM := graphics.GetMatrix(xform.Matrix).Mult(ctm)
+	state := cr.state
+	if bbox := graphics.GetNumbers(xform.BBox); len(bbox) == 4 {
+		saved := cr.state.clip
+		cr.ClipRectangle(math.Min(bbox[0], bbox[2]), math.Min(bbox[1], bbox[3]),
+			math.Max(bbox[0], bbox[2]), math.Max(bbox[1], bbox[3]), formCTM)
+		state.clip, cr.state.clip = cr.state.clip, saved
+	}
+
+	gs := cr.GS
+	gs.CTM = contentstream.IdentityMatrix()
+
+	isGroup := false
+	if group, ok := core.TraceToDirectObject(xform.Group).(*core.PdfObjectDictionary); ok {
+		s, ok := core.TraceToDirectObject(group.Get("S")).(*core.PdfObjectName)
+		isGroup = ok && *s == "Transparency"
+	}
+	if !isGroup || cr.state.FillAlpha >= 1 && cr.state.blendMode == "" && cr.state.mask == "" {
+		// The form is converted directly, with the graphics state restored afterwards.
+		child := cr.child(cr.dst, xform.Resources, formCTM, state)
+		if err := child.Process(string(content), gs); err != nil {
+			common.Log.Debug("Error converting form: %v", err)
+		}
+		return
+	}
+
+	// Transparency groups are converted to a group, which is composited as a whole, clipped by the bounding box.
+	clip := state.clip
+	group := &canvas{}
+	state.FillAlpha, state.StrokeAlpha = 1, 1
+	state.blendMode = ""
+	state.mask = ""
+	state.clip = ""
+	child := cr.child(group, xform.Resources, formCTM, state)
+	if err := child.Process(string(content), gs); err != nil {
+		common.Log.Debug("Error converting transparency group: %v", err)
+	}
+	attrs := ""
+	if cr.state.FillAlpha < 1 {
+		attrs = fmt.Sprintf(` opacity="%s"`, formatNumber(cr.state.FillAlpha))
+	}
+	saved := cr.state.clip
+	cr.state.clip = clip
+	cr.add(fmt.Sprintf("<g%s%s>\n%s</g>", attrs, cr.blendAttr(), group.content()))
+	cr.state.clip = saved
+}
+
+// softMask adds the mask element of soft mask dictionary `smask` drawn with matrix `ctm` and returns its ID,
+// "" if invalid.
+func (cr *contentConverter) softMask(smask *core.PdfObjectDictionary, ctm contentstream.Matrix) string {
+	if cr.depth >= maxDepth {
+		common.Log.Debug("Soft masks nested too deeply, skipping")
+		return ""
+	}
+	stream, ok := core.TraceToDirectObject(smask.Get("G")).(*core.PdfObjectStream)
+	if !ok {
+		common.Log.Debug("Invalid soft mask group, skipping")
+		return ""
+	}
+	xform, err := model.NewXObjectFormFromStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid soft mask group, skipping: %v", err)
+		return ""
+	}
+	content, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid soft mask group, skipping: %v", err)
+		return ""
+	}
+	luminosity := true
+	if s, ok := core.TraceToDirectObject(smask.Get("S")).(*core.PdfObjectName); ok && *s == "Alpha" {
+		luminosity = false
+	}
+	if tr := core.TraceToDirectObject(smask.Get("TR")); tr != nil {
+		if name, ok := tr.(*core.PdfObjectName); !ok || *name != "Identity" {
+			common.Log.Debug("Soft mask transfer functions are not supported, ignoring")
+		}
+	}
+
+	a := cr.area
+	layer := &canvas{}
+	if bc := graphics.GetNumbers(smask.Get("BC")); luminosity && len(bc) > 0 {
+		// Luminosity masks are drawn over the backdrop color, black by default.
+		cs := model.PdfColorspace(model.NewPdfColorspaceDeviceGray())
+		if group, ok := core.TraceToDirectObject(xform.Group).(*core.PdfObjectDictionary); ok {
+			if obj := group.Get("CS"); obj != nil {
+				if groupCS, err := model.NewPdfColorspaceFromPdfObject(obj); err == nil {
+					cs = groupCS
+				}
+			}
+		}
+		if r, g, b, ok := graphics.FloatsToRGB(cs, bc); ok {
+			layer.add("", fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`,
+				formatNumber(a.Llx), formatNumber(a.Lly), formatNumber(a.Width()), formatNumber(a.Height()),
+				formatColor(r, g, b)))
+		}
+	}
+
+	formCTM := graphics.GetMatrix(xform.Matrix).Mult(ctm)
+	child := newContentConverter(cr.d, layer, xform.Resources, formCTM, a, newGraphicsState(), cr.depth+1)
+	if bbox := graphics.GetNumbers(xform.BBox); len(bbox) == 4 {
+		child.ClipRectangle(math.Min(bbox[0], bbox[2]), math.Min(bbox[1], bbox[3]),
+			math.Max(bbox[0], bbox[2]), math.Max(bbox[1], bbox[3]), formCTM)
+	}
+	if err := child.Process(string(content), graphics.DefaultProcessorState()); err != nil {
+		common.Log.Debug("Error converting soft mask: %v", err)
+	}
+
+	id := cr.d.newID("m")
+	attrs := ""
+	if !luminosity {
+		attrs = ` style="mask-type:alpha"`
+	}
+	fmt.Fprintf(&cr.d.defs, "<mask id=\"%s\" maskUnits=\"userSpaceOnUse\" x=\"%s\" y=\"%s\" width=\"%s\" "+
+		"height=\"%s\"%s>\n%s</mask>\n", id, formatNumber(a.Llx), formatNumber(a.Lly), formatNumber(a.Width()),
+		formatNumber(a.Height()), attrs, layer.content())
+	return id
+}
@@ -0,0 +1,202 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package svg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Converter converts a PDF page to SVG.
+type Converter struct {
+	// TextAsPaths converts text to the paths of the glyph outlines instead of text elements.  Text of fonts
+	// without glyph outlines, non-embedded fonts without substitute, is converted to text elements regardless.
+	TextAsPaths bool
+	// SkipAnnotations disables converting the appearances of the annotations of the page.
+	SkipAnnotations bool
+	// OCProperties are the optional content properties of the document, content of optional content groups
+	// which are OFF in their default configuration is not converted.  All content is converted if nil.
+	OCProperties *model.PdfOCProperties
+
+	page     *model.PdfPage
+	contents string
+}
+
+// New returns a Converter for converting PDF page `page`.
+func New(page *model.PdfPage) (*Converter, error) {
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{page: page, contents: contents}, nil
+}
+
+// document is the state of one conversion: the definitions referenced by the elements of the page.
+type document struct {
+	c *Converter
+	// defs holds the definitions and fontFaces the @font-face rules of the embedded fonts.
+	defs      bytes.Buffer
+	fontFaces bytes.Buffer
+	lastID    int
+
+	fonts        map[core.PdfObject]*fontfile.Font
+	families     map[*fontfile.Font]string
+	missingFonts map[*fontfile.Font]bool
+	// images holds the IDs of the image definitions by image stream, "" for invalid images, and masks the IDs
+	// of the masks of stencil mask images by image ID.
+	images map[*core.PdfObjectStream]string
+	masks  map[string]string
+	// paints holds the IDs of the gradient and pattern elements, "" for invalid patterns.
+	paints map[paintKey]string
+}
+
+// paintKey identifies a gradient or pattern element: the shading or pattern object, drawn with a matrix to
+// the coordinate system of the element using it, painted with a color for uncolored patterns.
+type paintKey struct {
+	obj    core.PdfObject
+	matrix contentstream.Matrix
+	color  string
+}
+
+// newID returns a new unique element ID starting with `prefix`.
+func (d *document) newID(prefix string) string {
+	d.lastID++
+	return fmt.Sprintf("%s%d", prefix, d.lastID)
+}
+
+// loadFont returns the font of font dictionary `obj`.
+func (d *document) loadFont(obj core.PdfObject) *fontfile.Font {
+	if font, has := d.fonts[obj]; has {
+		return font
+	}
+	font := fontfile.Load(obj)
+	d.fonts[obj] = font
+	return font
+}
+
+// canvas is a sequence of SVG elements.  Consecutive elements with the same clipping path and mask are grouped
+// in a g element applying them.
+type canvas struct {
+	buf bytes.Buffer
+	// wrapper holds the attributes of the open group, "" if none is open.
+	wrapper string
+}
+
+// add appends `element` to the canvas in a group with attributes `wrapper`, none if empty.
+func (c *canvas) add(wrapper, element string) {
+	if wrapper != c.wrapper {
+		c.close()
+		if wrapper != "" {
+			fmt.Fprintf(&c.buf, "<g%s>\n", wrapper)
+		}
+		c.wrapper = wrapper
+	}
+	c.buf.WriteString(element)
+	c.buf.WriteByte('\n')
+}
+
+// close closes the open group, if any.
+func (c *canvas) close() {
+	if c.wrapper != "" {
+		c.buf.WriteString("</g>\n")
+		c.wrapper = ""
+	}
+}
+
+// content returns the elements of the canvas.
+func (c *canvas) content() string {
+	c.close()
+	return c.buf.String()
+}
+
+// Write writes the page converted to an SVG document to `w`.  The document covers the crop box of the page,
+// rotated as displayed, with 1 user space unit per SVG pixel.  Invalid content is skipped over, so that what
+// can be converted is converted.
+func (c *Converter) Write(w io.Writer) error {
+	area, err := c.page.GetVisibleArea()
+	if err != nil {
+		return err
+	}
+	display, err := c.page.GetDisplayMatrix()
+	if err != nil {
+		return err
+	}
+	box, err := c.page.GetCropBox()
+	if err != nil {
+		return err
+	}
+	unit := c.page.GetUserUnit()
+	width, height := area.Width(), area.Height()
+
+	d := &document{
+		c:            c,
+		fonts:        map[core.PdfObject]*fontfile.Font{},
+		families:     map[*fontfile.Font]string{},
+		missingFonts: map[*fontfile.Font]bool{},
+		images:       map[*core.PdfObjectStream]string{},
+		masks:        map[string]string{},
+		paints:       map[paintKey]string{},
+	}
+
+	// The elements are in the default coordinate space of the page, which the root group maps to the SVG
+	// coordinate system with its origin at the top left corner of the page as displayed, with y pointing down.
+	page := &canvas{}
+	cr := newContentConverter(d, page, c.page.Resources, contentstream.IdentityMatrix(), box, newGraphicsState(), 0)
+	if err := cr.Process(c.contents, graphics.DefaultProcessorState()); err != nil {
+		common.Log.Debug("Error converting page contents: %v", err)
+	}
+	if !c.SkipAnnotations {
+		for _, annot := range c.page.Annotations {
+			d.convertAnnotation(page, annot, box)
+		}
+	}
+
+	device := contentstream.NewMatrix(display[0], display[1], display[2], display[3], display[4], display[5]).
+		Mult(contentstream.NewMatrix(unit, 0, 0, -unit, 0, height))
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(b, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" "+
+		"version=\"1.1\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		formatNumber(width), formatNumber(height), formatNumber(width), formatNumber(height))
+	if d.defs.Len() > 0 || d.fontFaces.Len() > 0 {
+		b.WriteString("<defs>\n")
+		if d.fontFaces.Len() > 0 {
+			fmt.Fprintf(b, "<style type=\"text/css\"><![CDATA[\n%s]]></style>\n", d.fontFaces.String())
+		}
+		b.Write(d.defs.Bytes())
+		b.WriteString("</defs>\n")
+	}
+	fmt.Fprintf(b, "<g%s>\n", transformAttr(device))
+	b.WriteString(page.content())
+	b.WriteString("</g>\n</svg>\n")
+	return b.Flush()
+}
+
+// convertAnnotation converts the normal appearance of annotation `annot` to `page`, if it is displayed.
+func (d *document) convertAnnotation(page *canvas, annot *model.PdfAnnotation, box *model.PdfRectangle) {
+	stream, fit, ok := graphics.GetAppearance(annot)
+	if !ok {
+		return
+	}
+
+	cr := newContentConverter(d, page, d.c.page.Resources, contentstream.IdentityMatrix(), box,
+		newGraphicsState(), 0)
+	if !cr.IsVisible(annot.OC) {
+		return
+	}
+	cr.GS = graphics.DefaultProcessorState()
+	cr.drawForm(stream, fit)
+}
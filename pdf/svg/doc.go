@@ -0,0 +1,19 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package svg converts PDF pages to SVG documents.  Paths are converted to path elements, clipping paths to
+// clipPath elements, images to image elements with data URIs, axial and radial shadings to gradients, tiling
+// patterns to pattern elements and soft masks to mask elements.  Text is converted to text elements, drawn with
+// the embedded TrueType and OpenType fonts of the page where they map the text to their glyphs, or to the paths
+// of the glyph outlines.
+//
+// Example:
+//
+//	c, err := svg.New(page)
+//	if err != nil {
+//		return err
+//	}
+//	err = c.Write(w)
+package svg
@@ -0,0 +1,400 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/renderer"
+)
+
+// gradientStops is the number of stops of gradients sampled from shading functions which are not linear.
+const gradientStops = 33
+
+// maxTileCopies is the maximum number of copies of a tiling pattern cell drawn in one tile.
+const maxTileCopies = 64
+
+// formatColor formats the color (r, g, b) with components in the range [0, 1] as #rrggbb.
+func formatColor(r, g, b float64) string {
+	return fmt.Sprintf("#%02x%02x%02x", int(graphics.Clamp01(r)*255+0.5), int(graphics.Clamp01(g)*255+0.5),
+		int(graphics.Clamp01(b)*255+0.5))
+}
+
+// paintAttr returns the paint of `color` in colorspace `cs` for an element drawn with matrix `m`: a color or
+// the URL of a gradient or pattern.  Returns false if nothing is to be painted.
+func (cr *contentConverter) paintAttr(cs model.PdfColorspace, color model.PdfColor,
+	m contentstream.Matrix) (string, bool) {
+	if cr.color != "" {
+		return cr.color, true
+	}
+	if cs == nil || color == nil {
+		return "#000000", true
+	}
+	if patternCS, ok := cs.(*model.PdfColorspaceSpecialPattern); ok {
+		pcolor, ok := color.(*model.PdfColorPattern)
+		if !ok {
+			return "", false
+		}
+		return cr.patternPaint(patternCS, pcolor, m)
+	}
+	r, g, b, ok := graphics.ColorToRGB(cs, color)
+	if !ok {
+		return "#000000", true
+	}
+	return formatColor(r, g, b), true
+}
+
+// patternPaint returns the URL of the gradient or pattern element of pattern color `color` for an element
+// drawn with matrix `m`.  Returns false if the pattern is invalid or unsupported.
+func (cr *contentConverter) patternPaint(cs *model.PdfColorspaceSpecialPattern, color *model.PdfColorPattern,
+	m contentstream.Matrix) (string, bool) {
+	pattern, found := cr.Resources.GetPatternByName(color.PatternName)
+	if !found || pattern == nil {
+		common.Log.Debug("Pattern %s not found", color.PatternName)
+		return "", false
+	}
+	inv, ok := m.Inverse()
+	if !ok {
+		return "", false
+	}
+
+	// The pattern matrix maps pattern space to the default coordinate space of the content stream, the
+	// gradients and patterns are in the coordinate system of the element.
+	switch {
+	case pattern.IsShading():
+		sp := pattern.GetAsShadingPattern()
+		if sp.Shading == nil {
+			return "", false
+		}
+		id, ok := cr.d.gradient(sp.Shading, graphics.GetMatrix(sp.Matrix).Mult(cr.Base).Mult(inv))
+		if !ok {
+			common.Log.Debug("Unsupported shading pattern %s, skipping", color.PatternName)
+			return "", false
+		}
+		return fmt.Sprintf("url(#%s)", id), true
+	case pattern.IsTiling():
+		tp := pattern.GetAsTilingPattern()
+		paint := ""
+		if !tp.IsColored() {
+			if cs.UnderlyingCS == nil || color.Color == nil {
+				common.Log.Debug("Uncolored pattern without color, skipping")
+				return "", false
+			}
+			r, g, b, ok := graphics.ColorToRGB(cs.UnderlyingCS, color.Color)
+			if !ok {
+				return "", false
+			}
+			paint = formatColor(r, g, b)
+		}
+		id, ok := cr.tilingPattern(tp, graphics.GetMatrix(tp.Matrix).Mult(cr.Base).Mult(inv), paint)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("url(#%s)", id), true
+	}
+	return "", false
+}
+
+// gradient adds the gradient element of axial or radial shading `shading`, drawn with matrix `m` from shading
+// space to the coordinate system of the element it is used by, and returns its ID.  Returns false for other
+// shading types.
+func (d *document) gradient(shading *model.PdfShading, m contentstream.Matrix) (string, bool) {
+	key := paintKey{obj: shading.GetContainingPdfObject(), matrix: m}
+	if id, has := d.paints[key]; has {
+		return id, id != ""
+	}
+	id, ok := d.addGradient(shading, m)
+	d.paints[key] = id
+	return id, ok
+}
+
+// addGradient adds the gradient element of `shading` drawn with matrix `m`, as gradient.
+func (d *document) addGradient(shading *model.PdfShading, m contentstream.Matrix) (string, bool) {
+	var coords []float64
+	var functions []model.PdfFunction
+	var extend [2]bool
+	t0, t1 := 0.0, 1.0
+	switch t := shading.GetContext().(type) {
+	case *model.PdfShadingType2:
+		coords = graphics.GetNumbers(t.Coords)
+		if len(coords) != 4 || coords[0] == coords[2] && coords[1] == coords[3] {
+			return "", false
+		}
+		functions, extend = t.Function, graphics.GetExtend(t.Extend)
+		if domain := graphics.GetNumbers(t.Domain); len(domain) == 2 {
+			t0, t1 = domain[0], domain[1]
+		}
+	case *model.PdfShadingType3:
+		coords = graphics.GetNumbers(t.Coords)
+		if len(coords) != 6 {
+			return "", false
+		}
+		functions, extend = t.Function, graphics.GetExtend(t.Extend)
+		if domain := graphics.GetNumbers(t.Domain); len(domain) == 2 {
+			t0, t1 = domain[0], domain[1]
+		}
+	default:
+		return "", false
+	}
+	if len(functions) == 0 {
+		return "", false
+	}
+
+	// Linear functions are given by the colors at their ends, others are sampled.
+	n := gradientStops
+	if f, ok := functions[0].(*model.PdfFunctionType2); ok && len(functions) == 1 && f.N == 1 {
+		n = 2
+	}
+	stops := make([]string, n)
+	for i := range stops {
+		t := t0 + (t1-t0)*float64(i)/float64(n-1)
+		vals := []float64{}
+		for _, f := range functions {
+			out, err := f.Evaluate([]float64{t})
+			if err != nil {
+				common.Log.Debug("Failed evaluating shading function: %v", err)
+				return "", false
+			}
+			vals = append(vals, out...)
+		}
+		r, g, b, ok := graphics.FloatsToRGB(shading.ColorSpace, vals)
+		if !ok {
+			return "", false
+		}
+		stops[i] = formatColor(r, g, b)
+	}
+
+	id := d.newID("g")
+	var b bytes.Buffer
+	if len(coords) == 4 {
+		// The parts of the gradient vector beyond unextended ends are transparent: the vector is tripled, with
+		// the shading in its middle third.
+		x0, y0, x1, y1 := coords[0], coords[1], coords[2], coords[3]
+		lo, hi := 0.0, 1.0
+		if !extend[0] || !extend[1] {
+			dx, dy := x1-x0, y1-y0
+			x0, y0, x1, y1 = x0-dx, y0-dy, x1+dx, y1+dy
+			lo, hi = 1.0/3, 2.0/3
+		}
+		fmt.Fprintf(&b, "<linearGradient id=\"%s\" gradientUnits=\"userSpaceOnUse\" x1=\"%s\" y1=\"%s\" "+
+			"x2=\"%s\" y2=\"%s\"%s>\n", id, formatNumber(x0), formatNumber(y0), formatNumber(x1), formatNumber(y1),
+			gradientTransformAttr(m))
+		writeStops(&b, stops, lo, hi, extend)
+		b.WriteString("</linearGradient>\n")
+	} else {
+		// The start circle is the focal circle, the end circle is extended like the gradient vector of axial
+		// shadings, doubling it.
+		x0, y0, r0, x1, y1, r1 := coords[0], coords[1], coords[2], coords[3], coords[4], coords[5]
+		hi := 1.0
+		if !extend[1] && r0+2*(r1-r0) >= 0 {
+			x1, y1, r1 = x0+2*(x1-x0), y0+2*(y1-y0), r0+2*(r1-r0)
+			hi = 0.5
+		}
+		fmt.Fprintf(&b, "<radialGradient id=\"%s\" gradientUnits=\"userSpaceOnUse\" cx=\"%s\" cy=\"%s\" r=\"%s\" "+
+			"fx=\"%s\" fy=\"%s\" fr=\"%s\"%s>\n", id, formatNumber(x1), formatNumber(y1), formatNumber(r1),
+			formatNumber(x0), formatNumber(y0), formatNumber(r0), gradientTransformAttr(m))
+		writeStops(&b, stops, 0, hi, [2]bool{true, extend[1]})
+		b.WriteString("</radialGradient>\n")
+	}
+	d.defs.Write(b.Bytes())
+	return id, true
+}
+
+// writeStops writes the stop elements of the colors `stops`, evenly spaced from offset `lo` to `hi`.  The
+// gradient is transparent beyond the ends which are not extended.
+func writeStops(b *bytes.Buffer, stops []string, lo, hi float64, extend [2]bool) {
+	if !extend[0] && lo > 0 {
+		fmt.Fprintf(b, "<stop offset=\"%s\" stop-color=\"%s\" stop-opacity=\"0\"/>\n", formatNumber(lo), stops[0])
+	}
+	for i, stop := range stops {
+		offset := lo + (hi-lo)*float64(i)/float64(len(stops)-1)
+		fmt.Fprintf(b, "<stop offset=\"%s\" stop-color=\"%s\"/>\n", formatNumber(offset), stop)
+	}
+	if !extend[1] && hi < 1 {
+		fmt.Fprintf(b, "<stop offset=\"%s\" stop-color=\"%s\" stop-opacity=\"0\"/>\n", formatNumber(hi),
+			stops[len(stops)-1])
+	}
+}
+
+// gradientTransformAttr returns the gradientTransform attribute of matrix `m`, "" for the identity matrix.
+func gradientTransformAttr(m contentstream.Matrix) string {
+	if m == contentstream.IdentityMatrix() {
+		return ""
+	}
+	return fmt.Sprintf(` gradientTransform="matrix(%s)"`, formatMatrix(m))
+}
+
+// tilingPattern adds the pattern element of tiling pattern `tp`, drawn with matrix `m` from pattern space to
+// the coordinate system of the element it is used by, and returns its ID.  Uncolored patterns are painted
+// with `color`.  Returns false if the pattern is invalid.
+func (cr *contentConverter) tilingPattern(tp *model.PdfTilingPattern, m contentstream.Matrix,
+	color string) (string, bool) {
+	key := paintKey{obj: tp.GetContainingPdfObject(), matrix: m, color: color}
+	if id, has := cr.d.paints[key]; has {
+		return id, id != ""
+	}
+	id, ok := cr.addTilingPattern(tp, m, color)
+	cr.d.paints[key] = id
+	return id, ok
+}
+
+// addTilingPattern adds the pattern element of tiling pattern `tp` drawn with matrix `m`, as tilingPattern.
+func (cr *contentConverter) addTilingPattern(tp *model.PdfTilingPattern, m contentstream.Matrix,
+	color string) (string, bool) {
+	if cr.depth >= maxDepth {
+		common.Log.Debug("Patterns nested too deeply, skipping")
+		return "", false
+	}
+	if tp.BBox == nil || tp.XStep == nil || tp.YStep == nil {
+		common.Log.Debug("Invalid tiling pattern, skipping")
+		return "", false
+	}
+	xstep, ystep := math.Abs(float64(*tp.XStep)), math.Abs(float64(*tp.YStep))
+	if xstep == 0 || ystep == 0 {
+		common.Log.Debug("Invalid tiling pattern steps, skipping")
+		return "", false
+	}
+	content, err := tp.GetContentStream()
+	if err != nil {
+		common.Log.Debug("Invalid tiling pattern stream, skipping: %v", err)
+		return "", false
+	}
+
+	// The cell is drawn in pattern space, clipped by the bounding box.
+	bbox := tp.BBox
+	cell := &canvas{}
+	child := newContentConverter(cr.d, cell, tp.Resources, contentstream.IdentityMatrix(), bbox,
+		newGraphicsState(), cr.depth+1)
+	child.color = color
+	child.ClipRectangle(bbox.Llx, bbox.Lly, bbox.Urx, bbox.Ury, contentstream.IdentityMatrix())
+	if err := child.Process(string(content), graphics.DefaultProcessorState()); err != nil {
+		common.Log.Debug("Error converting tiling pattern: %v", err)
+		return "", false
+	}
+
+	// The tile at the origin is covered by the copies of the cell whose bounding boxes overlap it.
+	id := cr.d.newID("p")
+	cellID := cr.d.newID("t")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<pattern id=\"%s\" patternUnits=\"userSpaceOnUse\" x=\"0\" y=\"0\" width=\"%s\" "+
+		"height=\"%s\"%s>\n", id, formatNumber(xstep), formatNumber(ystep), patternTransformAttr(m))
+	fmt.Fprintf(&b, "<g id=\"%s\">\n%s</g>\n", cellID, cell.content())
+	i0, i1 := math.Ceil(-bbox.Urx/xstep), math.Floor((xstep-bbox.Llx)/xstep)
+	j0, j1 := math.Ceil(-bbox.Ury/ystep), math.Floor((ystep-bbox.Lly)/ystep)
+	copies := 0
+	for j := j0; j <= j1; j++ {
+		for i := i0; i <= i1 && copies < maxTileCopies; i++ {
+			if i == 0 && j == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "<use xlink:href=\"#%s\" transform=\"translate(%s %s)\"/>\n", cellID,
+				formatNumber(i*xstep), formatNumber(j*ystep))
+			copies++
+		}
+	}
+	b.WriteString("</pattern>\n")
+	cr.d.defs.Write(b.Bytes())
+	return id, true
+}
+
+// patternTransformAttr returns the patternTransform attribute of matrix `m`, "" for the identity matrix.
+func patternTransformAttr(m contentstream.Matrix) string {
+	if m == contentstream.IdentityMatrix() {
+		return ""
+	}
+	return fmt.Sprintf(` patternTransform="matrix(%s)"`, formatMatrix(m))
+}
+
+// image returns the ID of the image element of image XObject `stream`, "" if invalid.
+func (d *document) image(stream *core.PdfObjectStream) string {
+	if id, has := d.images[stream]; has {
+		return id
+	}
+	id := ""
+	if uri, ok := jpegDataURI(stream); ok {
+		id = d.addImage(uri, false)
+	} else if img, err := renderer.DecodeImage(stream); err == nil {
+		id = d.addDecodedImage(img)
+	} else {
+		common.Log.Debug("Failed decoding image: %v", err)
+	}
+	d.images[stream] = id
+	return id
+}
+
+// inlineImage returns the ID of the image element of inline image `inline`, "" if invalid.
+func (d *document) inlineImage(inline *contentstream.ContentStreamInlineImage,
+	resources *model.PdfPageResources) string {
+	img, err := renderer.DecodeInlineImage(inline, resources)
+	if err != nil {
+		common.Log.Debug("Failed decoding inline image: %v", err)
+		return ""
+	}
+	return d.addDecodedImage(img)
+}
+
+// addDecodedImage adds the image element of decoded image `img`, a stencil mask if it is an *image.Alpha, and
+// returns its ID.
+func (d *document) addDecodedImage(img image.Image) string {
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		common.Log.Debug("Failed encoding image: %v", err)
+		return ""
+	}
+	_, isStencil := img.(*image.Alpha)
+	return d.addImage("data:image/png;base64,"+base64.StdEncoding.EncodeToString(b.Bytes()), isStencil)
+}
+
+// addImage adds the image element of the image with data URI `uri`, drawn onto the unit square, and returns
+// its ID.  Stencil masks are added with a mask element for painting them.
+func (d *document) addImage(uri string, isStencil bool) string {
+	id := d.newID("i")
+	fmt.Fprintf(&d.defs, "<image id=\"%s\" width=\"1\" height=\"1\" preserveAspectRatio=\"none\" "+
+		"xlink:href=\"%s\"/>\n", id, uri)
+	if isStencil {
+		// The painted pixels of stencil masks are white, so that they are masked by luminance.
+		mask := d.newID("m")
+		fmt.Fprintf(&d.defs, "<mask id=\"%s\" maskUnits=\"userSpaceOnUse\" x=\"0\" y=\"0\" width=\"1\" "+
+			"height=\"1\">\n<use xlink:href=\"#%s\"/>\n</mask>\n", mask, id)
+		d.masks[id] = mask
+	}
+	return id
+}
+
+// jpegDataURI returns the data URI of the JPEG data of DCT encoded image XObject `stream`, if it can be shown
+// as is: a gray or RGB image without masks or decode array.
+func jpegDataURI(stream *core.PdfObjectStream) (string, bool) {
+	dict := stream.PdfObjectDictionary
+	filter, ok := core.TraceToDirectObject(dict.Get("Filter")).(*core.PdfObjectName)
+	if arr, isArray := core.TraceToDirectObject(dict.Get("Filter")).(*core.PdfObjectArray); isArray && len(*arr) == 1 {
+		filter, ok = core.TraceToDirectObject((*arr)[0]).(*core.PdfObjectName)
+	}
+	if !ok || *filter != "DCTDecode" {
+		return "", false
+	}
+	cs, ok := core.TraceToDirectObject(dict.Get("ColorSpace")).(*core.PdfObjectName)
+	if !ok || *cs != "DeviceRGB" && *cs != "DeviceGray" {
+		return "", false
+	}
+	for _, key := range []core.PdfObjectName{"SMask", "Mask", "Decode", "ImageMask"} {
+		if obj := core.TraceToDirectObject(dict.Get(key)); obj != nil {
+			if b, isBool := obj.(*core.PdfObjectBool); !isBool || bool(*b) {
+				return "", false
+			}
+		}
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(stream.Stream), true
+}
@@ -0,0 +1,42 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package svg
+
+import (
+	"bytes"
+
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+)
+
+// pathData returns the path data of the d attribute of a path element drawing path `p`.
+func pathData(p *graphics.Path) string {
+	var b bytes.Buffer
+	command := func(cmd byte, pts ...graphics.Point) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteByte(cmd)
+		coords := make([]float64, 0, 2*len(pts))
+		for _, pt := range pts {
+			coords = append(coords, pt.X, pt.Y)
+		}
+		b.WriteString(formatNumbers(coords, " "))
+	}
+	for _, sp := range p.Subpaths {
+		command('M', sp.Start)
+		for _, seg := range sp.Segments {
+			if seg.Curve {
+				command('C', seg.C1, seg.C2, seg.End)
+			} else {
+				command('L', seg.End)
+			}
+		}
+		if sp.Closed {
+			command('Z')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,207 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+func init() {
+	common.SetLogger(common.NewConsoleLogger(common.LogLevelDebug))
+}
+
+// newTestPage returns a `width` by `height` page drawn by `content` with resources `res`, or none if nil.
+func newTestPage(width, height float64, content string, res *model.PdfPageResources) *model.PdfPage {
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: width, Ury: height}
+	page.Resources = res
+	if res == nil {
+		page.Resources = model.NewPdfPageResources()
+	}
+	page.AddContentStreamByString(content)
+	return page
+}
+
+// convertTestPage converts `page` with converter options set by `setup`, if not nil, and checks that the
+// result is well-formed XML.
+func convertTestPage(t *testing.T, page *model.PdfPage, setup func(c *Converter)) string {
+	c, err := New(page)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if setup != nil {
+		setup(c)
+	}
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Invalid XML: %v\n%s", err, buf.String())
+		}
+	}
+	return buf.String()
+}
+
+// checkContains checks that `svg` contains each of `parts`.
+func checkContains(t *testing.T, svg string, parts ...string) {
+	for _, part := range parts {
+		if !strings.Contains(svg, part) {
+			t.Errorf("Missing %q in:\n%s", part, svg)
+		}
+	}
+}
+
+func TestConvertPaths(t *testing.T) {
+	page := newTestPage(100, 100, `
+1 0 0 rg 10 10 30 30 re f
+0 0 1 rg 50 10 m 90 10 l 90 50 l 50 50 l h 60 20 m 80 20 l 80 40 l 60 40 l h f*
+0 1 0 RG 4 w 1 J [2 3] 1 d 10 60 m 90 90 l S`, nil)
+	svg := convertTestPage(t, page, nil)
+
+	checkContains(t, svg,
+		`width="100" height="100" viewBox="0 0 100 100"`,
+		`transform="matrix(1 0 0 -1 0 100)"`,
+		`d="M10 10 L40 10 L40 40 L10 40 Z"`,
+		`fill="#ff0000"`,
+		`fill-rule="evenodd"`,
+		`stroke="#00ff00"`,
+		`stroke-width="4"`,
+		`stroke-linecap="round"`,
+		`stroke-dasharray="2,3"`,
+		`stroke-dashoffset="1"`)
+}
+
+func TestConvertClip(t *testing.T) {
+	page := newTestPage(100, 100, `
+q 20 20 40 40 re W n 1 0 0 rg 0 0 100 100 re f Q
+0 0 1 rg 70 70 20 20 re f`, nil)
+	svg := convertTestPage(t, page, nil)
+
+	checkContains(t, svg, `<clipPath id="`, `clip-path="url(#`)
+	// The clipping path is restored with the graphics state.
+	last := svg[strings.LastIndex(svg, "<path"):]
+	if strings.Contains(svg[strings.LastIndex(svg, "</g>\n<path"):], "clip-path") ||
+		!strings.Contains(last, `fill="#0000ff"`) {
+		t.Errorf("Blue square clipped:\n%s", svg)
+	}
+}
+
+func TestConvertShading(t *testing.T) {
+	fn := core.MakeDict()
+	fn.Set("FunctionType", core.MakeInteger(2))
+	fn.Set("Domain", core.MakeArrayFromFloats([]float64{0, 1}))
+	fn.Set("C0", core.MakeArrayFromFloats([]float64{1, 0, 0}))
+	fn.Set("C1", core.MakeArrayFromFloats([]float64{0, 0, 1}))
+	fn.Set("N", core.MakeFloat(1))
+	axial := core.MakeDict()
+	axial.Set("ShadingType", core.MakeInteger(2))
+	axial.Set("ColorSpace", core.MakeName("DeviceRGB"))
+	axial.Set("Coords", core.MakeArrayFromFloats([]float64{0, 0, 100, 0}))
+	axial.Set("Function", fn)
+	radial := core.MakeDict()
+	radial.Set("ShadingType", core.MakeInteger(3))
+	radial.Set("ColorSpace", core.MakeName("DeviceRGB"))
+	radial.Set("Coords", core.MakeArrayFromFloats([]float64{50, 75, 0, 50, 75, 25}))
+	radial.Set("Function", fn)
+	res := model.NewPdfPageResources()
+	res.SetShadingByName("Sh1", axial)
+	res.SetShadingByName("Sh2", radial)
+
+	page := newTestPage(100, 100, `q 0 0 100 50 re W n /Sh1 sh Q q 0 50 100 50 re W n /Sh2 sh Q`, res)
+	svg := convertTestPage(t, page, nil)
+
+	checkContains(t, svg, `<linearGradient id="`, `<radialGradient id="`, `stop-color="#ff0000"`,
+		`stop-color="#0000ff"`, `gradientUnits="userSpaceOnUse"`)
+}
+
+func TestConvertInlineImage(t *testing.T) {
+	page := newTestPage(100, 100, `
+q 80 0 0 40 10 30 cm
+BI /W 2 /H 1 /CS /RGB /BPC 8 /F /AHx ID ff00000000ff> EI
+Q`, nil)
+	svg := convertTestPage(t, page, nil)
+
+	checkContains(t, svg, `<image `, `xlink:href="data:image/png;base64,`, `preserveAspectRatio="none"`,
+		`<use transform="matrix(80 0 0 -40 10 70)" xlink:href="#`)
+}
+
+func TestConvertText(t *testing.T) {
+	res := model.NewPdfPageResources()
+	res.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+	page := newTestPage(100, 100, `BT /F1 20 Tf 10 40 Td (A & B) Tj 3 Tr 0 -20 Td (hidden) Tj ET`, res)
+
+	svg := convertTestPage(t, page, nil)
+	checkContains(t, svg, `<text xml:space="preserve"`, `font-family="Helvetica, Arial, sans-serif"`,
+		`font-size="20"`, `>A &amp; B</text>`, `fill="none"`, `>hidden</text>`)
+
+	data, err := ioutil.ReadFile("../../testfiles/roboto/Roboto-Regular.ttf")
+	if err != nil {
+		t.Skipf("Font not available: %v", err)
+	}
+	if err := fontfile.RegisterSubstitute("Helvetica", data); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	svg = convertTestPage(t, page, func(c *Converter) { c.TextAsPaths = true })
+	if strings.Contains(svg, "A &amp; B") {
+		t.Errorf("Text converted to text element:\n%s", svg)
+	}
+	// Invisible text remains searchable.
+	checkContains(t, svg, `>hidden</text>`)
+}
+
+func TestConvertRotated(t *testing.T) {
+	page := newTestPage(100, 200, `1 0 0 rg 0 0 50 100 re f`, nil)
+	rotate := int64(90)
+	page.Rotate = &rotate
+
+	svg := convertTestPage(t, page, nil)
+	checkContains(t, svg, `width="200" height="100" viewBox="0 0 200 100"`)
+}
+
+func TestConvertAnnotation(t *testing.T) {
+	appearance, err := core.MakeStream([]byte("1 0 0 rg 0 0 10 10 re f"), nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	appearance.PdfObjectDictionary.Set("Type", core.MakeName("XObject"))
+	appearance.PdfObjectDictionary.Set("Subtype", core.MakeName("Form"))
+	appearance.PdfObjectDictionary.Set("BBox", core.MakeArrayFromFloats([]float64{0, 0, 10, 10}))
+	ap := core.MakeDict()
+	ap.Set("N", appearance)
+
+	square := model.NewPdfAnnotationSquare()
+	square.Rect = core.MakeArrayFromFloats([]float64{50, 50, 90, 90})
+	square.AP = ap
+
+	page := newTestPage(100, 100, "", nil)
+	page.Annotations = append(page.Annotations, square.PdfAnnotation)
+
+	svg := convertTestPage(t, page, nil)
+	checkContains(t, svg, `fill="#ff0000"`)
+
+	svg = convertTestPage(t, page, func(c *Converter) { c.SkipAnnotations = true })
+	if strings.Contains(svg, `fill="#ff0000"`) {
+		t.Errorf("Annotation converted:\n%s", svg)
+	}
+}
@@ -0,0 +1,248 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/internal/fontfile"
+	"github.com/unidoc/unidoc/pdf/internal/graphics"
+)
+
+// textRun holds the characters of shown text converted to a text element, with their positions in the
+// coordinate system of the element.
+type textRun struct {
+	text   []rune
+	xs, ys []float64
+}
+
+// BeginText starts a text object.  Implements the graphics.Device interface.
+func (cr *contentConverter) BeginText() {
+	cr.textClip = nil
+}
+
+// EndText ends a text object, adding the glyphs shown with a clipping text rendering mode to the clipping path.
+// Implements the graphics.Device interface.
+func (cr *contentConverter) EndText() {
+	if cr.textClip != nil {
+		cr.clipPath(strings.TrimSuffix(cr.textClip.String(), "\n"))
+		cr.textClip = nil
+	}
+}
+
+// ShowText converts the glyphs `glyphs` of font `font`, shown from text matrix `tm`.  Implements the
+// graphics.Device interface.
+func (cr *contentConverter) ShowText(font *fontfile.Font, tm contentstream.Matrix, glyphs []graphics.Glyph,
+	ctm contentstream.Matrix) {
+	// The glyphs are converted to a text element, positioned relative to the text matrix at the start, and to
+	// a path of the glyph outlines.
+	run := &textRun{}
+	outlines := &graphics.Path{}
+	for _, g := range glyphs {
+		cr.addGlyph(font, g.Code, g.Trm, ctm, run, outlines, g.X, g.Y, g.Advance)
+	}
+
+	th := cr.state.Scaling / 100
+	if len(run.text) > 0 && th != 0 && cr.state.FontSize != 0 {
+		// Text elements are drawn with y pointing down.
+		m := contentstream.NewMatrix(1, 0, 0, -1, 0, 0).
+			Mult(contentstream.NewMatrix(th, 0, 0, 1, 0, cr.state.Rise)).Mult(tm)
+		cr.addText(font, run, m.Mult(ctm), m.ScalingFactor())
+	}
+	if !outlines.IsEmpty() {
+		cr.addTextPath(outlines, ctm)
+	}
+}
+
+// addGlyph adds the glyph of character code `code`, with matrix `trm` from text space for a font size of 1 to
+// user space, to the text run `run` at position (x, y) with advance `advance`, or to the glyph outlines
+// `outlines`.  The glyphs of Type 3 fonts are converted directly.
+func (cr *contentConverter) addGlyph(font *fontfile.Font, code int, trm, ctm contentstream.Matrix, run *textRun,
+	outlines *graphics.Path, x, y, advance float64) {
+	mode := cr.state.RenderMode
+	if font.Type3 != nil {
+		if mode != graphics.TextInvisible && mode != graphics.TextClip && !cr.IsHidden() {
+			cr.drawType3Glyph(font.Type3, code, trm, ctm)
+		}
+		return
+	}
+
+	// Glyphs are drawn as text if it shows them as in the font, otherwise by their outlines if the font has
+	// any.  Invisible text is always converted to text, so that it can be selected.
+	data, _ := font.WebFont()
+	asPath := cr.d.c.TextAsPaths || data != nil && !font.HasUnicodeGlyph(code)
+	text := []rune(font.Unicode(code))
+	if mode != graphics.TextInvisible && (asPath || len(text) == 0) {
+		if outline, ok := font.Glyph(code); ok {
+			outlines.AppendOutline(outline, trm)
+			return
+		}
+	}
+	if len(text) == 0 {
+		if !cr.d.missingFonts[font] {
+			common.Log.Debug("No glyph outlines or text for codes of font %q, text not converted", font.BaseFont)
+			cr.d.missingFonts[font] = true
+		}
+		return
+	}
+	for i, r := range text {
+		run.text = append(run.text, r)
+		run.xs = append(run.xs, x+advance*float64(i)/float64(len(text)))
+		run.ys = append(run.ys, -y)
+	}
+}
+
+// addText adds the text element of text run `run` in font `font`, drawn with matrix `m`, which scales user
+// space by `scale`.
+func (cr *contentConverter) addText(font *fontfile.Font, run *textRun, m contentstream.Matrix, scale float64) {
+	var b strings.Builder
+	b.WriteString(`<text xml:space="preserve"`)
+	b.WriteString(transformAttr(m))
+	b.WriteString(cr.d.fontAttrs(font))
+	fmt.Fprintf(&b, ` font-size="%s" x="%s"`, formatNumber(cr.state.FontSize), formatNumbers(run.xs, " "))
+	sameY := true
+	for _, y := range run.ys {
+		sameY = sameY && y == run.ys[0]
+	}
+	if sameY {
+		fmt.Fprintf(&b, ` y="%s"`, formatNumber(run.ys[0]))
+	} else {
+		fmt.Fprintf(&b, ` y="%s"`, formatNumbers(run.ys, " "))
+	}
+	attrs := b.String()
+
+	var text bytes.Buffer
+	xml.EscapeText(&text, []byte(string(run.text)))
+
+	mode := cr.state.RenderMode
+	switch mode {
+	case graphics.TextFill, graphics.TextFillClip:
+		attrs += cr.fillAttrs(m)
+	case graphics.TextStroke, graphics.TextStrokeClip:
+		attrs += ` fill="none"` + cr.strokeAttrs(m, 1/scale)
+	case graphics.TextFillStroke, graphics.TextFillStrokeClip:
+		attrs += cr.fillAttrs(m) + cr.strokeAttrs(m, 1/scale)
+	case graphics.TextInvisible:
+		// Invisible text is kept, so that it can be selected.
+		attrs += ` fill="none"`
+	}
+	if mode != graphics.TextClip {
+		cr.add(fmt.Sprintf("%s%s>%s</text>", attrs, cr.blendAttr(), text.String()))
+	}
+	if mode >= graphics.TextFillClip {
+		cr.addTextClip(fmt.Sprintf("%s>%s</text>", b.String(), text.String()))
+	}
+}
+
+// addTextPath adds the glyph outlines `outlines` of shown text, in user space transformed by `ctm`.
+func (cr *contentConverter) addTextPath(outlines *graphics.Path, ctm contentstream.Matrix) {
+	mode := cr.state.RenderMode
+	switch mode {
+	case graphics.TextFill, graphics.TextFillClip:
+		cr.drawPath(outlines, ctm, "nonzero", false)
+	case graphics.TextStroke, graphics.TextStrokeClip:
+		cr.drawPath(outlines, ctm, "", true)
+	case graphics.TextFillStroke, graphics.TextFillStrokeClip:
+		cr.drawPath(outlines, ctm, "nonzero", true)
+	}
+	if mode >= graphics.TextFillClip {
+		cr.addTextClip(fmt.Sprintf(`<path%s d="%s"/>`, transformAttr(ctm), pathData(outlines)))
+	}
+}
+
+// addTextClip adds `element` to the clipping path of the text object.
+func (cr *contentConverter) addTextClip(element string) {
+	if cr.textClip == nil {
+		cr.textClip = &bytes.Buffer{}
+	}
+	cr.textClip.WriteString(element)
+	cr.textClip.WriteByte('\n')
+}
+
+// drawType3Glyph converts the glyph description of character code `code` of a Type 3 font, with matrix `trm`
+// from text space to user space.
+func (cr *contentConverter) drawType3Glyph(font *fontfile.Type3, code int, trm, ctm contentstream.Matrix) {
+	stream, has := font.CharProcs[code]
+	if !has {
+		return
+	}
+	if cr.depth >= maxDepth {
+		common.Log.Debug("Type 3 glyphs nested too deeply, skipping")
+		return
+	}
+	content, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("Invalid Type 3 glyph stream, skipping: %v", err)
+		return
+	}
+	m := font.Matrix
+	base := contentstream.NewMatrix(m[0], m[1], m[2], m[3], m[4], m[5]).Mult(trm).Mult(ctm)
+	resources := font.Resources
+	if resources == nil {
+		resources = cr.Resources
+	}
+
+	gs := cr.GS
+	gs.CTM = contentstream.IdentityMatrix()
+	child := cr.child(cr.dst, resources, base, cr.state)
+	if err := child.Process(string(content), gs); err != nil {
+		common.Log.Debug("Error converting Type 3 glyph: %v", err)
+	}
+}
+
+// fontAttrs returns the attributes selecting the font of text elements in font `font`: its embedded program if
+// it is a web font, otherwise a font family resembling it.
+func (d *document) fontAttrs(font *fontfile.Font) string {
+	family, weight, style := standardFamily(font.SubstituteName())
+	if data, mediaType := font.WebFont(); data != nil {
+		name, has := d.families[font]
+		if !has {
+			name = d.newID("f")
+			d.families[font] = name
+			fmt.Fprintf(&d.fontFaces, "@font-face { font-family: \"%s\"; src: url(data:%s;base64,%s); }\n", name,
+				mediaType, base64.StdEncoding.EncodeToString(data))
+		}
+		return fmt.Sprintf(` font-family="%s, %s"`, name, family)
+	}
+	attrs := fmt.Sprintf(` font-family="%s"`, family)
+	if weight != "" {
+		attrs += fmt.Sprintf(` font-weight="%s"`, weight)
+	}
+	if style != "" {
+		attrs += fmt.Sprintf(` font-style="%s"`, style)
+	}
+	return attrs
+}
+
+// standardFamily returns the CSS font family, weight and style of standard 14 font `name`.
+func standardFamily(name string) (family, weight, style string) {
+	switch {
+	case strings.HasPrefix(name, "Courier"):
+		family = "'Courier New', Courier, monospace"
+	case strings.HasPrefix(name, "Times"):
+		family = "'Times New Roman', Times, serif"
+	case name == "Symbol":
+		family = "Symbol, serif"
+	case name == "ZapfDingbats":
+		family = "'Zapf Dingbats', sans-serif"
+	default:
+		family = "Helvetica, Arial, sans-serif"
+	}
+	if strings.Contains(name, "Bold") {
+		weight = "bold"
+	}
+	if strings.Contains(name, "Italic") || strings.Contains(name, "Oblique") {
+		style = "italic"
+	}
+	return family, weight, style
+}
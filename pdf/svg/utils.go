@@ -0,0 +1,45 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package svg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unidoc/pdf/contentstream"
+)
+
+// formatNumber formats `v` for attribute values, with 6 significant digits.
+func formatNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'g', 6, 64)
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// formatNumbers formats the numbers `vals` separated by `sep`.
+func formatNumbers(vals []float64, sep string) string {
+	s := make([]string, len(vals))
+	for i, v := range vals {
+		s[i] = formatNumber(v)
+	}
+	return strings.Join(s, sep)
+}
+
+// transformAttr returns the transform attribute of matrix `m`, "" for the identity matrix.
+func transformAttr(m contentstream.Matrix) string {
+	if m == contentstream.IdentityMatrix() {
+		return ""
+	}
+	return fmt.Sprintf(` transform="matrix(%s)"`, formatMatrix(m))
+}
+
+// formatMatrix formats the components a b c d e f of matrix `m`.
+func formatMatrix(m contentstream.Matrix) string {
+	return formatNumbers(m[:], " ")
+}
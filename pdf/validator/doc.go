@@ -0,0 +1,9 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+// Package validator is used for preflighting PDF documents: it reports the violations of the rules of
+// archival (PDF/A-1b and PDF/A-2b) and accessibility (PDF/UA) conformance levels found in a document.
+// The checks cover the most common problems rather than the complete standards.
+package validator
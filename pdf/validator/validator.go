@@ -0,0 +1,716 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/unidoc/unidoc/common"
+	"github.com/unidoc/unidoc/pdf/contentstream"
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+)
+
+// Profile is a conformance level that documents are validated against.
+type Profile string
+
+const (
+	ProfilePdfA1B Profile = "PDF/A-1b"
+	ProfilePdfA2B Profile = "PDF/A-2b"
+	ProfilePdfUA  Profile = "PDF/UA-1"
+)
+
+// Violation is a violation of a rule of a conformance level.
+type Violation struct {
+	// RuleID identifies the rule by conformance level and clause of the standard, e.g. "PDF/A-1b 6.3.4".
+	RuleID      string
+	Description string
+	// ObjectNumber is the number of the indirect object violating the rule, 0 if the rule applies to the
+	// document.
+	ObjectNumber int64
+	// PageNumber is the number of the page (starting from 1) using the object, 0 if not used by a page.
+	PageNumber int
+}
+
+func (v Violation) String() string {
+	s := fmt.Sprintf("%s: %s", v.RuleID, v.Description)
+	if v.ObjectNumber > 0 {
+		s += fmt.Sprintf(" (object %d)", v.ObjectNumber)
+	}
+	if v.PageNumber > 0 {
+		s += fmt.Sprintf(" (page %d)", v.PageNumber)
+	}
+	return s
+}
+
+// rule is a rule checked by the validator, with the corresponding clauses of the standards in clauses.
+type rule int
+
+const (
+	ruleEncryption rule = iota
+	ruleFileID
+	ruleLZW
+	ruleEmbeddedFile
+	ruleDeviceColor
+	ruleFontEmbedded
+	ruleTransparency
+	ruleTransparencyGroup
+	ruleAnnotation
+	ruleAction
+	ruleMetadata
+	ruleIdentification
+	ruleTagged
+	ruleTitle
+	ruleLang
+	ruleAltText
+)
+
+// clauses are the clauses of the rules checked for each profile.  The rules without a clause do not apply.
+var clauses = map[Profile]map[rule]string{
+	ProfilePdfA1B: {
+		ruleEncryption:     "6.1.3",
+		ruleFileID:         "6.1.3",
+		ruleLZW:            "6.1.10",
+		ruleEmbeddedFile:   "6.1.11",
+		ruleDeviceColor:    "6.2.3.3",
+		ruleFontEmbedded:   "6.3.4",
+		ruleTransparency:   "6.4",
+		ruleAnnotation:     "6.5.3",
+		ruleAction:         "6.6.1",
+		ruleMetadata:       "6.7.2",
+		ruleIdentification: "6.7.11",
+	},
+	ProfilePdfA2B: {
+		ruleEncryption:        "6.1.3",
+		ruleFileID:            "6.1.3",
+		ruleLZW:               "6.1.7.2",
+		ruleEmbeddedFile:      "6.8",
+		ruleDeviceColor:       "6.2.4.3",
+		ruleFontEmbedded:      "6.2.11.4.1",
+		ruleTransparencyGroup: "6.2.10",
+		ruleAnnotation:        "6.3.2",
+		ruleAction:            "6.5.1",
+		ruleMetadata:          "6.6.2.1",
+		ruleIdentification:    "6.6.4",
+	},
+	ProfilePdfUA: {
+		ruleFontEmbedded:   "7.21.4.1",
+		ruleMetadata:       "7.1",
+		ruleIdentification: "5",
+		ruleTagged:         "7.1",
+		ruleTitle:          "7.1",
+		ruleLang:           "7.2",
+		ruleAltText:        "7.3",
+	},
+}
+
+// Validator validates the document of a PdfReader against conformance levels.
+type Validator struct {
+	reader  *model.PdfReader
+	catalog *core.PdfObjectDictionary
+	// pageNumbers are the numbers of the pages using the indirect objects.
+	pageNumbers map[core.PdfObject]int
+
+	profile    Profile
+	violations []Violation
+	// outputComponents is the number of components of the PDF/A output intent profile, 0 if none.
+	outputComponents int
+}
+
+// New returns a Validator of the document read by `reader`.  Encrypted documents are decrypted with an empty
+// password if possible.
+func New(reader *model.PdfReader) (*Validator, error) {
+	v := &Validator{reader: reader}
+	if encrypted, err := reader.IsEncrypted(); err == nil && encrypted {
+		if ok, err := reader.Decrypt([]byte("")); err != nil || !ok {
+			return nil, errors.New("Unable to decrypt document")
+		}
+	}
+
+	trailer, err := reader.GetTrailer()
+	if err != nil {
+		return nil, err
+	}
+	catalog, ok := v.trace(trailer.Get("Root")).(*core.PdfObjectDictionary)
+	if !ok {
+		return nil, errors.New("Missing catalog")
+	}
+	v.catalog = catalog
+
+	v.pageNumbers = map[core.PdfObject]int{}
+	for i, page := range reader.PageList {
+		v.addPageObjects(page.GetContainingPdfObject(), i+1, map[core.PdfObject]bool{})
+	}
+	return v, nil
+}
+
+// Validate returns the violations of the rules of conformance level `profile` found in the document.
+func (v *Validator) Validate(profile Profile) ([]Violation, error) {
+	if _, has := clauses[profile]; !has {
+		return nil, fmt.Errorf("Unsupported profile %s", profile)
+	}
+	v.profile = profile
+	v.violations = nil
+	v.outputComponents = v.getOutputComponents()
+
+	if err := v.checkDocument(); err != nil {
+		return nil, err
+	}
+
+	objNums := v.reader.GetObjectNums()
+	sort.Ints(objNums)
+	for _, num := range objNums {
+		obj, err := v.reader.GetIndirectObjectByNumber(num)
+		if err != nil {
+			common.Log.Debug("ERROR: Unable to load object %d: %v", num, err)
+			continue
+		}
+		v.checkObject(obj, obj, int64(num))
+	}
+
+	for i, page := range v.reader.PageList {
+		if err := v.checkPageContents(page, i+1); err != nil {
+			return nil, err
+		}
+	}
+
+	if clauses[profile][ruleAltText] != "" {
+		if err := v.checkStructure(); err != nil {
+			return nil, err
+		}
+	}
+	return v.violations, nil
+}
+
+// addViolation adds a violation of `r` by indirect object `obj` (nil for the document), unless the rule
+// does not apply to the profile.
+func (v *Validator) addViolation(r rule, obj core.PdfObject, format string, args ...interface{}) {
+	clause, applies := clauses[v.profile][r]
+	if !applies {
+		return
+	}
+	violation := Violation{
+		RuleID:      fmt.Sprintf("%s %s", v.profile, clause),
+		Description: fmt.Sprintf(format, args...),
+	}
+	switch t := obj.(type) {
+	case *core.PdfIndirectObject:
+		violation.ObjectNumber = t.ObjectNumber
+	case *core.PdfObjectStream:
+		violation.ObjectNumber = t.ObjectNumber
+	}
+	if obj != nil {
+		violation.PageNumber = v.pageNumbers[obj]
+	}
+	for _, existing := range v.violations {
+		if existing == violation {
+			return
+		}
+	}
+	v.violations = append(v.violations, violation)
+}
+
+// trace returns the direct object of `obj`, resolving references.
+func (v *Validator) trace(obj core.PdfObject) core.PdfObject {
+	if ref, isRef := obj.(*core.PdfObjectReference); isRef {
+		resolved, err := v.reader.GetIndirectObjectByNumber(int(ref.ObjectNumber))
+		if err != nil {
+			common.Log.Debug("ERROR: Unable to resolve reference %s: %v", ref, err)
+			return nil
+		}
+		obj = resolved
+	}
+	if stream, isStream := obj.(*core.PdfObjectStream); isStream {
+		return stream
+	}
+	return core.TraceToDirectObject(obj)
+}
+
+// addPageObjects sets the page number of `obj` and the indirect objects it refers to, except other pages,
+// to `pageNumber` if not used by an earlier page.
+func (v *Validator) addPageObjects(obj core.PdfObject, pageNumber int, visited map[core.PdfObject]bool) {
+	if ref, isRef := obj.(*core.PdfObjectReference); isRef {
+		resolved, err := v.reader.GetIndirectObjectByNumber(int(ref.ObjectNumber))
+		if err != nil {
+			return
+		}
+		obj = resolved
+	}
+	if visited[obj] {
+		return
+	}
+	visited[obj] = true
+
+	switch t := obj.(type) {
+	case *core.PdfIndirectObject:
+		if dict, ok := t.PdfObject.(*core.PdfObjectDictionary); ok && len(visited) > 1 {
+			if typ, ok := dict.Get("Type").(*core.PdfObjectName); ok && (*typ == "Page" || *typ == "Pages") {
+				return
+			}
+		}
+		if _, has := v.pageNumbers[t]; !has {
+			v.pageNumbers[t] = pageNumber
+		}
+		v.addPageObjects(t.PdfObject, pageNumber, visited)
+	case *core.PdfObjectStream:
+		if _, has := v.pageNumbers[t]; !has {
+			v.pageNumbers[t] = pageNumber
+		}
+		v.addPageObjects(t.PdfObjectDictionary, pageNumber, visited)
+	case *core.PdfObjectDictionary:
+		for _, key := range t.Keys() {
+			if key != "Parent" {
+				v.addPageObjects(t.Get(key), pageNumber, visited)
+			}
+		}
+	case *core.PdfObjectArray:
+		for _, o := range *t {
+			v.addPageObjects(o, pageNumber, visited)
+		}
+	}
+}
+
+// getOutputComponents returns the number of components of the destination profile of the PDF/A output
+// intent, 0 if none.
+func (v *Validator) getOutputComponents() int {
+	outputIntents, ok := v.trace(v.catalog.Get("OutputIntents")).(*core.PdfObjectArray)
+	if !ok {
+		return 0
+	}
+	for _, obj := range *outputIntents {
+		outputIntent, ok := v.trace(obj).(*core.PdfObjectDictionary)
+		if !ok {
+			continue
+		}
+		if s, ok := v.trace(outputIntent.Get("S")).(*core.PdfObjectName); !ok || *s != "GTS_PDFA1" {
+			continue
+		}
+		profile, ok := v.trace(outputIntent.Get("DestOutputProfile")).(*core.PdfObjectStream)
+		if !ok {
+			continue
+		}
+		if n, ok := v.trace(profile.Get("N")).(*core.PdfObjectInteger); ok {
+			return int(*n)
+		}
+	}
+	return 0
+}
+
+// checkDocument checks the trailer, metadata and catalog entries of the document.
+func (v *Validator) checkDocument() error {
+	trailer, err := v.reader.GetTrailer()
+	if err != nil {
+		return err
+	}
+	if trailer.Get("Encrypt") != nil {
+		v.addViolation(ruleEncryption, nil, "Document is encrypted")
+	}
+	if ids, ok := v.trace(trailer.Get("ID")).(*core.PdfObjectArray); !ok || len(*ids) != 2 {
+		v.addViolation(ruleFileID, nil, "Missing file identifier (ID)")
+	}
+
+	if names, ok := v.trace(v.catalog.Get("Names")).(*core.PdfObjectDictionary); ok && names.Get("JavaScript") != nil {
+		v.addViolation(ruleAction, nil, "JavaScript actions are not allowed")
+	}
+
+	metadata, ok := v.trace(v.catalog.Get("Metadata")).(*core.PdfObjectStream)
+	if !ok {
+		v.addViolation(ruleMetadata, nil, "Missing XMP metadata")
+		v.addViolation(ruleTitle, nil, "Missing document title")
+	} else {
+		data, err := core.DecodeStream(metadata)
+		if err != nil {
+			return err
+		}
+		xmp, err := model.ParseXmpMetadata(data)
+		if err != nil {
+			v.addViolation(ruleMetadata, metadata, "Invalid XMP metadata: %v", err)
+		} else {
+			v.checkIdentification(xmp, data, metadata)
+			if xmp.Title == "" {
+				v.addViolation(ruleTitle, metadata, "Missing document title (dc:title)")
+			}
+		}
+	}
+
+	if clauses[v.profile][ruleTagged] != "" {
+		if v.catalog.Get("StructTreeRoot") == nil {
+			v.addViolation(ruleTagged, nil, "Missing structure tree")
+		}
+		markInfo, _ := v.trace(v.catalog.Get("MarkInfo")).(*core.PdfObjectDictionary)
+		if markInfo == nil {
+			v.addViolation(ruleTagged, nil, "Document not marked as tagged (MarkInfo)")
+		} else if marked, ok := v.trace(markInfo.Get("Marked")).(*core.PdfObjectBool); !ok || !bool(*marked) {
+			v.addViolation(ruleTagged, nil, "Document not marked as tagged (MarkInfo)")
+		}
+		prefs, _ := v.trace(v.catalog.Get("ViewerPreferences")).(*core.PdfObjectDictionary)
+		if prefs == nil {
+			v.addViolation(ruleTitle, nil, "Document title not displayed (DisplayDocTitle)")
+		} else if display, ok := v.trace(prefs.Get("DisplayDocTitle")).(*core.PdfObjectBool); !ok || !bool(*display) {
+			v.addViolation(ruleTitle, nil, "Document title not displayed (DisplayDocTitle)")
+		}
+		if lang, ok := v.trace(v.catalog.Get("Lang")).(*core.PdfObjectString); !ok || len(*lang) == 0 {
+			v.addViolation(ruleLang, nil, "Missing natural language (Lang)")
+		}
+	}
+	return nil
+}
+
+// checkIdentification checks that XMP metadata `xmp`, parsed from `data`, identifies the profile.
+func (v *Validator) checkIdentification(xmp *model.XmpMetadata, data []byte, metadata core.PdfObject) {
+	switch v.profile {
+	case ProfilePdfA1B, ProfilePdfA2B:
+		part := 1
+		if v.profile == ProfilePdfA2B {
+			part = 2
+		}
+		if xmp.PdfAPart != part || (xmp.PdfAConformance != "B" && xmp.PdfAConformance != "A") {
+			v.addViolation(ruleIdentification, metadata, "XMP metadata does not identify PDF/A-%db (pdfaid)",
+				part)
+		}
+	case ProfilePdfUA:
+		if !bytes.Contains(data, []byte("pdfuaid:part")) {
+			v.addViolation(ruleIdentification, metadata, "XMP metadata does not identify PDF/UA (pdfuaid)")
+		}
+	}
+}
+
+// checkObject checks `obj` and the direct objects it contains, in indirect object `container`.
+func (v *Validator) checkObject(obj core.PdfObject, container core.PdfObject, num int64) {
+	switch t := obj.(type) {
+	case *core.PdfIndirectObject:
+		v.checkObject(t.PdfObject, container, num)
+	case *core.PdfObjectStream:
+		v.checkStream(t)
+		// The direct objects of the stream dictionary, such as the fonts of form resources, are checked too.
+		v.checkObject(t.PdfObjectDictionary, container, num)
+	case *core.PdfObjectDictionary:
+		v.checkDict(t, container)
+		for _, key := range t.Keys() {
+			switch o := t.Get(key).(type) {
+			case *core.PdfObjectDictionary, *core.PdfObjectArray:
+				v.checkObject(o, container, num)
+			}
+		}
+	case *core.PdfObjectArray:
+		for _, o := range *t {
+			switch o.(type) {
+			case *core.PdfObjectDictionary, *core.PdfObjectArray:
+				v.checkObject(o, container, num)
+			}
+		}
+	}
+}
+
+// checkStream checks the filters, embedded files and image color spaces of `stream`.
+func (v *Validator) checkStream(stream *core.PdfObjectStream) {
+	var filters []core.PdfObject
+	switch f := v.trace(stream.Get("Filter")).(type) {
+	case *core.PdfObjectName:
+		filters = append(filters, f)
+	case *core.PdfObjectArray:
+		filters = *f
+	}
+	for _, filter := range filters {
+		if name, ok := v.trace(filter).(*core.PdfObjectName); ok && *name == "LZWDecode" {
+			v.addViolation(ruleLZW, stream, "LZW compression is not allowed")
+		}
+	}
+
+	typ, _ := v.trace(stream.Get("Type")).(*core.PdfObjectName)
+	subtype, _ := v.trace(stream.Get("Subtype")).(*core.PdfObjectName)
+	if typ != nil && *typ == "EmbeddedFile" {
+		if v.profile == ProfilePdfA1B {
+			v.addViolation(ruleEmbeddedFile, stream, "Embedded files are not allowed")
+		} else if subtype == nil || *subtype != "application/pdf" {
+			v.addViolation(ruleEmbeddedFile, stream, "Embedded file is not a PDF/A document")
+		}
+	}
+	if subtype != nil && *subtype == "Image" {
+		cs := v.trace(stream.Get("ColorSpace"))
+		if arr, ok := cs.(*core.PdfObjectArray); ok && len(*arr) > 1 {
+			if name, ok := v.trace((*arr)[0]).(*core.PdfObjectName); ok && *name == "Indexed" {
+				cs = v.trace((*arr)[1])
+			}
+		}
+		if name, ok := cs.(*core.PdfObjectName); ok {
+			v.checkDeviceColor(string(*name), stream)
+		}
+	}
+}
+
+// checkDeviceColor checks that device color space `cs` used by `obj` matches the output intent.
+func (v *Validator) checkDeviceColor(cs string, obj core.PdfObject) {
+	var allowed bool
+	switch cs {
+	case "DeviceGray":
+		allowed = v.outputComponents > 0
+	case "DeviceRGB":
+		allowed = v.outputComponents == 3
+	case "DeviceCMYK":
+		allowed = v.outputComponents == 4
+	default:
+		return
+	}
+	if !allowed {
+		v.addViolation(ruleDeviceColor, obj, "%s used without a matching output intent", cs)
+	}
+}
+
+// checkDict checks the fonts, actions, annotations and transparency of `dict`, in indirect object
+// `container`.
+func (v *Validator) checkDict(dict *core.PdfObjectDictionary, container core.PdfObject) {
+	typ, _ := v.trace(dict.Get("Type")).(*core.PdfObjectName)
+	subtype, _ := v.trace(dict.Get("Subtype")).(*core.PdfObjectName)
+
+	if typ != nil && *typ == "Font" && subtype != nil && *subtype != "Type0" && *subtype != "Type3" {
+		embedded := false
+		if descriptor, ok := v.trace(dict.Get("FontDescriptor")).(*core.PdfObjectDictionary); ok {
+			embedded = descriptor.Get("FontFile") != nil || descriptor.Get("FontFile2") != nil ||
+				descriptor.Get("FontFile3") != nil
+		}
+		if !embedded {
+			name := "(unnamed)"
+			if baseFont, ok := v.trace(dict.Get("BaseFont")).(*core.PdfObjectName); ok {
+				name = string(*baseFont)
+			}
+			v.addViolation(ruleFontEmbedded, container, "Font %s is not embedded", name)
+		}
+	}
+
+	if s, ok := v.trace(dict.Get("S")).(*core.PdfObjectName); ok && (typ == nil || *typ == "Action") {
+		if part, forbidden := model.PdfAForbiddenActions[string(*s)]; forbidden && (part == 1 || v.profile != ProfilePdfA1B) {
+			v.addViolation(ruleAction, container, "%s actions are not allowed", *s)
+		}
+	}
+
+	if typ != nil && *typ == "Annot" && (subtype == nil || *subtype != "Popup") {
+		flags := int64(0)
+		if f, ok := v.trace(dict.Get("F")).(*core.PdfObjectInteger); ok {
+			flags = int64(*f)
+		}
+		if flags&4 == 0 {
+			v.addViolation(ruleAnnotation, container, "Annotation not printable")
+		}
+		if flags&(1|2|32) != 0 {
+			v.addViolation(ruleAnnotation, container, "Annotation hidden")
+		}
+	}
+
+	if smask := v.trace(dict.Get("SMask")); smask != nil {
+		if name, ok := smask.(*core.PdfObjectName); !ok || *name != "None" {
+			v.addViolation(ruleTransparency, container, "Soft masks are not allowed")
+		}
+	}
+	for _, key := range []core.PdfObjectName{"CA", "ca"} {
+		var alpha float64
+		switch t := v.trace(dict.Get(key)).(type) {
+		case *core.PdfObjectFloat:
+			alpha = float64(*t)
+		case *core.PdfObjectInteger:
+			alpha = float64(*t)
+		default:
+			continue
+		}
+		if alpha < 1 {
+			v.addViolation(ruleTransparency, container, "Constant alpha %s %g is not allowed", key, alpha)
+		}
+	}
+	if bm, ok := v.trace(dict.Get("BM")).(*core.PdfObjectName); ok && *bm != "Normal" && *bm != "Compatible" {
+		v.addViolation(ruleTransparency, container, "Blend mode %s is not allowed", *bm)
+	}
+	if group, ok := v.trace(dict.Get("Group")).(*core.PdfObjectDictionary); ok {
+		if s, ok := v.trace(group.Get("S")).(*core.PdfObjectName); ok && *s == "Transparency" {
+			v.addViolation(ruleTransparency, container, "Transparency groups are not allowed")
+			if group.Get("CS") == nil && v.outputComponents == 0 && typ != nil && *typ == "Page" {
+				v.addViolation(ruleTransparencyGroup, container,
+					"Transparency group without color space (CS) or output intent")
+			}
+		}
+	}
+}
+
+// checkPageContents checks the device colors used by the content streams of `page`, the form XObjects and
+// Type 3 glyphs they paint and the appearance streams of its annotations.
+func (v *Validator) checkPageContents(page *model.PdfPage, pageNumber int) error {
+	if clauses[v.profile][ruleDeviceColor] == "" {
+		return nil
+	}
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return err
+	}
+	ops, err := contentstream.NewContentStreamParser(contents).Parse()
+	if err != nil {
+		return err
+	}
+
+	// The resources of the page, inherited from the page tree if not set.
+	var resources *core.PdfObjectDictionary
+	for node, _ := v.trace(page.GetContainingPdfObject()).(*core.PdfObjectDictionary); node != nil && resources == nil; {
+		resources, _ = v.trace(node.Get("Resources")).(*core.PdfObjectDictionary)
+		node, _ = v.trace(node.Get("Parent")).(*core.PdfObjectDictionary)
+	}
+
+	visited := map[core.PdfObject]bool{}
+	v.checkContentColors(*ops, resources, page.GetContainingPdfObject(), visited)
+
+	for _, annot := range page.Annotations {
+		ap, ok := v.trace(annot.AP).(*core.PdfObjectDictionary)
+		if !ok {
+			continue
+		}
+		for _, key := range ap.Keys() {
+			// An appearance stream or a dictionary of appearance streams by state.
+			switch t := v.trace(ap.Get(key)).(type) {
+			case *core.PdfObjectStream:
+				v.checkFormColors(t, nil, visited)
+			case *core.PdfObjectDictionary:
+				for _, state := range t.Keys() {
+					if stream, ok := v.trace(t.Get(state)).(*core.PdfObjectStream); ok {
+						v.checkFormColors(stream, nil, visited)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkFormColors checks the device colors used by form XObject or glyph procedure `stream`, in `container`.
+// Forms without resources use `resources` of the content painting them.  Streams in `visited` are skipped.
+func (v *Validator) checkFormColors(stream *core.PdfObjectStream, resources *core.PdfObjectDictionary,
+	visited map[core.PdfObject]bool) {
+	if visited[stream] {
+		return
+	}
+	visited[stream] = true
+
+	data, err := core.DecodeStream(stream)
+	if err != nil {
+		common.Log.Debug("ERROR: Unable to decode content stream: %v", err)
+		return
+	}
+	ops, err := contentstream.NewContentStreamParser(string(data)).Parse()
+	if err != nil {
+		common.Log.Debug("ERROR: Unable to parse content stream: %v", err)
+		return
+	}
+	if own, ok := v.trace(stream.Get("Resources")).(*core.PdfObjectDictionary); ok {
+		resources = own
+	}
+	v.checkContentColors(*ops, resources, stream, visited)
+}
+
+// checkContentColors checks the device colors used by content stream operations `ops` with `resources`, in
+// `container`, following the form XObjects painted (Do) and the glyph procedures of Type 3 fonts set (Tf).
+func (v *Validator) checkContentColors(ops contentstream.ContentStreamOperations, resources *core.PdfObjectDictionary,
+	container core.PdfObject, visited map[core.PdfObject]bool) {
+	// lookup returns the resource of `category` named by the first operand of `op`, nil if none.
+	lookup := func(category core.PdfObjectName, op *contentstream.ContentStreamOperation) core.PdfObject {
+		if resources == nil || len(op.Params) == 0 {
+			return nil
+		}
+		name, ok := op.Params[0].(*core.PdfObjectName)
+		if !ok {
+			return nil
+		}
+		dict, ok := v.trace(resources.Get(category)).(*core.PdfObjectDictionary)
+		if !ok {
+			return nil
+		}
+		return v.trace(dict.Get(*name))
+	}
+
+	for _, op := range ops {
+		var cs string
+		switch op.Operand {
+		case "g", "G":
+			cs = "DeviceGray"
+		case "rg", "RG":
+			cs = "DeviceRGB"
+		case "k", "K":
+			cs = "DeviceCMYK"
+		case "cs", "CS":
+			if len(op.Params) == 1 {
+				if name, ok := op.Params[0].(*core.PdfObjectName); ok {
+					cs = string(*name)
+				}
+			}
+			// Named color spaces of the resources, indexed in their base color space.
+			obj := lookup("ColorSpace", op)
+			if arr, ok := obj.(*core.PdfObjectArray); ok && len(*arr) > 1 {
+				if name, ok := v.trace((*arr)[0]).(*core.PdfObjectName); ok && *name == "Indexed" {
+					obj = v.trace((*arr)[1])
+				}
+			}
+			if name, ok := obj.(*core.PdfObjectName); ok {
+				cs = string(*name)
+			}
+		case "Do":
+			if stream, ok := lookup("XObject", op).(*core.PdfObjectStream); ok {
+				if subtype, ok := v.trace(stream.Get("Subtype")).(*core.PdfObjectName); ok && *subtype == "Form" {
+					v.checkFormColors(stream, resources, visited)
+				}
+			}
+		case "Tf":
+			font, ok := lookup("Font", op).(*core.PdfObjectDictionary)
+			if !ok {
+				continue
+			}
+			if subtype, ok := v.trace(font.Get("Subtype")).(*core.PdfObjectName); !ok || *subtype != "Type3" {
+				continue
+			}
+			glyphResources := resources
+			if own, ok := v.trace(font.Get("Resources")).(*core.PdfObjectDictionary); ok {
+				glyphResources = own
+			}
+			if procs, ok := v.trace(font.Get("CharProcs")).(*core.PdfObjectDictionary); ok {
+				for _, glyph := range procs.Keys() {
+					if stream, ok := v.trace(procs.Get(glyph)).(*core.PdfObjectStream); ok {
+						v.checkFormColors(stream, glyphResources, visited)
+					}
+				}
+			}
+		}
+		if cs != "" {
+			v.checkDeviceColor(cs, container)
+		}
+	}
+}
+
+// checkStructure checks that the figures of the structure tree have alternate descriptions.
+func (v *Validator) checkStructure() error {
+	root, err := v.reader.GetStructTreeRoot()
+	if err != nil || root == nil {
+		return err
+	}
+	pageNumbers := map[*model.PdfPage]int{}
+	for i, page := range v.reader.PageList {
+		pageNumbers[page] = i + 1
+	}
+
+	root.Walk(func(elem *model.PdfStructElement, depth int) bool {
+		if root.GetRole(elem.S) != model.StructTypeFigure || elem.Alt != "" || elem.ActualText != "" {
+			return true
+		}
+		clause := clauses[v.profile][ruleAltText]
+		violation := Violation{
+			RuleID:      fmt.Sprintf("%s %s", v.profile, clause),
+			Description: "Figure without alternate description (Alt)",
+			PageNumber:  pageNumbers[elem.Page],
+		}
+		if container, ok := elem.GetContainingPdfObject().(*core.PdfIndirectObject); ok {
+			violation.ObjectNumber = container.ObjectNumber
+		}
+		v.violations = append(v.violations, violation)
+		return true
+	})
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package validator
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unidoc/pdf/core"
+	"github.com/unidoc/unidoc/pdf/model"
+	"github.com/unidoc/unidoc/pdf/model/fonts"
+)
+
+// writeTestFile writes a single page document to `path` with a standard font, RGB colors, transparency and
+// a figure without alternate description.
+func writeTestFile(t *testing.T, path string) {
+	writer := model.NewPdfWriter()
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = model.NewPdfPageResources()
+	page.Resources.SetFontByName("F1", fonts.NewFontHelvetica().ToPdfObject())
+	gs := core.MakeDict()
+	gs.Set("ca", core.MakeFloat(0.5))
+	page.Resources.AddExtGState("GS1", gs)
+	page.AddContentStreamByString("/GS1 gs 1 0 0 rg /Figure <</MCID 0>> BDC 0 0 10 10 re f EMC " +
+		"BT /F1 12 Tf (Hello) Tj ET")
+	group := core.MakeDict()
+	group.Set("S", core.MakeName("Transparency"))
+	page.Group = group
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	root := model.NewPdfStructTreeRoot()
+	figure := model.NewPdfStructElement(model.StructTypeFigure)
+	figure.AddMarkedContent(page, 0)
+	root.K = append(root.K, figure)
+	writer.SetStructTreeRoot(root)
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
+func validateFile(t *testing.T, path string, profile Profile) []Violation {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	defer f.Close()
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	v, err := New(reader)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	violations, err := v.Validate(profile)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	return violations
+}
+
+func TestValidate(t *testing.T) {
+	writeTestFile(t, "/tmp/validator.pdf")
+
+	testcases := []struct {
+		profile  Profile
+		expected []Violation
+	}{
+		{ProfilePdfA1B, []Violation{
+			{RuleID: "PDF/A-1b 6.1.3", Description: "Missing file identifier (ID)"},
+			{RuleID: "PDF/A-1b 6.7.2", Description: "Missing XMP metadata"},
+			{RuleID: "PDF/A-1b 6.3.4", Description: "Font Helvetica is not embedded", PageNumber: 1},
+			{RuleID: "PDF/A-1b 6.4", Description: "Constant alpha ca 0.5 is not allowed", PageNumber: 1},
+			{RuleID: "PDF/A-1b 6.4", Description: "Transparency groups are not allowed", PageNumber: 1},
+			{RuleID: "PDF/A-1b 6.2.3.3", Description: "DeviceRGB used without a matching output intent", PageNumber: 1},
+		}},
+		{ProfilePdfA2B, []Violation{
+			{RuleID: "PDF/A-2b 6.1.3", Description: "Missing file identifier (ID)"},
+			{RuleID: "PDF/A-2b 6.6.2.1", Description: "Missing XMP metadata"},
+			{RuleID: "PDF/A-2b 6.2.11.4.1", Description: "Font Helvetica is not embedded", PageNumber: 1},
+			{RuleID: "PDF/A-2b 6.2.10", Description: "Transparency group without color space (CS) or output intent", PageNumber: 1},
+			{RuleID: "PDF/A-2b 6.2.4.3", Description: "DeviceRGB used without a matching output intent", PageNumber: 1},
+		}},
+		{ProfilePdfUA, []Violation{
+			{RuleID: "PDF/UA-1 7.1", Description: "Missing XMP metadata"},
+			{RuleID: "PDF/UA-1 7.1", Description: "Missing document title"},
+			{RuleID: "PDF/UA-1 7.1", Description: "Document title not displayed (DisplayDocTitle)"},
+			{RuleID: "PDF/UA-1 7.2", Description: "Missing natural language (Lang)"},
+			{RuleID: "PDF/UA-1 7.21.4.1", Description: "Font Helvetica is not embedded", PageNumber: 1},
+			{RuleID: "PDF/UA-1 7.3", Description: "Figure without alternate description (Alt)", PageNumber: 1},
+		}},
+	}
+
+	for _, tcase := range testcases {
+		violations := validateFile(t, "/tmp/validator.pdf", tcase.profile)
+		for _, expected := range tcase.expected {
+			found := false
+			for _, violation := range violations {
+				if violation.RuleID == expected.RuleID && violation.Description == expected.Description &&
+					violation.PageNumber == expected.PageNumber {
+					found = true
+					if expected.PageNumber > 0 && violation.ObjectNumber == 0 {
+						t.Errorf("%s: Missing object number", violation)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("%s: Missing violation %s in %v", tcase.profile, expected, violations)
+			}
+		}
+		for _, violation := range violations {
+			if !strings.HasPrefix(violation.RuleID, string(tcase.profile)+" ") {
+				t.Errorf("%s: Invalid rule %s", tcase.profile, violation.RuleID)
+			}
+		}
+	}
+}
+
+func TestValidateConformant(t *testing.T) {
+	writer := model.NewPdfWriter()
+	writer.SetPdfAConformance(model.PdfAConformanceA2B)
+	f, err := os.Create("/tmp/validator_pdfa.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	if violations := validateFile(t, "/tmp/validator_pdfa.pdf", ProfilePdfA2B); len(violations) != 0 {
+		t.Errorf("Unexpected violations: %v", violations)
+	}
+	// The document does not identify as PDF/A-1.
+	violations := validateFile(t, "/tmp/validator_pdfa.pdf", ProfilePdfA1B)
+	if len(violations) != 1 || violations[0].RuleID != "PDF/A-1b 6.7.11" {
+		t.Errorf("Invalid violations: %v", violations)
+	}
+}
+
+func TestValidateFormContents(t *testing.T) {
+	writer := model.NewPdfWriter()
+	page := model.NewPdfPage()
+	page.MediaBox = &model.PdfRectangle{Llx: 0, Lly: 0, Urx: 100, Ury: 100}
+	page.Resources = model.NewPdfPageResources()
+
+	// A form painted by the page, with its own resources and a form painted again by itself.
+	form, err := core.MakeStream([]byte("0 0 0 1 k 0 0 10 10 re f BT /F1 12 Tf (Hello) Tj ET /Fm1 Do"), nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	form.Set("Type", core.MakeName("XObject"))
+	form.Set("Subtype", core.MakeName("Form"))
+	form.Set("BBox", core.MakeArray(core.MakeInteger(0), core.MakeInteger(0), core.MakeInteger(100),
+		core.MakeInteger(100)))
+	fontResources := core.MakeDict()
+	fontResources.Set("F1", fonts.NewFontCourier().ToPdfObject())
+	formXObjects := core.MakeDict()
+	formXObjects.Set("Fm1", form)
+	formResources := core.MakeDict()
+	formResources.Set("Font", fontResources)
+	formResources.Set("XObject", formXObjects)
+	form.Set("Resources", formResources)
+	page.Resources.SetXObjectByName("Fm1", form)
+	page.AddContentStreamByString("/Fm1 Do")
+
+	// An annotation appearance painted in gray.
+	appearance, err := core.MakeStream([]byte("0.5 g 0 0 10 10 re f"), nil)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	appearance.Set("Subtype", core.MakeName("Form"))
+	appearance.Set("BBox", core.MakeArray(core.MakeInteger(0), core.MakeInteger(0), core.MakeInteger(10),
+		core.MakeInteger(10)))
+	ap := core.MakeDict()
+	ap.Set("N", appearance)
+	annot := model.NewPdfAnnotationSquare()
+	annot.Rect = core.MakeArray(core.MakeInteger(0), core.MakeInteger(0), core.MakeInteger(10),
+		core.MakeInteger(10))
+	annot.AP = ap
+	page.Annotations = append(page.Annotations, annot.PdfAnnotation)
+
+	if err := writer.AddPage(page); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f, err := os.Create("/tmp/validator_forms.pdf")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := writer.Write(f); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	f.Close()
+
+	violations := validateFile(t, "/tmp/validator_forms.pdf", ProfilePdfA2B)
+	for _, expected := range []string{
+		"DeviceCMYK used without a matching output intent",
+		"DeviceGray used without a matching output intent",
+		"Font Courier is not embedded",
+	} {
+		found := false
+		for _, violation := range violations {
+			if violation.Description == expected && violation.PageNumber == 1 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Missing violation %q in %v", expected, violations)
+		}
+	}
+}